@@ -92,6 +92,35 @@ export function useArticleDetail() {
     }
   );
 
+  // Report how long each article stays open, for the importance ranking
+  let openedArticleId: number | null = null;
+  let openedAt = 0;
+
+  function sendReadingFeedback() {
+    if (openedArticleId === null) return;
+    const id = openedArticleId;
+    const seconds = (Date.now() - openedAt) / 1000;
+    openedArticleId = null;
+    fetch('/api/articles/feedback', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ id, action: 'read', seconds }),
+      keepalive: true,
+    }).catch((e) => console.error('Error sending reading feedback:', e));
+  }
+
+  watch(
+    () => store.currentArticleId,
+    (newId) => {
+      sendReadingFeedback();
+      if (newId) {
+        openedArticleId = newId;
+        openedAt = Date.now();
+      }
+    },
+    { immediate: true }
+  );
+
   // Listen for default view mode changes from settings
   window.addEventListener('default-view-mode-changed', (e: Event) => {
     const event = e as ViewModeChangeEvent;
@@ -545,6 +574,7 @@ export function useArticleDetail() {
     window.addEventListener('explicit-render-action', handleExplicitRenderAction);
     window.addEventListener('toggle-content-view', handleToggleContentView);
     window.addEventListener('reset-user-view-preference', handleResetUserPreference);
+    window.addEventListener('pagehide', sendReadingFeedback);
 
     // Load default view mode from settings
     try {
@@ -561,6 +591,8 @@ export function useArticleDetail() {
     window.removeEventListener('explicit-render-action', handleExplicitRenderAction);
     window.removeEventListener('toggle-content-view', handleToggleContentView);
     window.removeEventListener('reset-user-view-preference', handleResetUserPreference);
+    window.removeEventListener('pagehide', sendReadingFeedback);
+    sendReadingFeedback();
   });
 
   return {
@@ -14,13 +14,25 @@ import { settingsDefaults } from '@/config/defaults';
 export function generateInitialSettings(): SettingsData {
   return {
     ai_api_key: settingsDefaults.ai_api_key,
+    ai_backend: settingsDefaults.ai_backend,
     ai_chat_enabled: settingsDefaults.ai_chat_enabled,
+    ai_chat_profile: settingsDefaults.ai_chat_profile,
     ai_custom_headers: settingsDefaults.ai_custom_headers,
+    ai_embedding_api_key: settingsDefaults.ai_embedding_api_key,
+    ai_embedding_enabled: settingsDefaults.ai_embedding_enabled,
+    ai_embedding_endpoint: settingsDefaults.ai_embedding_endpoint,
+    ai_embedding_model: settingsDefaults.ai_embedding_model,
     ai_endpoint: settingsDefaults.ai_endpoint,
     ai_model: settingsDefaults.ai_model,
+    ai_price_table: settingsDefaults.ai_price_table,
+    ai_summary_profile: settingsDefaults.ai_summary_profile,
     ai_summary_prompt: settingsDefaults.ai_summary_prompt,
+    ai_tagging_profile: settingsDefaults.ai_tagging_profile,
+    ai_translation_profile: settingsDefaults.ai_translation_profile,
     ai_translation_prompt: settingsDefaults.ai_translation_prompt,
+    ai_usage_daily_limit: settingsDefaults.ai_usage_daily_limit,
     ai_usage_limit: settingsDefaults.ai_usage_limit,
+    ai_usage_monthly_limit: settingsDefaults.ai_usage_monthly_limit,
     ai_usage_tokens: settingsDefaults.ai_usage_tokens,
     auto_cleanup_enabled: settingsDefaults.auto_cleanup_enabled,
     auto_show_all_content: settingsDefaults.auto_show_all_content,
@@ -28,9 +40,19 @@ export function generateInitialSettings(): SettingsData {
     baidu_secret_key: settingsDefaults.baidu_secret_key,
     close_to_tray: settingsDefaults.close_to_tray,
     custom_css_file: settingsDefaults.custom_css_file,
+    db_backup_dir: settingsDefaults.db_backup_dir,
+    db_backup_enabled: settingsDefaults.db_backup_enabled,
+    db_backup_keep_daily: settingsDefaults.db_backup_keep_daily,
+    db_backup_keep_weekly: settingsDefaults.db_backup_keep_weekly,
     deepl_api_key: settingsDefaults.deepl_api_key,
     deepl_endpoint: settingsDefaults.deepl_endpoint,
     default_view_mode: settingsDefaults.default_view_mode,
+    digest_category: settingsDefaults.digest_category,
+    digest_enabled: settingsDefaults.digest_enabled,
+    digest_frequency: settingsDefaults.digest_frequency,
+    digest_hour: settingsDefaults.digest_hour,
+    digest_last_run: settingsDefaults.digest_last_run,
+    duplicate_read_action: settingsDefaults.duplicate_read_action,
     freshrss_api_password: settingsDefaults.freshrss_api_password,
     freshrss_enabled: settingsDefaults.freshrss_enabled,
     freshrss_server_url: settingsDefaults.freshrss_server_url,
@@ -42,6 +64,10 @@ export function generateInitialSettings(): SettingsData {
     language: settingsDefaults.language,
     last_article_update: settingsDefaults.last_article_update,
     last_network_test: settingsDefaults.last_network_test,
+    markdown_export_filename: settingsDefaults.markdown_export_filename,
+    markdown_export_images: settingsDefaults.markdown_export_images,
+    markdown_export_layout: settingsDefaults.markdown_export_layout,
+    markdown_export_template: settingsDefaults.markdown_export_template,
     max_article_age_days: settingsDefaults.max_article_age_days,
     max_cache_size_mb: settingsDefaults.max_cache_size_mb,
     max_concurrent_refreshes: settingsDefaults.max_concurrent_refreshes,
@@ -60,20 +86,36 @@ export function generateInitialSettings(): SettingsData {
     proxy_port: settingsDefaults.proxy_port,
     proxy_type: settingsDefaults.proxy_type,
     proxy_username: settingsDefaults.proxy_username,
+    ranking_semantic_enabled: settingsDefaults.ranking_semantic_enabled,
     refresh_mode: settingsDefaults.refresh_mode,
     rules: settingsDefaults.rules,
     shortcuts: settingsDefaults.shortcuts,
     show_article_preview_images: settingsDefaults.show_article_preview_images,
     show_hidden_articles: settingsDefaults.show_hidden_articles,
     startup_on_boot: settingsDefaults.startup_on_boot,
+    summary_auto_categories: settingsDefaults.summary_auto_categories,
+    summary_auto_feeds: settingsDefaults.summary_auto_feeds,
     summary_enabled: settingsDefaults.summary_enabled,
     summary_length: settingsDefaults.summary_length,
     summary_provider: settingsDefaults.summary_provider,
+    summary_queue_concurrency: settingsDefaults.summary_queue_concurrency,
     summary_trigger_mode: settingsDefaults.summary_trigger_mode,
+    sync_app_id: settingsDefaults.sync_app_id,
+    sync_app_key: settingsDefaults.sync_app_key,
+    sync_enabled: settingsDefaults.sync_enabled,
+    sync_interval: settingsDefaults.sync_interval,
+    sync_password: settingsDefaults.sync_password,
+    sync_provider: settingsDefaults.sync_provider,
+    sync_server_url: settingsDefaults.sync_server_url,
+    sync_username: settingsDefaults.sync_username,
+    tagging_enabled: settingsDefaults.tagging_enabled,
+    tagging_provider: settingsDefaults.tagging_provider,
+    tagging_taxonomy: settingsDefaults.tagging_taxonomy,
     target_language: settingsDefaults.target_language,
     theme: settingsDefaults.theme,
     translation_enabled: settingsDefaults.translation_enabled,
     translation_provider: settingsDefaults.translation_provider,
+    translation_source_language: settingsDefaults.translation_source_language,
     update_interval: settingsDefaults.update_interval,
     window_height: settingsDefaults.window_height,
     window_maximized: settingsDefaults.window_maximized,
@@ -90,13 +132,25 @@ export function generateInitialSettings(): SettingsData {
 export function parseSettingsData(data: Record<string, string>): SettingsData {
   return {
     ai_api_key: data.ai_api_key || settingsDefaults.ai_api_key,
+    ai_backend: data.ai_backend || settingsDefaults.ai_backend,
     ai_chat_enabled: data.ai_chat_enabled === 'true',
+    ai_chat_profile: data.ai_chat_profile || settingsDefaults.ai_chat_profile,
     ai_custom_headers: data.ai_custom_headers || settingsDefaults.ai_custom_headers,
+    ai_embedding_api_key: data.ai_embedding_api_key || settingsDefaults.ai_embedding_api_key,
+    ai_embedding_enabled: data.ai_embedding_enabled === 'true',
+    ai_embedding_endpoint: data.ai_embedding_endpoint || settingsDefaults.ai_embedding_endpoint,
+    ai_embedding_model: data.ai_embedding_model || settingsDefaults.ai_embedding_model,
     ai_endpoint: data.ai_endpoint || settingsDefaults.ai_endpoint,
     ai_model: data.ai_model || settingsDefaults.ai_model,
+    ai_price_table: data.ai_price_table || settingsDefaults.ai_price_table,
+    ai_summary_profile: data.ai_summary_profile || settingsDefaults.ai_summary_profile,
     ai_summary_prompt: data.ai_summary_prompt || settingsDefaults.ai_summary_prompt,
+    ai_tagging_profile: data.ai_tagging_profile || settingsDefaults.ai_tagging_profile,
+    ai_translation_profile: data.ai_translation_profile || settingsDefaults.ai_translation_profile,
     ai_translation_prompt: data.ai_translation_prompt || settingsDefaults.ai_translation_prompt,
+    ai_usage_daily_limit: data.ai_usage_daily_limit || settingsDefaults.ai_usage_daily_limit,
     ai_usage_limit: data.ai_usage_limit || settingsDefaults.ai_usage_limit,
+    ai_usage_monthly_limit: data.ai_usage_monthly_limit || settingsDefaults.ai_usage_monthly_limit,
     ai_usage_tokens: data.ai_usage_tokens || settingsDefaults.ai_usage_tokens,
     auto_cleanup_enabled: data.auto_cleanup_enabled === 'true',
     auto_show_all_content: data.auto_show_all_content === 'true',
@@ -104,9 +158,21 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     baidu_secret_key: data.baidu_secret_key || settingsDefaults.baidu_secret_key,
     close_to_tray: data.close_to_tray === 'true',
     custom_css_file: data.custom_css_file || settingsDefaults.custom_css_file,
+    db_backup_dir: data.db_backup_dir || settingsDefaults.db_backup_dir,
+    db_backup_enabled: data.db_backup_enabled === 'true',
+    db_backup_keep_daily:
+      parseInt(data.db_backup_keep_daily) || settingsDefaults.db_backup_keep_daily,
+    db_backup_keep_weekly:
+      parseInt(data.db_backup_keep_weekly) || settingsDefaults.db_backup_keep_weekly,
     deepl_api_key: data.deepl_api_key || settingsDefaults.deepl_api_key,
     deepl_endpoint: data.deepl_endpoint || settingsDefaults.deepl_endpoint,
     default_view_mode: data.default_view_mode || settingsDefaults.default_view_mode,
+    digest_category: data.digest_category || settingsDefaults.digest_category,
+    digest_enabled: data.digest_enabled === 'true',
+    digest_frequency: data.digest_frequency || settingsDefaults.digest_frequency,
+    digest_hour: parseInt(data.digest_hour) || settingsDefaults.digest_hour,
+    digest_last_run: data.digest_last_run || settingsDefaults.digest_last_run,
+    duplicate_read_action: data.duplicate_read_action || settingsDefaults.duplicate_read_action,
     freshrss_api_password: data.freshrss_api_password || settingsDefaults.freshrss_api_password,
     freshrss_enabled: data.freshrss_enabled === 'true',
     freshrss_server_url: data.freshrss_server_url || settingsDefaults.freshrss_server_url,
@@ -119,6 +185,12 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     language: data.language || settingsDefaults.language,
     last_article_update: data.last_article_update || settingsDefaults.last_article_update,
     last_network_test: data.last_network_test || settingsDefaults.last_network_test,
+    markdown_export_filename:
+      data.markdown_export_filename || settingsDefaults.markdown_export_filename,
+    markdown_export_images: data.markdown_export_images === 'true',
+    markdown_export_layout: data.markdown_export_layout || settingsDefaults.markdown_export_layout,
+    markdown_export_template:
+      data.markdown_export_template || settingsDefaults.markdown_export_template,
     max_article_age_days:
       parseInt(data.max_article_age_days) || settingsDefaults.max_article_age_days,
     max_cache_size_mb: parseInt(data.max_cache_size_mb) || settingsDefaults.max_cache_size_mb,
@@ -141,20 +213,39 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     proxy_port: data.proxy_port || settingsDefaults.proxy_port,
     proxy_type: data.proxy_type || settingsDefaults.proxy_type,
     proxy_username: data.proxy_username || settingsDefaults.proxy_username,
+    ranking_semantic_enabled: data.ranking_semantic_enabled === 'true',
     refresh_mode: data.refresh_mode || settingsDefaults.refresh_mode,
     rules: data.rules || settingsDefaults.rules,
     shortcuts: data.shortcuts || settingsDefaults.shortcuts,
     show_article_preview_images: data.show_article_preview_images === 'true',
     show_hidden_articles: data.show_hidden_articles === 'true',
     startup_on_boot: data.startup_on_boot === 'true',
+    summary_auto_categories:
+      data.summary_auto_categories || settingsDefaults.summary_auto_categories,
+    summary_auto_feeds: data.summary_auto_feeds || settingsDefaults.summary_auto_feeds,
     summary_enabled: data.summary_enabled === 'true',
     summary_length: data.summary_length || settingsDefaults.summary_length,
     summary_provider: data.summary_provider || settingsDefaults.summary_provider,
+    summary_queue_concurrency:
+      parseInt(data.summary_queue_concurrency) || settingsDefaults.summary_queue_concurrency,
     summary_trigger_mode: data.summary_trigger_mode || settingsDefaults.summary_trigger_mode,
+    sync_app_id: data.sync_app_id || settingsDefaults.sync_app_id,
+    sync_app_key: data.sync_app_key || settingsDefaults.sync_app_key,
+    sync_enabled: data.sync_enabled === 'true',
+    sync_interval: parseInt(data.sync_interval) || settingsDefaults.sync_interval,
+    sync_password: data.sync_password || settingsDefaults.sync_password,
+    sync_provider: data.sync_provider || settingsDefaults.sync_provider,
+    sync_server_url: data.sync_server_url || settingsDefaults.sync_server_url,
+    sync_username: data.sync_username || settingsDefaults.sync_username,
+    tagging_enabled: data.tagging_enabled === 'true',
+    tagging_provider: data.tagging_provider || settingsDefaults.tagging_provider,
+    tagging_taxonomy: data.tagging_taxonomy || settingsDefaults.tagging_taxonomy,
     target_language: data.target_language || settingsDefaults.target_language,
     theme: data.theme || settingsDefaults.theme,
     translation_enabled: data.translation_enabled === 'true',
     translation_provider: data.translation_provider || settingsDefaults.translation_provider,
+    translation_source_language:
+      data.translation_source_language || settingsDefaults.translation_source_language,
     update_interval: parseInt(data.update_interval) || settingsDefaults.update_interval,
     window_height: data.window_height || settingsDefaults.window_height,
     window_maximized: data.window_maximized || settingsDefaults.window_maximized,
@@ -171,16 +262,35 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
 export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<string, string> {
   return {
     ai_api_key: settingsRef.value.ai_api_key ?? settingsDefaults.ai_api_key,
+    ai_backend: settingsRef.value.ai_backend ?? settingsDefaults.ai_backend,
     ai_chat_enabled: (
       settingsRef.value.ai_chat_enabled ?? settingsDefaults.ai_chat_enabled
     ).toString(),
+    ai_chat_profile: settingsRef.value.ai_chat_profile ?? settingsDefaults.ai_chat_profile,
     ai_custom_headers: settingsRef.value.ai_custom_headers ?? settingsDefaults.ai_custom_headers,
+    ai_embedding_api_key:
+      settingsRef.value.ai_embedding_api_key ?? settingsDefaults.ai_embedding_api_key,
+    ai_embedding_enabled: (
+      settingsRef.value.ai_embedding_enabled ?? settingsDefaults.ai_embedding_enabled
+    ).toString(),
+    ai_embedding_endpoint:
+      settingsRef.value.ai_embedding_endpoint ?? settingsDefaults.ai_embedding_endpoint,
+    ai_embedding_model: settingsRef.value.ai_embedding_model ?? settingsDefaults.ai_embedding_model,
     ai_endpoint: settingsRef.value.ai_endpoint ?? settingsDefaults.ai_endpoint,
     ai_model: settingsRef.value.ai_model ?? settingsDefaults.ai_model,
+    ai_price_table: settingsRef.value.ai_price_table ?? settingsDefaults.ai_price_table,
+    ai_summary_profile: settingsRef.value.ai_summary_profile ?? settingsDefaults.ai_summary_profile,
     ai_summary_prompt: settingsRef.value.ai_summary_prompt ?? settingsDefaults.ai_summary_prompt,
+    ai_tagging_profile: settingsRef.value.ai_tagging_profile ?? settingsDefaults.ai_tagging_profile,
+    ai_translation_profile:
+      settingsRef.value.ai_translation_profile ?? settingsDefaults.ai_translation_profile,
     ai_translation_prompt:
       settingsRef.value.ai_translation_prompt ?? settingsDefaults.ai_translation_prompt,
+    ai_usage_daily_limit:
+      settingsRef.value.ai_usage_daily_limit ?? settingsDefaults.ai_usage_daily_limit,
     ai_usage_limit: settingsRef.value.ai_usage_limit ?? settingsDefaults.ai_usage_limit,
+    ai_usage_monthly_limit:
+      settingsRef.value.ai_usage_monthly_limit ?? settingsDefaults.ai_usage_monthly_limit,
     ai_usage_tokens: settingsRef.value.ai_usage_tokens ?? settingsDefaults.ai_usage_tokens,
     auto_cleanup_enabled: (
       settingsRef.value.auto_cleanup_enabled ?? settingsDefaults.auto_cleanup_enabled
@@ -192,9 +302,28 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     baidu_secret_key: settingsRef.value.baidu_secret_key ?? settingsDefaults.baidu_secret_key,
     close_to_tray: (settingsRef.value.close_to_tray ?? settingsDefaults.close_to_tray).toString(),
     custom_css_file: settingsRef.value.custom_css_file ?? settingsDefaults.custom_css_file,
+    db_backup_dir: settingsRef.value.db_backup_dir ?? settingsDefaults.db_backup_dir,
+    db_backup_enabled: (
+      settingsRef.value.db_backup_enabled ?? settingsDefaults.db_backup_enabled
+    ).toString(),
+    db_backup_keep_daily: (
+      settingsRef.value.db_backup_keep_daily ?? settingsDefaults.db_backup_keep_daily
+    ).toString(),
+    db_backup_keep_weekly: (
+      settingsRef.value.db_backup_keep_weekly ?? settingsDefaults.db_backup_keep_weekly
+    ).toString(),
     deepl_api_key: settingsRef.value.deepl_api_key ?? settingsDefaults.deepl_api_key,
     deepl_endpoint: settingsRef.value.deepl_endpoint ?? settingsDefaults.deepl_endpoint,
     default_view_mode: settingsRef.value.default_view_mode ?? settingsDefaults.default_view_mode,
+    digest_category: settingsRef.value.digest_category ?? settingsDefaults.digest_category,
+    digest_enabled: (
+      settingsRef.value.digest_enabled ?? settingsDefaults.digest_enabled
+    ).toString(),
+    digest_frequency: settingsRef.value.digest_frequency ?? settingsDefaults.digest_frequency,
+    digest_hour: (settingsRef.value.digest_hour ?? settingsDefaults.digest_hour).toString(),
+    digest_last_run: settingsRef.value.digest_last_run ?? settingsDefaults.digest_last_run,
+    duplicate_read_action:
+      settingsRef.value.duplicate_read_action ?? settingsDefaults.duplicate_read_action,
     freshrss_api_password:
       settingsRef.value.freshrss_api_password ?? settingsDefaults.freshrss_api_password,
     freshrss_enabled: (
@@ -218,6 +347,15 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     last_article_update:
       settingsRef.value.last_article_update ?? settingsDefaults.last_article_update,
     last_network_test: settingsRef.value.last_network_test ?? settingsDefaults.last_network_test,
+    markdown_export_filename:
+      settingsRef.value.markdown_export_filename ?? settingsDefaults.markdown_export_filename,
+    markdown_export_images: (
+      settingsRef.value.markdown_export_images ?? settingsDefaults.markdown_export_images
+    ).toString(),
+    markdown_export_layout:
+      settingsRef.value.markdown_export_layout ?? settingsDefaults.markdown_export_layout,
+    markdown_export_template:
+      settingsRef.value.markdown_export_template ?? settingsDefaults.markdown_export_template,
     max_article_age_days: (
       settingsRef.value.max_article_age_days ?? settingsDefaults.max_article_age_days
     ).toString(),
@@ -251,6 +389,9 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     proxy_port: settingsRef.value.proxy_port ?? settingsDefaults.proxy_port,
     proxy_type: settingsRef.value.proxy_type ?? settingsDefaults.proxy_type,
     proxy_username: settingsRef.value.proxy_username ?? settingsDefaults.proxy_username,
+    ranking_semantic_enabled: (
+      settingsRef.value.ranking_semantic_enabled ?? settingsDefaults.ranking_semantic_enabled
+    ).toString(),
     refresh_mode: settingsRef.value.refresh_mode ?? settingsDefaults.refresh_mode,
     rules: settingsRef.value.rules ?? settingsDefaults.rules,
     shortcuts: settingsRef.value.shortcuts ?? settingsDefaults.shortcuts,
@@ -263,13 +404,32 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     startup_on_boot: (
       settingsRef.value.startup_on_boot ?? settingsDefaults.startup_on_boot
     ).toString(),
+    summary_auto_categories:
+      settingsRef.value.summary_auto_categories ?? settingsDefaults.summary_auto_categories,
+    summary_auto_feeds: settingsRef.value.summary_auto_feeds ?? settingsDefaults.summary_auto_feeds,
     summary_enabled: (
       settingsRef.value.summary_enabled ?? settingsDefaults.summary_enabled
     ).toString(),
     summary_length: settingsRef.value.summary_length ?? settingsDefaults.summary_length,
     summary_provider: settingsRef.value.summary_provider ?? settingsDefaults.summary_provider,
+    summary_queue_concurrency: (
+      settingsRef.value.summary_queue_concurrency ?? settingsDefaults.summary_queue_concurrency
+    ).toString(),
     summary_trigger_mode:
       settingsRef.value.summary_trigger_mode ?? settingsDefaults.summary_trigger_mode,
+    sync_app_id: settingsRef.value.sync_app_id ?? settingsDefaults.sync_app_id,
+    sync_app_key: settingsRef.value.sync_app_key ?? settingsDefaults.sync_app_key,
+    sync_enabled: (settingsRef.value.sync_enabled ?? settingsDefaults.sync_enabled).toString(),
+    sync_interval: (settingsRef.value.sync_interval ?? settingsDefaults.sync_interval).toString(),
+    sync_password: settingsRef.value.sync_password ?? settingsDefaults.sync_password,
+    sync_provider: settingsRef.value.sync_provider ?? settingsDefaults.sync_provider,
+    sync_server_url: settingsRef.value.sync_server_url ?? settingsDefaults.sync_server_url,
+    sync_username: settingsRef.value.sync_username ?? settingsDefaults.sync_username,
+    tagging_enabled: (
+      settingsRef.value.tagging_enabled ?? settingsDefaults.tagging_enabled
+    ).toString(),
+    tagging_provider: settingsRef.value.tagging_provider ?? settingsDefaults.tagging_provider,
+    tagging_taxonomy: settingsRef.value.tagging_taxonomy ?? settingsDefaults.tagging_taxonomy,
     target_language: settingsRef.value.target_language ?? settingsDefaults.target_language,
     theme: settingsRef.value.theme ?? settingsDefaults.theme,
     translation_enabled: (
@@ -277,6 +437,8 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     ).toString(),
     translation_provider:
       settingsRef.value.translation_provider ?? settingsDefaults.translation_provider,
+    translation_source_language:
+      settingsRef.value.translation_source_language ?? settingsDefaults.translation_source_language,
     update_interval: (
       settingsRef.value.update_interval ?? settingsDefaults.update_interval
     ).toString(),
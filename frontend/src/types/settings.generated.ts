@@ -6,13 +6,25 @@
 
 export interface SettingsData {
   ai_api_key: string;
+  ai_backend: string;
   ai_chat_enabled: boolean;
+  ai_chat_profile: string;
   ai_custom_headers: string;
+  ai_embedding_api_key: string;
+  ai_embedding_enabled: boolean;
+  ai_embedding_endpoint: string;
+  ai_embedding_model: string;
   ai_endpoint: string;
   ai_model: string;
+  ai_price_table: string;
+  ai_summary_profile: string;
   ai_summary_prompt: string;
+  ai_tagging_profile: string;
+  ai_translation_profile: string;
   ai_translation_prompt: string;
+  ai_usage_daily_limit: string;
   ai_usage_limit: string;
+  ai_usage_monthly_limit: string;
   ai_usage_tokens: string;
   auto_cleanup_enabled: boolean;
   auto_show_all_content: boolean;
@@ -20,9 +32,19 @@ export interface SettingsData {
   baidu_secret_key: string;
   close_to_tray: boolean;
   custom_css_file: string;
+  db_backup_dir: string;
+  db_backup_enabled: boolean;
+  db_backup_keep_daily: number;
+  db_backup_keep_weekly: number;
   deepl_api_key: string;
   deepl_endpoint: string;
   default_view_mode: string;
+  digest_category: string;
+  digest_enabled: boolean;
+  digest_frequency: string;
+  digest_hour: number;
+  digest_last_run: string;
+  duplicate_read_action: string;
   freshrss_api_password: string;
   freshrss_enabled: boolean;
   freshrss_server_url: string;
@@ -34,6 +56,10 @@ export interface SettingsData {
   language: string;
   last_article_update: string;
   last_network_test: string;
+  markdown_export_filename: string;
+  markdown_export_images: boolean;
+  markdown_export_layout: string;
+  markdown_export_template: string;
   max_article_age_days: number;
   max_cache_size_mb: number;
   max_concurrent_refreshes: string;
@@ -52,20 +78,36 @@ export interface SettingsData {
   proxy_port: string;
   proxy_type: string;
   proxy_username: string;
+  ranking_semantic_enabled: boolean;
   refresh_mode: string;
   rules: string;
   shortcuts: string;
   show_article_preview_images: boolean;
   show_hidden_articles: boolean;
   startup_on_boot: boolean;
+  summary_auto_categories: string;
+  summary_auto_feeds: string;
   summary_enabled: boolean;
   summary_length: string;
   summary_provider: string;
+  summary_queue_concurrency: number;
   summary_trigger_mode: string;
+  sync_app_id: string;
+  sync_app_key: string;
+  sync_enabled: boolean;
+  sync_interval: number;
+  sync_password: string;
+  sync_provider: string;
+  sync_server_url: string;
+  sync_username: string;
+  tagging_enabled: boolean;
+  tagging_provider: string;
+  tagging_taxonomy: string;
   target_language: string;
   theme: string;
   translation_enabled: boolean;
   translation_provider: string;
+  translation_source_language: string;
   update_interval: number;
   window_height: string;
   window_maximized: string;
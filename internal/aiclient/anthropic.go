@@ -0,0 +1,141 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens is used when the request sets no limit, since the API requires one
+	anthropicDefaultMaxTokens = 1024
+)
+
+// anthropicBackend implements the Anthropic Messages API. Endpoints without a path
+// (e.g. https://api.anthropic.com) get /v1/messages appended.
+type anthropicBackend struct{}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (anthropicBackend) request(cfg Config, req Request, stream bool) (string, interface{}, error) {
+	endpoint := cfg.Endpoint
+	if parsed, err := url.Parse(endpoint); err == nil && strings.Trim(parsed.Path, "/") == "" {
+		parsed.Path = "/v1/messages"
+		endpoint = parsed.String()
+	}
+
+	system, messages := splitSystem(req.Messages)
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	body := map[string]interface{}{
+		"model":      cfg.Model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+	}
+	if system != "" {
+		body["system"] = system
+	}
+	if req.Temperature > 0 {
+		body["temperature"] = req.Temperature
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return endpoint, body, nil
+}
+
+func (anthropicBackend) authorize(header http.Header, apiKey string) {
+	header.Set("x-api-key", apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+}
+
+func (anthropicBackend) parse(body []byte) (Response, error) {
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int64 `json:"input_tokens"`
+			OutputTokens int64 `json:"output_tokens"`
+		} `json:"usage"`
+		Error *anthropicError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Response{}, invalidJSON(BackendAnthropic, err)
+	}
+	if resp.Error != nil {
+		return Response{}, &Error{Kind: ErrBadRequest, Backend: BackendAnthropic, Message: resp.Error.Message}
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return Response{
+		Text:  text.String(),
+		Usage: Usage{PromptTokens: resp.Usage.InputTokens, CompletionTokens: resp.Usage.OutputTokens},
+	}, nil
+}
+
+func (anthropicBackend) stream(body io.Reader, emit DeltaFunc) (Usage, error) {
+	var usage Usage
+	err := readSSE(body, func(data string) error {
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage struct {
+					InputTokens int64 `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int64 `json:"output_tokens"`
+			} `json:"usage"`
+			Error *anthropicError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return invalidJSON(BackendAnthropic, err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				return emit(event.Delta.Text)
+			}
+		case "message_delta":
+			usage.CompletionTokens = event.Usage.OutputTokens
+		case "message_stop":
+			return errStopStream
+		case "error":
+			kind := ErrServer
+			if event.Error != nil && event.Error.Type == "rate_limit_error" {
+				kind = ErrRateLimited
+			}
+			msg := ""
+			if event.Error != nil {
+				msg = event.Error.Message
+			}
+			return &Error{Kind: kind, Backend: BackendAnthropic, Message: msg}
+		}
+		return nil
+	})
+	return usage, err
+}
@@ -0,0 +1,489 @@
+// Package aiclient is the AI provider client shared by translation, summarization and chat.
+//
+// It supports OpenAI-compatible chat completions, Ollama's /api/chat, Anthropic messages
+// and Gemini generateContent. The backend is chosen explicitly or detected from the
+// endpoint URL, token usage is read from provider responses, transient failures are
+// retried with jittered backoff, and errors are typed (see Error).
+package aiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/models"
+)
+
+// Backend identifies an AI provider API format.
+type Backend string
+
+// Supported backends
+const (
+	BackendAuto      Backend = "auto"
+	BackendOpenAI    Backend = "openai"
+	BackendOllama    Backend = "ollama"
+	BackendAnthropic Backend = "anthropic"
+	BackendGemini    Backend = "gemini"
+)
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+const (
+	// DefaultMaxRetries is the number of retries for transient errors when Config.MaxRetries is zero
+	DefaultMaxRetries = 2
+	// DefaultRetryDelay is the base backoff delay when Config.RetryDelay is zero
+	DefaultRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay caps backoff and Retry-After delays
+	maxRetryDelay = 30 * time.Second
+	// maxResponseSize bounds non-streaming response bodies
+	maxResponseSize = 8 * 1024 * 1024
+)
+
+// Message is one chat message.
+type Message struct {
+	Role    string `json:"role"` // "system", "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// Request is a completion request.
+type Request struct {
+	Messages    []Message
+	Temperature float64 // 0 uses the provider default
+	MaxTokens   int     // 0 uses the provider default
+}
+
+// Usage holds token counts for a request and the model that used them.
+type Usage struct {
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Estimated        bool   `json:"estimated,omitempty"` // True if the provider did not report usage
+	Model            string `json:"model,omitempty"`
+	ProfileID        int64  `json:"profile_id,omitempty"` // AI profile of the client; 0 for the global settings
+}
+
+// Total returns the total number of tokens.
+func (u Usage) Total() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Record returns a usage ledger entry for a feature ("translate", "summary", "chat",
+// "embedding" or "other"). articleID is 0 if the request was not about a single article.
+func (u Usage) Record(feature string, articleID int64) models.AIUsageRecord {
+	return models.AIUsageRecord{
+		Feature:          feature,
+		ProfileID:        u.ProfileID,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		Estimated:        u.Estimated,
+		ArticleID:        articleID,
+	}
+}
+
+// Response is a completed request.
+type Response struct {
+	Text  string
+	Usage Usage
+}
+
+// DeltaFunc receives each piece of streamed text. Returning an error stops the stream.
+type DeltaFunc func(delta string) error
+
+// Config configures a client.
+type Config struct {
+	Backend       Backend // Empty or "auto" detects the backend from Endpoint
+	Endpoint      string
+	APIKey        string
+	Model         string
+	CustomHeaders map[string]string
+	MaxRetries    int           // Retries for transient errors; 0 uses DefaultMaxRetries, negative disables
+	RetryDelay    time.Duration // Base backoff delay; 0 uses DefaultRetryDelay
+	ProfileID     int64         // AI profile the configuration was loaded from; 0 for the global settings
+	Temperature   float64       // Overrides the request temperature if non-zero
+	MaxTokens     int           // Overrides the request max tokens if non-zero
+	ContextWindow int           // Prompt token budget; 0 lets callers use their default
+	OnUsage       func(Usage)   // Called with the usage of every request that produced text
+}
+
+// Key returns a string identifying the connection and request settings of a configuration,
+// so callers can tell when a cached client must be recreated.
+func (c Config) Key() string {
+	key, _ := json.Marshal(struct {
+		Backend       Backend
+		Endpoint      string
+		APIKey        string
+		Model         string
+		CustomHeaders map[string]string
+		ProfileID     int64
+		Temperature   float64
+		MaxTokens     int
+		ContextWindow int
+	}{c.Backend, c.Endpoint, c.APIKey, c.Model, c.CustomHeaders, c.ProfileID, c.Temperature, c.MaxTokens, c.ContextWindow})
+	return string(key)
+}
+
+// Client sends requests to one AI provider.
+type Client struct {
+	cfg        Config
+	backend    backend
+	httpClient *http.Client
+}
+
+// backend implements a provider API format.
+type backend interface {
+	// request returns the URL and JSON body of a completion request
+	request(cfg Config, req Request, stream bool) (string, interface{}, error)
+	// authorize sets authentication headers
+	authorize(header http.Header, apiKey string)
+	// parse decodes a non-streaming response body
+	parse(body []byte) (Response, error)
+	// stream reads a streaming response body, passing text to emit
+	stream(body io.Reader, emit DeltaFunc) (Usage, error)
+}
+
+var backends = map[Backend]backend{
+	BackendOpenAI:    openAIBackend{},
+	BackendOllama:    ollamaBackend{},
+	BackendAnthropic: anthropicBackend{},
+	BackendGemini:    geminiBackend{},
+}
+
+// New creates a client. httpClient may be nil to use a default client.
+func New(cfg Config, httpClient *http.Client) (*Client, error) {
+	cfg.Endpoint = strings.TrimSuffix(strings.TrimSpace(cfg.Endpoint), "/")
+	if cfg.Backend == "" || cfg.Backend == BackendAuto {
+		cfg.Backend = DetectBackend(cfg.Endpoint)
+	}
+	b, ok := backends[cfg.Backend]
+	if !ok {
+		return nil, &Error{Kind: ErrConfig, Backend: cfg.Backend, Message: "unknown backend"}
+	}
+
+	if cfg.Endpoint == "" {
+		return nil, &Error{Kind: ErrConfig, Backend: cfg.Backend, Message: "endpoint is required"}
+	}
+	parsed, err := url.Parse(cfg.Endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, &Error{Kind: ErrConfig, Backend: cfg.Backend, Message: "invalid endpoint URL", Err: err}
+	}
+	// Allow HTTP only for local endpoints (e.g. Ollama) to avoid leaking keys
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && IsLocalEndpoint(parsed.Host)) {
+		return nil, &Error{Kind: ErrConfig, Backend: cfg.Backend, Message: "API endpoint must use HTTPS for security (HTTP allowed only for localhost)"}
+	}
+
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &Client{cfg: cfg, backend: b, httpClient: httpClient}, nil
+}
+
+// Backend returns the backend used by the client.
+func (c *Client) Backend() Backend {
+	return c.cfg.Backend
+}
+
+// Model returns the configured model.
+func (c *Client) Model() string {
+	return c.cfg.Model
+}
+
+// ContextWindow returns the configured prompt token budget, or 0 if not set.
+func (c *Client) ContextWindow() int {
+	return c.cfg.ContextWindow
+}
+
+// DetectBackend infers the backend from an endpoint URL, defaulting to OpenAI-compatible.
+func DetectBackend(endpoint string) Backend {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return BackendOpenAI
+	}
+	host := strings.ToLower(parsed.Hostname())
+	path := parsed.Path
+
+	switch {
+	case strings.HasSuffix(host, "anthropic.com") || strings.HasSuffix(path, "/v1/messages"):
+		return BackendAnthropic
+	case host == "generativelanguage.googleapis.com" ||
+		strings.Contains(path, ":generateContent") || strings.Contains(path, ":streamGenerateContent"):
+		return BackendGemini
+	case strings.HasSuffix(path, "/api/generate") || strings.HasSuffix(path, "/api/chat") ||
+		strings.HasSuffix(path, "/api/embed") || strings.HasSuffix(path, "/api/embeddings") ||
+		(parsed.Port() == "11434" && !strings.Contains(path, "/v1/")):
+		return BackendOllama
+	default:
+		return BackendOpenAI
+	}
+}
+
+// Complete sends a request and returns the full response.
+func (c *Client) Complete(ctx context.Context, req Request) (*Response, error) {
+	req = c.applyOverrides(req)
+	var result *Response
+	err := c.withRetry(ctx, func() error {
+		resp, err := c.send(ctx, req, false)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		if err != nil {
+			return c.transportError(ctx, err)
+		}
+		parsed, err := c.backend.parse(body)
+		if err != nil {
+			var e *Error
+			if errors.As(err, &e) {
+				return e
+			}
+			return &Error{Kind: ErrInvalidResponse, Backend: c.cfg.Backend, Err: err}
+		}
+		if strings.TrimSpace(parsed.Text) == "" {
+			return &Error{Kind: ErrInvalidResponse, Backend: c.cfg.Backend, Message: "empty response"}
+		}
+		parsed.Text = strings.TrimSpace(parsed.Text)
+		result = &parsed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Usage = c.completeUsage(req, result.Text, result.Usage)
+	c.reportUsage(result.Usage)
+	return result, nil
+}
+
+// Stream sends a streaming request, passing text to onDelta as it arrives. Connection
+// failures before the response starts are retried; once text was received the stream
+// is not restarted. The response holds any text received before an error, and usage
+// is estimated from it if the provider did not report any.
+func (c *Client) Stream(ctx context.Context, req Request, onDelta DeltaFunc) (*Response, error) {
+	req = c.applyOverrides(req)
+	var text strings.Builder
+	var callbackErr error
+	emit := func(delta string) error {
+		if delta == "" {
+			return nil
+		}
+		text.WriteString(delta)
+		if onDelta != nil {
+			callbackErr = onDelta(delta)
+		}
+		return callbackErr
+	}
+
+	var usage Usage
+	err := c.withRetry(ctx, func() error {
+		resp, err := c.send(ctx, req, true)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		usage, err = c.backend.stream(resp.Body, emit)
+		if err == nil {
+			return nil
+		}
+		// The stream has started, so it must not be retried
+		var e *Error
+		switch {
+		case ctx.Err() != nil:
+			return finalError{ctx.Err()}
+		case callbackErr != nil:
+			return finalError{callbackErr}
+		case errors.As(err, &e):
+			return finalError{e}
+		default:
+			// The connection failed while reading the body
+			return finalError{&Error{Kind: ErrNetwork, Backend: c.cfg.Backend, Err: err}}
+		}
+	})
+
+	result := &Response{Text: text.String()}
+	if err == nil && strings.TrimSpace(result.Text) == "" {
+		err = &Error{Kind: ErrInvalidResponse, Backend: c.cfg.Backend, Message: "empty response"}
+	}
+	if result.Text != "" {
+		result.Usage = c.completeUsage(req, result.Text, usage)
+		c.reportUsage(result.Usage)
+	}
+	return result, err
+}
+
+// applyOverrides applies the configured temperature and max tokens to a request.
+func (c *Client) applyOverrides(req Request) Request {
+	if c.cfg.Temperature != 0 {
+		req.Temperature = c.cfg.Temperature
+	}
+	if c.cfg.MaxTokens != 0 {
+		req.MaxTokens = c.cfg.MaxTokens
+	}
+	return req
+}
+
+// reportUsage passes request usage to the configured usage hook.
+func (c *Client) reportUsage(usage Usage) {
+	if c.cfg.OnUsage != nil && usage.Total() > 0 {
+		c.cfg.OnUsage(usage)
+	}
+}
+
+// send performs one HTTP request, returning an Error for non-200 responses.
+func (c *Client) send(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	endpoint, body, err := c.backend.request(c.cfg, req, stream)
+	if err != nil {
+		return nil, finalError{&Error{Kind: ErrConfig, Backend: c.cfg.Backend, Err: err}}
+	}
+	return c.post(ctx, endpoint, body, stream)
+}
+
+// post sends a JSON body to endpoint, returning an Error for non-200 responses.
+func (c *Client) post(ctx context.Context, endpoint string, body interface{}, stream bool) (*http.Response, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, finalError{&Error{Kind: ErrConfig, Backend: c.cfg.Backend, Err: err}}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, finalError{&Error{Kind: ErrConfig, Backend: c.cfg.Backend, Err: err}}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream, application/x-ndjson")
+	}
+	if c.cfg.APIKey != "" {
+		c.backend.authorize(httpReq.Header, c.cfg.APIKey)
+	}
+	for key, value := range c.cfg.CustomHeaders {
+		httpReq.Header.Set(key, value)
+	}
+
+	client := c.httpClient
+	if stream && client.Timeout > 0 {
+		// The client timeout would cut off long streams; the caller's context bounds them instead
+		copied := *client
+		copied.Timeout = 0
+		client = &copied
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, c.transportError(ctx, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		return nil, statusError(c.cfg.Backend, resp.StatusCode, resp.Header, body)
+	}
+	return resp, nil
+}
+
+// transportError wraps a connection failure, preferring the context error if the request was cancelled.
+func (c *Client) transportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return finalError{ctx.Err()}
+	}
+	return &Error{Kind: ErrNetwork, Backend: c.cfg.Backend, Err: err}
+}
+
+// finalError marks an error that must not be retried.
+type finalError struct{ err error }
+
+func (f finalError) Error() string { return f.err.Error() }
+
+// withRetry runs fn, retrying transient errors with jittered exponential backoff.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var final finalError
+		if errors.As(err, &final) {
+			return final.err
+		}
+		var e *Error
+		if !errors.As(err, &e) || !e.Retryable() || attempt >= c.cfg.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retryDelay(attempt, e.RetryAfter)):
+		}
+	}
+}
+
+// retryDelay returns the delay before the next attempt: the provider's Retry-After if
+// given, otherwise exponential backoff with full jitter.
+func (c *Client) retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+	backoff := min(c.cfg.RetryDelay<<attempt, maxRetryDelay)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// completeUsage fills in estimated token counts if the provider reported none,
+// and records which model and profile produced them.
+func (c *Client) completeUsage(req Request, text string, usage Usage) Usage {
+	if usage.Total() == 0 {
+		var prompt int64
+		for _, msg := range req.Messages {
+			prompt += aiusage.EstimateTokens(msg.Content)
+		}
+		usage = Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: aiusage.EstimateTokens(text),
+			Estimated:        true,
+		}
+	}
+	usage.Model = c.cfg.Model
+	usage.ProfileID = c.cfg.ProfileID
+	return usage
+}
+
+// splitSystem separates system messages, joined into one prompt, from the conversation.
+func splitSystem(messages []Message) (string, []Message) {
+	var system []string
+	var rest []Message
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// invalidJSON wraps a decoding error of a provider response.
+func invalidJSON(backend Backend, err error) error {
+	return &Error{Kind: ErrInvalidResponse, Backend: backend, Err: fmt.Errorf("failed to decode response: %w", err)}
+}
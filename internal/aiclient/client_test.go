@@ -0,0 +1,442 @@
+package aiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	if cfg.Model == "" {
+		cfg.Model = "test-model"
+	}
+	cfg.RetryDelay = time.Millisecond
+	c, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func testRequest() Request {
+	return Request{
+		Messages: []Message{
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Content: "Hello"},
+		},
+		Temperature: 0.2,
+		MaxTokens:   64,
+	}
+}
+
+func decodeBody(t *testing.T, r *http.Request) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("failed to decode request body: %v", err)
+	}
+	return body
+}
+
+func TestDetectBackend(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     Backend
+	}{
+		{"https://api.openai.com/v1/chat/completions", BackendOpenAI},
+		{"http://localhost:11434/v1/chat/completions", BackendOpenAI},
+		{"http://localhost:11434/api/generate", BackendOllama},
+		{"http://localhost:11434/api/chat", BackendOllama},
+		{"http://ollama.lan:8080/api/embeddings", BackendOllama},
+		{"http://localhost:11434", BackendOllama},
+		{"https://api.anthropic.com/v1/messages", BackendAnthropic},
+		{"https://proxy.example/v1/messages", BackendAnthropic},
+		{"https://generativelanguage.googleapis.com/v1beta", BackendGemini},
+		{"https://proxy.example/v1beta/models/gemini-pro:generateContent", BackendGemini},
+		{"https://openrouter.ai/api/v1/chat/completions", BackendOpenAI},
+	}
+	for _, tt := range tests {
+		if got := DetectBackend(tt.endpoint); got != tt.want {
+			t.Errorf("DetectBackend(%q) = %s, want %s", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	tests := []Config{
+		{Endpoint: ""},
+		{Endpoint: "http://api.example.com/v1/chat/completions"},
+		{Endpoint: "not a url"},
+		{Endpoint: "https://api.example.com", Backend: "unknown"},
+	}
+	for _, cfg := range tests {
+		if _, err := New(cfg, nil); !errors.Is(err, ErrConfig) {
+			t.Errorf("New(%+v) error = %v, want ErrConfig", cfg, err)
+		}
+	}
+
+	// Plain HTTP is allowed for local endpoints
+	if _, err := New(Config{Endpoint: "http://127.0.0.1:8080/v1/chat/completions"}, nil); err != nil {
+		t.Errorf("expected local HTTP endpoint to be allowed, got %v", err)
+	}
+}
+
+func TestComplete_OpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get("X-Custom"); got != "yes" {
+			t.Errorf("custom header = %q", got)
+		}
+		body := decodeBody(t, r)
+		if body["model"] != "test-model" || body["max_tokens"] != float64(64) || body["stream"] != nil {
+			t.Errorf("unexpected body %v", body)
+		}
+		if messages, _ := body["messages"].([]interface{}); len(messages) != 2 {
+			t.Errorf("expected system and user messages, got %v", body["messages"])
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":" Hi there \n"}}],"usage":{"prompt_tokens":11,"completion_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL + "/v1/chat/completions", APIKey: "secret", CustomHeaders: map[string]string{"X-Custom": "yes"}})
+	resp, err := c.Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hi there" {
+		t.Errorf("Text = %q", resp.Text)
+	}
+	if resp.Usage.PromptTokens != 11 || resp.Usage.CompletionTokens != 2 || resp.Usage.Estimated {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestComplete_EstimatesMissingUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"A reasonably long answer to estimate."}}]}`)
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(t, Config{Endpoint: server.URL}).Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !resp.Usage.Estimated || resp.Usage.CompletionTokens == 0 || resp.Usage.PromptTokens == 0 {
+		t.Errorf("expected estimated usage, got %+v", resp.Usage)
+	}
+}
+
+func TestComplete_Ollama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		body := decodeBody(t, r)
+		options, _ := body["options"].(map[string]interface{})
+		if body["stream"] != false || options["num_predict"] != float64(64) {
+			t.Errorf("unexpected body %v", body)
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"Bonjour"},"done":true,"prompt_eval_count":7,"eval_count":1}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL + "/api/generate", Backend: BackendOllama})
+	resp, err := c.Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Bonjour" || resp.Usage.Total() != 8 {
+		t.Errorf("got %q with usage %+v", resp.Text, resp.Usage)
+	}
+}
+
+func TestComplete_Anthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "secret" || r.Header.Get("anthropic-version") == "" || r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected auth headers %v", r.Header)
+		}
+		body := decodeBody(t, r)
+		messages, _ := body["messages"].([]interface{})
+		if body["system"] != "Be brief." || len(messages) != 1 {
+			t.Errorf("system prompt not separated: %v", body)
+		}
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Hello"},{"type":"text","text":" back"}],"usage":{"input_tokens":9,"output_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL, Backend: BackendAnthropic, APIKey: "secret"})
+	resp, err := c.Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello back" || resp.Usage.Total() != 11 {
+		t.Errorf("got %q with usage %+v", resp.Text, resp.Usage)
+	}
+}
+
+func TestComplete_Gemini(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/test-model:generateContent" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "secret" {
+			t.Errorf("missing API key header")
+		}
+		body := decodeBody(t, r)
+		if body["systemInstruction"] == nil || body["generationConfig"] == nil {
+			t.Errorf("unexpected body %v", body)
+		}
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hallo"}]}}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":1}}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL, Backend: BackendGemini, APIKey: "secret"})
+	resp, err := c.Complete(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hallo" || resp.Usage.Total() != 6 {
+		t.Errorf("got %q with usage %+v", resp.Text, resp.Usage)
+	}
+}
+
+func TestComplete_RetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(t, Config{Endpoint: server.URL}).Complete(context.Background(), testRequest())
+	if err != nil || resp.Text != "ok" {
+		t.Fatalf("Complete() = %v, %v", resp, err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestComplete_TypedErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		kind      error
+		wantCalls int32
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized, 1},
+		{http.StatusBadRequest, ErrBadRequest, 1},
+		{http.StatusTooManyRequests, ErrRateLimited, 3},
+		{http.StatusInternalServerError, ErrServer, 3},
+	}
+	for _, tt := range tests {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, `{"error":{"message":"provider says no"}}`)
+		}))
+
+		_, err := newTestClient(t, Config{Endpoint: server.URL}).Complete(context.Background(), testRequest())
+		server.Close()
+
+		var aiErr *Error
+		if !errors.Is(err, tt.kind) || !errors.As(err, &aiErr) {
+			t.Errorf("status %d: error = %v, want %v", tt.status, err, tt.kind)
+			continue
+		}
+		if aiErr.StatusCode != tt.status || aiErr.Message != "provider says no" {
+			t.Errorf("status %d: unexpected error details %+v", tt.status, aiErr)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("status %d: expected %d attempts, got %d", tt.status, tt.wantCalls, calls)
+		}
+	}
+}
+
+func TestComplete_InvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[]}`)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, Config{Endpoint: server.URL}).Complete(context.Background(), testRequest())
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse, got %v", err)
+	}
+}
+
+func TestStatusError_RetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": {"7"}}
+	e := statusError(BackendOpenAI, http.StatusTooManyRequests, header, []byte(`{"error":"slow down"}`))
+	if !e.Retryable() || e.RetryAfter != 7*time.Second || e.Message != "slow down" {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	c := &Client{cfg: Config{RetryDelay: 100 * time.Millisecond}}
+	if d := c.retryDelay(0, e.RetryAfter); d != 7*time.Second {
+		t.Errorf("expected Retry-After delay, got %v", d)
+	}
+	for attempt := 0; attempt < 4; attempt++ {
+		backoff := c.cfg.RetryDelay << attempt
+		if d := c.retryDelay(attempt, 0); d < backoff/2 || d > backoff {
+			t.Errorf("attempt %d: delay %v outside [%v, %v]", attempt, d, backoff/2, backoff)
+		}
+	}
+}
+
+func TestStream_Backends(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend Backend
+		path    string
+		body    string
+		usage   int64
+	}{
+		{"openai", BackendOpenAI, "/v1/chat/completions", ": keep-alive\n" +
+			"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\", world\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3}}\n\n" +
+			"data: [DONE]\n\n", 15},
+		{"ollama", BackendOllama, "/api/chat",
+			`{"message":{"role":"assistant","content":"Hello"},"done":false}` + "\n" +
+				`{"message":{"role":"assistant","content":", world"},"done":false}` + "\n" +
+				`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":20,"eval_count":2}` + "\n", 22},
+		{"anthropic", BackendAnthropic, "/v1/messages",
+			"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":10}}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n" +
+				"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\", world\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":4}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n", 14},
+		{"gemini", BackendGemini, "/v1beta/models/test-model:streamGenerateContent",
+			"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hello\"}]}}],\"usageMetadata\":{\"promptTokenCount\":6,\"candidatesTokenCount\":1}}\n\n" +
+				"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\", world\"}]}}],\"usageMetadata\":{\"promptTokenCount\":6,\"candidatesTokenCount\":3}}\n\n", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.path)
+				}
+				if tt.backend == BackendGemini && r.URL.Query().Get("alt") != "sse" {
+					t.Errorf("expected alt=sse, got %s", r.URL.RawQuery)
+				}
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			endpoint := server.URL
+			if tt.backend == BackendOpenAI {
+				endpoint += tt.path
+			}
+			c := newTestClient(t, Config{Endpoint: endpoint, Backend: tt.backend})
+
+			var deltas []string
+			resp, err := c.Stream(context.Background(), testRequest(), func(d string) error {
+				deltas = append(deltas, d)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			if resp.Text != "Hello, world" || len(deltas) != 2 {
+				t.Errorf("got text %q from deltas %q", resp.Text, deltas)
+			}
+			if resp.Usage.Total() != tt.usage || resp.Usage.Estimated {
+				t.Errorf("usage = %+v, want %d", resp.Usage, tt.usage)
+			}
+		})
+	}
+}
+
+func TestStream_ErrorsKeepPartialText(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"error\":{\"message\":\"quota exceeded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(t, Config{Endpoint: server.URL}).Stream(context.Background(), testRequest(), nil)
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if resp.Text != "partial" || !resp.Usage.Estimated {
+		t.Errorf("expected partial text with estimated usage, got %+v", resp)
+	}
+	// A stream that already started is never retried
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestStream_CallbackErrorStops(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"b\"}}]}\n\n")
+	}))
+	defer server.Close()
+
+	stop := errors.New("client gone")
+	resp, err := newTestClient(t, Config{Endpoint: server.URL}).Stream(context.Background(), testRequest(), func(string) error { return stop })
+	if err != stop || resp.Text != "a" {
+		t.Errorf("got %q, %v", resp.Text, err)
+	}
+}
+
+func TestStream_Empty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(t, Config{Endpoint: server.URL}).Stream(context.Background(), testRequest(), nil)
+	if !errors.Is(err, ErrInvalidResponse) || resp.Text != "" || resp.Usage.Total() != 0 {
+		t.Errorf("got %+v, %v", resp, err)
+	}
+}
+
+func TestParseCustomHeaders(t *testing.T) {
+	headers, err := ParseCustomHeaders(`{"X-Org":"team"}`)
+	if err != nil || headers["X-Org"] != "team" {
+		t.Errorf("got %v, %v", headers, err)
+	}
+	if headers, err := ParseCustomHeaders(""); err != nil || len(headers) != 0 {
+		t.Errorf("got %v, %v", headers, err)
+	}
+	if _, err := ParseCustomHeaders("{"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestIsLocalEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"localhost:11434", "127.0.0.1", "[::1]:8080", "http://localhost:11434/api/chat", "http://127.0.0.2"} {
+		if !IsLocalEndpoint(endpoint) {
+			t.Errorf("IsLocalEndpoint(%q) = false", endpoint)
+		}
+	}
+	for _, endpoint := range []string{"", "api.openai.com", "https://api.openai.com/v1/chat/completions", "http://10.0.0.5:11434"} {
+		if IsLocalEndpoint(endpoint) {
+			t.Errorf("IsLocalEndpoint(%q) = true", endpoint)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package aiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"MrRSS/internal/aiusage"
+)
+
+// Embed returns one embedding vector per text. The OpenAI-compatible backend posts to
+// an /v1/embeddings endpoint; the Ollama backend supports /api/embed and the older
+// /api/embeddings, which takes one text per request. Other backends are not supported.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	if len(texts) == 0 {
+		return nil, Usage{}, nil
+	}
+
+	var vectors [][]float32
+	var usage Usage
+	var err error
+	switch {
+	case c.cfg.Backend == BackendOpenAI:
+		vectors, usage, err = c.embedBatch(ctx, map[string]interface{}{"model": c.cfg.Model, "input": texts}, parseOpenAIEmbeddings)
+	case c.cfg.Backend == BackendOllama && strings.HasSuffix(c.cfg.Endpoint, "/api/embeddings"):
+		for _, text := range texts {
+			var v [][]float32
+			v, _, err = c.embedBatch(ctx, map[string]interface{}{"model": c.cfg.Model, "prompt": text}, parseOllamaEmbedding)
+			if err != nil {
+				break
+			}
+			vectors = append(vectors, v...)
+		}
+	case c.cfg.Backend == BackendOllama:
+		vectors, usage, err = c.embedBatch(ctx, map[string]interface{}{"model": c.cfg.Model, "input": texts}, parseOllamaEmbeddings)
+	default:
+		return nil, Usage{}, &Error{Kind: ErrConfig, Backend: c.cfg.Backend, Message: "embeddings are not supported by this backend"}
+	}
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, Usage{}, &Error{Kind: ErrInvalidResponse, Backend: c.cfg.Backend,
+			Message: fmt.Sprintf("expected %d embeddings, got %d", len(texts), len(vectors))}
+	}
+
+	if usage.PromptTokens == 0 {
+		for _, text := range texts {
+			usage.PromptTokens += aiusage.EstimateTokens(text)
+		}
+		usage.Estimated = true
+	}
+	usage.Model = c.cfg.Model
+	usage.ProfileID = c.cfg.ProfileID
+	c.reportUsage(usage)
+	return vectors, usage, nil
+}
+
+// embedBatch posts one embeddings request and decodes the response with parse.
+func (c *Client) embedBatch(ctx context.Context, body interface{}, parse func([]byte) ([][]float32, Usage, error)) ([][]float32, Usage, error) {
+	var vectors [][]float32
+	var usage Usage
+	err := c.withRetry(ctx, func() error {
+		resp, err := c.post(ctx, c.cfg.Endpoint, body, false)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		if err != nil {
+			return c.transportError(ctx, err)
+		}
+		vectors, usage, err = parse(data)
+		if err != nil {
+			return &Error{Kind: ErrInvalidResponse, Backend: c.cfg.Backend, Err: err}
+		}
+		return nil
+	})
+	return vectors, usage, err
+}
+
+// parseOpenAIEmbeddings decodes an OpenAI embeddings response, ordered by input index.
+func parseOpenAIEmbeddings(body []byte) ([][]float32, Usage, error) {
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int64 `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, Usage{}, err
+	}
+	vectors := make([][]float32, len(result.Data))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(vectors) || len(d.Embedding) == 0 {
+			return nil, Usage{}, errors.New("invalid embedding in response")
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, Usage{PromptTokens: result.Usage.PromptTokens}, nil
+}
+
+// parseOllamaEmbeddings decodes an Ollama /api/embed response.
+func parseOllamaEmbeddings(body []byte) ([][]float32, Usage, error) {
+	var result struct {
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int64       `json:"prompt_eval_count"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, Usage{}, err
+	}
+	return result.Embeddings, Usage{PromptTokens: result.PromptEvalCount}, nil
+}
+
+// parseOllamaEmbedding decodes an Ollama /api/embeddings response with a single vector.
+func parseOllamaEmbedding(body []byte) ([][]float32, Usage, error) {
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, Usage{}, err
+	}
+	if len(result.Embedding) == 0 {
+		return nil, Usage{}, errors.New("empty embedding in response")
+	}
+	return [][]float32{result.Embedding}, Usage{}, nil
+}
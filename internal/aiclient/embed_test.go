@@ -0,0 +1,94 @@
+package aiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmbed_OpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := decodeBody(t, r)
+		if r.URL.Path != "/v1/embeddings" || len(body["input"].([]interface{})) != 2 {
+			t.Errorf("unexpected request %s %v", r.URL.Path, body)
+		}
+		// Results may come back in any order
+		fmt.Fprint(w, `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":9}}`)
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL + "/v1/embeddings"})
+	vectors, usage, err := c.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("unexpected vectors %v", vectors)
+	}
+	if usage.PromptTokens != 9 || usage.Estimated || usage.Model != "test-model" {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestEmbed_Ollama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/api/embed":
+			fmt.Fprint(w, `{"embeddings":[[0.5,0.5],[1,0]],"prompt_eval_count":4}`)
+		case "/api/embeddings":
+			if body["prompt"] == nil {
+				t.Errorf("expected a single prompt, got %v", body)
+			}
+			fmt.Fprint(w, `{"embedding":[0.1,0.2,0.3]}`)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, Config{Endpoint: server.URL + "/api/embed"})
+	if c.Backend() != BackendOllama {
+		t.Fatalf("backend = %s, want ollama", c.Backend())
+	}
+	vectors, usage, err := c.Embed(context.Background(), []string{"a", "b"})
+	if err != nil || len(vectors) != 2 || usage.PromptTokens != 4 {
+		t.Errorf("/api/embed: vectors %v, usage %+v, error %v", vectors, usage, err)
+	}
+
+	c = newTestClient(t, Config{Endpoint: server.URL + "/api/embeddings"})
+	vectors, usage, err = c.Embed(context.Background(), []string{"first text", "second text"})
+	if err != nil || len(vectors) != 2 || len(vectors[1]) != 3 || !usage.Estimated {
+		t.Errorf("/api/embeddings: vectors %v, usage %+v, error %v", vectors, usage, err)
+	}
+}
+
+func TestEmbed_UnsupportedBackend(t *testing.T) {
+	c := newTestClient(t, Config{Endpoint: "https://api.anthropic.com/v1/messages"})
+	if _, _, err := c.Embed(context.Background(), []string{"x"}); !errors.Is(err, ErrConfig) {
+		t.Errorf("expected ErrConfig, got %v", err)
+	}
+}
+
+func TestEmbeddingConfigFromSettings(t *testing.T) {
+	store := &fakeProfileStore{settings: map[string]string{
+		"ai_endpoint":           "https://api.example.com/v1/chat/completions",
+		"ai_api_key":            "global-key",
+		"ai_embedding_endpoint": "https://api.example.com/v1/embeddings",
+	}}
+	cfg, err := EmbeddingConfigFromSettings(store)
+	if err != nil || cfg.APIKey != "global-key" || cfg.Model == "" {
+		t.Errorf("same host: config %+v, error %v", cfg, err)
+	}
+
+	store.settings["ai_embedding_endpoint"] = "https://embeddings.other.com/v1/embeddings"
+	if cfg, _ := EmbeddingConfigFromSettings(store); cfg.APIKey != "" {
+		t.Errorf("the global key must not be sent to another host, got %q", cfg.APIKey)
+	}
+
+	store.settings["ai_embedding_api_key"] = "embedding-key"
+	if cfg, _ := EmbeddingConfigFromSettings(store); cfg.APIKey != "embedding-key" {
+		t.Errorf("expected the embedding key, got %q", cfg.APIKey)
+	}
+}
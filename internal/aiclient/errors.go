@@ -0,0 +1,112 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds. Errors returned by the client match one of these with errors.Is.
+var (
+	ErrConfig          = errors.New("invalid AI configuration")
+	ErrNetwork         = errors.New("AI endpoint unreachable")
+	ErrUnauthorized    = errors.New("AI endpoint rejected the credentials")
+	ErrRateLimited     = errors.New("AI endpoint rate limit exceeded")
+	ErrBadRequest      = errors.New("AI endpoint rejected the request")
+	ErrServer          = errors.New("AI endpoint server error")
+	ErrInvalidResponse = errors.New("invalid response from AI endpoint")
+)
+
+// Error describes a failed AI request.
+type Error struct {
+	Kind       error // One of the Err* kinds above
+	Backend    Backend
+	StatusCode int           // HTTP status, if the endpoint responded
+	Message    string        // Error message from the provider or details
+	RetryAfter time.Duration // Delay requested by the provider, if any
+	Err        error         // Underlying error, if any
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Backend, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	} else if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is the kind of this error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed if sent again.
+func (e *Error) Retryable() bool {
+	return e.Kind == ErrNetwork || e.Kind == ErrRateLimited || e.Kind == ErrServer
+}
+
+// maxErrorMessage bounds provider error messages kept in errors.
+const maxErrorMessage = 300
+
+// statusError converts a non-200 response into an Error.
+func statusError(backend Backend, status int, header http.Header, body []byte) *Error {
+	e := &Error{Backend: backend, StatusCode: status, Message: errorMessage(body)}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		e.Kind = ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+	case status == http.StatusRequestTimeout || status >= 500:
+		e.Kind = ErrServer
+	default:
+		e.Kind = ErrBadRequest
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// errorMessage extracts the message from the error body formats used by the supported providers.
+func errorMessage(body []byte) string {
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var plain string
+		switch {
+		case json.Unmarshal(parsed.Error, &nested) == nil && nested.Message != "":
+			return truncate(nested.Message)
+		case json.Unmarshal(parsed.Error, &plain) == nil && plain != "":
+			return truncate(plain)
+		case parsed.Message != "":
+			return truncate(parsed.Message)
+		}
+	}
+	return truncate(strings.TrimSpace(string(body)))
+}
+
+func truncate(s string) string {
+	if runes := []rune(s); len(runes) > maxErrorMessage {
+		return string(runes[:maxErrorMessage]) + "..."
+	}
+	return s
+}
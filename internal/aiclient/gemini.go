@@ -0,0 +1,169 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// geminiBackend implements the Gemini generateContent API. The endpoint is the API base
+// (e.g. https://generativelanguage.googleapis.com/v1beta); a full model URL is also accepted.
+type geminiBackend struct{}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int64 `json:"promptTokenCount"`
+		CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// text joins the text parts of the first candidate.
+func (r geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+// check returns an error for error and blocked responses.
+func (r geminiResponse) check() error {
+	if r.Error != nil {
+		return &Error{Kind: ErrBadRequest, Backend: BackendGemini, Message: r.Error.Message}
+	}
+	if r.PromptFeedback != nil && r.PromptFeedback.BlockReason != "" {
+		return &Error{Kind: ErrInvalidResponse, Backend: BackendGemini, Message: "prompt blocked: " + r.PromptFeedback.BlockReason}
+	}
+	return nil
+}
+
+func (r geminiResponse) usage() Usage {
+	if r.UsageMetadata == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: r.UsageMetadata.PromptTokenCount, CompletionTokens: r.UsageMetadata.CandidatesTokenCount}
+}
+
+func (geminiBackend) request(cfg Config, req Request, stream bool) (string, interface{}, error) {
+	endpoint, err := geminiURL(cfg.Endpoint, cfg.Model, stream)
+	if err != nil {
+		return "", nil, err
+	}
+
+	system, messages := splitSystem(req.Messages)
+	contents := make([]geminiContent, 0, len(messages))
+	for _, msg := range messages {
+		role := "user"
+		if msg.Role == RoleAssistant {
+			role = "model"
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: []geminiPart{{Text: msg.Content}}})
+	}
+
+	body := map[string]interface{}{"contents": contents}
+	if system != "" {
+		body["systemInstruction"] = geminiContent{Parts: []geminiPart{{Text: system}}}
+	}
+	generation := map[string]interface{}{}
+	if req.Temperature > 0 {
+		generation["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		generation["maxOutputTokens"] = req.MaxTokens
+	}
+	if len(generation) > 0 {
+		body["generationConfig"] = generation
+	}
+	return endpoint, body, nil
+}
+
+func (geminiBackend) authorize(header http.Header, apiKey string) {
+	header.Set("x-goog-api-key", apiKey)
+}
+
+func (geminiBackend) parse(body []byte) (Response, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Response{}, invalidJSON(BackendGemini, err)
+	}
+	if err := resp.check(); err != nil {
+		return Response{}, err
+	}
+	return Response{Text: resp.text(), Usage: resp.usage()}, nil
+}
+
+func (geminiBackend) stream(body io.Reader, emit DeltaFunc) (Usage, error) {
+	var usage Usage
+	err := readSSE(body, func(data string) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return invalidJSON(BackendGemini, err)
+		}
+		if err := chunk.check(); err != nil {
+			return err
+		}
+		// Usage metadata is cumulative, so the last chunk holds the totals
+		if u := chunk.usage(); u.Total() > 0 {
+			usage = u
+		}
+		return emit(chunk.text())
+	})
+	return usage, err
+}
+
+// geminiURL builds the generateContent or streamGenerateContent URL for a model.
+func geminiURL(endpoint, model string, stream bool) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.TrimSuffix(parsed.Path, "/")
+	if idx := strings.Index(path, "/models/"); idx >= 0 {
+		// A full model URL: keep its model unless none is configured
+		if model == "" {
+			model = strings.SplitN(path[idx+len("/models/"):], ":", 2)[0]
+		}
+		path = path[:idx]
+	}
+	if path == "" {
+		path = "/v1beta"
+	}
+	if model == "" {
+		return "", fmt.Errorf("model is required")
+	}
+
+	method := ":generateContent"
+	if stream {
+		method = ":streamGenerateContent"
+		parsed.RawQuery = "alt=sse"
+	} else {
+		parsed.RawQuery = ""
+	}
+	parsed.Path = path + "/models/" + model + method
+	return parsed.String(), nil
+}
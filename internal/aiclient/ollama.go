@@ -0,0 +1,117 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ollamaBackend implements Ollama's /api/chat. Endpoints pointing at /api/generate or at
+// the server root are rewritten to /api/chat.
+type ollamaBackend struct{}
+
+type ollamaChunk struct {
+	Message *struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Response        string `json:"response"` // Set by /api/generate-style proxies
+	Done            bool   `json:"done"`
+	PromptEvalCount int64  `json:"prompt_eval_count"`
+	EvalCount       int64  `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+func (c ollamaChunk) text() string {
+	if c.Message != nil {
+		return c.Message.Content
+	}
+	return c.Response
+}
+
+func (c ollamaChunk) usage() Usage {
+	return Usage{PromptTokens: c.PromptEvalCount, CompletionTokens: c.EvalCount}
+}
+
+func (ollamaBackend) request(cfg Config, req Request, stream bool) (string, interface{}, error) {
+	endpoint, err := ollamaChatURL(cfg.Endpoint)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body := map[string]interface{}{
+		"model":    cfg.Model,
+		"messages": req.Messages,
+		"stream":   stream,
+	}
+	options := map[string]interface{}{}
+	if req.Temperature > 0 {
+		options["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	return endpoint, body, nil
+}
+
+func (ollamaBackend) authorize(header http.Header, apiKey string) {
+	// Ollama ignores authentication, but reverse proxies in front of it may not
+	header.Set("Authorization", "Bearer "+apiKey)
+}
+
+func (ollamaBackend) parse(body []byte) (Response, error) {
+	var chunk ollamaChunk
+	if err := json.Unmarshal(body, &chunk); err != nil {
+		return Response{}, invalidJSON(BackendOllama, err)
+	}
+	if chunk.Error != "" {
+		return Response{}, &Error{Kind: ErrBadRequest, Backend: BackendOllama, Message: chunk.Error}
+	}
+	return Response{Text: chunk.text(), Usage: chunk.usage()}, nil
+}
+
+func (ollamaBackend) stream(body io.Reader, emit DeltaFunc) (Usage, error) {
+	var usage Usage
+	err := readLines(body, func(line string) error {
+		if line == "" {
+			return nil
+		}
+		var chunk ollamaChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return invalidJSON(BackendOllama, err)
+		}
+		if chunk.Error != "" {
+			return &Error{Kind: ErrServer, Backend: BackendOllama, Message: chunk.Error}
+		}
+		if err := emit(chunk.text()); err != nil {
+			return err
+		}
+		if chunk.Done {
+			usage = chunk.usage()
+			return errStopStream
+		}
+		return nil
+	})
+	return usage, err
+}
+
+// ollamaChatURL returns the /api/chat URL for an Ollama endpoint.
+func ollamaChatURL(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(parsed.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/api/generate"):
+		path = strings.TrimSuffix(path, "/generate") + "/chat"
+	case !strings.HasSuffix(path, "/api/chat"):
+		path += "/api/chat"
+	}
+	parsed.Path = path
+	return parsed.String(), nil
+}
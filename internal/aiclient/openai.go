@@ -0,0 +1,101 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// openAIBackend implements OpenAI-compatible chat completions.
+// The endpoint is the full URL, e.g. https://api.openai.com/v1/chat/completions.
+type openAIBackend struct{}
+
+type openAIUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+}
+
+func (openAIBackend) request(cfg Config, req Request, stream bool) (string, interface{}, error) {
+	body := map[string]interface{}{
+		"model":    cfg.Model,
+		"messages": req.Messages,
+	}
+	if req.Temperature > 0 {
+		body["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	return cfg.Endpoint, body, nil
+}
+
+func (openAIBackend) authorize(header http.Header, apiKey string) {
+	header.Set("Authorization", "Bearer "+apiKey)
+}
+
+func (openAIBackend) parse(body []byte) (Response, error) {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Response{}, invalidJSON(BackendOpenAI, err)
+	}
+	if resp.Error != nil {
+		return Response{}, &Error{Kind: ErrBadRequest, Backend: BackendOpenAI, Message: resp.Error.Message}
+	}
+
+	var result Response
+	if len(resp.Choices) > 0 {
+		result.Text = resp.Choices[0].Message.Content
+	}
+	if resp.Usage != nil {
+		result.Usage = Usage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	}
+	return result, nil
+}
+
+func (openAIBackend) stream(body io.Reader, emit DeltaFunc) (Usage, error) {
+	var usage Usage
+	err := readSSE(body, func(data string) error {
+		if data == "[DONE]" {
+			return errStopStream
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+			Error *openAIError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return invalidJSON(BackendOpenAI, err)
+		}
+		if chunk.Error != nil {
+			return &Error{Kind: ErrServer, Backend: BackendOpenAI, Message: chunk.Error.Message}
+		}
+		if chunk.Usage != nil {
+			usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		if len(chunk.Choices) > 0 {
+			return emit(chunk.Choices[0].Delta.Content)
+		}
+		return nil
+	})
+	return usage, err
+}
@@ -0,0 +1,100 @@
+package aiclient
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// Feature identifies an AI feature that can select its own profile.
+type Feature string
+
+// Features with a profile setting
+const (
+	FeatureTranslation Feature = "translation"
+	FeatureSummary     Feature = "summary"
+	FeatureChat        Feature = "chat"
+	FeatureTagging     Feature = "tagging"
+)
+
+// ProfileStore is a settings provider that also stores AI profiles.
+type ProfileStore interface {
+	SettingsProvider
+	GetAIProfile(id int64) (*models.AIProfile, error)
+	AddAIProfileUsage(id int64, tokens int64) error
+}
+
+// ProfileSettingKey returns the setting that holds the profile ID selected for a feature.
+func ProfileSettingKey(feature Feature) string {
+	return "ai_" + string(feature) + "_profile"
+}
+
+// ConfigFromProfile builds a client configuration from an AI profile.
+func ConfigFromProfile(profile *models.AIProfile) (Config, error) {
+	headers, err := ParseCustomHeaders(profile.CustomHeaders)
+	if err != nil {
+		return Config{}, &Error{Kind: ErrConfig, Backend: Backend(profile.Backend), Err: err}
+	}
+	return Config{
+		Backend:       Backend(profile.Backend),
+		Endpoint:      profile.Endpoint,
+		APIKey:        profile.APIKey,
+		Model:         profile.Model,
+		CustomHeaders: headers,
+		ProfileID:     profile.ID,
+		Temperature:   profile.Temperature,
+		MaxTokens:     profile.MaxTokens,
+		ContextWindow: profile.ContextWindow,
+	}, nil
+}
+
+// ConfigForFeature builds the client configuration for a feature. If the feature selects
+// an AI profile, the profile is used and its usage counter is updated after each request;
+// otherwise the global AI settings are used.
+func ConfigForFeature(settings SettingsProvider, feature Feature) (Config, error) {
+	value, _ := settings.GetSetting(ProfileSettingKey(feature))
+	store, ok := settings.(ProfileStore)
+	if strings.TrimSpace(value) == "" || !ok {
+		return ConfigFromSettings(settings)
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return Config{}, &Error{Kind: ErrConfig, Message: fmt.Sprintf("invalid AI profile %q for %s", value, feature)}
+	}
+	profile, err := store.GetAIProfile(id)
+	if err != nil {
+		return Config{}, &Error{Kind: ErrConfig, Message: fmt.Sprintf("AI profile %d for %s is unavailable", id, feature), Err: err}
+	}
+
+	cfg, err := ConfigFromProfile(profile)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.OnUsage = func(usage Usage) {
+		if err := store.AddAIProfileUsage(id, usage.Total()); err != nil {
+			log.Printf("Warning: failed to track usage of AI profile %d: %v", id, err)
+		}
+	}
+	return cfg, nil
+}
+
+// NewForFeature creates a client for a feature from its selected AI profile or the
+// global AI settings, using the global proxy settings.
+func NewForFeature(settings SettingsProvider, feature Feature, timeout time.Duration) (*Client, error) {
+	cfg, err := ConfigForFeature(settings, feature)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := NewHTTPClient(settings, timeout)
+	if err != nil {
+		// Fallback to a direct connection if the proxy settings are invalid
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	return New(cfg, httpClient)
+}
@@ -0,0 +1,120 @@
+package aiclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+// fakeProfileStore is an in-memory ProfileStore.
+type fakeProfileStore struct {
+	settings map[string]string
+	profiles map[int64]*models.AIProfile
+	usage    map[int64]int64
+}
+
+func (s *fakeProfileStore) GetSetting(key string) (string, error) {
+	return s.settings[key], nil
+}
+
+func (s *fakeProfileStore) GetEncryptedSetting(key string) (string, error) {
+	return s.settings[key], nil
+}
+
+func (s *fakeProfileStore) GetAIProfile(id int64) (*models.AIProfile, error) {
+	if p, ok := s.profiles[id]; ok {
+		return p, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *fakeProfileStore) AddAIProfileUsage(id int64, tokens int64) error {
+	s.usage[id] += tokens
+	return nil
+}
+
+func TestConfigForFeature(t *testing.T) {
+	store := &fakeProfileStore{
+		settings: map[string]string{
+			"ai_endpoint":            "https://api.example.com/v1/chat/completions",
+			"ai_model":               "global-model",
+			"ai_api_key":             "global-key",
+			"ai_translation_profile": "7",
+			"ai_chat_profile":        "99",
+		},
+		profiles: map[int64]*models.AIProfile{
+			7: {ID: 7, Name: "local", Endpoint: "http://localhost:11434/api/chat", Model: "llama3", ContextWindow: 4096},
+		},
+		usage: map[int64]int64{},
+	}
+
+	cfg, err := ConfigForFeature(store, FeatureTranslation)
+	if err != nil {
+		t.Fatalf("ConfigForFeature(translation) error = %v", err)
+	}
+	if cfg.ProfileID != 7 || cfg.Model != "llama3" || cfg.APIKey != "" || cfg.ContextWindow != 4096 || cfg.OnUsage == nil {
+		t.Errorf("unexpected profile config %+v", cfg)
+	}
+
+	cfg, err = ConfigForFeature(store, FeatureSummary)
+	if err != nil {
+		t.Fatalf("ConfigForFeature(summary) error = %v", err)
+	}
+	if cfg.ProfileID != 0 || cfg.Model != "global-model" || cfg.APIKey != "global-key" {
+		t.Errorf("expected global settings, got %+v", cfg)
+	}
+
+	if _, err := ConfigForFeature(store, FeatureChat); !errors.Is(err, ErrConfig) {
+		t.Errorf("expected ErrConfig for a missing profile, got %v", err)
+	}
+}
+
+func TestProfileOverridesAndUsage(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = decodeBody(t, r)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":20,"completion_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	store := &fakeProfileStore{
+		settings: map[string]string{"ai_summary_profile": "3"},
+		profiles: map[int64]*models.AIProfile{
+			3: {ID: 3, Endpoint: server.URL + "/v1/chat/completions", Model: "mid", Temperature: 0.9, MaxTokens: 512},
+		},
+		usage: map[int64]int64{},
+	}
+
+	c, err := NewForFeature(store, FeatureSummary, 0)
+	if err != nil {
+		t.Fatalf("NewForFeature() error = %v", err)
+	}
+	if _, err := c.Complete(context.Background(), testRequest()); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if body["temperature"] != 0.9 || body["max_tokens"] != float64(512) || body["model"] != "mid" {
+		t.Errorf("profile values not applied: %v", body)
+	}
+	if store.usage[3] != 23 {
+		t.Errorf("profile usage = %d, want 23", store.usage[3])
+	}
+}
+
+func TestConfigKey(t *testing.T) {
+	a := Config{Endpoint: "https://a", Model: "m", CustomHeaders: map[string]string{"X": "1"}}
+	b := a
+	b.OnUsage = func(Usage) {}
+	if a.Key() != b.Key() {
+		t.Error("usage hook should not change the key")
+	}
+	b.MaxTokens = 10
+	if a.Key() == b.Key() {
+		t.Error("max tokens should change the key")
+	}
+}
@@ -0,0 +1,167 @@
+package aiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"MrRSS/internal/config"
+	"MrRSS/internal/utils"
+)
+
+// SettingsProvider is the subset of the database used to read AI and proxy settings.
+type SettingsProvider interface {
+	GetSetting(key string) (string, error)
+	GetEncryptedSetting(key string) (string, error)
+}
+
+// ConfigFromSettings builds a client configuration from the global AI settings.
+func ConfigFromSettings(settings SettingsProvider) (Config, error) {
+	defaults := config.Get()
+
+	backend, _ := settings.GetSetting("ai_backend")
+	endpoint, _ := settings.GetSetting("ai_endpoint")
+	model, _ := settings.GetSetting("ai_model")
+	apiKey, _ := settings.GetEncryptedSetting("ai_api_key")
+	customHeaders, _ := settings.GetSetting("ai_custom_headers")
+
+	if endpoint == "" {
+		endpoint = defaults.AIEndpoint
+	}
+	if model == "" {
+		model = defaults.AIModel
+	}
+	headers, err := ParseCustomHeaders(customHeaders)
+	if err != nil {
+		return Config{}, &Error{Kind: ErrConfig, Backend: Backend(backend), Err: err}
+	}
+
+	return Config{
+		Backend:       Backend(backend),
+		Endpoint:      endpoint,
+		APIKey:        apiKey,
+		Model:         model,
+		CustomHeaders: headers,
+	}, nil
+}
+
+// NewFromSettings creates a client from the global AI and proxy settings.
+func NewFromSettings(settings SettingsProvider, timeout time.Duration) (*Client, error) {
+	cfg, err := ConfigFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := NewHTTPClient(settings, timeout)
+	if err != nil {
+		// Fallback to a direct connection if the proxy settings are invalid
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	return New(cfg, httpClient)
+}
+
+// EmbeddingConfigFromSettings builds the configuration of the embeddings endpoint. Without
+// its own API key it uses the global AI key, but only for an endpoint on the same host.
+func EmbeddingConfigFromSettings(settings SettingsProvider) (Config, error) {
+	defaults := config.Get()
+
+	endpoint, _ := settings.GetSetting("ai_embedding_endpoint")
+	model, _ := settings.GetSetting("ai_embedding_model")
+	apiKey, _ := settings.GetEncryptedSetting("ai_embedding_api_key")
+
+	if endpoint == "" {
+		endpoint = defaults.AIEmbeddingEndpoint
+	}
+	if model == "" {
+		model = defaults.AIEmbeddingModel
+	}
+	if apiKey == "" {
+		aiEndpoint, _ := settings.GetSetting("ai_endpoint")
+		if aiEndpoint == "" {
+			aiEndpoint = defaults.AIEndpoint
+		}
+		if sameHost(endpoint, aiEndpoint) {
+			apiKey, _ = settings.GetEncryptedSetting("ai_api_key")
+		}
+	}
+
+	return Config{Endpoint: endpoint, APIKey: apiKey, Model: model}, nil
+}
+
+// NewEmbeddingClient creates a client for the embeddings endpoint, using the global proxy settings.
+func NewEmbeddingClient(settings SettingsProvider, timeout time.Duration) (*Client, error) {
+	cfg, err := EmbeddingConfigFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := NewHTTPClient(settings, timeout)
+	if err != nil {
+		// Fallback to a direct connection if the proxy settings are invalid
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	return New(cfg, httpClient)
+}
+
+// sameHost reports whether two endpoint URLs point to the same host.
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(strings.TrimSpace(a))
+	ub, errB := url.Parse(strings.TrimSpace(b))
+	return errA == nil && errB == nil && ua.Host != "" && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// NewHTTPClient creates an HTTP client that uses the global proxy settings if enabled.
+// settings may be nil, in which case no proxy is used.
+func NewHTTPClient(settings SettingsProvider, timeout time.Duration) (*http.Client, error) {
+	var proxyURL string
+	if settings != nil {
+		if proxyEnabled, _ := settings.GetSetting("proxy_enabled"); proxyEnabled == "true" {
+			proxyType, _ := settings.GetSetting("proxy_type")
+			proxyHost, _ := settings.GetSetting("proxy_host")
+			proxyPort, _ := settings.GetSetting("proxy_port")
+			proxyUsername, _ := settings.GetEncryptedSetting("proxy_username")
+			proxyPassword, _ := settings.GetEncryptedSetting("proxy_password")
+			proxyURL = utils.BuildProxyURL(proxyType, proxyHost, proxyPort, proxyUsername, proxyPassword)
+		}
+	}
+	return utils.CreateHTTPClient(proxyURL, timeout)
+}
+
+// ParseCustomHeaders parses the JSON object of extra request headers stored in ai_custom_headers.
+func ParseCustomHeaders(headersJSON string) (map[string]string, error) {
+	if strings.TrimSpace(headersJSON) == "" {
+		return map[string]string{}, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+		return nil, fmt.Errorf("failed to parse custom headers JSON: %w", err)
+	}
+	return headers, nil
+}
+
+// IsLocalEndpoint reports whether an endpoint URL or host points to the local machine,
+// where plain HTTP and missing API keys are allowed (e.g. Ollama).
+func IsLocalEndpoint(endpoint string) bool {
+	host := endpoint
+	if strings.Contains(endpoint, "://") {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return false
+		}
+		host = parsed.Host
+	}
+
+	// Remove port if present, keeping IPv6 addresses like [::1]:8080 intact
+	if idx := strings.LastIndex(host, ":"); idx != -1 && !strings.Contains(host[idx:], "]") {
+		host = host[:idx]
+	}
+	host = strings.Trim(host, "[]")
+
+	return host == "localhost" ||
+		host == "127.0.0.1" ||
+		host == "::1" ||
+		strings.HasPrefix(host, "127.") ||
+		host == "0.0.0.0"
+}
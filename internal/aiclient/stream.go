@@ -0,0 +1,41 @@
+package aiclient
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxLineSize bounds a single streamed line.
+const maxLineSize = 1024 * 1024
+
+// errStopStream ends reading a stream early without an error.
+var errStopStream = errors.New("stop stream")
+
+// readSSE calls fn with the payload of each Server-Sent Event data line. Event names,
+// comments and other fields are ignored since every provider repeats the type in the payload.
+func readSSE(body io.Reader, fn func(data string) error) error {
+	return readLines(body, func(line string) error {
+		if !strings.HasPrefix(line, "data:") {
+			return nil
+		}
+		return fn(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+	})
+}
+
+// readLines calls fn for each line of body until it returns an error.
+// errStopStream stops reading without an error.
+func readLines(body io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if err := fn(strings.TrimSpace(scanner.Text())); err != nil {
+			if errors.Is(err, errStopStream) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,53 @@
+// Package aistream relays streamed AI responses to HTTP clients as Server-Sent Events.
+package aistream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// EventWriter writes Server-Sent Events to an HTTP response.
+type EventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewEventWriter prepares w for Server-Sent Events. It returns false if the
+// response writer does not support flushing.
+func NewEventWriter(w http.ResponseWriter) (*EventWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &EventWriter{w: w, flusher: flusher}, true
+}
+
+// Send writes one event with a JSON payload and flushes it to the client.
+func (e *EventWriter) Send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+// Delta sends a "delta" event carrying a piece of generated text.
+func (e *EventWriter) Delta(content string) error {
+	return e.Send("delta", map[string]string{"content": content})
+}
+
+// Error sends an "error" event.
+func (e *EventWriter) Error(message string) error {
+	return e.Send("error", map[string]string{"error": message})
+}
@@ -0,0 +1,24 @@
+package aistream
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventWriter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	events, ok := NewEventWriter(rr)
+	if !ok {
+		t.Fatal("recorder should support flushing")
+	}
+	events.Delta("Hi")
+	events.Error("boom")
+
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	want := "event: delta\ndata: {\"content\":\"Hi\"}\n\nevent: error\ndata: {\"error\":\"boom\"}\n\n"
+	if rr.Body.String() != want {
+		t.Errorf("got body %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,131 @@
+package aiusage
+
+import (
+	"log"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// Features recorded in the usage ledger
+const (
+	FeatureTranslate = "translate"
+	FeatureSummary   = "summary"
+	FeatureChat      = "chat"
+	FeatureEmbedding = "embedding"
+	FeatureTagging   = "tagging"
+	FeatureOther     = "other"
+)
+
+// Ledger is implemented by settings providers that also store the usage ledger.
+// Without it, only the running ai_usage_tokens counter is kept.
+type Ledger interface {
+	AddAIUsageRecord(rec *models.AIUsageRecord) error
+	GetAIUsageTokensSince(since time.Time) (int64, error)
+}
+
+// Limiter is the part of Tracker that AI features use to respect the usage limits and
+// record their requests.
+type Limiter interface {
+	IsLimitReached() bool
+	WaitForRateLimit()
+	Record(rec models.AIUsageRecord) error
+}
+
+// Record adds the tokens of one AI request to the usage counter and appends the
+// request to the usage ledger with its cost from the price table.
+func (t *Tracker) Record(rec models.AIUsageRecord) error {
+	tokens := rec.PromptTokens + rec.CompletionTokens
+	if tokens <= 0 {
+		return nil
+	}
+	if err := t.AddUsage(tokens); err != nil {
+		return err
+	}
+
+	ledger, ok := t.settings.(Ledger)
+	if !ok {
+		return nil
+	}
+	if rec.Feature == "" {
+		rec.Feature = FeatureOther
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	rec.Cost = t.GetPriceTable().Cost(rec.Model, rec.PromptTokens, rec.CompletionTokens)
+	return ledger.AddAIUsageRecord(&rec)
+}
+
+// GetDailyUsage returns the tokens used since local midnight.
+func (t *Tracker) GetDailyUsage() (int64, error) {
+	return t.usageSince(StartOfDay(time.Now()))
+}
+
+// GetMonthlyUsage returns the tokens used since the start of the local month.
+func (t *Tracker) GetMonthlyUsage() (int64, error) {
+	return t.usageSince(StartOfMonth(time.Now()))
+}
+
+// GetDailyLimit returns the configured daily token limit (0 = unlimited).
+func (t *Tracker) GetDailyLimit() (int64, error) {
+	return t.getLimit("ai_usage_daily_limit")
+}
+
+// GetMonthlyLimit returns the configured monthly token limit (0 = unlimited).
+func (t *Tracker) GetMonthlyLimit() (int64, error) {
+	return t.getLimit("ai_usage_monthly_limit")
+}
+
+// GetPriceTable returns the configured price table. An invalid table is logged and ignored.
+func (t *Tracker) GetPriceTable() PriceTable {
+	value, _ := t.settings.GetSetting("ai_price_table")
+	table, err := ParsePriceTable(value)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid AI price table: %v", err)
+		return nil
+	}
+	return table
+}
+
+// isPeriodLimitReached reports whether the daily or monthly limit has been reached.
+func (t *Tracker) isPeriodLimitReached() bool {
+	periods := []struct {
+		limit func() (int64, error)
+		usage func() (int64, error)
+	}{
+		{t.GetDailyLimit, t.GetDailyUsage},
+		{t.GetMonthlyLimit, t.GetMonthlyUsage},
+	}
+	for _, p := range periods {
+		limit, err := p.limit()
+		if err != nil || limit <= 0 {
+			continue
+		}
+		if usage, err := p.usage(); err == nil && usage >= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// usageSince returns the tokens recorded in the ledger since a time, or 0 without a ledger.
+func (t *Tracker) usageSince(since time.Time) (int64, error) {
+	ledger, ok := t.settings.(Ledger)
+	if !ok {
+		return 0, nil
+	}
+	return ledger.GetAIUsageTokensSince(since)
+}
+
+// StartOfDay returns midnight of the day containing tm, in tm's location.
+func StartOfDay(tm time.Time) time.Time {
+	y, m, d := tm.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, tm.Location())
+}
+
+// StartOfMonth returns midnight of the first day of the month containing tm, in tm's location.
+func StartOfMonth(tm time.Time) time.Time {
+	y, m, _ := tm.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, tm.Location())
+}
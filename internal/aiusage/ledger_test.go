@@ -0,0 +1,149 @@
+package aiusage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// fakeStore is an in-memory settings provider with a usage ledger.
+type fakeStore struct {
+	settings map[string]string
+	records  []models.AIUsageRecord
+}
+
+func (s *fakeStore) GetSetting(key string) (string, error) { return s.settings[key], nil }
+
+func (s *fakeStore) SetSetting(key, value string) error {
+	s.settings[key] = value
+	return nil
+}
+
+func (s *fakeStore) AddAIUsageRecord(rec *models.AIUsageRecord) error {
+	s.records = append(s.records, *rec)
+	return nil
+}
+
+func (s *fakeStore) GetAIUsageTokensSince(since time.Time) (int64, error) {
+	var total int64
+	for _, r := range s.records {
+		if !r.CreatedAt.Before(since) {
+			total += r.PromptTokens + r.CompletionTokens
+		}
+	}
+	return total, nil
+}
+
+func TestRecord_CostAndLedger(t *testing.T) {
+	store := &fakeStore{settings: map[string]string{
+		"ai_price_table": `{"gpt-4o": {"prompt": 2.5, "completion": 10}, "GPT-4o-mini": {"prompt": 0.15, "completion": 0.6}}`,
+	}}
+	tracker := NewTracker(store)
+
+	if err := tracker.Record(models.AIUsageRecord{Model: "gpt-4o-mini", PromptTokens: 1000000, CompletionTokens: 500000, ArticleID: 9}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := tracker.Record(models.AIUsageRecord{Feature: FeatureChat, Model: "gpt-4o-2024-08-06", PromptTokens: 1000, CompletionTokens: 100}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := tracker.Record(models.AIUsageRecord{Feature: FeatureChat}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("expected 2 ledger records, got %d", len(store.records))
+	}
+	first, second := store.records[0], store.records[1]
+	if first.Feature != FeatureOther || first.ArticleID != 9 || math.Abs(first.Cost-0.45) > 1e-9 {
+		t.Errorf("unexpected first record %+v", first)
+	}
+	// Priced by the "gpt-4o" prefix
+	if math.Abs(second.Cost-0.0035) > 1e-9 {
+		t.Errorf("second cost = %v, want 0.0035", second.Cost)
+	}
+	if usage, _ := tracker.GetCurrentUsage(); usage != 1501100 {
+		t.Errorf("counter = %d, want 1501100", usage)
+	}
+}
+
+func TestIsLimitReached_PeriodLimits(t *testing.T) {
+	now := time.Now()
+	store := &fakeStore{settings: map[string]string{"ai_usage_daily_limit": "1000"}}
+	tracker := NewTracker(store)
+
+	// Yesterday's usage does not count towards today's limit
+	store.records = append(store.records, models.AIUsageRecord{CreatedAt: StartOfDay(now).Add(-time.Hour), PromptTokens: 5000})
+	if tracker.IsLimitReached() {
+		t.Fatal("daily limit should reset at midnight")
+	}
+
+	if err := tracker.Record(models.AIUsageRecord{PromptTokens: 900, CompletionTokens: 100}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if !tracker.IsLimitReached() {
+		t.Error("expected daily limit to be reached")
+	}
+
+	store.settings["ai_usage_daily_limit"] = "0"
+	store.settings["ai_usage_monthly_limit"] = "100000"
+	if tracker.IsLimitReached() {
+		t.Error("monthly limit should not be reached")
+	}
+}
+
+func TestPriceTable(t *testing.T) {
+	if _, err := ParsePriceTable(`{"x": {"prompt": -1}}`); err == nil {
+		t.Error("expected an error for a negative price")
+	}
+	if _, err := ParsePriceTable(`not json`); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+	table, err := ParsePriceTable(`{"claude": {"prompt": 1, "completion": 1}, "claude-3-5-haiku": {"prompt": 0.8, "completion": 4}}`)
+	if err != nil {
+		t.Fatalf("ParsePriceTable() error = %v", err)
+	}
+	if price, _ := table.Lookup("claude-3-5-haiku-latest"); price.Prompt != 0.8 {
+		t.Errorf("expected the longest prefix to win, got %+v", price)
+	}
+	if cost := table.Cost("llama3", 1000, 1000); cost != 0 {
+		t.Errorf("unknown model cost = %v", cost)
+	}
+}
+
+func TestBuildStats(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 3)
+	records := []models.AIUsageRecord{
+		// 2026-03-01 01:00 local time, stored in UTC the previous day
+		{CreatedAt: time.Date(2026, 2, 28, 17, 0, 0, 0, time.UTC), Feature: FeatureTranslate, Model: "small", PromptTokens: 10, CompletionTokens: 5, Cost: 0.1},
+		{CreatedAt: time.Date(2026, 3, 2, 12, 0, 0, 0, loc), Feature: FeatureChat, Model: "large", ProfileID: 2, PromptTokens: 100, CompletionTokens: 50, Cost: 1},
+		{CreatedAt: time.Date(2026, 3, 2, 13, 0, 0, 0, loc), Feature: FeatureChat, Model: "large", ProfileID: 2, PromptTokens: 100, CompletionTokens: 50, Cost: 1},
+		// Outside the range
+		{CreatedAt: to, Feature: FeatureSummary, PromptTokens: 1000},
+	}
+
+	stats := BuildStats(records, from, to, IntervalDay)
+	if len(stats.Series) != 3 || stats.Series[0].Period != "2026-03-01" || stats.Series[2].Period != "2026-03-03" {
+		t.Fatalf("unexpected series %+v", stats.Series)
+	}
+	if stats.Series[0].TotalTokens != 15 || stats.Series[1].Requests != 2 || stats.Series[2].Requests != 0 {
+		t.Errorf("unexpected series totals %+v", stats.Series)
+	}
+	if stats.Totals.TotalTokens != 315 || math.Abs(stats.Totals.Cost-2.1) > 1e-9 {
+		t.Errorf("unexpected totals %+v", stats.Totals)
+	}
+	if len(stats.ByFeature) != 2 || stats.ByFeature[0].Key != FeatureChat {
+		t.Errorf("unexpected feature breakdown %+v", stats.ByFeature)
+	}
+	if len(stats.ByProfile) != 2 || stats.ByProfile[0].Key != "2" || stats.ByProfile[1].Key != "0" {
+		t.Errorf("unexpected profile breakdown %+v", stats.ByProfile)
+	}
+
+	monthly := BuildStats(records, from, to, IntervalMonth)
+	if len(monthly.Series) != 1 || monthly.Series[0].Period != "2026-03" || monthly.Series[0].Requests != 3 {
+		t.Errorf("unexpected monthly series %+v", monthly.Series)
+	}
+}
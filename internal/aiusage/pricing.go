@@ -0,0 +1,68 @@
+package aiusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Price is the cost of one million prompt and completion tokens.
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// PriceTable maps model names to prices. A model without an exact entry uses the
+// longest entry that is a prefix of its name, so "gpt-4o" also prices "gpt-4o-2024-08-06".
+type PriceTable map[string]Price
+
+// ParsePriceTable parses the JSON object stored in ai_price_table, e.g.
+// {"gpt-4o-mini": {"prompt": 0.15, "completion": 0.6}}.
+func ParsePriceTable(value string) (PriceTable, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var table PriceTable
+	if err := json.Unmarshal([]byte(value), &table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table JSON: %w", err)
+	}
+	normalized := make(PriceTable, len(table))
+	for model, price := range table {
+		if price.Prompt < 0 || price.Completion < 0 {
+			return nil, fmt.Errorf("negative price for model %q", model)
+		}
+		normalized[strings.ToLower(strings.TrimSpace(model))] = price
+	}
+	return normalized, nil
+}
+
+// Lookup returns the price of a model.
+func (p PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return Price{}, false
+	}
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for name := range p {
+		if name != "" && strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return p[best], true
+}
+
+// Cost returns the cost of a request, or 0 if the model has no price.
+func (p PriceTable) Cost(model string, promptTokens, completionTokens int64) float64 {
+	price, ok := p.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+}
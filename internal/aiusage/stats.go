@@ -0,0 +1,143 @@
+package aiusage
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// Time series intervals
+const (
+	IntervalDay   = "day"
+	IntervalMonth = "month"
+)
+
+// Totals aggregates the requests in a period or group.
+type Totals struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// SeriesPoint is the usage in one day ("2006-01-02") or month ("2006-01").
+type SeriesPoint struct {
+	Period string `json:"period"`
+	Totals
+}
+
+// BreakdownEntry is the usage of one feature, model or profile.
+type BreakdownEntry struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Totals
+}
+
+// Stats is the usage over a time range as a time series and breakdowns.
+type Stats struct {
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Interval  string           `json:"interval"`
+	Totals    Totals           `json:"totals"`
+	Series    []SeriesPoint    `json:"series"`
+	ByFeature []BreakdownEntry `json:"by_feature"`
+	ByModel   []BreakdownEntry `json:"by_model"`
+	ByProfile []BreakdownEntry `json:"by_profile"` // Keyed by profile ID; "0" is the global AI settings
+}
+
+// BuildStats aggregates ledger records in [from, to). Series periods are in from's
+// location and include periods without usage.
+func BuildStats(records []models.AIUsageRecord, from, to time.Time, interval string) Stats {
+	if interval != IntervalMonth {
+		interval = IntervalDay
+	}
+	loc := from.Location()
+	stats := Stats{From: from, To: to, Interval: interval, Series: []SeriesPoint{}}
+
+	index := make(map[string]int)
+	for start := periodStart(from, interval); start.Before(to); start = nextPeriod(start, interval) {
+		label := periodLabel(start, interval)
+		index[label] = len(stats.Series)
+		stats.Series = append(stats.Series, SeriesPoint{Period: label})
+	}
+
+	features := make(map[string]*Totals)
+	modelTotals := make(map[string]*Totals)
+	profiles := make(map[string]*Totals)
+	for _, rec := range records {
+		if rec.CreatedAt.Before(from) || !rec.CreatedAt.Before(to) {
+			continue
+		}
+		stats.Totals.add(rec)
+		if i, ok := index[periodLabel(rec.CreatedAt.In(loc), interval)]; ok {
+			stats.Series[i].add(rec)
+		}
+		group(features, rec.Feature).add(rec)
+		group(modelTotals, rec.Model).add(rec)
+		group(profiles, strconv.FormatInt(rec.ProfileID, 10)).add(rec)
+	}
+
+	stats.ByFeature = breakdown(features)
+	stats.ByModel = breakdown(modelTotals)
+	stats.ByProfile = breakdown(profiles)
+	return stats
+}
+
+// add adds a record to the totals.
+func (t *Totals) add(rec models.AIUsageRecord) {
+	t.Requests++
+	t.PromptTokens += rec.PromptTokens
+	t.CompletionTokens += rec.CompletionTokens
+	t.TotalTokens += rec.PromptTokens + rec.CompletionTokens
+	t.Cost += rec.Cost
+}
+
+// group returns the totals for a key, creating them if needed.
+func group(groups map[string]*Totals, key string) *Totals {
+	if groups[key] == nil {
+		groups[key] = &Totals{}
+	}
+	return groups[key]
+}
+
+// breakdown converts grouped totals to entries sorted by total tokens, largest first.
+func breakdown(groups map[string]*Totals) []BreakdownEntry {
+	entries := make([]BreakdownEntry, 0, len(groups))
+	for key, totals := range groups {
+		entries = append(entries, BreakdownEntry{Key: key, Totals: *totals})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TotalTokens != entries[j].TotalTokens {
+			return entries[i].TotalTokens > entries[j].TotalTokens
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// periodStart returns the start of the period containing tm.
+func periodStart(tm time.Time, interval string) time.Time {
+	if interval == IntervalMonth {
+		return StartOfMonth(tm)
+	}
+	return StartOfDay(tm)
+}
+
+// nextPeriod returns the start of the period after the one starting at start.
+func nextPeriod(start time.Time, interval string) time.Time {
+	if interval == IntervalMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// periodLabel formats the period containing tm.
+func periodLabel(tm time.Time, interval string) string {
+	if interval == IntervalMonth {
+		return tm.Format("2006-01")
+	}
+	return tm.Format("2006-01-02")
+}
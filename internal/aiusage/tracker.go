@@ -82,7 +82,12 @@ func (t *Tracker) GetCurrentUsage() (int64, error) {
 
 // GetUsageLimit returns the configured usage limit (0 = unlimited).
 func (t *Tracker) GetUsageLimit() (int64, error) {
-	limitStr, err := t.settings.GetSetting("ai_usage_limit")
+	return t.getLimit("ai_usage_limit")
+}
+
+// getLimit reads a token limit setting (0 = unlimited).
+func (t *Tracker) getLimit(key string) (int64, error) {
+	limitStr, err := t.settings.GetSetting(key)
 	if err != nil {
 		return 0, err
 	}
@@ -92,7 +97,7 @@ func (t *Tracker) GetUsageLimit() (int64, error) {
 	return strconv.ParseInt(limitStr, 10, 64)
 }
 
-// IsLimitReached checks if the usage limit has been reached.
+// IsLimitReached checks if the total, daily or monthly usage limit has been reached.
 func (t *Tracker) IsLimitReached() bool {
 	usage, err := t.GetCurrentUsage()
 	if err != nil {
@@ -105,11 +110,11 @@ func (t *Tracker) IsLimitReached() bool {
 	}
 
 	// 0 means unlimited
-	if limit == 0 {
-		return false
+	if limit > 0 && usage >= limit {
+		return true
 	}
 
-	return usage >= limit
+	return t.isPeriodLimitReached()
 }
 
 // AddUsage adds tokens to the usage counter.
@@ -128,7 +133,8 @@ func (t *Tracker) AddUsage(tokens int64) error {
 	return t.settings.SetSetting("ai_usage_tokens", strconv.FormatInt(newUsage, 10))
 }
 
-// ResetUsage resets the usage counter to zero.
+// ResetUsage resets the usage counter to zero. Daily and monthly usage come from the
+// usage ledger and reset automatically.
 func (t *Tracker) ResetUsage() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
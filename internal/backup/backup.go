@@ -0,0 +1,337 @@
+// Package backup writes and restores backup archives of a complete setup: feeds with
+// all their options, categories and order, settings including rules, AI profiles, the
+// translation glossary, favorite and read-later articles with their labels, the custom
+// CSS file, the scripts directory and optionally the translation cache.
+//
+// Secrets are encrypted with a key bound to the machine, so the archive holds them
+// re-encrypted under a passphrase, or leaves them out without one.
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"MrRSS/internal/config"
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/version"
+)
+
+const (
+	// Format identifies backup archives in their manifest
+	Format = "mrrss-backup"
+	// FormatVersion is the version of the archive layout
+	FormatVersion = 1
+
+	// maxSavedArticles bounds the favorite and read-later articles of each kind
+	maxSavedArticles = 100000
+)
+
+// Archive entries
+const (
+	manifestFile         = "manifest.json"
+	feedsFile            = "feeds.json"
+	settingsFile         = "settings.json"
+	aiProfilesFile       = "ai_profiles.json"
+	glossaryFile         = "glossary.json"
+	articlesFile         = "articles.json"
+	translationCacheFile = "translation_cache.json"
+	customCSSDir         = "custom_css/"
+	scriptsDir           = "scripts/"
+)
+
+// Manifest describes a backup archive.
+type Manifest struct {
+	Format           string    `json:"format"`
+	Version          int       `json:"version"`
+	AppVersion       string    `json:"app_version"`
+	CreatedAt        time.Time `json:"created_at"`
+	SecretsIncluded  bool      `json:"secrets_included"` // Secrets encrypted under the passphrase
+	TranslationCache bool      `json:"translation_cache"`
+}
+
+// SavedArticle is a favorite or read-later article with its labels.
+type SavedArticle struct {
+	FeedURL         string    `json:"feed_url"`
+	Title           string    `json:"title"`
+	URL             string    `json:"url"`
+	ImageURL        string    `json:"image_url,omitempty"`
+	AudioURL        string    `json:"audio_url,omitempty"`
+	VideoURL        string    `json:"video_url,omitempty"`
+	PublishedAt     time.Time `json:"published_at"`
+	IsRead          bool      `json:"is_read"`
+	IsFavorite      bool      `json:"is_favorite"`
+	IsReadLater     bool      `json:"is_read_later"`
+	TranslatedTitle string    `json:"translated_title,omitempty"`
+	Summary         string    `json:"summary,omitempty"`
+	Content         string    `json:"content,omitempty"`
+	Labels          []string  `json:"labels,omitempty"`
+}
+
+// Options configures a backup.
+type Options struct {
+	// Passphrase encrypts the secrets; without it they are left out
+	Passphrase string
+	// TranslationCache includes the cached translations
+	TranslationCache bool
+}
+
+// Service creates and restores backups of a database and the files of a data directory.
+type Service struct {
+	db         *database.DB
+	dataDir    string // Holds the custom CSS file
+	scriptsDir string
+}
+
+// New creates a backup service.
+func New(db *database.DB, dataDir, scriptsDir string) *Service {
+	return &Service{db: db, dataDir: dataDir, scriptsDir: scriptsDir}
+}
+
+// Write writes a backup archive to w.
+func (s *Service) Write(w io.Writer, opts Options) error {
+	zw := zip.NewWriter(w)
+
+	manifest := Manifest{
+		Format:           Format,
+		Version:          FormatVersion,
+		AppVersion:       version.Version,
+		CreatedAt:        time.Now().UTC(),
+		SecretsIncluded:  opts.Passphrase != "",
+		TranslationCache: opts.TranslationCache,
+	}
+	if err := writeJSON(zw, manifestFile, manifest); err != nil {
+		return err
+	}
+
+	feeds, err := s.db.GetFeeds()
+	if err != nil {
+		return fmt.Errorf("get feeds: %w", err)
+	}
+	if err := writeJSON(zw, feedsFile, feeds); err != nil {
+		return err
+	}
+
+	settings, err := s.exportSettings(opts.Passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, settingsFile, settings); err != nil {
+		return err
+	}
+
+	profiles, err := s.exportAIProfiles(opts.Passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, aiProfilesFile, profiles); err != nil {
+		return err
+	}
+
+	glossary, err := s.db.GetGlossaryEntries()
+	if err != nil {
+		return fmt.Errorf("get glossary: %w", err)
+	}
+	if err := writeJSON(zw, glossaryFile, glossary); err != nil {
+		return err
+	}
+
+	articles, err := s.exportArticles(feeds)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, articlesFile, articles); err != nil {
+		return err
+	}
+
+	if opts.TranslationCache {
+		var entries []database.TranslationCache
+		if err := s.db.ForEachCachedTranslation(func(e database.TranslationCache) error {
+			entries = append(entries, e)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("get translation cache: %w", err)
+		}
+		if err := writeJSON(zw, translationCacheFile, entries); err != nil {
+			return err
+		}
+	}
+
+	if err := s.exportFiles(zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// exportSettings returns all settings, with the encrypted ones re-encrypted under the
+// passphrase or left out without one.
+func (s *Service) exportSettings(passphrase string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, key := range config.SettingsKeys() {
+		value, err := s.db.GetSetting(key)
+		if err != nil || value == "" {
+			continue
+		}
+		if crypto.IsEncrypted(value) {
+			if passphrase == "" {
+				continue
+			}
+			if value, err = reencrypt(value, passphrase); err != nil {
+				return nil, fmt.Errorf("encrypt setting %s: %w", key, err)
+			}
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// exportAIProfiles returns the AI profiles with their secrets encrypted under the
+// passphrase, or left out without one.
+func (s *Service) exportAIProfiles(passphrase string) ([]models.AIProfile, error) {
+	profiles, err := s.db.GetAIProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("get AI profiles: %w", err)
+	}
+	for i := range profiles {
+		p := &profiles[i]
+		p.UsageTokens = 0
+		if passphrase == "" {
+			p.APIKey, p.CustomHeaders = "", ""
+			continue
+		}
+		if p.APIKey, err = crypto.EncryptWithPassphrase(p.APIKey, passphrase); err != nil {
+			return nil, fmt.Errorf("encrypt AI profile %s: %w", p.Name, err)
+		}
+		if p.CustomHeaders, err = crypto.EncryptWithPassphrase(p.CustomHeaders, passphrase); err != nil {
+			return nil, fmt.Errorf("encrypt AI profile %s: %w", p.Name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// exportArticles returns the favorite and read-later articles.
+func (s *Service) exportArticles(feeds []models.Feed) ([]SavedArticle, error) {
+	feedURLs := make(map[int64]string, len(feeds))
+	for _, f := range feeds {
+		feedURLs[f.ID] = f.URL
+	}
+
+	seen := make(map[int64]bool)
+	var saved []models.Article
+	for _, filter := range []string{"favorites", "readLater"} {
+		articles, err := s.db.GetArticles(filter, 0, "", true, maxSavedArticles, 0)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", filter, err)
+		}
+		for _, a := range articles {
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				saved = append(saved, a)
+			}
+		}
+	}
+
+	ids := make([]int64, len(saved))
+	for i, a := range saved {
+		ids[i] = a.ID
+	}
+	texts, err := s.db.GetArticleTextsByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("get article contents: %w", err)
+	}
+	contents := make(map[int64]string, len(texts))
+	for _, t := range texts {
+		contents[t.ID] = t.Content
+	}
+
+	result := make([]SavedArticle, 0, len(saved))
+	for _, a := range saved {
+		result = append(result, SavedArticle{
+			FeedURL:         feedURLs[a.FeedID],
+			Title:           a.Title,
+			URL:             a.URL,
+			ImageURL:        a.ImageURL,
+			AudioURL:        a.AudioURL,
+			VideoURL:        a.VideoURL,
+			PublishedAt:     a.PublishedAt,
+			IsRead:          a.IsRead,
+			IsFavorite:      a.IsFavorite,
+			IsReadLater:     a.IsReadLater,
+			TranslatedTitle: a.TranslatedTitle,
+			Summary:         a.Summary,
+			Content:         contents[a.ID],
+			Labels:          a.Labels,
+		})
+	}
+	return result, nil
+}
+
+// exportFiles adds the custom CSS file and the scripts.
+func (s *Service) exportFiles(zw *zip.Writer) error {
+	if name, _ := s.db.GetSetting("custom_css_file"); name != "" && s.dataDir != "" {
+		data, err := os.ReadFile(filepath.Join(s.dataDir, name))
+		if err == nil {
+			if err := writeFile(zw, customCSSDir+path.Base(filepath.ToSlash(name)), data); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("read custom CSS: %w", err)
+		}
+	}
+
+	if s.scriptsDir == "" {
+		return nil
+	}
+	err := filepath.WalkDir(s.scriptsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.scriptsDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return writeFile(zw, scriptsDir+filepath.ToSlash(rel), data)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("add scripts: %w", err)
+	}
+	return nil
+}
+
+// reencrypt re-encrypts a machine-encrypted value under a passphrase.
+func reencrypt(value, passphrase string) (string, error) {
+	plain, err := crypto.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncryptWithPassphrase(plain, passphrase)
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return writeFile(zw, name, data)
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	_, err = f.Write(data)
+	return err
+}
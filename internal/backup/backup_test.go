@@ -0,0 +1,458 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+const passphrase = "correct horse battery staple"
+
+func newTestService(t *testing.T) (*Service, *database.DB) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	dataDir := t.TempDir()
+	scripts := filepath.Join(dataDir, "scripts")
+	if err := os.MkdirAll(scripts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return New(db, dataDir, scripts), db
+}
+
+// populate fills a database with one of everything a backup holds.
+func populate(t *testing.T, s *Service, db *database.DB) {
+	t.Helper()
+	feeds := []models.Feed{
+		{Title: "News", URL: "https://example.com/news.xml", Category: "Daily", RefreshInterval: 30, ScriptPath: "news.py"},
+		{Title: "Photos", URL: "https://example.com/photos.xml", Category: "Daily", IsImageMode: true, ArticleViewMode: "webpage"},
+		{Title: "Blog", URL: "https://example.com/blog.xml"},
+	}
+	for i := range feeds {
+		id, err := db.AddFeed(&feeds[i])
+		if err != nil {
+			t.Fatalf("AddFeed failed: %v", err)
+		}
+		feeds[i].ID = id
+	}
+	// Reverse the order of the category
+	if err := db.ReorderFeed(feeds[1].ID, "Daily", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	profileID, err := db.AddAIProfile(&models.AIProfile{Name: "Local", Endpoint: "http://localhost:11434", Model: "llama3", APIKey: "sk-profile"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := map[string]string{
+		"theme":                     "dark",
+		"ai_summary_profile":        strconv.FormatInt(profileID, 10),
+		"rules":                     `[{"id":1,"name":"Hide ads","enabled":true,"conditions":[],"actions":["hide"]}]`,
+		"window_width":              "1234",
+		"custom_css_file":           "custom.css",
+		"translation_provider":      "deepl",
+		"summary_trigger_mode":      "manual",
+		"summary_queue_concurrency": "2",
+	}
+	for key, value := range settings {
+		if err := db.SetSetting(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SetEncryptedSetting("deepl_api_key", "deepl-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddGlossaryEntry(&models.GlossaryEntry{SourceTerm: "MrRSS", DoNotTranslate: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	articles := []*models.Article{
+		{FeedID: feeds[0].ID, Title: "Saved", URL: "https://example.com/saved", PublishedAt: time.Now(), IsFavorite: true},
+		{FeedID: feeds[2].ID, Title: "Later", URL: "https://example.com/later", PublishedAt: time.Now(), IsReadLater: true},
+		{FeedID: feeds[2].ID, Title: "Plain", URL: "https://example.com/plain", PublishedAt: time.Now()},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateArticleContent(articles[0].ID, "<p>Saved content</p>"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetArticleLabels(articles[0].ID, []string{"go", "databases"}, "local"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.dataDir, "custom.css"), []byte("body { color: red; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(s.scriptsDir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.scriptsDir, "news.py"), []byte("print('news')"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.scriptsDir, "lib", "util.py"), []byte("pass"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeBackup(t *testing.T, s *Service, opts Options) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.Write(&buf, opts); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestRoundTrip(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{Passphrase: passphrase})
+
+	dst, db := newTestService(t)
+	report, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeReplace, Passphrase: passphrase})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := report.Sections[SectionFeeds].Added; got != 3 {
+		t.Errorf("added %d feeds, want 3", got)
+	}
+
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byURL := make(map[string]models.Feed)
+	for _, f := range feeds {
+		byURL[f.URL] = f
+	}
+	news, photos := byURL["https://example.com/news.xml"], byURL["https://example.com/photos.xml"]
+	if news.RefreshInterval != 30 || news.ScriptPath != "news.py" || news.Category != "Daily" {
+		t.Errorf("news feed options not restored: %+v", news)
+	}
+	if !photos.IsImageMode || photos.ArticleViewMode != "webpage" {
+		t.Errorf("photos feed options not restored: %+v", photos)
+	}
+	if photos.Position >= news.Position {
+		t.Errorf("feed order not restored: photos at %d, news at %d", photos.Position, news.Position)
+	}
+
+	if theme, _ := db.GetSetting("theme"); theme != "dark" {
+		t.Errorf("theme = %q, want dark", theme)
+	}
+	if key, _ := db.GetEncryptedSetting("deepl_api_key"); key != "deepl-secret" {
+		t.Errorf("deepl_api_key = %q, want the secret", key)
+	}
+	if width, _ := db.GetSetting("window_width"); width == "1234" {
+		t.Error("window size should not be restored")
+	}
+
+	profiles, err := db.GetAIProfiles()
+	if err != nil || len(profiles) != 1 || profiles[0].APIKey != "sk-profile" {
+		t.Fatalf("AI profiles not restored: %+v, %v", profiles, err)
+	}
+	if id, _ := db.GetSetting("ai_summary_profile"); id != strconv.FormatInt(profiles[0].ID, 10) {
+		t.Errorf("ai_summary_profile = %q, want the restored profile %d", id, profiles[0].ID)
+	}
+
+	if entries, _ := db.GetGlossaryEntries(); len(entries) != 1 {
+		t.Errorf("restored %d glossary entries, want 1", len(entries))
+	}
+
+	favorites, _ := db.GetArticles("favorites", 0, "", true, 10, 0)
+	readLater, _ := db.GetArticles("readLater", 0, "", true, 10, 0)
+	if len(favorites) != 1 || len(readLater) != 1 {
+		t.Fatalf("restored %d favorites and %d read-later articles, want 1 each", len(favorites), len(readLater))
+	}
+	if !slices.Equal(favorites[0].Labels, []string{"databases", "go"}) {
+		t.Errorf("labels = %v, want [databases go]", favorites[0].Labels)
+	}
+	if texts, _ := db.GetArticleTextsByIDs([]int64{favorites[0].ID}); len(texts) != 1 || texts[0].Content != "<p>Saved content</p>" {
+		t.Errorf("article content not restored: %+v", texts)
+	}
+	if state, _ := db.GetArticleStateByURL("https://example.com/plain"); state != nil {
+		t.Error("articles that are neither favorite nor read later should not be backed up")
+	}
+
+	// The custom CSS is restored to its fixed name, not the one in the archive
+	if css, err := os.ReadFile(filepath.Join(dst.dataDir, utils.CustomCSSFileName)); err != nil || string(css) != "body { color: red; }" {
+		t.Errorf("custom CSS not restored: %q, %v", css, err)
+	}
+	if name, _ := db.GetSetting("custom_css_file"); name != utils.CustomCSSFileName {
+		t.Errorf("custom_css_file = %q, want %s", name, utils.CustomCSSFileName)
+	}
+	if _, err := os.Stat(filepath.Join(dst.scriptsDir, "lib", "util.py")); err != nil {
+		t.Errorf("scripts not restored: %v", err)
+	}
+}
+
+func TestRestoreMerge(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{Passphrase: passphrase})
+
+	dst, db := newTestService(t)
+	if _, err := db.AddFeed(&models.Feed{Title: "Local", URL: "https://example.org/local.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddFeed(&models.Feed{Title: "Mine", URL: "https://example.com/news.xml", RefreshInterval: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSetting("theme", "light"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetSetting("rules", `[{"id":7,"name":"Local rule","enabled":true,"conditions":[],"actions":["favorite"]}]`); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeMerge, Passphrase: passphrase})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	feeds := report.Sections[SectionFeeds]
+	if feeds.Added != 2 || feeds.Skipped != 1 || feeds.Removed != 0 {
+		t.Errorf("feeds report = %+v, want 2 added and 1 skipped", feeds)
+	}
+
+	all, _ := db.GetFeeds()
+	if len(all) != 4 {
+		t.Errorf("got %d feeds, want 4", len(all))
+	}
+	for _, f := range all {
+		if f.URL == "https://example.com/news.xml" && (f.Title != "Mine" || f.RefreshInterval != 5) {
+			t.Errorf("merge changed an existing feed: %+v", f)
+		}
+	}
+	if theme, _ := db.GetSetting("theme"); theme != "light" {
+		t.Errorf("merge changed a customized setting: theme = %q", theme)
+	}
+	if provider, _ := db.GetSetting("translation_provider"); provider != "deepl" {
+		t.Errorf("merge skipped a default setting: translation_provider = %q", provider)
+	}
+	rules, _ := db.GetSetting("rules")
+	if !bytes.Contains([]byte(rules), []byte("Local rule")) || !bytes.Contains([]byte(rules), []byte("Hide ads")) {
+		t.Errorf("rules not merged: %s", rules)
+	}
+
+	// Merging again adds nothing
+	report, err = dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeMerge, Passphrase: passphrase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, section := range report.Sections {
+		if section.Added != 0 {
+			t.Errorf("second merge added %d to %s", section.Added, name)
+		}
+	}
+}
+
+func TestRestoreReplaceRemoves(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{})
+
+	dst, db := newTestService(t)
+	if _, err := db.AddFeed(&models.Feed{Title: "Local", URL: "https://example.org/local.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddAIProfile(&models.AIProfile{Name: "Local", APIKey: "sk-local"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeReplace})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := report.Sections[SectionFeeds].Removed; got != 1 {
+		t.Errorf("removed %d feeds, want 1", got)
+	}
+	if state, _ := db.GetFeeds(); len(state) != 3 {
+		t.Errorf("got %d feeds, want 3", len(state))
+	}
+
+	// Without a passphrase the backup holds no secrets, so the local ones are kept
+	profiles, _ := db.GetAIProfiles()
+	if len(profiles) != 1 || profiles[0].APIKey != "sk-local" || profiles[0].Model != "llama3" {
+		t.Errorf("AI profile = %+v, want the backup's settings with the local key", profiles)
+	}
+	if key, _ := db.GetEncryptedSetting("deepl_api_key"); key != "" {
+		t.Errorf("deepl_api_key = %q, want none without a passphrase", key)
+	}
+}
+
+func TestRestoreReplaceKeepsLastUpdated(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{})
+
+	dst, db := newTestService(t)
+	id, err := db.AddFeed(&models.Feed{Title: "Old news", URL: "https://example.com/news.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastUpdated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := db.Exec("UPDATE feeds SET last_updated = ? WHERE id = ?", lastUpdated, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeReplace}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	feed, err := db.GetFeedByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "News" || feed.Category != "Daily" || feed.RefreshInterval != 30 {
+		t.Errorf("feed = %+v, want the backup's details and options", feed)
+	}
+	if !feed.LastUpdated.Equal(lastUpdated) {
+		t.Errorf("last_updated = %v, want %v", feed.LastUpdated, lastUpdated)
+	}
+}
+
+func TestRestoreReplaceRollsBackFailedSection(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{})
+
+	dst, db := newTestService(t)
+	for _, f := range []models.Feed{{Title: "Old news", URL: "https://example.com/news.xml"}, {Title: "Local", URL: "https://example.org/local.xml"}} {
+		if _, err := db.AddFeed(&f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Fail the removal of the local feed, after the other feeds were added and updated
+	if _, err := db.Exec("CREATE TRIGGER keep_feeds BEFORE DELETE ON feeds BEGIN SELECT RAISE(ABORT, 'kept'); END"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeReplace}); err == nil {
+		t.Fatal("Restore succeeded, want the failed removal")
+	}
+	feeds, _ := db.GetFeeds()
+	var titles []string
+	for _, f := range feeds {
+		titles = append(titles, f.Title)
+	}
+	slices.Sort(titles)
+	if !slices.Equal(titles, []string{"Local", "Old news"}) {
+		t.Errorf("feeds = %v, want the local feeds as they were", titles)
+	}
+}
+
+func TestRestoreDryRun(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{Passphrase: passphrase, TranslationCache: true})
+
+	dst, db := newTestService(t)
+	report, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: ModeReplace, Passphrase: passphrase, DryRun: true})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !report.DryRun || report.Sections[SectionFeeds].Added != 3 || report.Sections[SectionArticles].Added != 2 {
+		t.Errorf("unexpected report: %+v", report.Sections)
+	}
+	if feeds, _ := db.GetFeeds(); len(feeds) != 0 {
+		t.Errorf("dry run added %d feeds", len(feeds))
+	}
+	if theme, _ := db.GetSetting("theme"); theme == "dark" {
+		t.Error("dry run changed settings")
+	}
+	if _, err := os.Stat(filepath.Join(dst.scriptsDir, "news.py")); !os.IsNotExist(err) {
+		t.Error("dry run restored scripts")
+	}
+}
+
+func TestRestoreSkipsNonCSSCustomCSS(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{})
+
+	// Rename the custom CSS in the archive to a script
+	zr, err := zip.NewReader(archive, archive.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		name := f.Name
+		if name == customCSSDir+"custom.css" {
+			name = customCSSDir + "startup.sh"
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, _ := f.Open()
+		io.Copy(w, rc)
+		rc.Close()
+	}
+	zw.Close()
+	tampered := bytes.NewReader(buf.Bytes())
+
+	dst, db := newTestService(t)
+	report, err := dst.Restore(context.Background(), tampered, tampered.Size(), RestoreOptions{Mode: ModeReplace})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	for _, name := range []string{"startup.sh", utils.CustomCSSFileName} {
+		if _, err := os.Stat(filepath.Join(dst.dataDir, name)); err == nil {
+			t.Errorf("restore wrote %s to the data directory", name)
+		}
+	}
+	if name, _ := db.GetSetting("custom_css_file"); name != "" {
+		t.Errorf("custom_css_file = %q, want none", name)
+	}
+	if len(report.Warnings) == 0 {
+		t.Error("expected a warning about the skipped file")
+	}
+}
+
+func TestRestoreValidation(t *testing.T) {
+	src, srcDB := newTestService(t)
+	populate(t, src, srcDB)
+	archive := writeBackup(t, src, Options{Passphrase: passphrase})
+	dst, _ := newTestService(t)
+
+	_, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Passphrase: "wrong"})
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Restore with a wrong passphrase returned %v, want ErrWrongPassphrase", err)
+	}
+
+	report, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Restore without a passphrase failed: %v", err)
+	}
+	if len(report.Warnings) == 0 {
+		t.Error("expected a warning about the skipped secrets")
+	}
+
+	garbage := bytes.NewReader([]byte("not a zip"))
+	if _, err := dst.Restore(context.Background(), garbage, garbage.Size(), RestoreOptions{}); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Restore of garbage returned %v, want ErrInvalidArchive", err)
+	}
+	if _, err := dst.Restore(context.Background(), archive, archive.Size(), RestoreOptions{Mode: "overwrite"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
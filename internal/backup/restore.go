@@ -0,0 +1,859 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/config"
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/rules"
+	"MrRSS/internal/utils"
+)
+
+// Restore modes
+const (
+	// ModeMerge adds what the setup is missing and keeps what it has
+	ModeMerge = "merge"
+	// ModeReplace makes the setup match the backup, removing what the backup lacks
+	ModeReplace = "replace"
+)
+
+// Report sections
+const (
+	SectionFeeds            = "feeds"
+	SectionSettings         = "settings"
+	SectionAIProfiles       = "ai_profiles"
+	SectionGlossary         = "glossary"
+	SectionArticles         = "articles"
+	SectionTranslationCache = "translation_cache"
+	SectionFiles            = "files"
+)
+
+var (
+	// ErrInvalidArchive is returned for files that are not backup archives
+	ErrInvalidArchive = errors.New("not a MrRSS backup archive")
+	// ErrWrongPassphrase is returned when the passphrase does not decrypt the secrets
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+)
+
+// machineSettings are settings that describe the machine rather than the setup, which
+// are not restored.
+var machineSettings = map[string]bool{
+	"window_x": true, "window_y": true, "window_width": true, "window_height": true, "window_maximized": true,
+	"last_article_update": true, "last_network_test": true, "network_speed": true,
+	"network_bandwidth_mbps": true, "network_latency_ms": true, "ai_usage_tokens": true,
+	"digest_last_run": true, "db_backup_dir": true, "custom_css_file": true, // Restored with the file
+}
+
+// RestoreOptions configures a restore.
+type RestoreOptions struct {
+	Mode       string // ModeMerge or ModeReplace
+	Passphrase string // Decrypts the secrets; without it they are skipped
+	DryRun     bool   // Validates the archive and reports what a restore would do
+}
+
+// SectionReport counts what a restore did with a section of the archive.
+type SectionReport struct {
+	Total   int `json:"total"`
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Removed int `json:"removed"`
+}
+
+// Report describes a restore or, for dry runs, what it would do.
+type Report struct {
+	Manifest Manifest                  `json:"manifest"`
+	Mode     string                    `json:"mode"`
+	DryRun   bool                      `json:"dry_run"`
+	Sections map[string]*SectionReport `json:"sections"`
+	Warnings []string                  `json:"warnings"`
+}
+
+func (r *Report) section(name string) *SectionReport {
+	if r.Sections[name] == nil {
+		r.Sections[name] = &SectionReport{}
+	}
+	return r.Sections[name]
+}
+
+func (r *Report) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// archive is the parsed content of a backup archive.
+type archive struct {
+	manifest         Manifest
+	feeds            []models.Feed
+	settings         map[string]string
+	aiProfiles       []models.AIProfile
+	glossary         []models.GlossaryEntry
+	articles         []SavedArticle
+	translationCache []database.TranslationCache
+	customCSS        *zip.File
+	scripts          []*zip.File
+}
+
+// Restore validates a backup archive and restores it, or with DryRun only reports what
+// it would restore.
+func (s *Service) Restore(ctx context.Context, r io.ReaderAt, size int64, opts RestoreOptions) (*Report, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeMerge
+	}
+	if opts.Mode != ModeMerge && opts.Mode != ModeReplace {
+		return nil, fmt.Errorf("unknown restore mode %q", opts.Mode)
+	}
+
+	a, err := readArchive(r, size)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Manifest: a.manifest, Mode: opts.Mode, DryRun: opts.DryRun, Sections: map[string]*SectionReport{}, Warnings: []string{}}
+	if err := checkPassphrase(a, opts.Passphrase, report); err != nil {
+		return nil, err
+	}
+
+	rs := &restore{Service: s, db: s.db, archive: a, opts: opts, report: report}
+	steps := []func(context.Context) error{
+		rs.inTx(rs.restoreFeeds),
+		rs.inTx(rs.restoreAIProfiles),
+		rs.inTx(rs.restoreSettings),
+		rs.restoreGlossary,
+		rs.inTx(rs.restoreArticles),
+		rs.restoreTranslationCache,
+		rs.restoreFiles,
+	}
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// readArchive reads and validates the entries of a backup archive.
+func readArchive(r io.ReaderAt, size int64) (*archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	a := &archive{settings: map[string]string{}}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+		switch {
+		case strings.HasPrefix(f.Name, customCSSDir) && !strings.HasSuffix(f.Name, "/"):
+			a.customCSS = f
+		case strings.HasPrefix(f.Name, scriptsDir) && !strings.HasSuffix(f.Name, "/"):
+			a.scripts = append(a.scripts, f)
+		}
+	}
+
+	if files[manifestFile] == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestFile)
+	}
+	if err := readJSON(files[manifestFile], &a.manifest); err != nil {
+		return nil, err
+	}
+	if a.manifest.Format != Format {
+		return nil, ErrInvalidArchive
+	}
+	if a.manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than this version of MrRSS supports", a.manifest.Version)
+	}
+
+	entries := []struct {
+		name string
+		v    interface{}
+	}{
+		{feedsFile, &a.feeds},
+		{settingsFile, &a.settings},
+		{aiProfilesFile, &a.aiProfiles},
+		{glossaryFile, &a.glossary},
+		{articlesFile, &a.articles},
+		{translationCacheFile, &a.translationCache},
+	}
+	for _, e := range entries {
+		if f := files[e.name]; f != nil {
+			if err := readJSON(f, e.v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+// checkPassphrase checks that the passphrase decrypts the secrets of an archive, if
+// any. Without a passphrase the secrets are skipped.
+func checkPassphrase(a *archive, passphrase string, report *Report) error {
+	var secret string
+	for _, key := range config.SettingsKeys() {
+		if crypto.IsPassphraseEncrypted(a.settings[key]) {
+			secret = a.settings[key]
+			break
+		}
+	}
+	for _, p := range a.aiProfiles {
+		if secret == "" && crypto.IsPassphraseEncrypted(p.APIKey) {
+			secret = p.APIKey
+		}
+	}
+	if secret == "" {
+		return nil
+	}
+	if passphrase == "" {
+		report.warnf("No passphrase given, so secrets such as API keys and passwords are not restored")
+		return nil
+	}
+	if _, err := crypto.DecryptWithPassphrase(secret, passphrase); err != nil {
+		return ErrWrongPassphrase
+	}
+	return nil
+}
+
+// restore holds the state of a restore.
+type restore struct {
+	*Service
+	db      *database.DB // The database, or the transaction of the current step
+	archive *archive
+	opts    RestoreOptions
+	report  *Report
+
+	profileIDs map[int64]int64 // Local IDs of the AI profiles of the archive
+}
+
+func (rs *restore) replace() bool {
+	return rs.opts.Mode == ModeReplace
+}
+
+// inTx runs a step of a replacing restore in a transaction, so that a failing step
+// leaves its section as it was rather than half replaced.
+func (rs *restore) inTx(step func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !rs.replace() || rs.opts.DryRun {
+			return step(ctx)
+		}
+		db := rs.db
+		defer func() { rs.db = db }()
+		return db.WithTx(func(tx *database.DB) error {
+			rs.db = tx
+			return step(ctx)
+		})
+	}
+}
+
+// secretsIncluded reports whether the secrets of the archive are restored.
+func (rs *restore) secretsIncluded() bool {
+	return rs.archive.manifest.SecretsIncluded && rs.opts.Passphrase != ""
+}
+
+// decrypt decrypts a secret of the archive, or returns "" without a passphrase.
+func (rs *restore) decrypt(value string) (string, error) {
+	if !crypto.IsPassphraseEncrypted(value) {
+		return value, nil
+	}
+	if rs.opts.Passphrase == "" {
+		return "", nil
+	}
+	return crypto.DecryptWithPassphrase(value, rs.opts.Passphrase)
+}
+
+// restoreFeeds adds the feeds of the archive. Replacing also updates the options and
+// order of existing feeds and deletes the feeds the archive lacks.
+func (rs *restore) restoreFeeds(ctx context.Context) error {
+	section := rs.report.section(SectionFeeds)
+	local, err := rs.db.GetFeeds()
+	if err != nil {
+		return fmt.Errorf("get feeds: %w", err)
+	}
+	localByURL := make(map[string]models.Feed, len(local))
+	for _, f := range local {
+		localByURL[f.URL] = f
+	}
+
+	inArchive := make(map[string]bool)
+	for _, feed := range rs.archive.feeds {
+		if feed.URL == "" || inArchive[feed.URL] {
+			section.Skipped++
+			rs.report.warnf("Skipped feed %q without a unique URL", feed.Title)
+			continue
+		}
+		inArchive[feed.URL] = true
+		section.Total++
+
+		existing, exists := localByURL[feed.URL]
+		switch {
+		case exists && !rs.replace():
+			section.Skipped++
+			continue
+		case exists:
+			section.Updated++
+		default:
+			section.Added++
+		}
+		if rs.opts.DryRun {
+			continue
+		}
+
+		if exists {
+			// Updated in place, keeping when the feed was last refreshed
+			if err := rs.updateFeed(existing.ID, feed); err != nil {
+				return fmt.Errorf("update feed %s: %w", feed.URL, err)
+			}
+			continue
+		}
+		position := feed.Position
+		if !rs.replace() {
+			// Appended to the local order of the category
+			feed.Position = 0
+		}
+		id, err := rs.db.AddFeed(&feed)
+		if err != nil {
+			return fmt.Errorf("add feed %s: %w", feed.URL, err)
+		}
+		if rs.replace() {
+			if err := rs.db.UpdateFeedPosition(id, feed.Category, position); err != nil {
+				return fmt.Errorf("order feed %s: %w", feed.URL, err)
+			}
+		}
+	}
+
+	if rs.replace() {
+		for _, f := range local {
+			if inArchive[f.URL] {
+				continue
+			}
+			section.Removed++
+			if !rs.opts.DryRun {
+				if err := rs.db.DeleteFeed(f.ID); err != nil {
+					return fmt.Errorf("delete feed %s: %w", f.URL, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// updateFeed updates the details, options and order of an existing feed to the ones of
+// the archive.
+func (rs *restore) updateFeed(id int64, feed models.Feed) error {
+	if err := rs.db.UpdateFeedWithPosition(id, feed.Title, feed.URL, feed.Category, feed.ScriptPath, feed.Position, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval, feed.IsImageMode, feed.Type, feed.XPathItem, feed.XPathItemTitle, feed.XPathItemContent, feed.XPathItemUri, feed.XPathItemAuthor, feed.XPathItemTimestamp, feed.XPathItemTimeFormat, feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid, feed.ArticleViewMode, feed.AutoExpandContent); err != nil {
+		return err
+	}
+	if err := rs.db.UpdateFeedDetails(id, feed.Title, feed.Link, feed.Description); err != nil {
+		return err
+	}
+	return rs.db.UpdateFeedImage(id, feed.ImageURL)
+}
+
+// restoreAIProfiles adds the AI profiles of the archive, matched to local ones by name.
+// Replacing also updates the existing profiles and deletes the ones the archive lacks.
+func (rs *restore) restoreAIProfiles(ctx context.Context) error {
+	section := rs.report.section(SectionAIProfiles)
+	rs.profileIDs = make(map[int64]int64)
+	local, err := rs.db.GetAIProfiles()
+	if err != nil {
+		return fmt.Errorf("get AI profiles: %w", err)
+	}
+	localByName := make(map[string]models.AIProfile, len(local))
+	for _, p := range local {
+		localByName[p.Name] = p
+	}
+
+	inArchive := make(map[string]bool)
+	for _, p := range rs.archive.aiProfiles {
+		section.Total++
+		inArchive[p.Name] = true
+		var err error
+		if p.APIKey, err = rs.decrypt(p.APIKey); err != nil {
+			return fmt.Errorf("decrypt AI profile %s: %w", p.Name, err)
+		}
+		if p.CustomHeaders, err = rs.decrypt(p.CustomHeaders); err != nil {
+			return fmt.Errorf("decrypt AI profile %s: %w", p.Name, err)
+		}
+
+		existing, exists := localByName[p.Name]
+		if exists {
+			rs.profileIDs[p.ID] = existing.ID
+			if !rs.replace() {
+				section.Skipped++
+				continue
+			}
+			section.Updated++
+			if !rs.secretsIncluded() {
+				// Keep the local secrets rather than clearing them
+				p.APIKey, p.CustomHeaders = existing.APIKey, existing.CustomHeaders
+			}
+			if !rs.opts.DryRun {
+				p.ID = existing.ID
+				if err := rs.db.UpdateAIProfile(&p); err != nil {
+					return fmt.Errorf("update AI profile %s: %w", p.Name, err)
+				}
+			}
+			continue
+		}
+
+		section.Added++
+		if !rs.opts.DryRun {
+			id, err := rs.db.AddAIProfile(&p)
+			if err != nil {
+				return fmt.Errorf("add AI profile %s: %w", p.Name, err)
+			}
+			rs.profileIDs[p.ID] = id
+		}
+	}
+
+	if rs.replace() {
+		for _, p := range local {
+			if inArchive[p.Name] {
+				continue
+			}
+			section.Removed++
+			if !rs.opts.DryRun {
+				if err := rs.db.DeleteAIProfile(p.ID); err != nil {
+					return fmt.Errorf("delete AI profile %s: %w", p.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restoreSettings restores the settings of the archive. Merging only sets the settings
+// that still have their default value, and adds the rules the setup lacks.
+func (rs *restore) restoreSettings(ctx context.Context) error {
+	section := rs.report.section(SectionSettings)
+	for _, key := range config.SettingsKeys() {
+		value, ok := rs.archive.settings[key]
+		if !ok || machineSettings[key] {
+			continue
+		}
+		section.Total++
+
+		encrypted := crypto.IsPassphraseEncrypted(value)
+		if encrypted {
+			if rs.opts.Passphrase == "" {
+				section.Skipped++
+				continue
+			}
+			var err error
+			if value, err = rs.decrypt(value); err != nil {
+				return fmt.Errorf("decrypt setting %s: %w", key, err)
+			}
+		}
+		if slices.Contains(aiProfileSettingKeys, key) {
+			value = rs.localProfileID(value)
+		}
+
+		current, _ := rs.db.GetSetting(key)
+		if encrypted {
+			current, _ = rs.db.GetEncryptedSetting(key)
+		}
+		if key == "rules" && !rs.replace() {
+			merged, added, err := mergeRules(current, value)
+			if err != nil {
+				section.Skipped++
+				rs.report.warnf("Skipped rules: %v", err)
+				continue
+			}
+			if added == 0 {
+				section.Skipped++
+				continue
+			}
+			value = merged
+		} else if current == value || (!rs.replace() && current != "" && current != config.GetString(key)) {
+			section.Skipped++
+			continue
+		}
+
+		section.Updated++
+		if rs.opts.DryRun {
+			continue
+		}
+		var err error
+		if encrypted {
+			err = rs.db.SetEncryptedSetting(key, value)
+		} else {
+			err = rs.db.SetSetting(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("save setting %s: %w", key, err)
+		}
+	}
+
+	for key := range rs.archive.settings {
+		if !slices.Contains(config.SettingsKeys(), key) {
+			rs.report.warnf("Skipped unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// aiProfileSettingKeys are the settings that select an AI profile by ID.
+var aiProfileSettingKeys = []string{"ai_translation_profile", "ai_summary_profile", "ai_chat_profile", "ai_tagging_profile"}
+
+// localProfileID maps the ID of an AI profile of the archive to the local one.
+func (rs *restore) localProfileID(value string) string {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return value
+	}
+	if local, ok := rs.profileIDs[id]; ok {
+		return strconv.FormatInt(local, 10)
+	}
+	if rs.opts.DryRun {
+		return value
+	}
+	return ""
+}
+
+// mergeRules adds the rules of the archive to the local ones, skipping rules with the
+// ID or name of a local one, and returns the merged rules and the number added.
+func mergeRules(localJSON, archiveJSON string) (string, int, error) {
+	var local, archived []rules.Rule
+	if localJSON != "" {
+		if err := json.Unmarshal([]byte(localJSON), &local); err != nil {
+			return "", 0, fmt.Errorf("invalid local rules: %w", err)
+		}
+	}
+	if err := json.Unmarshal([]byte(archiveJSON), &archived); err != nil {
+		return "", 0, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	added := 0
+	for _, rule := range archived {
+		if slices.ContainsFunc(local, func(r rules.Rule) bool { return r.ID == rule.ID || r.Name == rule.Name }) {
+			continue
+		}
+		local = append(local, rule)
+		added++
+	}
+	data, err := json.Marshal(local)
+	return string(data), added, err
+}
+
+// restoreGlossary adds the glossary entries the setup lacks. Replacing also deletes the
+// entries the archive lacks.
+func (rs *restore) restoreGlossary(ctx context.Context) error {
+	section := rs.report.section(SectionGlossary)
+	local, err := rs.db.GetGlossaryEntries()
+	if err != nil {
+		return fmt.Errorf("get glossary: %w", err)
+	}
+	key := func(e models.GlossaryEntry) string {
+		return strings.Join([]string{e.SourceTerm, e.TargetTerm, e.SourceLang, e.TargetLang, strconv.FormatBool(e.DoNotTranslate)}, "\x00")
+	}
+	localKeys := make(map[string]bool, len(local))
+	for _, e := range local {
+		localKeys[key(e)] = true
+	}
+
+	inArchive := make(map[string]bool)
+	for _, e := range rs.archive.glossary {
+		section.Total++
+		inArchive[key(e)] = true
+		if localKeys[key(e)] {
+			section.Skipped++
+			continue
+		}
+		section.Added++
+		if !rs.opts.DryRun {
+			if _, err := rs.db.AddGlossaryEntry(&e); err != nil {
+				return fmt.Errorf("add glossary entry %s: %w", e.SourceTerm, err)
+			}
+		}
+	}
+
+	if rs.replace() {
+		for _, e := range local {
+			if inArchive[key(e)] {
+				continue
+			}
+			section.Removed++
+			if !rs.opts.DryRun {
+				if err := rs.db.DeleteGlossaryEntry(e.ID); err != nil {
+					return fmt.Errorf("delete glossary entry %s: %w", e.SourceTerm, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restoreArticles restores the favorite and read-later articles with their labels,
+// adding the missing ones to their feeds. Merging keeps local favorites, read-later
+// marks and labels; replacing removes the marks the archive lacks.
+func (rs *restore) restoreArticles(ctx context.Context) error {
+	section := rs.report.section(SectionArticles)
+	feeds, err := rs.db.GetFeeds()
+	if err != nil {
+		return fmt.Errorf("get feeds: %w", err)
+	}
+	feedIDs := make(map[string]int64, len(feeds))
+	for _, f := range feeds {
+		feedIDs[f.URL] = f.ID
+	}
+	restoredFeeds := make(map[string]bool, len(rs.archive.feeds))
+	for _, f := range rs.archive.feeds {
+		restoredFeeds[f.URL] = true
+	}
+
+	inArchive := make(map[string]bool)
+	for _, saved := range rs.archive.articles {
+		section.Total++
+		inArchive[saved.URL] = true
+		feedID, ok := feedIDs[saved.FeedURL]
+		if saved.URL == "" || (!ok && !(rs.opts.DryRun && restoredFeeds[saved.FeedURL])) {
+			section.Skipped++
+			continue
+		}
+
+		existing, err := rs.db.GetArticleStateByURL(saved.URL)
+		if err != nil {
+			return fmt.Errorf("get article: %w", err)
+		}
+		if existing == nil {
+			section.Added++
+			if rs.opts.DryRun {
+				continue
+			}
+			article := &models.Article{
+				FeedID:          feedID,
+				Title:           saved.Title,
+				URL:             saved.URL,
+				ImageURL:        saved.ImageURL,
+				AudioURL:        saved.AudioURL,
+				VideoURL:        saved.VideoURL,
+				PublishedAt:     saved.PublishedAt,
+				IsRead:          saved.IsRead,
+				IsFavorite:      saved.IsFavorite,
+				IsReadLater:     saved.IsReadLater,
+				TranslatedTitle: saved.TranslatedTitle,
+				Summary:         saved.Summary,
+			}
+			if err := rs.db.SaveArticles(ctx, []*models.Article{article}); err != nil {
+				return fmt.Errorf("save article %s: %w", saved.URL, err)
+			}
+			if article.ID == 0 {
+				continue
+			}
+			if saved.Content != "" {
+				if err := rs.db.UpdateArticleContent(article.ID, saved.Content); err != nil {
+					return fmt.Errorf("save article %s: %w", saved.URL, err)
+				}
+			}
+			if len(saved.Labels) > 0 {
+				if err := rs.db.SetArticleLabels(article.ID, saved.Labels, "local"); err != nil {
+					return fmt.Errorf("save article %s: %w", saved.URL, err)
+				}
+			}
+			continue
+		}
+
+		section.Updated++
+		if rs.opts.DryRun {
+			continue
+		}
+		if err := rs.updateArticle(existing, saved); err != nil {
+			return fmt.Errorf("update article %s: %w", saved.URL, err)
+		}
+	}
+
+	if rs.replace() {
+		for _, filter := range []string{"favorites", "readLater"} {
+			articles, err := rs.db.GetArticles(filter, 0, "", true, maxSavedArticles, 0)
+			if err != nil {
+				return fmt.Errorf("get %s: %w", filter, err)
+			}
+			for _, a := range articles {
+				if inArchive[a.URL] {
+					continue
+				}
+				section.Removed++
+				if rs.opts.DryRun {
+					continue
+				}
+				if filter == "favorites" {
+					err = rs.db.SetArticleFavorite(a.ID, false)
+				} else {
+					err = rs.db.SetArticleReadLater(a.ID, false)
+				}
+				if err != nil {
+					return fmt.Errorf("update article %s: %w", a.URL, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// updateArticle restores the marks and labels of an existing article.
+func (rs *restore) updateArticle(existing *database.ArticleState, saved SavedArticle) error {
+	article, err := rs.db.GetArticleByID(existing.ID)
+	if err != nil {
+		return err
+	}
+	favorite, readLater, labels := saved.IsFavorite, saved.IsReadLater, saved.Labels
+	if !rs.replace() {
+		favorite = favorite || article.IsFavorite
+		readLater = readLater || article.IsReadLater
+		local, err := rs.db.GetArticleLabels(existing.ID)
+		if err != nil {
+			return err
+		}
+		for _, l := range local {
+			if !slices.Contains(labels, l) {
+				labels = append(labels, l)
+			}
+		}
+	}
+
+	if favorite != article.IsFavorite {
+		if err := rs.db.SetArticleFavorite(existing.ID, favorite); err != nil {
+			return err
+		}
+	}
+	if readLater != article.IsReadLater {
+		if err := rs.db.SetArticleReadLater(existing.ID, readLater); err != nil {
+			return err
+		}
+	}
+	if saved.IsRead && !article.IsRead {
+		if err := rs.db.MarkArticleRead(existing.ID, true); err != nil {
+			return err
+		}
+	}
+	return rs.db.SetArticleLabels(existing.ID, labels, "local")
+}
+
+// restoreTranslationCache imports the cached translations, keeping pinned local ones.
+func (rs *restore) restoreTranslationCache(ctx context.Context) error {
+	if len(rs.archive.translationCache) == 0 {
+		return nil
+	}
+	section := rs.report.section(SectionTranslationCache)
+	for _, entry := range rs.archive.translationCache {
+		section.Total++
+		if rs.opts.DryRun {
+			continue
+		}
+		imported, err := rs.db.ImportCachedTranslation(entry)
+		if err != nil {
+			return fmt.Errorf("import cached translation: %w", err)
+		}
+		if imported {
+			section.Added++
+		} else {
+			section.Skipped++
+		}
+	}
+	return nil
+}
+
+// restoreFiles restores the custom CSS file and the scripts. Merging keeps the local
+// custom CSS and scripts; replacing overwrites them. Local scripts the archive lacks are
+// kept either way, as feeds may still use them. The custom CSS is always written to its
+// fixed file name, whatever its name in the archive.
+func (rs *restore) restoreFiles(ctx context.Context) error {
+	section := rs.report.section(SectionFiles)
+
+	if f := rs.archive.customCSS; f != nil && rs.dataDir != "" {
+		section.Total++
+		current, _ := rs.db.GetSetting("custom_css_file")
+		switch {
+		case !strings.EqualFold(path.Ext(f.Name), ".css"):
+			section.Skipped++
+			rs.report.warnf("Skipped custom CSS file that is not a .css file %q", f.Name)
+		case current != "" && !rs.replace():
+			section.Skipped++
+		default:
+			section.Updated++
+			if !rs.opts.DryRun {
+				if err := extract(f, filepath.Join(rs.dataDir, utils.CustomCSSFileName)); err != nil {
+					return err
+				}
+				if err := rs.db.SetSetting("custom_css_file", utils.CustomCSSFileName); err != nil {
+					return fmt.Errorf("save setting custom_css_file: %w", err)
+				}
+			}
+		}
+	}
+
+	if rs.scriptsDir == "" {
+		return nil
+	}
+	for _, f := range rs.archive.scripts {
+		section.Total++
+		rel := strings.TrimPrefix(f.Name, scriptsDir)
+		target := filepath.Join(rs.scriptsDir, filepath.FromSlash(rel))
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			section.Skipped++
+			rs.report.warnf("Skipped script with an invalid path %q", f.Name)
+			continue
+		}
+
+		_, err := os.Stat(target)
+		switch {
+		case err == nil && !rs.replace():
+			section.Skipped++
+			continue
+		case err == nil:
+			section.Updated++
+		default:
+			section.Added++
+		}
+		if !rs.opts.DryRun {
+			if err := extract(f, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// extract writes an archive file to a path, creating its directory.
+func extract(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("restore %s: %w", f.Name, err)
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", f.Name, err)
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return fmt.Errorf("restore %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid %s: %v", ErrInvalidArchive, f.Name, err)
+	}
+	return nil
+}
@@ -64,6 +64,11 @@ func (mc *MediaCache) findCachedFile(url string) (string, bool) {
 	return matches[0], true
 }
 
+// CachedFile returns the path of the cached file for a URL, if it is cached
+func (mc *MediaCache) CachedFile(url string) (string, bool) {
+	return mc.findCachedFile(url)
+}
+
 // Exists checks if a media file is already cached (regardless of extension)
 func (mc *MediaCache) Exists(url string) bool {
 	_, found := mc.findCachedFile(url)
@@ -18,73 +18,115 @@ var defaultsJSON []byte
 
 // Defaults holds all default settings values
 type Defaults struct {
-	AIAPIKey                 string `json:"ai_api_key"`
-	AIChatEnabled            bool   `json:"ai_chat_enabled"`
-	AICustomHeaders          string `json:"ai_custom_headers"`
-	AIEndpoint               string `json:"ai_endpoint"`
-	AIModel                  string `json:"ai_model"`
-	AISummaryPrompt          string `json:"ai_summary_prompt"`
-	AITranslationPrompt      string `json:"ai_translation_prompt"`
-	AIUsageLimit             string `json:"ai_usage_limit"`
-	AIUsageTokens            string `json:"ai_usage_tokens"`
-	AutoCleanupEnabled       bool   `json:"auto_cleanup_enabled"`
-	AutoShowAllContent       bool   `json:"auto_show_all_content"`
-	BaiduAppId               string `json:"baidu_app_id"`
-	BaiduSecretKey           string `json:"baidu_secret_key"`
-	CloseToTray              bool   `json:"close_to_tray"`
-	CustomCssFile            string `json:"custom_css_file"`
-	DeeplAPIKey              string `json:"deepl_api_key"`
-	DeeplEndpoint            string `json:"deepl_endpoint"`
-	DefaultViewMode          string `json:"default_view_mode"`
-	FreshRSSAPIPassword      string `json:"freshrss_api_password"`
-	FreshRSSEnabled          bool   `json:"freshrss_enabled"`
-	FreshRSSServerUrl        string `json:"freshrss_server_url"`
-	FreshRSSUsername         string `json:"freshrss_username"`
-	FullTextFetchEnabled     bool   `json:"full_text_fetch_enabled"`
-	GoogleTranslateEndpoint  string `json:"google_translate_endpoint"`
-	HoverMarkAsRead          bool   `json:"hover_mark_as_read"`
-	ImageGalleryEnabled      bool   `json:"image_gallery_enabled"`
-	Language                 string `json:"language"`
-	LastArticleUpdate        string `json:"last_article_update"`
-	LastNetworkTest          string `json:"last_network_test"`
-	MaxArticleAgeDays        int    `json:"max_article_age_days"`
-	MaxCacheSizeMb           int    `json:"max_cache_size_mb"`
-	MaxConcurrentRefreshes   string `json:"max_concurrent_refreshes"`
-	MediaCacheEnabled        bool   `json:"media_cache_enabled"`
-	MediaCacheMaxAgeDays     int    `json:"media_cache_max_age_days"`
-	MediaCacheMaxSizeMb      int    `json:"media_cache_max_size_mb"`
-	NetworkBandwidthMbps     string `json:"network_bandwidth_mbps"`
-	NetworkLatencyMs         string `json:"network_latency_ms"`
-	NetworkSpeed             string `json:"network_speed"`
-	ObsidianEnabled          bool   `json:"obsidian_enabled"`
-	ObsidianVault            string `json:"obsidian_vault"`
-	ObsidianVaultPath        string `json:"obsidian_vault_path"`
-	ProxyEnabled             bool   `json:"proxy_enabled"`
-	ProxyHost                string `json:"proxy_host"`
-	ProxyPassword            string `json:"proxy_password"`
-	ProxyPort                string `json:"proxy_port"`
-	ProxyType                string `json:"proxy_type"`
-	ProxyUsername            string `json:"proxy_username"`
-	RefreshMode              string `json:"refresh_mode"`
-	Rules                    string `json:"rules"`
-	Shortcuts                string `json:"shortcuts"`
-	ShowArticlePreviewImages bool   `json:"show_article_preview_images"`
-	ShowHiddenArticles       bool   `json:"show_hidden_articles"`
-	StartupOnBoot            bool   `json:"startup_on_boot"`
-	SummaryEnabled           bool   `json:"summary_enabled"`
-	SummaryLength            string `json:"summary_length"`
-	SummaryProvider          string `json:"summary_provider"`
-	SummaryTriggerMode       string `json:"summary_trigger_mode"`
-	TargetLanguage           string `json:"target_language"`
-	Theme                    string `json:"theme"`
-	TranslationEnabled       bool   `json:"translation_enabled"`
-	TranslationProvider      string `json:"translation_provider"`
-	UpdateInterval           int    `json:"update_interval"`
-	WindowHeight             string `json:"window_height"`
-	WindowMaximized          string `json:"window_maximized"`
-	WindowWidth              string `json:"window_width"`
-	WindowX                  string `json:"window_x"`
-	WindowY                  string `json:"window_y"`
+	AIAPIKey                  string `json:"ai_api_key"`
+	AIBackend                 string `json:"ai_backend"`
+	AIChatEnabled             bool   `json:"ai_chat_enabled"`
+	AIChatProfile             string `json:"ai_chat_profile"`
+	AICustomHeaders           string `json:"ai_custom_headers"`
+	AIEmbeddingAPIKey         string `json:"ai_embedding_api_key"`
+	AIEmbeddingEnabled        bool   `json:"ai_embedding_enabled"`
+	AIEmbeddingEndpoint       string `json:"ai_embedding_endpoint"`
+	AIEmbeddingModel          string `json:"ai_embedding_model"`
+	AIEndpoint                string `json:"ai_endpoint"`
+	AIModel                   string `json:"ai_model"`
+	AIPriceTable              string `json:"ai_price_table"`
+	AISummaryProfile          string `json:"ai_summary_profile"`
+	AISummaryPrompt           string `json:"ai_summary_prompt"`
+	AITaggingProfile          string `json:"ai_tagging_profile"`
+	AITranslationProfile      string `json:"ai_translation_profile"`
+	AITranslationPrompt       string `json:"ai_translation_prompt"`
+	AIUsageDailyLimit         string `json:"ai_usage_daily_limit"`
+	AIUsageLimit              string `json:"ai_usage_limit"`
+	AIUsageMonthlyLimit       string `json:"ai_usage_monthly_limit"`
+	AIUsageTokens             string `json:"ai_usage_tokens"`
+	AutoCleanupEnabled        bool   `json:"auto_cleanup_enabled"`
+	AutoShowAllContent        bool   `json:"auto_show_all_content"`
+	BaiduAppId                string `json:"baidu_app_id"`
+	BaiduSecretKey            string `json:"baidu_secret_key"`
+	CloseToTray               bool   `json:"close_to_tray"`
+	CustomCssFile             string `json:"custom_css_file"`
+	DbBackupDir               string `json:"db_backup_dir"`
+	DbBackupEnabled           bool   `json:"db_backup_enabled"`
+	DbBackupKeepDaily         int    `json:"db_backup_keep_daily"`
+	DbBackupKeepWeekly        int    `json:"db_backup_keep_weekly"`
+	DeeplAPIKey               string `json:"deepl_api_key"`
+	DeeplEndpoint             string `json:"deepl_endpoint"`
+	DefaultViewMode           string `json:"default_view_mode"`
+	DigestCategory            string `json:"digest_category"`
+	DigestEnabled             bool   `json:"digest_enabled"`
+	DigestFrequency           string `json:"digest_frequency"`
+	DigestHour                int    `json:"digest_hour"`
+	DigestLastRun             string `json:"digest_last_run"`
+	DuplicateReadAction       string `json:"duplicate_read_action"`
+	FreshRSSAPIPassword       string `json:"freshrss_api_password"`
+	FreshRSSEnabled           bool   `json:"freshrss_enabled"`
+	FreshRSSServerUrl         string `json:"freshrss_server_url"`
+	FreshRSSUsername          string `json:"freshrss_username"`
+	FullTextFetchEnabled      bool   `json:"full_text_fetch_enabled"`
+	GoogleTranslateEndpoint   string `json:"google_translate_endpoint"`
+	HoverMarkAsRead           bool   `json:"hover_mark_as_read"`
+	ImageGalleryEnabled       bool   `json:"image_gallery_enabled"`
+	Language                  string `json:"language"`
+	LastArticleUpdate         string `json:"last_article_update"`
+	LastNetworkTest           string `json:"last_network_test"`
+	MarkdownExportFilename    string `json:"markdown_export_filename"`
+	MarkdownExportImages      bool   `json:"markdown_export_images"`
+	MarkdownExportLayout      string `json:"markdown_export_layout"`
+	MarkdownExportTemplate    string `json:"markdown_export_template"`
+	MaxArticleAgeDays         int    `json:"max_article_age_days"`
+	MaxCacheSizeMb            int    `json:"max_cache_size_mb"`
+	MaxConcurrentRefreshes    string `json:"max_concurrent_refreshes"`
+	MediaCacheEnabled         bool   `json:"media_cache_enabled"`
+	MediaCacheMaxAgeDays      int    `json:"media_cache_max_age_days"`
+	MediaCacheMaxSizeMb       int    `json:"media_cache_max_size_mb"`
+	NetworkBandwidthMbps      string `json:"network_bandwidth_mbps"`
+	NetworkLatencyMs          string `json:"network_latency_ms"`
+	NetworkSpeed              string `json:"network_speed"`
+	ObsidianEnabled           bool   `json:"obsidian_enabled"`
+	ObsidianVault             string `json:"obsidian_vault"`
+	ObsidianVaultPath         string `json:"obsidian_vault_path"`
+	ProxyEnabled              bool   `json:"proxy_enabled"`
+	ProxyHost                 string `json:"proxy_host"`
+	ProxyPassword             string `json:"proxy_password"`
+	ProxyPort                 string `json:"proxy_port"`
+	ProxyType                 string `json:"proxy_type"`
+	ProxyUsername             string `json:"proxy_username"`
+	RankingSemanticEnabled    bool   `json:"ranking_semantic_enabled"`
+	RefreshMode               string `json:"refresh_mode"`
+	Rules                     string `json:"rules"`
+	Shortcuts                 string `json:"shortcuts"`
+	ShowArticlePreviewImages  bool   `json:"show_article_preview_images"`
+	ShowHiddenArticles        bool   `json:"show_hidden_articles"`
+	StartupOnBoot             bool   `json:"startup_on_boot"`
+	SummaryAutoCategories     string `json:"summary_auto_categories"`
+	SummaryAutoFeeds          string `json:"summary_auto_feeds"`
+	SummaryEnabled            bool   `json:"summary_enabled"`
+	SummaryLength             string `json:"summary_length"`
+	SummaryProvider           string `json:"summary_provider"`
+	SummaryQueueConcurrency   int    `json:"summary_queue_concurrency"`
+	SummaryTriggerMode        string `json:"summary_trigger_mode"`
+	SyncAppId                 string `json:"sync_app_id"`
+	SyncAppKey                string `json:"sync_app_key"`
+	SyncEnabled               bool   `json:"sync_enabled"`
+	SyncInterval              int    `json:"sync_interval"`
+	SyncPassword              string `json:"sync_password"`
+	SyncProvider              string `json:"sync_provider"`
+	SyncServerUrl             string `json:"sync_server_url"`
+	SyncUsername              string `json:"sync_username"`
+	TaggingEnabled            bool   `json:"tagging_enabled"`
+	TaggingProvider           string `json:"tagging_provider"`
+	TaggingTaxonomy           string `json:"tagging_taxonomy"`
+	TargetLanguage            string `json:"target_language"`
+	Theme                     string `json:"theme"`
+	TranslationEnabled        bool   `json:"translation_enabled"`
+	TranslationProvider       string `json:"translation_provider"`
+	TranslationSourceLanguage string `json:"translation_source_language"`
+	UpdateInterval            int    `json:"update_interval"`
+	WindowHeight              string `json:"window_height"`
+	WindowMaximized           string `json:"window_maximized"`
+	WindowWidth               string `json:"window_width"`
+	WindowX                   string `json:"window_x"`
+	WindowY                   string `json:"window_y"`
 }
 
 var defaults Defaults
@@ -105,20 +147,44 @@ func GetString(key string) string {
 	switch key {
 	case "ai_api_key":
 		return defaults.AIAPIKey
+	case "ai_backend":
+		return defaults.AIBackend
 	case "ai_chat_enabled":
 		return strconv.FormatBool(defaults.AIChatEnabled)
+	case "ai_chat_profile":
+		return defaults.AIChatProfile
 	case "ai_custom_headers":
 		return defaults.AICustomHeaders
+	case "ai_embedding_api_key":
+		return defaults.AIEmbeddingAPIKey
+	case "ai_embedding_enabled":
+		return strconv.FormatBool(defaults.AIEmbeddingEnabled)
+	case "ai_embedding_endpoint":
+		return defaults.AIEmbeddingEndpoint
+	case "ai_embedding_model":
+		return defaults.AIEmbeddingModel
 	case "ai_endpoint":
 		return defaults.AIEndpoint
 	case "ai_model":
 		return defaults.AIModel
+	case "ai_price_table":
+		return defaults.AIPriceTable
+	case "ai_summary_profile":
+		return defaults.AISummaryProfile
 	case "ai_summary_prompt":
 		return defaults.AISummaryPrompt
+	case "ai_tagging_profile":
+		return defaults.AITaggingProfile
+	case "ai_translation_profile":
+		return defaults.AITranslationProfile
 	case "ai_translation_prompt":
 		return defaults.AITranslationPrompt
+	case "ai_usage_daily_limit":
+		return defaults.AIUsageDailyLimit
 	case "ai_usage_limit":
 		return defaults.AIUsageLimit
+	case "ai_usage_monthly_limit":
+		return defaults.AIUsageMonthlyLimit
 	case "ai_usage_tokens":
 		return defaults.AIUsageTokens
 	case "auto_cleanup_enabled":
@@ -133,12 +199,32 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.CloseToTray)
 	case "custom_css_file":
 		return defaults.CustomCssFile
+	case "db_backup_dir":
+		return defaults.DbBackupDir
+	case "db_backup_enabled":
+		return strconv.FormatBool(defaults.DbBackupEnabled)
+	case "db_backup_keep_daily":
+		return strconv.Itoa(defaults.DbBackupKeepDaily)
+	case "db_backup_keep_weekly":
+		return strconv.Itoa(defaults.DbBackupKeepWeekly)
 	case "deepl_api_key":
 		return defaults.DeeplAPIKey
 	case "deepl_endpoint":
 		return defaults.DeeplEndpoint
 	case "default_view_mode":
 		return defaults.DefaultViewMode
+	case "digest_category":
+		return defaults.DigestCategory
+	case "digest_enabled":
+		return strconv.FormatBool(defaults.DigestEnabled)
+	case "digest_frequency":
+		return defaults.DigestFrequency
+	case "digest_hour":
+		return strconv.Itoa(defaults.DigestHour)
+	case "digest_last_run":
+		return defaults.DigestLastRun
+	case "duplicate_read_action":
+		return defaults.DuplicateReadAction
 	case "freshrss_api_password":
 		return defaults.FreshRSSAPIPassword
 	case "freshrss_enabled":
@@ -161,6 +247,14 @@ func GetString(key string) string {
 		return defaults.LastArticleUpdate
 	case "last_network_test":
 		return defaults.LastNetworkTest
+	case "markdown_export_filename":
+		return defaults.MarkdownExportFilename
+	case "markdown_export_images":
+		return strconv.FormatBool(defaults.MarkdownExportImages)
+	case "markdown_export_layout":
+		return defaults.MarkdownExportLayout
+	case "markdown_export_template":
+		return defaults.MarkdownExportTemplate
 	case "max_article_age_days":
 		return strconv.Itoa(defaults.MaxArticleAgeDays)
 	case "max_cache_size_mb":
@@ -197,6 +291,8 @@ func GetString(key string) string {
 		return defaults.ProxyType
 	case "proxy_username":
 		return defaults.ProxyUsername
+	case "ranking_semantic_enabled":
+		return strconv.FormatBool(defaults.RankingSemanticEnabled)
 	case "refresh_mode":
 		return defaults.RefreshMode
 	case "rules":
@@ -209,14 +305,42 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.ShowHiddenArticles)
 	case "startup_on_boot":
 		return strconv.FormatBool(defaults.StartupOnBoot)
+	case "summary_auto_categories":
+		return defaults.SummaryAutoCategories
+	case "summary_auto_feeds":
+		return defaults.SummaryAutoFeeds
 	case "summary_enabled":
 		return strconv.FormatBool(defaults.SummaryEnabled)
 	case "summary_length":
 		return defaults.SummaryLength
 	case "summary_provider":
 		return defaults.SummaryProvider
+	case "summary_queue_concurrency":
+		return strconv.Itoa(defaults.SummaryQueueConcurrency)
 	case "summary_trigger_mode":
 		return defaults.SummaryTriggerMode
+	case "sync_app_id":
+		return defaults.SyncAppId
+	case "sync_app_key":
+		return defaults.SyncAppKey
+	case "sync_enabled":
+		return strconv.FormatBool(defaults.SyncEnabled)
+	case "sync_interval":
+		return strconv.Itoa(defaults.SyncInterval)
+	case "sync_password":
+		return defaults.SyncPassword
+	case "sync_provider":
+		return defaults.SyncProvider
+	case "sync_server_url":
+		return defaults.SyncServerUrl
+	case "sync_username":
+		return defaults.SyncUsername
+	case "tagging_enabled":
+		return strconv.FormatBool(defaults.TaggingEnabled)
+	case "tagging_provider":
+		return defaults.TaggingProvider
+	case "tagging_taxonomy":
+		return defaults.TaggingTaxonomy
 	case "target_language":
 		return defaults.TargetLanguage
 	case "theme":
@@ -225,6 +349,8 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.TranslationEnabled)
 	case "translation_provider":
 		return defaults.TranslationProvider
+	case "translation_source_language":
+		return defaults.TranslationSourceLanguage
 	case "update_interval":
 		return strconv.Itoa(defaults.UpdateInterval)
 	case "window_height":
@@ -7,5 +7,5 @@ package config
 
 // SettingsKeys returns all valid setting keys
 func SettingsKeys() []string {
-	return []string{"ai_api_key", "ai_chat_enabled", "ai_custom_headers", "ai_endpoint", "ai_model", "ai_summary_prompt", "ai_translation_prompt", "ai_usage_limit", "ai_usage_tokens", "auto_cleanup_enabled", "auto_show_all_content", "baidu_app_id", "baidu_secret_key", "close_to_tray", "custom_css_file", "deepl_api_key", "deepl_endpoint", "default_view_mode", "freshrss_api_password", "freshrss_enabled", "freshrss_server_url", "freshrss_username", "full_text_fetch_enabled", "google_translate_endpoint", "hover_mark_as_read", "image_gallery_enabled", "language", "last_article_update", "last_network_test", "max_article_age_days", "max_cache_size_mb", "max_concurrent_refreshes", "media_cache_enabled", "media_cache_max_age_days", "media_cache_max_size_mb", "network_bandwidth_mbps", "network_latency_ms", "network_speed", "obsidian_enabled", "obsidian_vault", "obsidian_vault_path", "proxy_enabled", "proxy_host", "proxy_password", "proxy_port", "proxy_type", "proxy_username", "refresh_mode", "rules", "shortcuts", "show_article_preview_images", "show_hidden_articles", "startup_on_boot", "summary_enabled", "summary_length", "summary_provider", "summary_trigger_mode", "target_language", "theme", "translation_enabled", "translation_provider", "update_interval", "window_height", "window_maximized", "window_width", "window_x", "window_y"}
+	return []string{"ai_api_key", "ai_backend", "ai_chat_enabled", "ai_chat_profile", "ai_custom_headers", "ai_embedding_api_key", "ai_embedding_enabled", "ai_embedding_endpoint", "ai_embedding_model", "ai_endpoint", "ai_model", "ai_price_table", "ai_summary_profile", "ai_summary_prompt", "ai_tagging_profile", "ai_translation_profile", "ai_translation_prompt", "ai_usage_daily_limit", "ai_usage_limit", "ai_usage_monthly_limit", "ai_usage_tokens", "auto_cleanup_enabled", "auto_show_all_content", "baidu_app_id", "baidu_secret_key", "close_to_tray", "custom_css_file", "db_backup_dir", "db_backup_enabled", "db_backup_keep_daily", "db_backup_keep_weekly", "deepl_api_key", "deepl_endpoint", "default_view_mode", "digest_category", "digest_enabled", "digest_frequency", "digest_hour", "digest_last_run", "duplicate_read_action", "freshrss_api_password", "freshrss_enabled", "freshrss_server_url", "freshrss_username", "full_text_fetch_enabled", "google_translate_endpoint", "hover_mark_as_read", "image_gallery_enabled", "language", "last_article_update", "last_network_test", "markdown_export_filename", "markdown_export_images", "markdown_export_layout", "markdown_export_template", "max_article_age_days", "max_cache_size_mb", "max_concurrent_refreshes", "media_cache_enabled", "media_cache_max_age_days", "media_cache_max_size_mb", "network_bandwidth_mbps", "network_latency_ms", "network_speed", "obsidian_enabled", "obsidian_vault", "obsidian_vault_path", "proxy_enabled", "proxy_host", "proxy_password", "proxy_port", "proxy_type", "proxy_username", "ranking_semantic_enabled", "refresh_mode", "rules", "shortcuts", "show_article_preview_images", "show_hidden_articles", "startup_on_boot", "summary_auto_categories", "summary_auto_feeds", "summary_enabled", "summary_length", "summary_provider", "summary_queue_concurrency", "summary_trigger_mode", "sync_app_id", "sync_app_key", "sync_enabled", "sync_interval", "sync_password", "sync_provider", "sync_server_url", "sync_username", "tagging_enabled", "tagging_provider", "tagging_taxonomy", "target_language", "theme", "translation_enabled", "translation_provider", "translation_source_language", "update_interval", "window_height", "window_maximized", "window_width", "window_x", "window_y"}
 }
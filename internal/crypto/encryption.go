@@ -25,6 +25,8 @@ const (
 	saltSize = 16
 	// Version marker to identify encrypted values (prevents false positives in IsEncrypted)
 	versionMarker = "MrRSS-v1:"
+	// Version marker of values encrypted with a passphrase, e.g. in backups
+	passphraseMarker = "MrRSS-passphrase-v1:"
 )
 
 var (
@@ -32,6 +34,8 @@ var (
 	ErrInvalidCiphertext = errors.New("invalid ciphertext")
 	// ErrDecryptionFailed is returned when decryption fails
 	ErrDecryptionFailed = errors.New("decryption failed")
+	// ErrEmptyPassphrase is returned when encrypting or decrypting with an empty passphrase
+	ErrEmptyPassphrase = errors.New("passphrase is empty")
 )
 
 // GetMachineID generates a machine-specific identifier for key derivation.
@@ -84,6 +88,56 @@ func Encrypt(plaintext string) (string, error) {
 		return "", fmt.Errorf("failed to get machine ID: %w", err)
 	}
 
+	return seal(plaintext, machineID, versionMarker)
+}
+
+// Decrypt decrypts ciphertext that was encrypted with Encrypt.
+// The input must be version-prefixed base64-encoded and contain: [salt][nonce][ciphertext+tag]
+func Decrypt(ciphertextBase64 string) (string, error) {
+	if ciphertextBase64 == "" {
+		return "", nil
+	}
+
+	// Get machine ID for key derivation
+	machineID, err := GetMachineID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get machine ID: %w", err)
+	}
+
+	return open(ciphertextBase64, machineID, versionMarker)
+}
+
+// EncryptWithPassphrase encrypts plaintext like Encrypt, but with a key derived from a
+// passphrase instead of the machine, so it can be decrypted on other machines.
+func EncryptWithPassphrase(plaintext, passphrase string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if passphrase == "" {
+		return "", ErrEmptyPassphrase
+	}
+	return seal(plaintext, passphrase, passphraseMarker)
+}
+
+// DecryptWithPassphrase decrypts ciphertext that was encrypted with EncryptWithPassphrase.
+func DecryptWithPassphrase(ciphertextBase64, passphrase string) (string, error) {
+	if ciphertextBase64 == "" {
+		return "", nil
+	}
+	if passphrase == "" {
+		return "", ErrEmptyPassphrase
+	}
+	return open(ciphertextBase64, passphrase, passphraseMarker)
+}
+
+// IsPassphraseEncrypted checks if a value was encrypted with EncryptWithPassphrase.
+func IsPassphraseEncrypted(value string) bool {
+	return strings.HasPrefix(value, passphraseMarker)
+}
+
+// seal encrypts plaintext with a key derived from secret and returns it base64-encoded
+// after marker.
+func seal(plaintext, secret, marker string) (string, error) {
 	// Generate random salt
 	salt := make([]byte, saltSize)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
@@ -91,7 +145,7 @@ func Encrypt(plaintext string) (string, error) {
 	}
 
 	// Derive encryption key
-	key := DeriveKey(machineID, salt)
+	key := DeriveKey(secret, salt)
 
 	// Create AES cipher
 	block, err := aes.NewCipher(key)
@@ -122,21 +176,16 @@ func Encrypt(plaintext string) (string, error) {
 
 	// Encode to base64 and prepend version marker for safe storage
 	encoded := base64.StdEncoding.EncodeToString(result)
-	return versionMarker + encoded, nil
+	return marker + encoded, nil
 }
 
-// Decrypt decrypts ciphertext that was encrypted with Encrypt.
-// The input must be version-prefixed base64-encoded and contain: [salt][nonce][ciphertext+tag]
-func Decrypt(ciphertextBase64 string) (string, error) {
-	if ciphertextBase64 == "" {
-		return "", nil
-	}
-
+// open decrypts a value sealed with the same secret and marker.
+func open(ciphertextBase64, secret, marker string) (string, error) {
 	// Check and strip version marker
-	if !strings.HasPrefix(ciphertextBase64, versionMarker) {
+	if !strings.HasPrefix(ciphertextBase64, marker) {
 		return "", fmt.Errorf("missing or invalid version marker")
 	}
-	ciphertextBase64 = strings.TrimPrefix(ciphertextBase64, versionMarker)
+	ciphertextBase64 = strings.TrimPrefix(ciphertextBase64, marker)
 
 	// Decode from base64
 	data, err := base64.StdEncoding.DecodeString(ciphertextBase64)
@@ -152,14 +201,8 @@ func Decrypt(ciphertextBase64 string) (string, error) {
 	// Extract salt
 	salt := data[:saltSize]
 
-	// Get machine ID for key derivation
-	machineID, err := GetMachineID()
-	if err != nil {
-		return "", fmt.Errorf("failed to get machine ID: %w", err)
-	}
-
 	// Derive decryption key
-	key := DeriveKey(machineID, salt)
+	key := DeriveKey(secret, salt)
 
 	// Create AES cipher
 	block, err := aes.NewCipher(key)
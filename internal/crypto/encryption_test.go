@@ -109,6 +109,32 @@ func TestDecryptInvalidInput(t *testing.T) {
 	}
 }
 
+func TestEncryptWithPassphrase(t *testing.T) {
+	encrypted, err := EncryptWithPassphrase("test-api-key-123", "correct horse")
+	if err != nil {
+		t.Fatalf("EncryptWithPassphrase() error = %v", err)
+	}
+	if !IsPassphraseEncrypted(encrypted) || IsEncrypted(encrypted) {
+		t.Errorf("expected a passphrase-encrypted value, got %q", encrypted)
+	}
+
+	decrypted, err := DecryptWithPassphrase(encrypted, "correct horse")
+	if err != nil || decrypted != "test-api-key-123" {
+		t.Errorf("DecryptWithPassphrase() = %q, %v", decrypted, err)
+	}
+	if _, err := DecryptWithPassphrase(encrypted, "wrong"); err != ErrDecryptionFailed {
+		t.Errorf("expected ErrDecryptionFailed with a wrong passphrase, got %v", err)
+	}
+	if _, err := EncryptWithPassphrase("secret", ""); err != ErrEmptyPassphrase {
+		t.Errorf("expected ErrEmptyPassphrase, got %v", err)
+	}
+	// Machine-encrypted values are not accepted
+	machineEncrypted, _ := Encrypt("secret")
+	if _, err := DecryptWithPassphrase(machineEncrypted, "correct horse"); err == nil {
+		t.Error("expected an error for a machine-encrypted value")
+	}
+}
+
 func TestIsEncrypted(t *testing.T) {
 	// Encrypt a sample value
 	plaintext := "test-api-key-123"
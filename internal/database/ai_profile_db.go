@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/models"
+)
+
+// aiProfileSettingKeys are the settings that select an AI profile for a feature.
+var aiProfileSettingKeys = []string{"ai_translation_profile", "ai_summary_profile", "ai_chat_profile", "ai_tagging_profile"}
+
+const aiProfileColumns = `id, name, COALESCE(backend, ''), COALESCE(endpoint, ''), COALESCE(model, ''),
+	COALESCE(api_key, ''), COALESCE(custom_headers, ''), COALESCE(temperature, 0), COALESCE(max_tokens, 0),
+	COALESCE(context_window, 0), COALESCE(usage_tokens, 0), created_at, updated_at`
+
+// GetAIProfiles returns all AI profiles with their secrets decrypted.
+func (db *DB) GetAIProfiles() ([]models.AIProfile, error) {
+	db.WaitForReady()
+	rows, err := db.Query("SELECT " + aiProfileColumns + " FROM ai_profiles ORDER BY name ASC, id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []models.AIProfile
+	for rows.Next() {
+		p, err := scanAIProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// GetAIProfile retrieves a single AI profile with its secrets decrypted.
+func (db *DB) GetAIProfile(id int64) (*models.AIProfile, error) {
+	db.WaitForReady()
+	return scanAIProfile(db.QueryRow("SELECT "+aiProfileColumns+" FROM ai_profiles WHERE id = ?", id))
+}
+
+// AddAIProfile inserts an AI profile, encrypting its API key and custom headers.
+func (db *DB) AddAIProfile(profile *models.AIProfile) (int64, error) {
+	db.WaitForReady()
+	apiKey, headers, err := encryptAIProfileSecrets(profile)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO ai_profiles (name, backend, endpoint, model, api_key, custom_headers, temperature, max_tokens, context_window)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		profile.Name, profile.Backend, profile.Endpoint, profile.Model, apiKey, headers,
+		profile.Temperature, profile.MaxTokens, profile.ContextWindow,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateAIProfile updates an AI profile's configuration. Usage counters are left unchanged.
+func (db *DB) UpdateAIProfile(profile *models.AIProfile) error {
+	db.WaitForReady()
+	apiKey, headers, err := encryptAIProfileSecrets(profile)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(
+		`UPDATE ai_profiles SET name = ?, backend = ?, endpoint = ?, model = ?, api_key = ?, custom_headers = ?,
+		 temperature = ?, max_tokens = ?, context_window = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		profile.Name, profile.Backend, profile.Endpoint, profile.Model, apiKey, headers,
+		profile.Temperature, profile.MaxTokens, profile.ContextWindow, profile.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteAIProfile removes an AI profile. Features that selected it fall back to the global AI settings.
+func (db *DB) DeleteAIProfile(id int64) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM ai_profiles WHERE id = ?", id); err != nil {
+		return err
+	}
+	for _, key := range aiProfileSettingKeys {
+		if _, err := tx.Exec("UPDATE settings SET value = '' WHERE key = ? AND value = ?", key, strconv.FormatInt(id, 10)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddAIProfileUsage adds tokens to a profile's usage counter.
+func (db *DB) AddAIProfileUsage(id int64, tokens int64) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE ai_profiles SET usage_tokens = usage_tokens + ? WHERE id = ?", tokens, id)
+	return err
+}
+
+// ResetAIProfileUsage resets the usage counters of all AI profiles.
+func (db *DB) ResetAIProfileUsage() error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE ai_profiles SET usage_tokens = 0")
+	return err
+}
+
+// scanAIProfile reads one ai_profiles row and decrypts its secrets.
+func scanAIProfile(row interface{ Scan(dest ...any) error }) (*models.AIProfile, error) {
+	var p models.AIProfile
+	err := row.Scan(&p.ID, &p.Name, &p.Backend, &p.Endpoint, &p.Model, &p.APIKey, &p.CustomHeaders,
+		&p.Temperature, &p.MaxTokens, &p.ContextWindow, &p.UsageTokens, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if p.APIKey, err = decryptAIProfileSecret(p.APIKey); err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key of AI profile %d: %w", p.ID, err)
+	}
+	if p.CustomHeaders, err = decryptAIProfileSecret(p.CustomHeaders); err != nil {
+		return nil, fmt.Errorf("failed to decrypt custom headers of AI profile %d: %w", p.ID, err)
+	}
+	return &p, nil
+}
+
+// encryptAIProfileSecrets returns the encrypted API key and custom headers of a profile.
+func encryptAIProfileSecrets(profile *models.AIProfile) (string, string, error) {
+	var apiKey, headers string
+	var err error
+	if profile.APIKey != "" {
+		if apiKey, err = crypto.Encrypt(profile.APIKey); err != nil {
+			return "", "", fmt.Errorf("failed to encrypt API key: %w", err)
+		}
+	}
+	if profile.CustomHeaders != "" {
+		if headers, err = crypto.Encrypt(profile.CustomHeaders); err != nil {
+			return "", "", fmt.Errorf("failed to encrypt custom headers: %w", err)
+		}
+	}
+	return apiKey, headers, nil
+}
+
+// decryptAIProfileSecret decrypts a stored secret, passing through empty and plain text values.
+func decryptAIProfileSecret(value string) (string, error) {
+	if value == "" || !crypto.IsEncrypted(value) {
+		return value, nil
+	}
+	return crypto.Decrypt(value)
+}
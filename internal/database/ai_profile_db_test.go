@@ -0,0 +1,94 @@
+package database_test
+
+import (
+	"strings"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestAIProfileCRUD(t *testing.T) {
+	db := setupTestDB(t)
+
+	profile := &models.AIProfile{
+		Name:          "Hosted",
+		Endpoint:      "https://api.openai.com/v1/chat/completions",
+		Model:         "gpt-4o",
+		APIKey:        "sk-secret",
+		CustomHeaders: `{"X-Team":"news"}`,
+		Temperature:   0.5,
+		MaxTokens:     2048,
+		ContextWindow: 128000,
+	}
+	id, err := db.AddAIProfile(profile)
+	if err != nil {
+		t.Fatalf("AddAIProfile() error = %v", err)
+	}
+
+	// Secrets are stored encrypted
+	var storedKey, storedHeaders string
+	if err := db.QueryRow("SELECT api_key, custom_headers FROM ai_profiles WHERE id = ?", id).Scan(&storedKey, &storedHeaders); err != nil {
+		t.Fatalf("query error = %v", err)
+	}
+	if strings.Contains(storedKey, "sk-secret") || strings.Contains(storedHeaders, "news") {
+		t.Errorf("secrets stored in plain text: %q %q", storedKey, storedHeaders)
+	}
+
+	got, err := db.GetAIProfile(id)
+	if err != nil {
+		t.Fatalf("GetAIProfile() error = %v", err)
+	}
+	if got.APIKey != "sk-secret" || got.CustomHeaders != `{"X-Team":"news"}` || got.MaxTokens != 2048 || got.ContextWindow != 128000 {
+		t.Errorf("unexpected profile %+v", got)
+	}
+
+	got.Model = "gpt-4o-mini"
+	if err := db.UpdateAIProfile(got); err != nil {
+		t.Fatalf("UpdateAIProfile() error = %v", err)
+	}
+	if err := db.AddAIProfileUsage(id, 150); err != nil {
+		t.Fatalf("AddAIProfileUsage() error = %v", err)
+	}
+	profiles, err := db.GetAIProfiles()
+	if err != nil {
+		t.Fatalf("GetAIProfiles() error = %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Model != "gpt-4o-mini" || profiles[0].UsageTokens != 150 {
+		t.Errorf("unexpected profiles %+v", profiles)
+	}
+
+	if _, err := db.AddAIProfile(&models.AIProfile{Name: "Hosted", Model: "x"}); err == nil {
+		t.Error("expected an error for a duplicate name")
+	}
+
+	if err := db.ResetAIProfileUsage(); err != nil {
+		t.Fatalf("ResetAIProfileUsage() error = %v", err)
+	}
+	if got, _ := db.GetAIProfile(id); got.UsageTokens != 0 {
+		t.Errorf("usage after reset = %d", got.UsageTokens)
+	}
+}
+
+func TestDeleteAIProfileClearsSelection(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := db.AddAIProfile(&models.AIProfile{Name: "Local", Endpoint: "http://localhost:11434/api/chat", Model: "llama3"})
+	if err != nil {
+		t.Fatalf("AddAIProfile() error = %v", err)
+	}
+	db.SetSetting("ai_translation_profile", "1")
+	db.SetSetting("ai_chat_profile", "42")
+
+	if err := db.DeleteAIProfile(id); err != nil {
+		t.Fatalf("DeleteAIProfile() error = %v", err)
+	}
+	if v, _ := db.GetSetting("ai_translation_profile"); v != "" {
+		t.Errorf("ai_translation_profile = %q, want empty", v)
+	}
+	if v, _ := db.GetSetting("ai_chat_profile"); v != "42" {
+		t.Errorf("ai_chat_profile = %q, want unchanged", v)
+	}
+	if _, err := db.GetAIProfile(id); err == nil {
+		t.Error("expected profile to be deleted")
+	}
+}
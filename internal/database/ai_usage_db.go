@@ -0,0 +1,74 @@
+package database
+
+import (
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// AddAIUsageRecord appends an AI request to the usage ledger. Times are stored in UTC.
+func (db *DB) AddAIUsageRecord(rec *models.AIUsageRecord) error {
+	db.WaitForReady()
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	result, err := db.Exec(
+		`INSERT INTO ai_usage_log (created_at, feature, profile_id, model, prompt_tokens, completion_tokens, estimated, cost, article_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.CreatedAt.UTC(), rec.Feature, rec.ProfileID, rec.Model, rec.PromptTokens, rec.CompletionTokens,
+		rec.Estimated, rec.Cost, rec.ArticleID,
+	)
+	if err != nil {
+		return err
+	}
+	rec.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAIUsageRecords returns ledger entries created in [since, until), oldest first.
+func (db *DB) GetAIUsageRecords(since, until time.Time) ([]models.AIUsageRecord, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT id, created_at, feature, COALESCE(profile_id, 0), COALESCE(model, ''), COALESCE(prompt_tokens, 0),
+		       COALESCE(completion_tokens, 0), COALESCE(estimated, 0), COALESCE(cost, 0), COALESCE(article_id, 0)
+		FROM ai_usage_log
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+	`, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.AIUsageRecord
+	for rows.Next() {
+		var r models.AIUsageRecord
+		if err := rows.Scan(&r.ID, &r.CreatedAt, &r.Feature, &r.ProfileID, &r.Model, &r.PromptTokens,
+			&r.CompletionTokens, &r.Estimated, &r.Cost, &r.ArticleID); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// GetAIUsageTokensSince returns the total tokens recorded since the given time.
+func (db *DB) GetAIUsageTokensSince(since time.Time) (int64, error) {
+	db.WaitForReady()
+	var total int64
+	err := db.QueryRow(
+		"SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0) FROM ai_usage_log WHERE created_at >= ?",
+		since.UTC(),
+	).Scan(&total)
+	return total, err
+}
+
+// DeleteAIUsageRecordsBefore removes ledger entries older than the given time.
+func (db *DB) DeleteAIUsageRecordsBefore(before time.Time) (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec("DELETE FROM ai_usage_log WHERE created_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
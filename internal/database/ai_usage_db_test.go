@@ -0,0 +1,48 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestAIUsageLedger(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	records := []models.AIUsageRecord{
+		{CreatedAt: now.AddDate(0, 0, -40), Feature: "summary", Model: "old", PromptTokens: 500},
+		{CreatedAt: now.Add(-2 * time.Hour), Feature: "translate", Model: "small", PromptTokens: 20, CompletionTokens: 5, Estimated: true},
+		{CreatedAt: now, Feature: "chat", ProfileID: 3, Model: "large", PromptTokens: 100, CompletionTokens: 40, Cost: 0.002, ArticleID: 7},
+	}
+	for i := range records {
+		if err := db.AddAIUsageRecord(&records[i]); err != nil {
+			t.Fatalf("AddAIUsageRecord() error = %v", err)
+		}
+	}
+
+	total, err := db.GetAIUsageTokensSince(now.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("GetAIUsageTokensSince() error = %v", err)
+	}
+	if total != 165 {
+		t.Errorf("tokens since yesterday = %d, want 165", total)
+	}
+
+	got, err := db.GetAIUsageRecords(now.AddDate(0, 0, -1), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetAIUsageRecords() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Model != "small" || !got[0].Estimated {
+		t.Fatalf("unexpected records %+v", got)
+	}
+	if got[1].ProfileID != 3 || got[1].ArticleID != 7 || got[1].Cost != 0.002 || got[1].Feature != "chat" {
+		t.Errorf("unexpected chat record %+v", got[1])
+	}
+
+	deleted, err := db.DeleteAIUsageRecordsBefore(now.AddDate(0, 0, -30))
+	if err != nil || deleted != 1 {
+		t.Errorf("DeleteAIUsageRecordsBefore() = %d, %v", deleted, err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"log"
+	"strings"
+	"time"
 
 	"MrRSS/internal/models"
 )
@@ -11,12 +13,13 @@ import (
 // SaveArticle saves a single article to the database.
 func (db *DB) SaveArticle(article *models.Article) error {
 	db.WaitForReady()
-	query := `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := db.Exec(query, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary)
+	query := `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary, fingerprint, word_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(query, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary, int64(article.Fingerprint), article.WordCount)
 	return err
 }
 
 // SaveArticles saves multiple articles in a transaction.
+// The ID of each newly inserted article is set on the given models; existing articles keep ID 0.
 func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) error {
 	db.WaitForReady()
 	tx, err := db.BeginTx(ctx, nil)
@@ -25,12 +28,17 @@ func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) erro
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary, fingerprint, word_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
+	clusters, err := loadClusterCandidates(ctx, tx, articles)
+	if err != nil {
+		return err
+	}
+
 	for _, article := range articles {
 		// Check context before each insert
 		select {
@@ -39,23 +47,42 @@ func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) erro
 		default:
 		}
 
-		_, err := stmt.ExecContext(ctx, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary)
+		result, err := stmt.ExecContext(ctx, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary, int64(article.Fingerprint), article.WordCount)
 		if err != nil {
 			log.Println("Error saving article in batch:", err)
 			// Continue even if one fails
+			continue
+		}
+		// Record the ID of newly inserted articles; duplicates are ignored and keep ID 0
+		if n, _ := result.RowsAffected(); n > 0 {
+			article.ID, _ = result.LastInsertId()
+			if err := clusters.assign(ctx, tx, article); err != nil {
+				log.Println("Error clustering article:", err)
+			}
+			if err := saveArticleKeywords(ctx, tx, article); err != nil {
+				log.Println("Error saving article keywords:", err)
+			}
 		}
 	}
 
 	return tx.Commit()
 }
 
-// GetArticles retrieves articles with filtering, pagination, and sorting.
+// GetArticles retrieves articles with filtering and pagination, newest first.
 func (db *DB) GetArticles(filter string, feedID int64, category string, showHidden bool, limit, offset int) ([]models.Article, error) {
+	return db.GetArticlesSorted(filter, feedID, category, showHidden, SortNewest, limit, offset)
+}
+
+// GetArticlesSorted retrieves articles with filtering, pagination, and sorting.
+// The "priority" filter selects unread articles scoring at least PriorityMinScore
+// and sorts them by score unless another order is given.
+func (db *DB) GetArticlesSorted(filter string, feedID int64, category string, showHidden bool, order string, limit, offset int) ([]models.Article, error) {
 	db.WaitForReady()
 	baseQuery := `
-		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title, ` + clusterColumns + `, ` + labelColumn + `, ` + scoreColumns + `
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
+		` + scoreJoin + `
 	`
 	var args []interface{}
 	whereClauses := []string{}
@@ -73,6 +100,16 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 			whereClauses = append(whereClauses, "COALESCE(f.hide_from_timeline, 0) = 0")
 			whereClauses = append(whereClauses, "COALESCE(f.is_image_mode, 0) = 0")
 		}
+	case "priority":
+		whereClauses = append(whereClauses, "a.is_read = 0", "COALESCE(s.score, 0) >= ?")
+		args = append(args, PriorityMinScore)
+		if feedID <= 0 && category == "" {
+			whereClauses = append(whereClauses, "COALESCE(f.hide_from_timeline, 0) = 0")
+			whereClauses = append(whereClauses, "COALESCE(f.is_image_mode, 0) = 0")
+		}
+		if order == SortNewest {
+			order = SortScore
+		}
 	case "favorites":
 		whereClauses = append(whereClauses, "a.is_favorite = 1")
 	case "readLater":
@@ -105,7 +142,11 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 			query += " AND " + whereClauses[i]
 		}
 	}
-	query += " ORDER BY a.published_at DESC LIMIT ? OFFSET ?"
+	if order == SortScore {
+		query += " ORDER BY COALESCE(s.score, 0) DESC, a.published_at DESC LIMIT ? OFFSET ?"
+	} else {
+		query += " ORDER BY a.published_at DESC LIMIT ? OFFSET ?"
+	}
 	args = append(args, limit, offset)
 
 	rows, err := db.Query(query, args...)
@@ -118,7 +159,8 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 	for rows.Next() {
 		var a models.Article
 		var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
-		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+		var labels string
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle, &a.ClusterID, &a.DuplicateCount, &labels, &a.Score, &a.ScoreReason); err != nil {
 			log.Println("Error scanning article:", err)
 			continue
 		}
@@ -127,6 +169,7 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 		a.VideoURL = videoURL.String
 		a.TranslatedTitle = translatedTitle.String
 		a.Summary = summary.String
+		a.Labels = splitLabels(labels)
 		articles = append(articles, a)
 	}
 	return articles, nil
@@ -137,16 +180,18 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 func (db *DB) GetArticleByID(id int64) (*models.Article, error) {
 	db.WaitForReady()
 	query := `
-		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title, ` + clusterColumns + `, ` + labelColumn + `, ` + scoreColumns + `
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
+		` + scoreJoin + `
 		WHERE a.id = ?
 	`
 	row := db.QueryRow(query, id)
 
 	var a models.Article
 	var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
-	if err := row.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+	var labels string
+	if err := row.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle, &a.ClusterID, &a.DuplicateCount, &labels, &a.Score, &a.ScoreReason); err != nil {
 		return nil, err
 	}
 	a.ImageURL = imageURL.String
@@ -154,6 +199,7 @@ func (db *DB) GetArticleByID(id int64) (*models.Article, error) {
 	a.VideoURL = videoURL.String
 	a.TranslatedTitle = translatedTitle.String
 	a.Summary = summary.String
+	a.Labels = splitLabels(labels)
 	return &a, nil
 }
 
@@ -165,8 +211,10 @@ func (db *DB) MarkArticleRead(id int64, read bool) error {
 	if read {
 		isRead = 1
 		// When marking as read, also remove from read later
-		_, err := db.Exec("UPDATE articles SET is_read = 1, is_read_later = 0 WHERE id = ?", id)
-		return err
+		if _, err := db.Exec("UPDATE articles SET is_read = 1, is_read_later = 0 WHERE id = ?", id); err != nil {
+			return err
+		}
+		return db.applyDuplicateReadAction("id = ?", id)
 	}
 	_, err := db.Exec("UPDATE articles SET is_read = ? WHERE id = ?", isRead, id)
 	return err
@@ -318,33 +366,32 @@ func (db *DB) GetUnreadCountsForAllFeeds() (map[int64]int, error) {
 // MarkAllAsReadForFeed marks all articles in a feed as read.
 func (db *DB) MarkAllAsReadForFeed(feedID int64) error {
 	db.WaitForReady()
-	_, err := db.Exec("UPDATE articles SET is_read = 1 WHERE feed_id = ? AND is_hidden = 0", feedID)
-	return err
+	return db.markAllAsRead("feed_id = ? AND is_hidden = 0", feedID)
 }
 
 // MarkAllAsRead marks all articles as read.
 func (db *DB) MarkAllAsRead() error {
 	db.WaitForReady()
-	_, err := db.Exec("UPDATE articles SET is_read = 1 WHERE is_hidden = 0")
-	return err
+	return db.markAllAsRead("is_hidden = 0")
 }
 
 // MarkAllAsReadForCategory marks all articles in a category as read.
 func (db *DB) MarkAllAsReadForCategory(category string) error {
 	db.WaitForReady()
-	// Get all feed IDs in this category
 	// Handle empty category (uncategorized) by matching NULL or empty string
-	var query string
 	if category == "" {
-		query = `UPDATE articles SET is_read = 1
-			WHERE feed_id IN (SELECT id FROM feeds WHERE category IS NULL OR category = '') AND is_hidden = 0`
-		_, err := db.Exec(query)
+		return db.markAllAsRead("feed_id IN (SELECT id FROM feeds WHERE category IS NULL OR category = '') AND is_hidden = 0")
+	}
+	return db.markAllAsRead("feed_id IN (SELECT id FROM feeds WHERE category = ?) AND is_hidden = 0", category)
+}
+
+// markAllAsRead marks the articles that match where as read and applies the duplicate
+// read action to their copies.
+func (db *DB) markAllAsRead(where string, args ...interface{}) error {
+	if _, err := db.Exec("UPDATE articles SET is_read = 1 WHERE "+where, args...); err != nil {
 		return err
 	}
-	query = `UPDATE articles SET is_read = 1
-		WHERE feed_id IN (SELECT id FROM feeds WHERE category = ?) AND is_hidden = 0`
-	_, err := db.Exec(query, category)
-	return err
+	return db.applyDuplicateReadAction(where, args...)
 }
 
 // ClearReadLater removes all articles from the read later list.
@@ -413,3 +460,177 @@ func (db *DB) UpdateArticleSummary(id int64, summary string) error {
 	_, err := db.Exec("UPDATE articles SET summary = ? WHERE id = ?", summary, id)
 	return err
 }
+
+// GetArticlesPublishedBetween retrieves articles published in [since, until), newest first.
+// feedID and category narrow the selection when set; hidden articles are excluded.
+func (db *DB) GetArticlesPublishedBetween(since, until time.Time, feedID int64, category string, limit int) ([]models.Article, error) {
+	db.WaitForReady()
+	query := `
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		WHERE a.is_hidden = 0 AND a.published_at >= ? AND a.published_at < ?
+	`
+	args := []interface{}{since, until}
+
+	if feedID > 0 {
+		query += " AND a.feed_id = ?"
+		args = append(args, feedID)
+	}
+	if category != "" {
+		query += " AND (f.category = ? OR f.category LIKE ?)"
+		args = append(args, category, category+"/%")
+	}
+	query += " ORDER BY a.published_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []models.Article
+	for rows.Next() {
+		var a models.Article
+		var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+			return nil, err
+		}
+		a.ImageURL = imageURL.String
+		a.AudioURL = audioURL.String
+		a.VideoURL = videoURL.String
+		a.TranslatedTitle = translatedTitle.String
+		a.Summary = summary.String
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// ArticleSearch selects the articles considered by a text search.
+type ArticleSearch struct {
+	Terms      []string  // Articles must contain at least one term; none matches all articles
+	FeedIDs    []int64   // Empty matches all feeds
+	Categories []string  // Feed categories, including subcategories; empty matches all
+	Filter     string    // "unread", "favorites" or "readLater"; empty matches all articles
+	Label      string    // Label the articles must have; empty matches all
+	Since      time.Time // Zero means no lower bound on published_at
+	Until      time.Time // Zero means no upper bound on published_at
+	Limit      int
+}
+
+// ArticleText is an article with its stored content, which is empty until the content was fetched.
+type ArticleText struct {
+	models.Article
+	Content string
+}
+
+// SearchArticleTexts returns visible articles whose title, summary or stored content contains
+// any of the search terms, newest first. Ranking by relevance is left to the caller.
+func (db *DB) SearchArticleTexts(search ArticleSearch) ([]ArticleText, error) {
+	db.WaitForReady()
+	query := `
+		SELECT ` + articleTextColumns + `
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		WHERE a.is_hidden = 0
+	`
+	var args []interface{}
+
+	if len(search.Terms) > 0 {
+		var clauses []string
+		for _, term := range search.Terms {
+			clauses = append(clauses, "a.title LIKE ? OR a.translated_title LIKE ? OR a.summary LIKE ? OR a.content LIKE ?")
+			pattern := "%" + term + "%"
+			args = append(args, pattern, pattern, pattern, pattern)
+		}
+		query += " AND (" + strings.Join(clauses, " OR ") + ")"
+	}
+	if len(search.FeedIDs) > 0 {
+		query += " AND a.feed_id IN (?" + strings.Repeat(", ?", len(search.FeedIDs)-1) + ")"
+		for _, id := range search.FeedIDs {
+			args = append(args, id)
+		}
+	}
+	if len(search.Categories) > 0 {
+		var clauses []string
+		for _, category := range search.Categories {
+			clauses = append(clauses, "f.category = ? OR f.category LIKE ?")
+			args = append(args, category, category+"/%")
+		}
+		query += " AND (" + strings.Join(clauses, " OR ") + ")"
+	}
+	switch search.Filter {
+	case "unread":
+		query += " AND a.is_read = 0"
+	case "favorites":
+		query += " AND a.is_favorite = 1"
+	case "readLater":
+		query += " AND a.is_read_later = 1"
+	}
+	if search.Label != "" {
+		query += " AND EXISTS (SELECT 1 FROM article_labels l WHERE l.article_id = a.id AND l.label = ?)"
+		args = append(args, search.Label)
+	}
+	if !search.Since.IsZero() {
+		query += " AND a.published_at >= ?"
+		args = append(args, search.Since)
+	}
+	if !search.Until.IsZero() {
+		query += " AND a.published_at < ?"
+		args = append(args, search.Until)
+	}
+	query += " ORDER BY a.published_at DESC LIMIT ?"
+	args = append(args, search.Limit)
+
+	return db.queryArticleTexts(query, args...)
+}
+
+// articleTextColumns are the columns scanned by queryArticleTexts, from articles a joined with feeds f.
+const articleTextColumns = `a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title, a.content, ` + labelColumn
+
+// GetArticleTextsByIDs returns the articles with the given IDs and their stored content.
+// Missing IDs are skipped; the order of the result is unspecified.
+func (db *DB) GetArticleTextsByIDs(ids []int64) ([]ArticleText, error) {
+	db.WaitForReady()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return db.queryArticleTexts(`
+		SELECT `+articleTextColumns+`
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		WHERE a.id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)`, args...)
+}
+
+// queryArticleTexts runs a query selecting articleTextColumns and scans the results.
+func (db *DB) queryArticleTexts(query string, args ...interface{}) ([]ArticleText, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ArticleText
+	for rows.Next() {
+		var a ArticleText
+		var imageURL, audioURL, videoURL, translatedTitle, summary, content sql.NullString
+		var labels string
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle, &content, &labels); err != nil {
+			return nil, err
+		}
+		a.ImageURL = imageURL.String
+		a.AudioURL = audioURL.String
+		a.VideoURL = videoURL.String
+		a.TranslatedTitle = translatedTitle.String
+		a.Summary = summary.String
+		a.Content = content.String
+		a.Labels = splitLabels(labels)
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
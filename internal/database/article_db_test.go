@@ -223,3 +223,53 @@ func TestSaveArticlesBatchContextCancel(t *testing.T) {
 		t.Fatalf("expected error due to canceled context")
 	}
 }
+
+func TestSearchArticleTexts(t *testing.T) {
+	db := setupTestDB(t)
+
+	techID, _ := db.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed", Category: "Tech/Software"})
+	newsID, _ := db.AddFeed(&models.Feed{Title: "News", URL: "https://news.example/feed", Category: "News"})
+	now := time.Now()
+	for i, a := range []models.Article{
+		{FeedID: techID, Title: "Rust adds async closures", URL: "https://tech.example/1", PublishedAt: now.Add(-time.Hour)},
+		{FeedID: newsID, Title: "City budget", URL: "https://news.example/1", Summary: "The rust on old bridges costs money.", PublishedAt: now.Add(-2 * time.Hour)},
+		{FeedID: techID, Title: "Go 1.24 released", URL: "https://tech.example/2", PublishedAt: now.AddDate(0, -2, 0)},
+	} {
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle(%d) error = %v", i, err)
+		}
+	}
+	db.Exec("UPDATE articles SET content = ? WHERE url = ?", "<p>The Go release ships rust-free tooling.</p>", "https://tech.example/2")
+
+	results, err := db.SearchArticleTexts(dbpkg.ArticleSearch{Terms: []string{"rust"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchArticleTexts() error = %v", err)
+	}
+	if len(results) != 3 || results[2].Content == "" {
+		t.Errorf("expected matches in title, summary and content, got %+v", results)
+	}
+
+	results, _ = db.SearchArticleTexts(dbpkg.ArticleSearch{Terms: []string{"rust"}, Categories: []string{"Tech"}, Since: now.AddDate(0, 0, -7), Limit: 10})
+	if len(results) != 1 || results[0].Title != "Rust adds async closures" || results[0].FeedTitle != "Tech" {
+		t.Errorf("unexpected filtered results %+v", results)
+	}
+
+	results, _ = db.SearchArticleTexts(dbpkg.ArticleSearch{FeedIDs: []int64{newsID}, Limit: 10})
+	if len(results) != 1 || results[0].FeedID != newsID {
+		t.Errorf("unexpected feed results %+v", results)
+	}
+
+	release, _ := db.SearchArticleTexts(dbpkg.ArticleSearch{Terms: []string{"Go 1.24"}, Limit: 1})
+	if len(release) != 1 {
+		t.Fatalf("expected one release article, got %+v", release)
+	}
+	db.SetArticleFavorite(release[0].ID, true)
+	db.SetArticleLabels(release[0].ID, []string{"release"}, "local")
+	results, _ = db.SearchArticleTexts(dbpkg.ArticleSearch{Filter: "favorites", Label: "release", Limit: 10})
+	if len(results) != 1 || results[0].ID != release[0].ID || len(results[0].Labels) != 1 {
+		t.Errorf("unexpected favorite results %+v", results)
+	}
+	if results, _ = db.SearchArticleTexts(dbpkg.ArticleSearch{Filter: "favorites", Label: "other", Limit: 10}); len(results) != 0 {
+		t.Errorf("unexpected label results %+v", results)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// restorer is implemented by connections of the SQLite driver.
+type restorer interface {
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// BackupTo writes a consistent, compacted copy of the database to a new file with
+// VACUUM INTO, which is safe while other connections read and write.
+func (db *DB) BackupTo(ctx context.Context, path string) error {
+	db.WaitForReady()
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// CheckIntegrity runs the SQLite integrity check on a database file without modifying it.
+func CheckIntegrity(ctx context.Context, path string) error {
+	file, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := file.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// RestoreFrom replaces the contents of the database with a database file using the
+// SQLite backup API, then migrates the schema in case the file is from an older version.
+// Other connections see the restored contents once it completes.
+func (db *DB) RestoreFrom(ctx context.Context, path string) error {
+	db.WaitForReady()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		r, ok := driverConn.(restorer)
+		if !ok {
+			return errors.New("database driver does not support restoring")
+		}
+		backup, err := r.NewRestore(path)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if more, err = backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+		}
+		return backup.Finish()
+	})
+	if err != nil {
+		return fmt.Errorf("restore database: %w", err)
+	}
+	db.invalidateGlossary()
+	return db.migrate()
+}
@@ -0,0 +1,70 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setupFileDB(t *testing.T, path string) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db := setupFileDB(t, filepath.Join(dir, "rss.db"))
+	if _, err := db.AddFeed(&models.Feed{Title: "Kept", URL: "https://example.com/kept"}); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := filepath.Join(dir, "backup.db")
+	if err := db.BackupTo(ctx, backup); err != nil {
+		t.Fatalf("BackupTo() error = %v", err)
+	}
+	if err := database.CheckIntegrity(ctx, backup); err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+
+	// Changes after the backup are undone by the restore
+	if _, err := db.AddFeed(&models.Feed{Title: "Lost", URL: "https://example.com/lost"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RestoreFrom(ctx, backup); err != nil {
+		t.Fatalf("RestoreFrom() error = %v", err)
+	}
+
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feeds) != 1 || feeds[0].Title != "Kept" {
+		t.Fatalf("feeds after restore = %+v, want only Kept", feeds)
+	}
+	if _, err := db.AddFeed(&models.Feed{Title: "New", URL: "https://example.com/new"}); err != nil {
+		t.Fatalf("AddFeed() after restore error = %v", err)
+	}
+}
+
+func TestCheckIntegrityRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(path, []byte("this is not a database, just some bytes padded out"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CheckIntegrity(context.Background(), path); err == nil {
+		t.Fatal("CheckIntegrity() of garbage succeeded")
+	}
+}
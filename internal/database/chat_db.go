@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+
+	"MrRSS/internal/models"
+)
+
+const chatSessionColumns = `s.id, COALESCE(s.article_id, 0), COALESCE(a.title, ''), COALESCE(s.title, ''), COALESCE(s.summary, ''),
+	COALESCE(s.summarized_until, 0), (SELECT COUNT(*) FROM chat_messages m WHERE m.session_id = s.id), s.created_at, s.updated_at`
+
+// GetChatSessions returns chat sessions, most recently used first. articleID 0 returns all sessions.
+func (db *DB) GetChatSessions(articleID int64) ([]models.ChatSession, error) {
+	db.WaitForReady()
+	query := "SELECT " + chatSessionColumns + " FROM chat_sessions s LEFT JOIN articles a ON a.id = s.article_id"
+	var args []interface{}
+	if articleID > 0 {
+		query += " WHERE s.article_id = ?"
+		args = append(args, articleID)
+	}
+	query += " ORDER BY s.updated_at DESC, s.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.ChatSession
+	for rows.Next() {
+		s, err := scanChatSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetChatSession retrieves a single chat session.
+func (db *DB) GetChatSession(id int64) (*models.ChatSession, error) {
+	db.WaitForReady()
+	return scanChatSession(db.QueryRow(
+		"SELECT "+chatSessionColumns+" FROM chat_sessions s LEFT JOIN articles a ON a.id = s.article_id WHERE s.id = ?", id))
+}
+
+// CreateChatSession creates an empty chat session.
+func (db *DB) CreateChatSession(articleID int64, title string) (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec("INSERT INTO chat_sessions (article_id, title) VALUES (?, ?)", articleID, title)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// RenameChatSession changes the title of a chat session.
+func (db *DB) RenameChatSession(id int64, title string) error {
+	db.WaitForReady()
+	result, err := db.Exec("UPDATE chat_sessions SET title = ? WHERE id = ?", title, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateChatSessionSummary stores the summary of a session's turns up to and including a message.
+func (db *DB) UpdateChatSessionSummary(id int64, summary string, summarizedUntil int64) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE chat_sessions SET summary = ?, summarized_until = ? WHERE id = ?", summary, summarizedUntil, id)
+	return err
+}
+
+// DeleteChatSession removes a chat session and its messages.
+func (db *DB) DeleteChatSession(id int64) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM chat_messages WHERE session_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM chat_sessions WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetChatMessages returns the messages of a session in order.
+func (db *DB) GetChatMessages(sessionID int64) ([]models.ChatMessage, error) {
+	db.WaitForReady()
+	rows, err := db.Query(
+		"SELECT id, session_id, role, content, created_at FROM chat_messages WHERE session_id = ? ORDER BY id ASC", sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var m models.ChatMessage
+		if err := rows.Scan(&m.ID, &m.SessionID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// AddChatMessages appends messages to a session and marks it as updated.
+func (db *DB) AddChatMessages(sessionID int64, messages ...models.ChatMessage) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range messages {
+		if _, err := tx.Exec("INSERT INTO chat_messages (session_id, role, content) VALUES (?, ?, ?)", sessionID, m.Role, m.Content); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE chat_sessions SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// scanChatSession reads one chat session row.
+func scanChatSession(row interface{ Scan(dest ...any) error }) (*models.ChatSession, error) {
+	var s models.ChatSession
+	err := row.Scan(&s.ID, &s.ArticleID, &s.ArticleTitle, &s.Title, &s.Summary, &s.SummarizedUntil,
+		&s.MessageCount, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
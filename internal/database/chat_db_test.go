@@ -0,0 +1,77 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestChatSessionLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := db.CreateChatSession(5, "What is new?")
+	if err != nil {
+		t.Fatalf("CreateChatSession() error = %v", err)
+	}
+	other, err := db.CreateChatSession(6, "Other article")
+	if err != nil {
+		t.Fatalf("CreateChatSession() error = %v", err)
+	}
+
+	err = db.AddChatMessages(id,
+		models.ChatMessage{Role: "user", Content: "What is new?"},
+		models.ChatMessage{Role: "assistant", Content: "A release."})
+	if err != nil {
+		t.Fatalf("AddChatMessages() error = %v", err)
+	}
+
+	messages, err := db.GetChatMessages(id)
+	if err != nil {
+		t.Fatalf("GetChatMessages() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].Role != "user" || messages[1].Content != "A release." {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+
+	sessions, err := db.GetChatSessions(5)
+	if err != nil {
+		t.Fatalf("GetChatSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != id || sessions[0].MessageCount != 2 {
+		t.Errorf("unexpected sessions %+v", sessions)
+	}
+	if all, _ := db.GetChatSessions(0); len(all) != 2 {
+		t.Errorf("GetChatSessions(0) returned %d sessions, want 2", len(all))
+	}
+
+	if err := db.RenameChatSession(id, "Release notes"); err != nil {
+		t.Fatalf("RenameChatSession() error = %v", err)
+	}
+	if err := db.RenameChatSession(999, "x"); err != sql.ErrNoRows {
+		t.Errorf("RenameChatSession(unknown) error = %v, want sql.ErrNoRows", err)
+	}
+	if err := db.UpdateChatSessionSummary(id, "Asked about the release.", messages[1].ID); err != nil {
+		t.Fatalf("UpdateChatSessionSummary() error = %v", err)
+	}
+	session, err := db.GetChatSession(id)
+	if err != nil {
+		t.Fatalf("GetChatSession() error = %v", err)
+	}
+	if session.Title != "Release notes" || session.Summary != "Asked about the release." || session.SummarizedUntil != messages[1].ID {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	if err := db.DeleteChatSession(id); err != nil {
+		t.Fatalf("DeleteChatSession() error = %v", err)
+	}
+	if _, err := db.GetChatSession(id); err != sql.ErrNoRows {
+		t.Errorf("GetChatSession(deleted) error = %v, want sql.ErrNoRows", err)
+	}
+	if messages, _ := db.GetChatMessages(id); len(messages) != 0 {
+		t.Errorf("messages of deleted session remain: %+v", messages)
+	}
+	if _, err := db.GetChatSession(other); err != nil {
+		t.Errorf("other session should remain: %v", err)
+	}
+}
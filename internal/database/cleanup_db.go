@@ -38,6 +38,16 @@ func (db *DB) CleanupOldArticles() (int64, error) {
 	// Also cleanup translation cache with the same age limit
 	_, _ = db.CleanupTranslationCache(maxAgeDays)
 
+	// Keep thirteen months of AI usage history for monthly statistics
+	_, _ = db.DeleteAIUsageRecordsBefore(time.Now().AddDate(0, -13, 0))
+
+	// Drop the embeddings, labels and keywords of deleted articles
+	_, _ = db.DeleteOrphanedEmbeddings()
+	_, _ = db.DeleteOrphanedArticleLabels()
+	_, _ = db.DeleteOrphanedArticleKeywords()
+	_, _ = db.DeleteOrphanedArticleRanking()
+	_, _ = db.DeleteOrphanedSyncItems()
+
 	// Run VACUUM to reclaim space
 	_, _ = db.Exec("VACUUM")
 
@@ -62,6 +72,11 @@ func (db *DB) CleanupUnimportantArticles() (int64, error) {
 
 	// Also cleanup translation cache (remove entries older than 7 days)
 	_, _ = db.CleanupTranslationCache(7)
+	_, _ = db.DeleteOrphanedEmbeddings()
+	_, _ = db.DeleteOrphanedArticleLabels()
+	_, _ = db.DeleteOrphanedArticleKeywords()
+	_, _ = db.DeleteOrphanedArticleRanking()
+	_, _ = db.DeleteOrphanedSyncItems()
 
 	// Run VACUUM to reclaim space
 	_, _ = db.Exec("VACUUM")
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"MrRSS/internal/dedup"
+	"MrRSS/internal/models"
+)
+
+// clusterColumns selects the cluster of article a and the number of other articles in it.
+const clusterColumns = `COALESCE(a.cluster_id, 0), CASE WHEN COALESCE(a.cluster_id, 0) = 0 THEN 0 ELSE (SELECT COUNT(*) FROM articles d WHERE d.cluster_id = a.cluster_id AND d.id != a.id) END`
+
+// clusterCandidate is a saved article that new articles may duplicate.
+type clusterCandidate struct {
+	id          int64
+	fingerprint uint64
+	clusterID   int64
+	publishedAt time.Time
+}
+
+// clusterCandidates holds the fingerprints of articles published near a batch of new articles.
+type clusterCandidates struct {
+	items []*clusterCandidate
+}
+
+// loadClusterCandidates loads the fingerprinted articles published within dedup.Window
+// of any fingerprinted article in the batch.
+func loadClusterCandidates(ctx context.Context, tx *Tx, articles []*models.Article) (*clusterCandidates, error) {
+	var first, last time.Time
+	for _, a := range articles {
+		if a.Fingerprint == 0 {
+			continue
+		}
+		if first.IsZero() || a.PublishedAt.Before(first) {
+			first = a.PublishedAt
+		}
+		if last.IsZero() || a.PublishedAt.After(last) {
+			last = a.PublishedAt
+		}
+	}
+	candidates := &clusterCandidates{}
+	if first.IsZero() {
+		return candidates, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, fingerprint, COALESCE(cluster_id, 0), published_at FROM articles
+		WHERE fingerprint != 0 AND published_at >= ? AND published_at <= ?`,
+		first.Add(-dedup.Window), last.Add(dedup.Window))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c clusterCandidate
+		var fingerprint int64
+		if err := rows.Scan(&c.id, &fingerprint, &c.clusterID, &c.publishedAt); err != nil {
+			return nil, err
+		}
+		c.fingerprint = uint64(fingerprint)
+		candidates.items = append(candidates.items, &c)
+	}
+	return candidates, rows.Err()
+}
+
+// assign puts a newly inserted article into the cluster of its closest near-duplicate.
+// The cluster ID is the ID of the first article of the cluster.
+func (cc *clusterCandidates) assign(ctx context.Context, tx *Tx, article *models.Article) error {
+	if article.Fingerprint == 0 {
+		return nil
+	}
+
+	var best *clusterCandidate
+	bestDistance := dedup.MaxDistance + 1
+	for _, c := range cc.items {
+		gap := c.publishedAt.Sub(article.PublishedAt)
+		if gap < -dedup.Window || gap > dedup.Window || !dedup.IsDuplicate(c.fingerprint, article.Fingerprint) {
+			continue
+		}
+		if d := dedup.Distance(c.fingerprint, article.Fingerprint); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+
+	if best != nil {
+		if best.clusterID == 0 {
+			if _, err := tx.ExecContext(ctx, `UPDATE articles SET cluster_id = ? WHERE id = ?`, best.id, best.id); err != nil {
+				return err
+			}
+			best.clusterID = best.id
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE articles SET cluster_id = ? WHERE id = ?`, best.clusterID, article.ID); err != nil {
+			return err
+		}
+		article.ClusterID = best.clusterID
+	}
+
+	cc.items = append(cc.items, &clusterCandidate{
+		id:          article.ID,
+		fingerprint: article.Fingerprint,
+		clusterID:   article.ClusterID,
+		publishedAt: article.PublishedAt,
+	})
+	return nil
+}
+
+// GetDuplicateArticles returns the other articles in the near-duplicate cluster of an
+// article, oldest first.
+func (db *DB) GetDuplicateArticles(articleID int64) ([]models.Article, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title, `+clusterColumns+`
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		WHERE a.cluster_id != 0 AND a.cluster_id = (SELECT cluster_id FROM articles WHERE id = ?) AND a.id != ?
+		ORDER BY a.published_at ASC`, articleID, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := []models.Article{}
+	for rows.Next() {
+		var a models.Article
+		var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle, &a.ClusterID, &a.DuplicateCount); err != nil {
+			return nil, err
+		}
+		a.ImageURL = imageURL.String
+		a.AudioURL = audioURL.String
+		a.VideoURL = videoURL.String
+		a.TranslatedTitle = translatedTitle.String
+		a.Summary = summary.String
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// applyDuplicateReadAction marks the other copies of the read articles that match
+// where as read or hidden, depending on the duplicate_read_action setting. Favorites,
+// read later articles and the matching articles themselves are left alone.
+func (db *DB) applyDuplicateReadAction(where string, args ...interface{}) error {
+	action, _ := db.GetSetting("duplicate_read_action")
+	var set string
+	switch action {
+	case "read":
+		set = "is_read = 1"
+	case "hide":
+		set = "is_hidden = 1"
+	default:
+		return nil
+	}
+
+	_, err := db.Exec(`
+		UPDATE articles SET `+set+`
+		WHERE cluster_id != 0 AND is_favorite = 0 AND is_read_later = 0
+		AND cluster_id IN (SELECT cluster_id FROM articles WHERE cluster_id != 0 AND is_read = 1 AND (`+where+`))
+		AND id NOT IN (SELECT id FROM articles WHERE `+where+`)`, append(args, args...)...)
+	return err
+}
@@ -0,0 +1,151 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"MrRSS/internal/dedup"
+	"MrRSS/internal/models"
+)
+
+const pressRelease = "The city council approved a new budget for public transport on Monday. " +
+	"The plan adds twenty electric buses to the fleet over the next two years. " +
+	"Council members said the investment will cut emissions and shorten waiting times."
+
+func TestSaveArticlesClustersNearDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	cityID, _ := db.AddFeed(&models.Feed{Title: "City", URL: "https://city.example/feed"})
+	wireID, _ := db.AddFeed(&models.Feed{Title: "Wire", URL: "https://wire.example/feed"})
+	now := time.Now()
+
+	first := &models.Article{FeedID: cityID, Title: "Council approves transport budget", URL: "https://city.example/1", PublishedAt: now.Add(-time.Hour),
+		Fingerprint: dedup.Fingerprint("Council approves transport budget", pressRelease)}
+	other := &models.Article{FeedID: cityID, Title: "Rust adds async closures", URL: "https://city.example/2", PublishedAt: now,
+		Fingerprint: dedup.Fingerprint("Rust adds async closures", "The Rust compiler team shipped async closures in the latest release for all developers.")}
+	if err := db.SaveArticles(ctx, []*models.Article{first, other}); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+
+	copied := &models.Article{FeedID: wireID, Title: "Council approves transport budget", URL: "https://wire.example/1", PublishedAt: now,
+		Fingerprint: dedup.Fingerprint("Council approves transport budget", pressRelease+" Source: City Press Office.")}
+	old := &models.Article{FeedID: wireID, Title: "Council approves transport budget", URL: "https://wire.example/old", PublishedAt: now.AddDate(0, 0, -10),
+		Fingerprint: first.Fingerprint}
+	if err := db.SaveArticles(ctx, []*models.Article{copied, old}); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	if copied.ClusterID != first.ID {
+		t.Errorf("expected the copy in cluster %d, got %d", first.ID, copied.ClusterID)
+	}
+	if old.ClusterID != 0 {
+		t.Errorf("articles outside the time window must not be clustered, got %d", old.ClusterID)
+	}
+
+	articles, err := db.GetArticles("", 0, "", false, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticles() error = %v", err)
+	}
+	counts := make(map[int64]int)
+	for _, a := range articles {
+		counts[a.ID] = a.DuplicateCount
+	}
+	if counts[first.ID] != 1 || counts[copied.ID] != 1 || counts[other.ID] != 0 {
+		t.Errorf("unexpected duplicate counts %v", counts)
+	}
+
+	duplicates, err := db.GetDuplicateArticles(first.ID)
+	if err != nil || len(duplicates) != 1 || duplicates[0].ID != copied.ID || duplicates[0].FeedTitle != "Wire" {
+		t.Errorf("GetDuplicateArticles() = %+v, %v", duplicates, err)
+	}
+	if duplicates, _ := db.GetDuplicateArticles(other.ID); len(duplicates) != 0 {
+		t.Errorf("expected no duplicates, got %+v", duplicates)
+	}
+}
+
+func TestMarkArticleReadAppliesDuplicateAction(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	feedID, _ := db.AddFeed(&models.Feed{Title: "City", URL: "https://city.example/feed"})
+	fingerprint := dedup.Fingerprint("Council approves transport budget", pressRelease)
+	var articles []*models.Article
+	for _, url := range []string{"https://a.example/1", "https://b.example/1", "https://c.example/1"} {
+		articles = append(articles, &models.Article{FeedID: feedID, Title: "Council approves transport budget", URL: url, PublishedAt: time.Now(), Fingerprint: fingerprint})
+	}
+	if err := db.SaveArticles(ctx, articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	db.SetArticleFavorite(articles[2].ID, true)
+
+	// Without a setting nothing else changes
+	db.MarkArticleRead(articles[0].ID, true)
+	if a, _ := db.GetArticleByID(articles[1].ID); a.IsRead {
+		t.Error("duplicate marked read without duplicate_read_action")
+	}
+
+	db.SetSetting("duplicate_read_action", "hide")
+	db.MarkArticleRead(articles[0].ID, true)
+	if a, _ := db.GetArticleByID(articles[1].ID); !a.IsHidden || a.IsRead {
+		t.Errorf("expected the duplicate hidden, got %+v", a)
+	}
+	if a, _ := db.GetArticleByID(articles[2].ID); a.IsHidden {
+		t.Error("favorites must not be hidden")
+	}
+
+	db.SetSetting("duplicate_read_action", "read")
+	db.MarkArticleRead(articles[0].ID, true)
+	if a, _ := db.GetArticleByID(articles[1].ID); !a.IsRead {
+		t.Error("expected the duplicate marked read")
+	}
+}
+
+func TestBulkAndSyncReadsApplyDuplicateAction(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	db.SetSetting("duplicate_read_action", "hide")
+
+	cityID, _ := db.AddFeed(&models.Feed{Title: "City", URL: "https://city.example/feed", Category: "Local"})
+	wireID, _ := db.AddFeed(&models.Feed{Title: "Wire", URL: "https://wire.example/feed", Category: "News"})
+	saveCopies := func(title string) (city, wire *models.Article) {
+		fingerprint := dedup.Fingerprint(title, pressRelease)
+		city = &models.Article{FeedID: cityID, Title: title, URL: "https://city.example/" + title, PublishedAt: time.Now(), Fingerprint: fingerprint}
+		wire = &models.Article{FeedID: wireID, Title: title, URL: "https://wire.example/" + title, PublishedAt: time.Now(), Fingerprint: fingerprint}
+		if err := db.SaveArticles(ctx, []*models.Article{city, wire}); err != nil {
+			t.Fatalf("SaveArticles() error = %v", err)
+		}
+		return city, wire
+	}
+	hidden := func(a *models.Article) bool {
+		got, _ := db.GetArticleByID(a.ID)
+		return got.IsHidden
+	}
+
+	city, wire := saveCopies("Council approves transport budget")
+	db.MarkAllAsReadForFeed(cityID)
+	if !hidden(wire) || hidden(city) {
+		t.Error("marking a feed read did not hide the copies in other feeds")
+	}
+
+	city, wire = saveCopies("Council approves bus lanes")
+	db.MarkAllAsReadForCategory("News")
+	if !hidden(city) || hidden(wire) {
+		t.Error("marking a category read did not hide the copies in other categories")
+	}
+
+	// Copies that are all read at once stay visible
+	city, wire = saveCopies("Council approves night buses")
+	db.MarkAllAsRead()
+	if hidden(city) || hidden(wire) {
+		t.Error("marking everything read hid articles")
+	}
+
+	city, wire = saveCopies("Council approves tram line")
+	if err := db.SetArticleSyncState(wire.ID, true, false); err != nil {
+		t.Fatalf("SetArticleSyncState() error = %v", err)
+	}
+	if !hidden(city) {
+		t.Error("a read state from a sync did not hide the copies")
+	}
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"MrRSS/internal/config"
+	"MrRSS/internal/models"
 
 	_ "modernc.org/sqlite"
 )
@@ -17,6 +19,12 @@ type DB struct {
 	*sql.DB
 	ready chan struct{}
 	once  sync.Once
+	tx    *sql.Tx // The transaction of a DB of WithTx, which runs its statements
+
+	// Glossary entries cached for translation, reloaded after edits
+	glossaryMu     sync.Mutex
+	glossary       []models.GlossaryEntry
+	glossaryLoaded bool
 }
 
 // NewDB creates a new database connection with optimized settings.
@@ -56,64 +64,77 @@ func (db *DB) Init() error {
 			return
 		}
 
-		if err = initSchema(db.DB); err != nil {
-			return
-		}
+		err = db.migrate()
+	})
+	return err
+}
 
-		// Create settings table if not exists
-		_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS settings (
-			key TEXT PRIMARY KEY,
-			value TEXT
-		)`)
-
-		// Insert default settings if they don't exist (using centralized defaults from config)
-		// Note: settingsKeys is auto-generated from settings_schema.json
-		settingsKeys := config.SettingsKeys()
-		for _, key := range settingsKeys {
-			defaultVal := config.GetString(key)
-			_, _ = db.Exec(fmt.Sprintf(`INSERT OR IGNORE INTO settings (key, value) VALUES ('%s', '%s')`, key, defaultVal))
-		}
+// migrate creates the schema and the default settings, and migrates databases created
+// by older versions. It is safe to run on an up-to-date database.
+func (db *DB) migrate() error {
+	if err := initSchema(db.DB); err != nil {
+		return err
+	}
 
-		// Migration: Add link column to feeds table if it doesn't exist
-		// Note: SQLite doesn't support IF NOT EXISTS for ALTER TABLE ADD COLUMN.
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN link TEXT DEFAULT ''`)
+	// Create settings table if not exists
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT
+	)`)
+
+	// Insert default settings if they don't exist (using centralized defaults from config)
+	// Note: settingsKeys is auto-generated from settings_schema.json
+	settingsKeys := config.SettingsKeys()
+	for _, key := range settingsKeys {
+		defaultVal := config.GetString(key)
+		_, _ = db.Exec(fmt.Sprintf(`INSERT OR IGNORE INTO settings (key, value) VALUES ('%s', '%s')`, key, defaultVal))
+	}
 
-		// Migration: Add discovery_completed column to feeds table
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN discovery_completed BOOLEAN DEFAULT 0`)
+	// Migration: Move the last FreshRSS sync time from its former setting to sync_accounts
+	_, _ = db.Exec(`INSERT OR IGNORE INTO sync_accounts (provider, last_sync)
+		SELECT 'freshrss', value FROM settings WHERE key = 'freshrss_last_sync' AND value != ''`)
+	_, _ = db.Exec(`DELETE FROM settings WHERE key = 'freshrss_last_sync'`)
 
-		// Migration: Add script_path column to feeds table for custom script support
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN script_path TEXT DEFAULT ''`)
+	// Migration: Add link column to feeds table if it doesn't exist
+	// Note: SQLite doesn't support IF NOT EXISTS for ALTER TABLE ADD COLUMN.
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN link TEXT DEFAULT ''`)
 
-		// Migration: Add hide_from_timeline column to feeds table
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN hide_from_timeline BOOLEAN DEFAULT 0`)
+	// Migration: Add discovery_completed column to feeds table
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN discovery_completed BOOLEAN DEFAULT 0`)
 
-		// Migration: Add proxy and refresh interval columns to feeds table
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN proxy_url TEXT DEFAULT ''`)
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN proxy_enabled BOOLEAN DEFAULT 0`)
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN refresh_interval INTEGER DEFAULT 0`)
+	// Migration: Add script_path column to feeds table for custom script support
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN script_path TEXT DEFAULT ''`)
 
-		// Migration: Add is_image_mode column to feeds table for image gallery feature
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN is_image_mode BOOLEAN DEFAULT 0`)
+	// Migration: Add hide_from_timeline column to feeds table
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN hide_from_timeline BOOLEAN DEFAULT 0`)
 
-		// Migration: Add position column to feeds table for custom ordering
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN position INTEGER DEFAULT 0`)
+	// Migration: Add proxy and refresh interval columns to feeds table
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN proxy_url TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN proxy_enabled BOOLEAN DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN refresh_interval INTEGER DEFAULT 0`)
 
-		// Migration: Add article_view_mode column to feeds table for per-feed view mode override
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN article_view_mode TEXT DEFAULT 'global'`)
+	// Migration: Add is_image_mode column to feeds table for image gallery feature
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN is_image_mode BOOLEAN DEFAULT 0`)
 
-		// Migration: Add auto_expand_content column to feeds table for per-feed content expansion override
-		// Error is ignored - if column exists, the operation fails harmlessly.
-		_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN auto_expand_content TEXT DEFAULT 'global'`)
-	})
-	return err
+	// Migration: Add position column to feeds table for custom ordering
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN position INTEGER DEFAULT 0`)
+
+	// Migration: Add article_view_mode column to feeds table for per-feed view mode override
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN article_view_mode TEXT DEFAULT 'global'`)
+
+	// Migration: Add auto_expand_content column to feeds table for per-feed content expansion override
+	// Error is ignored - if column exists, the operation fails harmlessly.
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN auto_expand_content TEXT DEFAULT 'global'`)
+
+	return nil
 }
 
 // WaitForReady blocks until the database is initialized.
@@ -121,6 +142,89 @@ func (db *DB) WaitForReady() {
 	<-db.ready
 }
 
+// Tx is a transaction of the database. Within WithTx it is the transaction of WithTx,
+// which is left to WithTx to commit or roll back.
+type Tx struct {
+	*sql.Tx
+	shared bool
+}
+
+// Commit commits the transaction, unless it is shared.
+func (tx *Tx) Commit() error {
+	if tx.shared {
+		return nil
+	}
+	return tx.Tx.Commit()
+}
+
+// Rollback rolls the transaction back, unless it is shared.
+func (tx *Tx) Rollback() error {
+	if tx.shared {
+		return nil
+	}
+	return tx.Tx.Rollback()
+}
+
+// Begin starts a transaction, or joins the transaction of WithTx.
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction, or joins the transaction of WithTx.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if db.tx != nil {
+		return &Tx{Tx: db.tx, shared: true}, nil
+	}
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+// Exec runs a statement, in the transaction of WithTx if any.
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if db.tx != nil {
+		return db.tx.Exec(query, args...)
+	}
+	return db.DB.Exec(query, args...)
+}
+
+// Query runs a query, in the transaction of WithTx if any.
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if db.tx != nil {
+		return db.tx.Query(query, args...)
+	}
+	return db.DB.Query(query, args...)
+}
+
+// QueryRow runs a query for a single row, in the transaction of WithTx if any.
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	if db.tx != nil {
+		return db.tx.QueryRow(query, args...)
+	}
+	return db.DB.QueryRow(query, args...)
+}
+
+// WithTx calls fn with a DB that runs all its methods in one transaction, which is
+// committed if fn returns nil and rolled back otherwise. The glossary cache of the DB
+// of fn is its own, so fn should not edit the glossary.
+func (db *DB) WithTx(fn func(tx *DB) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+	db.WaitForReady()
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(&DB{DB: db.DB, ready: db.ready, tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func initSchema(db *sql.DB) error {
 	// First create tables
 	query := `
@@ -165,6 +269,259 @@ func initSchema(db *sql.DB) error {
 		UNIQUE(source_text_hash, target_lang, provider)
 	);
 
+	CREATE TABLE IF NOT EXISTS summary_queue (
+		article_id INTEGER PRIMARY KEY,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER DEFAULT 0,
+		last_error TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Newly fetched articles waiting to be labeled by the classifier, with the content
+	-- they were fetched with
+	CREATE TABLE IF NOT EXISTS tagging_queue (
+		article_id INTEGER PRIMARY KEY,
+		content TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS digests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		period TEXT DEFAULT '',
+		scope TEXT DEFAULT '',
+		window_start DATETIME,
+		window_end DATETIME,
+		provider TEXT DEFAULT '',
+		used_fallback BOOLEAN DEFAULT 0,
+		article_count INTEGER DEFAULT 0,
+		clusters TEXT DEFAULT '[]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS translation_cache_stats (
+		provider TEXT NOT NULL,
+		target_lang TEXT NOT NULL,
+		hits INTEGER DEFAULT 0,
+		misses INTEGER DEFAULT 0,
+		PRIMARY KEY(provider, target_lang)
+	);
+
+	-- Named AI provider profiles; api_key and custom_headers are encrypted
+	CREATE TABLE IF NOT EXISTS ai_profiles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		backend TEXT DEFAULT '',
+		endpoint TEXT DEFAULT '',
+		model TEXT DEFAULT '',
+		api_key TEXT DEFAULT '',
+		custom_headers TEXT DEFAULT '',
+		temperature REAL DEFAULT 0,
+		max_tokens INTEGER DEFAULT 0,
+		context_window INTEGER DEFAULT 0,
+		usage_tokens INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Ledger of AI requests for usage limits, costs and statistics
+	CREATE TABLE IF NOT EXISTS ai_usage_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME NOT NULL,
+		feature TEXT NOT NULL DEFAULT 'other',
+		profile_id INTEGER DEFAULT 0,
+		model TEXT DEFAULT '',
+		prompt_tokens INTEGER DEFAULT 0,
+		completion_tokens INTEGER DEFAULT 0,
+		estimated BOOLEAN DEFAULT 0,
+		cost REAL DEFAULT 0,
+		article_id INTEGER DEFAULT 0
+	);
+
+	-- Persisted AI chat sessions about articles
+	CREATE TABLE IF NOT EXISTS chat_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		article_id INTEGER DEFAULT 0,
+		title TEXT DEFAULT '',
+		summary TEXT DEFAULT '',
+		summarized_until INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Article embedding vectors for semantic search (little-endian float32, unit length)
+	CREATE TABLE IF NOT EXISTS article_embeddings (
+		article_id INTEGER PRIMARY KEY,
+		model TEXT NOT NULL,
+		vector BLOB NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Articles the embeddings endpoint rejected, retried a few times before giving up
+	CREATE TABLE IF NOT EXISTS embedding_failures (
+		article_id INTEGER NOT NULL,
+		model TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		failed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (article_id, model)
+	);
+
+	-- Labels assigned to articles by the classifier ("ai" or "local")
+	CREATE TABLE IF NOT EXISTS article_labels (
+		article_id INTEGER NOT NULL,
+		label TEXT NOT NULL,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (article_id, label)
+	);
+
+	-- Keyphrases and entities extracted from articles by the local summarizer
+	CREATE TABLE IF NOT EXISTS article_keywords (
+		article_id INTEGER NOT NULL,
+		term TEXT NOT NULL,
+		text TEXT NOT NULL,
+		score REAL DEFAULT 0,
+		is_entity BOOLEAN DEFAULT 0,
+		PRIMARY KEY (article_id, term)
+	);
+
+	-- User feedback the ranking model learns from: "favorite" and "read_later" when
+	-- set by the user, "read" with the reading time in seconds as value, and "skip"
+	CREATE TABLE IF NOT EXISTS article_feedback (
+		article_id INTEGER NOT NULL,
+		signal TEXT NOT NULL,
+		value REAL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (article_id, signal)
+	);
+
+	-- Importance scores of articles with the features that explain them
+	CREATE TABLE IF NOT EXISTS article_scores (
+		article_id INTEGER PRIMARY KEY,
+		score REAL NOT NULL,
+		explanation TEXT DEFAULT '',
+		reasons TEXT DEFAULT '',
+		scored_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Remote subscriptions of sync accounts mapped to local feeds, with the category
+	-- at the last sync to tell local from remote category moves
+	CREATE TABLE IF NOT EXISTS sync_feeds (
+		provider TEXT NOT NULL,
+		remote_id TEXT NOT NULL,
+		feed_id INTEGER NOT NULL,
+		category TEXT DEFAULT '',
+		PRIMARY KEY (provider, remote_id)
+	);
+
+	-- Remote items of sync accounts mapped to local articles, with the read and starred
+	-- state at the last sync and the times of the local changes since
+	CREATE TABLE IF NOT EXISTS sync_items (
+		provider TEXT NOT NULL,
+		remote_id TEXT NOT NULL,
+		article_id INTEGER NOT NULL,
+		is_read BOOLEAN DEFAULT 0,
+		is_starred BOOLEAN DEFAULT 0,
+		local_read_at DATETIME,
+		local_starred_at DATETIME,
+		PRIMARY KEY (provider, remote_id)
+	);
+
+	-- Sync accounts with the start of their last successful sync
+	CREATE TABLE IF NOT EXISTS sync_accounts (
+		provider TEXT PRIMARY KEY,
+		last_sync DATETIME
+	);
+
+	-- Local feeds kept off a sync account until the user pushes them: feeds that existed
+	-- before the first sync and feeds unsubscribed on the server
+	CREATE TABLE IF NOT EXISTS sync_local_feeds (
+		provider TEXT NOT NULL,
+		feed_id INTEGER NOT NULL,
+		PRIMARY KEY (provider, feed_id)
+	);
+
+	-- Record local read and favorite changes of synced articles, whichever code path makes them
+	CREATE TRIGGER IF NOT EXISTS sync_items_local_update
+	AFTER UPDATE OF is_read, is_favorite ON articles
+	WHEN OLD.is_read != NEW.is_read OR OLD.is_favorite != NEW.is_favorite
+	BEGIN
+		UPDATE sync_items SET
+			local_read_at = CASE WHEN OLD.is_read != NEW.is_read THEN CURRENT_TIMESTAMP ELSE local_read_at END,
+			local_starred_at = CASE WHEN OLD.is_favorite != NEW.is_favorite THEN CURRENT_TIMESTAMP ELSE local_starred_at END
+		WHERE article_id = NEW.id;
+	END;
+
+	-- Subscriptions to remote OPML files
+	CREATE TABLE IF NOT EXISTS opml_lists (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT UNIQUE NOT NULL,
+		title TEXT DEFAULT '',
+		category TEXT DEFAULT '',
+		remove_missing BOOLEAN DEFAULT 0,
+		etag TEXT DEFAULT '',
+		last_modified TEXT DEFAULT '',
+		last_checked DATETIME,
+		last_error TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Feeds of OPML lists by normalized URL, with the title and category the list gave
+	-- them to tell local edits. feed_id is 0 for feeds subscribed before the list listed
+	-- them, and rows stay when the user deletes a feed, so that lists never add it again.
+	CREATE TABLE IF NOT EXISTS opml_list_feeds (
+		list_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		feed_id INTEGER NOT NULL,
+		title TEXT DEFAULT '',
+		category TEXT DEFAULT '',
+		PRIMARY KEY (list_id, url)
+	);
+
+	-- Articles exported as Markdown notes, by export folder, with the path relative to the
+	-- folder and the hash of the note as written, so that re-exports update notes in
+	-- place and leave notes the user edited alone
+	CREATE TABLE IF NOT EXISTS markdown_exports (
+		dir TEXT NOT NULL,
+		article_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		exported_at DATETIME NOT NULL,
+		PRIMARY KEY (dir, article_id)
+	);
+
+	-- Translation glossary and do-not-translate terms
+	CREATE TABLE IF NOT EXISTS translation_glossary (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_term TEXT NOT NULL,
+		target_term TEXT DEFAULT '',
+		source_lang TEXT DEFAULT '',
+		target_lang TEXT DEFAULT '',
+		case_sensitive BOOLEAN DEFAULT 0,
+		do_not_translate BOOLEAN DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Native glossaries created on DeepL accounts (keyed by a hash of the API key) per
+	-- language pair, with the hash of the entries they hold, so they survive restarts
+	CREATE TABLE IF NOT EXISTS deepl_glossaries (
+		account TEXT NOT NULL,
+		lang_pair TEXT NOT NULL,
+		glossary_id TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		PRIMARY KEY (account, lang_pair)
+	);
+
 	-- Create indexes for better query performance
 	CREATE INDEX IF NOT EXISTS idx_articles_feed_id ON articles(feed_id);
 	CREATE INDEX IF NOT EXISTS idx_articles_published_at ON articles(published_at DESC);
@@ -182,6 +539,29 @@ func initSchema(db *sql.DB) error {
 
 	-- Translation cache index
 	CREATE INDEX IF NOT EXISTS idx_translation_cache_lookup ON translation_cache(source_text_hash, target_lang, provider);
+
+	-- Embedding index by model
+	CREATE INDEX IF NOT EXISTS idx_article_embeddings_model ON article_embeddings(model);
+
+	-- Label index for filtering by label
+	CREATE INDEX IF NOT EXISTS idx_article_labels_label ON article_labels(label);
+
+	-- Keyword index for trending keywords
+	CREATE INDEX IF NOT EXISTS idx_article_keywords_term ON article_keywords(term);
+
+	-- Score index for sorting by importance
+	CREATE INDEX IF NOT EXISTS idx_article_scores_score ON article_scores(score DESC);
+
+	-- Sync item index for finding the remote item of an article
+	CREATE INDEX IF NOT EXISTS idx_sync_items_article ON sync_items(article_id);
+
+	-- Chat indexes
+	CREATE INDEX IF NOT EXISTS idx_chat_sessions_article ON chat_sessions(article_id);
+	CREATE INDEX IF NOT EXISTS idx_chat_messages_session ON chat_messages(session_id);
+
+	-- AI usage ledger index for time ranges
+	CREATE INDEX IF NOT EXISTS idx_ai_usage_log_created_at ON ai_usage_log(created_at);
+
 	`
 	_, err := db.Exec(query)
 	if err != nil {
@@ -230,18 +610,30 @@ func runMigrations(db *sql.DB) error {
 	// Migration: Add summary column for caching AI-generated summaries
 	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN summary TEXT DEFAULT ''`)
 
+	// Migration: Add pinned column for manually corrected translations
+	_, _ = db.Exec(`ALTER TABLE translation_cache ADD COLUMN pinned BOOLEAN DEFAULT 0`)
+
+	// Migration: Add near-duplicate fingerprint and cluster columns
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN fingerprint INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN cluster_id INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_articles_cluster_id ON articles(cluster_id)`)
+
+	// Migration: Add word count of the feed content for ranking
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN word_count INTEGER DEFAULT 0`)
+
 	return nil
 }
 
 // TranslationCache represents a cached translation entry
 type TranslationCache struct {
-	ID             int64
-	SourceTextHash string
-	SourceText     string
-	TargetLang     string
-	TranslatedText string
-	Provider       string
-	CreatedAt      string
+	ID             int64  `json:"id"`
+	SourceTextHash string `json:"source_text_hash"`
+	SourceText     string `json:"source_text"`
+	TargetLang     string `json:"target_lang"`
+	TranslatedText string `json:"translated_text"`
+	Provider       string `json:"provider"`
+	Pinned         bool   `json:"pinned"`
+	CreatedAt      string `json:"created_at"`
 }
 
 // GetCachedTranslation retrieves a translation from cache if available
@@ -262,21 +654,28 @@ func (db *DB) GetCachedTranslation(sourceTextHash, targetLang, provider string)
 	return translatedText, true, nil
 }
 
-// SetCachedTranslation stores a translation in cache
+// SetCachedTranslation stores a translation in cache.
+// Pinned (manually corrected) entries are never overwritten.
 func (db *DB) SetCachedTranslation(sourceTextHash, sourceText, targetLang, translatedText, provider string) error {
 	_, err := db.Exec(
-		`INSERT OR REPLACE INTO translation_cache
+		`INSERT INTO translation_cache
 		 (source_text_hash, source_text, target_lang, translated_text, provider, created_at)
-		 VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		 VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(source_text_hash, target_lang, provider) DO UPDATE SET
+		 source_text = excluded.source_text,
+		 translated_text = excluded.translated_text,
+		 created_at = excluded.created_at
+		 WHERE translation_cache.pinned = 0`,
 		sourceTextHash, sourceText, targetLang, translatedText, provider,
 	)
 	return err
 }
 
-// CleanupTranslationCache removes cached translations older than maxAgeDays
+// CleanupTranslationCache removes cached translations older than maxAgeDays.
+// Pinned entries are kept.
 func (db *DB) CleanupTranslationCache(maxAgeDays int) (int64, error) {
 	result, err := db.Exec(
-		`DELETE FROM translation_cache WHERE created_at < datetime('now', ?)`,
+		`DELETE FROM translation_cache WHERE created_at < datetime('now', ?) AND pinned = 0`,
 		fmt.Sprintf("-%d days", maxAgeDays),
 	)
 	if err != nil {
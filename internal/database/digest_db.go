@@ -0,0 +1,81 @@
+package database
+
+import (
+	"encoding/json"
+
+	"MrRSS/internal/models"
+)
+
+// SaveDigest stores a generated digest and returns its ID.
+func (db *DB) SaveDigest(digest *models.Digest) (int64, error) {
+	db.WaitForReady()
+	clusters, err := json.Marshal(digest.Clusters)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO digests (title, period, scope, window_start, window_end, provider, used_fallback, article_count, clusters)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		digest.Title, digest.Period, digest.Scope, digest.WindowStart, digest.WindowEnd,
+		digest.Provider, digest.UsedFallback, digest.ArticleCount, string(clusters),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetDigests lists stored digests, newest first. Clusters are not loaded.
+func (db *DB) GetDigests(limit, offset int) ([]models.Digest, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT id, title, COALESCE(period, ''), COALESCE(scope, ''), window_start, window_end,
+		       COALESCE(provider, ''), COALESCE(used_fallback, 0), COALESCE(article_count, 0), created_at
+		FROM digests
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var digests []models.Digest
+	for rows.Next() {
+		var d models.Digest
+		if err := rows.Scan(&d.ID, &d.Title, &d.Period, &d.Scope, &d.WindowStart, &d.WindowEnd,
+			&d.Provider, &d.UsedFallback, &d.ArticleCount, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+	return digests, rows.Err()
+}
+
+// GetDigestByID retrieves a digest including its clusters.
+func (db *DB) GetDigestByID(id int64) (*models.Digest, error) {
+	db.WaitForReady()
+	var d models.Digest
+	var clusters string
+	err := db.QueryRow(`
+		SELECT id, title, COALESCE(period, ''), COALESCE(scope, ''), window_start, window_end,
+		       COALESCE(provider, ''), COALESCE(used_fallback, 0), COALESCE(article_count, 0),
+		       COALESCE(clusters, '[]'), created_at
+		FROM digests WHERE id = ?`, id,
+	).Scan(&d.ID, &d.Title, &d.Period, &d.Scope, &d.WindowStart, &d.WindowEnd,
+		&d.Provider, &d.UsedFallback, &d.ArticleCount, &clusters, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(clusters), &d.Clusters); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// DeleteDigest removes a stored digest.
+func (db *DB) DeleteDigest(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec("DELETE FROM digests WHERE id = ?", id)
+	return err
+}
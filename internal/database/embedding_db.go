@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	// maxEmbeddingAttempts is how often an article is sent again after the endpoint rejected it
+	maxEmbeddingAttempts = 3
+	// embeddingRetryDelay is the SQLite modifier for the wait before a rejected article is retried
+	embeddingRetryDelay = "-1 hour"
+)
+
+// SaveArticleEmbedding stores the embedding of an article, replacing any previous one.
+func (db *DB) SaveArticleEmbedding(articleID int64, model string, vector []float32) error {
+	db.WaitForReady()
+	if _, err := db.Exec(`INSERT OR REPLACE INTO article_embeddings (article_id, model, vector, created_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		articleID, model, encodeVector(vector)); err != nil {
+		return err
+	}
+	_, err := db.Exec(`DELETE FROM embedding_failures WHERE article_id = ? AND model = ?`, articleID, model)
+	return err
+}
+
+// RecordEmbeddingFailure records that the endpoint rejected an article. The article is
+// left out of GetArticlesWithoutEmbedding for a while, and for good after a few attempts.
+func (db *DB) RecordEmbeddingFailure(articleID int64, model, lastError string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`
+		INSERT INTO embedding_failures (article_id, model, attempts, last_error, failed_at)
+		VALUES (?, ?, 1, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(article_id, model) DO UPDATE SET
+			attempts = attempts + 1, last_error = excluded.last_error, failed_at = excluded.failed_at`,
+		articleID, model, lastError)
+	return err
+}
+
+// CountEmbeddingFailures returns the number of visible articles that model failed to
+// embed and that are no longer retried.
+func (db *DB) CountEmbeddingFailures(model string) (int, error) {
+	db.WaitForReady()
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*)
+		FROM embedding_failures ef
+		JOIN articles a ON a.id = ef.article_id
+		WHERE a.is_hidden = 0 AND ef.model = ? AND ef.attempts >= ?`, model, maxEmbeddingAttempts).Scan(&count)
+	return count, err
+}
+
+// GetArticleEmbedding returns the embedding of an article and the model that produced it.
+// It returns sql.ErrNoRows if the article has no embedding.
+func (db *DB) GetArticleEmbedding(articleID int64) (string, []float32, error) {
+	db.WaitForReady()
+	var model string
+	var blob []byte
+	if err := db.QueryRow(`SELECT model, vector FROM article_embeddings WHERE article_id = ?`, articleID).Scan(&model, &blob); err != nil {
+		return "", nil, err
+	}
+	vector, err := decodeVector(blob)
+	return model, vector, err
+}
+
+// GetArticleEmbeddings returns all embeddings produced by a model, keyed by article ID.
+func (db *DB) GetArticleEmbeddings(model string) (map[int64][]float32, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT article_id, vector FROM article_embeddings WHERE model = ?`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vectors := make(map[int64][]float32)
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, err
+		}
+		vector, err := decodeVector(blob)
+		if err != nil {
+			return nil, err
+		}
+		vectors[id] = vector
+	}
+	return vectors, rows.Err()
+}
+
+// GetArticlesWithoutEmbedding returns visible articles that have no embedding from model, newest first.
+// Articles the endpoint rejected recently or too often are left out.
+func (db *DB) GetArticlesWithoutEmbedding(model string, limit int) ([]ArticleText, error) {
+	db.WaitForReady()
+	return db.queryArticleTexts(`
+		SELECT `+articleTextColumns+`
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		LEFT JOIN article_embeddings e ON e.article_id = a.id AND e.model = ?
+		LEFT JOIN embedding_failures ef ON ef.article_id = a.id AND ef.model = ?
+		WHERE a.is_hidden = 0 AND e.article_id IS NULL
+		AND (ef.article_id IS NULL OR (ef.attempts < ? AND ef.failed_at < datetime('now', ?)))
+		ORDER BY a.published_at DESC
+		LIMIT ?`, model, model, maxEmbeddingAttempts, embeddingRetryDelay, limit)
+}
+
+// CountArticleEmbeddings returns the number of visible articles with an embedding from
+// model and the total number of visible articles.
+func (db *DB) CountArticleEmbeddings(model string) (int, int, error) {
+	db.WaitForReady()
+	var indexed, total int
+	err := db.QueryRow(`
+		SELECT COUNT(e.article_id), COUNT(*)
+		FROM articles a
+		LEFT JOIN article_embeddings e ON e.article_id = a.id AND e.model = ?
+		WHERE a.is_hidden = 0`, model).Scan(&indexed, &total)
+	return indexed, total, err
+}
+
+// DeleteArticleEmbeddings removes all embeddings and recorded failures so they are computed again.
+func (db *DB) DeleteArticleEmbeddings() (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM article_embeddings`)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.Exec(`DELETE FROM embedding_failures`); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteOrphanedEmbeddings removes the embeddings and recorded failures of deleted articles.
+func (db *DB) DeleteOrphanedEmbeddings() (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM article_embeddings WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.Exec(`DELETE FROM embedding_failures WHERE article_id NOT IN (SELECT id FROM articles)`); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// encodeVector serializes a vector as little-endian float32 values.
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector parses a vector stored by encodeVector.
+func decodeVector(blob []byte) ([]float32, error) {
+	if len(blob)%4 != 0 {
+		return nil, errors.New("invalid embedding vector")
+	}
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return vector, nil
+}
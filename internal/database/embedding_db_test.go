@@ -0,0 +1,98 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestArticleEmbeddings(t *testing.T) {
+	db := setupTestDB(t)
+
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed"})
+	now := time.Now()
+	for i, a := range []models.Article{
+		{FeedID: feedID, Title: "First", URL: "https://tech.example/1", PublishedAt: now.Add(-2 * time.Hour)},
+		{FeedID: feedID, Title: "Second", URL: "https://tech.example/2", PublishedAt: now.Add(-time.Hour)},
+	} {
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle(%d) error = %v", i, err)
+		}
+	}
+
+	pending, err := db.GetArticlesWithoutEmbedding("m1", 10)
+	if err != nil {
+		t.Fatalf("GetArticlesWithoutEmbedding() error = %v", err)
+	}
+	if len(pending) != 2 || pending[0].Title != "Second" || pending[0].FeedTitle != "Tech" {
+		t.Fatalf("unexpected pending articles %+v", pending)
+	}
+	first, second := pending[1].ID, pending[0].ID
+
+	if err := db.SaveArticleEmbedding(first, "m1", []float32{0.6, -0.8}); err != nil {
+		t.Fatalf("SaveArticleEmbedding() error = %v", err)
+	}
+	model, vector, err := db.GetArticleEmbedding(first)
+	if err != nil || model != "m1" || len(vector) != 2 || vector[0] != 0.6 || vector[1] != -0.8 {
+		t.Errorf("GetArticleEmbedding() = %q, %v, %v", model, vector, err)
+	}
+	if _, _, err := db.GetArticleEmbedding(second); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	indexed, total, err := db.CountArticleEmbeddings("m1")
+	if err != nil || indexed != 1 || total != 2 {
+		t.Errorf("CountArticleEmbeddings(m1) = %d, %d, %v", indexed, total, err)
+	}
+	if indexed, _, _ := db.CountArticleEmbeddings("m2"); indexed != 0 {
+		t.Errorf("expected no m2 embeddings, got %d", indexed)
+	}
+	pending, _ = db.GetArticlesWithoutEmbedding("m1", 10)
+	if len(pending) != 1 || pending[0].ID != second {
+		t.Errorf("unexpected pending articles %+v", pending)
+	}
+
+	// Rejected articles wait before a retry and are given up after a few attempts
+	db.RecordEmbeddingFailure(second, "m1", "input rejected")
+	if pending, _ := db.GetArticlesWithoutEmbedding("m1", 10); len(pending) != 0 {
+		t.Errorf("expected a recently rejected article to be skipped, got %+v", pending)
+	}
+	db.Exec("UPDATE embedding_failures SET failed_at = datetime('now', '-2 hours')")
+	if pending, _ := db.GetArticlesWithoutEmbedding("m1", 10); len(pending) != 1 {
+		t.Errorf("expected the rejected article to be retried, got %+v", pending)
+	}
+	db.RecordEmbeddingFailure(second, "m1", "input rejected")
+	db.RecordEmbeddingFailure(second, "m1", "input rejected")
+	db.Exec("UPDATE embedding_failures SET failed_at = datetime('now', '-2 hours')")
+	if pending, _ := db.GetArticlesWithoutEmbedding("m1", 10); len(pending) != 0 {
+		t.Errorf("expected the article to be given up, got %+v", pending)
+	}
+	if n, err := db.CountEmbeddingFailures("m1"); err != nil || n != 1 {
+		t.Errorf("CountEmbeddingFailures() = %d, %v", n, err)
+	}
+
+	vectors, err := db.GetArticleEmbeddings("m1")
+	if err != nil || len(vectors) != 1 || len(vectors[first]) != 2 {
+		t.Errorf("GetArticleEmbeddings() = %v, %v", vectors, err)
+	}
+
+	texts, err := db.GetArticleTextsByIDs([]int64{second, 9999})
+	if err != nil || len(texts) != 1 || texts[0].Title != "Second" {
+		t.Errorf("GetArticleTextsByIDs() = %+v, %v", texts, err)
+	}
+
+	db.Exec("DELETE FROM articles WHERE id = ?", first)
+	if n, err := db.DeleteOrphanedEmbeddings(); err != nil || n != 1 {
+		t.Errorf("DeleteOrphanedEmbeddings() = %d, %v", n, err)
+	}
+
+	db.SaveArticleEmbedding(second, "m1", []float32{1})
+	if n, _ := db.CountEmbeddingFailures("m1"); n != 0 {
+		t.Errorf("expected an embedding to clear the failure, got %d", n)
+	}
+	if n, err := db.DeleteArticleEmbeddings(); err != nil || n != 1 {
+		t.Errorf("DeleteArticleEmbeddings() = %d, %v", n, err)
+	}
+}
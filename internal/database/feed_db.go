@@ -199,6 +199,13 @@ func (db *DB) UpdateFeedLink(id int64, link string) error {
 	return err
 }
 
+// UpdateFeedDetails updates a feed's title, homepage link and description.
+func (db *DB) UpdateFeedDetails(id int64, title, link, description string) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE feeds SET title = ?, link = ?, description = ? WHERE id = ?", title, link, description, id)
+	return err
+}
+
 // UpdateFeedError updates a feed's error message.
 func (db *DB) UpdateFeedError(id int64, errorMsg string) error {
 	db.WaitForReady()
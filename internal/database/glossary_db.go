@@ -0,0 +1,225 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+
+	"MrRSS/internal/models"
+)
+
+// GetGlossaryEntries returns all glossary and do-not-translate entries. The entries are
+// cached, since every translation applies them, until the glossary is edited.
+func (db *DB) GetGlossaryEntries() ([]models.GlossaryEntry, error) {
+	db.WaitForReady()
+	db.glossaryMu.Lock()
+	defer db.glossaryMu.Unlock()
+	if !db.glossaryLoaded {
+		entries, err := db.queryGlossaryEntries()
+		if err != nil {
+			return nil, err
+		}
+		db.glossary, db.glossaryLoaded = entries, true
+	}
+	if db.glossary == nil {
+		return nil, nil
+	}
+	return append([]models.GlossaryEntry(nil), db.glossary...), nil
+}
+
+// invalidateGlossary drops the cached glossary entries.
+func (db *DB) invalidateGlossary() {
+	db.glossaryMu.Lock()
+	db.glossary, db.glossaryLoaded = nil, false
+	db.glossaryMu.Unlock()
+}
+
+// queryGlossaryEntries reads all glossary entries from the database.
+func (db *DB) queryGlossaryEntries() ([]models.GlossaryEntry, error) {
+	rows, err := db.Query(`
+		SELECT id, source_term, COALESCE(target_term, ''), COALESCE(source_lang, ''), COALESCE(target_lang, ''),
+		       COALESCE(case_sensitive, 0), COALESCE(do_not_translate, 0), created_at
+		FROM translation_glossary
+		ORDER BY source_term ASC, id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.GlossaryEntry
+	for rows.Next() {
+		var e models.GlossaryEntry
+		if err := rows.Scan(&e.ID, &e.SourceTerm, &e.TargetTerm, &e.SourceLang, &e.TargetLang, &e.CaseSensitive, &e.DoNotTranslate, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetGlossaryEntryByID retrieves a single glossary entry.
+func (db *DB) GetGlossaryEntryByID(id int64) (*models.GlossaryEntry, error) {
+	db.WaitForReady()
+	var e models.GlossaryEntry
+	err := db.QueryRow(`
+		SELECT id, source_term, COALESCE(target_term, ''), COALESCE(source_lang, ''), COALESCE(target_lang, ''),
+		       COALESCE(case_sensitive, 0), COALESCE(do_not_translate, 0), created_at
+		FROM translation_glossary WHERE id = ?
+	`, id).Scan(&e.ID, &e.SourceTerm, &e.TargetTerm, &e.SourceLang, &e.TargetLang, &e.CaseSensitive, &e.DoNotTranslate, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// AddGlossaryEntry inserts a glossary entry and invalidates cached translations containing its term.
+func (db *DB) AddGlossaryEntry(entry *models.GlossaryEntry) (int64, error) {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO translation_glossary (source_term, target_term, source_lang, target_lang, case_sensitive, do_not_translate)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.SourceTerm, entry.TargetTerm, entry.SourceLang, entry.TargetLang, entry.CaseSensitive, entry.DoNotTranslate,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := invalidateCacheForTerm(tx, *entry); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	db.invalidateGlossary()
+	return id, nil
+}
+
+// UpdateGlossaryEntry updates a glossary entry and invalidates cached translations
+// containing either the old or the new term.
+func (db *DB) UpdateGlossaryEntry(entry *models.GlossaryEntry) error {
+	db.WaitForReady()
+	old, err := db.GetGlossaryEntryByID(entry.ID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE translation_glossary SET source_term = ?, target_term = ?, source_lang = ?, target_lang = ?,
+		 case_sensitive = ?, do_not_translate = ? WHERE id = ?`,
+		entry.SourceTerm, entry.TargetTerm, entry.SourceLang, entry.TargetLang, entry.CaseSensitive, entry.DoNotTranslate, entry.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := invalidateCacheForTerm(tx, *old); err != nil {
+		return err
+	}
+	if err := invalidateCacheForTerm(tx, *entry); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	db.invalidateGlossary()
+	return nil
+}
+
+// DeleteGlossaryEntry removes a glossary entry and invalidates cached translations containing its term.
+func (db *DB) DeleteGlossaryEntry(id int64) error {
+	db.WaitForReady()
+	old, err := db.GetGlossaryEntryByID(id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM translation_glossary WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := invalidateCacheForTerm(tx, *old); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	db.invalidateGlossary()
+	return nil
+}
+
+// invalidateCacheForTerm deletes cached translations whose source text contains the entry's term,
+// so the next translation picks up the changed glossary.
+func invalidateCacheForTerm(tx *Tx, entry models.GlossaryEntry) error {
+	term := strings.TrimSpace(entry.SourceTerm)
+	if term == "" {
+		return nil
+	}
+
+	// Pinned entries are manual corrections and are left alone
+	query := "DELETE FROM translation_cache WHERE pinned = 0 AND "
+	var args []interface{}
+	if entry.CaseSensitive {
+		query += "instr(source_text, ?) > 0"
+		args = append(args, term)
+	} else {
+		query += "instr(lower(source_text), ?) > 0"
+		args = append(args, strings.ToLower(term))
+	}
+	if entry.TargetLang != "" {
+		query += " AND lower(target_lang) LIKE ?"
+		args = append(args, strings.ToLower(entry.TargetLang)+"%")
+	}
+
+	_, err := tx.Exec(query, args...)
+	return err
+}
+
+// GetDeepLGlossary returns the ID and entries hash of the DeepL glossary recorded for an
+// account and language pair, or an empty ID if there is none.
+func (db *DB) GetDeepLGlossary(account, langPair string) (string, string, error) {
+	db.WaitForReady()
+	var id, hash string
+	err := db.QueryRow("SELECT glossary_id, hash FROM deepl_glossaries WHERE account = ? AND lang_pair = ?", account, langPair).Scan(&id, &hash)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return id, hash, err
+}
+
+// SaveDeepLGlossary records the DeepL glossary of an account and language pair.
+func (db *DB) SaveDeepLGlossary(account, langPair, id, hash string) error {
+	db.WaitForReady()
+	_, err := db.Exec("INSERT OR REPLACE INTO deepl_glossaries (account, lang_pair, glossary_id, hash) VALUES (?, ?, ?, ?)", account, langPair, id, hash)
+	return err
+}
+
+// DeleteDeepLGlossary removes the record of the DeepL glossary of an account and language pair.
+func (db *DB) DeleteDeepLGlossary(account, langPair string) error {
+	db.WaitForReady()
+	_, err := db.Exec("DELETE FROM deepl_glossaries WHERE account = ? AND lang_pair = ?", account, langPair)
+	return err
+}
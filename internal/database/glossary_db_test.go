@@ -0,0 +1,89 @@
+package database_test
+
+import (
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestGlossaryCRUDInvalidatesCache(t *testing.T) {
+	db := setupTestDB(t)
+
+	cacheEntries := []struct {
+		hash, text, lang string
+	}{
+		{"h1", "MrRSS released a new version", "zh"},
+		{"h2", "Unrelated headline", "zh"},
+		{"h3", "mrrss weekly digest", "ja"},
+	}
+	for _, c := range cacheEntries {
+		if err := db.SetCachedTranslation(c.hash, c.text, c.lang, "translated", "google"); err != nil {
+			t.Fatalf("SetCachedTranslation() error = %v", err)
+		}
+	}
+
+	entry := &models.GlossaryEntry{SourceTerm: "MrRSS", DoNotTranslate: true, CaseSensitive: true}
+	id, err := db.AddGlossaryEntry(entry)
+	if err != nil {
+		t.Fatalf("AddGlossaryEntry() error = %v", err)
+	}
+
+	if _, found, _ := db.GetCachedTranslation("h1", "zh", "google"); found {
+		t.Errorf("cached translation containing the term was not invalidated")
+	}
+	if _, found, _ := db.GetCachedTranslation("h2", "zh", "google"); !found {
+		t.Errorf("unrelated cached translation was invalidated")
+	}
+	if _, found, _ := db.GetCachedTranslation("h3", "ja", "google"); !found {
+		t.Errorf("case-sensitive term invalidated a differently-cased entry")
+	}
+
+	// Switching to case-insensitive should invalidate the lowercase entry too
+	entry.ID = id
+	entry.CaseSensitive = false
+	if err := db.UpdateGlossaryEntry(entry); err != nil {
+		t.Fatalf("UpdateGlossaryEntry() error = %v", err)
+	}
+	if _, found, _ := db.GetCachedTranslation("h3", "ja", "google"); found {
+		t.Errorf("case-insensitive update did not invalidate matching entry")
+	}
+
+	entries, err := db.GetGlossaryEntries()
+	if err != nil {
+		t.Fatalf("GetGlossaryEntries() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].CaseSensitive || !entries[0].DoNotTranslate {
+		t.Fatalf("unexpected entries after update: %+v", entries)
+	}
+
+	if err := db.DeleteGlossaryEntry(id); err != nil {
+		t.Fatalf("DeleteGlossaryEntry() error = %v", err)
+	}
+	entries, _ = db.GetGlossaryEntries()
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries after delete, got %d", len(entries))
+	}
+}
+
+func TestDeepLGlossaryRecords(t *testing.T) {
+	db := setupTestDB(t)
+
+	if id, _, err := db.GetDeepLGlossary("acct", "en|zh"); err != nil || id != "" {
+		t.Fatalf("GetDeepLGlossary() = %q, %v, want no glossary", id, err)
+	}
+	if err := db.SaveDeepLGlossary("acct", "en|zh", "g-1", "hash"); err != nil {
+		t.Fatalf("SaveDeepLGlossary() error = %v", err)
+	}
+	if id, hash, _ := db.GetDeepLGlossary("acct", "en|zh"); id != "g-1" || hash != "hash" {
+		t.Errorf("GetDeepLGlossary() = %q, %q", id, hash)
+	}
+	if id, _, _ := db.GetDeepLGlossary("other", "en|zh"); id != "" {
+		t.Errorf("glossary of another account returned: %q", id)
+	}
+	if err := db.DeleteDeepLGlossary("acct", "en|zh"); err != nil {
+		t.Fatalf("DeleteDeepLGlossary() error = %v", err)
+	}
+	if id, _, _ := db.GetDeepLGlossary("acct", "en|zh"); id != "" {
+		t.Errorf("deleted glossary returned: %q", id)
+	}
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+const (
+	// DefaultTrendingLimit is the number of trending keywords returned by default
+	DefaultTrendingLimit = 20
+	// DefaultTrendingMinCount is the number of articles a trending keyword needs by default
+	DefaultTrendingMinCount = 2
+)
+
+// KeywordTrendQuery selects the articles considered for trending keywords.
+type KeywordTrendQuery struct {
+	Since      time.Time
+	Until      time.Time
+	FeedIDs    []int64  // Empty matches all feeds
+	Categories []string // Feed categories, including subcategories; empty matches all
+	MinCount   int      // Minimum number of articles; 0 uses DefaultTrendingMinCount
+	Limit      int      // 0 uses DefaultTrendingLimit
+}
+
+// saveArticleKeywords stores the keywords of a newly inserted article.
+func saveArticleKeywords(ctx context.Context, tx *Tx, article *models.Article) error {
+	for _, k := range article.Keywords {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO article_keywords (article_id, term, text, score, is_entity) VALUES (?, ?, ?, ?, ?)`,
+			article.ID, k.Term, k.Text, k.Score, k.Entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetArticleKeywords returns the keywords of an article, best first.
+func (db *DB) GetArticleKeywords(articleID int64) ([]models.ArticleKeyword, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT term, text, score, is_entity FROM article_keywords WHERE article_id = ? ORDER BY score DESC, term ASC`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keywords := []models.ArticleKeyword{}
+	for rows.Next() {
+		var k models.ArticleKeyword
+		if err := rows.Scan(&k.Term, &k.Text, &k.Score, &k.Entity); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, k)
+	}
+	return keywords, rows.Err()
+}
+
+// GetTrendingKeywords returns the keywords of visible articles published in a time window,
+// ranked by the number of articles mentioning them and their growth compared with the
+// window of the same length before it.
+func (db *DB) GetTrendingKeywords(query KeywordTrendQuery) ([]models.TrendingKeyword, error) {
+	db.WaitForReady()
+	if query.MinCount <= 0 {
+		query.MinCount = DefaultTrendingMinCount
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultTrendingLimit
+	}
+	previousSince := query.Since.Add(-query.Until.Sub(query.Since))
+
+	sqlQuery := `
+		SELECT k.term, MIN(k.text), MAX(k.is_entity),
+			SUM(CASE WHEN a.published_at >= ? THEN 1 ELSE 0 END) AS current,
+			SUM(CASE WHEN a.published_at < ? THEN 1 ELSE 0 END),
+			COUNT(DISTINCT CASE WHEN a.published_at >= ? THEN a.feed_id END)
+		FROM article_keywords k
+		JOIN articles a ON a.id = k.article_id
+		JOIN feeds f ON f.id = a.feed_id
+		WHERE a.is_hidden = 0 AND a.published_at >= ? AND a.published_at < ?
+	`
+	args := []interface{}{query.Since, query.Since, query.Since, previousSince, query.Until}
+
+	if len(query.FeedIDs) > 0 {
+		sqlQuery += " AND a.feed_id IN (?" + strings.Repeat(", ?", len(query.FeedIDs)-1) + ")"
+		for _, id := range query.FeedIDs {
+			args = append(args, id)
+		}
+	}
+	if len(query.Categories) > 0 {
+		var clauses []string
+		for _, category := range query.Categories {
+			clauses = append(clauses, "f.category = ? OR f.category LIKE ?")
+			args = append(args, category, category+"/%")
+		}
+		sqlQuery += " AND (" + strings.Join(clauses, " OR ") + ")"
+	}
+	sqlQuery += " GROUP BY k.term HAVING current >= ?"
+	args = append(args, query.MinCount)
+
+	rows, err := db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trending := []models.TrendingKeyword{}
+	for rows.Next() {
+		var k models.TrendingKeyword
+		if err := rows.Scan(&k.Term, &k.Text, &k.Entity, &k.Count, &k.PreviousCount, &k.Feeds); err != nil {
+			return nil, err
+		}
+		k.Score = trendScore(k.Count, k.PreviousCount)
+		trending = append(trending, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].Term < trending[j].Term
+	})
+	if len(trending) > query.Limit {
+		trending = trending[:query.Limit]
+	}
+	return trending, nil
+}
+
+// trendScore weights the number of articles by their growth over the previous window,
+// so a keyword in as many articles as before scores its count and a rising one more.
+func trendScore(count, previous int) float64 {
+	return float64(count) * math.Log2(1+float64(count+1)/float64(previous+1))
+}
+
+// DeleteOrphanedArticleKeywords removes the keywords of articles that no longer exist.
+func (db *DB) DeleteOrphanedArticleKeywords() (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM article_keywords WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
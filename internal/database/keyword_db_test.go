@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestTrendingKeywords(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	newsID, _ := db.AddFeed(&models.Feed{Title: "News", URL: "https://news.example/feed", Category: "News"})
+	techID, _ := db.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed", Category: "Tech"})
+	now := time.Now()
+
+	apple := models.ArticleKeyword{Term: "apple", Text: "Apple", Score: 1, Entity: true}
+	rust := models.ArticleKeyword{Term: "rust", Text: "Rust", Score: 1, Entity: true}
+	budget := models.ArticleKeyword{Term: "budget", Text: "budget", Score: 0.5}
+	var articles []*models.Article
+	add := func(feedID int64, age time.Duration, keywords ...models.ArticleKeyword) {
+		articles = append(articles, &models.Article{FeedID: feedID, Title: fmt.Sprintf("Article %d", len(articles)),
+			URL: fmt.Sprintf("https://example.com/%d", len(articles)), PublishedAt: now.Add(-age), Keywords: keywords})
+	}
+	// Apple is new today in two feeds; budget is as common as yesterday; rust only once today
+	add(newsID, time.Hour, apple, budget)
+	add(techID, 2*time.Hour, apple)
+	add(techID, 3*time.Hour, apple, rust)
+	add(newsID, 4*time.Hour, budget)
+	add(newsID, 30*time.Hour, budget)
+	add(newsID, 31*time.Hour, budget)
+	add(techID, 32*time.Hour, rust)
+	if err := db.SaveArticles(ctx, articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+
+	keywords, err := db.GetArticleKeywords(articles[0].ID)
+	if err != nil || len(keywords) != 2 || keywords[0] != apple {
+		t.Errorf("GetArticleKeywords() = %+v, %v", keywords, err)
+	}
+
+	day := database.KeywordTrendQuery{Since: now.Add(-24 * time.Hour), Until: now.Add(time.Minute)}
+	trending, err := db.GetTrendingKeywords(day)
+	if err != nil {
+		t.Fatalf("GetTrendingKeywords() error = %v", err)
+	}
+	if len(trending) != 2 {
+		t.Fatalf("expected apple and budget, got %+v", trending)
+	}
+	if got := trending[0]; got.Term != "apple" || got.Text != "Apple" || !got.Entity || got.Count != 3 || got.Feeds != 2 || got.PreviousCount != 0 {
+		t.Errorf("unexpected first keyword %+v", got)
+	}
+	if got := trending[1]; got.Term != "budget" || got.Count != 2 || got.PreviousCount != 2 || got.Score >= trending[0].Score {
+		t.Errorf("unexpected second keyword %+v", got)
+	}
+
+	tech := day
+	tech.Categories = []string{"Tech"}
+	tech.MinCount = 1
+	trending, _ = db.GetTrendingKeywords(tech)
+	if len(trending) != 2 || trending[0].Term != "apple" || trending[1].Term != "rust" {
+		t.Errorf("unexpected keywords for Tech %+v", trending)
+	}
+
+	news := day
+	news.FeedIDs = []int64{newsID}
+	trending, _ = db.GetTrendingKeywords(news)
+	if len(trending) != 1 || trending[0].Term != "budget" {
+		t.Errorf("unexpected keywords for the News feed %+v", trending)
+	}
+
+	// Hidden articles do not count
+	db.SetArticleHidden(articles[1].ID, true)
+	db.SetArticleHidden(articles[2].ID, true)
+	trending, _ = db.GetTrendingKeywords(day)
+	if len(trending) != 1 || trending[0].Term != "budget" {
+		t.Errorf("unexpected keywords without hidden articles %+v", trending)
+	}
+
+	db.Exec("DELETE FROM articles WHERE id = ?", articles[0].ID)
+	if n, err := db.DeleteOrphanedArticleKeywords(); err != nil || n != 2 {
+		t.Errorf("DeleteOrphanedArticleKeywords() = %d, %v", n, err)
+	}
+}
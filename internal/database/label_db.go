@@ -0,0 +1,101 @@
+package database
+
+import (
+	"sort"
+	"strings"
+
+	"MrRSS/internal/models"
+)
+
+// labelSeparator joins the labels of an article in labelColumn.
+const labelSeparator = "\x1f"
+
+// labelColumn selects the labels of article a, joined by labelSeparator.
+const labelColumn = `COALESCE((SELECT GROUP_CONCAT(l.label, char(31)) FROM article_labels l WHERE l.article_id = a.id), '')`
+
+// splitLabels splits the result of labelColumn into sorted labels.
+func splitLabels(value string) []string {
+	if value == "" {
+		return nil
+	}
+	labels := strings.Split(value, labelSeparator)
+	sort.Strings(labels)
+	return labels
+}
+
+// SetArticleLabels replaces the labels of an article. source records who assigned
+// them, "ai" or "local", or "import" for the tags of imported articles.
+func (db *DB) SetArticleLabels(articleID int64, labels []string, source string) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM article_labels WHERE article_id = ?`, articleID); err != nil {
+		return err
+	}
+	for _, label := range labels {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO article_labels (article_id, label, source) VALUES (?, ?, ?)`, articleID, label, source); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetArticleLabels returns the labels of an article, sorted by name.
+func (db *DB) GetArticleLabels(articleID int64) ([]string, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT label FROM article_labels WHERE article_id = ? ORDER BY label ASC`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// GetLabelCounts returns every assigned label with the number of visible articles
+// that have it, most used first.
+func (db *DB) GetLabelCounts() ([]models.LabelCount, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT l.label, COUNT(*) FROM article_labels l
+		JOIN articles a ON a.id = l.article_id
+		WHERE a.is_hidden = 0
+		GROUP BY l.label
+		ORDER BY COUNT(*) DESC, l.label ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.LabelCount{}
+	for rows.Next() {
+		var c models.LabelCount
+		if err := rows.Scan(&c.Label, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// DeleteOrphanedArticleLabels removes the labels of articles that no longer exist.
+func (db *DB) DeleteOrphanedArticleLabels() (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM article_labels WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
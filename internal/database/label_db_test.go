@@ -0,0 +1,57 @@
+package database_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestArticleLabels(t *testing.T) {
+	db := setupTestDB(t)
+
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed"})
+	var ids []int64
+	for i, a := range []models.Article{
+		{FeedID: feedID, Title: "First", URL: "https://tech.example/1", PublishedAt: time.Now().Add(-time.Hour)},
+		{FeedID: feedID, Title: "Second", URL: "https://tech.example/2", PublishedAt: time.Now()},
+	} {
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle(%d) error = %v", i, err)
+		}
+		var id int64
+		db.QueryRow("SELECT id FROM articles WHERE url = ?", a.URL).Scan(&id)
+		ids = append(ids, id)
+	}
+
+	if err := db.SetArticleLabels(ids[0], []string{"Security", "AI"}, "local"); err != nil {
+		t.Fatalf("SetArticleLabels() error = %v", err)
+	}
+	db.SetArticleLabels(ids[1], []string{"AI"}, "ai")
+	// Labels are replaced, not added
+	db.SetArticleLabels(ids[1], []string{"AI", "AI"}, "ai")
+
+	article, err := db.GetArticleByID(ids[0])
+	if err != nil || !reflect.DeepEqual(article.Labels, []string{"AI", "Security"}) {
+		t.Errorf("GetArticleByID() labels = %v, %v", article.Labels, err)
+	}
+	articles, _ := db.GetArticles("", 0, "", false, 10, 0)
+	if len(articles) != 2 || !reflect.DeepEqual(articles[0].Labels, []string{"AI"}) {
+		t.Errorf("unexpected labels from GetArticles %+v", articles)
+	}
+
+	counts, err := db.GetLabelCounts()
+	want := []models.LabelCount{{Label: "AI", Count: 2}, {Label: "Security", Count: 1}}
+	if err != nil || !reflect.DeepEqual(counts, want) {
+		t.Errorf("GetLabelCounts() = %+v, %v", counts, err)
+	}
+
+	db.Exec("DELETE FROM articles WHERE id = ?", ids[0])
+	if n, err := db.DeleteOrphanedArticleLabels(); err != nil || n != 2 {
+		t.Errorf("DeleteOrphanedArticleLabels() = %d, %v", n, err)
+	}
+	if labels, _ := db.GetArticleLabels(ids[1]); !reflect.DeepEqual(labels, []string{"AI"}) {
+		t.Errorf("GetArticleLabels() = %v", labels)
+	}
+}
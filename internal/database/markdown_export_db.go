@@ -0,0 +1,38 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MarkdownExport records an article exported as a Markdown note.
+type MarkdownExport struct {
+	ArticleID  int64
+	Path       string    // Path of the note, relative to the export folder
+	Hash       string    // Hash of the note as written
+	ExportedAt time.Time // When the article was first exported
+}
+
+// GetMarkdownExport returns the record of an article exported to a folder, or nil if
+// it was not exported there.
+func (db *DB) GetMarkdownExport(dir string, articleID int64) (*MarkdownExport, error) {
+	db.WaitForReady()
+	e := MarkdownExport{ArticleID: articleID}
+	err := db.QueryRow(`SELECT path, hash, exported_at FROM markdown_exports WHERE dir = ? AND article_id = ?`, dir, articleID).
+		Scan(&e.Path, &e.Hash, &e.ExportedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// SaveMarkdownExport records an article exported to a folder, replacing an earlier record.
+func (db *DB) SaveMarkdownExport(dir string, e MarkdownExport) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT OR REPLACE INTO markdown_exports (dir, article_id, path, hash, exported_at) VALUES (?, ?, ?, ?, ?)`,
+		dir, e.ArticleID, e.Path, e.Hash, e.ExportedAt.UTC())
+	return err
+}
@@ -0,0 +1,32 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+)
+
+func TestMarkdownExports(t *testing.T) {
+	db := setupTestDB(t)
+
+	if e, err := db.GetMarkdownExport("/vault", 1); err != nil || e != nil {
+		t.Fatalf("GetMarkdownExport() = %+v, %v, want nil", e, err)
+	}
+
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := db.SaveMarkdownExport("/vault", database.MarkdownExport{ArticleID: 1, Path: "Note.md", Hash: "a", ExportedAt: first}); err != nil {
+		t.Fatalf("SaveMarkdownExport() error = %v", err)
+	}
+	if err := db.SaveMarkdownExport("/vault", database.MarkdownExport{ArticleID: 1, Path: "Note.md", Hash: "b", ExportedAt: first}); err != nil {
+		t.Fatalf("SaveMarkdownExport() error = %v", err)
+	}
+
+	e, err := db.GetMarkdownExport("/vault", 1)
+	if err != nil || e == nil || e.Hash != "b" || e.Path != "Note.md" || !e.ExportedAt.Equal(first) {
+		t.Errorf("GetMarkdownExport() = %+v, %v", e, err)
+	}
+	if e, _ := db.GetMarkdownExport("/other", 1); e != nil {
+		t.Errorf("export to another folder found: %+v", e)
+	}
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// OPMLListFeed is a feed an OPML list lists.
+type OPMLListFeed struct {
+	URL      string // Normalized URL
+	FeedID   int64  // Feed the list added, 0 if it was subscribed already
+	Title    string // Title the list gave the feed
+	Category string // Category the list gave the feed
+}
+
+// GetOPMLLists returns the OPML list subscriptions with the IDs of the feeds they added.
+func (db *DB) GetOPMLLists() ([]models.OPMLList, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT id, url, COALESCE(title, ''), COALESCE(category, ''), COALESCE(remove_missing, 0),
+		       COALESCE(etag, ''), COALESCE(last_modified, ''), last_checked, COALESCE(last_error, ''), created_at
+		FROM opml_lists
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := []models.OPMLList{}
+	for rows.Next() {
+		list, err := scanOPMLList(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lists = append(lists, *list)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lists {
+		if lists[i].FeedIDs, err = db.opmlListFeedIDs(lists[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return lists, nil
+}
+
+// GetOPMLList returns an OPML list subscription with the IDs of the feeds it added.
+func (db *DB) GetOPMLList(id int64) (*models.OPMLList, error) {
+	db.WaitForReady()
+	row := db.QueryRow(`
+		SELECT id, url, COALESCE(title, ''), COALESCE(category, ''), COALESCE(remove_missing, 0),
+		       COALESCE(etag, ''), COALESCE(last_modified, ''), last_checked, COALESCE(last_error, ''), created_at
+		FROM opml_lists WHERE id = ?
+	`, id)
+	list, err := scanOPMLList(row)
+	if err != nil {
+		return nil, err
+	}
+	if list.FeedIDs, err = db.opmlListFeedIDs(id); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// scanOPMLList scans a row of the OPML list queries.
+func scanOPMLList(row interface{ Scan(...interface{}) error }) (*models.OPMLList, error) {
+	var list models.OPMLList
+	var lastChecked sql.NullTime
+	if err := row.Scan(&list.ID, &list.URL, &list.Title, &list.Category, &list.RemoveMissing,
+		&list.ETag, &list.LastModified, &lastChecked, &list.LastError, &list.CreatedAt); err != nil {
+		return nil, err
+	}
+	if lastChecked.Valid {
+		list.LastChecked = &lastChecked.Time
+	}
+	return &list, nil
+}
+
+// opmlListFeedIDs returns the IDs of the existing feeds an OPML list added.
+func (db *DB) opmlListFeedIDs(listID int64) ([]int64, error) {
+	rows, err := db.Query(`
+		SELECT l.feed_id FROM opml_list_feeds l
+		JOIN feeds f ON f.id = l.feed_id
+		WHERE l.list_id = ?
+		ORDER BY f.category ASC, f.position ASC, f.id ASC
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AddOPMLList adds an OPML list subscription and returns its ID.
+func (db *DB) AddOPMLList(list *models.OPMLList) (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`INSERT INTO opml_lists (url, title, category, remove_missing) VALUES (?, ?, ?, ?)`,
+		list.URL, list.Title, list.Category, list.RemoveMissing)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateOPMLList updates the title, category and removal option of an OPML list
+// subscription. It drops the validators, so that the next check applies the list.
+func (db *DB) UpdateOPMLList(list *models.OPMLList) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE opml_lists SET title = ?, category = ?, remove_missing = ?, etag = '', last_modified = '' WHERE id = ?`,
+		list.Title, list.Category, list.RemoveMissing, list.ID)
+	return err
+}
+
+// SaveOPMLListCheck records the validators, time and error of a check of an OPML list.
+func (db *DB) SaveOPMLListCheck(id int64, etag, lastModified string, checkedAt time.Time, lastError string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE opml_lists SET etag = ?, last_modified = ?, last_checked = ?, last_error = ? WHERE id = ?`,
+		etag, lastModified, checkedAt.UTC(), lastError, id)
+	return err
+}
+
+// DeleteOPMLList deletes an OPML list subscription. The feeds it added stay.
+func (db *DB) DeleteOPMLList(id int64) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM opml_list_feeds WHERE list_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM opml_lists WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOPMLListFeeds returns the feeds an OPML list listed at its last check.
+func (db *DB) GetOPMLListFeeds(listID int64) ([]OPMLListFeed, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT url, feed_id, COALESCE(title, ''), COALESCE(category, '') FROM opml_list_feeds WHERE list_id = ?`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []OPMLListFeed
+	for rows.Next() {
+		var f OPMLListFeed
+		if err := rows.Scan(&f.URL, &f.FeedID, &f.Title, &f.Category); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// SaveOPMLListFeed records a feed of an OPML list, replacing an earlier record.
+func (db *DB) SaveOPMLListFeed(listID int64, feed OPMLListFeed) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT OR REPLACE INTO opml_list_feeds (list_id, url, feed_id, title, category) VALUES (?, ?, ?, ?, ?)`,
+		listID, feed.URL, feed.FeedID, feed.Title, feed.Category)
+	return err
+}
+
+// DeleteOPMLListFeed removes the record of a feed of an OPML list.
+func (db *DB) DeleteOPMLListFeed(listID int64, url string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM opml_list_feeds WHERE list_id = ? AND url = ?`, listID, url)
+	return err
+}
@@ -0,0 +1,66 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestOPMLLists(t *testing.T) {
+	db := setupTestDB(t)
+	id, err := db.AddOPMLList(&models.OPMLList{URL: "https://example.com/team.opml", Title: "Team", Category: "Team"})
+	if err != nil {
+		t.Fatalf("AddOPMLList() error = %v", err)
+	}
+
+	added, _ := db.AddFeed(&models.Feed{Title: "Added", URL: "https://example.com/added", Category: "Team"})
+	deleted, _ := db.AddFeed(&models.Feed{Title: "Deleted", URL: "https://example.com/deleted", Category: "Team"})
+	for _, f := range []database.OPMLListFeed{
+		{URL: "https://example.com/added", FeedID: added, Title: "Added", Category: "Team"},
+		{URL: "https://example.com/deleted", FeedID: deleted, Title: "Deleted", Category: "Team"},
+		{URL: "https://example.com/own", Title: "Own"},
+	} {
+		if err := db.SaveOPMLListFeed(id, f); err != nil {
+			t.Fatalf("SaveOPMLListFeed() error = %v", err)
+		}
+	}
+	db.DeleteFeed(deleted)
+	if err := db.SaveOPMLListCheck(id, `"v1"`, "", time.Now(), ""); err != nil {
+		t.Fatalf("SaveOPMLListCheck() error = %v", err)
+	}
+
+	lists, err := db.GetOPMLLists()
+	if err != nil {
+		t.Fatalf("GetOPMLLists() error = %v", err)
+	}
+	if len(lists) != 1 || lists[0].ETag != `"v1"` || lists[0].LastChecked == nil {
+		t.Fatalf("unexpected lists: %+v", lists)
+	}
+	if ids := lists[0].FeedIDs; len(ids) != 1 || ids[0] != added {
+		t.Errorf("FeedIDs = %v, want only the existing feed the list added", ids)
+	}
+	if feeds, _ := db.GetOPMLListFeeds(id); len(feeds) != 3 {
+		t.Errorf("got %d list feeds, want 3", len(feeds))
+	}
+
+	// Changing the list drops the validators
+	lists[0].Category = "Shared"
+	if err := db.UpdateOPMLList(&lists[0]); err != nil {
+		t.Fatalf("UpdateOPMLList() error = %v", err)
+	}
+	if list, _ := db.GetOPMLList(id); list.Category != "Shared" || list.ETag != "" {
+		t.Errorf("unexpected list after update: %+v", list)
+	}
+
+	if err := db.DeleteOPMLList(id); err != nil {
+		t.Fatalf("DeleteOPMLList() error = %v", err)
+	}
+	if feeds, _ := db.GetOPMLListFeeds(id); len(feeds) != 0 {
+		t.Errorf("list feeds remain after deleting the list: %+v", feeds)
+	}
+	if _, err := db.GetFeedByID(added); err != nil {
+		t.Error("deleting the list deleted its feed")
+	}
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// Feedback signals the ranking model learns from
+const (
+	FeedbackFavorite  = "favorite"   // Favorited by the user
+	FeedbackReadLater = "read_later" // Added to read later by the user
+	FeedbackRead      = "read"       // Opened; the value is the reading time in seconds
+	FeedbackSkip      = "skip"       // Marked as read without reading
+)
+
+// Sort orders of article listings
+const (
+	SortNewest = ""      // Newest first
+	SortScore  = "score" // Most important first, then newest
+)
+
+// PriorityMinScore is the importance score unread articles need to be in the priority inbox
+const PriorityMinScore = 0.5
+
+// scoreColumns selects the score and explanation of article a.
+const scoreColumns = `COALESCE(s.score, 0), COALESCE(s.explanation, '')`
+
+// scoreJoin joins the scores of articles a.
+const scoreJoin = `LEFT JOIN article_scores s ON s.article_id = a.id`
+
+// ArticleFeedback is a feedback signal recorded for an article.
+type ArticleFeedback struct {
+	ArticleID int64
+	Signal    string
+	Value     float64
+	CreatedAt time.Time
+}
+
+// ArticleFeatures are the properties of an article the ranking model scores.
+type ArticleFeatures struct {
+	ArticleID int64
+	FeedID    int64
+	FeedTitle string
+	Labels    []string
+	Keywords  []models.ArticleKeyword
+	WordCount int // 0 if unknown
+}
+
+// SetArticleFeedback records a feedback signal for an article, replacing an earlier
+// signal of the same kind. Read and skip exclude each other, so either replaces the other.
+func (db *DB) SetArticleFeedback(articleID int64, signal string, value float64) error {
+	db.WaitForReady()
+	if other := oppositeFeedback(signal); other != "" {
+		if err := db.DeleteArticleFeedback(articleID, other); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`INSERT OR REPLACE INTO article_feedback (article_id, signal, value, created_at) VALUES (?, ?, ?, ?)`,
+		articleID, signal, value, time.Now())
+	return err
+}
+
+// AddSkipFeedback records a skip for an article marked as read, unless reading
+// feedback was already recorded for it.
+func (db *DB) AddSkipFeedback(articleID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO article_feedback (article_id, signal, value, created_at)
+		SELECT ?, ?, 0, ?
+		WHERE NOT EXISTS (SELECT 1 FROM article_feedback WHERE article_id = ? AND signal = ?)`,
+		articleID, FeedbackSkip, time.Now(), articleID, FeedbackRead)
+	return err
+}
+
+// DeleteArticleFeedback withdraws a feedback signal of an article.
+func (db *DB) DeleteArticleFeedback(articleID int64, signal string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM article_feedback WHERE article_id = ? AND signal = ?`, articleID, signal)
+	return err
+}
+
+// oppositeFeedback returns the signal that signal replaces, if any.
+func oppositeFeedback(signal string) string {
+	switch signal {
+	case FeedbackRead:
+		return FeedbackSkip
+	case FeedbackSkip:
+		return FeedbackRead
+	}
+	return ""
+}
+
+// SyncArticleFeedback records the favorite and read later state of an article set by the
+// user. Removing a favorite withdraws the signal; removing an article from read later
+// keeps it, since that also happens when the article is read.
+func (db *DB) SyncArticleFeedback(articleID int64) error {
+	db.WaitForReady()
+	var favorite, readLater bool
+	if err := db.QueryRow(`SELECT is_favorite, is_read_later FROM articles WHERE id = ?`, articleID).Scan(&favorite, &readLater); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if favorite {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO article_feedback (article_id, signal, value, created_at) VALUES (?, ?, 0, ?)`, articleID, FeedbackFavorite, now); err != nil {
+			return err
+		}
+	} else if _, err := db.Exec(`DELETE FROM article_feedback WHERE article_id = ? AND signal = ?`, articleID, FeedbackFavorite); err != nil {
+		return err
+	}
+	if readLater {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO article_feedback (article_id, signal, value, created_at) VALUES (?, ?, 0, ?)`, articleID, FeedbackReadLater, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetArticleFeedback returns the most recent feedback signals, newest first.
+func (db *DB) GetArticleFeedback(limit int) ([]ArticleFeedback, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT article_id, signal, value, created_at FROM article_feedback ORDER BY created_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedback []ArticleFeedback
+	for rows.Next() {
+		var f ArticleFeedback
+		if err := rows.Scan(&f.ArticleID, &f.Signal, &f.Value, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		feedback = append(feedback, f)
+	}
+	return feedback, rows.Err()
+}
+
+// GetArticleFeatures returns the ranking features of the articles with the given IDs,
+// keyed by article ID. Missing IDs are skipped.
+func (db *DB) GetArticleFeatures(ids []int64) (map[int64]*ArticleFeatures, error) {
+	db.WaitForReady()
+	features := make(map[int64]*ArticleFeatures, len(ids))
+	if len(ids) == 0 {
+		return features, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	in := "(?" + strings.Repeat(", ?", len(ids)-1) + ")"
+
+	rows, err := db.Query(`
+		SELECT a.id, a.feed_id, f.title, COALESCE(a.word_count, 0), `+labelColumn+`
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		WHERE a.id IN `+in, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a ArticleFeatures
+		var labels string
+		if err := rows.Scan(&a.ArticleID, &a.FeedID, &a.FeedTitle, &a.WordCount, &labels); err != nil {
+			return nil, err
+		}
+		a.Labels = splitLabels(labels)
+		features[a.ArticleID] = &a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keywordRows, err := db.Query(`SELECT article_id, term, text, score, is_entity FROM article_keywords WHERE article_id IN `+in+` ORDER BY score DESC, term ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer keywordRows.Close()
+	for keywordRows.Next() {
+		var id int64
+		var k models.ArticleKeyword
+		if err := keywordRows.Scan(&id, &k.Term, &k.Text, &k.Score, &k.Entity); err != nil {
+			return nil, err
+		}
+		if a, ok := features[id]; ok {
+			a.Keywords = append(a.Keywords, k)
+		}
+	}
+	return features, keywordRows.Err()
+}
+
+// GetUnreadArticleIDs returns the IDs of visible unread articles, newest first.
+func (db *DB) GetUnreadArticleIDs(limit int) ([]int64, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT id FROM articles WHERE is_read = 0 AND is_hidden = 0 ORDER BY published_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetArticleWordCount returns the number of words of an article's feed content, 0 if unknown.
+func (db *DB) GetArticleWordCount(articleID int64) (int, error) {
+	db.WaitForReady()
+	var count int
+	err := db.QueryRow(`SELECT COALESCE(word_count, 0) FROM articles WHERE id = ?`, articleID).Scan(&count)
+	return count, err
+}
+
+// SaveArticleScores stores the importance scores of articles, replacing earlier scores.
+func (db *DB) SaveArticleScores(scores []models.ArticleScore) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO article_scores (article_id, score, explanation, reasons, scored_at) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range scores {
+		reasons, err := json.Marshal(s.Reasons)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(s.ArticleID, s.Score, s.Explanation, string(reasons), s.ScoredAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetArticleScore returns the stored importance score of an article, or nil if it has
+// not been scored.
+func (db *DB) GetArticleScore(articleID int64) (*models.ArticleScore, error) {
+	db.WaitForReady()
+	s := models.ArticleScore{ArticleID: articleID}
+	var reasons string
+	err := db.QueryRow(`SELECT score, explanation, reasons, scored_at FROM article_scores WHERE article_id = ?`, articleID).
+		Scan(&s.Score, &s.Explanation, &reasons, &s.ScoredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reasons != "" {
+		if err := json.Unmarshal([]byte(reasons), &s.Reasons); err != nil {
+			return nil, err
+		}
+	}
+	if s.Reasons == nil {
+		s.Reasons = []models.ScoreReason{}
+	}
+	return &s, nil
+}
+
+// DeleteOrphanedArticleRanking removes the scores and feedback of articles that no longer exist.
+func (db *DB) DeleteOrphanedArticleRanking() (int64, error) {
+	db.WaitForReady()
+	scores, err := db.Exec(`DELETE FROM article_scores WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	feedback, err := db.Exec(`DELETE FROM article_feedback WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := scores.RowsAffected()
+	m, _ := feedback.RowsAffected()
+	return n + m, nil
+}
@@ -0,0 +1,120 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestArticleFeedbackFollowsState(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	article := &models.Article{FeedID: feedID, Title: "A", URL: "https://example.com/a", PublishedAt: time.Now(), WordCount: 420}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+
+	signals := func() map[string]bool {
+		feedback, err := db.GetArticleFeedback(10)
+		if err != nil {
+			t.Fatalf("GetArticleFeedback() error = %v", err)
+		}
+		result := make(map[string]bool)
+		for _, f := range feedback {
+			result[f.Signal] = true
+		}
+		return result
+	}
+
+	db.SetArticleFavorite(article.ID, true)
+	db.SetArticleReadLater(article.ID, true)
+	if err := db.SyncArticleFeedback(article.ID); err != nil {
+		t.Fatalf("SyncArticleFeedback() error = %v", err)
+	}
+	if got := signals(); !got[database.FeedbackFavorite] || !got[database.FeedbackReadLater] {
+		t.Errorf("expected favorite and read later feedback, got %v", got)
+	}
+
+	// Reading removes the article from read later without withdrawing the signal
+	db.SetArticleFavorite(article.ID, false)
+	db.MarkArticleRead(article.ID, true)
+	db.SyncArticleFeedback(article.ID)
+	if got := signals(); got[database.FeedbackFavorite] || !got[database.FeedbackReadLater] {
+		t.Errorf("expected only read later feedback, got %v", got)
+	}
+
+	// Marking as read counts as a skip until the article is read, and not after
+	db.AddSkipFeedback(article.ID)
+	if got := signals(); !got[database.FeedbackSkip] {
+		t.Errorf("expected skip feedback, got %v", got)
+	}
+	db.SetArticleFeedback(article.ID, database.FeedbackRead, 120)
+	db.AddSkipFeedback(article.ID)
+	if got := signals(); got[database.FeedbackSkip] || !got[database.FeedbackRead] {
+		t.Errorf("expected reading to replace the skip, got %v", got)
+	}
+
+	features, err := db.GetArticleFeatures([]int64{article.ID, 9999})
+	if err != nil {
+		t.Fatalf("GetArticleFeatures() error = %v", err)
+	}
+	if len(features) != 1 || features[article.ID].WordCount != 420 || features[article.ID].FeedTitle != "Feed" {
+		t.Errorf("unexpected features %+v", features)
+	}
+}
+
+func TestPriorityInbox(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	now := time.Now()
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "Newest", URL: "https://example.com/1", PublishedAt: now},
+		{FeedID: feedID, Title: "Important", URL: "https://example.com/2", PublishedAt: now.Add(-time.Hour)},
+		{FeedID: feedID, Title: "Somewhat important", URL: "https://example.com/3", PublishedAt: now.Add(-2 * time.Hour)},
+		{FeedID: feedID, Title: "Important but read", URL: "https://example.com/4", PublishedAt: now.Add(-3 * time.Hour), IsRead: true},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+
+	reasons := []models.ScoreReason{{Kind: "feed", Name: "Feed", Contribution: 2}}
+	err := db.SaveArticleScores([]models.ArticleScore{
+		{ArticleID: articles[0].ID, Score: 0.1, Reasons: []models.ScoreReason{}, ScoredAt: now},
+		{ArticleID: articles[1].ID, Score: 2, Explanation: "boosted because: feed Feed", Reasons: reasons, ScoredAt: now},
+		{ArticleID: articles[2].ID, Score: 0.8, Reasons: []models.ScoreReason{}, ScoredAt: now},
+		{ArticleID: articles[3].ID, Score: 3, Reasons: []models.ScoreReason{}, ScoredAt: now},
+	})
+	if err != nil {
+		t.Fatalf("SaveArticleScores() error = %v", err)
+	}
+
+	byScore, err := db.GetArticlesSorted("all", 0, "", false, database.SortScore, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticlesSorted() error = %v", err)
+	}
+	if len(byScore) != 4 || byScore[0].Title != "Important but read" || byScore[1].Title != "Important" || byScore[3].Title != "Newest" {
+		t.Errorf("unexpected order by score: %+v", byScore)
+	}
+	if byScore[1].Score != 2 || byScore[1].ScoreReason != "boosted because: feed Feed" {
+		t.Errorf("expected the score and explanation, got %+v", byScore[1])
+	}
+
+	priority, err := db.GetArticlesSorted("priority", 0, "", false, database.SortNewest, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticlesSorted() error = %v", err)
+	}
+	if len(priority) != 2 || priority[0].Title != "Important" || priority[1].Title != "Somewhat important" {
+		t.Errorf("unexpected priority inbox: %+v", priority)
+	}
+
+	score, err := db.GetArticleScore(articles[1].ID)
+	if err != nil || score == nil || len(score.Reasons) != 1 || score.Reasons[0] != reasons[0] {
+		t.Errorf("GetArticleScore() = %+v, %v", score, err)
+	}
+	if score, err := db.GetArticleScore(9999); score != nil || err != nil {
+		t.Errorf("expected no score for a missing article, got %+v, %v", score, err)
+	}
+}
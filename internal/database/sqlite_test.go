@@ -2,8 +2,10 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -227,6 +229,67 @@ func BenchmarkGetArticles(b *testing.B) {
 	}
 }
 
+func TestWithTx(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "rss.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	// Helpers that start their own transaction join the one of WithTx
+	write := func(tx *DB, title string) error {
+		feedID, err := tx.AddFeed(&models.Feed{Title: title, URL: "https://example.com/" + title})
+		if err != nil {
+			return err
+		}
+		article := &models.Article{FeedID: feedID, Title: title, URL: "https://example.com/" + title + "/1", PublishedAt: time.Now()}
+		if err := tx.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+			return err
+		}
+		if err := tx.SetArticleLabels(article.ID, []string{"go"}, "local"); err != nil {
+			return err
+		}
+		return tx.SetSetting("language", title)
+	}
+
+	errFailed := errors.New("failed")
+	err = db.WithTx(func(tx *DB) error {
+		if err := write(tx, "rolledback"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("WithTx() error = %v, want %v", err, errFailed)
+	}
+	var count int
+	if err := db.QueryRow("SELECT (SELECT COUNT(*) FROM feeds) + (SELECT COUNT(*) FROM articles) + (SELECT COUNT(*) FROM article_labels)").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Rolled back transaction left %d rows", count)
+	}
+	if language, _ := db.GetSetting("language"); language == "rolledback" {
+		t.Error("Rolled back transaction saved the setting")
+	}
+
+	if err := db.WithTx(func(tx *DB) error { return write(tx, "committed") }); err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if err := db.QueryRow("SELECT (SELECT COUNT(*) FROM feeds) + (SELECT COUNT(*) FROM articles) + (SELECT COUNT(*) FROM article_labels)").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Committed transaction saved %d rows, want 3", count)
+	}
+	if language, _ := db.GetSetting("language"); language != "committed" {
+		t.Errorf("language = %q, want committed", language)
+	}
+}
+
 func TestCleanupOldArticles(t *testing.T) {
 	// Create temporary database
 	dbFile := "test_cleanup.db"
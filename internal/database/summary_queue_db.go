@@ -0,0 +1,174 @@
+package database
+
+import (
+	"strings"
+
+	"MrRSS/internal/models"
+)
+
+// Summary queue statuses
+const (
+	SummaryQueuePending = "pending"
+	SummaryQueueRunning = "running"
+	SummaryQueueFailed  = "failed"
+)
+
+// EnqueueSummaries adds articles to the summary queue. Articles already queued are left unchanged.
+// It returns the number of articles added.
+func (db *DB) EnqueueSummaries(articleIDs []int64) (int, error) {
+	db.WaitForReady()
+	if len(articleIDs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO summary_queue (article_id, status) VALUES (?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	added := 0
+	for _, id := range articleIDs {
+		result, err := stmt.Exec(id, SummaryQueuePending)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// ClaimPendingSummaries marks up to limit pending articles as running, oldest first, and returns their IDs.
+func (db *DB) ClaimPendingSummaries(limit int) ([]int64, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT article_id FROM summary_queue WHERE status = ? ORDER BY created_at ASC, article_id ASC LIMIT ?`,
+		SummaryQueuePending, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := db.Exec(`UPDATE summary_queue SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE article_id = ?`,
+			SummaryQueueRunning, id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// ResetRunningSummaries returns running items to pending, e.g. after an unclean shutdown.
+func (db *DB) ResetRunningSummaries() error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE summary_queue SET status = ? WHERE status = ?`, SummaryQueuePending, SummaryQueueRunning)
+	return err
+}
+
+// CompleteSummaryQueueItem removes a summarized article from the queue.
+func (db *DB) CompleteSummaryQueueItem(articleID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM summary_queue WHERE article_id = ?`, articleID)
+	return err
+}
+
+// FailSummaryQueueItem records a failed attempt. The item is retried until maxAttempts is reached.
+func (db *DB) FailSummaryQueueItem(articleID int64, errMsg string, maxAttempts int) error {
+	db.WaitForReady()
+	_, err := db.Exec(`
+		UPDATE summary_queue
+		SET attempts = attempts + 1,
+		    last_error = ?,
+		    status = CASE WHEN attempts + 1 >= ? THEN ? ELSE ? END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE article_id = ?`,
+		errMsg, maxAttempts, SummaryQueueFailed, SummaryQueuePending, articleID)
+	return err
+}
+
+// RemoveSummaryQueueItems removes articles from the queue. With no IDs, the whole queue is cleared.
+func (db *DB) RemoveSummaryQueueItems(articleIDs ...int64) (int64, error) {
+	db.WaitForReady()
+	query := `DELETE FROM summary_queue`
+	var args []interface{}
+	if len(articleIDs) > 0 {
+		query += ` WHERE article_id IN (?` + strings.Repeat(",?", len(articleIDs)-1) + `)`
+		for _, id := range articleIDs {
+			args = append(args, id)
+		}
+	}
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetSummaryQueueCounts returns the number of queued articles per status.
+func (db *DB) GetSummaryQueueCounts() (map[string]int, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT status, COUNT(*) FROM summary_queue GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{
+		SummaryQueuePending: 0,
+		SummaryQueueRunning: 0,
+		SummaryQueueFailed:  0,
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetSummaryQueueItems lists queued articles, running first, then oldest first.
+func (db *DB) GetSummaryQueueItems(limit int) ([]models.SummaryQueueItem, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT q.article_id, COALESCE(a.title, ''), q.status, COALESCE(q.attempts, 0), COALESCE(q.last_error, ''), q.created_at
+		FROM summary_queue q
+		LEFT JOIN articles a ON a.id = q.article_id
+		ORDER BY CASE q.status WHEN 'running' THEN 0 WHEN 'pending' THEN 1 ELSE 2 END, q.created_at ASC, q.article_id ASC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.SummaryQueueItem
+	for rows.Next() {
+		var item models.SummaryQueueItem
+		if err := rows.Scan(&item.ArticleID, &item.Title, &item.Status, &item.Attempts, &item.LastError, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
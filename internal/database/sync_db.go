@@ -0,0 +1,307 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SyncFeed maps a remote subscription of a sync account to a local feed.
+type SyncFeed struct {
+	RemoteID string
+	FeedID   int64
+	Category string // Category at the last sync
+}
+
+// SyncItem maps a remote item of a sync account to a local article.
+type SyncItem struct {
+	RemoteID  string
+	ArticleID int64
+	Read      bool // Read state at the last sync
+	Starred   bool // Starred state at the last sync
+
+	// Current local state, filled by GetSyncItems
+	LocalRead      bool
+	LocalStarred   bool
+	LocalReadAt    time.Time // Last local read change since the last sync, zero if none
+	LocalStarredAt time.Time // Last local favorite change since the last sync, zero if none
+}
+
+// ArticleState is the identity and read and favorite state of an article.
+type ArticleState struct {
+	ID       int64
+	FeedID   int64
+	Read     bool
+	Favorite bool
+}
+
+// GetSyncFeeds returns the subscriptions of a sync provider mapped to local feeds.
+func (db *DB) GetSyncFeeds(provider string) ([]SyncFeed, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT remote_id, feed_id, category FROM sync_feeds WHERE provider = ?`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []SyncFeed
+	for rows.Next() {
+		var f SyncFeed
+		if err := rows.Scan(&f.RemoteID, &f.FeedID, &f.Category); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// SaveSyncFeed maps a remote subscription to a local feed, replacing an earlier mapping.
+func (db *DB) SaveSyncFeed(provider string, feed SyncFeed) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT OR REPLACE INTO sync_feeds (provider, remote_id, feed_id, category) VALUES (?, ?, ?, ?)`,
+		provider, feed.RemoteID, feed.FeedID, feed.Category)
+	return err
+}
+
+// DeleteSyncFeed removes the mapping of a remote subscription.
+func (db *DB) DeleteSyncFeed(provider, remoteID string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM sync_feeds WHERE provider = ? AND remote_id = ?`, provider, remoteID)
+	return err
+}
+
+// UnlinkSyncFeed removes the mapping of a remote subscription and of its items, and
+// keeps its local feed off the account as a local feed.
+func (db *DB) UnlinkSyncFeed(provider, remoteID string, feedID int64) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM sync_feeds WHERE provider = ? AND remote_id = ?`, provider, remoteID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM sync_items WHERE provider = ? AND article_id IN (SELECT id FROM articles WHERE feed_id = ?)`, provider, feedID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO sync_local_feeds (provider, feed_id) VALUES (?, ?)`, provider, feedID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSyncLocalFeeds returns the IDs of the existing local feeds kept off the account of
+// a sync provider.
+func (db *DB) GetSyncLocalFeeds(provider string) (map[int64]bool, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT l.feed_id FROM sync_local_feeds l
+		JOIN feeds f ON f.id = l.feed_id
+		WHERE l.provider = ?`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// AddSyncLocalFeeds keeps local feeds off the account of a sync provider.
+func (db *DB) AddSyncLocalFeeds(provider string, feedIDs []int64) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range feedIDs {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO sync_local_feeds (provider, feed_id) VALUES (?, ?)`, provider, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteSyncLocalFeeds lets the next sync push the local feeds kept off the account of
+// a sync provider.
+func (db *DB) DeleteSyncLocalFeeds(provider string) (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM sync_local_feeds WHERE provider = ?`, provider)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetSyncItems returns the remote items of a sync provider mapped to existing articles,
+// with the current local state of the articles.
+func (db *DB) GetSyncItems(provider string) ([]SyncItem, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT s.remote_id, s.article_id, s.is_read, s.is_starred, a.is_read, a.is_favorite, s.local_read_at, s.local_starred_at
+		FROM sync_items s
+		JOIN articles a ON a.id = s.article_id
+		WHERE s.provider = ?`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []SyncItem
+	for rows.Next() {
+		var it SyncItem
+		var readAt, starredAt sql.NullTime
+		if err := rows.Scan(&it.RemoteID, &it.ArticleID, &it.Read, &it.Starred, &it.LocalRead, &it.LocalStarred, &readAt, &starredAt); err != nil {
+			return nil, err
+		}
+		it.LocalReadAt = readAt.Time
+		it.LocalStarredAt = starredAt.Time
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// GetSyncedRemoteIDs returns the remote item IDs of a sync provider that are mapped to articles.
+func (db *DB) GetSyncedRemoteIDs(provider string) (map[string]bool, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT remote_id FROM sync_items WHERE provider = ?`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// SaveSyncItems maps remote items to articles with their synced state and clears the
+// recorded local changes, replacing earlier mappings.
+func (db *DB) SaveSyncItems(provider string, items []SyncItem) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO sync_items (provider, remote_id, article_id, is_read, is_starred, local_read_at, local_starred_at) VALUES (?, ?, ?, ?, ?, NULL, NULL)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, it := range items {
+		if _, err := stmt.Exec(provider, it.RemoteID, it.ArticleID, it.Read, it.Starred); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetArticleStateByURL returns the state of the article with a URL, or nil if none exists.
+func (db *DB) GetArticleStateByURL(url string) (*ArticleState, error) {
+	db.WaitForReady()
+	var s ArticleState
+	err := db.QueryRow(`SELECT id, feed_id, is_read, is_favorite FROM articles WHERE url = ?`, url).Scan(&s.ID, &s.FeedID, &s.Read, &s.Favorite)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetArticleStateByNormalizedURL returns the state of the article whose URL without
+// its query and fragment is normalized, as utils.NormalizeURLForComparison returns it,
+// or nil if none exists.
+func (db *DB) GetArticleStateByNormalizedURL(normalized string) (*ArticleState, error) {
+	db.WaitForReady()
+	// The URL itself, or followed by "?" or "#" and anything, as ranges of the URL index
+	var s ArticleState
+	err := db.QueryRow(`SELECT id, feed_id, is_read, is_favorite FROM articles
+		WHERE url = ?1 OR (url >= ?1 || '?' AND url < ?1 || '@') OR (url >= ?1 || '#' AND url < ?1 || '$')
+		ORDER BY id ASC LIMIT 1`, normalized).Scan(&s.ID, &s.FeedID, &s.Read, &s.Favorite)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetArticleSyncState sets the read and favorite state of an article from a sync
+// account. Unlike marking it read locally, it keeps the article on the read later list,
+// but an article that becomes read still applies the duplicate read action.
+func (db *DB) SetArticleSyncState(id int64, read, favorite bool) error {
+	db.WaitForReady()
+	var wasRead bool
+	err := db.QueryRow(`SELECT is_read FROM articles WHERE id = ?`, id).Scan(&wasRead)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`UPDATE articles SET is_read = ?, is_favorite = ? WHERE id = ?`, read, favorite, id); err != nil {
+		return err
+	}
+	if read && !wasRead {
+		return db.applyDuplicateReadAction("id = ?", id)
+	}
+	return nil
+}
+
+// MoveArticleToFeed moves an article to another feed.
+func (db *DB) MoveArticleToFeed(id, feedID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE articles SET feed_id = ? WHERE id = ?`, feedID, id)
+	return err
+}
+
+// GetSyncLastSync returns the start of the last successful sync of a provider, or the
+// zero time if it never synced.
+func (db *DB) GetSyncLastSync(provider string) (time.Time, error) {
+	db.WaitForReady()
+	var lastSync sql.NullTime
+	err := db.QueryRow(`SELECT last_sync FROM sync_accounts WHERE provider = ?`, provider).Scan(&lastSync)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return lastSync.Time, err
+}
+
+// SetSyncLastSync records the start of the last successful sync of a provider.
+func (db *DB) SetSyncLastSync(provider string, t time.Time) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT OR REPLACE INTO sync_accounts (provider, last_sync) VALUES (?, ?)`, provider, t.UTC())
+	return err
+}
+
+// DeleteOrphanedSyncItems removes the mappings of remote items whose articles no longer exist.
+func (db *DB) DeleteOrphanedSyncItems() (int64, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM sync_items WHERE article_id NOT IN (SELECT id FROM articles)`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,153 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestSyncItemsRecordLocalChanges(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	article := &models.Article{FeedID: feedID, Title: "A", URL: "https://example.com/a", PublishedAt: time.Now()}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+
+	if err := db.SaveSyncItems("test", []database.SyncItem{{RemoteID: "item-1", ArticleID: article.ID}}); err != nil {
+		t.Fatalf("SaveSyncItems() error = %v", err)
+	}
+	db.MarkArticleRead(article.ID, true)
+
+	items, err := db.GetSyncItems("test")
+	if err != nil {
+		t.Fatalf("GetSyncItems() error = %v", err)
+	}
+	if len(items) != 1 || !items[0].LocalRead || items[0].Read || items[0].LocalReadAt.IsZero() || !items[0].LocalStarredAt.IsZero() {
+		t.Fatalf("expected a recorded local read change, got %+v", items)
+	}
+
+	// Saving the synced state clears the recorded changes
+	items[0].Read = true
+	if err := db.SaveSyncItems("test", items); err != nil {
+		t.Fatalf("SaveSyncItems() error = %v", err)
+	}
+	items, _ = db.GetSyncItems("test")
+	if len(items) != 1 || !items[0].Read || !items[0].LocalReadAt.IsZero() {
+		t.Errorf("expected the synced state without local changes, got %+v", items)
+	}
+
+	db.DeleteFeed(feedID)
+	if n, err := db.DeleteOrphanedSyncItems(); err != nil || n != 1 {
+		t.Errorf("DeleteOrphanedSyncItems() = %d, %v", n, err)
+	}
+}
+
+func TestUnlinkSyncFeed(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	article := &models.Article{FeedID: feedID, Title: "A", URL: "https://example.com/a", PublishedAt: time.Now(), IsFavorite: true}
+	db.SaveArticles(context.Background(), []*models.Article{article})
+	db.SaveSyncFeed("test", database.SyncFeed{RemoteID: "feed/1", FeedID: feedID})
+	db.SaveSyncItems("test", []database.SyncItem{{RemoteID: "item-1", ArticleID: article.ID, Starred: true}})
+
+	if err := db.UnlinkSyncFeed("test", "feed/1", feedID); err != nil {
+		t.Fatalf("UnlinkSyncFeed() error = %v", err)
+	}
+	if feeds, _ := db.GetSyncFeeds("test"); len(feeds) != 0 {
+		t.Errorf("expected no synced feeds, got %+v", feeds)
+	}
+	if items, _ := db.GetSyncItems("test"); len(items) != 0 {
+		t.Errorf("expected no synced items, got %+v", items)
+	}
+	if a, err := db.GetArticleByID(article.ID); err != nil || !a.IsFavorite {
+		t.Errorf("expected the starred article to stay, got %+v, %v", a, err)
+	}
+	if local, _ := db.GetSyncLocalFeeds("test"); !local[feedID] {
+		t.Errorf("expected the feed to be kept local, got %v", local)
+	}
+
+	if n, err := db.DeleteSyncLocalFeeds("test"); err != nil || n != 1 {
+		t.Errorf("DeleteSyncLocalFeeds() = %d, %v", n, err)
+	}
+}
+
+func TestMigrateFreshRSSLastSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rss.db")
+	db := setupFileDB(t, path)
+	db.SetSetting("freshrss_last_sync", "2024-01-01T09:00:00Z")
+	db.Close()
+
+	db = setupFileDB(t, path)
+	last, err := db.GetSyncLastSync("freshrss")
+	if err != nil || !last.Equal(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetSyncLastSync() = %v, %v", last, err)
+	}
+	if value, _ := db.GetSetting("freshrss_last_sync"); value != "" {
+		t.Errorf("expected the former setting to be removed, got %q", value)
+	}
+}
+
+func TestArticleStateByURL(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	otherID, _ := db.AddFeed(&models.Feed{Title: "Other", URL: "https://example.com/other"})
+	article := &models.Article{FeedID: feedID, Title: "A", URL: "https://example.com/a", PublishedAt: time.Now()}
+	db.SaveArticles(context.Background(), []*models.Article{article})
+
+	if err := db.SetArticleSyncState(article.ID, true, true); err != nil {
+		t.Fatalf("SetArticleSyncState() error = %v", err)
+	}
+	if err := db.MoveArticleToFeed(article.ID, otherID); err != nil {
+		t.Fatalf("MoveArticleToFeed() error = %v", err)
+	}
+
+	state, err := db.GetArticleStateByURL("https://example.com/a")
+	if err != nil || state == nil || state.FeedID != otherID || !state.Read || !state.Favorite {
+		t.Errorf("GetArticleStateByURL() = %+v, %v", state, err)
+	}
+	if state, err := db.GetArticleStateByURL("https://example.com/missing"); state != nil || err != nil {
+		t.Errorf("expected no state for a missing article, got %+v, %v", state, err)
+	}
+}
+
+func TestArticleStateByNormalizedURL(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Feed", URL: "https://example.com/feed"})
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "A", URL: "https://example.com/a?utm_source=rss", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "B", URL: "https://example.com/b#top", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "C", URL: "https://example.com/c", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "Longer", URL: "https://example.com/cd", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "Nested", URL: "https://example.com/d/e", PublishedAt: time.Now()},
+	}
+	db.SaveArticles(context.Background(), articles)
+
+	tests := []struct {
+		normalized string
+		want       int64
+	}{
+		{"https://example.com/a", articles[0].ID},
+		{"https://example.com/b", articles[1].ID},
+		{"https://example.com/c", articles[2].ID},
+		{"https://example.com/d", 0},
+		{"https://example.com/missing", 0},
+	}
+	for _, tt := range tests {
+		state, err := db.GetArticleStateByNormalizedURL(tt.normalized)
+		if err != nil {
+			t.Fatalf("GetArticleStateByNormalizedURL(%q) error = %v", tt.normalized, err)
+		}
+		var got int64
+		if state != nil {
+			got = state.ID
+		}
+		if got != tt.want {
+			t.Errorf("GetArticleStateByNormalizedURL(%q) = article %d, want %d", tt.normalized, got, tt.want)
+		}
+	}
+}
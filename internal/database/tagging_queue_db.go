@@ -0,0 +1,67 @@
+package database
+
+import (
+	"strings"
+
+	"MrRSS/internal/models"
+)
+
+// EnqueueClassifications adds saved articles to the queue of the classifier, with the
+// content they were fetched with. Articles already queued are left unchanged.
+func (db *DB) EnqueueClassifications(articles []*models.Article) error {
+	db.WaitForReady()
+	if len(articles) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO tagging_queue (article_id, content) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, a := range articles {
+		if _, err := stmt.Exec(a.ID, a.Content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetQueuedClassifications returns up to limit queued articles, oldest first, with the
+// content they were queued with, or else their stored content. Articles deleted since
+// they were queued are dropped from the queue.
+func (db *DB) GetQueuedClassifications(limit int) ([]ArticleText, error) {
+	db.WaitForReady()
+	if _, err := db.Exec(`DELETE FROM tagging_queue WHERE article_id NOT IN (SELECT id FROM articles)`); err != nil {
+		return nil, err
+	}
+	columns := strings.Replace(articleTextColumns, "a.content", "COALESCE(NULLIF(q.content, ''), a.content)", 1)
+	return db.queryArticleTexts(`
+		SELECT `+columns+`
+		FROM tagging_queue q
+		JOIN articles a ON a.id = q.article_id
+		JOIN feeds f ON a.feed_id = f.id
+		ORDER BY q.created_at ASC, q.article_id ASC
+		LIMIT ?`, limit)
+}
+
+// RemoveQueuedClassifications removes classified articles from the queue.
+func (db *DB) RemoveQueuedClassifications(articleIDs ...int64) error {
+	db.WaitForReady()
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(articleIDs))
+	for i, id := range articleIDs {
+		args[i] = id
+	}
+	_, err := db.Exec(`DELETE FROM tagging_queue WHERE article_id IN (?`+strings.Repeat(",?", len(articleIDs)-1)+`)`, args...)
+	return err
+}
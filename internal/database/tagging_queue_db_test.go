@@ -0,0 +1,43 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestTaggingQueue(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "News", URL: "https://news.example/feed"})
+	var articles []*models.Article
+	for _, url := range []string{"https://news.example/1", "https://news.example/2", "https://news.example/3"} {
+		articles = append(articles, &models.Article{FeedID: feedID, Title: url, URL: url, Content: "<p>Body</p>", PublishedAt: time.Now()})
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	ids := []int64{articles[0].ID, articles[1].ID, articles[2].ID}
+
+	if err := db.EnqueueClassifications(articles); err != nil {
+		t.Fatalf("EnqueueClassifications() error = %v", err)
+	}
+	db.EnqueueClassifications(articles[:1])
+	db.Exec(`DELETE FROM articles WHERE id = ?`, ids[2])
+
+	queued, err := db.GetQueuedClassifications(10)
+	if err != nil {
+		t.Fatalf("GetQueuedClassifications() error = %v", err)
+	}
+	if len(queued) != 2 || queued[0].ID != ids[0] || queued[1].ID != ids[1] || queued[0].Content != "<p>Body</p>" || queued[0].FeedTitle != "News" {
+		t.Fatalf("unexpected queue: %+v", queued)
+	}
+
+	if err := db.RemoveQueuedClassifications(ids[0]); err != nil {
+		t.Fatalf("RemoveQueuedClassifications() error = %v", err)
+	}
+	if queued, _ := db.GetQueuedClassifications(10); len(queued) != 1 || queued[0].ID != ids[1] {
+		t.Errorf("unexpected queue after removal: %+v", queued)
+	}
+}
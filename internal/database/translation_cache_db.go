@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// TranslationCacheStats summarizes cached translations for a provider and target language.
+type TranslationCacheStats struct {
+	Provider   string  `json:"provider"`
+	TargetLang string  `json:"target_lang"`
+	Entries    int64   `json:"entries"`
+	Pinned     int64   `json:"pinned"`
+	SizeBytes  int64   `json:"size_bytes"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// RecordTranslationCacheLookup increments the hit or miss counter for a provider and language.
+func (db *DB) RecordTranslationCacheLookup(provider, targetLang string, hit bool) error {
+	hits, misses := 0, 1
+	if hit {
+		hits, misses = 1, 0
+	}
+	_, err := db.Exec(`
+		INSERT INTO translation_cache_stats (provider, target_lang, hits, misses)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(provider, target_lang) DO UPDATE SET
+			hits = hits + excluded.hits,
+			misses = misses + excluded.misses
+	`, provider, targetLang, hits, misses)
+	return err
+}
+
+// GetTranslationCacheStats returns cache statistics grouped by provider and target language.
+func (db *DB) GetTranslationCacheStats() ([]TranslationCacheStats, error) {
+	db.WaitForReady()
+
+	type key struct{ provider, lang string }
+	byKey := make(map[key]*TranslationCacheStats)
+	get := func(provider, lang string) *TranslationCacheStats {
+		k := key{provider, lang}
+		if s, ok := byKey[k]; ok {
+			return s
+		}
+		s := &TranslationCacheStats{Provider: provider, TargetLang: lang}
+		byKey[k] = s
+		return s
+	}
+
+	rows, err := db.Query(`
+		SELECT provider, target_lang, COUNT(*), COALESCE(SUM(pinned), 0),
+		       COALESCE(SUM(length(CAST(source_text AS BLOB)) + length(CAST(translated_text AS BLOB))), 0)
+		FROM translation_cache
+		GROUP BY provider, target_lang
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var provider, lang string
+		var entries, pinned, size int64
+		if err := rows.Scan(&provider, &lang, &entries, &pinned, &size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s := get(provider, lang)
+		s.Entries, s.Pinned, s.SizeBytes = entries, pinned, size
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT provider, target_lang, COALESCE(hits, 0), COALESCE(misses, 0) FROM translation_cache_stats`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var provider, lang string
+		var hits, misses int64
+		if err := rows.Scan(&provider, &lang, &hits, &misses); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s := get(provider, lang)
+		s.Hits, s.Misses = hits, misses
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]TranslationCacheStats, 0, len(byKey))
+	for _, s := range byKey {
+		if total := s.Hits + s.Misses; total > 0 {
+			s.HitRate = float64(s.Hits) / float64(total)
+		}
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Provider != stats[j].Provider {
+			return stats[i].Provider < stats[j].Provider
+		}
+		return stats[i].TargetLang < stats[j].TargetLang
+	})
+	return stats, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, for patterns with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// SearchCachedTranslations returns cache entries whose source or translated text contains query.
+// Empty provider or targetLang match any value. It also returns the total number of matches.
+func (db *DB) SearchCachedTranslations(query, provider, targetLang string, limit, offset int) ([]TranslationCache, int, error) {
+	db.WaitForReady()
+
+	where := "WHERE 1=1"
+	var args []interface{}
+	if query != "" {
+		where += ` AND (source_text LIKE ? ESCAPE '\' OR translated_text LIKE ? ESCAPE '\')`
+		pattern := "%" + escapeLike(query) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if provider != "" {
+		where += " AND provider = ?"
+		args = append(args, provider)
+	}
+	if targetLang != "" {
+		where += " AND target_lang = ?"
+		args = append(args, targetLang)
+	}
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM translation_cache "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, source_text_hash, source_text, target_lang, translated_text, provider,
+		       COALESCE(pinned, 0), created_at
+		FROM translation_cache `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []TranslationCache
+	for rows.Next() {
+		e, err := scanCachedTranslation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// ForEachCachedTranslation calls fn for every cache entry, oldest first.
+func (db *DB) ForEachCachedTranslation(fn func(TranslationCache) error) error {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT id, source_text_hash, source_text, target_lang, translated_text, provider,
+		       COALESCE(pinned, 0), created_at
+		FROM translation_cache
+		ORDER BY id ASC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanCachedTranslation(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateCachedTranslation manually corrects a cached translation.
+// Pinned entries are never overwritten by new translations or removed by cleanup.
+func (db *DB) UpdateCachedTranslation(id int64, translatedText string, pinned bool) error {
+	db.WaitForReady()
+	result, err := db.Exec(
+		`UPDATE translation_cache SET translated_text = ?, pinned = ? WHERE id = ?`,
+		translatedText, pinned, id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteCachedTranslation removes a single cache entry.
+func (db *DB) DeleteCachedTranslation(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM translation_cache WHERE id = ?`, id)
+	return err
+}
+
+// ImportCachedTranslation inserts or updates a cache entry from an export.
+// Existing pinned entries are only replaced by pinned imports. It reports whether a row was written.
+func (db *DB) ImportCachedTranslation(entry TranslationCache) (bool, error) {
+	db.WaitForReady()
+	result, err := db.Exec(`
+		INSERT INTO translation_cache
+		(source_text_hash, source_text, target_lang, translated_text, provider, pinned, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, COALESCE(datetime(NULLIF(?, '')), CURRENT_TIMESTAMP))
+		ON CONFLICT(source_text_hash, target_lang, provider) DO UPDATE SET
+			source_text = excluded.source_text,
+			translated_text = excluded.translated_text,
+			pinned = excluded.pinned,
+			created_at = excluded.created_at
+		WHERE translation_cache.pinned = 0 OR excluded.pinned = 1`,
+		entry.SourceTextHash, entry.SourceText, entry.TargetLang, entry.TranslatedText,
+		entry.Provider, entry.Pinned, entry.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanCachedTranslation(rows *sql.Rows) (TranslationCache, error) {
+	var e TranslationCache
+	err := rows.Scan(&e.ID, &e.SourceTextHash, &e.SourceText, &e.TargetLang, &e.TranslatedText,
+		&e.Provider, &e.Pinned, &e.CreatedAt)
+	return e, err
+}
@@ -0,0 +1,94 @@
+package database_test
+
+import (
+	"testing"
+
+	"MrRSS/internal/database"
+)
+
+func TestTranslationCachePinnedEntries(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.SetCachedTranslation("h1", "Hello", "zh", "你好", "google"); err != nil {
+		t.Fatalf("SetCachedTranslation() error = %v", err)
+	}
+	entries, total, err := db.SearchCachedTranslations("Hell", "google", "zh", 10, 0)
+	if err != nil || total != 1 || len(entries) != 1 {
+		t.Fatalf("SearchCachedTranslations() = %v, %d, %v", entries, total, err)
+	}
+
+	// Wildcards in the query match literally
+	if err := db.SetCachedTranslation("h2", "100% free_trial", "zh", "百分百免费", "google"); err != nil {
+		t.Fatalf("SetCachedTranslation() error = %v", err)
+	}
+	for query, want := range map[string]int{"100%": 1, "%": 1, "e_t": 1, "_": 1, "H_llo": 0} {
+		if _, total, err := db.SearchCachedTranslations(query, "", "", 10, 0); err != nil || total != want {
+			t.Errorf("SearchCachedTranslations(%q) = %d, %v, want %d", query, total, err, want)
+		}
+	}
+
+	if err := db.UpdateCachedTranslation(entries[0].ID, "您好", true); err != nil {
+		t.Fatalf("UpdateCachedTranslation() error = %v", err)
+	}
+
+	// Neither a fresh translation nor cleanup may replace a pinned correction
+	if err := db.SetCachedTranslation("h1", "Hello", "zh", "你好", "google"); err != nil {
+		t.Fatalf("SetCachedTranslation() error = %v", err)
+	}
+	if _, err := db.Exec(`UPDATE translation_cache SET created_at = datetime('now', '-90 days')`); err != nil {
+		t.Fatalf("failed to age entries: %v", err)
+	}
+	if _, err := db.CleanupTranslationCache(30); err != nil {
+		t.Fatalf("CleanupTranslationCache() error = %v", err)
+	}
+	if got, found, _ := db.GetCachedTranslation("h1", "zh", "google"); !found || got != "您好" {
+		t.Fatalf("pinned translation = %q (found %v), want %q", got, found, "您好")
+	}
+
+	// Unpinned imports must not replace it either
+	written, err := db.ImportCachedTranslation(database.TranslationCache{
+		SourceTextHash: "h1", SourceText: "Hello", TargetLang: "zh", TranslatedText: "哈喽", Provider: "google",
+	})
+	if err != nil || written {
+		t.Fatalf("ImportCachedTranslation() = %v, %v; want skipped", written, err)
+	}
+
+	if err := db.DeleteCachedTranslation(entries[0].ID); err != nil {
+		t.Fatalf("DeleteCachedTranslation() error = %v", err)
+	}
+	if _, found, _ := db.GetCachedTranslation("h1", "zh", "google"); found {
+		t.Fatalf("entry still cached after delete")
+	}
+}
+
+func TestTranslationCacheStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetCachedTranslation("h1", "abc", "zh", "def", "google")
+	db.SetCachedTranslation("h2", "ghi", "zh", "jkl", "google")
+	db.SetCachedTranslation("h3", "abc", "ja", "xyz", "deepl")
+	for _, hit := range []bool{true, true, true, false} {
+		if err := db.RecordTranslationCacheLookup("google", "zh", hit); err != nil {
+			t.Fatalf("RecordTranslationCacheLookup() error = %v", err)
+		}
+	}
+
+	stats, err := db.GetTranslationCacheStats()
+	if err != nil {
+		t.Fatalf("GetTranslationCacheStats() error = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", stats)
+	}
+
+	google := stats[1]
+	if google.Provider != "google" || google.Entries != 2 || google.SizeBytes != 12 {
+		t.Errorf("unexpected google stats: %+v", google)
+	}
+	if google.Hits != 3 || google.Misses != 1 || google.HitRate != 0.75 {
+		t.Errorf("unexpected google hit rate: %+v", google)
+	}
+	if stats[0].Provider != "deepl" || stats[0].HitRate != 0 {
+		t.Errorf("unexpected deepl stats: %+v", stats[0])
+	}
+}
@@ -0,0 +1,353 @@
+// Package dbbackup keeps rotating copies of the database. Copies are written online
+// with VACUUM INTO, verified with an integrity check and thinned out to a number of
+// daily and weekly ones. Restoring one first copies the current database, so that a
+// restore can be undone.
+package dbbackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// checkInterval is how often the service checks whether a scheduled backup is due
+	checkInterval = time.Hour
+	// backupInterval is the age of the newest backup at which a new one is made
+	backupInterval = 24 * time.Hour
+
+	filePrefix       = "mrrss-"
+	fileSuffix       = ".db"
+	preRestoreSuffix = "-pre-restore"
+	timeLayout       = "20060102-150405"
+)
+
+var (
+	// ErrNotFound is returned for names that are not backups in the backup directory
+	ErrNotFound = errors.New("backup not found")
+	// ErrCorrupt is returned for backups failing the integrity check
+	ErrCorrupt = errors.New("backup is corrupt")
+)
+
+// Backup is a copy of the database in the backup directory.
+type Backup struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+	PreRestore bool      `json:"pre_restore"` // Made before restoring another backup
+}
+
+// Service makes scheduled and manual backups of a database and restores them.
+type Service struct {
+	db *database.DB
+
+	mu        sync.Mutex // Serializes backups, rotation and restores
+	lastError string
+}
+
+// New creates a backup service.
+func New(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// Dir returns the backup directory: the db_backup_dir setting, or "backups" in the
+// data directory.
+func (s *Service) Dir() (string, error) {
+	if dir, _ := s.db.GetSetting("db_backup_dir"); dir != "" {
+		return dir, nil
+	}
+	dataDir, err := utils.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "backups"), nil
+}
+
+// LastError returns the error of the last scheduled backup, or "".
+func (s *Service) LastError() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError
+}
+
+// List returns the backups, newest first.
+func (s *Service) List() ([]Backup, error) {
+	dir, err := s.Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		backup, ok := parseName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Create makes a backup and removes the backups the retention settings no longer keep.
+func (s *Service) Create(ctx context.Context) (Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backup, err := s.create(ctx, false)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := s.rotate(); err != nil {
+		log.Printf("Error removing old database backups: %v", err)
+	}
+	return backup, nil
+}
+
+// Restore replaces the database with a backup after backing up the current one, and
+// returns that backup. Callers should pause background work that writes to the
+// database first.
+func (s *Service) Restore(ctx context.Context, name string) (Backup, error) {
+	if _, ok := parseName(name); !ok || filepath.Base(name) != name {
+		return Backup{}, ErrNotFound
+	}
+	dir, err := s.Dir()
+	if err != nil {
+		return Backup{}, err
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return Backup{}, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := database.CheckIntegrity(ctx, path); err != nil {
+		return Backup{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	current, err := s.create(ctx, true)
+	if err != nil {
+		return Backup{}, fmt.Errorf("back up current database: %w", err)
+	}
+	if err := s.db.RestoreFrom(ctx, path); err != nil {
+		return Backup{}, err
+	}
+	log.Printf("Restored database backup %s, previous database saved as %s", name, current.Name)
+	return current, nil
+}
+
+// Run makes a backup whenever the newest one is a day old, while the db_backup_enabled
+// setting is on, until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.backupIfDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backupIfDue makes a scheduled backup if backups are enabled and the newest is old.
+func (s *Service) backupIfDue(ctx context.Context, now time.Time) {
+	if enabled, _ := s.db.GetSetting("db_backup_enabled"); enabled != "true" {
+		return
+	}
+	backups, err := s.List()
+	if err != nil {
+		log.Printf("Error listing database backups: %v", err)
+		return
+	}
+	if len(backups) > 0 && now.Sub(backups[0].CreatedAt) < backupInterval {
+		return
+	}
+
+	backup, err := s.Create(ctx)
+	s.mu.Lock()
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("Error backing up database: %v", err)
+		return
+	}
+	log.Printf("Backed up database to %s (%d bytes)", backup.Name, backup.Size)
+}
+
+// create writes a verified backup. The copy is written to a temporary file first, so
+// that failed backups never show up as backups.
+func (s *Service) create(ctx context.Context, preRestore bool) (Backup, error) {
+	dir, err := s.Dir()
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Backup{}, err
+	}
+
+	// Names have a resolution of a second, so later backups in the same second move on
+	backup := Backup{CreatedAt: time.Now().Truncate(time.Second), PreRestore: preRestore}
+	backup.Name = fileName(backup.CreatedAt, preRestore)
+	path := filepath.Join(dir, backup.Name)
+	for exists(path) {
+		backup.CreatedAt = backup.CreatedAt.Add(time.Second)
+		backup.Name = fileName(backup.CreatedAt, preRestore)
+		path = filepath.Join(dir, backup.Name)
+	}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	if err := s.db.BackupTo(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return Backup{}, fmt.Errorf("write backup: %w", err)
+	}
+	if err := database.CheckIntegrity(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return Backup{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+	if info, err := os.Stat(path); err == nil {
+		backup.Size = info.Size()
+	}
+	return backup, nil
+}
+
+// rotate removes the backups that the retention settings no longer keep.
+func (s *Service) rotate() error {
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+	dir, err := s.Dir()
+	if err != nil {
+		return err
+	}
+	keepDaily, keepWeekly := s.setting("db_backup_keep_daily", 7), s.setting("db_backup_keep_weekly", 4)
+	for _, backup := range Expired(backups, keepDaily, keepWeekly) {
+		if err := os.Remove(filepath.Join(dir, backup.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setting returns a numeric setting, or a default for invalid values.
+func (s *Service) setting(key string, fallback int) int {
+	value, _ := s.db.GetSetting(key)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
+
+// Expired returns the backups a retention policy no longer keeps. Of the scheduled and
+// manual backups it keeps the newest of each of the keepDaily newest days and of each
+// of the keepWeekly newest weeks with backups, and always the newest one. Backups made
+// before restores are kept apart, so that restores can be undone: the keepDaily newest,
+// and at least one.
+func Expired(backups []Backup, keepDaily, keepWeekly int) []Backup {
+	sorted := append([]Backup(nil), backups...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.After(sorted[j].CreatedAt) })
+
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	preRestore := 0
+	for _, b := range sorted {
+		if b.PreRestore {
+			if preRestore < max(keepDaily, 1) {
+				keep[b.Name] = true
+			}
+			preRestore++
+			continue
+		}
+
+		t := b.CreatedAt.Local()
+		day := t.Format("2006-01-02")
+		year, week := t.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if len(days) == 0 && len(weeks) == 0 {
+			// The newest backup
+			keep[b.Name] = true
+		}
+		if !days[day] && len(days) < keepDaily {
+			keep[b.Name] = true
+		}
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			keep[b.Name] = true
+		}
+		days[day], weeks[weekKey] = true, true
+	}
+
+	var expired []Backup
+	for _, b := range sorted {
+		if !keep[b.Name] {
+			expired = append(expired, b)
+		}
+	}
+	return expired
+}
+
+// exists reports whether a file exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// fileName returns the name of a backup made at a time.
+func fileName(t time.Time, preRestore bool) string {
+	name := filePrefix + t.UTC().Format(timeLayout)
+	if preRestore {
+		name += preRestoreSuffix
+	}
+	return name + fileSuffix
+}
+
+// parseName parses the name of a backup file.
+func parseName(name string) (Backup, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return Backup{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	preRestore := strings.HasSuffix(stamp, preRestoreSuffix)
+	stamp = strings.TrimSuffix(stamp, preRestoreSuffix)
+
+	createdAt, err := time.ParseInLocation(timeLayout, stamp, time.UTC)
+	if err != nil {
+		return Backup{}, false
+	}
+	return Backup{Name: name, CreatedAt: createdAt, PreRestore: preRestore}, true
+}
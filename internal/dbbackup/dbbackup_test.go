@@ -0,0 +1,180 @@
+package dbbackup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setupService(t *testing.T) (*Service, *database.DB, string) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.NewDB(filepath.Join(dir, "rss.db"))
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	backupDir := filepath.Join(dir, "backups")
+	db.SetSetting("db_backup_dir", backupDir)
+	return New(db), db, backupDir
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	s, db, dir := setupService(t)
+	db.AddFeed(&models.Feed{Title: "Kept", URL: "https://example.com/kept"})
+
+	backup, err := s.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if backup.Size == 0 || backup.PreRestore {
+		t.Errorf("unexpected backup: %+v", backup)
+	}
+	if _, err := os.Stat(filepath.Join(dir, backup.Name)); err != nil {
+		t.Fatalf("backup file missing: %v", err)
+	}
+
+	db.AddFeed(&models.Feed{Title: "Lost", URL: "https://example.com/lost"})
+	previous, err := s.Restore(ctx, backup.Name)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !previous.PreRestore {
+		t.Errorf("expected a pre-restore backup, got %+v", previous)
+	}
+	if feeds, _ := db.GetFeeds(); len(feeds) != 1 {
+		t.Errorf("got %d feeds after restore, want 1", len(feeds))
+	}
+
+	backups, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want the backup and the pre-restore backup", len(backups))
+	}
+
+	// Undo the restore
+	if _, err := s.Restore(ctx, previous.Name); err != nil {
+		t.Fatalf("Restore of the pre-restore backup failed: %v", err)
+	}
+	if feeds, _ := db.GetFeeds(); len(feeds) != 2 {
+		t.Errorf("got %d feeds after undoing the restore, want 2", len(feeds))
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	ctx := context.Background()
+	s, _, dir := setupService(t)
+
+	for _, name := range []string{"../rss.db", "mrrss-20240101-000000.db", "notes.txt"} {
+		if _, err := s.Restore(ctx, name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Restore(%q) returned %v, want ErrNotFound", name, err)
+		}
+	}
+
+	os.MkdirAll(dir, 0755)
+	corrupt := "mrrss-20240101-000000.db"
+	os.WriteFile(filepath.Join(dir, corrupt), []byte("not a database at all, but long enough to read"), 0644)
+	if _, err := s.Restore(ctx, corrupt); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Restore of a corrupt backup returned %v, want ErrCorrupt", err)
+	}
+}
+
+func TestBackupIfDue(t *testing.T) {
+	ctx := context.Background()
+	s, db, _ := setupService(t)
+
+	s.backupIfDue(ctx, time.Now())
+	if backups, _ := s.List(); len(backups) != 0 {
+		t.Fatalf("backed up while disabled: %+v", backups)
+	}
+
+	db.SetSetting("db_backup_enabled", "true")
+	s.backupIfDue(ctx, time.Now())
+	s.backupIfDue(ctx, time.Now().Add(time.Hour))
+	backups, _ := s.List()
+	if len(backups) != 1 {
+		t.Fatalf("got %d backups, want 1 a day", len(backups))
+	}
+	first := backups[0].Name
+
+	// A day later there is a new backup, which replaces the one of the same day
+	s.backupIfDue(ctx, time.Now().Add(25*time.Hour))
+	backups, _ = s.List()
+	if len(backups) != 1 || backups[0].Name == first {
+		t.Fatalf("got backups %+v, want a new one after a day", backups)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	// Two backups a day for 60 days
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	var backups []Backup
+	for day := 0; day < 60; day++ {
+		for _, hour := range []int{9, 21} {
+			at := start.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+			backups = append(backups, Backup{Name: fileName(at, false), CreatedAt: at})
+		}
+	}
+
+	expired := Expired(backups, 7, 4)
+	kept := make(map[string]bool)
+	for _, b := range backups {
+		kept[b.Name] = true
+	}
+	for _, b := range expired {
+		delete(kept, b.Name)
+	}
+
+	// 7 days, plus the newest of the 4 newest weeks that are not among them
+	if len(kept) < 7 || len(kept) > 11 {
+		t.Errorf("kept %d backups, want between 7 and 11", len(kept))
+	}
+	newest := backups[len(backups)-1]
+	if !kept[newest.Name] {
+		t.Error("the newest backup must be kept")
+	}
+	if kept[backups[len(backups)-2].Name] {
+		t.Error("only the newest backup of a day should be kept")
+	}
+	if kept[backups[0].Name] {
+		t.Error("the oldest backup should expire")
+	}
+
+	// Backups made before restores are kept apart from the daily ones
+	undo := Backup{Name: fileName(start.AddDate(0, 0, 59), true), CreatedAt: start.AddDate(0, 0, 59), PreRestore: true}
+	for _, b := range Expired(append(backups, undo), 7, 4) {
+		if b.Name == undo.Name {
+			t.Error("the pre-restore backup expired")
+		}
+	}
+
+	if got := Expired(backups[:1], 0, 0); len(got) != 0 {
+		t.Errorf("the only backup expired: %+v", got)
+	}
+}
+
+func TestParseName(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	b, ok := parseName(fileName(at, true))
+	if !ok || !b.PreRestore || !b.CreatedAt.Equal(at) {
+		t.Fatalf("parseName(fileName()) = %+v, %v", b, ok)
+	}
+	for _, name := range []string{"rss.db", "mrrss-yesterday.db", "mrrss-20240506-070809.db.tmp"} {
+		if _, ok := parseName(name); ok {
+			t.Errorf("parseName(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package dedup detects near-duplicate articles, such as the same press release
+// published by several feeds, with 64-bit SimHash fingerprints of their text.
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"time"
+
+	"MrRSS/internal/summary"
+)
+
+const (
+	// MaxDistance is the largest number of differing fingerprint bits between near-duplicates
+	MaxDistance = 6
+	// Window is how far apart in publication time near-duplicates may be
+	Window = 72 * time.Hour
+	// minTerms is the number of terms needed for a meaningful fingerprint
+	minTerms = 6
+)
+
+// Fingerprint returns the SimHash of an article's title and content (HTML allowed),
+// weighting each term by its frequency. It returns 0, meaning no fingerprint, for
+// texts too short to compare reliably.
+func Fingerprint(title, content string) uint64 {
+	terms := summary.Terms(title + " " + content)
+	if len(terms) < minTerms {
+		return 0
+	}
+
+	var weights [64]int
+	for _, term := range terms {
+		h := fnv.New64a()
+		h.Write([]byte(term))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	if fingerprint == 0 {
+		// 0 is reserved for "no fingerprint"
+		fingerprint = 1
+	}
+	return fingerprint
+}
+
+// Distance returns the number of bits in which two fingerprints differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// IsDuplicate reports whether two fingerprints belong to near-duplicate texts.
+func IsDuplicate(a, b uint64) bool {
+	return a != 0 && b != 0 && Distance(a, b) <= MaxDistance
+}
@@ -0,0 +1,34 @@
+package dedup
+
+import "testing"
+
+const pressRelease = `<p>The city council approved a new budget for public transport on Monday.
+The plan adds twenty electric buses to the fleet over the next two years.
+Council members said the investment will cut emissions and shorten waiting times.
+The first buses are expected to enter service early next spring.</p>`
+
+func TestFingerprint_NearDuplicates(t *testing.T) {
+	original := Fingerprint("Council approves transport budget", pressRelease)
+	copied := Fingerprint("Council approves transport budget", pressRelease+" <p>Source: City Press Office</p>")
+	unrelated := Fingerprint("Rust adds async closures", "<p>The Rust compiler team shipped async closures in the latest release. "+
+		"Developers can now write asynchronous callbacks without boxing futures, and the standard library gained helpers.</p>")
+
+	if original == 0 || copied == 0 || unrelated == 0 {
+		t.Fatalf("expected fingerprints, got %x %x %x", original, copied, unrelated)
+	}
+	if !IsDuplicate(original, copied) {
+		t.Errorf("expected near-duplicates, distance %d", Distance(original, copied))
+	}
+	if IsDuplicate(original, unrelated) {
+		t.Errorf("expected different texts, distance %d", Distance(original, unrelated))
+	}
+}
+
+func TestFingerprint_ShortText(t *testing.T) {
+	if got := Fingerprint("Breaking news", ""); got != 0 {
+		t.Errorf("expected no fingerprint for a short text, got %x", got)
+	}
+	if IsDuplicate(0, 0) {
+		t.Error("missing fingerprints must not be duplicates")
+	}
+}
@@ -0,0 +1,418 @@
+// Package digest builds daily and weekly digests that cluster related articles
+// and summarize each cluster with citations back to the source articles.
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/embedding"
+	"MrRSS/internal/models"
+	"MrRSS/internal/rules"
+	"MrRSS/internal/summary"
+	"MrRSS/internal/utils"
+)
+
+// Digest periods
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+	PeriodCustom = "custom"
+)
+
+const (
+	// DefaultMaxArticles bounds the number of articles considered for one digest
+	DefaultMaxArticles = 300
+	// MaxClusters is the number of clusters that get their own summary; the rest are listed as other stories
+	MaxClusters = 10
+	// minCharsPerArticle is the smallest excerpt sent to AI for each article in a cluster
+	minCharsPerArticle = 300
+	// aiTimeout bounds the HTTP requests of one AI cluster summary
+	aiTimeout = 30 * time.Second
+)
+
+// ErrNoArticles is returned when no articles match the digest options.
+var ErrNoArticles = errors.New("no articles found for digest")
+
+// citationPattern matches [#123] citations in summaries
+var citationPattern = regexp.MustCompile(`\[#(\d+)\]`)
+
+// Options selects the articles that go into a digest and how they are summarized.
+type Options struct {
+	Period     string            `json:"period"`     // "daily", "weekly" or "custom"
+	Since      time.Time         `json:"since"`      // Required for custom periods
+	Until      time.Time         `json:"until"`      // Defaults to now
+	Category   string            `json:"category"`   // Feed category (including subcategories)
+	FeedID     int64             `json:"feed_id"`    // Single feed
+	RuleID     int64             `json:"rule_id"`    // Saved rule whose conditions filter the articles
+	Conditions []rules.Condition `json:"conditions"` // Ad-hoc filter conditions
+	Provider   string            `json:"provider"`   // "ai" or "local"; defaults to the summary_provider setting
+	Length     string            `json:"length"`     // Summary length per cluster: "short", "medium" or "long"
+	Limit      int               `json:"max_articles"`
+}
+
+// Generator creates and stores digests.
+type Generator struct {
+	db      *database.DB
+	tracker aiusage.Limiter
+	content utils.ContentFunc
+}
+
+// NewGenerator creates a digest generator. tracker and content may be nil.
+func NewGenerator(db *database.DB, tracker aiusage.Limiter, content utils.ContentFunc) *Generator {
+	return &Generator{db: db, tracker: tracker, content: content}
+}
+
+// Generate builds a digest for the given options and stores it.
+func (g *Generator) Generate(ctx context.Context, opts Options) (*models.Digest, error) {
+	since, until, err := resolveWindow(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	articles, scope, err := g.selectArticles(opts, since, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, ErrNoArticles
+	}
+
+	provider := opts.Provider
+	if provider == "" {
+		provider, _ = g.db.GetSetting("summary_provider")
+	}
+	if provider != "ai" {
+		provider = "local"
+	}
+
+	digest := &models.Digest{
+		Title:        digestTitle(opts.Period, scope, since, until),
+		Period:       opts.Period,
+		Scope:        scope,
+		WindowStart:  since,
+		WindowEnd:    until,
+		Provider:     provider,
+		ArticleCount: len(articles),
+	}
+
+	docs := make([]summary.Document, len(articles))
+	for i, a := range articles {
+		docs[i] = summary.Document{ID: a.ID, Title: a.Title, Text: g.articleText(a)}
+	}
+
+	var ai *summary.AISummarizer
+	if provider == "ai" {
+		var err error
+		if ai, err = summary.NewAISummarizerForSummaries(g.db, aiTimeout); err != nil {
+			log.Printf("Digest: AI client unavailable, falling back to local: %v", err)
+			digest.UsedFallback = true
+		}
+	}
+	length, _ := summary.ParseLength(opts.Length)
+	local := summary.NewSummarizer()
+
+	clusters := summary.ClusterDocuments(docs, summary.DefaultClusterThreshold)
+	var others []models.DigestArticle
+	for i, members := range clusters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		clusterDocs := make([]summary.Document, len(members))
+		clusterArticles := make([]models.DigestArticle, len(members))
+		for j, idx := range members {
+			clusterDocs[j] = docs[idx]
+			clusterArticles[j] = snapshot(articles[idx])
+		}
+
+		// Singletons beyond the top clusters are only listed
+		if i >= MaxClusters || (len(members) == 1 && len(clusters) > MaxClusters) {
+			others = append(others, clusterArticles...)
+			continue
+		}
+
+		text := ""
+		if ai != nil && (g.tracker == nil || !g.tracker.IsLimitReached()) {
+			text, err = g.summarizeWithAI(ctx, ai, clusterDocs, clusterArticles, length)
+			if err != nil {
+				log.Printf("Digest: AI summary failed, falling back to local: %v", err)
+			}
+		}
+		if text == "" {
+			if ai != nil {
+				digest.UsedFallback = true
+			}
+			text = summarizeLocally(local, clusterDocs, length)
+		}
+
+		digest.Clusters = append(digest.Clusters, models.DigestCluster{
+			Title:    clusterArticles[0].Title,
+			Summary:  text,
+			Articles: clusterArticles,
+		})
+	}
+	if len(others) > 0 {
+		digest.Clusters = append(digest.Clusters, models.DigestCluster{
+			Title:    "Other stories",
+			Articles: others,
+		})
+	}
+
+	id, err := g.db.SaveDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	digest.ID = id
+	digest.CreatedAt = time.Now()
+	return digest, nil
+}
+
+// selectArticles loads articles in the window and applies rule and ad-hoc conditions.
+// It also returns a human-readable description of the scope.
+func (g *Generator) selectArticles(opts Options, since, until time.Time) ([]models.Article, string, error) {
+	limit := opts.Limit
+	if limit <= 0 || limit > DefaultMaxArticles {
+		limit = DefaultMaxArticles
+	}
+
+	var scopeParts []string
+	conditions := opts.Conditions
+	if opts.RuleID > 0 {
+		rule, err := g.findRule(opts.RuleID)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(append([]rules.Condition{}, rule.Conditions...), conditions...)
+		scopeParts = append(scopeParts, "Rule: "+rule.Name)
+	}
+
+	feeds, err := g.db.GetFeeds()
+	if err != nil {
+		return nil, "", err
+	}
+	feedCategories := make(map[int64]string)
+	feedTitles := make(map[int64]string)
+	for _, f := range feeds {
+		feedCategories[f.ID] = f.Category
+		feedTitles[f.ID] = f.Title
+	}
+
+	if opts.Category != "" {
+		scopeParts = append([]string{"Category: " + opts.Category}, scopeParts...)
+	}
+	if opts.FeedID > 0 {
+		scopeParts = append([]string{"Feed: " + feedTitles[opts.FeedID]}, scopeParts...)
+	}
+	if len(opts.Conditions) > 0 {
+		scopeParts = append(scopeParts, "Filtered")
+	}
+	scope := strings.Join(scopeParts, ", ")
+	if scope == "" {
+		scope = "All feeds"
+	}
+
+	// Without conditions the database limit is exact; with conditions load more and filter
+	queryLimit := limit
+	if len(conditions) > 0 {
+		queryLimit = limit * 10
+	}
+	articles, err := g.db.GetArticlesPublishedBetween(since, until, opts.FeedID, opts.Category, queryLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(conditions) > 0 {
+		var filtered []models.Article
+		similarity := embedding.StoredSimilarity(g.db)
+		for _, a := range articles {
+			if rules.MatchesConditionsWith(a, conditions, feedCategories, feedTitles, similarity) {
+				filtered = append(filtered, a)
+			}
+		}
+		articles = filtered
+	}
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, scope, nil
+}
+
+// findRule loads a saved rule by ID from settings.
+func (g *Generator) findRule(id int64) (*rules.Rule, error) {
+	rulesJSON, _ := g.db.GetSetting("rules")
+	var saved []rules.Rule
+	if rulesJSON != "" {
+		if err := json.Unmarshal([]byte(rulesJSON), &saved); err != nil {
+			return nil, fmt.Errorf("failed to parse rules: %w", err)
+		}
+	}
+	for i := range saved {
+		if saved[i].ID == id {
+			return &saved[i], nil
+		}
+	}
+	return nil, fmt.Errorf("rule %d not found", id)
+}
+
+// articleText returns the best available text for an article: its cached summary, then its content.
+func (g *Generator) articleText(a models.Article) string {
+	if a.Summary != "" {
+		return a.Summary
+	}
+	if g.content != nil {
+		content, err := g.content(a.ID)
+		if err != nil {
+			log.Printf("Digest: failed to get content for article %d: %v", a.ID, err)
+		}
+		if content != "" {
+			return content
+		}
+	}
+	return a.Title
+}
+
+// summarizeWithAI asks the AI endpoint for a cited summary of one cluster.
+func (g *Generator) summarizeWithAI(ctx context.Context, ai *summary.AISummarizer, docs []summary.Document, articles []models.DigestArticle, length summary.SummaryLength) (string, error) {
+	perArticle := summary.MaxInputCharsForAI / len(docs)
+	if perArticle < minCharsPerArticle {
+		perArticle = minCharsPerArticle
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Summarize these related articles in approximately %d words:\n\n", targetWords(length))
+	for i, doc := range docs {
+		text := []rune(utils.PlainText(doc.Text))
+		if len(text) > perArticle {
+			text = text[:perArticle]
+		}
+		fmt.Fprintf(&prompt, "[#%d] %s (%s)\n%s\n\n", doc.ID, doc.Title, articles[i].FeedTitle, string(text))
+	}
+
+	systemPrompt := "You write news digests. Combine the given articles into one concise paragraph. " +
+		"After each statement, cite the supporting articles using their IDs exactly as given, e.g. [#12]. " +
+		"Output ONLY the paragraph."
+
+	if g.tracker != nil {
+		g.tracker.WaitForRateLimit()
+	}
+	result, err := ai.Complete(ctx, systemPrompt, prompt.String())
+	if err != nil {
+		return "", err
+	}
+	if g.tracker != nil {
+		if err := g.tracker.Record(result.Usage.Record(aiusage.FeatureSummary, 0)); err != nil {
+			log.Printf("Digest: failed to track AI usage: %v", err)
+		}
+	}
+	return normalizeCitations(result.Text, docs), nil
+}
+
+// summarizeLocally builds a cited extractive summary of one cluster with TextRank.
+func summarizeLocally(s *summary.Summarizer, docs []summary.Document, length summary.SummaryLength) string {
+	sentences := s.SummarizeDocuments(docs, length)
+	parts := make([]string, len(sentences))
+	for i, sent := range sentences {
+		parts[i] = fmt.Sprintf("%s [#%d]", sent.Text, sent.DocumentID)
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeCitations drops citations to articles outside the cluster and
+// cites every article if the model produced no valid citation at all.
+func normalizeCitations(text string, docs []summary.Document) string {
+	valid := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		valid[fmt.Sprint(doc.ID)] = true
+	}
+
+	found := false
+	text = citationPattern.ReplaceAllStringFunc(text, func(m string) string {
+		id := citationPattern.FindStringSubmatch(m)[1]
+		if valid[id] {
+			found = true
+			return m
+		}
+		return ""
+	})
+	text = strings.TrimSpace(text)
+
+	if !found {
+		cites := make([]string, len(docs))
+		for i, doc := range docs {
+			cites[i] = fmt.Sprintf("[#%d]", doc.ID)
+		}
+		text += " " + strings.Join(cites, " ")
+	}
+	return text
+}
+
+// resolveWindow returns the published_at window for the options.
+func resolveWindow(opts Options) (time.Time, time.Time, error) {
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	switch opts.Period {
+	case PeriodDaily:
+		return until.Add(-24 * time.Hour), until, nil
+	case PeriodWeekly:
+		return until.AddDate(0, 0, -7), until, nil
+	case PeriodCustom:
+		if opts.Since.IsZero() || !opts.Since.Before(until) {
+			return time.Time{}, time.Time{}, errors.New("custom digests need a since time before until")
+		}
+		return opts.Since, until, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid digest period %q", opts.Period)
+	}
+}
+
+// digestTitle builds a title such as "Daily digest: Category: Tech (2024-01-02)".
+func digestTitle(period, scope string, since, until time.Time) string {
+	name := "Digest"
+	switch period {
+	case PeriodDaily:
+		name = "Daily digest"
+	case PeriodWeekly:
+		name = "Weekly digest"
+	}
+
+	dates := until.Format("2006-01-02")
+	if period != PeriodDaily {
+		dates = since.Format("2006-01-02") + " – " + dates
+	}
+	return fmt.Sprintf("%s: %s (%s)", name, scope, dates)
+}
+
+// snapshot copies the fields of an article that a digest needs to keep.
+func snapshot(a models.Article) models.DigestArticle {
+	return models.DigestArticle{
+		ID:        a.ID,
+		Title:     a.Title,
+		URL:       a.URL,
+		FeedTitle: a.FeedTitle,
+		Published: a.PublishedAt,
+	}
+}
+
+// targetWords mirrors the summary package's target word counts.
+func targetWords(length summary.SummaryLength) int {
+	switch length {
+	case summary.Short:
+		return summary.ShortTargetWords
+	case summary.Long:
+		return summary.LongTargetWords
+	default:
+		return summary.MediumTargetWords
+	}
+}
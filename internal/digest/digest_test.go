@@ -0,0 +1,169 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+type fakeTracker struct {
+	limitReached bool
+	tracked      int
+}
+
+func (f *fakeTracker) IsLimitReached() bool { return f.limitReached }
+func (f *fakeTracker) WaitForRateLimit()    {}
+func (f *fakeTracker) Record(rec models.AIUsageRecord) error {
+	f.tracked++
+	return nil
+}
+
+func setupDigestDB(t *testing.T) (*database.DB, time.Time) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+
+	techID, _ := db.AddFeed(&models.Feed{Title: "Tech News", URL: "https://tech.example/feed", Category: "Tech"})
+	spaceID, _ := db.AddFeed(&models.Feed{Title: "Sky Watch", URL: "https://sky.example/feed", Category: "Science"})
+
+	now := time.Now()
+	articles := []models.Article{
+		{FeedID: techID, Title: "Rust release adds async closures", URL: "https://tech.example/1",
+			Summary: "The Rust compiler team shipped async closures in the latest release. Developers can now write async closures directly."},
+		{FeedID: techID, Title: "Async closures arrive in new Rust compiler release", URL: "https://tech.example/2",
+			Summary: "The new Rust compiler release brings async closures. The release also improves compile times for large crates."},
+		{FeedID: spaceID, Title: "Lunar eclipse visible across Europe tonight", URL: "https://sky.example/1",
+			Summary: "Astronomers expect a total lunar eclipse visible from Europe tonight. Clear skies are forecast in the south."},
+		{FeedID: spaceID, Title: "Old eclipse report", URL: "https://sky.example/old", PublishedAt: now.AddDate(0, 0, -10),
+			Summary: "This article is outside of the digest window and must be ignored by daily digests."},
+	}
+	for i, a := range articles {
+		if a.PublishedAt.IsZero() {
+			a.PublishedAt = now.Add(-time.Duration(i+1) * time.Hour)
+		}
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle() error = %v", err)
+		}
+	}
+	return db, now
+}
+
+func TestGenerate_LocalDigestWithCitations(t *testing.T) {
+	db, now := setupDigestDB(t)
+
+	d, err := NewGenerator(db, nil, nil).Generate(context.Background(), Options{Period: PeriodDaily, Until: now, Provider: "local"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if d.ArticleCount != 3 || len(d.Clusters) != 2 {
+		t.Fatalf("expected 3 articles in 2 clusters, got %d articles, clusters %+v", d.ArticleCount, d.Clusters)
+	}
+	rust := d.Clusters[0]
+	if len(rust.Articles) != 2 || !strings.Contains(rust.Title, "Rust") {
+		t.Errorf("unexpected first cluster: %+v", rust)
+	}
+	if !citationPattern.MatchString(rust.Summary) {
+		t.Errorf("local summary has no citations: %q", rust.Summary)
+	}
+
+	stored, err := db.GetDigestByID(d.ID)
+	if err != nil {
+		t.Fatalf("GetDigestByID() error = %v", err)
+	}
+	if len(stored.Clusters) != 2 || stored.Clusters[0].Summary != rust.Summary {
+		t.Errorf("stored digest does not match generated digest: %+v", stored)
+	}
+
+	md := RenderMarkdown(stored)
+	if !strings.Contains(md, "# Daily digest: All feeds") ||
+		!strings.Contains(md, "](https://tech.example/1)") ||
+		!strings.Contains(md, "][") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+
+	// Scope narrows the selection
+	d, err = NewGenerator(db, nil, nil).Generate(context.Background(), Options{Period: PeriodDaily, Until: now, Provider: "local", Category: "Science"})
+	if err != nil || d.ArticleCount != 1 {
+		t.Fatalf("category digest = %+v, %v", d, err)
+	}
+
+	_, err = NewGenerator(db, nil, nil).Generate(context.Background(), Options{Period: PeriodDaily, Until: now, Category: "Missing"})
+	if !errors.Is(err, ErrNoArticles) {
+		t.Errorf("expected ErrNoArticles, got %v", err)
+	}
+}
+
+func TestGenerate_AIRespectsUsageLimit(t *testing.T) {
+	db, now := setupDigestDB(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": "Rust shipped async closures [#1] [#999]."}},
+			},
+		})
+	}))
+	defer server.Close()
+	db.SetSetting("ai_endpoint", server.URL)
+
+	tracker := &fakeTracker{}
+	d, err := NewGenerator(db, tracker, nil).Generate(context.Background(), Options{Period: PeriodDaily, Until: now, Provider: "ai"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if requests != 2 || tracker.tracked != 2 || d.UsedFallback {
+		t.Fatalf("requests=%d tracked=%d fallback=%v", requests, tracker.tracked, d.UsedFallback)
+	}
+	if strings.Contains(d.Clusters[0].Summary, "[#999]") || !strings.Contains(d.Clusters[0].Summary, "[#1]") {
+		t.Errorf("citations not normalized: %q", d.Clusters[0].Summary)
+	}
+
+	tracker.limitReached = true
+	d, err = NewGenerator(db, tracker, nil).Generate(context.Background(), Options{Period: PeriodDaily, Until: now, Provider: "ai"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if requests != 2 || !d.UsedFallback || d.Clusters[0].Summary == "" {
+		t.Errorf("expected local fallback when the limit is reached, requests=%d digest=%+v", requests, d)
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	loc := time.UTC
+	now := time.Date(2024, 5, 10, 8, 30, 0, 0, loc)
+
+	tests := []struct {
+		name      string
+		lastRun   time.Time
+		frequency string
+		hour      int
+		want      bool
+	}{
+		{"never run", time.Time{}, PeriodDaily, 7, true},
+		{"ran today", time.Date(2024, 5, 10, 7, 5, 0, 0, loc), PeriodDaily, 7, false},
+		{"ran yesterday", time.Date(2024, 5, 9, 7, 5, 0, 0, loc), PeriodDaily, 7, true},
+		{"before hour", time.Date(2024, 5, 9, 9, 5, 0, 0, loc), PeriodDaily, 9, false},
+		{"weekly ran 3 days ago", time.Date(2024, 5, 7, 7, 5, 0, 0, loc), PeriodWeekly, 7, false},
+		{"weekly ran a week ago", time.Date(2024, 5, 3, 7, 5, 0, 0, loc), PeriodWeekly, 7, true},
+	}
+	for _, tt := range tests {
+		if got := IsDue(now, tt.lastRun, tt.frequency, tt.hour); got != tt.want {
+			t.Errorf("%s: IsDue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
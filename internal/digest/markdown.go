@@ -0,0 +1,65 @@
+package digest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/models"
+)
+
+// RenderMarkdown renders a digest as a Markdown document.
+// Citations like [#12] become reference-style links to the cited articles.
+func RenderMarkdown(d *models.Digest) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", d.Title)
+	fmt.Fprintf(&b, "_%s · %s to %s · %d articles_\n\n",
+		d.Scope, d.WindowStart.Format("2006-01-02 15:04"), d.WindowEnd.Format("2006-01-02 15:04"), d.ArticleCount)
+
+	var refs []models.DigestArticle
+	seen := make(map[int64]bool)
+	for _, cluster := range d.Clusters {
+		for _, a := range cluster.Articles {
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				refs = append(refs, a)
+			}
+		}
+	}
+
+	for _, cluster := range d.Clusters {
+		fmt.Fprintf(&b, "## %s\n\n", escapeMarkdown(cluster.Title))
+		if cluster.Summary != "" {
+			b.WriteString(linkCitations(cluster.Summary, seen))
+			b.WriteString("\n\n")
+		}
+		for _, a := range cluster.Articles {
+			fmt.Fprintf(&b, "- [%s](%s) — %s\n", escapeMarkdown(a.Title), a.URL, escapeMarkdown(a.FeedTitle))
+		}
+		b.WriteString("\n")
+	}
+
+	// Reference definitions for the citation links
+	for _, a := range refs {
+		fmt.Fprintf(&b, "[%d]: %s\n", a.ID, a.URL)
+	}
+
+	return b.String()
+}
+
+// linkCitations turns [#12] into [#12][12] when the article is known.
+func linkCitations(text string, known map[int64]bool) string {
+	return citationPattern.ReplaceAllStringFunc(text, func(m string) string {
+		id, err := strconv.ParseInt(citationPattern.FindStringSubmatch(m)[1], 10, 64)
+		if err != nil || !known[id] {
+			return m
+		}
+		return fmt.Sprintf("[#%d][%d]", id, id)
+	})
+}
+
+// escapeMarkdown escapes characters that would break link text.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(s)
+}
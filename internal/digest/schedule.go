@@ -0,0 +1,23 @@
+package digest
+
+import "time"
+
+// IsDue reports whether a scheduled digest should run at now.
+// Digests run once the configured hour has passed, once per day or once per week.
+func IsDue(now, lastRun time.Time, frequency string, hour int) bool {
+	if hour < 0 || hour > 23 {
+		hour = 0
+	}
+
+	// Most recent scheduled slot at or before now
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+
+	if frequency == PeriodWeekly {
+		// Run at most once within any seven consecutive slots
+		return lastRun.Before(slot.AddDate(0, 0, -6))
+	}
+	return lastRun.Before(slot)
+}
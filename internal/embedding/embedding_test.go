@@ -0,0 +1,294 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/rag"
+)
+
+type fakeTracker struct {
+	mu           sync.Mutex
+	limitReached bool
+	records      []models.AIUsageRecord
+}
+
+func (f *fakeTracker) IsLimitReached() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.limitReached
+}
+
+func (f *fakeTracker) WaitForRateLimit() {}
+
+func (f *fakeTracker) Record(rec models.AIUsageRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, rec)
+	return nil
+}
+
+// keywords are the dimensions of the fake embeddings
+var keywords = []string{"rust", "go", "bridge", "city"}
+
+// newEmbeddingServer serves OpenAI-style embeddings that count keywords in each input.
+func newEmbeddingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+
+		for _, text := range req.Input {
+			if strings.Contains(text, "poison") {
+				http.Error(w, `{"error":{"message":"input rejected"}}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i, text := range req.Input {
+			text = strings.ToLower(text)
+			vector := make([]float32, len(keywords)+1)
+			for j, k := range keywords {
+				vector[j] = float32(strings.Count(text, k))
+			}
+			vector[len(keywords)] = 0.1
+			data = append(data, item{Index: i, Embedding: vector})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":  data,
+			"usage": map[string]int{"prompt_tokens": 7},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setupService(t *testing.T) (*Service, *fakeTracker, map[string]int64) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	server := newEmbeddingServer(t)
+	db.SetSetting("ai_embedding_enabled", "true")
+	db.SetSetting("ai_embedding_endpoint", server.URL+"/v1/embeddings")
+	db.SetSetting("ai_embedding_model", "test-embed")
+
+	techID, _ := db.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed", Category: "Tech"})
+	cityID, _ := db.AddFeed(&models.Feed{Title: "City", URL: "https://city.example/feed", Category: "Local"})
+	now := time.Now()
+	articles := []models.Article{
+		{FeedID: techID, Title: "Rust async closures", URL: "https://tech.example/rust", Summary: "Rust ships async closures for rust users.", PublishedAt: now.Add(-time.Hour)},
+		{FeedID: techID, Title: "Rust and Go compared", URL: "https://tech.example/compare", Summary: "Rust versus Go.", PublishedAt: now.Add(-2 * time.Hour)},
+		{FeedID: cityID, Title: "City bridge repairs", URL: "https://city.example/bridge", Summary: "The city repairs the bridge.", PublishedAt: now.Add(-3 * time.Hour)},
+	}
+	ids := make(map[string]int64)
+	for _, a := range articles {
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle() error = %v", err)
+		}
+		db.QueryRow("SELECT id FROM articles WHERE url = ?", a.URL).Scan(&a.ID)
+		ids[a.URL] = a.ID
+	}
+
+	tracker := &fakeTracker{}
+	return New(db, tracker, nil), tracker, ids
+}
+
+func TestCosineAndNormalize(t *testing.T) {
+	a := Normalize([]float32{3, 4})
+	if a[0] != 0.6 || a[1] != 0.8 {
+		t.Errorf("Normalize() = %v", a)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{2, 0}); got != 1 {
+		t.Errorf("Cosine(parallel) = %v", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Errorf("Cosine(orthogonal) = %v", got)
+	}
+	if got := Cosine([]float32{1}, []float32{1, 0}); got != 0 {
+		t.Errorf("Cosine(different lengths) = %v", got)
+	}
+}
+
+func TestBackfillRelatedAndSearch(t *testing.T) {
+	s, tracker, ids := setupService(t)
+	var indexed []models.Article
+	s.SetIndexedFunc(func(articles []models.Article) { indexed = append(indexed, articles...) })
+	s.EnqueueArticles(models.Feed{}, []int64{ids["https://city.example/bridge"]})
+
+	s.backfill(context.Background())
+
+	status, err := s.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Indexed != 3 || status.Total != 3 || status.Model != "test-embed" || status.Running || status.LastError != "" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if len(tracker.records) != 1 || tracker.records[0].Feature != aiusage.FeatureEmbedding || tracker.records[0].PromptTokens != 7 {
+		t.Errorf("unexpected usage records %+v", tracker.records)
+	}
+	if len(indexed) != 1 || indexed[0].ID != ids["https://city.example/bridge"] {
+		t.Errorf("expected the fresh article to be reported, got %+v", indexed)
+	}
+
+	related, err := s.Related(context.Background(), ids["https://tech.example/rust"], 5)
+	if err != nil {
+		t.Fatalf("Related() error = %v", err)
+	}
+	if len(related) != 2 || related[0].ID != ids["https://tech.example/compare"] || related[0].Score <= related[1].Score {
+		t.Errorf("unexpected related articles %+v", related)
+	}
+
+	results, err := s.Search(context.Background(), "bridges in the city", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID != ids["https://city.example/bridge"] {
+		t.Errorf("unexpected search results %+v", results)
+	}
+
+	similarity := StoredSimilarity(s.db)
+	if score, ok := similarity(ids["https://tech.example/rust"], ids["https://tech.example/compare"]); !ok || score < 0.5 {
+		t.Errorf("StoredSimilarity() = %v, %v", score, ok)
+	}
+	if _, ok := similarity(ids["https://tech.example/rust"], 9999); ok {
+		t.Error("expected no similarity for an article without embedding")
+	}
+}
+
+func TestRelatedIndexesOnDemand(t *testing.T) {
+	s, _, ids := setupService(t)
+
+	related, err := s.Related(context.Background(), ids["https://tech.example/rust"], 5)
+	if err != nil {
+		t.Fatalf("Related() error = %v", err)
+	}
+	// Only the requested article is indexed, so nothing else is similar yet
+	if len(related) != 0 {
+		t.Errorf("unexpected related articles %+v", related)
+	}
+	if status, _ := s.Status(); status.Indexed != 1 {
+		t.Errorf("expected one indexed article, got %+v", status)
+	}
+}
+
+func TestBackfillPausesAtUsageLimit(t *testing.T) {
+	s, tracker, ids := setupService(t)
+	tracker.limitReached = true
+
+	s.backfill(context.Background())
+	status, _ := s.Status()
+	if status.Indexed != 0 || !status.Paused {
+		t.Errorf("expected a paused backfill, got %+v", status)
+	}
+	if _, err := s.Related(context.Background(), ids["https://tech.example/rust"], 5); err != ErrLimitReached {
+		t.Errorf("expected ErrLimitReached, got %v", err)
+	}
+
+	s.db.SetSetting("ai_embedding_enabled", "false")
+	if _, err := s.Search(context.Background(), "rust", 5); err != ErrDisabled {
+		t.Errorf("expected ErrDisabled, got %v", err)
+	}
+}
+
+func TestBackfillSkipsRejectedArticles(t *testing.T) {
+	s, _, _ := setupService(t)
+	feedID, _ := s.db.AddFeed(&models.Feed{Title: "Spam", URL: "https://spam.example/feed"})
+	s.db.SaveArticle(&models.Article{FeedID: feedID, Title: "poison", URL: "https://spam.example/1", PublishedAt: time.Now()})
+
+	s.backfill(context.Background())
+	status, _ := s.Status()
+	if status.Indexed != 3 || status.LastError != "" {
+		t.Fatalf("expected the other articles indexed, got %+v", status)
+	}
+	if pending, _ := s.db.GetArticlesWithoutEmbedding(s.Model(), 10); len(pending) != 0 {
+		t.Errorf("expected the rejected article to wait before a retry, got %+v", pending)
+	}
+
+	// After the last attempt the article is counted as failed
+	s.db.Exec(`UPDATE embedding_failures SET attempts = 2, failed_at = datetime('now', '-2 hours')`)
+	s.backfill(context.Background())
+	if status, _ := s.Status(); status.Failed != 1 {
+		t.Errorf("expected one failed article, got %+v", status)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	s, _, _ := setupService(t)
+	s.backfill(context.Background())
+	if err := s.Rebuild(); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if status, _ := s.Status(); status.Indexed != 0 {
+		t.Errorf("expected no embeddings after rebuild, got %+v", status)
+	}
+	s.backfill(context.Background())
+	if status, _ := s.Status(); status.Indexed != 3 {
+		t.Errorf("expected all articles indexed again, got %+v", status)
+	}
+}
+
+func TestReset(t *testing.T) {
+	s, _, ids := setupService(t)
+	s.backfill(context.Background())
+	article := ids["https://tech.example/rust"]
+	if _, err := s.Related(context.Background(), article, 2); err != nil {
+		t.Fatal(err)
+	}
+	s.EnqueueArticles(models.Feed{}, []int64{article})
+
+	s.Reset()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil || len(s.fresh) != 0 {
+		t.Errorf("expected no index and no queued articles after a reset, got %v and %v", s.index, s.fresh)
+	}
+}
+
+func TestRetrieverAppliesFilter(t *testing.T) {
+	s, _, ids := setupService(t)
+	s.backfill(context.Background())
+	r := NewRetriever(s)
+
+	candidates, err := r.Retrieve(context.Background(), "rust", rag.Filter{}, 2)
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].Article.ID != ids["https://tech.example/rust"] {
+		t.Errorf("unexpected candidates %+v", candidates)
+	}
+
+	candidates, _ = r.Retrieve(context.Background(), "rust", rag.Filter{Categories: []string{"Local"}}, 5)
+	if len(candidates) != 1 || candidates[0].Article.ID != ids["https://city.example/bridge"] {
+		t.Errorf("unexpected filtered candidates %+v", candidates)
+	}
+
+	// Failing to embed the question yields no candidates rather than an error
+	s.db.SetSetting("ai_embedding_enabled", "false")
+	if candidates, err := r.Retrieve(context.Background(), "rust", rag.Filter{}, 5); err != nil || len(candidates) != 0 {
+		t.Errorf("expected no candidates, got %+v, %v", candidates, err)
+	}
+}
@@ -0,0 +1,157 @@
+package embedding
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"MrRSS/internal/database"
+)
+
+// Match is an article found by similarity search.
+type Match struct {
+	ArticleID int64   `json:"article_id"`
+	Score     float64 `json:"score"` // Cosine similarity
+}
+
+// Index holds the article vectors of one model in memory for brute-force search.
+type Index struct {
+	model string
+
+	mu      sync.RWMutex
+	vectors map[int64][]float32
+}
+
+// NewIndex creates an index of normalized vectors keyed by article ID.
+func NewIndex(model string, vectors map[int64][]float32) *Index {
+	if vectors == nil {
+		vectors = make(map[int64][]float32)
+	}
+	return &Index{model: model, vectors: vectors}
+}
+
+// Model returns the model that produced the vectors.
+func (idx *Index) Model() string {
+	return idx.model
+}
+
+// Len returns the number of vectors.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.vectors)
+}
+
+// Get returns the vector of an article, or nil.
+func (idx *Index) Get(articleID int64) []float32 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.vectors[articleID]
+}
+
+// Put adds or replaces the normalized vector of an article.
+func (idx *Index) Put(articleID int64, vector []float32) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.vectors[articleID] = vector
+}
+
+// Search returns up to limit articles most similar to a normalized query vector,
+// best first, skipping the excluded article (0 for none).
+func (idx *Index) Search(query []float32, limit int, exclude int64) []Match {
+	idx.mu.RLock()
+	matches := make([]Match, 0, len(idx.vectors))
+	for id, v := range idx.vectors {
+		if id != exclude && len(v) == len(query) {
+			matches = append(matches, Match{ArticleID: id, Score: dot(query, v)})
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ArticleID > matches[j].ArticleID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Normalize scales a vector to unit length in place and returns it.
+func Normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i := range v {
+		v[i] = float32(float64(v[i]) * scale)
+	}
+	return v
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their lengths differ
+// or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var ab, aa, bb float64
+	for i := range a {
+		ab += float64(a[i]) * float64(b[i])
+		aa += float64(a[i]) * float64(a[i])
+		bb += float64(b[i]) * float64(b[i])
+	}
+	if aa == 0 || bb == 0 {
+		return 0
+	}
+	return ab / math.Sqrt(aa*bb)
+}
+
+// dot returns the dot product, which is the cosine similarity of normalized vectors.
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// StoredSimilarity returns a function comparing the stored embeddings of two articles.
+// It reports false if either article has no embedding or they come from different
+// models. Vectors are cached for the lifetime of the returned function.
+func StoredSimilarity(db *database.DB) func(articleID, otherID int64) (float64, bool) {
+	type entry struct {
+		model  string
+		vector []float32
+	}
+	var mu sync.Mutex
+	cache := make(map[int64]*entry)
+	load := func(id int64) *entry {
+		mu.Lock()
+		defer mu.Unlock()
+		if e, ok := cache[id]; ok {
+			return e
+		}
+		var e *entry
+		if model, vector, err := db.GetArticleEmbedding(id); err == nil {
+			e = &entry{model: model, vector: vector}
+		}
+		cache[id] = e
+		return e
+	}
+
+	return func(articleID, otherID int64) (float64, bool) {
+		a, b := load(articleID), load(otherID)
+		if a == nil || b == nil || a.model != b.model {
+			return 0, false
+		}
+		return Cosine(a.vector, b.vector), true
+	}
+}
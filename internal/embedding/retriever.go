@@ -0,0 +1,125 @@
+package embedding
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"MrRSS/internal/rag"
+)
+
+// maxRetrieveCandidates bounds the matches searched before applying the filter
+const maxRetrieveCandidates = 500
+
+// Retriever ranks articles by the similarity of their embedding to the question.
+// It implements rag.Retriever.
+type Retriever struct {
+	service *Service
+}
+
+// NewRetriever creates a semantic retriever for library chat.
+func NewRetriever(s *Service) *Retriever {
+	return &Retriever{service: s}
+}
+
+// Retrieve returns the indexed articles most similar to the question that match the
+// filter. When the question cannot be embedded it returns no articles, so full-text
+// results are still used.
+func (r *Retriever) Retrieve(ctx context.Context, question string, filter rag.Filter, limit int) ([]rag.Candidate, error) {
+	searchLimit := maxRetrieveCandidates
+	if filter.IsZero() {
+		searchLimit = limit
+	}
+	matches, err := r.service.searchMatches(ctx, question, searchLimit)
+	if err != nil {
+		log.Printf("Semantic retrieval skipped: %v", err)
+		return nil, nil
+	}
+
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ArticleID
+	}
+	articles, err := r.service.db.GetArticleTextsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := r.feedCategories(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]int, len(articles))
+	for i, a := range articles {
+		byID[a.ID] = i
+	}
+	var candidates []rag.Candidate
+	for _, m := range matches {
+		i, ok := byID[m.ArticleID]
+		if !ok {
+			continue
+		}
+		a := articles[i]
+		if a.IsHidden || !matchesFilter(a.FeedID, categories[a.FeedID], a.PublishedAt, filter) {
+			continue
+		}
+		candidates = append(candidates, rag.Candidate{Article: a, Score: m.Score})
+		if len(candidates) == limit {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// feedCategories maps feed IDs to categories when the filter needs them.
+func (r *Retriever) feedCategories(filter rag.Filter) (map[int64]string, error) {
+	if len(filter.Categories) == 0 {
+		return nil, nil
+	}
+	feeds, err := r.service.db.GetFeeds()
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[int64]string, len(feeds))
+	for _, f := range feeds {
+		categories[f.ID] = f.Category
+	}
+	return categories, nil
+}
+
+// matchesFilter applies a library chat filter to an article.
+func matchesFilter(feedID int64, category string, publishedAt time.Time, filter rag.Filter) bool {
+	if len(filter.FeedIDs) > 0 {
+		found := false
+		for _, id := range filter.FeedIDs {
+			if id == feedID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(filter.Categories) > 0 {
+		found := false
+		for _, c := range filter.Categories {
+			// Categories include their subcategories
+			if category == c || strings.HasPrefix(category, c+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !filter.Since.IsZero() && publishedAt.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && !publishedAt.Before(filter.Until) {
+		return false
+	}
+	return true
+}
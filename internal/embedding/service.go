@@ -0,0 +1,511 @@
+// Package embedding computes article embeddings through the configured embeddings
+// endpoint, stores them in the database and answers similarity queries: related
+// articles, semantic search and retrieval for library chat. New and existing
+// articles are indexed in the background within the AI usage limits.
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// DefaultLimit is the number of related articles or search results by default
+	DefaultLimit = 10
+	// MaxLimit bounds the number of related articles or search results
+	MaxLimit = 50
+	// batchSize is the number of articles embedded per request
+	batchSize = 16
+	// pollInterval is how often unindexed articles are checked when no new work is signalled
+	pollInterval = 5 * time.Minute
+	// requestTimeout bounds one embeddings request
+	requestTimeout = 60 * time.Second
+	// maxTextChars bounds the text embedded for one article (about 2000 tokens)
+	maxTextChars = 8000
+)
+
+var (
+	// ErrDisabled is returned when embeddings are disabled
+	ErrDisabled = errors.New("embeddings are disabled")
+	// ErrLimitReached is returned when the AI usage limit is reached
+	ErrLimitReached = errors.New("AI usage limit reached")
+)
+
+// IndexedFunc receives newly fetched articles once they are indexed.
+type IndexedFunc func(articles []models.Article)
+
+// Status describes the progress of indexing.
+type Status struct {
+	Enabled   bool   `json:"enabled"`
+	Model     string `json:"model"`
+	Indexed   int    `json:"indexed"` // Visible articles with an embedding from Model
+	Total     int    `json:"total"`   // Visible articles
+	Failed    int    `json:"failed"`  // Visible articles the endpoint kept rejecting
+	Running   bool   `json:"running"`
+	Paused    bool   `json:"paused"` // Waiting for the AI usage limit to reset
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Result is an article found by similarity.
+type Result struct {
+	models.Article
+	Score float64 `json:"score"`
+}
+
+// Service indexes articles in the background and answers similarity queries.
+type Service struct {
+	db      *database.DB
+	tracker aiusage.Limiter
+	content utils.ContentFunc
+
+	wake chan struct{}
+
+	mu        sync.Mutex
+	index     *Index
+	running   bool
+	paused    bool
+	lastError string
+	fresh     map[int64]bool // Newly fetched articles waiting to be indexed
+	onIndexed IndexedFunc
+}
+
+// New creates an embedding service. tracker and content may be nil.
+func New(db *database.DB, tracker aiusage.Limiter, content utils.ContentFunc) *Service {
+	return &Service{
+		db:      db,
+		tracker: tracker,
+		content: content,
+		wake:    make(chan struct{}, 1),
+		fresh:   make(map[int64]bool),
+	}
+}
+
+// SetIndexedFunc sets the function called with newly fetched articles once they are indexed,
+// e.g. to apply rules with similarity conditions.
+func (s *Service) SetIndexedFunc(f IndexedFunc) {
+	s.mu.Lock()
+	s.onIndexed = f
+	s.mu.Unlock()
+}
+
+// Enabled reports whether embeddings are enabled.
+func (s *Service) Enabled() bool {
+	enabled, _ := s.db.GetSetting("ai_embedding_enabled")
+	return enabled == "true"
+}
+
+// Model returns the configured embeddings model.
+func (s *Service) Model() string {
+	cfg, _ := aiclient.EmbeddingConfigFromSettings(s.db)
+	return cfg.Model
+}
+
+// EnqueueArticles indexes newly saved articles of a feed ahead of the next poll.
+func (s *Service) EnqueueArticles(feed models.Feed, articleIDs []int64) {
+	if len(articleIDs) == 0 || !s.Enabled() {
+		return
+	}
+	s.mu.Lock()
+	for _, id := range articleIDs {
+		s.fresh[id] = true
+	}
+	s.mu.Unlock()
+	s.Trigger()
+}
+
+// Trigger starts indexing without waiting for the next poll.
+func (s *Service) Trigger() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Rebuild deletes all embeddings and indexes the articles again, e.g. after changing the model.
+func (s *Service) Rebuild() error {
+	if _, err := s.db.DeleteArticleEmbeddings(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.index = nil
+	s.lastError = ""
+	s.mu.Unlock()
+	s.Trigger()
+	return nil
+}
+
+// Reset forgets the in-memory index and the queued articles, e.g. after the database was
+// replaced by a backup whose article IDs they do not match.
+func (s *Service) Reset() {
+	s.mu.Lock()
+	s.index = nil
+	s.fresh = make(map[int64]bool)
+	s.lastError = ""
+	s.mu.Unlock()
+	s.Trigger()
+}
+
+// Status returns the indexing progress.
+func (s *Service) Status() (*Status, error) {
+	model := s.Model()
+	indexed, total, err := s.db.CountArticleEmbeddings(model)
+	if err != nil {
+		return nil, err
+	}
+	failed, err := s.db.CountEmbeddingFailures(model)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Status{
+		Enabled:   s.Enabled(),
+		Model:     model,
+		Indexed:   indexed,
+		Total:     total,
+		Failed:    failed,
+		Running:   s.running,
+		Paused:    s.paused,
+		LastError: s.lastError,
+	}, nil
+}
+
+// Run indexes unindexed articles until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.Enabled() {
+			s.backfill(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// backfill embeds unindexed articles, newest first, until none are left, the usage
+// limit is reached or a request fails.
+func (s *Service) backfill(ctx context.Context) {
+	client, err := aiclient.NewEmbeddingClient(s.db, requestTimeout)
+	if err != nil {
+		s.setError(err)
+		return
+	}
+
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	for ctx.Err() == nil && s.Enabled() {
+		limited := s.tracker != nil && s.tracker.IsLimitReached()
+		s.mu.Lock()
+		s.paused = limited
+		s.mu.Unlock()
+		if limited {
+			return
+		}
+
+		articles, err := s.db.GetArticlesWithoutEmbedding(client.Model(), batchSize)
+		if err != nil {
+			s.setError(err)
+			return
+		}
+		if len(articles) == 0 {
+			// Fresh articles left over were hidden or deleted before being indexed
+			s.mu.Lock()
+			s.fresh = make(map[int64]bool)
+			s.mu.Unlock()
+			return
+		}
+		err = s.indexArticles(ctx, client, articles)
+		if err != nil && ctx.Err() == nil && rejected(err) {
+			err = s.indexEach(ctx, client, articles, err)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error indexing article embeddings: %v", err)
+				s.setError(err)
+			}
+			return
+		}
+	}
+}
+
+// indexEach embeds the articles of a rejected batch one at a time and records the
+// articles the endpoint rejects, so that they do not hold up the others.
+func (s *Service) indexEach(ctx context.Context, client *aiclient.Client, articles []database.ArticleText, batchErr error) error {
+	for _, a := range articles {
+		err := batchErr
+		if len(articles) > 1 {
+			err = s.indexArticles(ctx, client, []database.ArticleText{a})
+		}
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil || !rejected(err) {
+			return err
+		}
+		log.Printf("Failed to embed article %d: %v", a.ID, err)
+		if err := s.db.RecordEmbeddingFailure(a.ID, client.Model(), err.Error()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejected reports whether err is about the request content rather than the endpoint,
+// so that sending the same article again would fail again.
+func rejected(err error) bool {
+	return errors.Is(err, aiclient.ErrBadRequest) || errors.Is(err, aiclient.ErrInvalidResponse)
+}
+
+// indexArticles embeds and stores a batch of articles.
+func (s *Service) indexArticles(ctx context.Context, client *aiclient.Client, articles []database.ArticleText) error {
+	texts := make([]string, len(articles))
+	for i, a := range articles {
+		texts[i] = s.articleText(a)
+	}
+
+	if s.tracker != nil {
+		s.tracker.WaitForRateLimit()
+	}
+	vectors, usage, err := client.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	s.recordUsage(usage)
+
+	model := client.Model()
+	index := s.loadIndex(model)
+	var indexedFresh []models.Article
+	for i, a := range articles {
+		vector := Normalize(vectors[i])
+		if err := s.db.SaveArticleEmbedding(a.ID, model, vector); err != nil {
+			return err
+		}
+		if index != nil {
+			index.Put(a.ID, vector)
+		}
+
+		s.mu.Lock()
+		if s.fresh[a.ID] {
+			delete(s.fresh, a.ID)
+			indexedFresh = append(indexedFresh, a.Article)
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.lastError = ""
+	onIndexed := s.onIndexed
+	s.mu.Unlock()
+	if onIndexed != nil && len(indexedFresh) > 0 {
+		onIndexed(indexedFresh)
+	}
+	return nil
+}
+
+// Related returns the articles most similar to an article. An article that is not
+// indexed yet is embedded first.
+func (s *Service) Related(ctx context.Context, articleID int64, limit int) ([]Result, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	model := s.Model()
+	index := s.loadIndex(model)
+	if index == nil {
+		return nil, errors.New("failed to load embeddings")
+	}
+
+	vector := index.Get(articleID)
+	if vector == nil {
+		articles, err := s.db.GetArticleTextsByIDs([]int64{articleID})
+		if err != nil {
+			return nil, err
+		}
+		if len(articles) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		if s.tracker != nil && s.tracker.IsLimitReached() {
+			return nil, ErrLimitReached
+		}
+		client, err := aiclient.NewEmbeddingClient(s.db, requestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.indexArticles(ctx, client, articles); err != nil {
+			return nil, err
+		}
+		if vector = index.Get(articleID); vector == nil {
+			return nil, errors.New("failed to index article")
+		}
+	}
+
+	return s.results(index.Search(vector, candidateLimit(limit), articleID), limit)
+}
+
+// Search returns the articles most similar to a query.
+func (s *Service) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	matches, err := s.searchMatches(ctx, query, candidateLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	return s.results(matches, limit)
+}
+
+// searchMatches embeds a query and returns the most similar indexed articles.
+func (s *Service) searchMatches(ctx context.Context, query string, limit int) ([]Match, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("missing query")
+	}
+	if s.tracker != nil {
+		if s.tracker.IsLimitReached() {
+			return nil, ErrLimitReached
+		}
+		s.tracker.WaitForRateLimit()
+	}
+
+	client, err := aiclient.NewEmbeddingClient(s.db, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	vectors, usage, err := client.Embed(ctx, []string{utils.Truncate(query, maxTextChars)})
+	if err != nil {
+		return nil, err
+	}
+	s.recordUsage(usage)
+
+	index := s.loadIndex(client.Model())
+	if index == nil {
+		return nil, errors.New("failed to load embeddings")
+	}
+	return index.Search(Normalize(vectors[0]), limit, 0), nil
+}
+
+// results loads the visible articles of matches, keeping their order, up to limit.
+func (s *Service) results(matches []Match, limit int) ([]Result, error) {
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ArticleID
+	}
+	articles, err := s.db.GetArticleTextsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Article, len(articles))
+	for _, a := range articles {
+		byID[a.ID] = a.Article
+	}
+
+	results := []Result{}
+	for _, m := range matches {
+		a, ok := byID[m.ArticleID]
+		if !ok || a.IsHidden {
+			continue
+		}
+		results = append(results, Result{Article: a, Score: m.Score})
+		if len(results) == clampLimit(limit) {
+			break
+		}
+	}
+	return results, nil
+}
+
+// loadIndex returns the in-memory index of a model, loading it from the database when
+// the model changed. It returns nil if the embeddings cannot be loaded.
+func (s *Service) loadIndex(model string) *Index {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil && s.index.Model() == model {
+		return s.index
+	}
+	vectors, err := s.db.GetArticleEmbeddings(model)
+	if err != nil {
+		log.Printf("Error loading article embeddings: %v", err)
+		return nil
+	}
+	s.index = NewIndex(model, vectors)
+	return s.index
+}
+
+// articleText returns the text embedded for an article: its title followed by its
+// summary, or its stored or fetched content.
+func (s *Service) articleText(a database.ArticleText) string {
+	body := a.Summary
+	if body == "" {
+		body = a.Content
+	}
+	if body == "" && s.content != nil {
+		text, err := s.content(a.ID)
+		if err != nil {
+			log.Printf("Embeddings: failed to get content for article %d: %v", a.ID, err)
+		}
+		body = text
+	}
+	text := a.Title
+	if body = utils.PlainText(body); body != "" {
+		text += "\n\n" + body
+	}
+	return utils.Truncate(text, maxTextChars)
+}
+
+// recordUsage records the tokens of an embeddings request.
+func (s *Service) recordUsage(usage aiclient.Usage) {
+	if s.tracker == nil {
+		return
+	}
+	if err := s.tracker.Record(usage.Record(aiusage.FeatureEmbedding, 0)); err != nil {
+		log.Printf("Embeddings: failed to track AI usage: %v", err)
+	}
+}
+
+// setError records the last indexing error.
+func (s *Service) setError(err error) {
+	s.mu.Lock()
+	s.lastError = err.Error()
+	s.mu.Unlock()
+}
+
+// clampLimit bounds a requested number of results, defaulting to DefaultLimit.
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// candidateLimit returns the number of matches searched for limit results, leaving
+// room for hidden and deleted articles.
+func candidateLimit(limit int) int {
+	return 2*clampLimit(limit) + 10
+}
@@ -1,7 +1,9 @@
 package feed
 
 import (
+	"MrRSS/internal/dedup"
 	"MrRSS/internal/models"
+	"MrRSS/internal/summary"
 	"MrRSS/internal/utils"
 	"regexp"
 	"strings"
@@ -85,6 +87,10 @@ func (f *Fetcher) processArticles(feed models.Feed, items []*gofeed.Item) []*mod
 			VideoURL:        videoURL,
 			PublishedAt:     published,
 			TranslatedTitle: translatedTitle,
+			Fingerprint:     dedup.Fingerprint(title, content),
+			Content:         content,
+			Keywords:        extractKeywords(title, content),
+			WordCount:       summary.CountWords(content),
 		}
 		articles = append(articles, article)
 	}
@@ -92,6 +98,15 @@ func (f *Fetcher) processArticles(feed models.Feed, items []*gofeed.Item) []*mod
 	return articles
 }
 
+// extractKeywords extracts the keyphrases and entities of an article with the local summarizer.
+func extractKeywords(title, content string) []models.ArticleKeyword {
+	var keywords []models.ArticleKeyword
+	for _, k := range summary.ExtractKeywords(title, content, summary.DefaultKeywordLimit) {
+		keywords = append(keywords, models.ArticleKeyword{Term: k.Term, Text: k.Text, Score: k.Score, Entity: k.Entity})
+	}
+	return keywords
+}
+
 // extractImageURL extracts the image URL from a feed item
 func extractImageURL(item *gofeed.Item) string {
 	// Try item.Image first
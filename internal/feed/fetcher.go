@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"MrRSS/internal/aiclient"
 	"MrRSS/internal/database"
 	"MrRSS/internal/models"
 	"MrRSS/internal/rules"
@@ -10,6 +11,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +31,7 @@ type Fetcher struct {
 	translator        translation.Translator
 	scriptExecutor    *ScriptExecutor
 	progress          Progress
+	paused            bool // Refreshes are skipped while paused, e.g. during a database restore
 	mu                sync.Mutex
 	refreshCalculator *IntelligentRefreshCalculator
 	// Queue tracking for individual feed refreshes
@@ -36,6 +39,23 @@ type Fetcher struct {
 	queueMu     sync.Mutex
 	// Priority system
 	priorityMu sync.Mutex // Protects priority operations
+	// Receive newly saved articles, e.g. for automatic summaries and embeddings
+	articleQueues []ArticleQueue
+	// Labels newly saved articles in the background
+	classifier ArticleClassifier
+}
+
+// ArticleQueue receives the IDs of articles newly saved for a feed.
+type ArticleQueue interface {
+	EnqueueArticles(feed models.Feed, articleIDs []int64)
+}
+
+// ArticleClassifier assigns labels to articles newly saved for a feed in the background.
+// The rules that match labels are applied once the labels are stored, and the other
+// rules right away.
+type ArticleClassifier interface {
+	Enabled() bool
+	EnqueueArticles(feed models.Feed, articles []*models.Article)
 }
 
 func NewFetcher(db *database.DB, translator translation.Translator) *Fetcher {
@@ -72,6 +92,16 @@ func NewFetcher(db *database.DB, translator translation.Translator) *Fetcher {
 	}
 }
 
+// AddArticleQueue adds a queue that is notified of newly saved articles.
+func (f *Fetcher) AddArticleQueue(q ArticleQueue) {
+	f.articleQueues = append(f.articleQueues, q)
+}
+
+// SetClassifier sets the classifier that labels newly saved articles.
+func (f *Fetcher) SetClassifier(c ArticleClassifier) {
+	f.classifier = c
+}
+
 // GetIntelligentRefreshCalculator returns the refresh calculator
 func (f *Fetcher) GetIntelligentRefreshCalculator() *IntelligentRefreshCalculator {
 	return f.refreshCalculator
@@ -179,17 +209,19 @@ func (f *Fetcher) setupTranslator() {
 			t = translation.NewGoogleFreeTranslatorWithDB(f.db)
 		}
 	case "ai":
-		apiKey, _ := f.db.GetEncryptedSetting("ai_api_key")
-		endpoint, _ := f.db.GetSetting("ai_endpoint")
-		model, _ := f.db.GetSetting("ai_model")
-		if apiKey != "" {
-			t = translation.NewAITranslatorWithDB(apiKey, endpoint, model, f.db)
-			// Set custom headers if available
-			if aiTranslator, ok := t.(*translation.AITranslator); ok {
-				customHeaders, _ := f.db.GetSetting("ai_custom_headers")
-				aiTranslator.SetCustomHeaders(customHeaders)
+		// Use the AI profile selected for translation, or the global AI settings.
+		// Local endpoints (e.g. Ollama) work without an API key.
+		cfg, err := aiclient.ConfigForFeature(f.db, aiclient.FeatureTranslation)
+		if err == nil && (cfg.APIKey != "" || aiclient.IsLocalEndpoint(cfg.Endpoint)) {
+			httpClient, proxyErr := aiclient.NewHTTPClient(f.db, 30*time.Second)
+			if proxyErr != nil {
+				httpClient = &http.Client{Timeout: 30 * time.Second}
 			}
-		} else {
+			if client, err := aiclient.New(cfg, httpClient); err == nil {
+				t = translation.NewAITranslatorWithClient(client)
+			}
+		}
+		if t == nil {
 			t = translation.NewGoogleFreeTranslatorWithDB(f.db)
 		}
 	default:
@@ -201,7 +233,7 @@ func (f *Fetcher) setupTranslator() {
 
 func (f *Fetcher) FetchAll(ctx context.Context) {
 	f.mu.Lock()
-	if f.progress.IsRunning {
+	if f.progress.IsRunning || f.paused {
 		f.mu.Unlock()
 		return
 	}
@@ -275,7 +307,20 @@ Finish:
 	f.db.SetSetting("last_article_update", time.Now().Format(time.RFC3339))
 }
 
+// LocalURLPrefix starts the URLs of local feeds, which hold articles added by the app
+// itself, such as imported read-later items. Local feeds are never fetched.
+const LocalURLPrefix = "local://"
+
+// IsLocal reports whether feed is a local feed.
+func IsLocal(feed models.Feed) bool {
+	return strings.HasPrefix(feed.URL, LocalURLPrefix)
+}
+
 func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
+	if IsLocal(feed) {
+		return
+	}
+
 	// Use ParseFeedWithFeed with normal priority for feed refresh
 	parsedFeed, err := f.ParseFeedWithFeed(ctx, &feed, false) // Normal priority for refresh
 	if err != nil {
@@ -318,24 +363,52 @@ func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
 		if err := f.db.SaveArticles(ctx, articlesToSave); err != nil {
 			log.Printf("Error saving articles for feed %s: %v", feed.Title, err)
 		} else {
-			// Apply rules to newly saved articles
-			// We fetch the recent articles for this feed since SaveArticles doesn't return IDs
-			// This is limited to the number of articles we just saved
-			savedArticles, err := f.db.GetArticles("", feed.ID, "", false, len(articlesToSave), 0)
-			if err == nil && len(savedArticles) > 0 {
-				engine := rules.NewEngine(f.db)
-				affected, err := engine.ApplyRulesToArticles(savedArticles)
-				if err != nil {
-					log.Printf("Error applying rules for feed %s: %v", feed.Title, err)
-				} else if affected > 0 {
-					utils.DebugLog("Applied rules to %d articles in feed %s", affected, feed.Title)
+			var newArticles []*models.Article
+			for _, article := range articlesToSave {
+				if article.ID > 0 {
+					newArticles = append(newArticles, article)
 				}
 			}
+			f.processNewArticles(feed, newArticles)
 		}
 	}
 	utils.DebugLog("Updated feed: %s", feed.Title)
 }
 
+// processNewArticles applies the rules to the articles just saved for a feed and
+// passes them to the classifier and the article queues. While the classifier labels
+// them, only the rules that do not match labels are applied.
+func (f *Fetcher) processNewArticles(feed models.Feed, newArticles []*models.Article) {
+	if len(newArticles) == 0 {
+		return
+	}
+	articles := make([]models.Article, len(newArticles))
+	newIDs := make([]int64, len(newArticles))
+	for i, article := range newArticles {
+		articles[i] = *article
+		newIDs[i] = article.ID
+	}
+
+	engine := rules.NewEngine(f.db)
+	var affected int
+	var err error
+	if f.classifier != nil && f.classifier.Enabled() {
+		affected, err = engine.ApplyRulesWithoutLabels(articles)
+		f.classifier.EnqueueArticles(feed, newArticles)
+	} else {
+		affected, err = engine.ApplyRulesToArticles(articles)
+	}
+	if err != nil {
+		log.Printf("Error applying rules for feed %s: %v", feed.Title, err)
+	} else if affected > 0 {
+		utils.DebugLog("Applied rules to %d articles in feed %s", affected, feed.Title)
+	}
+
+	for _, q := range f.articleQueues {
+		q.EnqueueArticles(feed, newIDs)
+	}
+}
+
 // FetchSingleFeed fetches a single feed with progress tracking.
 // This is used when adding a new feed, refreshing a single feed from the context menu,
 // or when the scheduler triggers individual feed refreshes.
@@ -354,6 +427,14 @@ func (f *Fetcher) FetchSingleFeed(ctx context.Context, feed models.Feed) {
 
 	// Update progress to reflect the new queue state
 	f.mu.Lock()
+	if f.paused {
+		f.mu.Unlock()
+		f.queueMu.Lock()
+		delete(f.queuedFeeds, feed.ID)
+		f.queueMu.Unlock()
+		utils.DebugLog("Refreshes are paused, skipping feed %s", feed.Title)
+		return
+	}
 	if !f.progress.IsRunning {
 		f.progress.IsRunning = true
 		f.progress.Total = queuedCount
@@ -410,6 +491,11 @@ func (f *Fetcher) FetchFeedsByIDs(ctx context.Context, feedIDs []int64) {
 		}
 		f.mu.Lock()
 	}
+	if f.paused {
+		f.mu.Unlock()
+		log.Println("FetchFeedsByIDs: Refreshes are paused")
+		return
+	}
 	f.progress.IsRunning = true
 	f.progress.Total = len(feedIDs)
 	f.progress.Current = 0
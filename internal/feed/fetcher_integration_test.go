@@ -87,3 +87,72 @@ func TestFetchFeed_SavesArticlesAndAppliesRules(t *testing.T) {
 		t.Fatalf("expected at least one favorite article from rules, got 0")
 	}
 }
+
+type fakeClassifier struct{ queued []*models.Article }
+
+func (c *fakeClassifier) Enabled() bool { return true }
+
+func (c *fakeClassifier) EnqueueArticles(feed models.Feed, articles []*models.Article) {
+	c.queued = append(c.queued, articles...)
+}
+
+type fakeArticleQueue struct{ ids []int64 }
+
+func (q *fakeArticleQueue) EnqueueArticles(feed models.Feed, articleIDs []int64) {
+	q.ids = append(q.ids, articleIDs...)
+}
+
+func TestFetchFeed_DefersOnlyLabelRulesWhileLabeling(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+
+	rss := `<?xml version="1.0"?><rss><channel><title>ITest</title>` +
+		`<item><title>favme item</title><link>/1</link><guid>1</guid><pubDate>Mon, 02 Jan 2006 15:04:05 MST</pubDate></item>` +
+		`</channel></rss>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rss))
+	}))
+	defer srv.Close()
+
+	ffetcher := NewFetcher(db, nil)
+	classifier := &fakeClassifier{}
+	queue := &fakeArticleQueue{}
+	ffetcher.SetClassifier(classifier)
+	ffetcher.AddArticleQueue(queue)
+
+	id, _ := db.AddFeed(&models.Feed{Title: "itest", URL: srv.URL})
+	rules := []map[string]interface{}{
+		{
+			"name": "unlabeled", "enabled": true,
+			"conditions": []map[string]interface{}{{"field": "label", "negate": true}},
+			"actions":    []string{"hide"},
+		},
+		{
+			"name": "fav rule", "enabled": true,
+			"conditions": []map[string]interface{}{{"field": "article_title", "operator": "contains", "value": "favme"}},
+			"actions":    []string{"favorite"},
+		},
+	}
+	rb, _ := json.Marshal(rules)
+	db.SetSetting("rules", string(rb))
+
+	feedRow, _ := db.GetFeedByID(id)
+	ffetcher.FetchFeed(context.Background(), *feedRow)
+
+	if len(classifier.queued) != 1 || len(queue.ids) != 1 || classifier.queued[0].ID != queue.ids[0] {
+		t.Fatalf("new article not queued right away: classifier %d, queue %v", len(classifier.queued), queue.ids)
+	}
+	article, err := db.GetArticleByID(queue.ids[0])
+	if err != nil {
+		t.Fatalf("GetArticleByID error: %v", err)
+	}
+	if article.IsHidden || !article.IsFavorite {
+		t.Errorf("expected only the rule without labels applied, got hidden %v, favorite %v", article.IsHidden, article.IsFavorite)
+	}
+}
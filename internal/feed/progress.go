@@ -33,3 +33,24 @@ func (f *Fetcher) waitForProgressComplete(timeout time.Duration) bool {
 	}
 	return true
 }
+
+// Pause keeps new refreshes from starting and waits for running ones to complete.
+// It returns false and resumes if they do not complete within the timeout.
+func (f *Fetcher) Pause(timeout time.Duration) bool {
+	f.mu.Lock()
+	f.paused = true
+	f.mu.Unlock()
+
+	if !f.waitForProgressComplete(timeout) {
+		f.Resume()
+		return false
+	}
+	return true
+}
+
+// Resume allows refreshes again after Pause.
+func (f *Fetcher) Resume() {
+	f.mu.Lock()
+	f.paused = false
+	f.mu.Unlock()
+}
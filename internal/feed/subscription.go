@@ -162,6 +162,17 @@ func (f *Fetcher) ImportSubscription(title, url, category string) (int64, error)
 	return f.db.AddFeed(feed)
 }
 
+// ImportFeed imports a feed with all its options and returns the feed ID. Imported
+// feeds go after the feeds already in their category, in the order they are imported,
+// which for OPML files is the order of their outlines.
+func (f *Fetcher) ImportFeed(feed models.Feed) (int64, error) {
+	if feed.Title == "" {
+		feed.Title = "Untitled Feed"
+	}
+	feed.Position = 0
+	return f.db.AddFeed(&feed)
+}
+
 // ParseFeed parses an RSS feed from a URL and returns the parsed feed
 func (f *Fetcher) ParseFeed(ctx context.Context, url string) (*gofeed.Feed, error) {
 	return f.fp.ParseURLWithContext(url, ctx)
@@ -0,0 +1,614 @@
+package greader
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetryWait bounds how long a rate-limited request waits to be retried once
+const maxRetryWait = time.Minute
+
+// ErrRateLimited is returned when the server rate-limits requests for longer than
+// maxRetryWait
+var ErrRateLimited = errors.New("rate limited")
+
+// Account holds the server and credentials of a sync account
+type Account struct {
+	Profile   *Profile
+	ServerURL string // "" for the profile's default
+	Username  string
+	Password  string // Password, API password or, with TokenAuth profiles, an access token
+	AppID     string // Application ID, for AppCredentials profiles
+	AppKey    string // Application key, for AppCredentials profiles
+}
+
+// Client represents a Google Reader API client
+type Client struct {
+	profile    *Profile
+	baseURL    string
+	account    Account
+	authScheme string // Prefix of the Authorization header before the auth token
+	authToken  string
+	writeToken string // Write token for modifying operations, fetched on first use
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	lastRequest time.Time // Time of the last request, for RequestInterval
+}
+
+// NewClient creates a new Google Reader API client for an account
+func NewClient(account Account) *Client {
+	serverURL := account.ServerURL
+	if serverURL == "" {
+		serverURL = account.Profile.DefaultURL
+	}
+	// Ensure URL ends with the API path, e.g. /api/greader.php
+	serverURL = strings.TrimSuffix(serverURL, "/")
+	if !strings.HasSuffix(serverURL, account.Profile.APIPath) {
+		serverURL += account.Profile.APIPath
+	}
+
+	return &Client{
+		profile: account.Profile,
+		baseURL: serverURL,
+		account: account,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
+			},
+		},
+	}
+}
+
+// Profile returns the provider of the client
+func (c *Client) Profile() *Profile {
+	return c.profile
+}
+
+// Login authenticates with the server and retrieves an auth token. An access token
+// given instead of a username and password is used as is.
+func (c *Client) Login(ctx context.Context) error {
+	if c.profile.TokenAuth && c.account.Username == "" && c.account.Password != "" {
+		c.authScheme, c.authToken = "Bearer ", c.account.Password
+		return nil
+	}
+
+	data := url.Values{}
+	for key, values := range c.profile.LoginParams {
+		data[key] = values
+	}
+	data.Set("Email", c.account.Username)
+	data.Set("Passwd", c.account.Password)
+
+	resp, err := c.do(ctx, "POST", c.baseURL+"/accounts/ClientLogin", data, false)
+	if err != nil {
+		return fmt.Errorf("login request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read login response: %w", err)
+	}
+
+	// Parse response: SID=token\nAuth=token
+	lines := strings.Split(string(body), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Auth=") {
+			c.authScheme = "GoogleLogin auth="
+			c.authToken = strings.TrimSpace(strings.TrimPrefix(line, "Auth="))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("auth token not found in response")
+}
+
+// GetToken retrieves a write token for modifying operations
+func (c *Client) GetToken(ctx context.Context) (string, error) {
+	if c.authToken == "" {
+		return "", fmt.Errorf("not authenticated")
+	}
+
+	resp, err := c.do(ctx, "GET", c.baseURL+"/reader/api/0/token", nil, true)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read token response: %w", err)
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
+
+// Subscription represents a feed subscription
+type Subscription struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	Categories []Category `json:"categories"`
+}
+
+// Category represents a feed category
+type Category struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// GetCategories retrieves all categories/tags
+func (c *Client) GetCategories(ctx context.Context) ([]Category, error) {
+	var result struct {
+		Tags []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"` // "folder" or "tag"
+		} `json:"tags"`
+	}
+	if err := c.get(ctx, "/reader/api/0/tag/list", url.Values{"output": {"json"}}, &result); err != nil {
+		return nil, fmt.Errorf("categories: %w", err)
+	}
+
+	// Convert tags to categories
+	categories := make([]Category, 0, len(result.Tags))
+	for _, tag := range result.Tags {
+		// Extract label from ID ("user/-/label/LabelName" format)
+		id := userTag(tag.ID)
+		if strings.HasPrefix(id, LabelPrefix) {
+			categories = append(categories, Category{
+				ID:    id,
+				Label: strings.TrimPrefix(id, LabelPrefix),
+			})
+		}
+	}
+
+	return categories, nil
+}
+
+// GetSubscriptions retrieves all feed subscriptions
+func (c *Client) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var result struct {
+		Subscriptions []Subscription `json:"subscriptions"`
+	}
+	if err := c.get(ctx, "/reader/api/0/subscription/list", url.Values{"output": {"json"}}, &result); err != nil {
+		return nil, fmt.Errorf("subscriptions: %w", err)
+	}
+
+	for i := range result.Subscriptions {
+		for j := range result.Subscriptions[i].Categories {
+			cat := &result.Subscriptions[i].Categories[j]
+			cat.ID = userTag(cat.ID)
+		}
+	}
+	return result.Subscriptions, nil
+}
+
+// Stream and tag IDs of the Google Reader API
+const (
+	StreamReadingList = "user/-/state/com.google/reading-list"
+	StateRead         = "user/-/state/com.google/read"
+	StateStarred      = "user/-/state/com.google/starred"
+	LabelPrefix       = "user/-/label/"
+	FeedPrefix        = "feed/"
+
+	// ItemIDPrefix starts the long form of item IDs
+	ItemIDPrefix = "tag:google.com,2005:reader/item/"
+	// editBatchSize bounds the items of an edit-tag request
+	editBatchSize = 250
+)
+
+// StreamOptions selects and pages the items of a stream.
+type StreamOptions struct {
+	Count        int       // Items per page (n)
+	Continuation string    // Continuation token of the previous page (c)
+	Since        time.Time // Only items crawled after this time (ot)
+	Until        time.Time // Only items crawled before this time (nt)
+	Exclude      string    // Exclude items with this tag, e.g. StateRead (xt)
+	Oldest       bool      // Oldest items first (r=o)
+}
+
+func (o StreamOptions) query() url.Values {
+	q := url.Values{}
+	q.Set("output", "json")
+	if o.Count > 0 {
+		q.Set("n", strconv.Itoa(o.Count))
+	}
+	if o.Continuation != "" {
+		q.Set("c", o.Continuation)
+	}
+	if !o.Since.IsZero() {
+		q.Set("ot", strconv.FormatInt(o.Since.Unix(), 10))
+	}
+	if !o.Until.IsZero() {
+		q.Set("nt", strconv.FormatInt(o.Until.Unix(), 10))
+	}
+	if o.Exclude != "" {
+		q.Set("xt", o.Exclude)
+	}
+	if o.Oldest {
+		q.Set("r", "o")
+	}
+	return q
+}
+
+// Item represents an item of a stream
+type Item struct {
+	ID           string // Long form item ID
+	FeedStreamID string // Stream ID of the item's subscription, e.g. "feed/12"
+	Title        string
+	URL          string
+	Content      string
+	Author       string
+	Published    time.Time
+	Crawled      time.Time
+	Updated      time.Time // Latest change the server reports (updated or timestampUsec), zero if none
+	Read         bool
+	Starred      bool
+	Categories   []string
+}
+
+// ItemRef references an item of a stream by ID
+type ItemRef struct {
+	ID      string    // Long form item ID
+	Crawled time.Time // Crawl time of the item
+}
+
+// Article represents a FreshRSS article
+type Article struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	URL        string    `json:"canonical,omitempty"`
+	Content    string    `json:"summary,omitempty"`
+	Published  time.Time `json:"published"`
+	Updated    time.Time `json:"updated"`
+	Author     string    `json:"author,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+}
+
+// GetUnreadArticles retrieves unread articles
+func (c *Client) GetUnreadArticles(ctx context.Context, maxItems int) ([]Article, error) {
+	items, _, err := c.GetStreamContents(ctx, StreamReadingList, StreamOptions{Count: maxItems, Exclude: StateRead})
+	if err != nil {
+		return nil, err
+	}
+
+	articles := make([]Article, len(items))
+	for i, item := range items {
+		articles[i] = Article{
+			ID:         item.ID,
+			Title:      item.Title,
+			URL:        item.URL,
+			Content:    item.Content,
+			Published:  item.Published,
+			Updated:    item.Published,
+			Author:     item.Author,
+			Categories: item.Categories,
+		}
+	}
+	return articles, nil
+}
+
+// GetStreamContents retrieves a page of the items of a stream with their content,
+// and the continuation token of the next page ("" on the last page)
+func (c *Client) GetStreamContents(ctx context.Context, streamID string, opts StreamOptions) ([]Item, string, error) {
+	var result struct {
+		Continuation string `json:"continuation"`
+		Items        []struct {
+			ID            string `json:"id"`
+			Title         string `json:"title"`
+			CrawlTimeMsec string `json:"crawlTimeMsec"`
+			TimestampUsec string `json:"timestampUsec"`
+			Published     int64  `json:"published"`
+			Updated       int64  `json:"updated"`
+			Author        string `json:"author"`
+			Canonical     []struct {
+				Href string `json:"href"`
+			} `json:"canonical"`
+			Alternate []struct {
+				Href string `json:"href"`
+			} `json:"alternate"`
+			Summary struct {
+				Content string `json:"content"`
+			} `json:"summary"`
+			Content struct {
+				Content string `json:"content"`
+			} `json:"content"`
+			Categories []string `json:"categories"`
+			Origin     struct {
+				StreamID string `json:"streamId"`
+			} `json:"origin"`
+		} `json:"items"`
+	}
+	if err := c.get(ctx, "/reader/api/0/stream/contents/"+streamID, opts.query(), &result); err != nil {
+		return nil, "", fmt.Errorf("stream contents: %w", err)
+	}
+
+	items := make([]Item, len(result.Items))
+	for i, it := range result.Items {
+		item := Item{
+			ID:           LongItemID(it.ID, c.profile.ShortIDs),
+			FeedStreamID: it.Origin.StreamID,
+			Title:        it.Title,
+			Content:      it.Content.Content,
+			Author:       it.Author,
+			Published:    time.Unix(it.Published, 0),
+			Categories:   it.Categories,
+		}
+		if item.Content == "" {
+			item.Content = it.Summary.Content
+		}
+		if len(it.Canonical) > 0 {
+			item.URL = it.Canonical[0].Href
+		} else if len(it.Alternate) > 0 {
+			item.URL = it.Alternate[0].Href
+		}
+		if ms, err := strconv.ParseInt(it.CrawlTimeMsec, 10, 64); err == nil {
+			item.Crawled = time.UnixMilli(ms)
+		}
+		if it.Updated > 0 {
+			item.Updated = time.Unix(it.Updated, 0)
+		}
+		if usec, err := strconv.ParseInt(it.TimestampUsec, 10, 64); err == nil && time.UnixMicro(usec).After(item.Updated) {
+			item.Updated = time.UnixMicro(usec)
+		}
+		for _, cat := range it.Categories {
+			switch userTag(cat) {
+			case StateRead:
+				item.Read = true
+			case StateStarred:
+				item.Starred = true
+			}
+		}
+		items[i] = item
+	}
+	return items, result.Continuation, nil
+}
+
+// GetStreamItemIDs retrieves a page of the item IDs of a stream, and the continuation
+// token of the next page ("" on the last page)
+func (c *Client) GetStreamItemIDs(ctx context.Context, streamID string, opts StreamOptions) ([]ItemRef, string, error) {
+	q := opts.query()
+	q.Set("s", streamID)
+
+	var result struct {
+		Continuation string `json:"continuation"`
+		ItemRefs     []struct {
+			ID            string `json:"id"`
+			TimestampUsec string `json:"timestampUsec"`
+		} `json:"itemRefs"`
+	}
+	if err := c.get(ctx, "/reader/api/0/stream/items/ids", q, &result); err != nil {
+		return nil, "", fmt.Errorf("stream item IDs: %w", err)
+	}
+
+	refs := make([]ItemRef, len(result.ItemRefs))
+	for i, ref := range result.ItemRefs {
+		refs[i] = ItemRef{ID: LongItemID(ref.ID, c.profile.ShortIDs)}
+		if usec, err := strconv.ParseInt(ref.TimestampUsec, 10, 64); err == nil {
+			refs[i].Crawled = time.UnixMicro(usec)
+		}
+	}
+	return refs, result.Continuation, nil
+}
+
+// EditTag adds and removes a tag, e.g. StateRead, on items, in batches
+func (c *Client) EditTag(ctx context.Context, itemIDs []string, addTag, removeTag string) error {
+	for start := 0; start < len(itemIDs); start += editBatchSize {
+		end := min(start+editBatchSize, len(itemIDs))
+
+		data := url.Values{}
+		for _, id := range itemIDs[start:end] {
+			data.Add("i", id)
+		}
+		if addTag != "" {
+			data.Set("a", addTag)
+		}
+		if removeTag != "" {
+			data.Set("r", removeTag)
+		}
+		if err := c.post(ctx, "/reader/api/0/edit-tag", data); err != nil {
+			return fmt.Errorf("edit tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarkAsRead marks articles as read
+func (c *Client) MarkAsRead(ctx context.Context, articleIDs []string) error {
+	return c.EditTag(ctx, articleIDs, StateRead, "")
+}
+
+// EditSubscription edits a subscription: action is "subscribe", "unsubscribe" or
+// "edit", and addLabel and removeLabel move it between categories (labels)
+func (c *Client) EditSubscription(ctx context.Context, action, streamID, title, addLabel, removeLabel string) error {
+	data := url.Values{}
+	data.Set("ac", action)
+	data.Set("s", streamID)
+	if title != "" {
+		data.Set("t", title)
+	}
+	if addLabel != "" {
+		data.Set("a", LabelPrefix+addLabel)
+	}
+	if removeLabel != "" {
+		data.Set("r", LabelPrefix+removeLabel)
+	}
+	if err := c.post(ctx, "/reader/api/0/subscription/edit", data); err != nil {
+		return fmt.Errorf("%s subscription: %w", action, err)
+	}
+	return nil
+}
+
+// SubscribeToFeed subscribes to a new feed
+func (c *Client) SubscribeToFeed(ctx context.Context, feedURL, title string) error {
+	return c.EditSubscription(ctx, "subscribe", FeedPrefix+feedURL, title, "", "")
+}
+
+// get performs an authenticated GET request and decodes the JSON response into result
+func (c *Client) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if c.authToken == "" {
+		return fmt.Errorf("not authenticated")
+	}
+
+	resp, err := c.do(ctx, "GET", c.baseURL+path+"?"+query.Encode(), nil, true)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// post performs an authenticated POST request with a write token
+func (c *Client) post(ctx context.Context, path string, data url.Values) error {
+	if c.authToken == "" {
+		return fmt.Errorf("not authenticated")
+	}
+	if c.writeToken == "" && !c.profile.NoWriteToken {
+		token, err := c.GetToken(ctx)
+		if err != nil {
+			return fmt.Errorf("get token: %w", err)
+		}
+		c.writeToken = token
+	}
+	if c.writeToken != "" {
+		data.Set("T", c.writeToken)
+	}
+
+	resp, err := c.do(ctx, "POST", c.baseURL+path, data, true)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// do performs a request, waiting for the profile's request interval, and retries it once
+// when the server asks to wait for at most maxRetryWait. data is sent as a form.
+func (c *Client) do(ctx context.Context, method, rawURL string, data url.Values, auth bool) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.throttle(ctx); err != nil {
+			return nil, err
+		}
+
+		var body io.Reader
+		if data != nil {
+			body = strings.NewReader(data.Encode())
+		}
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if auth {
+			req.Header.Set("Authorization", c.authScheme+c.authToken)
+		}
+		if c.profile.AppCredentials && c.account.AppID != "" {
+			req.Header.Set("AppId", c.account.AppID)
+			req.Header.Set("AppKey", c.account.AppKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		wait := maxRetryWait + 1
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if attempt > 0 || wait > maxRetryWait {
+			return nil, fmt.Errorf("%w by %s", ErrRateLimited, c.profile.Title)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// throttle waits until the profile's request interval passed since the last request
+func (c *Client) throttle(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.lastRequest.Add(c.profile.RequestInterval))
+	c.lastRequest = time.Now().Add(max(wait, 0))
+	c.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// LongItemID returns the long form of an item ID, given its short form in a format
+// (IDDecimal or IDHex) or its long form. Item ID lists return the short form and items
+// the long form.
+func LongItemID(id, format string) string {
+	if hex, ok := strings.CutPrefix(id, ItemIDPrefix); ok {
+		id = hex
+	} else if format == IDDecimal {
+		if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+			return fmt.Sprintf("%s%016x", ItemIDPrefix, n)
+		}
+		return id
+	}
+
+	if format == IDDecimal {
+		if n, err := strconv.ParseUint(id, 16, 64); err == nil {
+			return fmt.Sprintf("%s%016x", ItemIDPrefix, n)
+		}
+	}
+	return ItemIDPrefix + strings.ToLower(id)
+}
+
+// userTag normalizes a tag of a user ID, e.g. "user/1005/state/com.google/read", to the
+// "user/-/" form
+func userTag(tag string) string {
+	if rest, ok := strings.CutPrefix(tag, "user/"); ok {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			return "user/-" + rest[i:]
+		}
+	}
+	return tag
+}
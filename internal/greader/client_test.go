@@ -0,0 +1,109 @@
+package greader
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLongItemID(t *testing.T) {
+	tests := []struct {
+		id, format, want string
+	}{
+		{"1", IDDecimal, ItemIDPrefix + "0000000000000001"},
+		{"1705000000000000", IDDecimal, ItemIDPrefix + "00060eb03f579000"},
+		{ItemIDPrefix + "60eb03f579000", IDDecimal, ItemIDPrefix + "00060eb03f579000"},
+		{ItemIDPrefix + "00060eb03f579000", IDDecimal, ItemIDPrefix + "00060eb03f579000"},
+		{"tag:example.com,2024:other/item/x", IDDecimal, "tag:example.com,2024:other/item/x"},
+		{"5a1B2c3d4e5f6a7b8c9d0e1f", IDHex, ItemIDPrefix + "5a1b2c3d4e5f6a7b8c9d0e1f"},
+		{ItemIDPrefix + "5a1b2c3d4e5f6a7b8c9d0e1f", IDHex, ItemIDPrefix + "5a1b2c3d4e5f6a7b8c9d0e1f"},
+		{"123456789012345678901234", IDHex, ItemIDPrefix + "123456789012345678901234"},
+	}
+	for _, tt := range tests {
+		if got := LongItemID(tt.id, tt.format); got != tt.want {
+			t.Errorf("LongItemID(%q, %q) = %q, want %q", tt.id, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestClientRetriesRateLimitedRequests(t *testing.T) {
+	server, _ := newFakeServer(t, Miniflux)
+	client := NewClient(server.account)
+	ctx := t.Context()
+
+	server.limited = 1
+	if err := client.Login(ctx); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	// A second 429 in a row gives up
+	server.limited = 2
+	if _, err := client.GetSubscriptions(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestClientThrottlesRequests(t *testing.T) {
+	server, _ := newFakeServer(t, BazQux)
+	server.profile.RequestInterval = 50 * time.Millisecond
+	client := NewClient(server.account)
+
+	start := time.Now()
+	client.Login(t.Context())
+	client.GetSubscriptions(t.Context())
+	client.GetCategories(t.Context())
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected requests to be spaced by the request interval, took %v", elapsed)
+	}
+}
+
+func TestClientRateLimitTooLong(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		http.Error(w, "Daily limit reached", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := NewClient(Account{Profile: Inoreader, ServerURL: ts.URL, Password: "access-token"})
+	client.Login(t.Context())
+	if _, err := client.GetSubscriptions(t.Context()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited without waiting, got %v", err)
+	}
+}
+
+func TestClientProfileQuirks(t *testing.T) {
+	for _, profile := range Profiles {
+		if profile.Protocol != "" {
+			continue
+		}
+		t.Run(profile.Name, func(t *testing.T) {
+			server, _ := newFakeServer(t, profile)
+			server.addSub("https://example.com/feed", "Example", "Tech")
+
+			// Wrong app credentials or passwords are rejected by the stand-in server
+			wrong := server.account
+			wrong.Password, wrong.AppKey = "wrong", "wrong"
+			if profile.TokenAuth {
+				wrong.Username = "user"
+			}
+			if err := NewClient(wrong).Login(t.Context()); err == nil {
+				t.Error("expected a login error with wrong credentials")
+			}
+
+			client := NewClient(server.account)
+			if err := client.Login(t.Context()); err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			subs, err := client.GetSubscriptions(t.Context())
+			if err != nil || len(subs) != 1 || subs[0].Categories[0].ID != LabelPrefix+"Tech" {
+				t.Errorf("GetSubscriptions() = %+v, %v", subs, err)
+			}
+			categories, err := client.GetCategories(t.Context())
+			if err != nil || len(categories) != 1 || categories[0].Label != "Tech" {
+				t.Errorf("GetCategories() = %+v, %v", categories, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package greader syncs subscriptions, items and their read and starred state with
+// servers speaking the Google Reader API: FreshRSS, Miniflux, Inoreader and compatible
+// servers, The Old Reader and BazQux. A Profile describes the quirks of each provider.
+// Providers with a native REST API, Miniflux and Nextcloud News, sync through it with
+// the restsync package.
+package greader
+
+import (
+	"net/url"
+	"time"
+)
+
+// Formats of the short item IDs in item ID lists
+const (
+	// IDDecimal is the decimal form of a 64-bit ID, whose long form is 16 hex digits
+	IDDecimal = "decimal"
+	// IDHex is the hex ID itself, whose long form only adds the prefix
+	IDHex = "hex"
+)
+
+// Sync protocols besides the Google Reader API
+const (
+	// ProtocolMiniflux is the Miniflux /v1/ REST API
+	ProtocolMiniflux = "miniflux"
+	// ProtocolNextcloud is the Nextcloud News v1-3 API
+	ProtocolNextcloud = "nextcloud"
+)
+
+// Profile describes a sync provider and how its API differs from the common protocol.
+type Profile struct {
+	Name       string // Identifies the provider in settings and the sync tables
+	Title      string // Display name
+	DefaultURL string // Server URL used when none is configured, "" if it is required
+	Protocol   string // Native API to sync with, "" for the Google Reader API
+
+	// APIPath is appended to the server URL, e.g. "/api/greader.php"
+	APIPath string
+	// LoginParams are sent with ClientLogin in addition to the credentials
+	LoginParams url.Values
+	// AppCredentials sends the AppId and AppKey headers with every request
+	AppCredentials bool
+	// TokenAuth accepts an OAuth access token as the password, without a username,
+	// sent as a Bearer token instead of logging in
+	TokenAuth bool
+	// NoWriteToken skips fetching the write token, for servers without one
+	NoWriteToken bool
+
+	// ShortIDs is the format of item IDs in item ID lists, IDDecimal or IDHex
+	ShortIDs string
+	// PageSize bounds the items per page of item contents
+	PageSize int
+	// RequestInterval is the minimum time between requests, to stay under rate limits
+	RequestInterval time.Duration
+}
+
+// Sync providers
+var (
+	FreshRSS = &Profile{
+		Name:     "freshrss",
+		Title:    "FreshRSS",
+		APIPath:  "/api/greader.php",
+		ShortIDs: IDDecimal,
+		PageSize: 250,
+	}
+	Miniflux = &Profile{
+		Name:     "miniflux",
+		Title:    "Miniflux",
+		ShortIDs: IDDecimal,
+		PageSize: 250,
+	}
+	// Inoreader allows 100 items per page and counts requests against a daily limit
+	Inoreader = &Profile{
+		Name:            "inoreader",
+		Title:           "Inoreader",
+		DefaultURL:      "https://www.inoreader.com",
+		AppCredentials:  true,
+		TokenAuth:       true,
+		ShortIDs:        IDDecimal,
+		PageSize:        100,
+		RequestInterval: time.Second,
+	}
+	// The Old Reader identifies items by 24 hex digits and needs no write token
+	TheOldReader = &Profile{
+		Name:            "theoldreader",
+		Title:           "The Old Reader",
+		DefaultURL:      "https://theoldreader.com",
+		LoginParams:     url.Values{"client": {"MrRSS"}, "accountType": {"HOSTED_OR_GOOGLE"}, "service": {"reader"}},
+		NoWriteToken:    true,
+		ShortIDs:        IDHex,
+		PageSize:        100,
+		RequestInterval: 500 * time.Millisecond,
+	}
+	BazQux = &Profile{
+		Name:       "bazqux",
+		Title:      "BazQux Reader",
+		DefaultURL: "https://bazqux.com",
+		ShortIDs:   IDDecimal,
+		PageSize:   250,
+	}
+	// MinifluxAPI authenticates with an API token, or a username and password
+	MinifluxAPI = &Profile{
+		Name:      "miniflux_api",
+		Title:     "Miniflux (REST API)",
+		Protocol:  ProtocolMiniflux,
+		TokenAuth: true,
+	}
+	NextcloudNews = &Profile{
+		Name:     "nextcloud",
+		Title:    "Nextcloud News",
+		Protocol: ProtocolNextcloud,
+	}
+)
+
+// Profiles lists the supported sync providers.
+var Profiles = []*Profile{FreshRSS, Miniflux, Inoreader, TheOldReader, BazQux, MinifluxAPI, NextcloudNews}
+
+// ProfileByName returns the provider with a name, or nil if none.
+func ProfileByName(name string) *Profile {
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
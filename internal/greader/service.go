@@ -0,0 +1,306 @@
+package greader
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/restsync"
+)
+
+const (
+	// checkInterval is how often the service checks whether a scheduled sync is due
+	checkInterval = time.Minute
+	// syncTimeout bounds a sync
+	syncTimeout = 10 * time.Minute
+)
+
+// ErrSyncRunning is returned when a sync is started while another one runs
+var ErrSyncRunning = errors.New("sync already running")
+
+// Status describes the configured sync account and its last sync.
+type Status struct {
+	Provider  string     `json:"provider"` // "" if no account is configured
+	Running   bool       `json:"running"`
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	// LocalFeeds counts the local feeds kept off the account until they are pushed
+	LocalFeeds int `json:"local_feeds"`
+}
+
+// Service syncs the configured account on the schedule of the sync_interval setting,
+// and on demand.
+type Service struct {
+	db       *database.DB
+	onSynced func() // Called after each successful sync, e.g. to refresh the feeds
+
+	wake    chan struct{}
+	running sync.Mutex
+
+	mu          sync.Mutex
+	lastError   string
+	lastAttempt time.Time // Start of the last sync, so failing syncs wait for the schedule too
+}
+
+// NewService creates a sync service. onSynced, if not nil, is called after each
+// successful sync.
+func NewService(db *database.DB, onSynced func()) *Service {
+	return &Service{
+		db:       db,
+		onSynced: onSynced,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// AccountFromSettings returns the configured sync account, or nil if none is. Syncing
+// is opted into with sync_enabled; the FreshRSS integration settings do not enable it.
+func AccountFromSettings(db *database.DB) (*Account, error) {
+	if enabled, _ := db.GetSetting("sync_enabled"); enabled != "true" {
+		return nil, nil
+	}
+	name, _ := db.GetSetting("sync_provider")
+	if name == "" {
+		return nil, nil
+	}
+
+	profile := ProfileByName(name)
+	if profile == nil {
+		return nil, errors.New("unknown sync provider: " + name)
+	}
+	account := &Account{Profile: profile}
+	account.ServerURL, _ = db.GetSetting("sync_server_url")
+	account.Username, _ = db.GetSetting("sync_username")
+	account.AppID, _ = db.GetSetting("sync_app_id")
+	var err error
+	if account.Password, err = db.GetEncryptedSetting("sync_password"); err != nil {
+		return nil, err
+	}
+	if account.AppKey, err = db.GetEncryptedSetting("sync_app_key"); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Validate reports whether an account has the settings its provider needs.
+func (a *Account) Validate() error {
+	switch {
+	case a.Profile == nil:
+		return errors.New("sync provider missing")
+	case a.ServerURL == "" && a.Profile.DefaultURL == "":
+		return errors.New(a.Profile.Title + " server URL missing")
+	case a.Password == "":
+		return errors.New(a.Profile.Title + " password missing")
+	case a.Username == "" && !a.Profile.TokenAuth:
+		return errors.New(a.Profile.Title + " username missing")
+	}
+	return nil
+}
+
+// Syncer syncs an account.
+type Syncer interface {
+	Sync(ctx context.Context) error
+}
+
+// NewSyncer returns the sync service for an account in the protocol of its provider.
+func NewSyncer(account Account, db *database.DB) Syncer {
+	if backend := nativeBackend(account); backend != nil {
+		return restsync.NewSyncService(account.Profile.Name, backend, db)
+	}
+	return NewSyncService(account, db)
+}
+
+// TestConnection logs in to an account and returns its number of subscriptions.
+func TestConnection(ctx context.Context, account Account) (int, error) {
+	if backend := nativeBackend(account); backend != nil {
+		if err := backend.Login(ctx); err != nil {
+			return 0, err
+		}
+		subscriptions, err := backend.Subscriptions(ctx)
+		return len(subscriptions), err
+	}
+
+	client := NewClient(account)
+	if err := client.Login(ctx); err != nil {
+		return 0, err
+	}
+	subscriptions, err := client.GetSubscriptions(ctx)
+	return len(subscriptions), err
+}
+
+// nativeBackend returns the REST API backend of an account, or nil for the Google
+// Reader API.
+func nativeBackend(account Account) restsync.Backend {
+	switch account.Profile.Protocol {
+	case ProtocolMiniflux:
+		return restsync.NewMiniflux(account.ServerURL, account.Username, account.Password)
+	case ProtocolNextcloud:
+		return restsync.NewNextcloud(account.ServerURL, account.Username, account.Password)
+	}
+	return nil
+}
+
+// Trigger syncs the configured account without waiting for the schedule.
+func (s *Service) Trigger() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start syncs an account in the background, or returns ErrSyncRunning.
+func (s *Service) Start(account Account) error {
+	if !s.running.TryLock() {
+		return ErrSyncRunning
+	}
+	go func() {
+		defer s.running.Unlock()
+		s.sync(account)
+	}()
+	return nil
+}
+
+// Pause waits for a running sync to finish and keeps new ones from starting until
+// Resume. It returns false if the sync does not finish within the timeout.
+func (s *Service) Pause(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !s.running.TryLock() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
+// Resume allows syncs again after a successful Pause.
+func (s *Service) Resume() {
+	s.running.Unlock()
+}
+
+// Status returns the configured account and its last sync.
+func (s *Service) Status() (Status, error) {
+	account, err := AccountFromSettings(s.db)
+	if err != nil || account == nil {
+		return Status{}, err
+	}
+
+	status := Status{Provider: account.Profile.Name}
+	lastSync, err := s.db.GetSyncLastSync(account.Profile.Name)
+	if err != nil {
+		return Status{}, err
+	}
+	if !lastSync.IsZero() {
+		status.LastSync = &lastSync
+	}
+	localFeeds, err := s.db.GetSyncLocalFeeds(account.Profile.Name)
+	if err != nil {
+		return Status{}, err
+	}
+	status.LocalFeeds = len(localFeeds)
+	if s.running.TryLock() {
+		s.running.Unlock()
+	} else {
+		status.Running = true
+	}
+	s.mu.Lock()
+	status.LastError = s.lastError
+	s.mu.Unlock()
+	return status, nil
+}
+
+// PushLocalFeeds subscribes the configured account to the local feeds kept off it, on
+// a sync started in the background. It returns the number of feeds to push.
+func (s *Service) PushLocalFeeds(account Account) (int64, error) {
+	count, err := s.db.DeleteSyncLocalFeeds(account.Profile.Name)
+	if err != nil {
+		return 0, err
+	}
+	s.Trigger()
+	return count, nil
+}
+
+// Run syncs the configured account when it is due until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.syncConfigured(true)
+		case <-ticker.C:
+			s.syncConfigured(false)
+		}
+	}
+}
+
+// syncConfigured syncs the configured account if it is due, or always if forced.
+func (s *Service) syncConfigured(force bool) {
+	account, err := AccountFromSettings(s.db)
+	if err != nil {
+		log.Printf("Error loading sync account: %v", err)
+		return
+	}
+	if account == nil || account.Validate() != nil {
+		return
+	}
+
+	if !force {
+		value, _ := s.db.GetSetting("sync_interval")
+		minutes, _ := strconv.Atoi(value)
+		if minutes <= 0 {
+			return
+		}
+		lastSync, err := s.db.GetSyncLastSync(account.Profile.Name)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.lastAttempt.After(lastSync) {
+			lastSync = s.lastAttempt
+		}
+		s.mu.Unlock()
+		if time.Since(lastSync) < time.Duration(minutes)*time.Minute {
+			return
+		}
+	}
+
+	if !s.running.TryLock() {
+		return
+	}
+	defer s.running.Unlock()
+	s.sync(*account)
+}
+
+// sync syncs an account and records the outcome.
+func (s *Service) sync(account Account) {
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	s.lastAttempt = time.Now()
+	s.mu.Unlock()
+
+	err := NewSyncer(account, s.db).Sync(ctx)
+
+	s.mu.Lock()
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("%s sync failed: %v", account.Profile.Title, err)
+		return
+	}
+	if s.onSynced != nil {
+		s.onSynced()
+	}
+}
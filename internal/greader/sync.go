@@ -0,0 +1,448 @@
+package greader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/restsync"
+)
+
+const (
+	// legacyFeedURL is the feed that earlier versions synced all FreshRSS items into
+	legacyFeedURL = "freshrss://synced"
+
+	// maxPages bounds the pages of new items pulled per sync
+	maxPages = 20
+	// idPageSize is the number of item IDs per page of item ID lists
+	idPageSize = 1000
+	// maxIDPages bounds the pages of item ID lists per sync
+	maxIDPages = 50
+	// initialWindow is how far back the first sync pulls items
+	initialWindow = 30 * 24 * time.Hour
+	// crawlOverlap overlaps incremental pulls with the last sync, for items the server
+	// crawled while it ran
+	crawlOverlap = time.Hour
+)
+
+// SyncService handles synchronization between MrRSS and a sync account
+type SyncService struct {
+	client   *Client
+	db       Database
+	provider string
+}
+
+// Database interface for sync operations
+type Database interface {
+	restsync.SubscriptionDatabase
+	DeleteFeed(id int64) error
+	SaveArticles(ctx context.Context, articles []*models.Article) error
+	GetArticles(filter string, feedID int64, category string, showHidden bool, limit, offset int) ([]models.Article, error)
+	UpdateArticleContent(id int64, content string) error
+
+	GetSyncLastSync(provider string) (time.Time, error)
+	SetSyncLastSync(provider string, t time.Time) error
+	GetSyncItems(provider string) ([]database.SyncItem, error)
+	GetSyncedRemoteIDs(provider string) (map[string]bool, error)
+	SaveSyncItems(provider string, items []database.SyncItem) error
+	GetArticleStateByURL(url string) (*database.ArticleState, error)
+	SetArticleSyncState(id int64, read, favorite bool) error
+	MoveArticleToFeed(id, feedID int64) error
+}
+
+// NewSyncService creates a new sync service for an account
+func NewSyncService(account Account, db Database) *SyncService {
+	return &SyncService{
+		client:   NewClient(account),
+		db:       db,
+		provider: account.Profile.Name,
+	}
+}
+
+// Sync performs a bidirectional sync: subscriptions and their categories, new items
+// since the last sync into their local feeds, and the read and starred state of all
+// synced items. The first sync merges the subscriptions without pushing local ones.
+func (s *SyncService) Sync(ctx context.Context) error {
+	title := s.client.Profile().Title
+	if err := s.client.Login(ctx); err != nil {
+		return fmt.Errorf("login to %s: %w", title, err)
+	}
+
+	// Items and state changes after start are left to the next sync
+	start := time.Now().UTC().Truncate(time.Second)
+	last, err := s.db.GetSyncLastSync(s.provider)
+	if err != nil {
+		return fmt.Errorf("get last sync time: %w", err)
+	}
+
+	feeds, err := restsync.SyncSubscriptions(ctx, &subscriber{client: s.client}, s.db, s.provider, last.IsZero())
+	if err != nil {
+		return err
+	}
+	times, next, err := s.pullItems(ctx, feeds, last, start)
+	if err != nil {
+		return err
+	}
+	if err := s.syncStates(ctx, times, last, start); err != nil {
+		return err
+	}
+
+	if err := s.db.SetSyncLastSync(s.provider, next); err != nil {
+		return fmt.Errorf("save last sync time: %w", err)
+	}
+	log.Printf("%s sync completed successfully", title)
+	return nil
+}
+
+// subscriber adapts the subscription API to the subscription sync of restsync. It
+// keeps the categories of the last listed subscriptions, which moves remove.
+type subscriber struct {
+	client     *Client
+	categories map[string]string
+}
+
+func (s *subscriber) Title() string {
+	return s.client.Profile().Title
+}
+
+// Subscriptions lists the subscriptions with their category paths.
+func (s *subscriber) Subscriptions(ctx context.Context) ([]restsync.Subscription, error) {
+	// Get categories from the server to build category hierarchy
+	categories, err := s.client.GetCategories(ctx)
+	if err != nil {
+		log.Printf("Failed to get categories, continuing without category sync: %v", err)
+		categories = []Category{} // Continue without categories
+	}
+	categoryMap := make(map[string]Category)
+	for _, cat := range categories {
+		categoryMap[cat.ID] = cat
+	}
+
+	subscriptions, err := s.client.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.categories = make(map[string]string, len(subscriptions))
+	result := make([]restsync.Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		category := buildCategoryPath(sub.Categories, categoryMap)
+		s.categories[sub.ID] = category
+		result = append(result, restsync.Subscription{ID: sub.ID, URL: sub.URL, Title: sub.Title, Category: category})
+	}
+	return result, nil
+}
+
+func (s *subscriber) Subscribe(ctx context.Context, feedURL, category string) error {
+	return s.client.EditSubscription(ctx, "subscribe", FeedPrefix+feedURL, "", category, "")
+}
+
+func (s *subscriber) Unsubscribe(ctx context.Context, id string) error {
+	return s.client.EditSubscription(ctx, "unsubscribe", id, "", "", "")
+}
+
+func (s *subscriber) Move(ctx context.Context, id, category string) error {
+	return s.client.EditSubscription(ctx, "edit", id, "", category, s.categories[id])
+}
+
+// pullItems pulls the items crawled since the last sync into their local feeds and maps
+// them to articles, oldest first. Items that are already local articles keep their
+// state merged. It returns the latest time the server reports for each pulled item, and
+// the time to record as the last sync: start, or before the first item left for the
+// next sync when the pages run out.
+func (s *SyncService) pullItems(ctx context.Context, feeds map[string]int64, last, start time.Time) (map[string]time.Time, time.Time, error) {
+	synced, err := s.db.GetSyncedRemoteIDs(s.provider)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get synced items: %w", err)
+	}
+	legacyFeedID, err := s.legacyFeedID()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get local feeds: %w", err)
+	}
+
+	opts := StreamOptions{Count: s.client.Profile().PageSize, Since: start.Add(-initialWindow), Until: start, Oldest: true}
+	if !last.IsZero() {
+		opts.Since = last.Add(-crawlOverlap)
+	}
+
+	times := make(map[string]time.Time)
+	var newest time.Time // Crawl time of the newest item pulled
+	pulled := 0
+	continuation := ""
+	for page := 0; page < maxPages; page++ {
+		var items []Item
+		items, continuation, err = s.client.GetStreamContents(ctx, StreamReadingList, opts)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("get items: %w", err)
+		}
+
+		var mapped []database.SyncItem
+		var newItems []Item
+		var newArticles []*models.Article
+		for _, item := range items {
+			times[item.ID] = latest(item.Crawled, item.Updated)
+			newest = latest(newest, item.Crawled)
+			feedID, ok := feeds[item.FeedStreamID]
+			if synced[item.ID] || !ok || item.URL == "" {
+				continue
+			}
+			synced[item.ID] = true
+
+			existing, err := s.db.GetArticleStateByURL(item.URL)
+			if err != nil {
+				return nil, time.Time{}, fmt.Errorf("get article: %w", err)
+			}
+			if existing == nil {
+				newItems = append(newItems, item)
+				newArticles = append(newArticles, &models.Article{
+					FeedID:      feedID,
+					Title:       item.Title,
+					URL:         item.URL,
+					PublishedAt: item.Published,
+					IsRead:      item.Read,
+					IsFavorite:  item.Starred,
+				})
+				continue
+			}
+
+			if legacyFeedID != 0 && existing.FeedID == legacyFeedID {
+				s.db.MoveArticleToFeed(existing.ID, feedID)
+			}
+			// Without a common earlier state, an item read or starred on either side
+			// stays so; the local part is pushed with the state sync
+			read, starred := existing.Read || item.Read, existing.Favorite || item.Starred
+			if read != existing.Read || starred != existing.Favorite {
+				s.db.SetArticleSyncState(existing.ID, read, starred)
+			}
+			mapped = append(mapped, database.SyncItem{RemoteID: item.ID, ArticleID: existing.ID, Read: item.Read, Starred: item.Starred})
+		}
+
+		if len(newArticles) > 0 {
+			if err := s.db.SaveArticles(ctx, newArticles); err != nil {
+				return nil, time.Time{}, fmt.Errorf("save articles: %w", err)
+			}
+			for i, article := range newArticles {
+				// Duplicates within the page are ignored and keep ID 0
+				if article.ID == 0 {
+					continue
+				}
+				if newItems[i].Content != "" {
+					s.db.UpdateArticleContent(article.ID, newItems[i].Content)
+				}
+				mapped = append(mapped, database.SyncItem{RemoteID: newItems[i].ID, ArticleID: article.ID, Read: article.IsRead, Starred: article.IsFavorite})
+			}
+		}
+		if err := s.db.SaveSyncItems(s.provider, mapped); err != nil {
+			return nil, time.Time{}, fmt.Errorf("save synced items: %w", err)
+		}
+		pulled += len(mapped)
+
+		if continuation == "" {
+			break
+		}
+		opts.Continuation = continuation
+	}
+
+	if pulled > 0 {
+		log.Printf("Synced %d new articles from %s", pulled, s.client.Profile().Title)
+	}
+
+	next := start
+	if resume := newest.Add(crawlOverlap - time.Second); continuation != "" && !newest.IsZero() && resume.Before(start) {
+		// Out of pages: the next sync pulls from a second before the newest item pulled,
+		// so the last sync is dated ahead of it by the overlap the next pull starts with
+		next = resume
+		log.Printf("Pulled the items of %s up to %s, leaving the rest to the next sync", s.client.Profile().Title, newest.Format(time.RFC3339))
+	}
+
+	// The legacy feed goes once all of its articles moved to their feeds
+	if legacyFeedID != 0 {
+		if remaining, err := s.db.GetArticles("all", legacyFeedID, "", true, 1, 0); err == nil && len(remaining) == 0 {
+			s.db.DeleteFeed(legacyFeedID)
+		}
+	}
+	return times, next, nil
+}
+
+// syncStates reconciles the read and starred state of the synced items, pushing local
+// changes and pulling remote ones. times holds the times the server reported for the
+// items pulled by this sync.
+func (s *SyncService) syncStates(ctx context.Context, times map[string]time.Time, last, start time.Time) error {
+	unread, unreadComplete, err := s.itemIDs(ctx, StreamReadingList, StateRead, start)
+	if err != nil {
+		return fmt.Errorf("get unread items: %w", err)
+	}
+	starred, starredComplete, err := s.itemIDs(ctx, StateStarred, "", start)
+	if err != nil {
+		return fmt.Errorf("get starred items: %w", err)
+	}
+	items, err := s.db.GetSyncItems(s.provider)
+	if err != nil {
+		return fmt.Errorf("get synced items: %w", err)
+	}
+
+	var markRead, markUnread, star, unstar []string
+	var changed []database.SyncItem
+	for _, it := range items {
+		unreadAt, isUnread := unread[it.RemoteID]
+		remoteRead := !isUnread
+		if !unreadComplete && remoteRead {
+			// Possibly beyond the truncated list
+			remoteRead = it.Read
+		}
+		starredAt, remoteStarred := starred[it.RemoteID]
+		if !starredComplete && !remoteStarred {
+			remoteStarred = it.Starred
+		}
+
+		// The API does not date state changes, so remote changes are dated to the latest
+		// time the server reports for the item, or to the last sync without any
+		remoteAt := latest(times[it.RemoteID], unreadAt, starredAt)
+		if remoteAt.IsZero() {
+			remoteAt = last
+		}
+		read := restsync.Resolve(it.Read, it.LocalRead, remoteRead, it.LocalReadAt, remoteAt)
+		isStarred := restsync.Resolve(it.Starred, it.LocalStarred, remoteStarred, it.LocalStarredAt, remoteAt)
+
+		if read != remoteRead {
+			if read {
+				markRead = append(markRead, it.RemoteID)
+			} else {
+				markUnread = append(markUnread, it.RemoteID)
+			}
+		}
+		if isStarred != remoteStarred {
+			if isStarred {
+				star = append(star, it.RemoteID)
+			} else {
+				unstar = append(unstar, it.RemoteID)
+			}
+		}
+		pull := read != it.LocalRead || isStarred != it.LocalStarred
+		if pull {
+			if err := s.db.SetArticleSyncState(it.ArticleID, read, isStarred); err != nil {
+				return fmt.Errorf("update article: %w", err)
+			}
+		}
+		if pull || read != it.Read || isStarred != it.Starred || !it.LocalReadAt.IsZero() || !it.LocalStarredAt.IsZero() {
+			it.Read, it.Starred = read, isStarred
+			changed = append(changed, it)
+		}
+	}
+
+	// Push before saving, so failed pushes are retried with the next sync
+	edits := []struct {
+		ids         []string
+		add, remove string
+	}{
+		{markRead, StateRead, ""},
+		{markUnread, "", StateRead},
+		{star, StateStarred, ""},
+		{unstar, "", StateStarred},
+	}
+	for _, e := range edits {
+		if err := s.client.EditTag(ctx, e.ids, e.add, e.remove); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.SaveSyncItems(s.provider, changed); err != nil {
+		return fmt.Errorf("save synced items: %w", err)
+	}
+	if n := len(markRead) + len(markUnread) + len(star) + len(unstar); n > 0 {
+		log.Printf("Pushed %d state changes to %s", n, s.client.Profile().Title)
+	}
+	return nil
+}
+
+// itemIDs returns the IDs of the items of a stream crawled before until with their
+// timestamps, and whether the list is complete.
+func (s *SyncService) itemIDs(ctx context.Context, streamID, exclude string, until time.Time) (map[string]time.Time, bool, error) {
+	ids := make(map[string]time.Time)
+	opts := StreamOptions{Count: idPageSize, Until: until, Exclude: exclude}
+	for page := 0; page < maxIDPages; page++ {
+		refs, continuation, err := s.client.GetStreamItemIDs(ctx, streamID, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, ref := range refs {
+			ids[ref.ID] = ref.Crawled
+		}
+		if continuation == "" {
+			return ids, true, nil
+		}
+		opts.Continuation = continuation
+	}
+	return ids, false, nil
+}
+
+// latest returns the latest of times, or the zero time if all are zero.
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, u := range times {
+		if u.After(t) {
+			t = u
+		}
+	}
+	return t
+}
+
+// legacyFeedID returns the ID of the feed earlier versions synced FreshRSS items into,
+// or 0.
+func (s *SyncService) legacyFeedID() (int64, error) {
+	if s.provider != FreshRSS.Name {
+		return 0, nil
+	}
+	feeds, err := s.db.GetFeeds()
+	if err != nil {
+		return 0, err
+	}
+	for _, feed := range feeds {
+		if feed.URL == legacyFeedURL {
+			return feed.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// buildCategoryPath builds a category path from the server's categories
+// Supports nested folder structure by parsing category labels that contain "/"
+func buildCategoryPath(categories []Category, categoryMap map[string]Category) string {
+	if len(categories) == 0 {
+		return ""
+	}
+
+	// Use the first category from the subscription
+	categoryID := categories[0].ID
+
+	// Look up the category in our map to get the full label
+	if cat, exists := categoryMap[categoryID]; exists {
+		label := cat.Label
+		// Servers like FreshRSS support nested categories with "/" separator
+		// The label itself may contain "/" for hierarchy (e.g., "Tech/News")
+		// MrRSS already uses "/" as category separator, so we can use it directly
+		return label
+	}
+
+	// Fallback: try to extract label from category ID
+	if strings.HasPrefix(categoryID, LabelPrefix) {
+		label := strings.TrimPrefix(categoryID, LabelPrefix)
+		// Check if this label exists in our category map (in case of different ID formats)
+		for _, cat := range categoryMap {
+			if cat.Label == label {
+				return label
+			}
+		}
+		return label
+	}
+
+	// Last resort: use the ID as-is if it looks like a label
+	if !strings.HasPrefix(categoryID, "user/-/") {
+		return categoryID
+	}
+
+	return ""
+}
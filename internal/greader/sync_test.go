@@ -0,0 +1,466 @@
+package greader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+// fakeServer is a minimal Google Reader API server holding subscriptions and items,
+// with the quirks of a provider profile.
+type fakeServer struct {
+	profile *Profile
+	account Account
+
+	mu      sync.Mutex
+	nextSub int
+	subs    map[string]*fakeSub
+	items   []*fakeItem
+	limited int // Requests to answer with 429 Too Many Requests
+}
+
+type fakeSub struct {
+	id, url, title, label string
+}
+
+type fakeItem struct {
+	id               int64
+	feed, url, title string
+	read, starred    bool
+	crawled          time.Time // Now if zero
+}
+
+// newFakeServer starts a stand-in server of a provider. Its account uses a copy of the
+// profile without request interval, which only slows tests down.
+func newFakeServer(t *testing.T, profile *Profile) (*fakeServer, *httptest.Server) {
+	unthrottled := *profile
+	unthrottled.RequestInterval = 0
+	profile = &unthrottled
+
+	f := &fakeServer{profile: profile, subs: make(map[string]*fakeSub)}
+	ts := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(ts.Close)
+
+	f.account = Account{Profile: profile, ServerURL: ts.URL, Username: "user", Password: "password", AppID: "app", AppKey: "key"}
+	if profile.TokenAuth {
+		f.account.Username, f.account.Password = "", "access-token"
+	}
+	return f, ts
+}
+
+func (f *fakeServer) addSub(url, title, label string) string {
+	f.nextSub++
+	id := fmt.Sprintf("feed/%d", f.nextSub)
+	f.subs[id] = &fakeSub{id: id, url: url, title: title, label: label}
+	return id
+}
+
+func (f *fakeServer) item(id int64) *fakeItem {
+	for _, it := range f.items {
+		if it.id == id {
+			return it
+		}
+	}
+	return nil
+}
+
+func (f *fakeServer) subByURL(url string) *fakeSub {
+	for _, sub := range f.subs {
+		if sub.url == url {
+			return sub
+		}
+	}
+	return nil
+}
+
+// shortID and longID format item IDs as the provider does.
+func (f *fakeServer) shortID(id int64) string {
+	if f.profile.ShortIDs == IDHex {
+		return fmt.Sprintf("%024x", id)
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func (f *fakeServer) longID(id int64) string {
+	if f.profile.ShortIDs == IDHex {
+		return ItemIDPrefix + f.shortID(id)
+	}
+	return fmt.Sprintf("%s%x", ItemIDPrefix, id)
+}
+
+// parseID accepts the short and long forms of item IDs.
+func (f *fakeServer) parseID(id string) int64 {
+	if hex, ok := strings.CutPrefix(id, ItemIDPrefix); ok {
+		n, _ := strconv.ParseInt(hex, 16, 64)
+		return n
+	}
+	base := 10
+	if f.profile.ShortIDs == IDHex {
+		base = 16
+	}
+	n, _ := strconv.ParseInt(id, base, 64)
+	return n
+}
+
+// label returns a label tag with the user ID in it, as some providers return them.
+func label(name string) string {
+	return "user/1005/label/" + name
+}
+
+func (f *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r.ParseForm()
+	path, ok := strings.CutPrefix(r.URL.Path, f.profile.APIPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if f.limited > 0 {
+		f.limited--
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return
+	}
+	if f.profile.AppCredentials && (r.Header.Get("AppId") != "app" || r.Header.Get("AppKey") != "key") {
+		http.Error(w, "Unknown application", http.StatusForbidden)
+		return
+	}
+
+	if path == "/accounts/ClientLogin" {
+		if r.PostForm.Get("Email") != "user" || r.PostForm.Get("Passwd") != "password" {
+			http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+			return
+		}
+		for key := range f.profile.LoginParams {
+			if r.PostForm.Get(key) != f.profile.LoginParams.Get(key) {
+				http.Error(w, "Error=BadRequest", http.StatusBadRequest)
+				return
+			}
+		}
+		fmt.Fprint(w, "SID=sid\nLSID=lsid\nAuth=auth\n")
+		return
+	}
+
+	want := "GoogleLogin auth=auth"
+	if f.profile.TokenAuth {
+		want = "Bearer access-token"
+	}
+	if r.Header.Get("Authorization") != want {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodPost && !f.profile.NoWriteToken && r.PostForm.Get("T") != "write-token" {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case path == "/reader/api/0/token":
+		fmt.Fprint(w, "write-token\n")
+	case path == "/reader/api/0/tag/list":
+		var tags []map[string]string
+		for _, sub := range f.subs {
+			if sub.label != "" {
+				tags = append(tags, map[string]string{"id": label(sub.label), "type": "folder"})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"tags": tags})
+	case path == "/reader/api/0/subscription/list":
+		subs := []Subscription{}
+		for _, sub := range f.subs {
+			s := Subscription{ID: sub.id, Title: sub.title, URL: sub.url, Categories: []Category{}}
+			if sub.label != "" {
+				s.Categories = append(s.Categories, Category{ID: label(sub.label), Label: sub.label})
+			}
+			subs = append(subs, s)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"subscriptions": subs})
+	case path == "/reader/api/0/subscription/edit":
+		stream := r.PostForm.Get("s")
+		switch r.PostForm.Get("ac") {
+		case "subscribe":
+			f.addSub(strings.TrimPrefix(stream, FeedPrefix), r.PostForm.Get("t"), strings.TrimPrefix(r.PostForm.Get("a"), LabelPrefix))
+		case "unsubscribe":
+			delete(f.subs, stream)
+		case "edit":
+			f.subs[stream].label = strings.TrimPrefix(r.PostForm.Get("a"), LabelPrefix)
+		}
+		fmt.Fprint(w, "OK")
+	case strings.HasPrefix(path, "/reader/api/0/stream/contents/"):
+		// Pages of two items to exercise continuations, oldest first
+		since, _ := strconv.ParseInt(r.Form.Get("ot"), 10, 64)
+		var matching []*fakeItem
+		for _, it := range f.items {
+			if it.crawled.IsZero() || it.crawled.Unix() >= since {
+				matching = append(matching, it)
+			}
+		}
+		offset, _ := strconv.Atoi(r.Form.Get("c"))
+		end := min(offset+2, len(matching))
+		var items []map[string]interface{}
+		for _, it := range matching[offset:end] {
+			crawled := it.crawled
+			if crawled.IsZero() {
+				crawled = time.Now()
+			}
+			categories := []string{StreamReadingList}
+			if it.read {
+				categories = append(categories, "user/1005/state/com.google/read")
+			}
+			if it.starred {
+				categories = append(categories, StateStarred)
+			}
+			items = append(items, map[string]interface{}{
+				"id":            f.longID(it.id),
+				"title":         it.title,
+				"published":     time.Now().Unix(),
+				"crawlTimeMsec": strconv.FormatInt(crawled.UnixMilli(), 10),
+				"alternate":     []map[string]string{{"href": it.url}},
+				"summary":       map[string]string{"content": "<p>" + it.title + "</p>"},
+				"categories":    categories,
+				"origin":        map[string]string{"streamId": it.feed},
+			})
+		}
+		result := map[string]interface{}{"items": items}
+		if end < len(matching) {
+			result["continuation"] = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(result)
+	case path == "/reader/api/0/stream/items/ids":
+		refs := []map[string]string{}
+		for _, it := range f.items {
+			if (r.Form.Get("s") == StateStarred && !it.starred) || (r.Form.Get("xt") == StateRead && it.read) {
+				continue
+			}
+			refs = append(refs, map[string]string{"id": f.shortID(it.id)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"itemRefs": refs})
+	case path == "/reader/api/0/edit-tag":
+		for _, id := range r.PostForm["i"] {
+			it := f.item(f.parseID(id))
+			if it == nil {
+				continue
+			}
+			for _, tag := range []string{r.PostForm.Get("a"), r.PostForm.Get("r")} {
+				value := tag == r.PostForm.Get("a")
+				switch tag {
+				case StateRead:
+					it.read = value
+				case StateStarred:
+					it.starred = value
+				}
+			}
+		}
+		fmt.Fprint(w, "OK")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func setupSyncDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return db
+}
+
+func feedByURL(t *testing.T, db *database.DB, url string) *models.Feed {
+	t.Helper()
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		t.Fatalf("GetFeeds() error = %v", err)
+	}
+	for _, feed := range feeds {
+		if feed.URL == url {
+			return &feed
+		}
+	}
+	return nil
+}
+
+func TestSyncBidirectional(t *testing.T) {
+	for _, profile := range Profiles {
+		if profile.Protocol != "" {
+			continue
+		}
+		t.Run(profile.Name, func(t *testing.T) {
+			testSyncBidirectional(t, profile)
+		})
+	}
+}
+
+func testSyncBidirectional(t *testing.T, profile *Profile) {
+	server, _ := newFakeServer(t, profile)
+	db := setupSyncDB(t)
+	service := NewSyncService(server.account, db)
+	ctx := t.Context()
+
+	remoteSub := server.addSub("https://remote.example/feed", "Remote", "Tech")
+	server.items = []*fakeItem{
+		{id: 1, feed: remoteSub, url: "https://remote.example/1", title: "One"},
+		{id: 2, feed: remoteSub, url: "https://remote.example/2", title: "Two", read: true, starred: true},
+		{id: 3, feed: remoteSub, url: "https://remote.example/3", title: "Three"},
+	}
+
+	// A local feed, and for FreshRSS an article of the legacy synced feed that the remote
+	// item maps to
+	db.AddFeed(&models.Feed{Title: "Local", URL: "https://local.example/feed", Category: "Local"})
+	var legacy *models.Article
+	if profile.Name == FreshRSS.Name {
+		legacyID, _ := db.AddFeed(&models.Feed{Title: "FreshRSS Synced Articles", URL: legacyFeedURL})
+		legacy = &models.Article{FeedID: legacyID, Title: "Three", URL: "https://remote.example/3", IsRead: true, PublishedAt: time.Now()}
+		db.SaveArticles(ctx, []*models.Article{legacy})
+	}
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The first sync only merges, keeping the local feed off the server
+	if sub := server.subByURL("https://local.example/feed"); sub != nil {
+		t.Errorf("expected the first sync not to push the local feed, got %+v", sub)
+	}
+	if kept, _ := db.GetSyncLocalFeeds(profile.Name); len(kept) != 1 {
+		t.Errorf("expected the local feed to be kept local, got %v", kept)
+	}
+	remoteFeed := feedByURL(t, db, "https://remote.example/feed")
+	if remoteFeed == nil || remoteFeed.Category != "Tech" {
+		t.Fatalf("expected the remote subscription as a local feed, got %+v", remoteFeed)
+	}
+	articles, _ := db.GetArticles("all", remoteFeed.ID, "", true, 10, 0)
+	if len(articles) != 3 {
+		t.Fatalf("expected 3 articles in the remote feed, got %d", len(articles))
+	}
+	states := make(map[string]models.Article)
+	for _, a := range articles {
+		states[a.Title] = a
+	}
+	if states["One"].IsRead || !states["Two"].IsRead || !states["Two"].IsFavorite {
+		t.Errorf("unexpected pulled articles %+v", states)
+	}
+	if legacy != nil {
+		if feedByURL(t, db, legacyFeedURL) != nil || states["Three"].ID != legacy.ID {
+			t.Error("expected the legacy article to move and the emptied legacy feed to be deleted")
+		}
+		// The legacy article was read locally, which is pushed
+		if !server.item(3).read {
+			t.Error("expected the local read state of the legacy article to be pushed")
+		}
+	}
+	if texts, _ := db.GetArticleTextsByIDs([]int64{states["One"].ID}); len(texts) != 1 || texts[0].Content != "<p>One</p>" {
+		t.Errorf("expected the item content to be stored, got %+v", texts)
+	}
+	if last, _ := db.GetSyncLastSync(profile.Name); last.IsZero() {
+		t.Error("expected the last sync time to be recorded")
+	}
+
+	// Pushing the kept local feeds subscribes to them on the next sync
+	db.DeleteSyncLocalFeeds(profile.Name)
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if sub := server.subByURL("https://local.example/feed"); sub == nil || sub.label != "Local" {
+		t.Errorf("expected the local feed to be subscribed remotely, got %+v", sub)
+	}
+
+	// Change state and categories on both sides. The remote unstar is newer than the
+	// local changes, so it wins over them even though both are after the last sync.
+	db.SetSyncLastSync(profile.Name, time.Now().Add(-time.Hour))
+	db.MarkArticleRead(states["One"].ID, true)
+	db.SetArticleFavorite(states["Two"].ID, false)
+	db.SetArticleFavorite(states["Two"].ID, true)
+	server.item(2).starred = false
+	db.UpdateFeedCategory(remoteFeed.ID, "News")
+	server.subByURL("https://local.example/feed").label = "Elsewhere"
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !server.item(1).read {
+		t.Error("expected the local read to be pushed")
+	}
+	if a, _ := db.GetArticleByID(states["Two"].ID); a.IsFavorite {
+		t.Error("expected the remote unstar to be pulled")
+	}
+	if sub := server.subByURL("https://remote.example/feed"); sub.label != "News" {
+		t.Errorf("expected the local category move to be pushed, got %q", sub.label)
+	}
+	if feed := feedByURL(t, db, "https://local.example/feed"); feed.Category != "Elsewhere" {
+		t.Errorf("expected the remote category move to be pulled, got %q", feed.Category)
+	}
+
+	// Remove subscriptions on both sides
+	delete(server.subs, server.subByURL("https://local.example/feed").id)
+	db.DeleteFeed(remoteFeed.ID)
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(server.subs) != 0 {
+		t.Errorf("expected the local removal to be pushed, got %+v", server.subs)
+	}
+	// The feed unsubscribed remotely stays as a local feed that is not pushed again
+	feed := feedByURL(t, db, "https://local.example/feed")
+	if feed == nil {
+		t.Fatal("expected the remotely removed feed to stay locally")
+	}
+	if kept, _ := db.GetSyncLocalFeeds(profile.Name); !kept[feed.ID] {
+		t.Errorf("expected the remotely removed feed to be kept local, got %v", kept)
+	}
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(server.subs) != 0 {
+		t.Errorf("expected the unlinked feed not to be pushed again, got %+v", server.subs)
+	}
+}
+
+func TestSyncResumesAfterPageLimit(t *testing.T) {
+	server, _ := newFakeServer(t, FreshRSS)
+	db := setupSyncDB(t)
+	service := NewSyncService(server.account, db)
+	ctx := t.Context()
+
+	// One item more than the pages of a sync hold, crawled two hours apart
+	sub := server.addSub("https://remote.example/feed", "Remote", "")
+	base := time.Now().Add(-100 * time.Hour)
+	for i := range int64(maxPages*2 + 1) {
+		server.items = append(server.items, &fakeItem{id: i + 1, feed: sub, url: fmt.Sprintf("https://remote.example/%d", i+1), title: fmt.Sprint(i + 1), crawled: base.Add(time.Duration(i) * 2 * time.Hour)})
+	}
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	feed := feedByURL(t, db, "https://remote.example/feed")
+	if articles, _ := db.GetArticles("all", feed.ID, "", true, 100, 0); len(articles) != maxPages*2 {
+		t.Fatalf("expected %d articles after the first sync, got %d", maxPages*2, len(articles))
+	}
+	last, _ := db.GetSyncLastSync(FreshRSS.Name)
+	if newest := server.items[maxPages*2-1].crawled; !last.After(newest) || !last.Before(newest.Add(crawlOverlap)) {
+		t.Errorf("expected the last sync to resume after the newest item pulled, got %v", last)
+	}
+
+	// The next sync pulls the rest
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if articles, _ := db.GetArticles("all", feed.ID, "", true, 100, 0); len(articles) != maxPages*2+1 {
+		t.Errorf("expected %d articles after the second sync, got %d", maxPages*2+1, len(articles))
+	}
+}
@@ -1,17 +1,15 @@
 package ai
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"net/url"
-	"strings"
+	"strconv"
 	"time"
 
-	"MrRSS/internal/config"
+	"MrRSS/internal/aiclient"
 	"MrRSS/internal/handlers/core"
 )
 
@@ -25,7 +23,8 @@ type TestResult struct {
 	ErrorMessage      string `json:"error_message,omitempty"`
 }
 
-// HandleTestAIConfig handles POST /api/ai/test to test AI configuration
+// HandleTestAIConfig handles POST /api/ai/test to test AI configuration.
+// With ?profile_id= the given AI profile is tested instead of the global settings.
 func HandleTestAIConfig(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -36,83 +35,54 @@ func HandleTestAIConfig(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		TestTime: time.Now().Format(time.RFC3339),
 	}
 
-	// Get AI settings
-	apiKey, _ := h.DB.GetEncryptedSetting("ai_api_key")
-	endpoint, _ := h.DB.GetSetting("ai_endpoint")
-	model, _ := h.DB.GetSetting("ai_model")
-
-	// Use defaults if not set
-	defaults := config.Get()
-	if endpoint == "" {
-		endpoint = defaults.AIEndpoint
-	}
-	if model == "" {
-		model = defaults.AIModel
-	}
-
-	// Validate configuration
-	result.ConfigValid = true
-	validationErrors := []string{}
-
-	if endpoint == "" {
-		validationErrors = append(validationErrors, "endpoint is required")
-		result.ConfigValid = false
+	cfg, err := testConfig(h, r.URL.Query().Get("profile_id"))
+	if err == nil && cfg.Model == "" {
+		err = errors.New("model is required")
 	}
-
-	if model == "" {
-		validationErrors = append(validationErrors, "model is required")
-		result.ConfigValid = false
-	}
-
-	if !result.ConfigValid {
-		result.ErrorMessage = "Configuration incomplete: " + strings.Join(validationErrors, ", ")
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
-		return
+	var client *aiclient.Client
+	if err == nil {
+		// Report failures directly instead of retrying them
+		cfg.MaxRetries = -1
+		httpClient, proxyErr := aiclient.NewHTTPClient(h.DB, 30*time.Second)
+		if proxyErr != nil {
+			log.Printf("Failed to create HTTP client with proxy: %v", proxyErr)
+			httpClient = &http.Client{Timeout: 30 * time.Second}
+		}
+		client, err = aiclient.New(cfg, httpClient)
 	}
-
-	// Validate endpoint URL format
-	parsedURL, err := url.Parse(endpoint)
 	if err != nil {
-		result.ConfigValid = false
-		result.ErrorMessage = "Invalid endpoint URL: " + err.Error()
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
-		return
-	}
-
-	// Allow HTTP for local endpoints only
-	if parsedURL.Scheme != "https" && !isLocalEndpoint(parsedURL.Host) {
-		result.ConfigValid = false
-		result.ErrorMessage = "API endpoint must use HTTPS for security (HTTP allowed only for localhost)"
+		result.ErrorMessage = "Invalid configuration: " + err.Error()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(result)
 		return
 	}
+	result.ConfigValid = true
 
 	// Test connection with a simple request
 	startTime := time.Now()
+	_, err = client.Complete(r.Context(), aiclient.Request{
+		Messages:  []aiclient.Message{{Role: aiclient.RoleUser, Content: "test"}},
+		MaxTokens: 5,
+	})
+	result.ResponseTimeMs = time.Since(startTime).Milliseconds()
 
-	// Try OpenAI format first
-	connectionSuccess, modelAvailable, err := testOpenAIConnection(endpoint, apiKey, model, h)
-	if err != nil {
-		// If OpenAI format fails, try Ollama format
-		connectionSuccess, modelAvailable, err = testOllamaConnection(endpoint, apiKey, model, h)
-		if err != nil {
-			result.ConnectionSuccess = false
-			result.ModelAvailable = false
-			result.ErrorMessage = fmt.Sprintf("Connection failed: %v", err)
-		} else {
-			result.ConnectionSuccess = connectionSuccess
-			result.ModelAvailable = modelAvailable
-		}
-	} else {
-		result.ConnectionSuccess = connectionSuccess
-		result.ModelAvailable = modelAvailable
+	switch {
+	case err == nil:
+		result.ConnectionSuccess = true
+		result.ModelAvailable = true
+	case errors.Is(err, aiclient.ErrNetwork):
+		result.ErrorMessage = fmt.Sprintf("Connection failed: %v", err)
+	case errors.Is(err, aiclient.ErrUnauthorized):
+		result.ConnectionSuccess = true
+		result.ErrorMessage = "Connection failed: authentication failed - check API key"
+	case isNotFound(err):
+		result.ConnectionSuccess = true
+		result.ErrorMessage = fmt.Sprintf("Connection failed: model '%s' not found", cfg.Model)
+	default:
+		result.ConnectionSuccess = true
+		result.ErrorMessage = fmt.Sprintf("Connection failed: %v", err)
 	}
 
-	result.ResponseTimeMs = time.Since(startTime).Milliseconds()
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
@@ -138,209 +108,27 @@ func HandleGetAITestInfo(h *core.Handler, w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(result)
 }
 
-// testOpenAIConnection tests the AI connection using OpenAI format
-func testOpenAIConnection(endpoint, apiKey, model string, h *core.Handler) (connectionSuccess, modelAvailable bool, err error) {
-	requestBody := map[string]interface{}{
-		"model": model,
-		"messages": []map[string]string{
-			{"role": "user", "content": "test"},
-		},
-		"max_tokens": 5,
+// testConfig returns the configuration of an AI profile, or of the global AI settings if profileID is empty
+func testConfig(h *core.Handler, profileID string) (aiclient.Config, error) {
+	if profileID == "" {
+		return aiclient.ConfigFromSettings(h.DB)
 	}
-
-	jsonBody, err := json.Marshal(requestBody)
+	id, err := strconv.ParseInt(profileID, 10, 64)
 	if err != nil {
-		return false, false, fmt.Errorf("failed to marshal request: %w", err)
+		return aiclient.Config{}, errors.New("invalid profile id")
 	}
-
-	resp, err := sendTestRequest(endpoint, apiKey, jsonBody, h)
+	profile, err := h.DB.GetAIProfile(id)
 	if err != nil {
-		return false, false, err
-	}
-	defer resp.Body.Close()
-
-	// Check for HTTP errors
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return true, false, fmt.Errorf("authentication failed - check API key")
-	}
-
-	if resp.StatusCode == http.StatusNotFound {
-		return true, false, fmt.Errorf("model '%s' not found", model)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return true, false, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error *struct {
-			Message string `json:"message"`
-			Type    string `json:"type"`
-		} `json:"error,omitempty"`
+		return aiclient.Config{}, fmt.Errorf("profile %d not found", id)
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return true, false, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Check for API error in response body
-	if result.Error != nil {
-		if strings.Contains(result.Error.Type, "invalid_request_error") {
-			return true, false, fmt.Errorf("API error: %s", result.Error.Message)
-		}
-		return true, false, fmt.Errorf("API error: %s", result.Error.Message)
-	}
-
-	// Success if we got choices back
-	return true, len(result.Choices) > 0, nil
+	cfg, err := aiclient.ConfigFromProfile(profile)
+	// Keep the test request small regardless of the profile's max tokens
+	cfg.MaxTokens = 0
+	return cfg, err
 }
 
-// testOllamaConnection tests the AI connection using Ollama format
-func testOllamaConnection(endpoint, apiKey, model string, h *core.Handler) (connectionSuccess, modelAvailable bool, err error) {
-	requestBody := map[string]interface{}{
-		"model":  model,
-		"prompt": "test",
-		"stream": false,
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return false, false, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	resp, err := sendTestRequest(endpoint, apiKey, jsonBody, h)
-	if err != nil {
-		return false, false, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return true, false, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var result struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-		Error    string `json:"error,omitempty"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return true, false, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if result.Error != "" {
-		return true, false, fmt.Errorf("Ollama error: %s", result.Error)
-	}
-
-	// Success if response is done
-	return true, result.Done, nil
-}
-
-// sendTestRequest sends the HTTP test request with proper headers and proxy support
-func sendTestRequest(endpoint, apiKey string, jsonBody []byte, h *core.Handler) (*http.Response, error) {
-	// Create HTTP client with proxy support if configured
-	client, err := createHTTPClientWithProxy(h)
-	if err != nil {
-		log.Printf("Failed to create HTTP client with proxy: %v", err)
-		client = &http.Client{Timeout: 30 * time.Second}
-	} else {
-		// Set timeout for test request
-		client.Timeout = 30 * time.Second
-	}
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-	}
-
-	return client.Do(req)
-}
-
-// createHTTPClientWithProxy creates an HTTP client with global proxy settings if enabled
-func createHTTPClientWithProxy(h *core.Handler) (*http.Client, error) {
-	// Check if global proxy is enabled
-	proxyEnabled, _ := h.DB.GetSetting("proxy_enabled")
-	if proxyEnabled != "true" {
-		return &http.Client{}, nil
-	}
-
-	// Build proxy URL from global settings
-	proxyType, _ := h.DB.GetSetting("proxy_type")
-	proxyHost, _ := h.DB.GetSetting("proxy_host")
-	proxyPort, _ := h.DB.GetSetting("proxy_port")
-	proxyUsername, _ := h.DB.GetEncryptedSetting("proxy_username")
-	proxyPassword, _ := h.DB.GetEncryptedSetting("proxy_password")
-
-	// Build proxy URL
-	proxyURL := buildProxyURL(proxyType, proxyHost, proxyPort, proxyUsername, proxyPassword)
-
-	if proxyURL == "" {
-		return &http.Client{}, nil
-	}
-
-	// Parse proxy URL
-	u, err := url.Parse(proxyURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid proxy URL: %w", err)
-	}
-
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyURL(u),
-		},
-	}, nil
-}
-
-// buildProxyURL builds a proxy URL from components
-func buildProxyURL(proxyType, proxyHost, proxyPort, proxyUsername, proxyPassword string) string {
-	if proxyHost == "" || proxyPort == "" {
-		return ""
-	}
-
-	var urlBuilder strings.Builder
-	urlBuilder.WriteString(strings.ToLower(proxyType))
-	urlBuilder.WriteString("://")
-
-	if proxyUsername != "" && proxyPassword != "" {
-		urlBuilder.WriteString(url.QueryEscape(proxyUsername))
-		urlBuilder.WriteString(":")
-		urlBuilder.WriteString(url.QueryEscape(proxyPassword))
-		urlBuilder.WriteString("@")
-	}
-
-	urlBuilder.WriteString(proxyHost)
-	urlBuilder.WriteString(":")
-	urlBuilder.WriteString(proxyPort)
-
-	return urlBuilder.String()
-}
-
-// isLocalEndpoint checks if a host is a local endpoint
-func isLocalEndpoint(host string) bool {
-	// Remove port if present
-	if idx := strings.LastIndex(host, ":"); idx != -1 {
-		if !strings.Contains(host[idx:], "]") {
-			host = host[:idx]
-		}
-	}
-	// Remove brackets from IPv6 addresses
-	host = strings.Trim(host, "[]")
-
-	return host == "localhost" ||
-		host == "127.0.0.1" ||
-		host == "::1" ||
-		strings.HasPrefix(host, "127.") ||
-		host == "0.0.0.0"
+// isNotFound reports whether the endpoint answered with 404, which usually means an unknown model
+func isNotFound(err error) bool {
+	var aiErr *aiclient.Error
+	return errors.As(err, &aiErr) && aiErr.StatusCode == http.StatusNotFound
 }
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/embedding"
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleEmbeddingStatus handles GET /api/ai/embeddings to return the indexing progress.
+func HandleEmbeddingStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status, err := h.Embeddings.Status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleEmbeddingBackfill handles POST /api/ai/embeddings/backfill to index unindexed
+// articles now instead of at the next background check.
+func HandleEmbeddingBackfill(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.Embeddings.Enabled() {
+		http.Error(w, "Embeddings are disabled", http.StatusForbidden)
+		return
+	}
+
+	h.Embeddings.Trigger()
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleEmbeddingRebuild handles POST /api/ai/embeddings/rebuild to delete all
+// embeddings and index the articles again, e.g. after changing the model.
+func HandleEmbeddingRebuild(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.Embeddings.Rebuild(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleRelatedArticles handles GET /api/articles/related?id=&limit= to return the
+// articles most similar to an article, best first.
+func HandleRelatedArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	results, err := h.Embeddings.Related(r.Context(), id, limit)
+	writeSimilarityResults(w, results, err)
+}
+
+// HandleSemanticSearch handles GET /api/articles/semantic-search?q=&limit= to return
+// the articles closest in meaning to a query, best first.
+func HandleSemanticSearch(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	results, err := h.Embeddings.Search(r.Context(), query, limit)
+	writeSimilarityResults(w, results, err)
+}
+
+// writeSimilarityResults writes the results of a similarity query or its error.
+func writeSimilarityResults(w http.ResponseWriter, results []embedding.Result, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, embedding.ErrDisabled):
+		http.Error(w, "Embeddings are disabled", http.StatusForbidden)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Article not found", http.StatusNotFound)
+	case errors.Is(err, embedding.ErrLimitReached):
+		log.Printf("AI usage limit reached for embeddings")
+		json.NewEncoder(w).Encode(map[string]string{"error": "AI usage limit reached"})
+	case err != nil:
+		log.Printf("Similarity query failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		json.NewEncoder(w).Encode(results)
+	}
+}
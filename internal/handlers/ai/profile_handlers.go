@@ -0,0 +1,261 @@
+package ai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// maskedHeaderValue replaces the values of custom headers in responses, since they
+// usually hold credentials.
+const maskedHeaderValue = "********"
+
+// ProfileResponse is an AI profile as returned by the API. The API key is never
+// returned; HasAPIKey reports whether one is stored. Custom headers are returned with
+// masked values.
+type ProfileResponse struct {
+	models.AIProfile
+	HasAPIKey bool `json:"has_api_key"`
+}
+
+// ProfileRequest is the body of add and update requests. On update, an empty
+// API key keeps the stored key unless ClearAPIKey is set, and empty custom headers
+// keep the stored headers unless ClearCustomHeaders is set. Masked header values keep
+// the stored value of the header.
+type ProfileRequest struct {
+	models.AIProfile
+	ClearAPIKey        bool `json:"clear_api_key,omitempty"`
+	ClearCustomHeaders bool `json:"clear_custom_headers,omitempty"`
+}
+
+// HandleAIProfiles handles GET /api/ai/profiles to list AI profiles.
+func HandleAIProfiles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	profiles, err := h.DB.GetAIProfiles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]ProfileResponse, 0, len(profiles))
+	for _, p := range profiles {
+		response = append(response, newProfileResponse(p))
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleAddAIProfile handles POST /api/ai/profiles/add to create an AI profile.
+func HandleAddAIProfile(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	profile := req.AIProfile
+	if msg := validateProfile(&profile); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.DB.AddAIProfile(&profile)
+	if err != nil {
+		log.Printf("Error adding AI profile: %v", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	saved, err := h.DB.GetAIProfile(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(newProfileResponse(*saved))
+}
+
+// HandleUpdateAIProfile handles POST /api/ai/profiles/update to update an AI profile.
+func HandleUpdateAIProfile(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	profile := req.AIProfile
+	if profile.ID <= 0 {
+		http.Error(w, "Missing profile id", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.DB.GetAIProfile(profile.ID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if profile.APIKey == "" && !req.ClearAPIKey {
+		profile.APIKey = existing.APIKey
+	}
+	if !req.ClearCustomHeaders {
+		profile.CustomHeaders = unmaskHeaders(profile.CustomHeaders, existing.CustomHeaders)
+	}
+	if msg := validateProfile(&profile); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.UpdateAIProfile(&profile); err != nil {
+		log.Printf("Error updating AI profile %d: %v", profile.ID, err)
+		writeProfileError(w, err)
+		return
+	}
+
+	saved, err := h.DB.GetAIProfile(profile.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(newProfileResponse(*saved))
+}
+
+// HandleDeleteAIProfile handles POST/DELETE /api/ai/profiles/delete?id= to delete an AI profile.
+// Features that used the profile fall back to the global AI settings.
+func HandleDeleteAIProfile(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid profile id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteAIProfile(id); err != nil {
+		log.Printf("Error deleting AI profile %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// newProfileResponse masks the API key and custom header values of a profile.
+func newProfileResponse(p models.AIProfile) ProfileResponse {
+	hasKey := p.APIKey != ""
+	p.APIKey = ""
+	p.CustomHeaders = maskHeaders(p.CustomHeaders)
+	return ProfileResponse{AIProfile: p, HasAPIKey: hasKey}
+}
+
+// maskHeaders replaces the values of custom headers, keeping their names.
+func maskHeaders(headersJSON string) string {
+	headers, err := aiclient.ParseCustomHeaders(headersJSON)
+	if err != nil || len(headers) == 0 {
+		return ""
+	}
+	for name := range headers {
+		headers[name] = maskedHeaderValue
+	}
+	data, _ := json.Marshal(headers)
+	return string(data)
+}
+
+// unmaskHeaders restores the stored values of an update's custom headers: empty headers
+// keep the stored headers, and masked values keep the stored value of their header.
+// Headers that cannot be parsed are returned unchanged for validation to reject.
+func unmaskHeaders(headersJSON, storedJSON string) string {
+	if strings.TrimSpace(headersJSON) == "" {
+		return storedJSON
+	}
+	headers, err := aiclient.ParseCustomHeaders(headersJSON)
+	if err != nil {
+		return headersJSON
+	}
+	stored, _ := aiclient.ParseCustomHeaders(storedJSON)
+	for name, value := range headers {
+		if value != maskedHeaderValue {
+			continue
+		}
+		if storedValue, ok := stored[name]; ok {
+			headers[name] = storedValue
+		} else {
+			delete(headers, name)
+		}
+	}
+	if len(headers) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(headers)
+	return string(data)
+}
+
+// validateProfile normalizes a profile and returns an error message if it is invalid.
+func validateProfile(p *models.AIProfile) string {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Backend = strings.TrimSpace(p.Backend)
+	p.Endpoint = strings.TrimSpace(p.Endpoint)
+	p.Model = strings.TrimSpace(p.Model)
+	p.APIKey = strings.TrimSpace(p.APIKey)
+	p.CustomHeaders = strings.TrimSpace(p.CustomHeaders)
+
+	switch {
+	case p.Name == "":
+		return "Missing profile name"
+	case p.Model == "":
+		return "Missing model"
+	case p.Temperature < 0 || p.Temperature > 2:
+		return "Temperature must be between 0 and 2"
+	case p.MaxTokens < 0:
+		return "Max tokens must not be negative"
+	case p.ContextWindow < 0:
+		return "Context window must not be negative"
+	}
+
+	// Check the backend, endpoint and headers the same way requests would
+	cfg, err := aiclient.ConfigFromProfile(p)
+	if err == nil {
+		_, err = aiclient.New(cfg, nil)
+	}
+	if err != nil {
+		return "Invalid configuration: " + err.Error()
+	}
+	return ""
+}
+
+// writeProfileError reports a database error, mapping duplicate names to 409 Conflict.
+func writeProfileError(w http.ResponseWriter, err error) {
+	if err == sql.ErrNoRows {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		http.Error(w, "A profile with this name already exists", http.StatusConflict)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/database"
+	corepkg "MrRSS/internal/handlers/core"
+)
+
+func setupHandler(t *testing.T) *corepkg.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	return corepkg.NewHandler(db, nil, nil)
+}
+
+func TestProfileCustomHeadersAreMasked(t *testing.T) {
+	h := setupHandler(t)
+	call := func(handler func(*corepkg.Handler, http.ResponseWriter, *http.Request), body string) ProfileResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai/profiles", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp ProfileResponse
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return resp
+	}
+
+	added := call(HandleAddAIProfile, `{"name":"P","endpoint":"https://api.example.com/v1/chat/completions","model":"m","custom_headers":"{\"Authorization\":\"Bearer secret\",\"X-Org\":\"team\"}"}`)
+	if strings.Contains(added.CustomHeaders, "secret") || added.CustomHeaders != `{"Authorization":"********","X-Org":"********"}` {
+		t.Fatalf("custom headers not masked: %q", added.CustomHeaders)
+	}
+
+	// Masked and empty headers keep the stored values
+	call(HandleUpdateAIProfile, `{"id":1,"name":"P","endpoint":"https://api.example.com/v1/chat/completions","model":"m","custom_headers":"{\"Authorization\":\"********\",\"X-Org\":\"other\"}"}`)
+	call(HandleUpdateAIProfile, `{"id":1,"name":"P","endpoint":"https://api.example.com/v1/chat/completions","model":"m2"}`)
+	stored, err := h.DB.GetAIProfile(added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CustomHeaders != `{"Authorization":"Bearer secret","X-Org":"other"}` || stored.Model != "m2" {
+		t.Errorf("stored profile = %+v", stored)
+	}
+
+	call(HandleUpdateAIProfile, `{"id":1,"name":"P","endpoint":"https://api.example.com/v1/chat/completions","model":"m","clear_custom_headers":true}`)
+	if stored, _ := h.DB.GetAIProfile(added.ID); stored.CustomHeaders != "" {
+		t.Errorf("custom headers not cleared: %q", stored.CustomHeaders)
+	}
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/handlers/core"
+)
+
+// maxStatsDays bounds the range of a usage statistics request
+const maxStatsDays = 400
+
+// HandleAIUsageStats handles GET /api/ai-usage/stats to return AI usage as a time series
+// with breakdowns by feature, model and profile.
+//
+// Query parameters: interval ("day" or "month", default "day"), and from/to as local
+// dates (YYYY-MM-DD, to inclusive). The default range is the last 30 days, or the last
+// 12 months for the monthly interval.
+func HandleAIUsageStats(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = aiusage.IntervalDay
+	}
+	if interval != aiusage.IntervalDay && interval != aiusage.IntervalMonth {
+		http.Error(w, "Invalid interval", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := statsRange(query.Get("from"), query.Get("to"), interval, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.DB.GetAIUsageRecords(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stats := aiusage.BuildStats(records, from, to, interval)
+
+	// Label profiles with their names
+	names := map[string]string{"0": "Global settings"}
+	if profiles, err := h.DB.GetAIProfiles(); err == nil {
+		for _, p := range profiles {
+			names[strconv.FormatInt(p.ID, 10)] = p.Name
+		}
+	}
+	for i := range stats.ByProfile {
+		if name, ok := names[stats.ByProfile[i].Key]; ok {
+			stats.ByProfile[i].Label = name
+		} else {
+			stats.ByProfile[i].Label = "Deleted profile"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// statsRange parses the from and to dates of a statistics request into [from, to).
+func statsRange(fromParam, toParam, interval string, now time.Time) (time.Time, time.Time, error) {
+	to := aiusage.StartOfDay(now).AddDate(0, 0, 1)
+	if toParam != "" {
+		day, err := time.ParseInLocation("2006-01-02", toParam, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+
+	var from time.Time
+	if fromParam != "" {
+		day, err := time.ParseInLocation("2006-01-02", fromParam, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		from = day
+	} else if interval == aiusage.IntervalMonth {
+		from = aiusage.StartOfMonth(to.AddDate(0, 0, -1)).AddDate(0, -11, 0)
+	} else {
+		from = to.AddDate(0, 0, -30)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if to.Sub(from) > maxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", maxStatsDays)
+	}
+	return from, to, nil
+}
@@ -6,16 +6,23 @@ import (
 	"net/http"
 	"strconv"
 
+	"MrRSS/internal/database"
 	"MrRSS/internal/handlers/core"
 )
 
-// HandleArticles returns articles with filtering and pagination.
+// HandleArticles returns articles with filtering and pagination, newest first or, with
+// sort=score, most important first. filter=priority is the priority inbox.
 func HandleArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	filter := r.URL.Query().Get("filter")
 	feedIDStr := r.URL.Query().Get("feed_id")
 	category := r.URL.Query().Get("category")
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
+	sort := r.URL.Query().Get("sort")
+	if sort != database.SortNewest && sort != database.SortScore {
+		http.Error(w, "Invalid sort", http.StatusBadRequest)
+		return
+	}
 
 	var feedID int64
 	if feedIDStr != "" {
@@ -38,7 +45,7 @@ func HandleArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	showHiddenStr, _ := h.DB.GetSetting("show_hidden_articles")
 	showHidden := showHiddenStr == "true"
 
-	articles, err := h.DB.GetArticles(filter, feedID, category, showHidden, limit, offset)
+	articles, err := h.DB.GetArticlesSorted(filter, feedID, category, showHidden, sort, limit, offset)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -46,7 +53,46 @@ func HandleArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(articles)
 }
 
-// HandleMarkRead marks an article as read or unread.
+// HandleDuplicateArticles returns the other copies of an article found in other feeds
+// (or the same feed under another URL), oldest first.
+func HandleDuplicateArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	articles, err := h.DB.GetDuplicateArticles(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(articles)
+}
+
+// HandleLabels returns the labels assigned to visible articles with their article
+// counts, most used first, e.g. for building label filters.
+func HandleLabels(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	counts, err := h.DB.GetLabelCounts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(counts)
+}
+
+// HandleMarkRead marks an article as read or unread. Marking an article as read counts
+// as skipping it for the ranking model until reading feedback arrives for it.
 func HandleMarkRead(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
@@ -61,6 +107,9 @@ func HandleMarkRead(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.Ranking.RecordMarkRead(id, read); err != nil {
+		log.Printf("Error recording ranking feedback: %v", err)
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -72,6 +121,9 @@ func HandleToggleFavorite(h *core.Handler, w http.ResponseWriter, r *http.Reques
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.Ranking.RecordState(id); err != nil {
+		log.Printf("Error recording ranking feedback: %v", err)
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -118,6 +170,9 @@ func HandleToggleReadLater(h *core.Handler, w http.ResponseWriter, r *http.Reque
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.Ranking.RecordState(id); err != nil {
+		log.Printf("Error recording ranking feedback: %v", err)
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
@@ -3,17 +3,23 @@ package article
 import (
 	"encoding/json"
 	"fmt"
-	"html"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
+	"MrRSS/internal/database"
 	"MrRSS/internal/handlers/core"
-	"MrRSS/internal/models"
+	"MrRSS/internal/mdexport"
+	"MrRSS/internal/utils"
+)
 
-	md "github.com/JohannesKaufmann/html-to-markdown"
+const (
+	// defaultExportLimit is the number of articles a bulk export exports by default
+	defaultExportLimit = 500
+	// maxExportLimit bounds the number of articles of a bulk export
+	maxExportLimit = 5000
 )
 
 // ExportToObsidianRequest represents the request for exporting to Obsidian
@@ -21,6 +27,22 @@ type ExportToObsidianRequest struct {
 	ArticleID int `json:"article_id"`
 }
 
+// ExportMarkdownRequest selects the articles of a bulk Markdown export and overrides
+// the export settings.
+type ExportMarkdownRequest struct {
+	Filter         string `json:"filter"`          // "unread", "favorites" or "readLater"; empty exports all articles
+	FeedID         int64  `json:"feed_id"`         // 0 exports all feeds
+	Category       string `json:"category"`        // Feed category, including subcategories
+	Label          string `json:"label"`           // Label the articles must have
+	Since          string `json:"since"`           // Date or RFC 3339 time articles are published at or after
+	Until          string `json:"until"`           // Date (inclusive) or RFC 3339 time articles are published before
+	Limit          int    `json:"limit"`           // Newest articles to export
+	Layout         string `json:"layout"`          // "obsidian" or "logseq"
+	Template       string `json:"template"`        // Note template
+	Filename       string `json:"filename"`        // Filename template
+	DownloadImages *bool  `json:"download_images"` // Download images next to the notes
+}
+
 // HandleExportToObsidian exports an article to Obsidian using direct file system access
 func HandleExportToObsidian(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -40,8 +62,8 @@ func HandleExportToObsidian(h *core.Handler, w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get article from database
-	article, err := h.DB.GetArticleByID(int64(req.ArticleID))
-	if err != nil {
+	articles, err := h.DB.GetArticleTextsByIDs([]int64{int64(req.ArticleID)})
+	if err != nil || len(articles) == 0 {
 		http.Error(w, fmt.Sprintf("Article not found: %v", err), http.StatusNotFound)
 		return
 	}
@@ -69,175 +91,153 @@ func HandleExportToObsidian(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Get article content
-	content, err := h.GetArticleContent(int64(req.ArticleID))
+	exporter, err := newMarkdownExporter(h, mdexport.Options{Dir: vaultPath}, nil, h.GetArticleContent)
 	if err != nil {
-		// If content fetch fails, continue with empty content
-		content = ""
-	}
-
-	// Generate Markdown content
-	markdownContent := generateObsidianMarkdown(*article, content)
-
-	// Generate filename (sanitize title)
-	filename := sanitizeFilename(article.Title)
-	if filename == "" {
-		filename = fmt.Sprintf("Article_%d", article.ID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	filename += ".md"
 
-	// Create full file path
-	filePath := filepath.Join(vaultPath, filename)
-
-	// Write file to Obsidian vault
-	if err := os.WriteFile(filePath, []byte(markdownContent), 0644); err != nil {
+	// Write the note, or update the note of an earlier export
+	result, err := exporter.Export(r.Context(), articles)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to write file to Obsidian vault: %v", err), http.StatusInternalServerError)
 		return
 	}
+	record, err := h.DB.GetMarkdownExport(exporter.Dir(), articles[0].ID)
+	if err != nil || record == nil {
+		http.Error(w, fmt.Sprintf("Failed to record export: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	message := "Article exported to Obsidian successfully"
+	if result.Skipped > 0 {
+		message = "The note was edited in Obsidian and was left unchanged"
+	}
 
 	// Return success response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"success":   "true",
-		"file_path": filePath,
-		"message":   "Article exported to Obsidian successfully",
+		"file_path": filepath.Join(exporter.Dir(), filepath.FromSlash(record.Path)),
+		"message":   message,
 	})
 }
 
-// generateObsidianMarkdown converts an article to Markdown format for Obsidian
-func generateObsidianMarkdown(article models.Article, content string) string {
-	var sb strings.Builder
-
-	// Front matter - exclude URL to avoid URI parsing issues
-	sb.WriteString("---\n")
-	sb.WriteString(fmt.Sprintf("title: \"%s\"\n", escapeYamlString(article.Title)))
-	sb.WriteString(fmt.Sprintf("feed: \"%s\"\n", escapeYamlString(article.FeedTitle)))
-	sb.WriteString(fmt.Sprintf("published: \"%s\"\n", article.PublishedAt.Format(time.RFC3339)))
-	sb.WriteString(fmt.Sprintf("tags: [rss, %s]\n", sanitizeTag(article.FeedTitle)))
-	sb.WriteString("---\n\n")
-
-	// Title
-	sb.WriteString(fmt.Sprintf("# %s\n\n", article.Title))
-
-	// Source URL (HTML encoded to avoid URI parsing issues)
-	sb.WriteString(fmt.Sprintf("**Source:** %s\n\n", htmlEncodeURL(article.URL)))
-
-	// Content
-	if content != "" {
-		// Decode HTML entities first, then convert HTML to Markdown
-		decodedContent := html.UnescapeString(content)
-		markdownContent := htmlToMarkdown(decodedContent)
-		sb.WriteString(markdownContent)
-		sb.WriteString("\n\n")
+// HandleExportMarkdown exports the articles selected by the request as Markdown notes
+// to the Obsidian vault. Articles exported to the vault before update their notes in
+// place, unless the user edited them. Articles without stored content are exported
+// with their summary, since fetching thousands of articles would hold the request.
+func HandleExportMarkdown(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Add metadata at the end
-	sb.WriteString("---\n")
-	sb.WriteString(fmt.Sprintf("**Added to Obsidian:** %s\n", time.Now().Format("2006-01-02 15:04:05")))
-	sb.WriteString(fmt.Sprintf("**Article ID:** %d\n", article.ID))
-
-	return sb.String()
-}
-
-// sanitizeFilename creates a safe filename from a title
-func sanitizeFilename(title string) string {
-	// Replace invalid filename characters
-	invalidChars := []string{"<", ">", ":", "\"", "|", "?", "*", "\\", "/"}
-	result := title
-
-	for _, char := range invalidChars {
-		result = strings.ReplaceAll(result, char, "_")
+	var req ExportMarkdownRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	// Trim spaces and limit length
-	result = strings.TrimSpace(result)
-	if len(result) > 100 {
-		result = result[:100]
+	search := database.ArticleSearch{Label: req.Label, Limit: req.Limit}
+	switch req.Filter {
+	case "", "all":
+	case "unread", "favorites", "readLater":
+		search.Filter = req.Filter
+	default:
+		http.Error(w, "filter must be unread, favorites or readLater", http.StatusBadRequest)
+		return
 	}
+	if req.FeedID > 0 {
+		search.FeedIDs = []int64{req.FeedID}
+	}
+	if req.Category != "" {
+		search.Categories = []string{req.Category}
+	}
+	var err error
+	if search.Since, err = parseExportTime(req.Since, false); err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	if search.Until, err = parseExportTime(req.Until, true); err != nil {
+		http.Error(w, "invalid until", http.StatusBadRequest)
+		return
+	}
+	if search.Limit <= 0 {
+		search.Limit = defaultExportLimit
+	}
+	search.Limit = min(search.Limit, maxExportLimit)
 
-	return result
-}
-
-// sanitizeTag creates a safe tag from feed name
-func sanitizeTag(feedName string) string {
-	// Convert to lowercase, replace spaces with underscores
-	tag := strings.ToLower(strings.ReplaceAll(feedName, " ", "_"))
-	// Remove special characters
-	tag = strings.ReplaceAll(tag, "-", "_")
-	tag = strings.ReplaceAll(tag, ".", "_")
-	return tag
-}
-
-// htmlEncodeURL encodes URL characters that could interfere with URI parsing
-func htmlEncodeURL(url string) string {
-	// Replace characters that could be mistaken for URI parameters
-	result := strings.ReplaceAll(url, "&", "&amp;")
-	result = strings.ReplaceAll(result, "?", "&#63;")
-	result = strings.ReplaceAll(result, "=", "&#61;")
-	result = strings.ReplaceAll(result, "%", "&#37;")
-	return result
-}
-
-// escapeYamlString escapes special characters for YAML
-func escapeYamlString(s string) string {
-	// Basic escaping for quotes
-	return strings.ReplaceAll(s, "\"", "\\\"")
-}
-
-// htmlToMarkdown converts HTML to Markdown using html-to-markdown library
-func htmlToMarkdown(html string) string {
-	// Create a new converter with default plugins
-	converter := md.NewConverter("", true, nil)
+	// Notes only go to the configured vault, never to a folder of the request
+	var dir string
+	if enabled, _ := h.DB.GetSetting("obsidian_enabled"); enabled == "true" {
+		dir, _ = h.DB.GetSetting("obsidian_vault_path")
+	}
+	opts := mdexport.Options{
+		Dir:      dir,
+		Layout:   mdexport.Layout(req.Layout),
+		Template: req.Template,
+		Filename: req.Filename,
+	}
+	exporter, err := newMarkdownExporter(h, opts, req.DownloadImages, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Convert HTML to Markdown
-	markdown, err := converter.ConvertString(html)
+	articles, err := h.DB.SearchArticleTexts(search)
 	if err != nil {
-		// If conversion fails, return the original HTML with basic cleanup
-		return cleanWhitespace(removeHTMLTags(html))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := exporter.Export(r.Context(), articles)
+	if err != nil {
+		log.Printf("Error exporting articles to %s: %v", exporter.Dir(), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Clean up excessive whitespace
-	return cleanWhitespace(markdown)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Dir string `json:"dir"`
+		*mdexport.Result
+	}{exporter.Dir(), result})
 }
 
-// removeHTMLTags removes HTML tags (basic implementation)
-func removeHTMLTags(html string) string {
-	var result strings.Builder
-	inTag := false
-
-	for _, char := range html {
-		if char == '<' {
-			inTag = true
-		} else if char == '>' {
-			inTag = false
-		} else if !inTag {
-			result.WriteRune(char)
-		}
+// newMarkdownExporter creates an exporter with the export settings, for the options
+// the request left empty. downloadImages overrides the image setting if not nil, and
+// content, if not nil, gets the content of articles that have no stored content.
+func newMarkdownExporter(h *core.Handler, opts mdexport.Options, downloadImages *bool, content utils.ContentFunc) (*mdexport.Exporter, error) {
+	if opts.Layout == "" {
+		layout, _ := h.DB.GetSetting("markdown_export_layout")
+		opts.Layout = mdexport.Layout(layout)
 	}
-
-	return result.String()
+	if opts.Template == "" {
+		opts.Template, _ = h.DB.GetSetting("markdown_export_template")
+	}
+	if opts.Filename == "" {
+		opts.Filename, _ = h.DB.GetSetting("markdown_export_filename")
+	}
+	if downloadImages != nil {
+		opts.DownloadImages = *downloadImages
+	} else {
+		images, _ := h.DB.GetSetting("markdown_export_images")
+		opts.DownloadImages = images == "true"
+	}
+	return mdexport.New(h.DB, opts, content)
 }
 
-// cleanWhitespace removes excessive whitespace and empty lines
-func cleanWhitespace(text string) string {
-	lines := strings.Split(text, "\n")
-	var cleaned []string
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Only keep non-empty lines or single empty lines between content
-		if trimmed != "" || (len(cleaned) > 0 && cleaned[len(cleaned)-1] != "") {
-			cleaned = append(cleaned, trimmed)
-		}
+// parseExportTime parses a date or RFC 3339 time of an export request. A date as an
+// upper bound includes the whole day.
+func parseExportTime(value string, until bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
-
-	// Join with single newlines
-	result := strings.Join(cleaned, "\n")
-
-	// Remove multiple consecutive empty lines
-	for strings.Contains(result, "\n\n\n") {
-		result = strings.ReplaceAll(result, "\n\n\n", "\n\n")
+	if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if until {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
 	}
-
-	return result
+	return time.Parse(time.RFC3339, value)
 }
@@ -13,10 +13,10 @@ type FilterCondition struct {
 	ID       int64    `json:"id"`
 	Logic    string   `json:"logic"`    // "and", "or" (null for first condition)
 	Negate   bool     `json:"negate"`   // NOT modifier for this condition
-	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", "published_after", "published_before"
+	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", "published_after", "published_before", "label"
 	Operator string   `json:"operator"` // "contains", "exact" (null for date fields and multi-select)
 	Value    string   `json:"value"`    // Single value for text/date fields
-	Values   []string `json:"values"`   // Multiple values for feed_name and feed_category
+	Values   []string `json:"values"`   // Multiple values for feed_name, feed_category and label
 }
 
 // FilterRequest represents the request body for filtered articles
@@ -24,6 +24,7 @@ type FilterRequest struct {
 	Conditions []FilterCondition `json:"conditions"`
 	Page       int               `json:"page"`
 	Limit      int               `json:"limit"`
+	Sort       string            `json:"sort"` // "" for newest first or "score"
 }
 
 // FilterResponse represents the response for filtered articles with pagination info
@@ -74,6 +75,25 @@ func matchMultiSelectContains(fieldValue string, values []string, singleValue st
 	return true
 }
 
+// matchLabelValues checks if any label equals (ignoring case) one of the selected values.
+// With no values selected, it matches articles that have any label.
+func matchLabelValues(labels []string, values []string, singleValue string) bool {
+	if len(values) == 0 && singleValue != "" {
+		values = []string{singleValue}
+	}
+	if len(values) == 0 {
+		return len(labels) > 0
+	}
+	for _, label := range labels {
+		for _, val := range values {
+			if strings.EqualFold(label, strings.TrimSpace(val)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // evaluateSingleCondition evaluates a single filter condition for an article
 func evaluateSingleCondition(article models.Article, condition FilterCondition, feedCategories map[int64]string) bool {
 	var result bool
@@ -158,6 +178,10 @@ func evaluateSingleCondition(article models.Article, condition FilterCondition,
 			result = article.IsReadLater == wantReadLater
 		}
 
+	case "label":
+		// Filter by labels assigned by the classifier
+		result = matchLabelValues(article.Labels, condition.Values, condition.Value)
+
 	default:
 		result = true
 	}
@@ -3,7 +3,9 @@ package article
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 
+	"MrRSS/internal/database"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/models"
 )
@@ -27,6 +29,11 @@ func HandleFilteredArticles(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if req.Sort != database.SortNewest && req.Sort != database.SortScore {
+		http.Error(w, "Invalid sort", http.StatusBadRequest)
+		return
+	}
+
 	// Set default pagination values
 	page := req.Page
 	if page < 1 {
@@ -74,6 +81,11 @@ func HandleFilteredArticles(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		articles = filteredArticles
 	}
 
+	// Most important first; the stable sort keeps newer articles first among equal scores
+	if req.Sort == database.SortScore {
+		sort.SliceStable(articles, func(i, j int) bool { return articles[i].Score > articles[j].Score })
+	}
+
 	// Apply pagination
 	total := len(articles)
 	offset := (page - 1) * limit
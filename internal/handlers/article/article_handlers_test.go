@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -108,6 +110,9 @@ func TestArticleActions_MarkRead_Favorite_Hide_ReadLater(t *testing.T) {
 	if w.Result().StatusCode != http.StatusOK {
 		t.Fatalf("mark read failed: %d", w.Result().StatusCode)
 	}
+	if feedback, _ := h.DB.GetArticleFeedback(10); len(feedback) != 1 || feedback[0].Signal != database.FeedbackSkip {
+		t.Errorf("expected a skip after marking as read, got %+v", feedback)
+	}
 
 	// Toggle favorite
 	req2 := httptest.NewRequest(http.MethodPost, "/api/articles/toggle_fav?id="+fmt.Sprint(id), nil)
@@ -198,3 +203,164 @@ func TestHandleExportToObsidian(t *testing.T) {
 		t.Fatalf("Export not successful: %v", response)
 	}
 }
+
+func TestHandleExportMarkdown(t *testing.T) {
+	h := setupHandler(t)
+
+	feedID, err := h.DB.AddFeed(&models.Feed{Title: "Test Feed", URL: "http://example.com"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	var ids []int64
+	for _, title := range []string{"Starred", "Other"} {
+		a := &models.Article{FeedID: feedID, Title: title, URL: "http://example.com/" + title, PublishedAt: time.Now()}
+		if err := h.DB.SaveArticles(context.Background(), []*models.Article{a}); err != nil {
+			t.Fatalf("SaveArticles: %v", err)
+		}
+		h.DB.UpdateArticleContent(a.ID, "<p>Body of "+title+"</p>")
+		ids = append(ids, a.ID)
+	}
+	h.DB.ToggleFavorite(ids[0])
+
+	dir := t.TempDir()
+	export := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/api/articles/export/markdown", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		article.HandleExportMarkdown(h, w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Export failed: %d, body: %s", w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	// Without a folder or Obsidian vault there is nothing to export to
+	req := httptest.NewRequest(http.MethodPost, "/api/articles/export/markdown", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	article.HandleExportMarkdown(h, w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("export without a folder returned %d", w.Code)
+	}
+
+	h.DB.SetSetting("obsidian_enabled", "true")
+	h.DB.SetSetting("obsidian_vault_path", dir)
+
+	// Notes go to the vault, not to a folder of the request
+	other := t.TempDir()
+	body := fmt.Sprintf(`{"path": %q, "filter": "favorites", "filename": "RSS/{{.Title}}"}`, other)
+	response := export(body)
+	if response["created"] != float64(1) || fmt.Sprint(response["files"]) != "[RSS/Starred.md]" {
+		t.Fatalf("unexpected response: %v", response)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "RSS", "Starred.md")); err != nil {
+		t.Errorf("note was not written to the vault: %v", err)
+	}
+	if entries, _ := os.ReadDir(other); len(entries) != 0 {
+		t.Errorf("export wrote to the folder of the request: %v", entries)
+	}
+	if response := export(body); response["unchanged"] != float64(1) || response["created"] != float64(0) {
+		t.Fatalf("unexpected response of a second export: %v", response)
+	}
+}
+
+func TestHandleTrendingKeywords(t *testing.T) {
+	h := setupHandler(t)
+	feedID, _ := h.DB.AddFeed(&models.Feed{Title: "F", URL: "http://x"})
+
+	apple := models.ArticleKeyword{Term: "apple", Text: "Apple", Score: 1, Entity: true}
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "a1", URL: "u1", PublishedAt: time.Now().Add(-time.Hour), Keywords: []models.ArticleKeyword{apple}},
+		{FeedID: feedID, Title: "a2", URL: "u2", PublishedAt: time.Now().Add(-2 * time.Hour), Keywords: []models.ArticleKeyword{apple}},
+	}
+	if err := h.DB.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/keywords/trending?hours=24&feed_ids=%d", feedID), nil)
+	w := httptest.NewRecorder()
+	article.HandleTrendingKeywords(h, w, req)
+	var got []models.TrendingKeyword
+	if err := json.NewDecoder(w.Result().Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 1 || got[0].Text != "Apple" || got[0].Count != 2 {
+		t.Errorf("unexpected trending keywords %+v", got)
+	}
+
+	for _, query := range []string{"hours=0", "hours=1000", "feed_ids=x"} {
+		w = httptest.NewRecorder()
+		article.HandleTrendingKeywords(h, w, httptest.NewRequest(http.MethodGet, "/api/keywords/trending?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandleArticleFeedbackAndScore(t *testing.T) {
+	h := setupHandler(t)
+	likedID, _ := h.DB.AddFeed(&models.Feed{Title: "Liked", URL: "http://liked"})
+	otherID, _ := h.DB.AddFeed(&models.Feed{Title: "Other", URL: "http://other"})
+	articles := []*models.Article{
+		{FeedID: likedID, Title: "a1", URL: "u1", PublishedAt: time.Now()},
+		{FeedID: likedID, Title: "a2", URL: "u2", PublishedAt: time.Now()},
+		{FeedID: otherID, Title: "a3", URL: "u3", PublishedAt: time.Now()},
+		{FeedID: otherID, Title: "a4", URL: "u4", PublishedAt: time.Now()},
+	}
+	if err := h.DB.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	article.HandleToggleFavorite(h, w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/articles/favorite?id=%d", articles[0].ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("favorite: expected 200, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	body := fmt.Sprintf(`{"id": %d, "action": "skip"}`, articles[2].ID)
+	article.HandleArticleFeedback(h, w, httptest.NewRequest(http.MethodPost, "/api/articles/feedback", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("feedback: expected 200, got %d", w.Code)
+	}
+
+	score := func(id int64) models.ArticleScore {
+		w := httptest.NewRecorder()
+		article.HandleArticleScore(h, w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/articles/score?id=%d", id), nil))
+		var s models.ArticleScore
+		if err := json.NewDecoder(w.Result().Body).Decode(&s); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return s
+	}
+	if s := score(articles[1].ID); s.Score <= 0 || s.Explanation != "boosted because: feed Liked" {
+		t.Errorf("unexpected score of an article from the liked feed %+v", s)
+	}
+	if s := score(articles[3].ID); s.Score >= 0 || s.Explanation != "lowered because: feed Other" {
+		t.Errorf("unexpected score of an article from the skipped feed %+v", s)
+	}
+
+	w = httptest.NewRecorder()
+	article.HandleArticles(h, w, httptest.NewRequest(http.MethodGet, "/api/articles?filter=priority", nil))
+	var priority []models.Article
+	if err := json.NewDecoder(w.Result().Body).Decode(&priority); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(priority) != 1 || priority[0].ID != articles[1].ID {
+		t.Errorf("unexpected priority inbox %+v", priority)
+	}
+
+	for _, body := range []string{`{"id": 0, "action": "skip"}`, `{"id": 1, "action": "like"}`, `{"id": 1, "action": "read", "seconds": -1}`} {
+		w = httptest.NewRecorder()
+		article.HandleArticleFeedback(h, w, httptest.NewRequest(http.MethodPost, "/api/articles/feedback", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	article.HandleArticles(h, w, httptest.NewRequest(http.MethodGet, "/api/articles?sort=oldest", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("sort=oldest: expected 400, got %d", w.Code)
+	}
+}
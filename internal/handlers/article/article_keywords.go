@@ -0,0 +1,98 @@
+package article
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+)
+
+// maxTrendingHours bounds the time window of trending keywords to 30 days
+const maxTrendingHours = 720
+
+// HandleArticleKeywords returns the keyphrases and entities extracted from an article
+// (?id=), best first.
+func HandleArticleKeywords(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	keywords, err := h.DB.GetArticleKeywords(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(keywords)
+}
+
+// HandleTrendingKeywords returns the keywords mentioned by the most articles in the
+// last hours (?hours=, default 24), favoring keywords that are rising compared with
+// the window before. Optional feed_ids and categories (comma-separated), min_count
+// and limit narrow the result.
+func HandleTrendingKeywords(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	hours := 24
+	if v := q.Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxTrendingHours {
+			http.Error(w, "Invalid hours", http.StatusBadRequest)
+			return
+		}
+		hours = n
+	}
+
+	until := time.Now()
+	query := database.KeywordTrendQuery{
+		Since:      until.Add(-time.Duration(hours) * time.Hour),
+		Until:      until,
+		Categories: splitParam(q.Get("categories")),
+	}
+	for _, v := range splitParam(q.Get("feed_ids")) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+			return
+		}
+		query.FeedIDs = append(query.FeedIDs, id)
+	}
+	if n, err := strconv.Atoi(q.Get("min_count")); err == nil && n > 0 {
+		query.MinCount = n
+	}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 && n <= 100 {
+		query.Limit = n
+	}
+
+	trending, err := h.DB.GetTrendingKeywords(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(trending)
+}
+
+// splitParam splits a comma-separated query parameter, dropping empty entries.
+func splitParam(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
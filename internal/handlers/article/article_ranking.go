@@ -0,0 +1,82 @@
+package article
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/handlers/core"
+)
+
+// FeedbackRequest reports how the user handled an opened or listed article.
+type FeedbackRequest struct {
+	ID      int64   `json:"id"`
+	Action  string  `json:"action"`  // "read" or "skip"
+	Seconds float64 `json:"seconds"` // Reading time, for "read"
+}
+
+// HandleArticleScore returns the importance score of an article (?id=) with the
+// contributions explaining it, computed with the current ranking model.
+func HandleArticleScore(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	score, err := h.Ranking.ScoreArticle(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if score == nil {
+		http.Error(w, "Article not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(score)
+}
+
+// HandleArticleFeedback records reading feedback for the ranking model: the time an
+// article was open ("read"), or that it was marked as read without reading ("skip").
+// Favorites and read later are recorded when they are toggled.
+func HandleArticleFeedback(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch req.Action {
+	case "read":
+		if req.Seconds < 0 {
+			http.Error(w, "Invalid seconds", http.StatusBadRequest)
+			return
+		}
+		err = h.Ranking.RecordReading(req.ID, req.Seconds)
+	case "skip":
+		err = h.Ranking.RecordSkip(req.ID)
+	default:
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
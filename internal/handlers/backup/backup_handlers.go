@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/backup"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/utils"
+)
+
+// maxRestoreSize bounds uploaded backup archives
+const maxRestoreSize = 512 << 20
+
+// exportRequest is the body of a backup export request.
+type exportRequest struct {
+	Passphrase              string `json:"passphrase"`
+	IncludeTranslationCache bool   `json:"include_translation_cache"`
+}
+
+// HandleExport writes a backup archive of the whole setup. Secrets are included
+// encrypted under the passphrase, or left out without one.
+func HandleExport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req exportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	service, err := newService(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Build the archive first so that failures are reported as errors
+	var buf bytes.Buffer
+	if err := service.Write(&buf, backup.Options{Passphrase: req.Passphrase, TranslationCache: req.IncludeTranslationCache}); err != nil {
+		log.Printf("Error writing backup: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="mrrss-backup-%s.zip"`, time.Now().Format("2006-01-02")))
+	w.Write(buf.Bytes())
+}
+
+// HandleRestore restores an uploaded backup archive and returns a report of what was
+// restored. The multipart form holds the archive as "file", the "mode" (merge or
+// replace), the "passphrase" and "dry_run" to only validate the archive.
+func HandleRestore(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRestoreSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	dryRun, _ := strconv.ParseBool(r.FormValue("dry_run"))
+	opts := backup.RestoreOptions{
+		Mode:       r.FormValue("mode"),
+		Passphrase: r.FormValue("passphrase"),
+		DryRun:     dryRun,
+	}
+	if opts.Mode != "" && opts.Mode != backup.ModeMerge && opts.Mode != backup.ModeReplace {
+		http.Error(w, "mode must be merge or replace", http.StatusBadRequest)
+		return
+	}
+
+	service, err := newService(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report, err := service.Restore(r.Context(), file, header.Size, opts)
+	switch {
+	case errors.Is(err, backup.ErrInvalidArchive):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, backup.ErrWrongPassphrase):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		log.Printf("Error restoring backup: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// newService creates a backup service for the data and scripts directories.
+func newService(h *core.Handler) (*backup.Service, error) {
+	dataDir, err := utils.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	scriptsDir, err := utils.GetScriptsDir()
+	if err != nil {
+		return nil, err
+	}
+	return backup.New(h.DB, dataDir, scriptsDir), nil
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MrRSS/internal/backup"
+	"MrRSS/internal/database"
+	corepkg "MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+func setupHandler(t *testing.T) *corepkg.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	return corepkg.NewHandler(db, nil, nil)
+}
+
+func restoreRequest(t *testing.T, archive []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "backup.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(archive)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/backup/restore", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestHandleExportAndRestore(t *testing.T) {
+	src := setupHandler(t)
+	if _, err := src.DB.AddFeed(&models.Feed{Title: "News", URL: "https://example.com/news.xml", Category: "Daily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	HandleExport(src, rr, httptest.NewRequest(http.MethodPost, "/api/backup/export", bytes.NewBufferString(`{"passphrase":"secret"}`)))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("export failed: %d %s", rr.Code, rr.Body.String())
+	}
+	archive := rr.Body.Bytes()
+
+	dst := setupHandler(t)
+	rr = httptest.NewRecorder()
+	HandleRestore(dst, rr, restoreRequest(t, archive, map[string]string{"mode": "merge", "passphrase": "secret", "dry_run": "true"}))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("restore failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var report backup.Report
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Sections[backup.SectionFeeds].Added != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if feeds, _ := dst.DB.GetFeeds(); len(feeds) != 0 {
+		t.Fatalf("dry run added %d feeds", len(feeds))
+	}
+}
+
+func TestHandleRestoreErrors(t *testing.T) {
+	h := setupHandler(t)
+
+	rr := httptest.NewRecorder()
+	HandleRestore(h, rr, httptest.NewRequest(http.MethodGet, "/api/backup/restore", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleRestore(h, rr, restoreRequest(t, []byte("not a zip"), nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid archive, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleRestore(h, rr, restoreRequest(t, []byte("not a zip"), map[string]string{"mode": "overwrite"}))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown mode, got %d", rr.Code)
+	}
+}
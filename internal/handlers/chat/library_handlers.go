@@ -0,0 +1,147 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/embedding"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+	"MrRSS/internal/rag"
+)
+
+// libraryHistoryMessages is the number of earlier messages of a library session sent with a question
+const libraryHistoryMessages = 6
+
+// LibraryChatRequest is a question about the whole library.
+type LibraryChatRequest struct {
+	Question   string `json:"question"`
+	SessionID  int64  `json:"session_id,omitempty"` // Continues a library chat session
+	MaxSources int    `json:"max_sources,omitempty"`
+	rag.Filter
+}
+
+// LibraryChatResponse is the answer to a library question with its citations.
+type LibraryChatResponse struct {
+	*rag.Answer
+	SessionID int64 `json:"session_id"`
+}
+
+// HandleLibraryChat handles POST /api/ai-chat/library to answer questions across all
+// articles, optionally restricted to feeds, categories and a date range. The answer
+// cites article IDs ([#123]) and is stored as a chat session without an article.
+func HandleLibraryChat(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req LibraryChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		http.Error(w, "Missing question", http.StatusBadRequest)
+		return
+	}
+
+	// Check if AI chat is enabled
+	chatEnabled, _ := h.DB.GetSetting("ai_chat_enabled")
+	if chatEnabled != "true" {
+		http.Error(w, "AI chat is disabled", http.StatusForbidden)
+		return
+	}
+
+	var session *models.ChatSession
+	var history []aiclient.Message
+	if req.SessionID > 0 {
+		var err error
+		session, history, err = loadLibraryHistory(h, req.SessionID)
+		if err != nil {
+			writeTurnError(w, err)
+			return
+		}
+	}
+
+	// Use the AI profile selected for chat, or the global AI settings
+	client, err := aiclient.NewForFeature(h.DB, aiclient.FeatureChat, chatTimeout)
+	if err != nil {
+		log.Printf("Library chat failed: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No response from AI"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), chatTimeout)
+	defer cancel()
+	// Combine full-text and semantic retrieval when embeddings are enabled
+	var retrievers []rag.Retriever
+	if h.Embeddings != nil && h.Embeddings.Enabled() {
+		retrievers = []rag.Retriever{rag.NewTextRetriever(h.DB), embedding.NewRetriever(h.Embeddings)}
+	}
+	engine := rag.NewEngine(h.DB, h.AITracker, h.GetArticleContent, retrievers...)
+	answer, err := engine.Ask(ctx, client, rag.Request{
+		Question:   req.Question,
+		Filter:     req.Filter,
+		History:    history,
+		MaxSources: req.MaxSources,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, rag.ErrNoArticles):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No matching articles found"})
+		return
+	case errors.Is(err, rag.ErrLimitReached):
+		log.Printf("AI usage limit reached for library chat")
+		json.NewEncoder(w).Encode(map[string]string{"error": "AI usage limit reached"})
+		return
+	case err != nil:
+		log.Printf("Library chat failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No response from AI"})
+		return
+	}
+
+	turn := &chatTurn{session: session, persist: true, pending: []ChatMessage{{Role: "user", Content: req.Question}}}
+	sessionID, err := turn.save(h, answer.Text)
+	if err != nil {
+		log.Printf("Failed to save library chat session: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(LibraryChatResponse{Answer: answer, SessionID: sessionID})
+}
+
+// loadLibraryHistory loads a library chat session and its latest messages.
+func loadLibraryHistory(h *core.Handler, sessionID int64) (*models.ChatSession, []aiclient.Message, error) {
+	session, err := h.DB.GetChatSession(sessionID)
+	if err == sql.ErrNoRows {
+		return nil, nil, errSessionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := h.DB.GetChatMessages(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stored) > libraryHistoryMessages {
+		stored = stored[len(stored)-libraryHistoryMessages:]
+	}
+	history := make([]aiclient.Message, len(stored))
+	for i, msg := range stored {
+		history[i] = aiclient.Message{Role: msg.Role, Content: msg.Content}
+	}
+	return session, history, nil
+}
@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestHandleLibraryChat(t *testing.T) {
+	server := newRecordingServer(t, "")
+	h := setupChatHandler(t, server.URL)
+
+	feedID, _ := h.DB.AddFeed(&models.Feed{Title: "Transit", URL: "https://transit.example/feed", Category: "Local"})
+	h.DB.SaveArticle(&models.Article{FeedID: feedID, Title: "Night buses return", URL: "https://transit.example/buses",
+		Summary: "The city restores night buses on five routes.", PublishedAt: time.Now()})
+	articles, _ := h.DB.GetArticles("all", 0, "", false, 1, 0)
+	articleID := articles[0].ID
+	server.setReply(fmt.Sprintf("Night buses are back [#%d].", articleID))
+
+	post := func(payload string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		HandleLibraryChat(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat/library", bytes.NewBufferString(payload)))
+		return rr
+	}
+
+	rr := post(`{"question":"What happened to night buses?","categories":["Local"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Response  string `json:"response"`
+		SessionID int64  `json:"session_id"`
+		Citations []struct {
+			ArticleID int64  `json:"article_id"`
+			URL       string `json:"url"`
+		} `json:"citations"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.SessionID == 0 || len(resp.Citations) != 1 || resp.Citations[0].ArticleID != articleID ||
+		resp.Citations[0].URL != "https://transit.example/buses" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	// Follow-up questions carry the stored conversation
+	rr = post(fmt.Sprintf(`{"question":"Which routes run night buses?","session_id":%d}`, resp.SessionID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("follow-up status %d: %s", rr.Code, rr.Body.String())
+	}
+	sent := server.last()
+	if len(sent) != 4 || sent[1].Content != "What happened to night buses?" || !strings.Contains(sent[3].Content, "Which routes") {
+		t.Errorf("unexpected follow-up prompt %+v", sent)
+	}
+	if session, _ := h.DB.GetChatSession(resp.SessionID); session == nil || session.MessageCount != 4 || session.ArticleID != 0 {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	if rr := post(`{"question":"night buses","feed_ids":[999]}`); rr.Code != http.StatusNotFound {
+		t.Errorf("expected %d without matching articles, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,297 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+const (
+	// sessionTitleLength bounds the title derived from the first question of a session
+	sessionTitleLength = 60
+	// keepRecentMessages is the number of latest messages that are never folded into the session summary
+	keepRecentMessages = 4
+	// compactTimeout bounds a background summarization of older turns
+	compactTimeout = 60 * time.Second
+)
+
+var (
+	// errSessionNotFound is returned when a request refers to an unknown chat session
+	errSessionNotFound = errors.New("chat session not found")
+	// errMissingQuestion is returned when a session request has no user message to answer
+	errMissingQuestion = errors.New("missing user message")
+)
+
+// compacting holds the IDs of sessions whose older turns are being summarized.
+var compacting sync.Map
+
+// chatTurn is a chat request resolved against its stored session, if any.
+type chatTurn struct {
+	session        *models.ChatSession // nil until a persisted session exists
+	persist        bool                // whether the turn is stored
+	articleID      int64
+	articleTitle   string
+	articleURL     string
+	articleContent string
+	isFirstMessage bool
+	history        []ChatMessage // messages not yet covered by the session summary
+	pending        []ChatMessage // messages to store with the reply
+}
+
+// writeTurnError reports an error from prepareChatTurn.
+func writeTurnError(w http.ResponseWriter, err error) {
+	switch err {
+	case errSessionNotFound:
+		http.Error(w, "Session not found", http.StatusNotFound)
+	case errMissingQuestion:
+		http.Error(w, "Missing user message", http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// prepareChatTurn resolves the conversation for a request.
+//
+// With a session ID, the history is loaded from the database and only the last user
+// message of the request is used. With an article ID only, a new session is started
+// from the request's messages. Without either, the request is stateless and the client
+// supplies the history, as before sessions were persisted.
+func prepareChatTurn(h *core.Handler, req *ChatRequest) (*chatTurn, error) {
+	turn := &chatTurn{
+		articleID:      req.ArticleID,
+		articleTitle:   req.ArticleTitle,
+		articleURL:     req.ArticleURL,
+		articleContent: req.ArticleContent,
+		isFirstMessage: req.IsFirstMessage,
+	}
+
+	if req.SessionID == 0 && req.ArticleID == 0 {
+		turn.history = req.Messages
+		return turn, nil
+	}
+	turn.persist = true
+
+	if req.SessionID == 0 {
+		for _, msg := range req.Messages {
+			if msg.Role == "user" || msg.Role == "assistant" {
+				turn.pending = append(turn.pending, msg)
+			}
+		}
+		if len(turn.pending) == 0 || turn.pending[len(turn.pending)-1].Role != "user" {
+			return nil, errMissingQuestion
+		}
+		turn.history = turn.pending
+		turn.isFirstMessage = true
+	} else {
+		session, err := h.DB.GetChatSession(req.SessionID)
+		if err == sql.ErrNoRows {
+			return nil, errSessionNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		turn.session = session
+		turn.articleID = session.ArticleID
+		if turn.articleTitle == "" {
+			turn.articleTitle = session.ArticleTitle
+		}
+
+		question := lastUserMessage(req.Messages)
+		if question == "" {
+			return nil, errMissingQuestion
+		}
+		stored, err := h.DB.GetChatMessages(session.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range stored {
+			if msg.ID > session.SummarizedUntil {
+				turn.history = append(turn.history, ChatMessage{Role: msg.Role, Content: msg.Content})
+			}
+		}
+		turn.pending = []ChatMessage{{Role: "user", Content: question}}
+		turn.history = append(turn.history, turn.pending...)
+		turn.isFirstMessage = len(stored) == 0
+	}
+
+	turn.loadArticle(h)
+	return turn, nil
+}
+
+// loadArticle fills in article details the client did not send.
+func (t *chatTurn) loadArticle(h *core.Handler) {
+	if t.articleID == 0 {
+		return
+	}
+	if t.articleTitle == "" || t.articleURL == "" {
+		if article, err := h.DB.GetArticleByID(t.articleID); err == nil {
+			if t.articleTitle == "" {
+				t.articleTitle = article.Title
+			}
+			if t.articleURL == "" {
+				t.articleURL = article.URL
+			}
+		}
+	}
+	if t.isFirstMessage && t.articleContent == "" {
+		content, err := h.GetArticleContent(t.articleID)
+		if err != nil {
+			log.Printf("Chat: failed to load content of article %d: %v", t.articleID, err)
+		}
+		t.articleContent = content
+	}
+}
+
+// prompt builds the messages sent to the AI provider within the context window.
+func (t *chatTurn) prompt(contextWindow int) []ChatMessage {
+	messages := t.history
+	summary := ""
+	if t.persist {
+		// Ask optimizeChatContext to build the system message from the article
+		messages = append([]ChatMessage{{Role: "system"}}, t.history...)
+		if t.session != nil {
+			summary = t.session.Summary
+		}
+	}
+	return optimizeChatContext(messages, t.articleTitle, t.articleURL, t.articleContent, t.isFirstMessage, contextWindow, summary)
+}
+
+// save stores the turn and its reply, creating the session on the first turn.
+// It returns the session ID, or 0 for stateless requests.
+func (t *chatTurn) save(h *core.Handler, reply string) (int64, error) {
+	if !t.persist {
+		return 0, nil
+	}
+	if t.session == nil {
+		id, err := h.DB.CreateChatSession(t.articleID, sessionTitle(t.pending))
+		if err != nil {
+			return 0, err
+		}
+		t.session = &models.ChatSession{ID: id, ArticleID: t.articleID}
+	}
+
+	messages := make([]models.ChatMessage, 0, len(t.pending)+1)
+	for _, msg := range t.pending {
+		messages = append(messages, models.ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	messages = append(messages, models.ChatMessage{Role: "assistant", Content: reply})
+	return t.session.ID, h.DB.AddChatMessages(t.session.ID, messages...)
+}
+
+// finishChatTurn stores a successful turn and starts summarizing older turns of the
+// session in the background when they no longer fit comfortably in the context window.
+func finishChatTurn(h *core.Handler, client *aiclient.Client, turn *chatTurn, reply string) int64 {
+	sessionID, err := turn.save(h, reply)
+	if err != nil {
+		log.Printf("Failed to save chat session: %v", err)
+	}
+	if sessionID == 0 {
+		return sessionID
+	}
+
+	if _, busy := compacting.LoadOrStore(sessionID, true); !busy {
+		go func() {
+			defer compacting.Delete(sessionID)
+			ctx, cancel := context.WithTimeout(context.Background(), compactTimeout)
+			defer cancel()
+			if err := compactChatSession(ctx, h, client, sessionID); err != nil {
+				log.Printf("Failed to summarize chat session %d: %v", sessionID, err)
+			}
+		}()
+	}
+	return sessionID
+}
+
+// compactChatSession folds older turns of a session into its summary once the
+// unsummarized history uses more than half of the prompt budget. The latest
+// keepRecentMessages messages are always kept verbatim.
+func compactChatSession(ctx context.Context, h *core.Handler, client *aiclient.Client, sessionID int64) error {
+	session, err := h.DB.GetChatSession(sessionID)
+	if err != nil {
+		return err
+	}
+	stored, err := h.DB.GetChatMessages(sessionID)
+	if err != nil {
+		return err
+	}
+
+	var unsummarized []models.ChatMessage
+	tokens := 0
+	for _, msg := range stored {
+		if msg.ID > session.SummarizedUntil {
+			unsummarized = append(unsummarized, msg)
+			tokens += estimateTokens(msg.Content)
+		}
+	}
+	if len(unsummarized) <= keepRecentMessages || tokens <= contextTokenBudget(client.ContextWindow())/2 {
+		return nil
+	}
+	if h.AITracker.IsLimitReached() {
+		return nil
+	}
+
+	older := unsummarized[:len(unsummarized)-keepRecentMessages]
+	var transcript strings.Builder
+	for _, msg := range older {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", msg.Role, msg.Content)
+	}
+	prompt := "Summarize the following conversation about an article so it can be continued later. Keep the questions asked, the facts and conclusions given, and any preferences the user stated. Respond in the language of the conversation, in plain text, in at most 200 words."
+	if session.Summary != "" {
+		prompt += "\n\nSummary of the conversation before these messages:\n" + session.Summary
+	}
+
+	h.AITracker.WaitForRateLimit()
+	response, err := client.Complete(ctx, aiclient.Request{
+		Messages: []aiclient.Message{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: transcript.String()},
+		},
+		Temperature: 0.3,
+		MaxTokens:   chatMaxTokens,
+	})
+	if err != nil {
+		return err
+	}
+	trackChatUsage(h, response.Usage, session.ArticleID)
+
+	summary := strings.TrimSpace(response.Text)
+	if summary == "" {
+		return errors.New("empty summary")
+	}
+	return h.DB.UpdateChatSessionSummary(sessionID, summary, older[len(older)-1].ID)
+}
+
+// lastUserMessage returns the content of the last user message.
+func lastUserMessage(messages []ChatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return strings.TrimSpace(messages[i].Content)
+		}
+	}
+	return ""
+}
+
+// sessionTitle derives a session title from its first question.
+func sessionTitle(messages []ChatMessage) string {
+	title := ""
+	for _, msg := range messages {
+		if msg.Role == "user" {
+			title = strings.Join(strings.Fields(msg.Content), " ")
+			break
+		}
+	}
+	if runes := []rune(title); len(runes) > sessionTitleLength {
+		title = strings.TrimSpace(string(runes[:sessionTitleLength])) + "…"
+	}
+	return title
+}
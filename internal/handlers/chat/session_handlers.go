@@ -0,0 +1,217 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// SessionDetail is a chat session with its messages.
+type SessionDetail struct {
+	models.ChatSession
+	Messages []models.ChatMessage `json:"messages"`
+}
+
+// HandleChatSessions handles GET /api/ai-chat/sessions to list chat sessions,
+// optionally only those about one article (?article_id=).
+func HandleChatSessions(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var articleID int64
+	if param := r.URL.Query().Get("article_id"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || id <= 0 {
+			http.Error(w, "Invalid article id", http.StatusBadRequest)
+			return
+		}
+		articleID = id
+	}
+
+	sessions, err := h.DB.GetChatSessions(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []models.ChatSession{}
+	}
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// HandleGetChatSession handles GET /api/ai-chat/sessions/get?id= to resume a chat session.
+func HandleGetChatSession(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	detail, ok := loadSessionDetail(h, w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(detail)
+}
+
+// HandleRenameChatSession handles POST /api/ai-chat/sessions/rename with {"id", "title"}.
+func HandleRenameChatSession(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if req.ID <= 0 || title == "" {
+		http.Error(w, "Missing session id or title", http.StatusBadRequest)
+		return
+	}
+
+	err := h.DB.RenameChatSession(req.ID, title)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleDeleteChatSession handles POST/DELETE /api/ai-chat/sessions/delete?id= to delete
+// a chat session and its messages.
+func HandleDeleteChatSession(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid session id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteChatSession(id); err != nil {
+		log.Printf("Error deleting chat session %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleExportChatSession handles GET /api/ai-chat/sessions/export?id=&format= to download
+// a chat session as Markdown (default) or JSON.
+func HandleExportChatSession(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "json" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	detail, ok := loadSessionDetail(h, w, r)
+	if !ok {
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"chat-%d.json\"", detail.ID))
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(detail)
+		return
+	}
+
+	var article *models.Article
+	if detail.ArticleID > 0 {
+		article, _ = h.DB.GetArticleByID(detail.ArticleID)
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"chat-%d.md\"", detail.ID))
+	w.Write([]byte(sessionMarkdown(detail, article)))
+}
+
+// loadSessionDetail loads the session given by the id query parameter with its
+// messages, writing an error response if that fails.
+func loadSessionDetail(h *core.Handler, w http.ResponseWriter, r *http.Request) (*SessionDetail, bool) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid session id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	session, err := h.DB.GetChatSession(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	messages, err := h.DB.GetChatMessages(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if messages == nil {
+		messages = []models.ChatMessage{}
+	}
+	return &SessionDetail{ChatSession: *session, Messages: messages}, true
+}
+
+// sessionMarkdown renders a chat session as a Markdown document. article may be nil.
+func sessionMarkdown(detail *SessionDetail, article *models.Article) string {
+	var b strings.Builder
+	title := detail.Title
+	if title == "" {
+		title = fmt.Sprintf("Chat %d", detail.ID)
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if article != nil {
+		fmt.Fprintf(&b, "Article: [%s](%s)\n\n", article.Title, article.URL)
+	} else if detail.ArticleTitle != "" {
+		fmt.Fprintf(&b, "Article: %s\n\n", detail.ArticleTitle)
+	}
+	fmt.Fprintf(&b, "Started: %s\n", detail.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	for _, msg := range detail.Messages {
+		speaker := "Assistant"
+		if msg.Role == "user" {
+			speaker = "You"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", speaker, strings.TrimSpace(msg.Content))
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// recordingServer is an OpenAI-compatible stand-in that records the messages of each request.
+type recordingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests [][]aiclient.Message
+	reply    string
+}
+
+func newRecordingServer(t *testing.T, reply string) *recordingServer {
+	s := &recordingServer{reply: reply}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []aiclient.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		s.mu.Lock()
+		s.requests = append(s.requests, body.Messages)
+		reply := s.reply
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%q}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`, reply)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordingServer) setReply(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+func (s *recordingServer) last() []aiclient.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+// waitForCompaction waits until no background summarization is running.
+func waitForCompaction(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		busy := false
+		compacting.Range(func(_, _ any) bool { busy = true; return false })
+		if !busy {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("background summarization did not finish")
+}
+
+func postChat(t *testing.T, h *core.Handler, payload string) ChatResponse {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	HandleAIChat(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat", bytes.NewBufferString(payload)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("chat status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp ChatResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	waitForCompaction(t)
+	return resp
+}
+
+func TestHandleAIChat_PersistsAndResumesSessions(t *testing.T) {
+	server := newRecordingServer(t, "It is about buses.")
+	h := setupChatHandler(t, server.URL)
+
+	feedID, err := h.DB.AddFeed(&models.Feed{Title: "News", URL: "https://example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	if err := h.DB.SaveArticle(&models.Article{FeedID: feedID, Title: "Buses", URL: "https://example.com/buses", PublishedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveArticle() error = %v", err)
+	}
+	articles, _ := h.DB.GetArticles("all", 0, "", false, 1, 0)
+	articleID := articles[0].ID
+
+	first := postChat(t, h, fmt.Sprintf(`{"article_id":%d,"article_content":"Buses are back.","messages":[{"role":"user","content":"What is this about?"}]}`, articleID))
+	if first.SessionID == 0 {
+		t.Fatal("expected a session to be created")
+	}
+
+	// Only the new question is sent; the server supplies the history
+	payload := fmt.Sprintf(`{"session_id":%d,"messages":[{"role":"user","content":"And when?"}]}`, first.SessionID)
+	second := postChat(t, h, payload)
+	if second.SessionID != first.SessionID {
+		t.Errorf("session id = %d, want %d", second.SessionID, first.SessionID)
+	}
+
+	sent := server.last()
+	if len(sent) != 4 || sent[0].Role != "system" || sent[1].Content != "What is this about?" ||
+		sent[2].Content != "It is about buses." || sent[3].Content != "And when?" {
+		t.Errorf("unexpected prompt %+v", sent)
+	}
+	if !strings.Contains(sent[0].Content, "Buses") {
+		t.Errorf("system message should name the article: %q", sent[0].Content)
+	}
+
+	session, err := h.DB.GetChatSession(first.SessionID)
+	if err != nil {
+		t.Fatalf("GetChatSession() error = %v", err)
+	}
+	if session.ArticleID != articleID || session.ArticleTitle != "Buses" || session.Title != "What is this about?" || session.MessageCount != 4 {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	rr := httptest.NewRecorder()
+	HandleAIChat(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat", bytes.NewBufferString(`{"session_id":999,"messages":[{"role":"user","content":"hi"}]}`)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleAIChat_StatelessWithoutArticle(t *testing.T) {
+	server := newRecordingServer(t, "Hello.")
+	h := setupChatHandler(t, server.URL)
+
+	resp := postChat(t, h, `{"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.SessionID != 0 {
+		t.Errorf("stateless request created session %d", resp.SessionID)
+	}
+	if sessions, _ := h.DB.GetChatSessions(0); len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %+v", sessions)
+	}
+}
+
+func TestCompactChatSession(t *testing.T) {
+	server := newRecordingServer(t, "The user asked about buses.")
+	h := setupChatHandler(t, server.URL)
+
+	id, _ := h.DB.CreateChatSession(1, "Buses")
+	long := strings.Repeat("word ", 1000) // About 1250 tokens
+	for i := 0; i < 4; i++ {
+		h.DB.AddChatMessages(id, modelsMessage("user", long), modelsMessage("assistant", long))
+	}
+
+	client, err := aiclient.New(aiclient.Config{Endpoint: server.URL, Model: "test-model"}, nil)
+	if err != nil {
+		t.Fatalf("aiclient.New() error = %v", err)
+	}
+	if err := compactChatSession(context.Background(), h, client, id); err != nil {
+		t.Fatalf("compactChatSession() error = %v", err)
+	}
+
+	session, _ := h.DB.GetChatSession(id)
+	messages, _ := h.DB.GetChatMessages(id)
+	if session.Summary != "The user asked about buses." || session.SummarizedUntil != messages[3].ID {
+		t.Errorf("unexpected session after compaction %+v", session)
+	}
+
+	// The summary replaces the older turns in the prompt
+	turn, err := prepareChatTurn(h, &ChatRequest{SessionID: id, Messages: []ChatMessage{{Role: "user", Content: "Next?"}}})
+	if err != nil {
+		t.Fatalf("prepareChatTurn() error = %v", err)
+	}
+	prompt := turn.prompt(0)
+	if !strings.Contains(prompt[0].Content, "The user asked about buses.") {
+		t.Errorf("summary missing from system message: %q", prompt[0].Content)
+	}
+	if len(prompt) != 6 || prompt[len(prompt)-1].Content != "Next?" {
+		t.Errorf("expected system message, 4 recent messages and the question, got %d messages", len(prompt))
+	}
+}
+
+func TestExportChatSession(t *testing.T) {
+	h := setupChatHandler(t, "http://localhost")
+
+	id, _ := h.DB.CreateChatSession(0, "Buses")
+	h.DB.AddChatMessages(id, modelsMessage("user", "What is this about?"), modelsMessage("assistant", "Buses."))
+
+	rr := httptest.NewRecorder()
+	HandleExportChatSession(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/ai-chat/sessions/export?id=%d", id), nil))
+	body := rr.Body.String()
+	if rr.Header().Get("Content-Type") != "text/markdown; charset=utf-8" ||
+		!strings.Contains(body, "# Buses") || !strings.Contains(body, "## You\n\nWhat is this about?") {
+		t.Errorf("unexpected markdown export: %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleExportChatSession(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/ai-chat/sessions/export?id=%d&format=json", id), nil))
+	var detail SessionDetail
+	if err := json.NewDecoder(rr.Body).Decode(&detail); err != nil || len(detail.Messages) != 2 || detail.Title != "Buses" {
+		t.Errorf("unexpected json export %+v (%v)", detail, err)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleRenameChatSession(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat/sessions/rename", bytes.NewBufferString(fmt.Sprintf(`{"id":%d,"title":"Transit"}`, id))))
+	if rr.Code != http.StatusOK {
+		t.Errorf("rename status = %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleDeleteChatSession(h, rr, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/ai-chat/sessions/delete?id=%d", id), nil))
+	rr = httptest.NewRecorder()
+	HandleGetChatSession(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/ai-chat/sessions/get?id=%d", id), nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("deleted session status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func modelsMessage(role, content string) models.ChatMessage {
+	return models.ChatMessage{Role: role, Content: content}
+}
@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/aistream"
+	"MrRSS/internal/handlers/core"
+)
+
+// chatStreamTimeout bounds the total duration of a streamed chat response
+const chatStreamTimeout = 5 * time.Minute
+
+// HandleAIChatStream handles chat requests like HandleAIChat, but relays the response
+// to the client as Server-Sent Events while it is generated.
+//
+// Events: "delta" {"content"} for each piece of text, then either "done"
+// {"response", "usage", "session_id"} or "error" {"error"}. Closing the connection cancels the request.
+func HandleAIChatStream(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Messages) == 0 {
+		http.Error(w, "Missing messages", http.StatusBadRequest)
+		return
+	}
+
+	// Check if AI chat is enabled
+	chatEnabled, _ := h.DB.GetSetting("ai_chat_enabled")
+	if chatEnabled != "true" {
+		http.Error(w, "AI chat is disabled", http.StatusForbidden)
+		return
+	}
+
+	turn, err := prepareChatTurn(h, &req)
+	if err != nil {
+		writeTurnError(w, err)
+		return
+	}
+
+	events, ok := aistream.NewEventWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Check if AI usage limit is reached
+	if h.AITracker.IsLimitReached() {
+		log.Printf("AI usage limit reached for chat")
+		events.Error("AI usage limit reached")
+		return
+	}
+
+	// Apply rate limiting for AI requests
+	h.AITracker.WaitForRateLimit()
+
+	// Use the AI profile selected for chat, or the global AI settings
+	client, err := aiclient.NewForFeature(h.DB, aiclient.FeatureChat, chatTimeout)
+	if err != nil {
+		log.Printf("Chat stream failed: %v", err)
+		events.Error("No response from AI")
+		return
+	}
+
+	// Optimize context to reduce token usage and fit the model's context window
+	optimizedMessages := turn.prompt(client.ContextWindow())
+
+	result, err := streamChat(r.Context(), client, optimizedMessages, events.Delta)
+
+	// Track usage even when the client went away mid-stream, since tokens were still generated
+	trackChatUsage(h, result.Usage, turn.articleID)
+
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Printf("Chat stream cancelled by client")
+			return
+		}
+		log.Printf("Chat stream failed: %v", err)
+		events.Error("No response from AI")
+		return
+	}
+
+	response := strings.TrimSpace(result.Text)
+	sessionID := finishChatTurn(h, client, turn, response)
+
+	events.Send("done", map[string]interface{}{
+		"response":   response,
+		"usage":      result.Usage,
+		"session_id": sessionID,
+	})
+}
+
+// streamChat sends a streaming chat request to the AI provider. The response is
+// never nil and holds any text relayed before an error.
+func streamChat(ctx context.Context, client *aiclient.Client, messages []ChatMessage, onDelta aiclient.DeltaFunc) (*aiclient.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, chatStreamTimeout)
+	defer cancel()
+	return client.Stream(ctx, buildChatRequest(messages), onDelta)
+}
@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+)
+
+func setupChatHandler(t *testing.T, endpoint string) *core.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db init failed: %v", err)
+	}
+	db.SetSetting("ai_chat_enabled", "true")
+	db.SetSetting("ai_endpoint", endpoint)
+	db.SetSetting("ai_model", "test-model")
+
+	h := core.NewHandler(db, nil, nil)
+	h.AITracker.SetMinInterval(0)
+	return h
+}
+
+func TestHandleAIChatStream_RelaysDeltasAndTracksUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("expected a streaming request, got %v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"It is \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"about buses.\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":90,\"completion_tokens\":10}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	h := setupChatHandler(t, server.URL)
+
+	payload := `{"messages":[{"role":"user","content":"What is this about?"}],"article_title":"Buses","article_content":"Buses.","is_first_message":true}`
+	rr := httptest.NewRecorder()
+	HandleAIChatStream(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat/stream", bytes.NewBufferString(payload)))
+
+	body := rr.Body.String()
+	if rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q: %s", rr.Header().Get("Content-Type"), body)
+	}
+	for _, want := range []string{
+		"event: delta\ndata: {\"content\":\"It is \"}",
+		"event: delta\ndata: {\"content\":\"about buses.\"}",
+		"event: done\ndata: {\"response\":\"It is about buses.\"",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in %s", want, body)
+		}
+	}
+
+	usage, err := h.AITracker.GetCurrentUsage()
+	if err != nil || usage != 100 {
+		t.Errorf("expected reported usage of 100 tokens, got %d (%v)", usage, err)
+	}
+}
+
+func TestHandleAIChatStream_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	h := setupChatHandler(t, server.URL)
+
+	rr := httptest.NewRecorder()
+	HandleAIChatStream(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat/stream", bytes.NewBufferString(`{"messages":[]}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleAIChatStream(h, rr, httptest.NewRequest(http.MethodPost, "/api/ai-chat/stream", bytes.NewBufferString(`{"messages":[{"role":"user","content":"hi"}]}`)))
+	if !strings.Contains(rr.Body.String(), "event: error") {
+		t.Errorf("expected an error event, got %s", rr.Body.String())
+	}
+	if usage, _ := h.AITracker.GetCurrentUsage(); usage != 0 {
+		t.Errorf("failed requests should not be tracked, got %d", usage)
+	}
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"MrRSS/internal/database"
 	"MrRSS/internal/feed"
+	"MrRSS/internal/greader"
 )
 
 func TestNewHandler_ConstructsHandler(t *testing.T) {
@@ -29,3 +32,91 @@ func TestNewHandler_ConstructsHandler(t *testing.T) {
 		t.Fatal("DiscoveryService should be initialized")
 	}
 }
+
+func TestPauseBackgroundTasks(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init failed: %v", err)
+	}
+	f := feed.NewFetcher(db, nil)
+	h := NewHandler(db, f, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartBackgroundScheduler(ctx)
+
+	resume, err := h.PauseBackgroundTasks(time.Second)
+	if err != nil {
+		t.Fatalf("PauseBackgroundTasks failed: %v", err)
+	}
+
+	// Refreshes are skipped while paused
+	f.FetchAll(ctx)
+	if f.GetProgress().IsRunning {
+		t.Fatal("refresh started while paused")
+	}
+	if err := h.Sync.Start(greader.Account{}); err != greader.ErrSyncRunning {
+		t.Fatalf("sync started while paused: %v", err)
+	}
+
+	resume()
+	h.schedulerMu.Lock()
+	restarted := h.schedulerCancel != nil
+	h.schedulerMu.Unlock()
+	if !restarted {
+		t.Fatal("schedulers not restarted")
+	}
+
+	// Pausing again works after resuming
+	resume, err = h.PauseBackgroundTasks(time.Second)
+	if err != nil {
+		t.Fatalf("second PauseBackgroundTasks failed: %v", err)
+	}
+	resume()
+}
+
+func TestRunBackgroundTask(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init failed: %v", err)
+	}
+	h := NewHandler(db, feed.NewFetcher(db, nil), nil)
+
+	if h.RunBackgroundTask(func(context.Context) {}) {
+		t.Fatal("task ran before the scheduler started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartBackgroundScheduler(ctx)
+
+	// Pausing cancels the task and waits for it to return
+	var stopped bool
+	started := make(chan struct{})
+	if !h.RunBackgroundTask(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	}) {
+		t.Fatal("task did not run")
+	}
+	<-started
+	resume, err := h.PauseBackgroundTasks(time.Second)
+	if err != nil {
+		t.Fatalf("PauseBackgroundTasks failed: %v", err)
+	}
+	if !stopped {
+		t.Error("PauseBackgroundTasks returned before the task")
+	}
+	if h.RunBackgroundTask(func(context.Context) {}) {
+		t.Error("task ran while paused")
+	}
+	resume()
+}
@@ -6,16 +6,26 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"MrRSS/internal/aiclient"
 	"MrRSS/internal/aiusage"
 	"MrRSS/internal/cache"
 	"MrRSS/internal/database"
+	"MrRSS/internal/dbbackup"
 	"MrRSS/internal/discovery"
+	"MrRSS/internal/embedding"
 	"MrRSS/internal/feed"
+	"MrRSS/internal/greader"
 	"MrRSS/internal/models"
+	"MrRSS/internal/opmllist"
+	"MrRSS/internal/ranking"
+	"MrRSS/internal/rules"
+	"MrRSS/internal/summaryqueue"
+	"MrRSS/internal/tagging"
 	"MrRSS/internal/translation"
 	"MrRSS/internal/utils"
 
@@ -50,6 +60,20 @@ type Handler struct {
 	DiscoveryService *discovery.Service
 	App              interface{}         // Wails app instance for browser integration (interface{} to avoid import in server mode)
 	ContentCache     *cache.ContentCache // Cache for article content
+	SummaryQueue     *summaryqueue.Queue // Background summaries for newly fetched articles
+	Embeddings       *embedding.Service  // Background article embeddings and similarity search
+	Classifier       *tagging.Classifier // Labels newly fetched articles
+	Ranking          *ranking.Service    // Scores article importance from user feedback
+	Sync             *greader.Service    // Syncs with the configured Google Reader API account
+	DBBackups        *dbbackup.Service   // Scheduled database backups
+	OPMLLists        *opmllist.Service   // Keeps the feeds of subscribed remote OPML files
+
+	// Background scheduler state, so that it can be paused
+	schedulerMu     sync.Mutex
+	schedulerCtx    context.Context    // Context the scheduler was started with
+	schedulerCancel context.CancelFunc // Stops the running schedulers
+	tasksCtx        context.Context    // Context of the running schedulers, nil while stopped
+	schedulerWG     sync.WaitGroup
 
 	// Discovery state tracking for polling-based progress
 	DiscoveryMu          sync.RWMutex
@@ -59,7 +83,7 @@ type Handler struct {
 
 // NewHandler creates a new Handler with the given dependencies.
 func NewHandler(db *database.DB, fetcher *feed.Fetcher, translator translation.Translator) *Handler {
-	return &Handler{
+	h := &Handler{
 		DB:               db,
 		Fetcher:          fetcher,
 		Translator:       translator,
@@ -67,6 +91,65 @@ func NewHandler(db *database.DB, fetcher *feed.Fetcher, translator translation.T
 		DiscoveryService: discovery.NewService(),
 		ContentCache:     cache.NewContentCache(100, 30*time.Minute), // Cache up to 100 articles for 30 minutes
 	}
+	// Record the token usage reported by AI translation requests
+	if t, ok := translator.(interface{ SetUsageFunc(translation.UsageFunc) }); ok {
+		t.SetUsageFunc(h.trackAIUsage)
+	}
+	h.SummaryQueue = summaryqueue.New(db, h.AITracker, h.GetArticleContent)
+	h.Embeddings = embedding.New(db, h.AITracker, h.GetArticleContent)
+	h.Embeddings.SetIndexedFunc(h.applySimilarityRules)
+	h.Classifier = tagging.New(db, h.AITracker)
+	h.Classifier.SetClassifiedFunc(h.applyLabelRules)
+	h.Ranking = ranking.New(db)
+	h.DBBackups = dbbackup.New(db)
+	h.OPMLLists = opmllist.NewService(db, func(feedIDs []int64) {
+		// Fetch the feeds added by the list
+		if h.Fetcher != nil {
+			go h.Fetcher.FetchFeedsByIDs(context.Background(), feedIDs)
+		}
+	})
+	h.Sync = greader.NewService(db, func() {
+		// Fetch the feeds added by the sync
+		if h.Fetcher != nil {
+			go h.Fetcher.FetchAll(context.Background())
+		}
+	})
+	if fetcher != nil {
+		fetcher.SetClassifier(h.Classifier)
+		fetcher.AddArticleQueue(h.SummaryQueue)
+		fetcher.AddArticleQueue(h.Embeddings)
+		fetcher.AddArticleQueue(h.Ranking)
+	}
+	return h
+}
+
+// applySimilarityRules applies rules with "similar_to" conditions to newly fetched
+// articles once their embeddings exist.
+func (h *Handler) applySimilarityRules(articles []models.Article) {
+	affected, err := rules.NewEngine(h.DB).ApplySimilarityRules(articles)
+	if err != nil {
+		log.Printf("Error applying similarity rules: %v", err)
+	} else if affected > 0 {
+		utils.DebugLog("Applied similarity rules to %d articles", affected)
+	}
+}
+
+// applyLabelRules applies rules with "label" conditions to newly fetched articles once
+// their labels are stored.
+func (h *Handler) applyLabelRules(articles []models.Article) {
+	affected, err := rules.NewEngine(h.DB).ApplyLabelRules(articles)
+	if err != nil {
+		log.Printf("Error applying label rules: %v", err)
+	} else if affected > 0 {
+		utils.DebugLog("Applied label rules to %d articles", affected)
+	}
+}
+
+// trackAIUsage records the token usage of an AI translation request.
+func (h *Handler) trackAIUsage(usage aiclient.Usage) {
+	if err := h.AITracker.Record(usage.Record(aiusage.FeatureTranslate, 0)); err != nil {
+		log.Printf("Failed to track AI usage: %v", err)
+	}
 }
 
 // SetApp sets the Wails application instance for browser integration.
@@ -106,33 +189,44 @@ func (h *Handler) GetArticleContent(articleID int64) (string, error) {
 		return "", nil
 	}
 
-	// Try to get feed from cache first
-	var parsedFeed *gofeed.Feed
-	if cachedFeed, found := h.ContentCache.GetFeed(targetFeed.ID); found {
-		parsedFeed = cachedFeed
-	} else {
-		// Parse the feed to get fresh content
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		parsedFeed, err = h.Fetcher.ParseFeedWithFeed(ctx, targetFeed, true) // High priority for content fetching
-		if err != nil {
-			return "", err
+	// Local feeds cannot be parsed; their articles only have the stored content
+	if !feed.IsLocal(*targetFeed) {
+		// Try to get feed from cache first
+		var parsedFeed *gofeed.Feed
+		if cachedFeed, found := h.ContentCache.GetFeed(targetFeed.ID); found {
+			parsedFeed = cachedFeed
+		} else {
+			// Parse the feed to get fresh content
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			parsedFeed, err = h.Fetcher.ParseFeedWithFeed(ctx, targetFeed, true) // High priority for content fetching
+			if err != nil {
+				return "", err
+			}
+
+			// Cache the feed for future use
+			h.ContentCache.SetFeed(targetFeed.ID, parsedFeed)
 		}
 
-		// Cache the feed for future use
-		h.ContentCache.SetFeed(targetFeed.ID, parsedFeed)
-	}
+		// Find the article in the feed by multiple criteria for better matching
+		matchingItem := h.findMatchingFeedItem(article, parsedFeed.Items)
+		if matchingItem != nil {
+			content := feed.ExtractContent(matchingItem)
+			cleanContent := utils.CleanHTML(content)
 
-	// Find the article in the feed by multiple criteria for better matching
-	matchingItem := h.findMatchingFeedItem(article, parsedFeed.Items)
-	if matchingItem != nil {
-		content := feed.ExtractContent(matchingItem)
-		cleanContent := utils.CleanHTML(content)
+			// Cache the content
+			h.ContentCache.Set(articleID, cleanContent)
 
-		// Cache the content
-		h.ContentCache.Set(articleID, cleanContent)
+			return cleanContent, nil
+		}
+	}
 
+	// Fall back to the stored content, e.g. of articles synced from a sync account that the
+	// feed no longer lists, or of imported articles
+	if texts, err := h.DB.GetArticleTextsByIDs([]int64{articleID}); err == nil && len(texts) > 0 && texts[0].Content != "" {
+		cleanContent := utils.CleanHTML(texts[0].Content)
+		h.ContentCache.Set(articleID, cleanContent)
 		return cleanContent, nil
 	}
 
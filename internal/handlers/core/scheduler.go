@@ -3,11 +3,13 @@ package core
 import (
 	"MrRSS/internal/models"
 	"context"
+	"errors"
 	"log"
 	"strconv"
 	"time"
 
 	"MrRSS/internal/cache"
+	"MrRSS/internal/digest"
 	"MrRSS/internal/utils"
 )
 
@@ -34,16 +36,152 @@ func (h *Handler) StartBackgroundScheduler(ctx context.Context) {
 		}
 	}()
 
+	h.schedulerMu.Lock()
+	h.schedulerCtx = ctx
+	h.startSchedulers()
+	h.schedulerMu.Unlock()
+}
+
+// startSchedulers starts the schedulers and background workers with a context that
+// PauseBackgroundTasks cancels. The caller holds schedulerMu.
+func (h *Handler) startSchedulers() {
+	ctx, cancel := context.WithCancel(h.schedulerCtx)
+	h.schedulerCancel = cancel
+	h.tasksCtx = ctx
+
+	run := h.runTask
+
+	// Scheduled digests run independently of the refresh mode
+	run(h.startDigestScheduler)
+
+	// Summarize queued articles in the background, including those left from a previous run
+	if h.SummaryQueue != nil {
+		run(h.SummaryQueue.Run)
+	}
+
+	// Label newly fetched articles in the background, then apply their rules
+	if h.Classifier != nil {
+		run(h.Classifier.Run)
+	}
+
+	// Index article embeddings in the background when enabled
+	if h.Embeddings != nil {
+		run(h.Embeddings.Run)
+	}
+
+	// Score article importance in the background
+	if h.Ranking != nil {
+		run(h.Ranking.Run)
+	}
+
+	// Sync the configured sync account on its schedule
+	if h.Sync != nil {
+		run(h.Sync.Run)
+	}
+
+	// Back up the database on its schedule
+	if h.DBBackups != nil {
+		run(h.DBBackups.Run)
+	}
+
+	// Refresh the subscribed OPML lists
+	if h.OPMLLists != nil {
+		run(h.OPMLLists.Run)
+	}
+
 	// Check refresh mode
 	refreshMode, _ := h.DB.GetSetting("refresh_mode")
 
 	if refreshMode == "intelligent" {
 		// Use intelligent refresh mode with per-feed intervals
-		h.startIntelligentScheduler(ctx)
+		run(h.startIntelligentScheduler)
 	} else {
 		// Use fixed interval mode (default)
-		h.startFixedScheduler(ctx)
+		run(h.startFixedScheduler)
+	}
+}
+
+// runTask runs a task in the background with the context of the running schedulers,
+// so that PauseBackgroundTasks stops and waits for it. The caller holds schedulerMu.
+func (h *Handler) runTask(task func(context.Context)) {
+	ctx := h.tasksCtx
+	h.schedulerWG.Add(1)
+	go func() {
+		defer h.schedulerWG.Done()
+		task(ctx)
+	}()
+}
+
+// RunBackgroundTask runs a one-off task, such as fetching the full text of imported
+// articles, alongside the schedulers. The task should return when its context is
+// cancelled. It reports false without running the task while the schedulers are
+// stopped or paused.
+func (h *Handler) RunBackgroundTask(task func(context.Context)) bool {
+	h.schedulerMu.Lock()
+	defer h.schedulerMu.Unlock()
+	if h.tasksCtx == nil {
+		return false
+	}
+	h.runTask(task)
+	return true
+}
+
+// PauseBackgroundTasks stops the schedulers and background workers and waits for them
+// and for running feed refreshes and syncs to complete, e.g. to restore the database.
+// Each wait gives up after timeout. The returned function resumes them.
+func (h *Handler) PauseBackgroundTasks(timeout time.Duration) (resume func(), err error) {
+	h.schedulerMu.Lock()
+	started := h.schedulerCancel != nil
+	if started {
+		h.schedulerCancel()
+		h.schedulerCancel, h.tasksCtx = nil, nil
+	}
+	h.schedulerMu.Unlock()
+
+	restart := func() {
+		if !started {
+			return
+		}
+		h.schedulerMu.Lock()
+		defer h.schedulerMu.Unlock()
+		if h.schedulerCancel == nil {
+			h.startSchedulers()
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		h.schedulerWG.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		restart()
+		return nil, errors.New("timed out waiting for background tasks to stop")
 	}
+
+	if h.Fetcher != nil && !h.Fetcher.Pause(timeout) {
+		restart()
+		return nil, errors.New("timed out waiting for feed refreshes to complete")
+	}
+	if h.Sync != nil && !h.Sync.Pause(timeout) {
+		if h.Fetcher != nil {
+			h.Fetcher.Resume()
+		}
+		restart()
+		return nil, errors.New("timed out waiting for the sync to complete")
+	}
+
+	return func() {
+		if h.Sync != nil {
+			h.Sync.Resume()
+		}
+		if h.Fetcher != nil {
+			h.Fetcher.Resume()
+		}
+		restart()
+	}, nil
 }
 
 // startFixedScheduler uses a fixed interval for all feeds (but respects per-feed custom intervals)
@@ -250,6 +388,68 @@ func (h *Handler) runCleanup() {
 	}
 }
 
+// startDigestScheduler periodically generates the scheduled digest when it is due
+func (h *Handler) startDigestScheduler(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.runScheduledDigest(ctx, time.Now())
+		}
+	}
+}
+
+// runScheduledDigest generates the configured digest if it is enabled and due
+func (h *Handler) runScheduledDigest(ctx context.Context, now time.Time) {
+	enabled, _ := h.DB.GetSetting("digest_enabled")
+	if enabled != "true" {
+		return
+	}
+
+	frequency, _ := h.DB.GetSetting("digest_frequency")
+	if frequency != digest.PeriodWeekly {
+		frequency = digest.PeriodDaily
+	}
+	hourStr, _ := h.DB.GetSetting("digest_hour")
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil {
+		hour = 7
+	}
+	lastRunStr, _ := h.DB.GetSetting("digest_last_run")
+	lastRun, _ := time.Parse(time.RFC3339, lastRunStr)
+
+	if !digest.IsDue(now, lastRun, frequency, hour) {
+		return
+	}
+
+	// Record the run first so failures are not retried on every tick
+	if err := h.DB.SetSetting("digest_last_run", now.Format(time.RFC3339)); err != nil {
+		log.Printf("Failed to record digest run: %v", err)
+		return
+	}
+
+	category, _ := h.DB.GetSetting("digest_category")
+	generator := digest.NewGenerator(h.DB, h.AITracker, h.GetArticleContent)
+	d, err := generator.Generate(ctx, digest.Options{
+		Period:   frequency,
+		Until:    now,
+		Category: category,
+	})
+	if errors.Is(err, digest.ErrNoArticles) {
+		log.Printf("Scheduled %s digest skipped: no new articles", frequency)
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating scheduled %s digest: %v", frequency, err)
+		return
+	}
+	log.Printf("Generated %s digest %d with %d articles", frequency, d.ID, d.ArticleCount)
+}
+
 // cleanupMediaCache performs media cache cleanup based on settings
 func (h *Handler) cleanupMediaCache() {
 	cacheDir, err := utils.GetMediaCacheDir()
@@ -15,8 +15,6 @@ import (
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
-const customCSSFileName = "custom_article.css"
-
 // HandleUploadCSSDialog opens a file dialog to select CSS file for upload.
 func HandleUploadCSSDialog(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if h.App == nil {
@@ -132,7 +130,7 @@ func HandleUploadCSSDialog(h *core.Handler, w http.ResponseWriter, r *http.Reque
 	}
 
 	// Save CSS file
-	cssFilePath := filepath.Join(dataDir, customCSSFileName)
+	cssFilePath := filepath.Join(dataDir, utils.CustomCSSFileName)
 	destFile, err := os.Create(cssFilePath)
 	if err != nil {
 		log.Printf("Error creating CSS file: %v", err)
@@ -160,7 +158,7 @@ func HandleUploadCSSDialog(h *core.Handler, w http.ResponseWriter, r *http.Reque
 	log.Printf("CSS file uploaded via dialog: %s (%d bytes)", filePath, written)
 
 	// Update setting in database
-	if err := h.DB.SetSetting("custom_css_file", customCSSFileName); err != nil {
+	if err := h.DB.SetSetting("custom_css_file", utils.CustomCSSFileName); err != nil {
 		log.Printf("Error saving custom_css_file setting: %v", err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -223,7 +221,7 @@ func HandleUploadCSS(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save CSS file
-	cssFilePath := filepath.Join(dataDir, customCSSFileName)
+	cssFilePath := filepath.Join(dataDir, utils.CustomCSSFileName)
 	destFile, err := os.Create(cssFilePath)
 	if err != nil {
 		log.Printf("Error creating CSS file: %v", err)
@@ -243,7 +241,7 @@ func HandleUploadCSS(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	log.Printf("CSS file uploaded successfully: %s (%d bytes)", header.Filename, written)
 
 	// Update setting in database
-	if err := h.DB.SetSetting("custom_css_file", customCSSFileName); err != nil {
+	if err := h.DB.SetSetting("custom_css_file", utils.CustomCSSFileName); err != nil {
 		log.Printf("Error saving custom_css_file setting: %v", err)
 		http.Error(w, "Failed to update settings", http.StatusInternalServerError)
 		return
@@ -0,0 +1,128 @@
+package dbbackup
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"MrRSS/internal/dbbackup"
+	"MrRSS/internal/handlers/core"
+)
+
+// pauseTimeout bounds the wait for feed refreshes and syncs before a restore
+const pauseTimeout = 2 * time.Minute
+
+// restoring keeps restores from overlapping
+var restoring sync.Mutex
+
+// HandleList returns the backup directory and the backups, newest first.
+func HandleList(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dir, err := h.DBBackups.Dir()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	backups, err := h.DBBackups.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	enabled, _ := h.DB.GetSetting("db_backup_enabled")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dir":        dir,
+		"enabled":    enabled == "true",
+		"last_error": h.DBBackups.LastError(),
+		"backups":    backups,
+	})
+}
+
+// HandleCreate makes a backup now.
+func HandleCreate(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backup, err := h.DBBackups.Create(r.Context())
+	if err != nil {
+		log.Printf("Error backing up database: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backup)
+}
+
+// HandleRestore replaces the database with a backup. Feed refreshes, syncs and the
+// background schedulers are paused while it runs.
+func HandleRestore(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !restoring.TryLock() {
+		http.Error(w, "a restore is already running", http.StatusConflict)
+		return
+	}
+	defer restoring.Unlock()
+
+	resume, err := h.PauseBackgroundTasks(pauseTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	defer resume()
+
+	previous, err := h.DBBackups.Restore(r.Context(), req.Name)
+	switch {
+	case errors.Is(err, dbbackup.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, dbbackup.ErrCorrupt):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		log.Printf("Error restoring database backup %s: %v", req.Name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Cached content, the embedding index and queued articles are keyed by article IDs
+	// of the replaced database
+	h.ContentCache.Clear()
+	if h.Embeddings != nil {
+		h.Embeddings.Reset()
+	}
+	if h.Ranking != nil {
+		h.Ranking.Reset()
+	}
+	if h.Classifier != nil {
+		h.Classifier.Reset()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"restored": req.Name,
+		"previous": previous,
+	})
+}
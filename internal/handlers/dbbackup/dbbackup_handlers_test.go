@@ -0,0 +1,80 @@
+package dbbackup
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/dbbackup"
+	corepkg "MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+func setupHandler(t *testing.T) *corepkg.Handler {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.NewDB(filepath.Join(dir, "rss.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetSetting("db_backup_dir", filepath.Join(dir, "backups"))
+	return corepkg.NewHandler(db, nil, nil)
+}
+
+func TestHandleCreateListRestore(t *testing.T) {
+	h := setupHandler(t)
+	h.DB.AddFeed(&models.Feed{Title: "Kept", URL: "https://example.com/kept"})
+
+	rr := httptest.NewRecorder()
+	HandleCreate(h, rr, httptest.NewRequest(http.MethodPost, "/api/db-backups/create", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var backup dbbackup.Backup
+	json.NewDecoder(rr.Body).Decode(&backup)
+
+	rr = httptest.NewRecorder()
+	HandleList(h, rr, httptest.NewRequest(http.MethodGet, "/api/db-backups", nil))
+	var list struct {
+		Backups []dbbackup.Backup `json:"backups"`
+	}
+	json.NewDecoder(rr.Body).Decode(&list)
+	if len(list.Backups) != 1 || list.Backups[0].Name != backup.Name {
+		t.Fatalf("unexpected backups: %+v", list.Backups)
+	}
+
+	h.DB.AddFeed(&models.Feed{Title: "Lost", URL: "https://example.com/lost"})
+	rr = httptest.NewRecorder()
+	body, _ := json.Marshal(map[string]string{"name": backup.Name})
+	HandleRestore(h, rr, httptest.NewRequest(http.MethodPost, "/api/db-backups/restore", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("restore failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if feeds, _ := h.DB.GetFeeds(); len(feeds) != 1 {
+		t.Fatalf("got %d feeds after restore, want 1", len(feeds))
+	}
+}
+
+func TestHandleRestoreUnknownBackup(t *testing.T) {
+	h := setupHandler(t)
+
+	rr := httptest.NewRecorder()
+	HandleRestore(h, rr, httptest.NewRequest(http.MethodPost, "/api/db-backups/restore", bytes.NewBufferString(`{"name":"mrrss-20240101-000000.db"}`)))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleRestore(h, rr, httptest.NewRequest(http.MethodPost, "/api/db-backups/restore", bytes.NewBufferString(`{}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a name, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,151 @@
+package digest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/digest"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// HandleDigests lists stored digests without their clusters.
+func HandleDigests(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	digests, err := h.DB.GetDigests(limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if digests == nil {
+		digests = []models.Digest{}
+	}
+	json.NewEncoder(w).Encode(digests)
+}
+
+// HandleGetDigest returns a single digest with its clusters.
+func HandleGetDigest(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	d, ok := loadDigest(h, w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(d)
+}
+
+// HandleGenerateDigest generates and stores a digest for the requested scope and window.
+func HandleGenerateDigest(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var opts digest.Options
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Period == "" {
+		opts.Period = digest.PeriodDaily
+	}
+	if opts.Provider != "" && opts.Provider != "ai" && opts.Provider != "local" {
+		http.Error(w, "Invalid provider. Use 'ai' or 'local'", http.StatusBadRequest)
+		return
+	}
+
+	generator := digest.NewGenerator(h.DB, h.AITracker, h.GetArticleContent)
+	d, err := generator.Generate(r.Context(), opts)
+	if errors.Is(err, digest.ErrNoArticles) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating digest: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(d)
+}
+
+// HandleDeleteDigest deletes a stored digest.
+func HandleDeleteDigest(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid digest id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteDigest(id); err != nil {
+		log.Printf("Error deleting digest %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleExportDigest returns a digest as a Markdown document.
+func HandleExportDigest(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	d, ok := loadDigest(h, w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="digest-%d.md"`, d.ID))
+	w.Write([]byte(digest.RenderMarkdown(d)))
+}
+
+// loadDigest reads the id query parameter and loads the digest, writing an error response on failure.
+func loadDigest(h *core.Handler, w http.ResponseWriter, r *http.Request) (*models.Digest, bool) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid digest id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	d, err := h.DB.GetDigestByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Digest not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return d, true
+}
@@ -0,0 +1,68 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	corepkg "MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+func setupHandler(t *testing.T) *corepkg.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	return &corepkg.Handler{DB: db}
+}
+
+func TestDigestGenerateListExport(t *testing.T) {
+	h := setupHandler(t)
+	feedID, _ := h.DB.AddFeed(&models.Feed{Title: "Example", URL: "https://example.com/feed"})
+	h.DB.SaveArticle(&models.Article{
+		FeedID: feedID, Title: "Example headline", URL: "https://example.com/a", PublishedAt: time.Now().Add(-time.Hour),
+		Summary: "Example summary sentence one for the digest. Example summary sentence two for the digest.",
+	})
+
+	body, _ := json.Marshal(map[string]string{"period": "weekly", "provider": "local"})
+	rr := httptest.NewRecorder()
+	HandleGenerateDigest(h, rr, httptest.NewRequest(http.MethodPost, "/api/digests/generate", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("generate: expected 200 got %d: %s", rr.Code, rr.Body.String())
+	}
+	var d models.Digest
+	json.NewDecoder(rr.Body).Decode(&d)
+	if d.ID == 0 || d.Period != "weekly" || d.ArticleCount != 1 {
+		t.Fatalf("unexpected digest: %+v", d)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleDigests(h, rr, httptest.NewRequest(http.MethodGet, "/api/digests", nil))
+	var list []models.Digest
+	json.NewDecoder(rr.Body).Decode(&list)
+	if len(list) != 1 || list[0].ID != d.ID {
+		t.Fatalf("unexpected digest list: %+v", list)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleExportDigest(h, rr, httptest.NewRequest(http.MethodGet, "/api/digests/export?id=1", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "[Example headline](https://example.com/a)") {
+		t.Fatalf("unexpected export (%d): %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	HandleExportDigest(h, rr, httptest.NewRequest(http.MethodGet, "/api/digests/export?id=42", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing digest, got %d", rr.Code)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"log"
 	"net/http"
 
-	"MrRSS/internal/freshrss"
+	"MrRSS/internal/greader"
 	"MrRSS/internal/handlers/core"
 )
 
@@ -39,20 +39,12 @@ func HandleSync(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create sync service
-	syncService := freshrss.NewSyncService(serverURL, username, password, h.DB)
-
-	// Perform sync in background
-	go func() {
-		ctx := context.Background()
-		if err := syncService.Sync(ctx); err != nil {
-			log.Printf("FreshRSS sync failed: %v", err)
-		} else {
-			log.Printf("FreshRSS sync completed successfully")
-			// Trigger a refresh of all feeds to update the article list
-			go h.Fetcher.FetchAll(context.Background())
-		}
-	}()
+	// Perform sync in background, refreshing all feeds after it
+	account := greader.Account{Profile: greader.FreshRSS, ServerURL: serverURL, Username: username, Password: password}
+	if err := h.Sync.Start(account); err != nil {
+		http.Error(w, "FreshRSS sync already running", http.StatusConflict)
+		return
+	}
 
 	// Return success response
 	w.Header().Set("Content-Type", "application/json")
@@ -100,7 +92,7 @@ func HandleTestConnection(h *core.Handler, w http.ResponseWriter, r *http.Reques
 	password := req.APIPassword
 
 	// Test connection
-	client := freshrss.NewClient(serverURL, username, password)
+	client := greader.NewClient(greader.Account{Profile: greader.FreshRSS, ServerURL: serverURL, Username: username, Password: password})
 	ctx := context.Background()
 
 	err := client.Login(ctx)
@@ -57,7 +57,7 @@ func HandleOPMLImport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	// Import feeds
 	imported := 0
 	for _, feed := range feeds {
-		_, err := h.DB.AddFeed(&feed)
+		_, err := h.Fetcher.ImportFeed(feed)
 		if err != nil {
 			log.Printf("Error importing feed %s: %v", feed.URL, err)
 			continue
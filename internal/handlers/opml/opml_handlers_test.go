@@ -23,7 +23,7 @@ func TestHandleOPMLImport_RawBody(t *testing.T) {
   </body>
 </opml>`
 
-	// Use a real fetcher that writes to an in-memory DB (ImportSubscription uses DB.AddFeed)
+	// Use a real fetcher that writes to an in-memory DB (ImportFeed uses DB.AddFeed)
 	db := func() *database.DB {
 		db, err := database.NewDB(":memory:")
 		if err != nil {
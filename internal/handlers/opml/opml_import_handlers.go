@@ -0,0 +1,101 @@
+package opml
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/jsonimport"
+	"MrRSS/internal/models"
+	"MrRSS/internal/opml"
+)
+
+// HandleOPMLImportPreview parses an OPML or JSON file and compares its feeds to the
+// subscriptions, without importing anything.
+func HandleOPMLImportPreview(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feeds, err := parseImportFile(r)
+	if err != nil {
+		log.Printf("Error parsing import file: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, err := h.DB.GetFeeds()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opml.Compare(feeds, existing))
+}
+
+// HandleOPMLImportApply imports the feeds selected from a preview. Subscribed feeds are
+// treated by the strategy, and the subscriptions listed in remove are deleted.
+func HandleOPMLImportApply(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Feeds    []models.Feed `json:"feeds"`
+		Strategy opml.Strategy `json:"strategy"`
+		Remove   []int64       `json:"remove"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Strategy == "" {
+		req.Strategy = opml.StrategySkip
+	}
+	if !req.Strategy.Valid() {
+		http.Error(w, "unknown strategy", http.StatusBadRequest)
+		return
+	}
+
+	result, err := opml.Apply(h.DB, req.Feeds, opml.ApplyOptions{Strategy: req.Strategy, Remove: req.Remove})
+	if err != nil {
+		log.Printf("Error applying import: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Fetch articles for the new feeds in the background
+	if len(result.AddedIDs) > 0 && h.Fetcher != nil {
+		go h.Fetcher.FetchFeedsByIDs(context.Background(), result.AddedIDs)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseImportFile parses the feeds of an uploaded file or of the raw request body.
+// Files ending in .json are read as JSON exports, everything else as OPML.
+func parseImportFile(r *http.Request) ([]models.Feed, error) {
+	var file io.Reader = r.Body
+	var filename string
+	if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		file, filename = f, header.Filename
+	}
+
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		return jsonimport.Parse(file)
+	}
+	return opml.Parse(file)
+}
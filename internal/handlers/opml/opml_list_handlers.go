@@ -0,0 +1,204 @@
+package opml
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// HandleOPMLLists returns the OPML list subscriptions with the IDs of the feeds they added.
+func HandleOPMLLists(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lists, err := h.DB.GetOPMLLists()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+}
+
+// HandleAddOPMLList subscribes to a remote OPML file and adds its feeds. Lists that
+// cannot be fetched or parsed are not added.
+func HandleAddOPMLList(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var list models.OPMLList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	list.URL = strings.TrimSpace(list.URL)
+	if u, err := url.Parse(list.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "invalid list url", http.StatusBadRequest)
+		return
+	}
+	normalizeOPMLList(&list)
+
+	lists, err := h.DB.GetOPMLLists()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, l := range lists {
+		if l.URL == list.URL {
+			http.Error(w, "already subscribed to this list", http.StatusConflict)
+			return
+		}
+	}
+
+	id, err := h.DB.AddOPMLList(&list)
+	if err != nil {
+		log.Printf("Error adding OPML list: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := h.OPMLLists.Refresh(r.Context(), id)
+	if err != nil {
+		if delErr := h.DB.DeleteOPMLList(id); delErr != nil {
+			log.Printf("Error deleting OPML list %d: %v", id, delErr)
+		}
+		http.Error(w, "fetch list: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	added, err := h.DB.GetOPMLList(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"list":   added,
+		"result": result,
+	})
+}
+
+// HandleUpdateOPMLList changes the title, category and removal option of an OPML list
+// subscription. The next refresh moves the feeds the user has not moved.
+func HandleUpdateOPMLList(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.OPMLList
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	list, ok := getOPMLList(h, w, req.ID)
+	if !ok {
+		return
+	}
+	list.Title, list.Category, list.RemoveMissing = req.Title, req.Category, req.RemoveMissing
+	normalizeOPMLList(list)
+
+	if err := h.DB.UpdateOPMLList(list); err != nil {
+		log.Printf("Error updating OPML list %d: %v", list.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleDeleteOPMLList deletes an OPML list subscription. Its feeds stay unless
+// delete_feeds is true.
+func HandleDeleteOPMLList(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	list, ok := getOPMLList(h, w, id)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("delete_feeds") == "true" {
+		for _, feedID := range list.FeedIDs {
+			if err := h.DB.DeleteFeed(feedID); err != nil {
+				log.Printf("Error deleting feed %d of OPML list %d: %v", feedID, id, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	if err := h.DB.DeleteOPMLList(id); err != nil {
+		log.Printf("Error deleting OPML list %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleRefreshOPMLList fetches an OPML list now and applies its changes.
+func HandleRefreshOPMLList(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if _, ok := getOPMLList(h, w, id); !ok {
+		return
+	}
+
+	result, err := h.OPMLLists.Refresh(r.Context(), id)
+	if err != nil {
+		http.Error(w, "fetch list: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// getOPMLList returns an OPML list subscription, or writes an error response.
+func getOPMLList(h *core.Handler, w http.ResponseWriter, id int64) (*models.OPMLList, bool) {
+	if id <= 0 {
+		http.Error(w, "invalid list id", http.StatusBadRequest)
+		return nil, false
+	}
+	list, err := h.DB.GetOPMLList(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "list not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return list, true
+}
+
+// normalizeOPMLList trims the title and category of a list, and titles it by its URL
+// if it has no title.
+func normalizeOPMLList(list *models.OPMLList) {
+	list.Title = strings.TrimSpace(list.Title)
+	list.Category = strings.Trim(strings.TrimSpace(list.Category), "/")
+	if list.Title == "" {
+		list.Title = list.URL
+	}
+}
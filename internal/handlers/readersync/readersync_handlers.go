@@ -0,0 +1,188 @@
+// Package readersync contains the HTTP handlers for syncing with Google Reader API
+// accounts such as Miniflux, Inoreader, The Old Reader and BazQux.
+package readersync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"MrRSS/internal/greader"
+	"MrRSS/internal/handlers/core"
+)
+
+// testTimeout bounds a connection test
+const testTimeout = 30 * time.Second
+
+// providerInfo describes a sync provider to the settings UI.
+type providerInfo struct {
+	Name           string `json:"name"`
+	Title          string `json:"title"`
+	DefaultURL     string `json:"default_url,omitempty"`
+	TokenAuth      bool   `json:"token_auth"`      // Accepts an access token without a username
+	AppCredentials bool   `json:"app_credentials"` // Needs an app ID and key
+}
+
+// HandleProviders lists the supported sync providers.
+func HandleProviders(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	providers := make([]providerInfo, 0, len(greader.Profiles))
+	for _, p := range greader.Profiles {
+		providers = append(providers, providerInfo{
+			Name:           p.Name,
+			Title:          p.Title,
+			DefaultURL:     p.DefaultURL,
+			TokenAuth:      p.TokenAuth,
+			AppCredentials: p.AppCredentials,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(providers)
+}
+
+// HandleStatus returns the configured sync account and its last sync.
+func HandleStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status, err := h.Sync.Status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleRun syncs the configured account in the background.
+func HandleRun(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	account, err := greader.AccountFromSettings(h.DB)
+	if err != nil {
+		log.Printf("Error loading sync account: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if account == nil {
+		http.Error(w, "No sync account configured", http.StatusBadRequest)
+		return
+	}
+	if err := account.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Sync.Start(*account); err != nil {
+		if errors.Is(err, greader.ErrSyncRunning) {
+			http.Error(w, "Sync already running", http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "sync_started",
+		"message": account.Profile.Title + " synchronization started",
+	})
+}
+
+// HandlePushLocal subscribes the sync account to the local feeds kept off it, such as
+// the feeds that existed before the first sync.
+func HandlePushLocal(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	account, err := greader.AccountFromSettings(h.DB)
+	if err != nil {
+		log.Printf("Error loading sync account: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if account == nil {
+		http.Error(w, "No sync account configured", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.Sync.PushLocalFeeds(*account)
+	if err != nil {
+		log.Printf("Error pushing local feeds: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"pushed": count})
+}
+
+// HandleTestConnection logs in to a sync account and counts its subscriptions.
+func HandleTestConnection(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Provider  string `json:"provider"`
+		ServerURL string `json:"server_url"`
+		Username  string `json:"username"`
+		Password  string `json:"password"`
+		AppID     string `json:"app_id"`
+		AppKey    string `json:"app_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeTestResult(w, 0, errors.New("invalid request body"))
+		return
+	}
+
+	account := greader.Account{
+		Profile:   greader.ProfileByName(req.Provider),
+		ServerURL: req.ServerURL,
+		Username:  req.Username,
+		Password:  req.Password,
+		AppID:     req.AppID,
+		AppKey:    req.AppKey,
+	}
+	if err := account.Validate(); err != nil {
+		writeTestResult(w, 0, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), testTimeout)
+	defer cancel()
+
+	subscriptionCount, err := greader.TestConnection(ctx, account)
+	writeTestResult(w, subscriptionCount, err)
+}
+
+// writeTestResult writes the outcome of a connection test.
+func writeTestResult(w http.ResponseWriter, subscriptionCount int, err error) {
+	result := map[string]interface{}{"success": err == nil}
+	if err != nil {
+		result["error"] = err.Error()
+	} else {
+		result["subscriptionCount"] = subscriptionCount
+		result["message"] = "Connection successful"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
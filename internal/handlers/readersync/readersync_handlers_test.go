@@ -0,0 +1,117 @@
+package readersync
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MrRSS/internal/database"
+	corepkg "MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+func setupHandler(t *testing.T) *corepkg.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	return corepkg.NewHandler(db, nil, nil)
+}
+
+func TestHandleProviders(t *testing.T) {
+	h := setupHandler(t)
+	rr := httptest.NewRecorder()
+	HandleProviders(h, rr, httptest.NewRequest(http.MethodGet, "/api/sync/providers", nil))
+
+	var providers []providerInfo
+	json.NewDecoder(rr.Body).Decode(&providers)
+	if len(providers) != 7 || providers[0].Name != "freshrss" {
+		t.Fatalf("unexpected providers: %+v", providers)
+	}
+}
+
+func TestHandleRunRequiresAccount(t *testing.T) {
+	h := setupHandler(t)
+
+	rr := httptest.NewRecorder()
+	HandleRun(h, rr, httptest.NewRequest(http.MethodPost, "/api/sync/run", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without an account, got %d", rr.Code)
+	}
+
+	// The FreshRSS integration and a provider alone do not opt into syncing
+	h.DB.SetSetting("freshrss_enabled", "true")
+	h.DB.SetSetting("freshrss_server_url", "https://freshrss.example.com")
+	h.DB.SetSetting("freshrss_username", "user")
+	h.DB.SetEncryptedSetting("freshrss_api_password", "password")
+	h.DB.SetSetting("sync_provider", "miniflux")
+	rr = httptest.NewRecorder()
+	HandleStatus(h, rr, httptest.NewRequest(http.MethodGet, "/api/sync/status", nil))
+	var status map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&status)
+	if status["provider"] != "" {
+		t.Fatalf("expected no account without sync_enabled, got %v", status)
+	}
+
+	h.DB.SetSetting("sync_enabled", "true")
+	h.DB.SetSetting("sync_server_url", "https://miniflux.example.com")
+	rr = httptest.NewRecorder()
+	HandleRun(h, rr, httptest.NewRequest(http.MethodPost, "/api/sync/run", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a password, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleStatus(h, rr, httptest.NewRequest(http.MethodGet, "/api/sync/status", nil))
+	status = nil
+	json.NewDecoder(rr.Body).Decode(&status)
+	if status["provider"] != "miniflux" || status["last_sync"] != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+}
+
+func TestHandlePushLocal(t *testing.T) {
+	h := setupHandler(t)
+	h.DB.SetSetting("sync_enabled", "true")
+	h.DB.SetSetting("sync_provider", "miniflux")
+	feedID, _ := h.DB.AddFeed(&models.Feed{Title: "Local", URL: "https://example.com/feed.xml"})
+	h.DB.AddSyncLocalFeeds("miniflux", []int64{feedID})
+
+	rr := httptest.NewRecorder()
+	HandleStatus(h, rr, httptest.NewRequest(http.MethodGet, "/api/sync/status", nil))
+	var status map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&status)
+	if status["local_feeds"] != float64(1) {
+		t.Fatalf("unexpected status: %v", status)
+	}
+
+	rr = httptest.NewRecorder()
+	HandlePushLocal(h, rr, httptest.NewRequest(http.MethodPost, "/api/sync/push-local", nil))
+	var result map[string]int64
+	json.NewDecoder(rr.Body).Decode(&result)
+	if rr.Code != http.StatusOK || result["pushed"] != 1 {
+		t.Fatalf("unexpected response %d: %v", rr.Code, result)
+	}
+	if kept, _ := h.DB.GetSyncLocalFeeds("miniflux"); len(kept) != 0 {
+		t.Errorf("feeds still kept local: %v", kept)
+	}
+}
+
+func TestHandleTestConnectionUnknownProvider(t *testing.T) {
+	h := setupHandler(t)
+	body, _ := json.Marshal(map[string]string{"provider": "unknown", "password": "secret"})
+	rr := httptest.NewRecorder()
+	HandleTestConnection(h, rr, httptest.NewRequest(http.MethodPost, "/api/sync/test-connection", bytes.NewReader(body)))
+
+	var result map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&result)
+	if result["success"] != false || result["error"] == nil {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
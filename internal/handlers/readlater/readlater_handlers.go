@@ -0,0 +1,75 @@
+package readlater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/readlater"
+)
+
+// maxImportSize bounds uploaded export files
+const maxImportSize = 64 << 20
+
+// HandleImport imports the saved articles of an uploaded read-it-later or bookmarks
+// export into the Imported feed. The multipart form holds the export as "file", the
+// "format" if it should not be detected, and "fetch_full_text" to fetch the full text
+// of the articles the export has no content for in the background.
+func HandleImport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := readlater.Parse(data, readlater.Format(r.FormValue("format")), header.Filename)
+	if errors.Is(err, readlater.ErrUnknownFormat) {
+		http.Error(w, "unknown export format, expected pocket, instapaper, wallabag, bookmarks or feedly", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error parsing read-later export: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := readlater.Import(r.Context(), h.DB, entries)
+	if err != nil {
+		log.Printf("Error importing read-later export: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if fetch, _ := strconv.ParseBool(r.FormValue("fetch_full_text")); fetch && len(result.AddedIDs) > 0 {
+		ids := result.AddedIDs
+		if !h.RunBackgroundTask(func(ctx context.Context) {
+			readlater.FetchFullText(ctx, h.DB, ids, h.FetchFullArticleContent)
+		}) {
+			log.Printf("Background tasks are stopped, not fetching the full text of %d imported articles", len(ids))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,71 @@
+package readlater
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"MrRSS/internal/database"
+	corepkg "MrRSS/internal/handlers/core"
+	"MrRSS/internal/readlater"
+)
+
+func importRequest(t *testing.T, filename, data string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(data))
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/readlater/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestHandleImport(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	h := corepkg.NewHandler(db, nil, nil)
+
+	csv := "URL,Title,Selection,Folder,Timestamp\nhttps://example.com/a,A,,Unread,1700000000\nhttps://example.com/b,B,,Archive,1700000000\n"
+	rr := httptest.NewRecorder()
+	HandleImport(h, rr, importRequest(t, "instapaper-export.csv", csv, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var result readlater.Result
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 2 || result.FeedID == 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Files that are not detected are rejected, unless the format is given
+	reordered := "Title,URL,Selection,Folder,Timestamp\nC,https://example.com/c,,Unread,1700000000\n"
+	rr = httptest.NewRecorder()
+	HandleImport(h, rr, importRequest(t, "export.csv", reordered, nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("import of an unknown format: %d, want 400", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	HandleImport(h, rr, importRequest(t, "export.csv", reordered, map[string]string{"format": "instapaper"}))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"added":1`)) {
+		t.Errorf("import with a format: %d %s", rr.Code, rr.Body.String())
+	}
+}
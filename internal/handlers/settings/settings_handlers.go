@@ -15,13 +15,25 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		aiApiKey, _ := h.DB.GetEncryptedSetting("ai_api_key")
+		aiBackend, _ := h.DB.GetSetting("ai_backend")
 		aiChatEnabled, _ := h.DB.GetSetting("ai_chat_enabled")
+		aiChatProfile, _ := h.DB.GetSetting("ai_chat_profile")
 		aiCustomHeaders, _ := h.DB.GetSetting("ai_custom_headers")
+		aiEmbeddingApiKey, _ := h.DB.GetEncryptedSetting("ai_embedding_api_key")
+		aiEmbeddingEnabled, _ := h.DB.GetSetting("ai_embedding_enabled")
+		aiEmbeddingEndpoint, _ := h.DB.GetSetting("ai_embedding_endpoint")
+		aiEmbeddingModel, _ := h.DB.GetSetting("ai_embedding_model")
 		aiEndpoint, _ := h.DB.GetSetting("ai_endpoint")
 		aiModel, _ := h.DB.GetSetting("ai_model")
+		aiPriceTable, _ := h.DB.GetSetting("ai_price_table")
+		aiSummaryProfile, _ := h.DB.GetSetting("ai_summary_profile")
 		aiSummaryPrompt, _ := h.DB.GetSetting("ai_summary_prompt")
+		aiTaggingProfile, _ := h.DB.GetSetting("ai_tagging_profile")
+		aiTranslationProfile, _ := h.DB.GetSetting("ai_translation_profile")
 		aiTranslationPrompt, _ := h.DB.GetSetting("ai_translation_prompt")
+		aiUsageDailyLimit, _ := h.DB.GetSetting("ai_usage_daily_limit")
 		aiUsageLimit, _ := h.DB.GetSetting("ai_usage_limit")
+		aiUsageMonthlyLimit, _ := h.DB.GetSetting("ai_usage_monthly_limit")
 		aiUsageTokens, _ := h.DB.GetSetting("ai_usage_tokens")
 		autoCleanupEnabled, _ := h.DB.GetSetting("auto_cleanup_enabled")
 		autoShowAllContent, _ := h.DB.GetSetting("auto_show_all_content")
@@ -29,9 +41,19 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		baiduSecretKey, _ := h.DB.GetEncryptedSetting("baidu_secret_key")
 		closeToTray, _ := h.DB.GetSetting("close_to_tray")
 		customCssFile, _ := h.DB.GetSetting("custom_css_file")
+		dbBackupDir, _ := h.DB.GetSetting("db_backup_dir")
+		dbBackupEnabled, _ := h.DB.GetSetting("db_backup_enabled")
+		dbBackupKeepDaily, _ := h.DB.GetSetting("db_backup_keep_daily")
+		dbBackupKeepWeekly, _ := h.DB.GetSetting("db_backup_keep_weekly")
 		deeplApiKey, _ := h.DB.GetEncryptedSetting("deepl_api_key")
 		deeplEndpoint, _ := h.DB.GetSetting("deepl_endpoint")
 		defaultViewMode, _ := h.DB.GetSetting("default_view_mode")
+		digestCategory, _ := h.DB.GetSetting("digest_category")
+		digestEnabled, _ := h.DB.GetSetting("digest_enabled")
+		digestFrequency, _ := h.DB.GetSetting("digest_frequency")
+		digestHour, _ := h.DB.GetSetting("digest_hour")
+		digestLastRun, _ := h.DB.GetSetting("digest_last_run")
+		duplicateReadAction, _ := h.DB.GetSetting("duplicate_read_action")
 		freshrssApiPassword, _ := h.DB.GetEncryptedSetting("freshrss_api_password")
 		freshrssEnabled, _ := h.DB.GetSetting("freshrss_enabled")
 		freshrssServerUrl, _ := h.DB.GetSetting("freshrss_server_url")
@@ -43,6 +65,10 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		language, _ := h.DB.GetSetting("language")
 		lastArticleUpdate, _ := h.DB.GetSetting("last_article_update")
 		lastNetworkTest, _ := h.DB.GetSetting("last_network_test")
+		markdownExportFilename, _ := h.DB.GetSetting("markdown_export_filename")
+		markdownExportImages, _ := h.DB.GetSetting("markdown_export_images")
+		markdownExportLayout, _ := h.DB.GetSetting("markdown_export_layout")
+		markdownExportTemplate, _ := h.DB.GetSetting("markdown_export_template")
 		maxArticleAgeDays, _ := h.DB.GetSetting("max_article_age_days")
 		maxCacheSizeMb, _ := h.DB.GetSetting("max_cache_size_mb")
 		maxConcurrentRefreshes, _ := h.DB.GetSetting("max_concurrent_refreshes")
@@ -61,20 +87,36 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		proxyPort, _ := h.DB.GetSetting("proxy_port")
 		proxyType, _ := h.DB.GetSetting("proxy_type")
 		proxyUsername, _ := h.DB.GetEncryptedSetting("proxy_username")
+		rankingSemanticEnabled, _ := h.DB.GetSetting("ranking_semantic_enabled")
 		refreshMode, _ := h.DB.GetSetting("refresh_mode")
 		rules, _ := h.DB.GetSetting("rules")
 		shortcuts, _ := h.DB.GetSetting("shortcuts")
 		showArticlePreviewImages, _ := h.DB.GetSetting("show_article_preview_images")
 		showHiddenArticles, _ := h.DB.GetSetting("show_hidden_articles")
 		startupOnBoot, _ := h.DB.GetSetting("startup_on_boot")
+		summaryAutoCategories, _ := h.DB.GetSetting("summary_auto_categories")
+		summaryAutoFeeds, _ := h.DB.GetSetting("summary_auto_feeds")
 		summaryEnabled, _ := h.DB.GetSetting("summary_enabled")
 		summaryLength, _ := h.DB.GetSetting("summary_length")
 		summaryProvider, _ := h.DB.GetSetting("summary_provider")
+		summaryQueueConcurrency, _ := h.DB.GetSetting("summary_queue_concurrency")
 		summaryTriggerMode, _ := h.DB.GetSetting("summary_trigger_mode")
+		syncAppId, _ := h.DB.GetSetting("sync_app_id")
+		syncAppKey, _ := h.DB.GetEncryptedSetting("sync_app_key")
+		syncEnabled, _ := h.DB.GetSetting("sync_enabled")
+		syncInterval, _ := h.DB.GetSetting("sync_interval")
+		syncPassword, _ := h.DB.GetEncryptedSetting("sync_password")
+		syncProvider, _ := h.DB.GetSetting("sync_provider")
+		syncServerUrl, _ := h.DB.GetSetting("sync_server_url")
+		syncUsername, _ := h.DB.GetSetting("sync_username")
+		taggingEnabled, _ := h.DB.GetSetting("tagging_enabled")
+		taggingProvider, _ := h.DB.GetSetting("tagging_provider")
+		taggingTaxonomy, _ := h.DB.GetSetting("tagging_taxonomy")
 		targetLanguage, _ := h.DB.GetSetting("target_language")
 		theme, _ := h.DB.GetSetting("theme")
 		translationEnabled, _ := h.DB.GetSetting("translation_enabled")
 		translationProvider, _ := h.DB.GetSetting("translation_provider")
+		translationSourceLanguage, _ := h.DB.GetSetting("translation_source_language")
 		updateInterval, _ := h.DB.GetSetting("update_interval")
 		windowHeight, _ := h.DB.GetSetting("window_height")
 		windowMaximized, _ := h.DB.GetSetting("window_maximized")
@@ -83,13 +125,25 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		windowY, _ := h.DB.GetSetting("window_y")
 		json.NewEncoder(w).Encode(map[string]string{
 			"ai_api_key":                  aiApiKey,
+			"ai_backend":                  aiBackend,
 			"ai_chat_enabled":             aiChatEnabled,
+			"ai_chat_profile":             aiChatProfile,
 			"ai_custom_headers":           aiCustomHeaders,
+			"ai_embedding_api_key":        aiEmbeddingApiKey,
+			"ai_embedding_enabled":        aiEmbeddingEnabled,
+			"ai_embedding_endpoint":       aiEmbeddingEndpoint,
+			"ai_embedding_model":          aiEmbeddingModel,
 			"ai_endpoint":                 aiEndpoint,
 			"ai_model":                    aiModel,
+			"ai_price_table":              aiPriceTable,
+			"ai_summary_profile":          aiSummaryProfile,
 			"ai_summary_prompt":           aiSummaryPrompt,
+			"ai_tagging_profile":          aiTaggingProfile,
+			"ai_translation_profile":      aiTranslationProfile,
 			"ai_translation_prompt":       aiTranslationPrompt,
+			"ai_usage_daily_limit":        aiUsageDailyLimit,
 			"ai_usage_limit":              aiUsageLimit,
+			"ai_usage_monthly_limit":      aiUsageMonthlyLimit,
 			"ai_usage_tokens":             aiUsageTokens,
 			"auto_cleanup_enabled":        autoCleanupEnabled,
 			"auto_show_all_content":       autoShowAllContent,
@@ -97,9 +151,19 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			"baidu_secret_key":            baiduSecretKey,
 			"close_to_tray":               closeToTray,
 			"custom_css_file":             customCssFile,
+			"db_backup_dir":               dbBackupDir,
+			"db_backup_enabled":           dbBackupEnabled,
+			"db_backup_keep_daily":        dbBackupKeepDaily,
+			"db_backup_keep_weekly":       dbBackupKeepWeekly,
 			"deepl_api_key":               deeplApiKey,
 			"deepl_endpoint":              deeplEndpoint,
 			"default_view_mode":           defaultViewMode,
+			"digest_category":             digestCategory,
+			"digest_enabled":              digestEnabled,
+			"digest_frequency":            digestFrequency,
+			"digest_hour":                 digestHour,
+			"digest_last_run":             digestLastRun,
+			"duplicate_read_action":       duplicateReadAction,
 			"freshrss_api_password":       freshrssApiPassword,
 			"freshrss_enabled":            freshrssEnabled,
 			"freshrss_server_url":         freshrssServerUrl,
@@ -111,6 +175,10 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			"language":                    language,
 			"last_article_update":         lastArticleUpdate,
 			"last_network_test":           lastNetworkTest,
+			"markdown_export_filename":    markdownExportFilename,
+			"markdown_export_images":      markdownExportImages,
+			"markdown_export_layout":      markdownExportLayout,
+			"markdown_export_template":    markdownExportTemplate,
 			"max_article_age_days":        maxArticleAgeDays,
 			"max_cache_size_mb":           maxCacheSizeMb,
 			"max_concurrent_refreshes":    maxConcurrentRefreshes,
@@ -129,20 +197,36 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			"proxy_port":                  proxyPort,
 			"proxy_type":                  proxyType,
 			"proxy_username":              proxyUsername,
+			"ranking_semantic_enabled":    rankingSemanticEnabled,
 			"refresh_mode":                refreshMode,
 			"rules":                       rules,
 			"shortcuts":                   shortcuts,
 			"show_article_preview_images": showArticlePreviewImages,
 			"show_hidden_articles":        showHiddenArticles,
 			"startup_on_boot":             startupOnBoot,
+			"summary_auto_categories":     summaryAutoCategories,
+			"summary_auto_feeds":          summaryAutoFeeds,
 			"summary_enabled":             summaryEnabled,
 			"summary_length":              summaryLength,
 			"summary_provider":            summaryProvider,
+			"summary_queue_concurrency":   summaryQueueConcurrency,
 			"summary_trigger_mode":        summaryTriggerMode,
+			"sync_app_id":                 syncAppId,
+			"sync_app_key":                syncAppKey,
+			"sync_enabled":                syncEnabled,
+			"sync_interval":               syncInterval,
+			"sync_password":               syncPassword,
+			"sync_provider":               syncProvider,
+			"sync_server_url":             syncServerUrl,
+			"sync_username":               syncUsername,
+			"tagging_enabled":             taggingEnabled,
+			"tagging_provider":            taggingProvider,
+			"tagging_taxonomy":            taggingTaxonomy,
 			"target_language":             targetLanguage,
 			"theme":                       theme,
 			"translation_enabled":         translationEnabled,
 			"translation_provider":        translationProvider,
+			"translation_source_language": translationSourceLanguage,
 			"update_interval":             updateInterval,
 			"window_height":               windowHeight,
 			"window_maximized":            windowMaximized,
@@ -152,73 +236,115 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		})
 	case http.MethodPost:
 		var req struct {
-			AIAPIKey                 string `json:"ai_api_key"`
-			AIChatEnabled            string `json:"ai_chat_enabled"`
-			AICustomHeaders          string `json:"ai_custom_headers"`
-			AIEndpoint               string `json:"ai_endpoint"`
-			AIModel                  string `json:"ai_model"`
-			AISummaryPrompt          string `json:"ai_summary_prompt"`
-			AITranslationPrompt      string `json:"ai_translation_prompt"`
-			AIUsageLimit             string `json:"ai_usage_limit"`
-			AIUsageTokens            string `json:"ai_usage_tokens"`
-			AutoCleanupEnabled       string `json:"auto_cleanup_enabled"`
-			AutoShowAllContent       string `json:"auto_show_all_content"`
-			BaiduAppId               string `json:"baidu_app_id"`
-			BaiduSecretKey           string `json:"baidu_secret_key"`
-			CloseToTray              string `json:"close_to_tray"`
-			CustomCssFile            string `json:"custom_css_file"`
-			DeeplAPIKey              string `json:"deepl_api_key"`
-			DeeplEndpoint            string `json:"deepl_endpoint"`
-			DefaultViewMode          string `json:"default_view_mode"`
-			FreshRSSAPIPassword      string `json:"freshrss_api_password"`
-			FreshRSSEnabled          string `json:"freshrss_enabled"`
-			FreshRSSServerUrl        string `json:"freshrss_server_url"`
-			FreshRSSUsername         string `json:"freshrss_username"`
-			FullTextFetchEnabled     string `json:"full_text_fetch_enabled"`
-			GoogleTranslateEndpoint  string `json:"google_translate_endpoint"`
-			HoverMarkAsRead          string `json:"hover_mark_as_read"`
-			ImageGalleryEnabled      string `json:"image_gallery_enabled"`
-			Language                 string `json:"language"`
-			LastArticleUpdate        string `json:"last_article_update"`
-			LastNetworkTest          string `json:"last_network_test"`
-			MaxArticleAgeDays        string `json:"max_article_age_days"`
-			MaxCacheSizeMb           string `json:"max_cache_size_mb"`
-			MaxConcurrentRefreshes   string `json:"max_concurrent_refreshes"`
-			MediaCacheEnabled        string `json:"media_cache_enabled"`
-			MediaCacheMaxAgeDays     string `json:"media_cache_max_age_days"`
-			MediaCacheMaxSizeMb      string `json:"media_cache_max_size_mb"`
-			NetworkBandwidthMbps     string `json:"network_bandwidth_mbps"`
-			NetworkLatencyMs         string `json:"network_latency_ms"`
-			NetworkSpeed             string `json:"network_speed"`
-			ObsidianEnabled          string `json:"obsidian_enabled"`
-			ObsidianVault            string `json:"obsidian_vault"`
-			ObsidianVaultPath        string `json:"obsidian_vault_path"`
-			ProxyEnabled             string `json:"proxy_enabled"`
-			ProxyHost                string `json:"proxy_host"`
-			ProxyPassword            string `json:"proxy_password"`
-			ProxyPort                string `json:"proxy_port"`
-			ProxyType                string `json:"proxy_type"`
-			ProxyUsername            string `json:"proxy_username"`
-			RefreshMode              string `json:"refresh_mode"`
-			Rules                    string `json:"rules"`
-			Shortcuts                string `json:"shortcuts"`
-			ShowArticlePreviewImages string `json:"show_article_preview_images"`
-			ShowHiddenArticles       string `json:"show_hidden_articles"`
-			StartupOnBoot            string `json:"startup_on_boot"`
-			SummaryEnabled           string `json:"summary_enabled"`
-			SummaryLength            string `json:"summary_length"`
-			SummaryProvider          string `json:"summary_provider"`
-			SummaryTriggerMode       string `json:"summary_trigger_mode"`
-			TargetLanguage           string `json:"target_language"`
-			Theme                    string `json:"theme"`
-			TranslationEnabled       string `json:"translation_enabled"`
-			TranslationProvider      string `json:"translation_provider"`
-			UpdateInterval           string `json:"update_interval"`
-			WindowHeight             string `json:"window_height"`
-			WindowMaximized          string `json:"window_maximized"`
-			WindowWidth              string `json:"window_width"`
-			WindowX                  string `json:"window_x"`
-			WindowY                  string `json:"window_y"`
+			AIAPIKey                  string `json:"ai_api_key"`
+			AIBackend                 string `json:"ai_backend"`
+			AIChatEnabled             string `json:"ai_chat_enabled"`
+			AIChatProfile             string `json:"ai_chat_profile"`
+			AICustomHeaders           string `json:"ai_custom_headers"`
+			AIEmbeddingAPIKey         string `json:"ai_embedding_api_key"`
+			AIEmbeddingEnabled        string `json:"ai_embedding_enabled"`
+			AIEmbeddingEndpoint       string `json:"ai_embedding_endpoint"`
+			AIEmbeddingModel          string `json:"ai_embedding_model"`
+			AIEndpoint                string `json:"ai_endpoint"`
+			AIModel                   string `json:"ai_model"`
+			AIPriceTable              string `json:"ai_price_table"`
+			AISummaryProfile          string `json:"ai_summary_profile"`
+			AISummaryPrompt           string `json:"ai_summary_prompt"`
+			AITaggingProfile          string `json:"ai_tagging_profile"`
+			AITranslationProfile      string `json:"ai_translation_profile"`
+			AITranslationPrompt       string `json:"ai_translation_prompt"`
+			AIUsageDailyLimit         string `json:"ai_usage_daily_limit"`
+			AIUsageLimit              string `json:"ai_usage_limit"`
+			AIUsageMonthlyLimit       string `json:"ai_usage_monthly_limit"`
+			AIUsageTokens             string `json:"ai_usage_tokens"`
+			AutoCleanupEnabled        string `json:"auto_cleanup_enabled"`
+			AutoShowAllContent        string `json:"auto_show_all_content"`
+			BaiduAppId                string `json:"baidu_app_id"`
+			BaiduSecretKey            string `json:"baidu_secret_key"`
+			CloseToTray               string `json:"close_to_tray"`
+			CustomCssFile             string `json:"custom_css_file"`
+			DbBackupDir               string `json:"db_backup_dir"`
+			DbBackupEnabled           string `json:"db_backup_enabled"`
+			DbBackupKeepDaily         string `json:"db_backup_keep_daily"`
+			DbBackupKeepWeekly        string `json:"db_backup_keep_weekly"`
+			DeeplAPIKey               string `json:"deepl_api_key"`
+			DeeplEndpoint             string `json:"deepl_endpoint"`
+			DefaultViewMode           string `json:"default_view_mode"`
+			DigestCategory            string `json:"digest_category"`
+			DigestEnabled             string `json:"digest_enabled"`
+			DigestFrequency           string `json:"digest_frequency"`
+			DigestHour                string `json:"digest_hour"`
+			DigestLastRun             string `json:"digest_last_run"`
+			DuplicateReadAction       string `json:"duplicate_read_action"`
+			FreshRSSAPIPassword       string `json:"freshrss_api_password"`
+			FreshRSSEnabled           string `json:"freshrss_enabled"`
+			FreshRSSServerUrl         string `json:"freshrss_server_url"`
+			FreshRSSUsername          string `json:"freshrss_username"`
+			FullTextFetchEnabled      string `json:"full_text_fetch_enabled"`
+			GoogleTranslateEndpoint   string `json:"google_translate_endpoint"`
+			HoverMarkAsRead           string `json:"hover_mark_as_read"`
+			ImageGalleryEnabled       string `json:"image_gallery_enabled"`
+			Language                  string `json:"language"`
+			LastArticleUpdate         string `json:"last_article_update"`
+			LastNetworkTest           string `json:"last_network_test"`
+			MarkdownExportFilename    string `json:"markdown_export_filename"`
+			MarkdownExportImages      string `json:"markdown_export_images"`
+			MarkdownExportLayout      string `json:"markdown_export_layout"`
+			MarkdownExportTemplate    string `json:"markdown_export_template"`
+			MaxArticleAgeDays         string `json:"max_article_age_days"`
+			MaxCacheSizeMb            string `json:"max_cache_size_mb"`
+			MaxConcurrentRefreshes    string `json:"max_concurrent_refreshes"`
+			MediaCacheEnabled         string `json:"media_cache_enabled"`
+			MediaCacheMaxAgeDays      string `json:"media_cache_max_age_days"`
+			MediaCacheMaxSizeMb       string `json:"media_cache_max_size_mb"`
+			NetworkBandwidthMbps      string `json:"network_bandwidth_mbps"`
+			NetworkLatencyMs          string `json:"network_latency_ms"`
+			NetworkSpeed              string `json:"network_speed"`
+			ObsidianEnabled           string `json:"obsidian_enabled"`
+			ObsidianVault             string `json:"obsidian_vault"`
+			ObsidianVaultPath         string `json:"obsidian_vault_path"`
+			ProxyEnabled              string `json:"proxy_enabled"`
+			ProxyHost                 string `json:"proxy_host"`
+			ProxyPassword             string `json:"proxy_password"`
+			ProxyPort                 string `json:"proxy_port"`
+			ProxyType                 string `json:"proxy_type"`
+			ProxyUsername             string `json:"proxy_username"`
+			RankingSemanticEnabled    string `json:"ranking_semantic_enabled"`
+			RefreshMode               string `json:"refresh_mode"`
+			Rules                     string `json:"rules"`
+			Shortcuts                 string `json:"shortcuts"`
+			ShowArticlePreviewImages  string `json:"show_article_preview_images"`
+			ShowHiddenArticles        string `json:"show_hidden_articles"`
+			StartupOnBoot             string `json:"startup_on_boot"`
+			SummaryAutoCategories     string `json:"summary_auto_categories"`
+			SummaryAutoFeeds          string `json:"summary_auto_feeds"`
+			SummaryEnabled            string `json:"summary_enabled"`
+			SummaryLength             string `json:"summary_length"`
+			SummaryProvider           string `json:"summary_provider"`
+			SummaryQueueConcurrency   string `json:"summary_queue_concurrency"`
+			SummaryTriggerMode        string `json:"summary_trigger_mode"`
+			SyncAppId                 string `json:"sync_app_id"`
+			SyncAppKey                string `json:"sync_app_key"`
+			SyncEnabled               string `json:"sync_enabled"`
+			SyncInterval              string `json:"sync_interval"`
+			SyncPassword              string `json:"sync_password"`
+			SyncProvider              string `json:"sync_provider"`
+			SyncServerUrl             string `json:"sync_server_url"`
+			SyncUsername              string `json:"sync_username"`
+			TaggingEnabled            string `json:"tagging_enabled"`
+			TaggingProvider           string `json:"tagging_provider"`
+			TaggingTaxonomy           string `json:"tagging_taxonomy"`
+			TargetLanguage            string `json:"target_language"`
+			Theme                     string `json:"theme"`
+			TranslationEnabled        string `json:"translation_enabled"`
+			TranslationProvider       string `json:"translation_provider"`
+			TranslationSourceLanguage string `json:"translation_source_language"`
+			UpdateInterval            string `json:"update_interval"`
+			WindowHeight              string `json:"window_height"`
+			WindowMaximized           string `json:"window_maximized"`
+			WindowWidth               string `json:"window_width"`
+			WindowX                   string `json:"window_x"`
+			WindowY                   string `json:"window_y"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -230,14 +356,40 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if req.AIBackend != "" {
+			h.DB.SetSetting("ai_backend", req.AIBackend)
+		}
+
 		if req.AIChatEnabled != "" {
 			h.DB.SetSetting("ai_chat_enabled", req.AIChatEnabled)
 		}
 
+		if req.AIChatProfile != "" {
+			h.DB.SetSetting("ai_chat_profile", req.AIChatProfile)
+		}
+
 		if req.AICustomHeaders != "" {
 			h.DB.SetSetting("ai_custom_headers", req.AICustomHeaders)
 		}
 
+		if err := h.DB.SetEncryptedSetting("ai_embedding_api_key", req.AIEmbeddingAPIKey); err != nil {
+			log.Printf("Failed to save ai_embedding_api_key: %v", err)
+			http.Error(w, "Failed to save ai_embedding_api_key", http.StatusInternalServerError)
+			return
+		}
+
+		if req.AIEmbeddingEnabled != "" {
+			h.DB.SetSetting("ai_embedding_enabled", req.AIEmbeddingEnabled)
+		}
+
+		if req.AIEmbeddingEndpoint != "" {
+			h.DB.SetSetting("ai_embedding_endpoint", req.AIEmbeddingEndpoint)
+		}
+
+		if req.AIEmbeddingModel != "" {
+			h.DB.SetSetting("ai_embedding_model", req.AIEmbeddingModel)
+		}
+
 		if req.AIEndpoint != "" {
 			h.DB.SetSetting("ai_endpoint", req.AIEndpoint)
 		}
@@ -246,18 +398,42 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("ai_model", req.AIModel)
 		}
 
+		if req.AIPriceTable != "" {
+			h.DB.SetSetting("ai_price_table", req.AIPriceTable)
+		}
+
+		if req.AISummaryProfile != "" {
+			h.DB.SetSetting("ai_summary_profile", req.AISummaryProfile)
+		}
+
 		if req.AISummaryPrompt != "" {
 			h.DB.SetSetting("ai_summary_prompt", req.AISummaryPrompt)
 		}
 
+		if req.AITaggingProfile != "" {
+			h.DB.SetSetting("ai_tagging_profile", req.AITaggingProfile)
+		}
+
+		if req.AITranslationProfile != "" {
+			h.DB.SetSetting("ai_translation_profile", req.AITranslationProfile)
+		}
+
 		if req.AITranslationPrompt != "" {
 			h.DB.SetSetting("ai_translation_prompt", req.AITranslationPrompt)
 		}
 
+		if req.AIUsageDailyLimit != "" {
+			h.DB.SetSetting("ai_usage_daily_limit", req.AIUsageDailyLimit)
+		}
+
 		if req.AIUsageLimit != "" {
 			h.DB.SetSetting("ai_usage_limit", req.AIUsageLimit)
 		}
 
+		if req.AIUsageMonthlyLimit != "" {
+			h.DB.SetSetting("ai_usage_monthly_limit", req.AIUsageMonthlyLimit)
+		}
+
 		if req.AIUsageTokens != "" {
 			h.DB.SetSetting("ai_usage_tokens", req.AIUsageTokens)
 		}
@@ -288,6 +464,22 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("custom_css_file", req.CustomCssFile)
 		}
 
+		if req.DbBackupDir != "" {
+			h.DB.SetSetting("db_backup_dir", req.DbBackupDir)
+		}
+
+		if req.DbBackupEnabled != "" {
+			h.DB.SetSetting("db_backup_enabled", req.DbBackupEnabled)
+		}
+
+		if req.DbBackupKeepDaily != "" {
+			h.DB.SetSetting("db_backup_keep_daily", req.DbBackupKeepDaily)
+		}
+
+		if req.DbBackupKeepWeekly != "" {
+			h.DB.SetSetting("db_backup_keep_weekly", req.DbBackupKeepWeekly)
+		}
+
 		if err := h.DB.SetEncryptedSetting("deepl_api_key", req.DeeplAPIKey); err != nil {
 			log.Printf("Failed to save deepl_api_key: %v", err)
 			http.Error(w, "Failed to save deepl_api_key", http.StatusInternalServerError)
@@ -302,6 +494,30 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("default_view_mode", req.DefaultViewMode)
 		}
 
+		if req.DigestCategory != "" {
+			h.DB.SetSetting("digest_category", req.DigestCategory)
+		}
+
+		if req.DigestEnabled != "" {
+			h.DB.SetSetting("digest_enabled", req.DigestEnabled)
+		}
+
+		if req.DigestFrequency != "" {
+			h.DB.SetSetting("digest_frequency", req.DigestFrequency)
+		}
+
+		if req.DigestHour != "" {
+			h.DB.SetSetting("digest_hour", req.DigestHour)
+		}
+
+		if req.DigestLastRun != "" {
+			h.DB.SetSetting("digest_last_run", req.DigestLastRun)
+		}
+
+		if req.DuplicateReadAction != "" {
+			h.DB.SetSetting("duplicate_read_action", req.DuplicateReadAction)
+		}
+
 		if err := h.DB.SetEncryptedSetting("freshrss_api_password", req.FreshRSSAPIPassword); err != nil {
 			log.Printf("Failed to save freshrss_api_password: %v", err)
 			http.Error(w, "Failed to save freshrss_api_password", http.StatusInternalServerError)
@@ -348,6 +564,22 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("last_network_test", req.LastNetworkTest)
 		}
 
+		if req.MarkdownExportFilename != "" {
+			h.DB.SetSetting("markdown_export_filename", req.MarkdownExportFilename)
+		}
+
+		if req.MarkdownExportImages != "" {
+			h.DB.SetSetting("markdown_export_images", req.MarkdownExportImages)
+		}
+
+		if req.MarkdownExportLayout != "" {
+			h.DB.SetSetting("markdown_export_layout", req.MarkdownExportLayout)
+		}
+
+		if req.MarkdownExportTemplate != "" {
+			h.DB.SetSetting("markdown_export_template", req.MarkdownExportTemplate)
+		}
+
 		if req.MaxArticleAgeDays != "" {
 			h.DB.SetSetting("max_article_age_days", req.MaxArticleAgeDays)
 		}
@@ -424,6 +656,10 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if req.RankingSemanticEnabled != "" {
+			h.DB.SetSetting("ranking_semantic_enabled", req.RankingSemanticEnabled)
+		}
+
 		if req.RefreshMode != "" {
 			h.DB.SetSetting("refresh_mode", req.RefreshMode)
 		}
@@ -448,6 +684,14 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("startup_on_boot", req.StartupOnBoot)
 		}
 
+		if req.SummaryAutoCategories != "" {
+			h.DB.SetSetting("summary_auto_categories", req.SummaryAutoCategories)
+		}
+
+		if req.SummaryAutoFeeds != "" {
+			h.DB.SetSetting("summary_auto_feeds", req.SummaryAutoFeeds)
+		}
+
 		if req.SummaryEnabled != "" {
 			h.DB.SetSetting("summary_enabled", req.SummaryEnabled)
 		}
@@ -460,10 +704,62 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("summary_provider", req.SummaryProvider)
 		}
 
+		if req.SummaryQueueConcurrency != "" {
+			h.DB.SetSetting("summary_queue_concurrency", req.SummaryQueueConcurrency)
+		}
+
 		if req.SummaryTriggerMode != "" {
 			h.DB.SetSetting("summary_trigger_mode", req.SummaryTriggerMode)
 		}
 
+		if req.SyncAppId != "" {
+			h.DB.SetSetting("sync_app_id", req.SyncAppId)
+		}
+
+		if err := h.DB.SetEncryptedSetting("sync_app_key", req.SyncAppKey); err != nil {
+			log.Printf("Failed to save sync_app_key: %v", err)
+			http.Error(w, "Failed to save sync_app_key", http.StatusInternalServerError)
+			return
+		}
+
+		if req.SyncEnabled != "" {
+			h.DB.SetSetting("sync_enabled", req.SyncEnabled)
+		}
+
+		if req.SyncInterval != "" {
+			h.DB.SetSetting("sync_interval", req.SyncInterval)
+		}
+
+		if err := h.DB.SetEncryptedSetting("sync_password", req.SyncPassword); err != nil {
+			log.Printf("Failed to save sync_password: %v", err)
+			http.Error(w, "Failed to save sync_password", http.StatusInternalServerError)
+			return
+		}
+
+		if req.SyncProvider != "" {
+			h.DB.SetSetting("sync_provider", req.SyncProvider)
+		}
+
+		if req.SyncServerUrl != "" {
+			h.DB.SetSetting("sync_server_url", req.SyncServerUrl)
+		}
+
+		if req.SyncUsername != "" {
+			h.DB.SetSetting("sync_username", req.SyncUsername)
+		}
+
+		if req.TaggingEnabled != "" {
+			h.DB.SetSetting("tagging_enabled", req.TaggingEnabled)
+		}
+
+		if req.TaggingProvider != "" {
+			h.DB.SetSetting("tagging_provider", req.TaggingProvider)
+		}
+
+		if req.TaggingTaxonomy != "" {
+			h.DB.SetSetting("tagging_taxonomy", req.TaggingTaxonomy)
+		}
+
 		if req.TargetLanguage != "" {
 			h.DB.SetSetting("target_language", req.TargetLanguage)
 		}
@@ -480,6 +776,10 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("translation_provider", req.TranslationProvider)
 		}
 
+		if req.TranslationSourceLanguage != "" {
+			h.DB.SetSetting("translation_source_language", req.TranslationSourceLanguage)
+		}
+
 		if req.UpdateInterval != "" {
 			h.DB.SetSetting("update_interval", req.UpdateInterval)
 		}
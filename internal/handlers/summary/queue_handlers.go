@@ -0,0 +1,89 @@
+package summary
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleSummaryQueue returns the state of the background summary queue.
+func HandleSummaryQueue(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	status, err := h.SummaryQueue.Status(limit)
+	if err != nil {
+		log.Printf("Error getting summary queue status: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandleEnqueueSummaries queues articles for background summarization,
+// regardless of the trigger mode.
+func HandleEnqueueSummaries(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ArticleIDs []int64 `json:"article_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.ArticleIDs) == 0 {
+		http.Error(w, "Missing article_ids", http.StatusBadRequest)
+		return
+	}
+
+	added, err := h.SummaryQueue.Add(req.ArticleIDs)
+	if err != nil {
+		log.Printf("Error queueing summaries: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int{"queued": added})
+}
+
+// HandleCancelSummaries removes an article from the summary queue, or clears
+// the whole queue when no article_id is given. Running summaries are stopped.
+func HandleCancelSummaries(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var removed int64
+	var err error
+	if idStr := r.URL.Query().Get("article_id"); idStr != "" {
+		id, parseErr := strconv.ParseInt(idStr, 10, 64)
+		if parseErr != nil || id <= 0 {
+			http.Error(w, "Invalid article id", http.StatusBadRequest)
+			return
+		}
+		removed, err = h.SummaryQueue.Cancel(id)
+	} else {
+		removed, err = h.SummaryQueue.CancelAll()
+	}
+	if err != nil {
+		log.Printf("Error cancelling summaries: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int64{"cancelled": removed})
+}
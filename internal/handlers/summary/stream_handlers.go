@@ -0,0 +1,142 @@
+package summary
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"MrRSS/internal/aistream"
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/summary"
+)
+
+// HandleSummarizeArticleStream summarizes an article like HandleSummarizeArticle, but
+// relays AI output to the client as Server-Sent Events while it is generated.
+//
+// Events: "delta" {"content"} for each piece of text, then either "done" with the same
+// fields as the non-streaming response, or "error" {"error"}. Local summaries are sent
+// as a single delta. Closing the connection cancels the request.
+func HandleSummarizeArticleStream(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ArticleID int64  `json:"article_id"`
+		Length    string `json:"length"`            // "short", "medium", "long"
+		Content   string `json:"content,omitempty"` // Optional: use provided content instead of fetching from DB
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summaryLength, ok := summary.ParseLength(req.Length)
+	if !ok {
+		http.Error(w, "Invalid length parameter. Use 'short', 'medium', or 'long'", http.StatusBadRequest)
+		return
+	}
+
+	content, err := getArticleContent(h, req.ArticleID, req.Content)
+	if err != nil {
+		log.Printf("Error getting article content for summary: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events, ok := aistream.NewEventWriter(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	if content == "" {
+		events.Send("done", map[string]interface{}{
+			"summary":      "",
+			"is_too_short": true,
+			"error":        "No content available for this article",
+		})
+		return
+	}
+
+	provider, err := h.DB.GetSetting("summary_provider")
+	if err != nil || provider == "" {
+		provider = "local"
+	}
+
+	var result summary.SummaryResult
+	usedFallback := false
+	limitReached := false
+	streamed := false
+
+	if provider == "ai" && h.AITracker.IsLimitReached() {
+		log.Printf("AI usage limit reached, falling back to local summarization")
+		limitReached = true
+		usedFallback = true
+	}
+
+	if provider == "ai" && !limitReached {
+		// Apply rate limiting for AI requests
+		h.AITracker.WaitForRateLimit()
+
+		var aiResult summary.SummaryResult
+		aiSummarizer, err := summary.NewAISummarizerForSummaries(h.DB, aiSummaryTimeout)
+		if err == nil {
+			aiResult, err = aiSummarizer.SummarizeStream(r.Context(), content, summaryLength, func(delta string) error {
+				streamed = true
+				return events.Delta(delta)
+			})
+		}
+
+		// Track usage even when the client went away mid-stream, since tokens were still generated
+		if aiResult.Usage.Total() > 0 {
+			if err := h.AITracker.Record(aiResult.Usage.Record(aiusage.FeatureSummary, req.ArticleID)); err != nil {
+				log.Printf("Warning: failed to track AI usage: %v", err)
+			}
+		}
+
+		switch {
+		case err == nil:
+			result = aiResult
+		case r.Context().Err() != nil:
+			log.Printf("Summary stream for article %d cancelled by client", req.ArticleID)
+			return
+		case streamed:
+			// Part of the summary was already sent, so a local summary cannot replace it
+			log.Printf("Summary stream for article %d failed: %v", req.ArticleID, err)
+			events.Error(err.Error())
+			return
+		default:
+			log.Printf("Error generating AI summary, falling back to local: %v", err)
+			usedFallback = true
+		}
+	}
+
+	if provider != "ai" || usedFallback {
+		result = summary.NewSummarizer().Summarize(content, summaryLength)
+		if result.Summary != "" {
+			events.Delta(result.Summary)
+		}
+	} else if result.IsTooShort && result.Summary != "" {
+		// Too short for AI; the cleaned text was not streamed
+		events.Delta(result.Summary)
+	}
+
+	// Cache the summary in the database
+	if err := h.DB.UpdateArticleSummary(req.ArticleID, result.Summary); err != nil {
+		log.Printf("Failed to cache summary for article %d: %v", req.ArticleID, err)
+	}
+
+	response := map[string]interface{}{
+		"summary":        result.Summary,
+		"sentence_count": result.SentenceCount,
+		"is_too_short":   result.IsTooShort,
+		"limit_reached":  limitReached,
+	}
+	if usedFallback {
+		response["used_fallback"] = true
+	}
+	events.Send("done", response)
+}
@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
+	"MrRSS/internal/aiusage"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/summary"
 )
 
+// aiSummaryTimeout bounds the HTTP requests of an AI summary
+const aiSummaryTimeout = 30 * time.Second
+
 // HandleSummarizeArticle generates a summary for an article's content.
 func HandleSummarizeArticle(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -28,15 +33,8 @@ func HandleSummarizeArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 	}
 
 	// Validate length parameter
-	summaryLength := summary.Medium
-	switch req.Length {
-	case "short":
-		summaryLength = summary.Short
-	case "long":
-		summaryLength = summary.Long
-	case "medium", "":
-		summaryLength = summary.Medium
-	default:
+	summaryLength, ok := summary.ParseLength(req.Length)
+	if !ok {
 		http.Error(w, "Invalid length parameter. Use 'short', 'medium', or 'long'", http.StatusBadRequest)
 		return
 	}
@@ -77,33 +75,11 @@ func HandleSummarizeArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 			result = summarizer.Summarize(content, summaryLength)
 			usedFallback = true
 		} else {
-			// Use AI summarization (API key is optional for some providers)
-			apiKey, err := h.DB.GetEncryptedSetting("ai_api_key")
-			// Some AI providers don't require API keys, so we proceed regardless
-			log.Printf("Using AI summarization (API key: %s)", func() string {
-				if apiKey != "" {
-					return "configured"
-				}
-				return "not configured (using keyless provider)"
-			}())
-
 			// Apply rate limiting for AI requests
 			h.AITracker.WaitForRateLimit()
 
-			// Get global AI settings
-			endpoint, _ := h.DB.GetSetting("ai_endpoint")
-			model, _ := h.DB.GetSetting("ai_model")
-			systemPrompt, _ := h.DB.GetSetting("ai_summary_prompt")
-			customHeaders, _ := h.DB.GetSetting("ai_custom_headers")
-
-			aiSummarizer := summary.NewAISummarizerWithDB(apiKey, endpoint, model, h.DB)
-			if systemPrompt != "" {
-				aiSummarizer.SetSystemPrompt(systemPrompt)
-			}
-			if customHeaders != "" {
-				aiSummarizer.SetCustomHeaders(customHeaders)
-			}
-			aiResult, err := aiSummarizer.Summarize(content, summaryLength)
+			// Use AI summarization (API key is optional for some providers)
+			aiResult, err := summarizeWithAI(h, content, summaryLength)
 			if err != nil {
 				log.Printf("Error generating AI summary, falling back to local: %v", err)
 				// Fallback to local algorithm on any AI error
@@ -112,8 +88,10 @@ func HandleSummarizeArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 				usedFallback = true
 			} else {
 				result = aiResult
-				// Track AI usage only on success
-				h.AITracker.TrackSummary(content, result.Summary)
+				// Track the token usage reported by the provider
+				if err := h.AITracker.Record(result.Usage.Record(aiusage.FeatureSummary, req.ArticleID)); err != nil {
+					log.Printf("Warning: failed to track AI usage: %v", err)
+				}
 			}
 		}
 	} else {
@@ -141,6 +119,15 @@ func HandleSummarizeArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(response)
 }
 
+// summarizeWithAI summarizes content with the AI profile selected for summaries
+func summarizeWithAI(h *core.Handler, content string, length summary.SummaryLength) (summary.SummaryResult, error) {
+	aiSummarizer, err := summary.NewAISummarizerForSummaries(h.DB, aiSummaryTimeout)
+	if err != nil {
+		return summary.SummaryResult{}, err
+	}
+	return aiSummarizer.Summarize(content, length)
+}
+
 // getArticleContent fetches the content of an article by ID, or uses provided content
 func getArticleContent(h *core.Handler, articleID int64, providedContent string) (string, error) {
 	// If content is provided, use it directly
@@ -114,3 +114,76 @@ func (m *mockParser) ParseURL(url string) (*gofeed.Feed, error) {
 func (m *mockParser) ParseURLWithContext(url string, ctx context.Context) (*gofeed.Feed, error) {
 	return &gofeed.Feed{Items: m.items}, nil
 }
+
+func TestSummaryQueueHandlers(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db init failed: %v", err)
+	}
+	feedID, _ := db.AddFeed(&models.Feed{Title: "T", URL: "http://example.com/feed"})
+	db.SaveArticle(&models.Article{FeedID: feedID, Title: "A", URL: "http://example.com/a"})
+	articles, _ := db.GetArticles("", feedID, "", false, 1, 0)
+	if len(articles) != 1 {
+		t.Fatalf("expected 1 article, got %d", len(articles))
+	}
+	id := articles[0].ID
+
+	h := core.NewHandler(db, nil, nil)
+
+	rr := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"article_ids": [%d]}`, id)
+	HandleEnqueueSummaries(h, rr, httptest.NewRequest(http.MethodPost, "/api/summary-queue/enqueue", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"queued":1`)) {
+		t.Fatalf("enqueue: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	HandleSummaryQueue(h, rr, httptest.NewRequest(http.MethodGet, "/api/summary-queue", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"pending":1`)) {
+		t.Fatalf("status: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	HandleCancelSummaries(h, rr, httptest.NewRequest(http.MethodPost, "/api/summary-queue/cancel?article_id=abc", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	HandleCancelSummaries(h, rr, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/summary-queue/cancel?article_id=%d", id), nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"cancelled":1`)) {
+		t.Fatalf("cancel: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleSummarizeArticleStream_Local(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db init failed: %v", err)
+	}
+	db.SetSetting("summary_provider", "local")
+	h := core.NewHandler(db, nil, nil)
+
+	content := "The city council approved a new budget for public transport on Monday. " +
+		"The plan adds twenty electric buses to the fleet over the next two years. " +
+		"Council members said the investment will cut emissions and shorten waiting times. " +
+		"Opposition members questioned whether the funding is sustainable in the long term."
+	payload := fmt.Sprintf(`{"article_id": 1, "length": "short", "content": %q}`, content)
+
+	rr := httptest.NewRecorder()
+	HandleSummarizeArticleStream(h, rr, httptest.NewRequest(http.MethodPost, "/api/articles/summarize/stream", bytes.NewBufferString(payload)))
+
+	body := rr.Body.String()
+	if rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q: %s", rr.Header().Get("Content-Type"), body)
+	}
+	if !bytes.Contains(rr.Body.Bytes(), []byte("event: delta\n")) || !bytes.Contains(rr.Body.Bytes(), []byte("event: done\n")) {
+		t.Errorf("expected delta and done events, got %s", body)
+	}
+}
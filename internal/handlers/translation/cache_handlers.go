@@ -0,0 +1,224 @@
+package translation
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/translation"
+)
+
+// maxCacheImportLine bounds the size of a single JSONL line accepted by the import.
+const maxCacheImportLine = 4 * 1024 * 1024
+
+// HandleTranslationCacheStats returns cache statistics per provider and target language.
+func HandleTranslationCacheStats(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, err := h.DB.GetTranslationCacheStats()
+	if err != nil {
+		log.Printf("Error getting translation cache stats: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(stats)
+}
+
+// HandleTranslationCache searches cached translations.
+func HandleTranslationCache(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 50
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	entries, total, err := h.DB.SearchCachedTranslations(query.Get("q"), query.Get("provider"), query.Get("target_lang"), limit, offset)
+	if err != nil {
+		log.Printf("Error searching translation cache: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []database.TranslationCache{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
+// HandleUpdateCachedTranslation manually corrects a cached translation.
+// Corrected entries are pinned by default so they are never overwritten.
+func HandleUpdateCachedTranslation(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID             int64  `json:"id"`
+		TranslatedText string `json:"translated_text"`
+		Pinned         *bool  `json:"pinned"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 || strings.TrimSpace(req.TranslatedText) == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	pinned := true
+	if req.Pinned != nil {
+		pinned = *req.Pinned
+	}
+
+	if err := h.DB.UpdateCachedTranslation(req.ID, req.TranslatedText, pinned); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Cache entry not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating cached translation %d: %v", req.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleDeleteCachedTranslation deletes a single cached translation.
+func HandleDeleteCachedTranslation(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid entry id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteCachedTranslation(id); err != nil {
+		log.Printf("Error deleting cached translation %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// HandleExportTranslationCache streams the whole translation cache as JSON Lines.
+func HandleExportTranslationCache(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="translation-cache.jsonl"`)
+
+	enc := json.NewEncoder(w)
+	err := h.DB.ForEachCachedTranslation(func(entry database.TranslationCache) error {
+		return enc.Encode(cacheExportEntry{
+			SourceText:     entry.SourceText,
+			TargetLang:     entry.TargetLang,
+			TranslatedText: entry.TranslatedText,
+			Provider:       entry.Provider,
+			Pinned:         entry.Pinned,
+			CreatedAt:      entry.CreatedAt,
+		})
+	})
+	if err != nil {
+		// Headers are already sent, so the error can only be logged
+		log.Printf("Error exporting translation cache: %v", err)
+	}
+}
+
+// HandleImportTranslationCache imports cached translations from a JSON Lines body
+// produced by HandleExportTranslationCache.
+func HandleImportTranslationCache(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	imported, skipped := 0, 0
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxCacheImportLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry cacheExportEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil ||
+			entry.SourceText == "" || entry.TargetLang == "" || entry.TranslatedText == "" || entry.Provider == "" {
+			skipped++
+			continue
+		}
+
+		written, err := h.DB.ImportCachedTranslation(database.TranslationCache{
+			// Always rehash so imported entries match what the translator looks up
+			SourceTextHash: translation.HashText(entry.SourceText),
+			SourceText:     entry.SourceText,
+			TargetLang:     entry.TargetLang,
+			TranslatedText: entry.TranslatedText,
+			Provider:       entry.Provider,
+			Pinned:         entry.Pinned,
+			CreatedAt:      entry.CreatedAt,
+		})
+		if err != nil {
+			log.Printf("Error importing cached translation: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if written {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int{
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
+
+// cacheExportEntry is one line of a translation cache export.
+type cacheExportEntry struct {
+	SourceText     string `json:"source_text"`
+	TargetLang     string `json:"target_lang"`
+	TranslatedText string `json:"translated_text"`
+	Provider       string `json:"provider"`
+	Pinned         bool   `json:"pinned"`
+	CreatedAt      string `json:"created_at,omitempty"`
+}
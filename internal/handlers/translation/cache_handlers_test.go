@@ -0,0 +1,48 @@
+package translation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corepkg "MrRSS/internal/handlers/core"
+	transpkg "MrRSS/internal/translation"
+)
+
+func TestTranslationCacheExportImportRoundTrip(t *testing.T) {
+	src := setupDB(t)
+	src.SetCachedTranslation(transpkg.HashText("Hello"), "Hello", "zh", "你好", "google")
+	src.SetCachedTranslation(transpkg.HashText("World"), "World", "zh", "世界", "google")
+
+	rr := httptest.NewRecorder()
+	HandleExportTranslationCache(&corepkg.Handler{DB: src}, rr, httptest.NewRequest(http.MethodGet, "/api/translation/cache/export", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("export: expected 200 got %d", rr.Code)
+	}
+	export := rr.Body.String()
+	if lines := strings.Count(export, "\n"); lines != 2 {
+		t.Fatalf("expected 2 JSONL lines, got %d: %q", lines, export)
+	}
+
+	dst := setupDB(t)
+	body := export + "not json\n"
+	rr = httptest.NewRecorder()
+	HandleImportTranslationCache(&corepkg.Handler{DB: dst}, rr, httptest.NewRequest(http.MethodPost, "/api/translation/cache/import", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import: expected 200 got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp map[string]int
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["imported"] != 2 || resp["skipped"] != 1 {
+		t.Fatalf("unexpected import result: %v", resp)
+	}
+
+	got, found, _ := dst.GetCachedTranslation(transpkg.HashText("World"), "zh", "google")
+	if !found || got != "世界" {
+		t.Fatalf("imported entry = %q (found %v)", got, found)
+	}
+}
@@ -0,0 +1,141 @@
+package translation
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+	"MrRSS/internal/translation"
+)
+
+// HandleGlossary returns all glossary and do-not-translate entries.
+func HandleGlossary(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := h.DB.GetGlossaryEntries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.GlossaryEntry{}
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+// HandleAddGlossaryEntry adds a glossary or do-not-translate entry.
+func HandleAddGlossaryEntry(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var entry models.GlossaryEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validateGlossaryEntry(&entry); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.DB.AddGlossaryEntry(&entry)
+	if err != nil {
+		log.Printf("Error adding glossary entry: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entry.ID = id
+
+	json.NewEncoder(w).Encode(entry)
+}
+
+// HandleUpdateGlossaryEntry updates an existing glossary entry.
+func HandleUpdateGlossaryEntry(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var entry models.GlossaryEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if entry.ID <= 0 {
+		http.Error(w, "Missing entry id", http.StatusBadRequest)
+		return
+	}
+	if msg := validateGlossaryEntry(&entry); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.UpdateGlossaryEntry(&entry); err != nil {
+		log.Printf("Error updating glossary entry %d: %v", entry.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(entry)
+}
+
+// HandleDeleteGlossaryEntry deletes a glossary entry.
+func HandleDeleteGlossaryEntry(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid entry id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteGlossaryEntry(id); err != nil {
+		log.Printf("Error deleting glossary entry %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// validateGlossaryEntry normalizes an entry and returns an error message if it is invalid.
+func validateGlossaryEntry(entry *models.GlossaryEntry) string {
+	entry.SourceTerm = strings.TrimSpace(entry.SourceTerm)
+	entry.TargetTerm = strings.TrimSpace(entry.TargetTerm)
+	entry.SourceLang = strings.TrimSpace(entry.SourceLang)
+	entry.TargetLang = strings.TrimSpace(entry.TargetLang)
+
+	if entry.SourceTerm == "" {
+		return "Missing source term"
+	}
+	if entry.DoNotTranslate {
+		entry.TargetTerm = ""
+	} else if entry.TargetTerm == "" {
+		return "Missing target term"
+	}
+	return ""
+}
+
+// newFallbackTranslator returns the Google Translate fallback used when AI translation
+// is unavailable, with glossary terms still applied.
+func newFallbackTranslator(h *core.Handler) translation.Translator {
+	googleTranslator := translation.NewGoogleFreeTranslatorWithDB(h.DB)
+	entries, err := h.DB.GetGlossaryEntries()
+	if err != nil || len(entries) == 0 {
+		return googleTranslator
+	}
+	return translation.NewGlossaryTranslator(googleTranslator, entries)
+}
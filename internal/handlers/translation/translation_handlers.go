@@ -7,7 +7,6 @@ import (
 
 	"MrRSS/internal/aiusage"
 	"MrRSS/internal/handlers/core"
-	"MrRSS/internal/translation"
 )
 
 // HandleTranslateArticle translates an article's title.
@@ -47,7 +46,7 @@ func HandleTranslateArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 			log.Printf("AI usage limit reached, falling back to Google Translate")
 			limitReached = true
 			// Fallback to Google Translate
-			googleTranslator := translation.NewGoogleFreeTranslatorWithDB(h.DB)
+			googleTranslator := newFallbackTranslator(h)
 			translatedTitle, err = googleTranslator.Translate(req.Title, req.TargetLang)
 		} else {
 			// Apply rate limiting for AI requests
@@ -59,14 +58,9 @@ func HandleTranslateArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 			// If AI fails, fallback to Google Translate
 			if err != nil {
 				log.Printf("AI translation failed, falling back to Google Translate: %v", err)
-				googleTranslator := translation.NewGoogleFreeTranslatorWithDB(h.DB)
+				googleTranslator := newFallbackTranslator(h)
 				translatedTitle, err = googleTranslator.Translate(req.Title, req.TargetLang)
 			}
-
-			// Track AI usage only on success (whether AI or fallback)
-			if err == nil {
-				h.AITracker.TrackTranslation(req.Title, translatedTitle)
-			}
 		}
 	} else {
 		// Non-AI provider, no special handling needed
@@ -144,7 +138,7 @@ func HandleTranslateText(h *core.Handler, w http.ResponseWriter, r *http.Request
 		if h.AITracker.IsLimitReached() {
 			log.Printf("AI usage limit reached, falling back to Google Translate")
 			// Fallback to Google Translate
-			googleTranslator := translation.NewGoogleFreeTranslatorWithDB(h.DB)
+			googleTranslator := newFallbackTranslator(h)
 			translatedText, err = googleTranslator.Translate(req.Text, req.TargetLang)
 		} else {
 			// Apply rate limiting for AI requests
@@ -156,14 +150,9 @@ func HandleTranslateText(h *core.Handler, w http.ResponseWriter, r *http.Request
 			// If AI fails, fallback to Google Translate
 			if err != nil {
 				log.Printf("AI translation failed, falling back to Google Translate: %v", err)
-				googleTranslator := translation.NewGoogleFreeTranslatorWithDB(h.DB)
+				googleTranslator := newFallbackTranslator(h)
 				translatedText, err = googleTranslator.Translate(req.Text, req.TargetLang)
 			}
-
-			// Track AI usage only on success (whether AI or fallback)
-			if err == nil {
-				h.AITracker.TrackTranslation(req.Text, translatedText)
-			}
 		}
 	} else {
 		// Non-AI provider, no special handling needed
@@ -181,7 +170,7 @@ func HandleTranslateText(h *core.Handler, w http.ResponseWriter, r *http.Request
 	})
 }
 
-// HandleResetAIUsage resets the AI usage counter.
+// HandleResetAIUsage resets the AI usage counter and the per-profile counters.
 func HandleResetAIUsage(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -193,12 +182,17 @@ func HandleResetAIUsage(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.DB.ResetAIProfileUsage(); err != nil {
+		log.Printf("Error resetting AI profile usage: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
 
-// HandleGetAIUsage returns the current AI usage statistics.
+// HandleGetAIUsage returns the current AI usage and the total, daily and monthly limits.
 func HandleGetAIUsage(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -207,10 +201,18 @@ func HandleGetAIUsage(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 
 	usage, _ := h.AITracker.GetCurrentUsage()
 	limit, _ := h.AITracker.GetUsageLimit()
+	dailyUsage, _ := h.AITracker.GetDailyUsage()
+	dailyLimit, _ := h.AITracker.GetDailyLimit()
+	monthlyUsage, _ := h.AITracker.GetMonthlyUsage()
+	monthlyLimit, _ := h.AITracker.GetMonthlyLimit()
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"usage":         usage,
 		"limit":         limit,
+		"daily_usage":   dailyUsage,
+		"daily_limit":   dailyLimit,
+		"monthly_usage": monthlyUsage,
+		"monthly_limit": monthlyLimit,
 		"limit_reached": h.AITracker.IsLimitReached(),
 	})
 }
@@ -0,0 +1,159 @@
+package mdexport
+
+import (
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+)
+
+// localizeImages downloads the images of article content and points them to the
+// downloaded files, relative to the folder of the note. Images that cannot be
+// downloaded keep their remote URL.
+func (e *Exporter) localizeImages(content, articleURL, noteDir string, result *Result) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	base, _ := url.Parse(articleURL)
+
+	changed := false
+	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
+		src, ok := img.Attr("src")
+		if !ok || src == "" {
+			return
+		}
+		u, err := url.Parse(src)
+		if err != nil {
+			return
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return
+		}
+
+		cached := e.images.Exists(u.String())
+		if _, _, err := e.images.Get(u.String(), articleURL); err != nil {
+			log.Printf("Error downloading image %s for export: %v", u, err)
+			return
+		}
+		file, ok := e.images.CachedFile(u.String())
+		if !ok {
+			return
+		}
+		rel, err := filepath.Rel(noteDir, file)
+		if err != nil {
+			return
+		}
+		if !cached {
+			result.Images++
+		}
+		img.SetAttr("src", filepath.ToSlash(rel))
+		img.RemoveAttr("srcset")
+		changed = true
+	})
+	if !changed {
+		return content
+	}
+
+	localized, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return localized
+}
+
+// sanitizeFilename creates a safe filename from a title
+func sanitizeFilename(title string) string {
+	// Replace invalid filename characters
+	invalidChars := []string{"<", ">", ":", "\"", "|", "?", "*", "\\", "/"}
+	result := title
+
+	for _, char := range invalidChars {
+		result = strings.ReplaceAll(result, char, "_")
+	}
+
+	// Trim spaces and limit length
+	result = strings.TrimSpace(result)
+	if len(result) > 100 {
+		result = result[:100]
+	}
+
+	return result
+}
+
+// sanitizeTag creates a safe tag from a feed name or label
+func sanitizeTag(name string) string {
+	// Convert to lowercase, replace spaces with underscores
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	// Remove special characters
+	tag = strings.ReplaceAll(tag, "-", "_")
+	tag = strings.ReplaceAll(tag, ".", "_")
+	tag = strings.ReplaceAll(tag, ",", "")
+	tag = strings.ReplaceAll(tag, "#", "")
+	return tag
+}
+
+// htmlToMarkdown converts HTML to Markdown using html-to-markdown library
+func htmlToMarkdown(html string) string {
+	// Create a new converter with default plugins
+	converter := md.NewConverter("", true, nil)
+
+	// Convert HTML to Markdown
+	markdown, err := converter.ConvertString(html)
+	if err != nil {
+		// If conversion fails, return the original HTML with basic cleanup
+		return cleanWhitespace(removeHTMLTags(html))
+	}
+
+	// Clean up excessive whitespace
+	return cleanWhitespace(markdown)
+}
+
+// removeHTMLTags removes HTML tags (basic implementation)
+func removeHTMLTags(html string) string {
+	var result strings.Builder
+	inTag := false
+
+	for _, char := range html {
+		if char == '<' {
+			inTag = true
+		} else if char == '>' {
+			inTag = false
+		} else if !inTag {
+			result.WriteRune(char)
+		}
+	}
+
+	return result.String()
+}
+
+// cleanWhitespace removes excessive whitespace and empty lines
+func cleanWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	var cleaned []string
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		// Only keep non-empty lines or single empty lines between content
+		if trimmed != "" || (len(cleaned) > 0 && cleaned[len(cleaned)-1] != "") {
+			cleaned = append(cleaned, trimmed)
+		}
+	}
+
+	// Join with single newlines
+	result := strings.Join(cleaned, "\n")
+
+	// Remove multiple consecutive empty lines
+	for strings.Contains(result, "\n\n\n") {
+		result = strings.ReplaceAll(result, "\n\n\n", "\n\n")
+	}
+
+	return result
+}
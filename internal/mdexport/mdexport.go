@@ -0,0 +1,307 @@
+// Package mdexport exports articles as Markdown notes to a folder, such as an Obsidian
+// vault, a Logseq graph or a plain folder. Notes are rendered with user-editable
+// templates. Each export is recorded, so that exporting an article again updates its
+// note in place, and notes the user edited since are left alone.
+package mdexport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"MrRSS/internal/cache"
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils"
+)
+
+// Layout is the way notes are laid out in the export folder.
+type Layout string
+
+const (
+	// LayoutObsidian writes a note with YAML front matter per article
+	LayoutObsidian Layout = "obsidian"
+	// LayoutLogseq writes a page with Logseq properties per article, and links it from
+	// the journal page of the day it was published
+	LayoutLogseq Layout = "logseq"
+)
+
+// Options configures an export.
+type Options struct {
+	Dir            string // Export folder
+	Layout         Layout // Empty means LayoutObsidian
+	Template       string // Note template; empty uses the default of the layout
+	Filename       string // Filename template without extension, "/" makes subfolders; empty uses the default of the layout
+	DownloadImages bool   // Download images into the folder and link them relatively
+}
+
+// Note is the data the note and filename templates are executed with.
+type Note struct {
+	ID        int64
+	Title     string
+	URL       string
+	Feed      string
+	Published time.Time
+	Exported  time.Time // When the article was first exported
+	Tags      []string  // Labels of the article
+	Summary   string
+	Content   string // Article content as Markdown
+	Favorite  bool
+	ReadLater bool
+	Read      bool
+}
+
+// Result describes what an export wrote.
+type Result struct {
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Skipped   int      `json:"skipped"` // Notes edited since the last export
+	Images    int      `json:"images"`  // Images downloaded
+	Files     []string `json:"files"`   // Created and updated notes, relative to the folder
+}
+
+// Exporter exports articles to a folder.
+type Exporter struct {
+	db       *database.DB
+	dir      string
+	layout   Layout
+	note     *template.Template
+	filename *template.Template
+	images   *cache.MediaCache // Downloads images, nil unless enabled
+	content  utils.ContentFunc
+	taken    map[string]bool // Paths of the notes created by this exporter
+}
+
+// New creates an exporter. content, if not nil, gets the content of articles that have
+// no stored content.
+func New(db *database.DB, opts Options, content utils.ContentFunc) (*Exporter, error) {
+	if opts.Layout == "" {
+		opts.Layout = LayoutObsidian
+	}
+	if opts.Layout != LayoutObsidian && opts.Layout != LayoutLogseq {
+		return nil, fmt.Errorf("unknown layout %q", opts.Layout)
+	}
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("export folder is not configured")
+	}
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("export folder does not exist")
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("export folder is not a directory")
+	}
+
+	if opts.Template == "" {
+		opts.Template = DefaultTemplate(opts.Layout)
+	}
+	if opts.Filename == "" {
+		opts.Filename = DefaultFilename(opts.Layout)
+	}
+	note, err := template.New("note").Funcs(funcs).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note template: %w", err)
+	}
+	filename, err := template.New("filename").Funcs(funcs).Parse(opts.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename template: %w", err)
+	}
+
+	e := &Exporter{
+		db:       db,
+		dir:      dir,
+		layout:   opts.Layout,
+		note:     note,
+		filename: filename,
+		content:  content,
+		taken:    make(map[string]bool),
+	}
+	if opts.DownloadImages {
+		if e.images, err = cache.NewMediaCache(filepath.Join(dir, assetsDir(opts.Layout))); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Dir returns the absolute path of the export folder.
+func (e *Exporter) Dir() string {
+	return e.dir
+}
+
+// Export exports the articles, stopping at the first article that cannot be written.
+func (e *Exporter) Export(ctx context.Context, articles []database.ArticleText) (*Result, error) {
+	result := &Result{Files: []string{}}
+	for _, a := range articles {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := e.export(a, result); err != nil {
+			return result, fmt.Errorf("export %q: %w", a.Title, err)
+		}
+	}
+	return result, nil
+}
+
+// export writes the note of an article, unless it is unchanged or the user edited it.
+func (e *Exporter) export(a database.ArticleText, result *Result) error {
+	record, err := e.db.GetMarkdownExport(e.dir, a.ID)
+	if err != nil {
+		return err
+	}
+
+	note := Note{
+		ID:        a.ID,
+		Title:     a.Title,
+		URL:       a.URL,
+		Feed:      a.FeedTitle,
+		Published: a.PublishedAt,
+		Exported:  time.Now().Truncate(time.Second),
+		Tags:      a.Labels,
+		Summary:   a.Summary,
+		Favorite:  a.IsFavorite,
+		ReadLater: a.IsReadLater,
+		Read:      a.IsRead,
+	}
+	var rel string
+	if record != nil {
+		note.Exported, rel = record.ExportedAt.Local(), record.Path
+	} else if rel, err = e.newPath(note); err != nil {
+		return err
+	}
+	path := filepath.Join(e.dir, filepath.FromSlash(rel))
+
+	current, err := os.ReadFile(path)
+	exists := err == nil
+	if record != nil && exists && hash(current) != record.Hash {
+		result.Skipped++
+		return nil
+	}
+
+	content := a.Content
+	if content == "" && e.content != nil {
+		if content, err = e.content(a.ID); err != nil {
+			log.Printf("Error getting content of article %d for export: %v", a.ID, err)
+			content = ""
+		}
+	}
+	if content != "" {
+		if e.images != nil {
+			content = e.localizeImages(content, a.URL, filepath.Dir(path), result)
+		}
+		// Decode HTML entities first, then convert HTML to Markdown
+		note.Content = htmlToMarkdown(html.UnescapeString(content))
+	}
+
+	var buf bytes.Buffer
+	if err := e.note.Execute(&buf, note); err != nil {
+		return fmt.Errorf("execute note template: %w", err)
+	}
+	sum := hash(buf.Bytes())
+	if record != nil && exists && sum == record.Hash {
+		result.Unchanged++
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := e.db.SaveMarkdownExport(e.dir, database.MarkdownExport{ArticleID: a.ID, Path: rel, Hash: sum, ExportedAt: note.Exported}); err != nil {
+		return err
+	}
+	result.Files = append(result.Files, rel)
+
+	if record != nil {
+		result.Updated++
+		return nil
+	}
+	result.Created++
+	if e.layout == LayoutLogseq {
+		return e.linkFromJournal(note)
+	}
+	return nil
+}
+
+// newPath returns the path of a new note relative to the export folder, from the
+// filename template. Taken paths get a number appended.
+func (e *Exporter) newPath(note Note) (string, error) {
+	var buf bytes.Buffer
+	if err := e.filename.Execute(&buf, note); err != nil {
+		return "", fmt.Errorf("execute filename template: %w", err)
+	}
+	var segments []string
+	for _, segment := range strings.Split(buf.String(), "/") {
+		segment = sanitizeFilename(segment)
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		segments = append(segments, fmt.Sprintf("Article_%d", note.ID))
+	}
+
+	base := strings.Join(segments, "/")
+	rel := base + ".md"
+	for n := 2; e.taken[rel] || fileExists(filepath.Join(e.dir, filepath.FromSlash(rel))); n++ {
+		rel = fmt.Sprintf("%s (%d).md", base, n)
+	}
+	e.taken[rel] = true
+	return rel, nil
+}
+
+// linkFromJournal adds a link to the page of a note to the Logseq journal page of the
+// day it was published.
+func (e *Exporter) linkFromJournal(note Note) error {
+	path := filepath.Join(e.dir, "journals", note.Published.Local().Format("2006_01_02")+".md")
+	link := "[[" + note.Title + "]]"
+	journal, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if bytes.Contains(journal, []byte(link)) {
+		return nil
+	}
+
+	if len(journal) > 0 && !bytes.HasSuffix(journal, []byte("\n")) {
+		journal = append(journal, '\n')
+	}
+	journal = append(journal, "- "+link+"\n"...)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, journal, 0644)
+}
+
+// assetsDir returns the folder images are downloaded to, relative to the export folder.
+func assetsDir(layout Layout) string {
+	if layout == LayoutLogseq {
+		return "assets"
+	}
+	return "attachments"
+}
+
+// hash returns the hex SHA-256 hash of a note.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileExists reports whether a file exists at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,183 @@
+package mdexport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setup(t *testing.T) (*database.DB, int64) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom"})
+	article := &models.Article{FeedID: feedID, Title: "Go 1.24 is released", URL: "https://go.dev/blog/go1.24", PublishedAt: time.Date(2025, 2, 11, 12, 0, 0, 0, time.Local)}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles failed: %v", err)
+	}
+	db.UpdateArticleContent(article.ID, "<p>Go 1.24 brings <b>generic type aliases</b>.</p>")
+	db.SetArticleLabels(article.ID, []string{"Go Releases"}, "local")
+	return db, article.ID
+}
+
+func export(t *testing.T, db *database.DB, opts Options, id int64) *Result {
+	t.Helper()
+	e, err := New(db, opts, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	articles, err := db.GetArticleTextsByIDs([]int64{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Export(context.Background(), articles)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return result
+}
+
+func TestExportObsidian(t *testing.T) {
+	db, id := setup(t)
+	dir := t.TempDir()
+	opts := Options{Dir: dir, Filename: "{{.Feed}}/{{.Title}}"}
+
+	result := export(t, db, opts, id)
+	if result.Created != 1 || len(result.Files) != 1 || result.Files[0] != "Go Blog/Go 1.24 is released.md" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	path := filepath.Join(dir, "Go Blog", "Go 1.24 is released.md")
+	note, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`title: "Go 1.24 is released"`,
+		"tags: [rss, go_blog, go_releases]",
+		"**Source:** https://go.dev/blog/go1.24",
+		"Go 1.24 brings **generic type aliases**.",
+	} {
+		if !strings.Contains(string(note), want) {
+			t.Errorf("note lacks %q:\n%s", want, note)
+		}
+	}
+
+	// Exporting again leaves the note alone
+	if result := export(t, db, opts, id); result.Unchanged != 1 || result.Created != 0 {
+		t.Errorf("unexpected result of a second export: %+v", result)
+	}
+
+	// A changed article updates its note in place, even with a new title
+	db.Exec(`UPDATE articles SET title = 'Go 1.24' WHERE id = ?`, id)
+	if result := export(t, db, opts, id); result.Updated != 1 {
+		t.Errorf("unexpected result after a change: %+v", result)
+	}
+	note, _ = os.ReadFile(path)
+	if !strings.Contains(string(note), `title: "Go 1.24"`) {
+		t.Errorf("note was not updated:\n%s", note)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "Go Blog")); len(entries) != 1 {
+		t.Errorf("re-export duplicated the note: %v", entries)
+	}
+
+	// Notes the user edited are not overwritten
+	os.WriteFile(path, append(note, "My thoughts\n"...), 0644)
+	db.Exec(`UPDATE articles SET title = 'Go 1.24 released' WHERE id = ?`, id)
+	if result := export(t, db, opts, id); result.Skipped != 1 {
+		t.Errorf("unexpected result after an edit: %+v", result)
+	}
+	if note, _ := os.ReadFile(path); !strings.Contains(string(note), "My thoughts") {
+		t.Error("the edited note was overwritten")
+	}
+}
+
+func TestExportLogseq(t *testing.T) {
+	db, id := setup(t)
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "journals", "2025_02_11.md")
+	os.MkdirAll(filepath.Dir(journal), 0755)
+	os.WriteFile(journal, []byte("- Morning notes"), 0644)
+
+	result := export(t, db, Options{Dir: dir, Layout: LayoutLogseq}, id)
+	if result.Created != 1 || result.Files[0] != "pages/Go 1.24 is released.md" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "pages", "Go 1.24 is released.md"))
+	for _, want := range []string{"title:: Go 1.24 is released\n", "published:: [[Feb 11th, 2025]]\n", "tags:: rss, Go Releases\n", "- Go 1.24 brings"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("page lacks %q:\n%s", want, page)
+		}
+	}
+	if got, _ := os.ReadFile(journal); string(got) != "- Morning notes\n- [[Go 1.24 is released]]\n" {
+		t.Errorf("journal = %q", got)
+	}
+
+	// Updating the page does not link it again
+	db.Exec(`UPDATE articles SET summary = 'New' WHERE id = ?`, id)
+	db.Exec(`UPDATE articles SET content = '<p>Changed</p>' WHERE id = ?`, id)
+	export(t, db, Options{Dir: dir, Layout: LayoutLogseq}, id)
+	if got, _ := os.ReadFile(journal); strings.Count(string(got), "[[Go 1.24 is released]]") != 1 {
+		t.Errorf("journal = %q", got)
+	}
+}
+
+func TestExportDownloadsImages(t *testing.T) {
+	db, id := setup(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	t.Cleanup(server.Close)
+	db.Exec(`UPDATE articles SET content = ? WHERE id = ?`, `<p>Gopher</p><img src="`+server.URL+`/gopher" alt="Gopher">`, id)
+
+	dir := t.TempDir()
+	result := export(t, db, Options{Dir: dir, Filename: "Notes/{{.Title}}", DownloadImages: true}, id)
+	if result.Images != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	note, _ := os.ReadFile(filepath.Join(dir, "Notes", "Go 1.24 is released.md"))
+	if !strings.Contains(string(note), "![Gopher](../attachments/") {
+		t.Errorf("image is not linked relatively:\n%s", note)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "attachments", "*.png")); len(files) != 1 {
+		t.Errorf("downloaded images = %v", files)
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	db, _ := setup(t)
+	dir := t.TempDir()
+	for name, opts := range map[string]Options{
+		"missing folder": {Dir: filepath.Join(dir, "missing")},
+		"layout":         {Dir: dir, Layout: "roam"},
+		"template":       {Dir: dir, Template: "{{.Title"},
+		"filename":       {Dir: dir, Filename: "{{.Nope}"},
+	} {
+		if _, err := New(db, opts, nil); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
+
+func TestJournalTitle(t *testing.T) {
+	for day, want := range map[int]string{1: "Mar 1st, 2024", 2: "Mar 2nd, 2024", 3: "Mar 3rd, 2024", 11: "Mar 11th, 2024", 22: "Mar 22nd, 2024"} {
+		if got := journalTitle(time.Date(2024, 3, day, 12, 0, 0, 0, time.Local)); got != want {
+			t.Errorf("journalTitle(%d) = %q, want %q", day, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package mdexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// obsidianTemplate is the default note template of the Obsidian layout.
+const obsidianTemplate = `---
+title: {{yaml .Title}}
+source: {{yaml .URL}}
+feed: {{yaml .Feed}}
+published: {{yaml (date "2006-01-02T15:04:05Z07:00" .Published)}}
+tags: [rss, {{tag .Feed}}{{range .Tags}}, {{tag .}}{{end}}]
+---
+
+# {{.Title}}
+
+**Source:** {{.URL}}
+
+{{with .Content}}{{.}}
+
+{{end}}---
+**Added to Obsidian:** {{date "2006-01-02 15:04:05" .Exported}}
+**Article ID:** {{.ID}}
+`
+
+// logseqTemplate is the default page template of the Logseq layout. The title property
+// names the page the journal links to.
+const logseqTemplate = `title:: {{.Title}}
+source:: {{.URL}}
+feed:: [[{{.Feed}}]]
+published:: [[{{journal .Published}}]]
+tags:: rss{{range .Tags}}, {{.}}{{end}}
+
+- {{with .Content}}{{indent 2 .}}{{else}}[{{.Title}}]({{.URL}}){{end}}
+`
+
+// funcs are the functions available to the note and filename templates.
+var funcs = template.FuncMap{
+	"yaml":    yamlString,
+	"tag":     sanitizeTag,
+	"date":    func(layout string, t time.Time) string { return t.Format(layout) },
+	"join":    strings.Join,
+	"indent":  indent,
+	"journal": journalTitle,
+}
+
+// DefaultTemplate returns the default note template of a layout.
+func DefaultTemplate(layout Layout) string {
+	if layout == LayoutLogseq {
+		return logseqTemplate
+	}
+	return obsidianTemplate
+}
+
+// DefaultFilename returns the default filename template of a layout.
+func DefaultFilename(layout Layout) string {
+	if layout == LayoutLogseq {
+		return "pages/{{.Title}}"
+	}
+	return "{{.Title}}"
+}
+
+// yamlString quotes a string for YAML front matter.
+func yamlString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// indent indents all but the first line of s by n spaces, so that multi-line text
+// stays inside a Logseq block.
+func indent(n int, s string) string {
+	return strings.ReplaceAll(s, "\n", "\n"+strings.Repeat(" ", n))
+}
+
+// journalTitle returns the title of the Logseq journal page of a day, in Logseq's
+// default date format, such as "Jan 2nd, 2006".
+func journalTitle(t time.Time) string {
+	t = t.Local()
+	day := t.Day()
+	suffix := "th"
+	if day < 11 || day > 13 {
+		switch day % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%s %d%s, %d", t.Format("Jan"), day, suffix, t.Year())
+}
@@ -37,19 +37,194 @@ type Feed struct {
 }
 
 type Article struct {
+	ID              int64            `json:"id"`
+	FeedID          int64            `json:"feed_id"`
+	Title           string           `json:"title"`
+	URL             string           `json:"url"`
+	ImageURL        string           `json:"image_url"`
+	AudioURL        string           `json:"audio_url"`
+	VideoURL        string           `json:"video_url"` // YouTube video URL for embedded player
+	PublishedAt     time.Time        `json:"published_at"`
+	IsRead          bool             `json:"is_read"`
+	IsFavorite      bool             `json:"is_favorite"`
+	IsHidden        bool             `json:"is_hidden"`
+	IsReadLater     bool             `json:"is_read_later"`
+	FeedTitle       string           `json:"feed_title,omitempty"` // Joined field
+	TranslatedTitle string           `json:"translated_title"`
+	Summary         string           `json:"summary"`                   // Cached AI-generated summary
+	Fingerprint     uint64           `json:"-"`                         // SimHash of title and content, 0 if unknown
+	ClusterID       int64            `json:"cluster_id,omitempty"`      // ID of the first article of its near-duplicate cluster
+	DuplicateCount  int              `json:"duplicate_count,omitempty"` // Other articles in the cluster
+	Labels          []string         `json:"labels,omitempty"`          // Labels assigned by the classifier
+	Content         string           `json:"-"`                         // Feed content, only set while fetching
+	Keywords        []ArticleKeyword `json:"-"`                         // Extracted keywords, only set while fetching
+	WordCount       int              `json:"-"`                         // Words of the feed content, set while fetching
+	Score           float64          `json:"score,omitempty"`           // Importance from the ranking model
+	ScoreReason     string           `json:"score_reason,omitempty"`    // Explanation of the score
+}
+
+// ArticleKeyword is a keyphrase or named-entity-like term extracted from an article.
+type ArticleKeyword struct {
+	Term   string  `json:"term"`   // Lowercase form used to compare keywords across articles
+	Text   string  `json:"text"`   // Spelling used in the article
+	Score  float64 `json:"score"`  // Importance in the article, 1 for the best keyword
+	Entity bool    `json:"entity"` // Name or acronym rather than a common phrase
+}
+
+// TrendingKeyword is a keyword with the number of articles mentioning it in a time
+// window and in the window before it.
+type TrendingKeyword struct {
+	Term          string  `json:"term"`
+	Text          string  `json:"text"`
+	Entity        bool    `json:"entity"`
+	Count         int     `json:"count"`          // Articles in the window
+	Feeds         int     `json:"feeds"`          // Feeds of those articles
+	PreviousCount int     `json:"previous_count"` // Articles in the preceding window of the same length
+	Score         float64 `json:"score"`          // Count weighted by its growth over the previous window
+}
+
+// ScoreReason is a feature of an article and its contribution to the article's
+// importance score.
+type ScoreReason struct {
+	Kind         string  `json:"kind"` // feed, label, keyword, length or similarity
+	Name         string  `json:"name"`
+	Contribution float64 `json:"contribution"`
+}
+
+// ArticleScore is the importance of an article according to the ranking model.
+type ArticleScore struct {
+	ArticleID   int64         `json:"article_id"`
+	Score       float64       `json:"score"`
+	Explanation string        `json:"explanation"` // e.g. "boosted because: feed X, keyword Y"
+	Reasons     []ScoreReason `json:"reasons"`     // Largest contributions first
+	ScoredAt    time.Time     `json:"scored_at"`
+}
+
+// LabelCount is a label with the number of articles assigned to it.
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// GlossaryEntry is a translation glossary term or a do-not-translate term.
+type GlossaryEntry struct {
+	ID             int64     `json:"id"`
+	SourceTerm     string    `json:"source_term"`
+	TargetTerm     string    `json:"target_term"`      // Ignored for do-not-translate terms
+	SourceLang     string    `json:"source_lang"`      // Empty matches any source language
+	TargetLang     string    `json:"target_lang"`      // Empty matches any target language
+	CaseSensitive  bool      `json:"case_sensitive"`   // Whether the source term must match case exactly
+	DoNotTranslate bool      `json:"do_not_translate"` // Keep the source term unchanged in translations
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// AIProfile is a named AI provider configuration that translation, summary and chat
+// can each select independently.
+type AIProfile struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Backend       string    `json:"backend"` // Empty or "auto" detects the backend from Endpoint
+	Endpoint      string    `json:"endpoint"`
+	Model         string    `json:"model"`
+	APIKey        string    `json:"api_key,omitempty"`
+	CustomHeaders string    `json:"custom_headers"` // JSON object of extra request headers
+	Temperature   float64   `json:"temperature"`    // 0 keeps each feature's default
+	MaxTokens     int       `json:"max_tokens"`     // 0 keeps each feature's default
+	ContextWindow int       `json:"context_window"` // Prompt budget in tokens; 0 uses the default
+	UsageTokens   int64     `json:"usage_tokens"`   // Tokens used by requests made with this profile
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AIUsageRecord is one AI request in the usage ledger.
+type AIUsageRecord struct {
+	ID               int64     `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	Feature          string    `json:"feature"`    // "translate", "summary", "chat", "embedding" or "other"
+	ProfileID        int64     `json:"profile_id"` // 0 for the global AI settings
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	Estimated        bool      `json:"estimated"`  // True if the provider did not report token counts
+	Cost             float64   `json:"cost"`       // From the price table at the time of the request
+	ArticleID        int64     `json:"article_id"` // 0 if the request was not about a single article
+}
+
+// ChatSession is a persisted AI chat conversation, usually about one article.
+type ChatSession struct {
 	ID              int64     `json:"id"`
-	FeedID          int64     `json:"feed_id"`
+	ArticleID       int64     `json:"article_id"` // 0 if the chat is not about a single article
+	ArticleTitle    string    `json:"article_title,omitempty"`
 	Title           string    `json:"title"`
-	URL             string    `json:"url"`
-	ImageURL        string    `json:"image_url"`
-	AudioURL        string    `json:"audio_url"`
-	VideoURL        string    `json:"video_url"` // YouTube video URL for embedded player
-	PublishedAt     time.Time `json:"published_at"`
-	IsRead          bool      `json:"is_read"`
-	IsFavorite      bool      `json:"is_favorite"`
-	IsHidden        bool      `json:"is_hidden"`
-	IsReadLater     bool      `json:"is_read_later"`
-	FeedTitle       string    `json:"feed_title,omitempty"` // Joined field
-	TranslatedTitle string    `json:"translated_title"`
-	Summary         string    `json:"summary"` // Cached AI-generated summary
+	Summary         string    `json:"summary,omitempty"` // Summary of the turns up to SummarizedUntil
+	SummarizedUntil int64     `json:"summarized_until"`  // ID of the last message covered by Summary
+	MessageCount    int       `json:"message_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ChatMessage is one message of a chat session.
+type ChatMessage struct {
+	ID        int64     `json:"id"`
+	SessionID int64     `json:"session_id"`
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Digest is a generated summary of many articles over a time window, grouped into clusters.
+type Digest struct {
+	ID           int64           `json:"id"`
+	Title        string          `json:"title"`
+	Period       string          `json:"period"`        // "daily", "weekly" or "custom"
+	Scope        string          `json:"scope"`         // Human-readable description of the selected articles
+	WindowStart  time.Time       `json:"window_start"`  // Start of the published_at window
+	WindowEnd    time.Time       `json:"window_end"`    // End of the published_at window
+	Provider     string          `json:"provider"`      // "ai" or "local"
+	UsedFallback bool            `json:"used_fallback"` // Some AI clusters were summarized locally
+	ArticleCount int             `json:"article_count"`
+	Clusters     []DigestCluster `json:"clusters,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// DigestCluster is a group of related articles in a digest.
+// The summary cites articles as [#ID].
+type DigestCluster struct {
+	Title    string          `json:"title"`
+	Summary  string          `json:"summary"`
+	Articles []DigestArticle `json:"articles"`
+}
+
+// DigestArticle is a snapshot of an article cited by a digest, kept so digests survive article cleanup.
+type DigestArticle struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	FeedTitle string    `json:"feed_title"`
+	Published time.Time `json:"published_at"`
+}
+
+// SummaryQueueItem is an article waiting for background summarization.
+type SummaryQueueItem struct {
+	ArticleID int64     `json:"article_id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"` // "pending", "running" or "failed"
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OPMLList is a subscription to a remote OPML file, whose feeds are kept subscribed.
+type OPMLList struct {
+	ID            int64      `json:"id"`
+	URL           string     `json:"url"`
+	Title         string     `json:"title"`
+	Category      string     `json:"category"`       // Category the feeds go in, with the categories of the file below it
+	RemoveMissing bool       `json:"remove_missing"` // Unsubscribe from feeds the file no longer lists
+	ETag          string     `json:"-"`              // Validators of the last fetch, for conditional requests
+	LastModified  string     `json:"-"`
+	LastChecked   *time.Time `json:"last_checked,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	FeedIDs       []int64    `json:"feed_ids"` // Feeds the list added, filled by GetOPMLLists
+	CreatedAt     time.Time  `json:"created_at"`
 }
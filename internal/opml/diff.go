@@ -0,0 +1,220 @@
+package opml
+
+import (
+	"fmt"
+	"strings"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+// Status of a feed of an import compared to the subscriptions.
+const (
+	StatusNew       = "new"       // Not subscribed yet
+	StatusDuplicate = "duplicate" // Subscribed under the same normalized URL
+)
+
+// Strategy is how an import treats feeds that are already subscribed.
+type Strategy string
+
+const (
+	StrategySkip      Strategy = "skip"      // Leave them as they are
+	StrategyOverwrite Strategy = "overwrite" // Take title, homepage and description from the file
+	StrategyMove      Strategy = "move"      // Move them to the category of the file
+)
+
+// Valid reports whether s is a known strategy.
+func (s Strategy) Valid() bool {
+	return s == StrategySkip || s == StrategyOverwrite || s == StrategyMove
+}
+
+// DiffEntry is a feed of an import and the subscription it matches, if any.
+type DiffEntry struct {
+	Feed             models.Feed `json:"feed"`
+	Status           string      `json:"status"`
+	ExistingID       int64       `json:"existing_id,omitempty"`
+	ExistingTitle    string      `json:"existing_title,omitempty"`
+	ExistingCategory string      `json:"existing_category,omitempty"`
+	TitleChanged     bool        `json:"title_changed"`
+	CategoryChanged  bool        `json:"category_changed"`
+}
+
+// DiffSummary counts the entries of a diff.
+type DiffSummary struct {
+	New             int `json:"new"`
+	Duplicate       int `json:"duplicate"`
+	TitleChanged    int `json:"title_changed"`
+	CategoryChanged int `json:"category_changed"`
+	Missing         int `json:"missing"`
+}
+
+// Diff compares the feeds of an import to the subscriptions.
+type Diff struct {
+	Feeds   []DiffEntry   `json:"feeds"`
+	Missing []models.Feed `json:"missing"` // Subscriptions the import lacks
+	Summary DiffSummary   `json:"summary"`
+}
+
+// Compare compares imported feeds to the existing subscriptions. Feeds match by
+// normalized URL, and feeds the import lists twice are only compared once.
+func Compare(feeds, existing []models.Feed) *Diff {
+	byURL := make(map[string]models.Feed, len(existing))
+	for _, f := range existing {
+		byURL[utils.NormalizeURLForComparison(f.URL)] = f
+	}
+
+	diff := &Diff{Feeds: []DiffEntry{}, Missing: []models.Feed{}}
+	seen := make(map[string]bool)
+	for _, f := range feeds {
+		key := utils.NormalizeURLForComparison(f.URL)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		entry := DiffEntry{Feed: f, Status: StatusNew}
+		if e, ok := byURL[key]; ok {
+			entry.Status = StatusDuplicate
+			entry.ExistingID = e.ID
+			entry.ExistingTitle = e.Title
+			entry.ExistingCategory = e.Category
+			entry.TitleChanged = strings.TrimSpace(f.Title) != "" && strings.TrimSpace(f.Title) != e.Title
+			entry.CategoryChanged = f.Category != e.Category
+			diff.Summary.Duplicate++
+			if entry.TitleChanged {
+				diff.Summary.TitleChanged++
+			}
+			if entry.CategoryChanged {
+				diff.Summary.CategoryChanged++
+			}
+		} else {
+			diff.Summary.New++
+		}
+		diff.Feeds = append(diff.Feeds, entry)
+	}
+
+	for _, f := range existing {
+		if !seen[utils.NormalizeURLForComparison(f.URL)] {
+			diff.Missing = append(diff.Missing, f)
+		}
+	}
+	diff.Summary.Missing = len(diff.Missing)
+	return diff
+}
+
+// ApplyOptions controls how Apply merges an import.
+type ApplyOptions struct {
+	Strategy Strategy
+	Remove   []int64 // Subscriptions to delete, usually from the missing ones of a diff
+}
+
+// ApplyResult counts what Apply changed.
+type ApplyResult struct {
+	Added    int     `json:"added"`
+	Updated  int     `json:"updated"`
+	Skipped  int     `json:"skipped"`
+	Removed  int     `json:"removed"`
+	AddedIDs []int64 `json:"-"`
+}
+
+// Apply subscribes to the new feeds of an import and treats the subscribed ones by
+// the strategy. New feeds go after the feeds already in their category, in the order
+// of the import. Subscriptions to remove are kept if the import lists them.
+func Apply(db *database.DB, feeds []models.Feed, opts ApplyOptions) (*ApplyResult, error) {
+	existing, err := db.GetFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("get feeds: %w", err)
+	}
+	byURL := make(map[string]models.Feed, len(existing))
+	for _, f := range existing {
+		byURL[utils.NormalizeURLForComparison(f.URL)] = f
+	}
+
+	result := &ApplyResult{}
+	imported := make(map[int64]bool)
+	seen := make(map[string]bool)
+	for _, f := range feeds {
+		key := utils.NormalizeURLForComparison(f.URL)
+		if key == "" || seen[key] {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+
+		e, ok := byURL[key]
+		if !ok {
+			if strings.TrimSpace(f.Title) == "" {
+				f.Title = "Untitled Feed"
+			}
+			f.ID, f.Position = 0, 0
+			id, err := db.AddFeed(&f)
+			if err != nil {
+				return result, fmt.Errorf("add feed %s: %w", f.URL, err)
+			}
+			result.Added++
+			result.AddedIDs = append(result.AddedIDs, id)
+			continue
+		}
+		imported[e.ID] = true
+
+		changed, err := update(db, e, f, opts.Strategy)
+		if err != nil {
+			return result, fmt.Errorf("update feed %s: %w", e.URL, err)
+		}
+		if changed {
+			result.Updated++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	current := make(map[int64]bool, len(existing))
+	for _, f := range existing {
+		current[f.ID] = true
+	}
+	for _, id := range opts.Remove {
+		if !current[id] || imported[id] {
+			continue
+		}
+		if err := db.DeleteFeed(id); err != nil {
+			return result, fmt.Errorf("delete feed %d: %w", id, err)
+		}
+		delete(current, id)
+		result.Removed++
+	}
+	return result, nil
+}
+
+// update applies a strategy to a subscribed feed and reports whether it changed.
+func update(db *database.DB, existing, imported models.Feed, strategy Strategy) (bool, error) {
+	switch strategy {
+	case StrategyOverwrite:
+		merged := existing
+		if title := strings.TrimSpace(imported.Title); title != "" {
+			merged.Title = title
+		}
+		if imported.Link != "" {
+			merged.Link = imported.Link
+		}
+		if imported.Description != "" {
+			merged.Description = imported.Description
+		}
+		if merged.Title == existing.Title && merged.Link == existing.Link && merged.Description == existing.Description {
+			return false, nil
+		}
+		// Only the details change; the refresh state and the other settings stay
+		return true, db.UpdateFeedDetails(existing.ID, merged.Title, merged.Link, merged.Description)
+
+	case StrategyMove:
+		if imported.Category == existing.Category {
+			return false, nil
+		}
+		position, err := db.GetNextPositionInCategory(imported.Category)
+		if err != nil {
+			return false, err
+		}
+		return true, db.UpdateFeedPosition(existing.ID, imported.Category, position)
+	}
+	return false, nil
+}
@@ -0,0 +1,112 @@
+package opml
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setupDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCompare(t *testing.T) {
+	existing := []models.Feed{
+		{ID: 1, Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Tech"},
+		{ID: 2, Title: "Hacker News", URL: "https://news.ycombinator.com/rss", Category: "News"},
+		{ID: 3, Title: "Old", URL: "https://example.com/old", Category: "News"},
+	}
+	feeds := []models.Feed{
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom?utm_source=opml", Category: "Tech"},
+		{Title: "HN", URL: "https://news.ycombinator.com/rss", Category: "Tech"},
+		{Title: "HN again", URL: "https://news.ycombinator.com/rss"},
+		{Title: "New", URL: "https://example.com/new"},
+	}
+
+	diff := Compare(feeds, existing)
+	if len(diff.Feeds) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(diff.Feeds), diff.Feeds)
+	}
+	goBlog, hn, added := diff.Feeds[0], diff.Feeds[1], diff.Feeds[2]
+	if goBlog.Status != StatusDuplicate || goBlog.ExistingID != 1 || goBlog.TitleChanged || goBlog.CategoryChanged {
+		t.Errorf("unexpected entry for an unchanged feed: %+v", goBlog)
+	}
+	if hn.Status != StatusDuplicate || !hn.TitleChanged || !hn.CategoryChanged || hn.ExistingCategory != "News" {
+		t.Errorf("unexpected entry for a changed feed: %+v", hn)
+	}
+	if added.Status != StatusNew || added.ExistingID != 0 {
+		t.Errorf("unexpected entry for a new feed: %+v", added)
+	}
+	if len(diff.Missing) != 1 || diff.Missing[0].ID != 3 {
+		t.Errorf("unexpected missing feeds: %+v", diff.Missing)
+	}
+
+	want := DiffSummary{New: 1, Duplicate: 2, TitleChanged: 1, CategoryChanged: 1, Missing: 1}
+	if diff.Summary != want {
+		t.Errorf("summary = %+v, want %+v", diff.Summary, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	feeds := []models.Feed{
+		{Title: "HN", URL: "https://news.ycombinator.com/rss", Category: "Tech", Link: "https://news.ycombinator.com/"},
+		{Title: "New", URL: "https://example.com/new", Category: "Tech"},
+	}
+
+	tests := []struct {
+		strategy     Strategy
+		wantTitle    string
+		wantCategory string
+		wantUpdated  int
+	}{
+		{StrategySkip, "Hacker News", "News", 0},
+		{StrategyOverwrite, "HN", "News", 1},
+		{StrategyMove, "Hacker News", "Tech", 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			db := setupDB(t)
+			hnID, _ := db.AddFeed(&models.Feed{Title: "Hacker News", URL: "https://news.ycombinator.com/rss", Category: "News", RefreshInterval: 30})
+			lastUpdated := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+			db.Exec(`UPDATE feeds SET last_updated = ? WHERE id = ?`, lastUpdated, hnID)
+			oldID, _ := db.AddFeed(&models.Feed{Title: "Old", URL: "https://example.com/old", Category: "News"})
+			keptID, _ := db.AddFeed(&models.Feed{Title: "Kept", URL: "https://example.com/kept", Category: "News"})
+
+			result, err := Apply(db, feeds, ApplyOptions{Strategy: tt.strategy, Remove: []int64{oldID, hnID}})
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if result.Added != 1 || result.Updated != tt.wantUpdated || result.Removed != 1 || len(result.AddedIDs) != 1 {
+				t.Errorf("unexpected result: %+v", result)
+			}
+
+			hn, err := db.GetFeedByID(hnID)
+			if err != nil {
+				t.Fatalf("the imported feed was removed: %v", err)
+			}
+			if hn.Title != tt.wantTitle || hn.Category != tt.wantCategory {
+				t.Errorf("feed after import = %q in %q, want %q in %q", hn.Title, hn.Category, tt.wantTitle, tt.wantCategory)
+			}
+			if !hn.LastUpdated.Equal(lastUpdated) || hn.RefreshInterval != 30 {
+				t.Errorf("import reset the refresh state: last updated %v, interval %d", hn.LastUpdated, hn.RefreshInterval)
+			}
+			if _, err := db.GetFeedByID(oldID); err == nil {
+				t.Error("the missing feed was not removed")
+			}
+			if _, err := db.GetFeedByID(keptID); err != nil {
+				t.Error("a feed that was not selected for removal was removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package opml
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"MrRSS/internal/models"
+)
+
+// Namespace is the namespace of the outline attributes for the feed options OPML has
+// no attributes for. Documents bind it to the mrrss prefix.
+const Namespace = "https://github.com/WCY-dt/MrRSS/opml"
+
+// namespacePrefix is the prefix Generate binds Namespace to
+const namespacePrefix = "mrrss"
+
+// extensionAttrs returns the mrrss: attributes for the options of a feed. Options
+// with their default value are left out, and so is the position, which the order of the
+// outlines carries.
+func extensionAttrs(f models.Feed) []xml.Attr {
+	var attrs []xml.Attr
+	add := func(name, value string) {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: namespacePrefix + ":" + name}, Value: value})
+	}
+
+	if f.ScriptPath != "" {
+		add("scriptPath", f.ScriptPath)
+	}
+	if f.HideFromTimeline {
+		add("hideFromTimeline", "true")
+	}
+	if f.ProxyEnabled {
+		add("proxyEnabled", "true")
+	}
+	if f.ProxyURL != "" {
+		add("proxyUrl", f.ProxyURL)
+	}
+	if f.RefreshInterval != 0 {
+		add("refreshInterval", strconv.Itoa(f.RefreshInterval))
+	}
+	if f.IsImageMode {
+		add("imageMode", "true")
+	}
+	if f.ArticleViewMode != "" && f.ArticleViewMode != "global" {
+		add("articleViewMode", f.ArticleViewMode)
+	}
+	if f.AutoExpandContent != "" && f.AutoExpandContent != "global" {
+		add("autoExpandContent", f.AutoExpandContent)
+	}
+	return attrs
+}
+
+// applyExtensionAttrs sets the options of a feed from the mrrss: attributes of its
+// outline. Other attributes and invalid values are ignored.
+func applyExtensionAttrs(f *models.Feed, attrs []xml.Attr) {
+	for _, attr := range attrs {
+		// Documents that use the prefix without declaring it leave it as the namespace
+		if attr.Name.Space != Namespace && attr.Name.Space != namespacePrefix {
+			continue
+		}
+
+		value := attr.Value
+		switch attr.Name.Local {
+		case "scriptPath":
+			f.ScriptPath = value
+		case "hideFromTimeline":
+			f.HideFromTimeline, _ = strconv.ParseBool(value)
+		case "proxyEnabled":
+			f.ProxyEnabled, _ = strconv.ParseBool(value)
+		case "proxyUrl":
+			f.ProxyURL = value
+		case "refreshInterval":
+			if n, err := strconv.Atoi(value); err == nil && n >= -1 {
+				f.RefreshInterval = n
+			}
+		case "imageMode":
+			f.IsImageMode, _ = strconv.ParseBool(value)
+		case "articleViewMode":
+			if value == "global" || value == "webpage" || value == "rendered" {
+				f.ArticleViewMode = value
+			}
+		case "autoExpandContent":
+			if value == "global" || value == "enabled" || value == "disabled" {
+				f.AutoExpandContent = value
+			}
+		}
+	}
+}
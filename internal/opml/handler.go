@@ -1,6 +1,7 @@
 package opml
 
 import (
+	"MrRSS/internal/feed"
 	"MrRSS/internal/models"
 	"MrRSS/internal/utils"
 	"bytes"
@@ -10,17 +11,20 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type OPML struct {
 	XMLName xml.Name `xml:"opml"`
 	Version string   `xml:"version,attr"`
+	XMLNS   string   `xml:"xmlns:mrrss,attr,omitempty"` // Binds the mrrss prefix when generating
 	Head    Head     `xml:"head"`
 	Body    Body     `xml:"body"`
 }
 
 type Head struct {
-	Title string `xml:"title"`
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
 }
 
 type Body struct {
@@ -31,25 +35,27 @@ type Body struct {
 // Different OPML exporters use different case for attributes (xmlUrl vs xmlurl vs XmlUrl)
 type Outline struct {
 	Text     string     `xml:"text,attr"`
-	Title    string     `xml:"title,attr"`
-	Type     string     `xml:"type,attr"`
-	XMLURL   string     `xml:"xmlUrl,attr"`
-	HTMLURL  string     `xml:"htmlUrl,attr"`
+	Title    string     `xml:"title,attr,omitempty"`
+	Type     string     `xml:"type,attr,omitempty"`
+	XMLURL   string     `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string     `xml:"htmlUrl,attr,omitempty"`
 	Outlines []*Outline `xml:"outline"` // Nested outlines
 	// Additional attributes for compatibility with various OPML formats
-	Description string `xml:"description,attr"`
-	Category    string `xml:"category,attr"`
+	Description string `xml:"description,attr,omitempty"`
+	Category    string `xml:"category,attr,omitempty"`
 	// FreshRSS XPath extension attributes
-	XPathItem           string `xml:"xPathItem,attr"`
-	XPathItemTitle      string `xml:"xPathItemTitle,attr"`
-	XPathItemContent    string `xml:"xPathItemContent,attr"`
-	XPathItemUri        string `xml:"xPathItemUri,attr"`
-	XPathItemAuthor     string `xml:"xPathItemAuthor,attr"`
-	XPathItemTimestamp  string `xml:"xPathItemTimestamp,attr"`
-	XPathItemTimeFormat string `xml:"xPathItemTimeFormat,attr"`
-	XPathItemThumbnail  string `xml:"xPathItemThumbnail,attr"`
-	XPathItemCategories string `xml:"xPathItemCategories,attr"`
-	XPathItemUid        string `xml:"xPathItemUid,attr"`
+	XPathItem           string `xml:"xPathItem,attr,omitempty"`
+	XPathItemTitle      string `xml:"xPathItemTitle,attr,omitempty"`
+	XPathItemContent    string `xml:"xPathItemContent,attr,omitempty"`
+	XPathItemUri        string `xml:"xPathItemUri,attr,omitempty"`
+	XPathItemAuthor     string `xml:"xPathItemAuthor,attr,omitempty"`
+	XPathItemTimestamp  string `xml:"xPathItemTimestamp,attr,omitempty"`
+	XPathItemTimeFormat string `xml:"xPathItemTimeFormat,attr,omitempty"`
+	XPathItemThumbnail  string `xml:"xPathItemThumbnail,attr,omitempty"`
+	XPathItemCategories string `xml:"xPathItemCategories,attr,omitempty"`
+	XPathItemUid        string `xml:"xPathItemUid,attr,omitempty"`
+	// All other attributes, among them the mrrss: feed options
+	Extension []xml.Attr `xml:",any,attr"`
 }
 
 // normalizeOPMLAttributes normalizes attribute names in OPML content to handle
@@ -73,7 +79,19 @@ func normalizeOPMLAttributes(content []byte) []byte {
 	return result
 }
 
+// Parse reads the feeds of an OPML file. Files that are not well-formed XML are
+// scanned for feed URLs instead.
 func Parse(r io.Reader) ([]models.Feed, error) {
+	return parse(r, true)
+}
+
+// ParseStrict reads the feeds of an OPML file like Parse, but fails on files that are
+// not well-formed XML rather than guessing their feeds.
+func ParseStrict(r io.Reader) ([]models.Feed, error) {
+	return parse(r, false)
+}
+
+func parse(r io.Reader, lenient bool) ([]models.Feed, error) {
 	// Read all content to handle BOM
 	content, err := io.ReadAll(r)
 	if err != nil {
@@ -109,6 +127,9 @@ func Parse(r io.Reader) ([]models.Feed, error) {
 
 	if err := decoder.Decode(&doc); err != nil {
 		log.Printf("OPML Parse: Decode error: %v", err)
+		if !lenient {
+			return nil, err
+		}
 		// Try fallback parsing for malformed OPML
 		feeds := fallbackParse(content)
 		if len(feeds) > 0 {
@@ -136,12 +157,19 @@ func Parse(r io.Reader) ([]models.Feed, error) {
 				if o.Category != "" {
 					feedCategory = strings.TrimSpace(o.Category)
 				}
-				feeds = append(feeds, models.Feed{
-					Title:    title,
-					URL:      xmlURL,
-					Category: feedCategory,
+				// Plain feeds have no type, while OPML 2.0 marks them as "rss"
+				feedType := strings.TrimSpace(o.Type)
+				if strings.EqualFold(feedType, "rss") {
+					feedType = ""
+				}
+				f := models.Feed{
+					Title:       title,
+					URL:         xmlURL,
+					Link:        strings.TrimSpace(o.HTMLURL),
+					Description: strings.TrimSpace(o.Description),
+					Category:    feedCategory,
 					// XPath support
-					Type:                o.Type,
+					Type:                feedType,
 					XPathItem:           o.XPathItem,
 					XPathItemTitle:      o.XPathItemTitle,
 					XPathItemContent:    o.XPathItemContent,
@@ -152,7 +180,9 @@ func Parse(r io.Reader) ([]models.Feed, error) {
 					XPathItemThumbnail:  o.XPathItemThumbnail,
 					XPathItemCategories: o.XPathItemCategories,
 					XPathItemUid:        o.XPathItemUid,
-				})
+				}
+				applyExtensionAttrs(&f, o.Extension)
+				feeds = append(feeds, f)
 			}
 
 			newCategory := category
@@ -228,15 +258,24 @@ func fallbackParse(content []byte) []models.Feed {
 	return feeds
 }
 
+// Generate writes feeds as an OPML 2.0 document, with categories as nested outlines in
+// the order of the feeds. The feed options OPML has no attributes for are written as
+// mrrss: attributes, which Parse reads back and other readers ignore.
 func Generate(feeds []models.Feed) ([]byte, error) {
 	doc := OPML{
-		Version: "1.0",
+		Version: "2.0",
+		XMLNS:   Namespace,
 		Head: Head{
-			Title: "MrRSS Subscriptions",
+			Title:       "MrRSS Subscriptions",
+			DateCreated: time.Now().Format(time.RFC1123Z),
 		},
 	}
 
 	for _, f := range feeds {
+		// Local feeds hold articles the app added itself and have no URL to subscribe to
+		if feed.IsLocal(f) {
+			continue
+		}
 		currentOutlines := &doc.Body.Outlines
 
 		if f.Category != "" {
@@ -260,11 +299,17 @@ func Generate(feeds []models.Feed) ([]byte, error) {
 			}
 		}
 
+		feedType := f.Type
+		if feedType == "" {
+			feedType = "rss"
+		}
 		*currentOutlines = append(*currentOutlines, &Outline{
-			Text:   f.Title,
-			Title:  f.Title,
-			Type:   f.Type,
-			XMLURL: f.URL,
+			Text:        f.Title,
+			Title:       f.Title,
+			Type:        feedType,
+			XMLURL:      f.URL,
+			HTMLURL:     f.Link,
+			Description: f.Description,
 			// XPath support
 			XPathItem:           f.XPathItem,
 			XPathItemTitle:      f.XPathItemTitle,
@@ -276,8 +321,13 @@ func Generate(feeds []models.Feed) ([]byte, error) {
 			XPathItemThumbnail:  f.XPathItemThumbnail,
 			XPathItemCategories: f.XPathItemCategories,
 			XPathItemUid:        f.XPathItemUid,
+			Extension:           extensionAttrs(f),
 		})
 	}
 
-	return xml.MarshalIndent(doc, "", "  ")
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
 }
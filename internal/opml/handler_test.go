@@ -49,6 +49,7 @@ func TestGenerate(t *testing.T) {
 	feeds := []models.Feed{
 		{Title: "Feed 1", URL: "http://feed1.com/rss", Category: "Cat1"},
 		{Title: "Feed 2", URL: "http://feed2.com/rss", Category: ""},
+		{Title: "Imported", URL: "local://imported", Category: ""},
 	}
 
 	data, err := Generate(feeds)
@@ -63,4 +64,87 @@ func TestGenerate(t *testing.T) {
 	if !strings.Contains(xmlStr, `xmlUrl="http://feed2.com/rss"`) {
 		t.Error("Generated XML missing Feed 2 URL")
 	}
+	if strings.Contains(xmlStr, "local://") {
+		t.Error("Generated XML has a local feed")
+	}
+}
+
+func TestGenerateParseRoundTrip(t *testing.T) {
+	feeds := []models.Feed{
+		{
+			Title: "Script Feed", URL: "script://news.py", Link: "https://example.com/", Description: "Scraped & cleaned",
+			Category: "Tech/Go", ScriptPath: "news.py", HideFromTimeline: true,
+			ProxyEnabled: true, ProxyURL: "socks5://127.0.0.1:1080", RefreshInterval: -1, IsImageMode: true,
+			ArticleViewMode: "webpage", AutoExpandContent: "disabled",
+		},
+		{
+			Title: "XPath Feed", URL: "https://example.com/list", Category: "Tech/Go",
+			Type: "HTML+XPath", XPathItem: "//li", XPathItemTitle: "./a", RefreshInterval: 30,
+			ArticleViewMode: "global", AutoExpandContent: "global",
+		},
+		{Title: "Plain", URL: "https://example.com/rss", ArticleViewMode: "global", AutoExpandContent: "global"},
+	}
+
+	data, err := Generate(feeds)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	xmlStr := string(data)
+	for _, want := range []string{`<opml version="2.0" xmlns:mrrss="` + Namespace + `"`, "<dateCreated>", `mrrss:scriptPath="news.py"`, `type="rss"`} {
+		if !strings.Contains(xmlStr, want) {
+			t.Errorf("generated OPML lacks %s:\n%s", want, xmlStr)
+		}
+	}
+	if strings.Contains(xmlStr, "mrrss:position") {
+		t.Errorf("generated OPML has positions, which the outline order carries:\n%s", xmlStr)
+	}
+
+	parsed, err := Parse(strings.NewReader(xmlStr))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(parsed) != len(feeds) {
+		t.Fatalf("got %d feeds, want %d", len(parsed), len(feeds))
+	}
+	for i, want := range feeds {
+		got := parsed[i]
+		// Parse leaves unset view modes empty, which means the global setting as well
+		if got.ArticleViewMode == "" {
+			got.ArticleViewMode = "global"
+		}
+		if got.AutoExpandContent == "" {
+			got.AutoExpandContent = "global"
+		}
+		if got != want {
+			t.Errorf("feed %d after round trip:\n got %+v\nwant %+v", i, got, want)
+		}
+	}
+}
+
+func TestParseForeignOPML(t *testing.T) {
+	// Other readers' extensions, and mrrss: attributes without a namespace declaration
+	xmlData := `<?xml version="1.0" encoding="UTF-8"?>
+	<opml version="2.0" xmlns:frss="https://freshrss.org/opml">
+		<head><title>Export</title><dateCreated>Mon, 01 Jan 2024 09:00:00 +0000</dateCreated></head>
+		<body>
+			<outline text="News">
+				<outline type="rss" text="Example" xmlUrl="https://example.com/feed" htmlUrl="https://example.com/" description="An example" frss:cssFullContent="article" mrrss:imageMode="true" mrrss:position="bogus"/>
+			</outline>
+		</body>
+	</opml>`
+
+	feeds, err := Parse(strings.NewReader(xmlData))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(feeds) != 1 {
+		t.Fatalf("got %d feeds, want 1", len(feeds))
+	}
+	f := feeds[0]
+	if f.Type != "" || f.Link != "https://example.com/" || f.Description != "An example" || f.Category != "News" {
+		t.Errorf("unexpected feed: %+v", f)
+	}
+	if !f.IsImageMode {
+		t.Errorf("mrrss: attributes not applied: %+v", f)
+	}
 }
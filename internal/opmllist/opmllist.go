@@ -0,0 +1,305 @@
+// Package opmllist keeps the feeds of remote OPML files subscribed, such as a list a
+// team maintains. Lists are fetched with conditional requests on a schedule. Newly
+// listed feeds are added below the category of the list, and feeds the user has not
+// renamed or moved follow the changes of the list.
+package opmllist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/opml"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// checkInterval is how often the service checks whether a list is due
+	checkInterval = 5 * time.Minute
+	// refreshInterval is the time between checks of a list
+	refreshInterval = time.Hour
+	// maxListSize bounds the size of a fetched list
+	maxListSize = 10 << 20
+)
+
+// Result describes what a refresh of a list changed.
+type Result struct {
+	NotModified bool    `json:"not_modified"` // The list did not change since the last check
+	Added       int     `json:"added"`
+	Updated     int     `json:"updated"`
+	Removed     int     `json:"removed"`
+	AddedIDs    []int64 `json:"-"`
+}
+
+// Service refreshes the OPML list subscriptions on a schedule and on demand.
+type Service struct {
+	db      *database.DB
+	client  *http.Client
+	onAdded func(feedIDs []int64) // Called with the feeds a refresh added, e.g. to fetch them
+
+	mu sync.Mutex // Serializes refreshes
+}
+
+// NewService creates an OPML list service. onAdded, if not nil, is called with the IDs
+// of the feeds each refresh adds.
+func NewService(db *database.DB, onAdded func(feedIDs []int64)) *Service {
+	return &Service{
+		db:      db,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		onAdded: onAdded,
+	}
+}
+
+// Run refreshes each list an hour after its last check, until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.refreshDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshDue refreshes the lists that are due.
+func (s *Service) refreshDue(ctx context.Context, now time.Time) {
+	lists, err := s.db.GetOPMLLists()
+	if err != nil {
+		log.Printf("Error getting OPML lists: %v", err)
+		return
+	}
+	for _, list := range lists {
+		if ctx.Err() != nil {
+			return
+		}
+		if list.LastChecked != nil && now.Sub(*list.LastChecked) < refreshInterval {
+			continue
+		}
+		if _, err := s.Refresh(ctx, list.ID); err != nil {
+			log.Printf("Error refreshing OPML list %s: %v", list.URL, err)
+		}
+	}
+}
+
+// Refresh fetches a list and applies its changes. Errors are also recorded as the
+// last error of the list.
+func (s *Service) Refresh(ctx context.Context, id int64) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.db.GetOPMLList(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, etag, lastModified, err := s.refresh(ctx, list)
+	checkedAt := time.Now()
+	if err != nil {
+		// Keeping the old validators makes the next check apply the list again
+		if saveErr := s.db.SaveOPMLListCheck(id, list.ETag, list.LastModified, checkedAt, err.Error()); saveErr != nil {
+			log.Printf("Error saving OPML list check: %v", saveErr)
+		}
+		return nil, err
+	}
+	if err := s.db.SaveOPMLListCheck(id, etag, lastModified, checkedAt, ""); err != nil {
+		return nil, err
+	}
+
+	if len(result.AddedIDs) > 0 && s.onAdded != nil {
+		s.onAdded(result.AddedIDs)
+	}
+	return result, nil
+}
+
+// refresh fetches a list unless it is unchanged, applies it, and returns the new
+// validators.
+func (s *Service) refresh(ctx context.Context, list *models.OPMLList) (*Result, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, list.URL, nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if list.ETag != "" {
+		req.Header.Set("If-None-Match", list.ETag)
+	}
+	if list.LastModified != "" {
+		req.Header.Set("If-Modified-Since", list.LastModified)
+	}
+	req.Header.Set("Accept", "text/x-opml, application/xml, text/xml, */*")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &Result{NotModified: true}, list.ETag, list.LastModified, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	// A truncated or malformed list would look like it dropped feeds, so only
+	// complete, well-formed lists are applied
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListSize+1))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("read list: %w", err)
+	}
+	if len(body) > maxListSize {
+		return nil, "", "", fmt.Errorf("list is larger than %d MB", maxListSize>>20)
+	}
+	feeds, err := opml.ParseStrict(bytes.NewReader(body))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("parse list: %w", err)
+	}
+	result, err := s.apply(list, feeds)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return result, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+}
+
+// apply adds the newly listed feeds, updates the feeds the user has not changed, and
+// removes or lets go of the feeds the list no longer lists. A list without feeds is
+// more likely broken than emptied, so it removes nothing.
+func (s *Service) apply(list *models.OPMLList, feeds []models.Feed) (*Result, error) {
+	existing, err := s.db.GetFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("get feeds: %w", err)
+	}
+	byURL := make(map[string]models.Feed, len(existing))
+	byID := make(map[int64]models.Feed, len(existing))
+	for _, f := range existing {
+		byURL[utils.NormalizeURLForComparison(f.URL)] = f
+		byID[f.ID] = f
+	}
+
+	records, err := s.db.GetOPMLListFeeds(list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get list feeds: %w", err)
+	}
+	known := make(map[string]database.OPMLListFeed, len(records))
+	for _, r := range records {
+		known[r.URL] = r
+	}
+
+	result := &Result{}
+	listed := make(map[string]bool)
+	for _, f := range feeds {
+		key := utils.NormalizeURLForComparison(f.URL)
+		if key == "" || listed[key] {
+			continue
+		}
+		listed[key] = true
+		record := database.OPMLListFeed{URL: key, Title: f.Title, Category: joinCategory(list.Category, f.Category)}
+
+		previous, ok := known[key]
+		switch {
+		case !ok:
+			if _, subscribed := byURL[key]; subscribed {
+				// Already subscribed, so the feed stays the user's own
+				break
+			}
+			f.ID, f.Position, f.Category = 0, 0, record.Category
+			// Scripts and proxies of a remote list must not run on this machine
+			f.ScriptPath, f.ProxyURL, f.ProxyEnabled = "", "", false
+			id, err := s.db.AddFeed(&f)
+			if err != nil {
+				return nil, fmt.Errorf("add feed %s: %w", f.URL, err)
+			}
+			record.FeedID = id
+			result.Added++
+			result.AddedIDs = append(result.AddedIDs, id)
+
+		case previous.FeedID != 0:
+			record.FeedID = previous.FeedID
+			e, exists := byID[previous.FeedID]
+			if !exists {
+				// Deleted by the user; the record keeps the list from adding it again
+				break
+			}
+			changed, err := s.follow(e, previous, record)
+			if err != nil {
+				return nil, fmt.Errorf("update feed %s: %w", e.URL, err)
+			}
+			if changed {
+				result.Updated++
+			}
+		}
+
+		if err := s.db.SaveOPMLListFeed(list.ID, record); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(listed) == 0 {
+		log.Printf("OPML list %s lists no feeds, keeping its feeds", list.URL)
+		return result, nil
+	}
+	for _, r := range records {
+		if listed[r.URL] {
+			continue
+		}
+		if e, exists := byID[r.FeedID]; exists && r.FeedID != 0 && list.RemoveMissing && !edited(e, r) {
+			if err := s.db.DeleteFeed(e.ID); err != nil {
+				return nil, fmt.Errorf("delete feed %s: %w", e.URL, err)
+			}
+			result.Removed++
+		}
+		if err := s.db.DeleteOPMLListFeed(list.ID, r.URL); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// follow renames and moves a feed like the list did, unless the user changed the
+// title or category the list gave the feed before. It reports whether it changed the feed.
+func (s *Service) follow(feed models.Feed, previous, current database.OPMLListFeed) (bool, error) {
+	changed := false
+	if current.Title != previous.Title && current.Title != "" && feed.Title == previous.Title {
+		if err := s.db.UpdateFeedDetails(feed.ID, current.Title, feed.Link, feed.Description); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if current.Category != previous.Category && feed.Category == previous.Category {
+		position, err := s.db.GetNextPositionInCategory(current.Category)
+		if err != nil {
+			return false, err
+		}
+		if err := s.db.UpdateFeedPosition(feed.ID, current.Category, position); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// edited reports whether the user changed the title or category a list gave a feed.
+func edited(feed models.Feed, record database.OPMLListFeed) bool {
+	return feed.Title != record.Title || feed.Category != record.Category
+}
+
+// joinCategory puts the category a list gives a feed below the category of the list.
+func joinCategory(listCategory, feedCategory string) string {
+	switch {
+	case listCategory == "":
+		return feedCategory
+	case feedCategory == "":
+		return listCategory
+	}
+	return listCategory + "/" + feedCategory
+}
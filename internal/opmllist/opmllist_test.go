@@ -0,0 +1,284 @@
+package opmllist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/opml"
+)
+
+// listServer serves an OPML list with an ETag and counts the full responses.
+type listServer struct {
+	mu      sync.Mutex
+	body    string
+	version int
+	served  int
+}
+
+func (s *listServer) set(outlines string) {
+	s.setRaw(`<?xml version="1.0"?><opml version="2.0" xmlns:mrrss="` + opml.Namespace + `"><head><title>Team</title></head><body>` + outlines + `</body></opml>`)
+}
+
+func (s *listServer) setRaw(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.version++
+}
+
+func (s *listServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.served++
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(s.body))
+}
+
+func setup(t *testing.T) (*Service, *database.DB, *listServer, int64) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	server := &listServer{}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	id, err := db.AddOPMLList(&models.OPMLList{URL: ts.URL + "/team.opml", Title: "Team", Category: "Team", RemoveMissing: true})
+	if err != nil {
+		t.Fatalf("AddOPMLList failed: %v", err)
+	}
+	return NewService(db, nil), db, server, id
+}
+
+func feedsByURL(t *testing.T, db *database.DB) map[string]models.Feed {
+	t.Helper()
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byURL := make(map[string]models.Feed)
+	for _, f := range feeds {
+		byURL[f.URL] = f
+	}
+	return byURL
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, db, server, id := setup(t)
+	ownID, _ := db.AddFeed(&models.Feed{Title: "Mine", URL: "https://example.com/mine", Category: "Own"})
+
+	server.set(`
+		<outline text="Go"><outline text="Go Blog" xmlUrl="https://go.dev/blog/feed.atom"/></outline>
+		<outline text="Release Notes" xmlUrl="https://example.com/releases"/>
+		<outline text="Mine" xmlUrl="https://example.com/mine"/>`)
+	result, err := s.Refresh(ctx, id)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Added != 2 || len(result.AddedIDs) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	feeds := feedsByURL(t, db)
+	if feeds["https://go.dev/blog/feed.atom"].Category != "Team/Go" || feeds["https://example.com/releases"].Category != "Team" {
+		t.Errorf("feeds not added below the list category: %+v", feeds)
+	}
+
+	list, _ := db.GetOPMLList(id)
+	if len(list.FeedIDs) != 2 || list.LastChecked == nil || list.LastError != "" {
+		t.Errorf("unexpected list after refresh: %+v", list)
+	}
+
+	// Unchanged lists are not downloaded again
+	result, err = s.Refresh(ctx, id)
+	if err != nil || !result.NotModified || server.served != 1 {
+		t.Fatalf("expected a conditional request, got %+v, %v, %d downloads", result, err, server.served)
+	}
+
+	// The user renames one feed; the list renames and moves both, and drops the other
+	// feeds, including the one the user subscribed to before
+	goBlog := feeds["https://go.dev/blog/feed.atom"]
+	goBlog.Title = "My Go Blog"
+	db.AddFeed(&goBlog)
+	server.set(`
+		<outline text="Golang"><outline text="The Go Blog" xmlUrl="https://go.dev/blog/feed.atom"/></outline>`)
+	result, err = s.Refresh(ctx, id)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Updated != 1 || result.Removed != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	feeds = feedsByURL(t, db)
+	if got := feeds["https://go.dev/blog/feed.atom"]; got.Title != "My Go Blog" || got.Category != "Team/Golang" {
+		t.Errorf("feed after list change = %q in %q, want the local title in the new category", got.Title, got.Category)
+	}
+	if _, ok := feeds["https://example.com/releases"]; ok {
+		t.Error("the feed the list dropped was not removed")
+	}
+	if f, ok := feeds["https://example.com/mine"]; !ok || f.ID != ownID {
+		t.Error("the feed subscribed before the list was removed")
+	}
+}
+
+func TestRefreshKeepsDeletedFeedsDeleted(t *testing.T) {
+	ctx := context.Background()
+	s, db, server, id := setup(t)
+
+	server.set(`<outline text="News" xmlUrl="https://example.com/news"/>`)
+	if _, err := s.Refresh(ctx, id); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	news := feedsByURL(t, db)["https://example.com/news"]
+	db.DeleteFeed(news.ID)
+
+	server.set(`<outline text="News" xmlUrl="https://example.com/news"/><outline text="More" xmlUrl="https://example.com/more"/>`)
+	result, err := s.Refresh(ctx, id)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Added != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, ok := feedsByURL(t, db)["https://example.com/news"]; ok {
+		t.Error("the list added a feed the user deleted again")
+	}
+}
+
+func TestRefreshFollowKeepsLastUpdated(t *testing.T) {
+	ctx := context.Background()
+	s, db, server, id := setup(t)
+
+	server.set(`<outline text="News" xmlUrl="https://example.com/news"/>`)
+	if _, err := s.Refresh(ctx, id); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	news := feedsByURL(t, db)["https://example.com/news"]
+	lastUpdated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := db.Exec("UPDATE feeds SET last_updated = ? WHERE id = ?", lastUpdated, news.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	server.set(`<outline text="World"><outline text="World News" xmlUrl="https://example.com/news"/></outline>`)
+	result, err := s.Refresh(ctx, id)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Updated != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	got := feedsByURL(t, db)["https://example.com/news"]
+	if got.Title != "World News" || got.Category != "Team/World" {
+		t.Errorf("feed = %q in %q, want the list's title and category", got.Title, got.Category)
+	}
+	if !got.LastUpdated.Equal(lastUpdated) {
+		t.Errorf("last_updated = %v, want %v", got.LastUpdated, lastUpdated)
+	}
+}
+
+func TestRefreshStripsLocalOptions(t *testing.T) {
+	s, db, server, id := setup(t)
+
+	server.set(`<outline text="News" xmlUrl="https://example.com/news" mrrss:scriptPath="steal.sh" mrrss:proxyEnabled="true" mrrss:proxyUrl="http://proxy.example.com:8080"/>`)
+	if _, err := s.Refresh(context.Background(), id); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	news := feedsByURL(t, db)["https://example.com/news"]
+	if news.ScriptPath != "" || news.ProxyEnabled || news.ProxyURL != "" {
+		t.Errorf("the list set local options: script %q, proxy %v %q", news.ScriptPath, news.ProxyEnabled, news.ProxyURL)
+	}
+}
+
+func TestRefreshKeepsFeedsOfBrokenLists(t *testing.T) {
+	ctx := context.Background()
+	s, db, server, id := setup(t)
+
+	server.set(`<outline text="News" xmlUrl="https://example.com/news"/><outline text="More" xmlUrl="https://example.com/more"/>`)
+	if _, err := s.Refresh(ctx, id); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// Malformed lists fail instead of applying the feeds a scan finds
+	server.setRaw(`<opml version="2.0"><body><outline text="News" xmlUrl="https://example.com/news"/>`)
+	if _, err := s.Refresh(ctx, id); err == nil {
+		t.Error("Refresh of a malformed list succeeded")
+	}
+
+	// Lists over the size limit fail instead of being cut off
+	server.set(`<outline text="News" xmlUrl="https://example.com/news"/>` + strings.Repeat(" ", maxListSize))
+	if _, err := s.Refresh(ctx, id); err == nil || !strings.Contains(err.Error(), "larger") {
+		t.Errorf("Refresh of an oversized list = %v, want a size error", err)
+	}
+
+	// Empty lists remove nothing
+	server.set(``)
+	result, err := s.Refresh(ctx, id)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Removed != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	feeds := feedsByURL(t, db)
+	for _, url := range []string{"https://example.com/news", "https://example.com/more"} {
+		if _, ok := feeds[url]; !ok {
+			t.Errorf("%s was removed", url)
+		}
+	}
+	if list, _ := db.GetOPMLList(id); len(list.FeedIDs) != 2 {
+		t.Errorf("the list lost its feeds: %v", list.FeedIDs)
+	}
+}
+
+func TestRefreshRecordsErrors(t *testing.T) {
+	s, db, _, id := setup(t)
+	s.client.Transport = roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error", Body: http.NoBody}, nil
+	})
+
+	if _, err := s.Refresh(context.Background(), id); err == nil {
+		t.Fatal("Refresh of a failing list succeeded")
+	}
+	list, _ := db.GetOPMLList(id)
+	if list.LastChecked == nil || !strings.Contains(list.LastError, "500") {
+		t.Errorf("last error = %q, want the status", list.LastError)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestJoinCategory(t *testing.T) {
+	tests := []struct{ list, feed, want string }{
+		{"", "", ""},
+		{"Team", "", "Team"},
+		{"", "Go", "Go"},
+		{"Team", "Go/Tools", "Team/Go/Tools"},
+	}
+	for _, tt := range tests {
+		if got := joinCategory(tt.list, tt.feed); got != tt.want {
+			t.Errorf("joinCategory(%q, %q) = %q, want %q", tt.list, tt.feed, got, tt.want)
+		}
+	}
+}
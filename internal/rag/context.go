@@ -0,0 +1,90 @@
+package rag
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"MrRSS/internal/utils"
+)
+
+const (
+	// maxExcerptTokens bounds the excerpt of a single article
+	maxExcerptTokens = 800
+	// minExcerptTokens is the smallest excerpt worth adding to the context
+	minExcerptTokens = 50
+)
+
+// Source is an article given to the model as context.
+type Source struct {
+	ArticleID   int64     `json:"article_id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	FeedTitle   string    `json:"feed_title"`
+	PublishedAt time.Time `json:"published_at"`
+	Score       float64   `json:"score"`
+}
+
+// BuildContext formats the candidates, best first, as cited excerpts that fit in
+// budget tokens. It returns the context and the articles included in it.
+// content is used for articles without a summary or stored content and may be nil.
+func BuildContext(candidates []Candidate, budget int, content utils.ContentFunc) (string, []Source) {
+	var b strings.Builder
+	sources := []Source{}
+	used := 0
+
+	for _, c := range candidates {
+		a := c.Article
+		header := fmt.Sprintf("[#%d] %s (%s, %s)\nURL: %s\n", a.ID, a.Title, a.FeedTitle, a.PublishedAt.Format("2006-01-02"), a.URL)
+		remaining := budget - used - estimateTokens(header)
+		if remaining < minExcerptTokens {
+			break
+		}
+		if remaining > maxExcerptTokens {
+			remaining = maxExcerptTokens
+		}
+
+		// Leave room for the ellipsis and the separator
+		entry := header + utils.TruncateAtWord(articleText(c, content), remaining*4-8) + "\n\n"
+		b.WriteString(entry)
+		used += estimateTokens(entry)
+
+		sources = append(sources, Source{
+			ArticleID:   a.ID,
+			Title:       a.Title,
+			URL:         a.URL,
+			FeedTitle:   a.FeedTitle,
+			PublishedAt: a.PublishedAt,
+			Score:       c.Score,
+		})
+	}
+	return b.String(), sources
+}
+
+// articleText returns the best available plain text of an article: its summary,
+// its stored or fetched content, then its title.
+func articleText(c Candidate, content utils.ContentFunc) string {
+	a := c.Article
+	if a.Summary != "" {
+		return utils.PlainText(a.Summary)
+	}
+	if a.Content != "" {
+		return utils.PlainText(a.Content)
+	}
+	if content != nil {
+		text, err := content(a.ID)
+		if err != nil {
+			log.Printf("Library chat: failed to get content for article %d: %v", a.ID, err)
+		}
+		if text != "" {
+			return utils.PlainText(text)
+		}
+	}
+	return a.Title
+}
+
+// estimateTokens provides a rough token count estimation (1 token ≈ 4 characters)
+func estimateTokens(text string) int {
+	return len(text) / 4
+}
@@ -0,0 +1,194 @@
+// Package rag answers questions across the whole article library. It retrieves the
+// articles most relevant to a question, packs them into a prompt within a token budget
+// and asks the chat model for an answer that cites the articles it used.
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// DefaultMaxSources is the number of articles retrieved for a question by default
+	DefaultMaxSources = 8
+	// MaxSources bounds the number of articles retrieved for a question
+	MaxSources = 30
+	// defaultContextTokens is the article context budget when the model's context window is unknown
+	defaultContextTokens = 6000
+	// minContextTokens is the smallest article context budget
+	minContextTokens = 1000
+	// replyTokens bounds the length of an answer
+	replyTokens = 1024
+	// promptOverheadTokens is reserved for the instructions and the question
+	promptOverheadTokens = 500
+)
+
+var (
+	// ErrNoArticles is returned when no article matches the question and filters
+	ErrNoArticles = errors.New("no matching articles found")
+	// ErrLimitReached is returned when the AI usage limit is reached
+	ErrLimitReached = errors.New("AI usage limit reached")
+)
+
+// citationPattern matches [#123] citations in answers
+var citationPattern = regexp.MustCompile(`\[#(\d+)\]`)
+
+// Filter restricts the articles considered for a question.
+type Filter struct {
+	FeedIDs    []int64   `json:"feed_ids,omitempty"`
+	Categories []string  `json:"categories,omitempty"` // Including subcategories
+	Since      time.Time `json:"since,omitempty"`      // Published at or after; zero for no bound
+	Until      time.Time `json:"until,omitempty"`      // Published before; zero for no bound
+}
+
+// IsZero reports whether the filter allows all articles.
+func (f Filter) IsZero() bool {
+	return len(f.FeedIDs) == 0 && len(f.Categories) == 0 && f.Since.IsZero() && f.Until.IsZero()
+}
+
+// Request is a question about the library.
+type Request struct {
+	Question      string
+	Filter        Filter
+	History       []aiclient.Message // Earlier turns of the conversation, oldest first
+	MaxSources    int                // 0 uses DefaultMaxSources
+	ContextTokens int                // Article context budget; 0 derives it from the model's context window
+}
+
+// Answer is the model's answer with the articles it was based on.
+type Answer struct {
+	Text      string         `json:"response"`
+	Citations []Source       `json:"citations"` // Sources cited in the answer, in order of first citation
+	Sources   []Source       `json:"sources"`   // All articles given to the model
+	Usage     aiclient.Usage `json:"usage"`
+}
+
+// Engine answers questions from retrieved articles.
+type Engine struct {
+	tracker    aiusage.Limiter
+	content    utils.ContentFunc
+	retrievers []Retriever
+}
+
+// NewEngine creates an engine. Without retrievers it uses full-text search only.
+// tracker and content may be nil.
+func NewEngine(db *database.DB, tracker aiusage.Limiter, content utils.ContentFunc, retrievers ...Retriever) *Engine {
+	if len(retrievers) == 0 {
+		retrievers = []Retriever{NewTextRetriever(db)}
+	}
+	return &Engine{tracker: tracker, content: content, retrievers: retrievers}
+}
+
+// Ask retrieves articles relevant to the question and asks the model to answer from them.
+func (e *Engine) Ask(ctx context.Context, client *aiclient.Client, req Request) (*Answer, error) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		return nil, errors.New("missing question")
+	}
+	maxSources := req.MaxSources
+	if maxSources <= 0 {
+		maxSources = DefaultMaxSources
+	}
+	if maxSources > MaxSources {
+		maxSources = MaxSources
+	}
+
+	candidates, err := Retrieve(ctx, e.retrievers, question, req.Filter, maxSources)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoArticles
+	}
+
+	budget := req.ContextTokens
+	if budget <= 0 {
+		budget = contextBudget(client.ContextWindow(), req.History)
+	}
+	articleContext, sources := BuildContext(candidates, budget, e.content)
+
+	if e.tracker != nil {
+		if e.tracker.IsLimitReached() {
+			return nil, ErrLimitReached
+		}
+		e.tracker.WaitForRateLimit()
+	}
+
+	messages := make([]aiclient.Message, 0, len(req.History)+2)
+	messages = append(messages, aiclient.Message{Role: "system", Content: systemPrompt})
+	messages = append(messages, req.History...)
+	messages = append(messages, aiclient.Message{
+		Role:    "user",
+		Content: fmt.Sprintf("Articles:\n\n%s\nQuestion: %s", articleContext, question),
+	})
+
+	response, err := client.Complete(ctx, aiclient.Request{Messages: messages, Temperature: 0.3, MaxTokens: replyTokens})
+	if err != nil {
+		return nil, err
+	}
+	if e.tracker != nil {
+		if err := e.tracker.Record(response.Usage.Record(aiusage.FeatureChat, 0)); err != nil {
+			log.Printf("Library chat: failed to track AI usage: %v", err)
+		}
+	}
+
+	text, citations := resolveCitations(strings.TrimSpace(response.Text), sources)
+	return &Answer{Text: text, Citations: citations, Sources: sources, Usage: response.Usage}, nil
+}
+
+// systemPrompt instructs the model to answer from the given articles only.
+const systemPrompt = "You answer questions about the articles from the user's feeds. Use ONLY the articles given with the question. " +
+	"After each statement, cite the supporting articles using their IDs exactly as given, e.g. [#12]. " +
+	"If the articles do not answer the question, say so. Respond in the same language as the question, in plain text."
+
+// contextBudget returns the article context budget for a context window, leaving room
+// for the conversation history, the instructions and the answer.
+func contextBudget(contextWindow int, history []aiclient.Message) int {
+	if contextWindow <= 0 {
+		return defaultContextTokens
+	}
+	budget := contextWindow - replyTokens - promptOverheadTokens
+	for _, msg := range history {
+		budget -= estimateTokens(msg.Content)
+	}
+	if budget < minContextTokens {
+		return minContextTokens
+	}
+	return budget
+}
+
+// resolveCitations drops citations of articles that were not given to the model and
+// returns the cited sources in order of first citation.
+func resolveCitations(text string, sources []Source) (string, []Source) {
+	byID := make(map[int64]Source, len(sources))
+	for _, s := range sources {
+		byID[s.ArticleID] = s
+	}
+
+	citations := []Source{}
+	cited := make(map[int64]bool)
+	text = citationPattern.ReplaceAllStringFunc(text, func(m string) string {
+		id, _ := strconv.ParseInt(citationPattern.FindStringSubmatch(m)[1], 10, 64)
+		source, ok := byID[id]
+		if !ok {
+			return ""
+		}
+		if !cited[id] {
+			cited[id] = true
+			citations = append(citations, source)
+		}
+		return m
+	})
+	return strings.TrimSpace(text), citations
+}
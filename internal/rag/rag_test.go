@@ -0,0 +1,153 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+type fakeTracker struct {
+	limitReached bool
+	records      []models.AIUsageRecord
+}
+
+func (f *fakeTracker) IsLimitReached() bool { return f.limitReached }
+func (f *fakeTracker) WaitForRateLimit()    {}
+func (f *fakeTracker) Record(rec models.AIUsageRecord) error {
+	f.records = append(f.records, rec)
+	return nil
+}
+
+func setupLibrary(t *testing.T) (*database.DB, map[string]int64) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+
+	techID, _ := db.AddFeed(&models.Feed{Title: "Tech News", URL: "https://tech.example/feed", Category: "Tech"})
+	cityID, _ := db.AddFeed(&models.Feed{Title: "City Desk", URL: "https://city.example/feed", Category: "Local"})
+
+	now := time.Now()
+	articles := []models.Article{
+		{FeedID: techID, Title: "Rust release adds async closures", URL: "https://tech.example/rust",
+			Summary: "The Rust compiler team shipped async closures. Rust developers welcomed the release.", PublishedAt: now.Add(-time.Hour)},
+		{FeedID: techID, Title: "Go release improves generics", URL: "https://tech.example/go",
+			Summary: "The Go team released a new version with faster generic code.", PublishedAt: now.Add(-2 * time.Hour)},
+		{FeedID: cityID, Title: "Bridge repairs start", URL: "https://city.example/bridge",
+			Summary: "Workers remove rust from the old bridge before painting it.", PublishedAt: now.Add(-3 * time.Hour)},
+		{FeedID: techID, Title: "Rust 1.0 turns ten", URL: "https://tech.example/rust-old",
+			Summary: "A look back at ten years of Rust.", PublishedAt: now.AddDate(0, -3, 0)},
+	}
+	ids := make(map[string]int64)
+	for _, a := range articles {
+		if err := db.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle() error = %v", err)
+		}
+		db.QueryRow("SELECT id FROM articles WHERE url = ?", a.URL).Scan(&a.ID)
+		ids[a.URL] = a.ID
+	}
+	return db, ids
+}
+
+func TestTextRetriever_RanksAndFilters(t *testing.T) {
+	db, ids := setupLibrary(t)
+	r := NewTextRetriever(db)
+
+	results, err := r.Retrieve(context.Background(), "Anything about Rust async closures?", Filter{}, 10)
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if len(results) != 3 || results[0].Article.ID != ids["https://tech.example/rust"] {
+		t.Fatalf("unexpected ranking %+v", results)
+	}
+
+	results, _ = r.Retrieve(context.Background(), "rust", Filter{Categories: []string{"Tech"}, Since: time.Now().AddDate(0, 0, -30)}, 10)
+	if len(results) != 1 || results[0].Article.ID != ids["https://tech.example/rust"] {
+		t.Errorf("unexpected filtered results %+v", results)
+	}
+}
+
+func TestBuildContext_RespectsBudget(t *testing.T) {
+	long := strings.Repeat("Rust closures are async now. ", 200)
+	candidates := []Candidate{
+		{Article: database.ArticleText{Article: models.Article{ID: 1, Title: "One", URL: "https://a/1", Summary: long}}},
+		{Article: database.ArticleText{Article: models.Article{ID: 2, Title: "Two", URL: "https://a/2", Summary: long}}},
+		{Article: database.ArticleText{Article: models.Article{ID: 3, Title: "Three", URL: "https://a/3"}, Content: "<p>Fetched</p>"}},
+	}
+
+	text, sources := BuildContext(candidates, 1000, nil)
+	if estimateTokens(text) > 1000 {
+		t.Errorf("context uses %d tokens, budget 1000", estimateTokens(text))
+	}
+	if len(sources) != 2 || sources[0].ArticleID != 1 || !strings.Contains(text, "[#2] Two") {
+		t.Errorf("unexpected sources %+v", sources)
+	}
+
+	text, sources = BuildContext(candidates[2:], 1000, nil)
+	if len(sources) != 1 || !strings.Contains(text, "Fetched") || strings.Contains(text, "<p>") {
+		t.Errorf("expected stored content as plain text, got %q", text)
+	}
+}
+
+func TestAsk_AnswersWithCitations(t *testing.T) {
+	db, ids := setupLibrary(t)
+	rustID := ids["https://tech.example/rust"]
+
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []aiclient.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Messages[len(body.Messages)-1].Content
+		answer := fmt.Sprintf("Rust shipped async closures [#%d]. Unrelated [#99999].", rustID)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%q}}],"usage":{"prompt_tokens":300,"completion_tokens":20}}`, answer)
+	}))
+	defer server.Close()
+
+	client, err := aiclient.New(aiclient.Config{Endpoint: server.URL + "/v1/chat/completions", Model: "test-model"}, nil)
+	if err != nil {
+		t.Fatalf("aiclient.New() error = %v", err)
+	}
+	tracker := &fakeTracker{}
+	engine := NewEngine(db, tracker, nil)
+
+	answer, err := engine.Ask(context.Background(), client, Request{Question: "What did my feeds say about Rust?", Filter: Filter{Categories: []string{"Tech"}}})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	if !strings.Contains(prompt, fmt.Sprintf("[#%d] Rust release adds async closures", rustID)) || strings.Contains(prompt, "Bridge repairs") {
+		t.Errorf("unexpected prompt %q", prompt)
+	}
+	if strings.Contains(answer.Text, "99999") {
+		t.Errorf("invalid citation kept: %q", answer.Text)
+	}
+	if len(answer.Citations) != 1 || answer.Citations[0].ArticleID != rustID || answer.Citations[0].URL != "https://tech.example/rust" {
+		t.Errorf("unexpected citations %+v", answer.Citations)
+	}
+	if len(tracker.records) != 1 || tracker.records[0].Feature != "chat" || tracker.records[0].PromptTokens != 300 {
+		t.Errorf("unexpected usage records %+v", tracker.records)
+	}
+
+	if _, err := engine.Ask(context.Background(), client, Request{Question: "quantum gravity", Filter: Filter{}}); !errors.Is(err, ErrNoArticles) {
+		t.Errorf("expected ErrNoArticles, got %v", err)
+	}
+	tracker.limitReached = true
+	if _, err := engine.Ask(context.Background(), client, Request{Question: "rust"}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected ErrLimitReached, got %v", err)
+	}
+}
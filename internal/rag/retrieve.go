@@ -0,0 +1,179 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/summary"
+)
+
+const (
+	// maxQueryTerms bounds the number of question terms used for full-text search
+	maxQueryTerms = 12
+	// maxTextCandidates bounds the articles loaded from the database for ranking
+	maxTextCandidates = 500
+	// rrfK is the rank constant of reciprocal rank fusion
+	rrfK = 60
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Candidate is a retrieved article with its relevance score.
+type Candidate struct {
+	Article database.ArticleText
+	Score   float64
+}
+
+// Retriever finds the articles most relevant to a question, best first.
+type Retriever interface {
+	Retrieve(ctx context.Context, question string, filter Filter, limit int) ([]Candidate, error)
+}
+
+// Retrieve queries all retrievers and merges their rankings with reciprocal rank fusion.
+// With a single retriever its ranking is returned unchanged.
+func Retrieve(ctx context.Context, retrievers []Retriever, question string, filter Filter, limit int) ([]Candidate, error) {
+	if len(retrievers) == 1 {
+		return retrievers[0].Retrieve(ctx, question, filter, limit)
+	}
+
+	fused := make(map[int64]*Candidate)
+	for _, r := range retrievers {
+		results, err := r.Retrieve(ctx, question, filter, limit)
+		if err != nil {
+			return nil, err
+		}
+		for rank, c := range results {
+			if fused[c.Article.ID] == nil {
+				fused[c.Article.ID] = &Candidate{Article: c.Article}
+			}
+			fused[c.Article.ID].Score += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	merged := make([]Candidate, 0, len(fused))
+	for _, c := range fused {
+		merged = append(merged, *c)
+	}
+	sortCandidates(merged)
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// TextRetriever ranks articles containing the question's terms with BM25.
+type TextRetriever struct {
+	db *database.DB
+}
+
+// NewTextRetriever creates a full-text retriever.
+func NewTextRetriever(db *database.DB) *TextRetriever {
+	return &TextRetriever{db: db}
+}
+
+// Retrieve returns the articles matching the question's terms, best first. A question
+// without searchable terms returns the newest articles matching the filter.
+func (r *TextRetriever) Retrieve(ctx context.Context, question string, filter Filter, limit int) ([]Candidate, error) {
+	terms := queryTerms(question)
+	articles, err := r.db.SearchArticleTexts(database.ArticleSearch{
+		Terms:      terms,
+		FeedIDs:    filter.FeedIDs,
+		Categories: filter.Categories,
+		Since:      filter.Since,
+		Until:      filter.Until,
+		Limit:      maxTextCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("full-text search: %w", err)
+	}
+
+	var candidates []Candidate
+	if len(terms) == 0 {
+		for _, a := range articles {
+			candidates = append(candidates, Candidate{Article: a})
+		}
+	} else {
+		scores := bm25(articles, terms)
+		for i, a := range articles {
+			if scores[i] > 0 {
+				candidates = append(candidates, Candidate{Article: a, Score: scores[i]})
+			}
+		}
+		sortCandidates(candidates)
+	}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// queryTerms returns the distinct search terms of a question.
+func queryTerms(question string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range summary.Terms(question) {
+		if !seen[term] && len(terms) < maxQueryTerms {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// bm25 scores articles against the query terms, using the loaded articles as the corpus.
+// Titles count twice.
+func bm25(articles []database.ArticleText, terms []string) []float64 {
+	termFreqs := make([]map[string]int, len(articles))
+	lengths := make([]int, len(articles))
+	docFreq := make(map[string]int)
+	totalLength := 0
+
+	for i, a := range articles {
+		tokens := summary.Terms(a.Title + " " + a.Title + " " + a.TranslatedTitle + " " + a.Summary + " " + a.Content)
+		freq := make(map[string]int)
+		for _, t := range tokens {
+			freq[t]++
+		}
+		for _, term := range terms {
+			if freq[term] > 0 {
+				docFreq[term]++
+			}
+		}
+		termFreqs[i] = freq
+		lengths[i] = len(tokens)
+		totalLength += len(tokens)
+	}
+
+	scores := make([]float64, len(articles))
+	if len(articles) == 0 {
+		return scores
+	}
+	n := float64(len(articles))
+	avgLength := math.Max(float64(totalLength)/n, 1)
+	for i, freq := range termFreqs {
+		for _, term := range terms {
+			tf := float64(freq[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLength))
+		}
+	}
+	return scores
+}
+
+// sortCandidates orders candidates by score, newest first on ties.
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Article.PublishedAt.After(candidates[j].Article.PublishedAt)
+	})
+}
@@ -0,0 +1,278 @@
+// Package ranking scores articles by their likely importance to the user with a local
+// model learned from feedback: favorites, read later, reading time and skipped
+// articles. Every feature of an article (its feed, labels, keywords and length) has a
+// weight from the feedback on the articles sharing it, and the score is the sum of the
+// weights, so each score is explained by its largest contributions. When embeddings
+// are available, similarity to the articles the user liked adds to the score.
+package ranking
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/embedding"
+	"MrRSS/internal/models"
+)
+
+// Kinds of features
+const (
+	KindFeed       = "feed"
+	KindLabel      = "label"
+	KindKeyword    = "keyword"
+	KindLength     = "length"
+	KindSimilarity = "similarity"
+)
+
+const (
+	// QuickReadSeconds is the reading time under which an opened article counts as skipped
+	QuickReadSeconds = 10
+	// wordsPerMinute is the reading speed used to expect the reading time of an article
+	wordsPerMinute = 230
+	// minExpectedSeconds is the expected reading time of short or unknown-length articles
+	minExpectedSeconds = 30
+	// halfLife is the age at which feedback counts half, so the model follows changing interests
+	halfLife = 90 * 24 * time.Hour
+
+	// similarityFactor scales the similarity to liked articles above similarityBaseline
+	similarityFactor = 3.0
+	// similarityBaseline is the cosine similarity that unrelated articles typically reach
+	similarityBaseline = 0.5
+	// similarityName names the similarity feature in explanations
+	similarityName = "similar to articles you liked"
+
+	// maxReasons bounds the stored contributions of a score
+	maxReasons = 10
+	// maxExplained bounds the contributions named in an explanation
+	maxExplained = 3
+	// minExplained is the contribution a feature needs to be named in an explanation
+	minExplained = 0.1
+)
+
+// Values of feedback signals, from -1 (not interested) to 1 (interested)
+const (
+	favoriteValue  = 1.0
+	readLaterValue = 0.6
+	skipValue      = -0.5
+	fullReadValue  = 0.7
+	glanceValue    = -0.1
+)
+
+// kindFactors scale the weights of each kind of feature. Keywords are many per article
+// and individually weaker evidence than the feed or labels.
+var kindFactors = map[string]float64{
+	KindFeed:    1.0,
+	KindLabel:   0.8,
+	KindKeyword: 0.4,
+	KindLength:  0.3,
+}
+
+// SignalValue returns how strongly a feedback signal indicates interest, from -1 to 1.
+// Reading time counts relative to the time expected for the article's length: reading
+// it fully counts a little more than read later, a glance slightly against it and a
+// quick close like a skip.
+func SignalValue(signal string, value float64, wordCount int) float64 {
+	switch signal {
+	case database.FeedbackFavorite:
+		return favoriteValue
+	case database.FeedbackReadLater:
+		return readLaterValue
+	case database.FeedbackSkip:
+		return skipValue
+	case database.FeedbackRead:
+		if value < QuickReadSeconds {
+			return skipValue
+		}
+		expected := math.Max(minExpectedSeconds, float64(wordCount)*60/wordsPerMinute)
+		ratio := math.Min(value/expected, 1)
+		return fullReadValue*ratio + glanceValue*(1-ratio)
+	}
+	return 0
+}
+
+// Example is an article with feedback for training.
+type Example struct {
+	Features *database.ArticleFeatures
+	Value    float64       // From SignalValue
+	Age      time.Duration // Age of the feedback
+	Vector   []float32     // Embedding of the article, nil if unavailable
+}
+
+// Model holds the learned feature weights.
+type Model struct {
+	weights  map[string]float64
+	centroid []float32 // Normalized mean embedding of liked articles, nil if unknown
+	examples int
+}
+
+// feature is a property of an article. strength scales its weight, e.g. by the
+// importance of a keyword in the article.
+type feature struct {
+	key      string
+	kind     string
+	name     string
+	strength float64
+}
+
+// Train learns the weight of each feature as the log ratio of the positive and the
+// negative feedback on articles having it, smoothed so that features without feedback
+// weigh 0. Feedback counts less as it ages.
+func Train(examples []Example) *Model {
+	positive := make(map[string]float64)
+	negative := make(map[string]float64)
+	var centroid []float32
+	m := &Model{weights: make(map[string]float64)}
+
+	for _, ex := range examples {
+		if ex.Features == nil || ex.Value == 0 {
+			continue
+		}
+		m.examples++
+		value := ex.Value * math.Pow(0.5, ex.Age.Hours()/halfLife.Hours())
+		for _, f := range features(ex.Features) {
+			if value > 0 {
+				positive[f.key] += value * f.strength
+			} else {
+				negative[f.key] -= value * f.strength
+			}
+		}
+
+		if value > 0 && len(ex.Vector) > 0 {
+			if centroid == nil {
+				centroid = make([]float32, len(ex.Vector))
+			}
+			if len(ex.Vector) != len(centroid) {
+				continue
+			}
+			for i, x := range embedding.Normalize(append([]float32(nil), ex.Vector...)) {
+				centroid[i] += float32(value) * x
+			}
+		}
+	}
+
+	for key, p := range positive {
+		m.weights[key] = math.Log((p + 1) / (negative[key] + 1))
+	}
+	for key, n := range negative {
+		if _, ok := positive[key]; !ok {
+			m.weights[key] = math.Log(1 / (n + 1))
+		}
+	}
+	if centroid != nil {
+		m.centroid = embedding.Normalize(centroid)
+	}
+	return m
+}
+
+// Examples returns the number of examples the model learned from.
+func (m *Model) Examples() int {
+	return m.examples
+}
+
+// Score returns the importance of an article with the contributions explaining it.
+// vector is the article's embedding, nil if unavailable.
+func (m *Model) Score(a *database.ArticleFeatures, vector []float32) models.ArticleScore {
+	var total float64
+	var reasons []models.ScoreReason
+	for _, f := range features(a) {
+		weight, ok := m.weights[f.key]
+		if !ok {
+			continue
+		}
+		contribution := kindFactors[f.kind] * weight * f.strength
+		total += contribution
+		reasons = append(reasons, models.ScoreReason{Kind: f.kind, Name: f.name, Contribution: contribution})
+	}
+	if m.centroid != nil && len(vector) > 0 {
+		if c := similarityFactor * (embedding.Cosine(vector, m.centroid) - similarityBaseline); c > 0 {
+			total += c
+			reasons = append(reasons, models.ScoreReason{Kind: KindSimilarity, Name: similarityName, Contribution: c})
+		}
+	}
+
+	// Largest contributions first, keeping feature order for ties
+	sort.SliceStable(reasons, func(i, j int) bool {
+		return math.Abs(reasons[i].Contribution) > math.Abs(reasons[j].Contribution)
+	})
+	kept := []models.ScoreReason{}
+	for _, r := range reasons {
+		r.Contribution = round(r.Contribution)
+		if r.Contribution == 0 || len(kept) == maxReasons {
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	return models.ArticleScore{
+		ArticleID:   a.ArticleID,
+		Score:       round(total),
+		Explanation: Explain(kept),
+		Reasons:     kept,
+	}
+}
+
+// Explain describes the largest contributions to a score, e.g.
+// "boosted because: feed X, keyword Y; lowered because: length long".
+func Explain(reasons []models.ScoreReason) string {
+	var boosted, lowered []string
+	for _, r := range reasons {
+		if math.Abs(r.Contribution) < minExplained || len(boosted)+len(lowered) == maxExplained {
+			continue
+		}
+		text := r.Kind + " " + r.Name
+		if r.Kind == KindSimilarity {
+			text = r.Name
+		}
+		if r.Contribution > 0 {
+			boosted = append(boosted, text)
+		} else {
+			lowered = append(lowered, text)
+		}
+	}
+
+	var parts []string
+	if len(boosted) > 0 {
+		parts = append(parts, "boosted because: "+strings.Join(boosted, ", "))
+	}
+	if len(lowered) > 0 {
+		parts = append(parts, "lowered because: "+strings.Join(lowered, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// features returns the features of an article.
+func features(a *database.ArticleFeatures) []feature {
+	result := []feature{{key: "feed:" + strconv.FormatInt(a.FeedID, 10), kind: KindFeed, name: a.FeedTitle, strength: 1}}
+	for _, label := range a.Labels {
+		result = append(result, feature{key: "label:" + strings.ToLower(label), kind: KindLabel, name: label, strength: 1})
+	}
+	for _, k := range a.Keywords {
+		result = append(result, feature{key: "keyword:" + k.Term, kind: KindKeyword, name: k.Text, strength: k.Score})
+	}
+	if bucket := lengthBucket(a.WordCount); bucket != "" {
+		result = append(result, feature{key: "length:" + bucket, kind: KindLength, name: bucket, strength: 1})
+	}
+	return result
+}
+
+// lengthBucket classifies an article by its number of words, or returns "" if unknown.
+func lengthBucket(words int) string {
+	switch {
+	case words <= 0:
+		return ""
+	case words < 300:
+		return "short"
+	case words < 1200:
+		return "medium"
+	default:
+		return "long"
+	}
+}
+
+// round rounds to two decimals, which is all explanations need.
+func round(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,127 @@
+package ranking
+
+import (
+	"strings"
+	"testing"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestSignalValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal string
+		value  float64
+		words  int
+		want   float64
+	}{
+		{"favorite", database.FeedbackFavorite, 0, 0, favoriteValue},
+		{"read later", database.FeedbackReadLater, 0, 0, readLaterValue},
+		{"skip", database.FeedbackSkip, 0, 0, skipValue},
+		{"quick close", database.FeedbackRead, 5, 1000, skipValue},
+		{"full read", database.FeedbackRead, 300, 1000, fullReadValue},
+		{"unknown length read", database.FeedbackRead, 60, 0, fullReadValue},
+		{"unknown signal", "other", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignalValue(tt.signal, tt.value, tt.words); got != tt.want {
+				t.Errorf("SignalValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	glance := SignalValue(database.FeedbackRead, 15, 2300)
+	if glance >= 0 {
+		t.Errorf("expected a glance at a long article to count against it, got %v", glance)
+	}
+}
+
+func TestModelScoresAndExplains(t *testing.T) {
+	tech := func(id int64) *database.ArticleFeatures {
+		return &database.ArticleFeatures{
+			ArticleID: id, FeedID: 1, FeedTitle: "Tech Daily", Labels: []string{"Security"},
+			Keywords: []models.ArticleKeyword{{Term: "rust", Text: "Rust", Score: 1}}, WordCount: 800,
+		}
+	}
+	gossip := func(id int64) *database.ArticleFeatures {
+		return &database.ArticleFeatures{ArticleID: id, FeedID: 2, FeedTitle: "Gossip", WordCount: 100}
+	}
+
+	model := Train([]Example{
+		{Features: tech(1), Value: favoriteValue},
+		{Features: tech(2), Value: fullReadValue},
+		{Features: gossip(3), Value: skipValue},
+		{Features: gossip(4), Value: skipValue},
+	})
+	if model.Examples() != 4 {
+		t.Errorf("Examples() = %d, want 4", model.Examples())
+	}
+
+	liked := model.Score(tech(10), nil)
+	disliked := model.Score(gossip(11), nil)
+	unknown := model.Score(&database.ArticleFeatures{ArticleID: 12, FeedID: 3, FeedTitle: "New"}, nil)
+
+	if liked.Score <= 0 || disliked.Score >= 0 || unknown.Score != 0 {
+		t.Fatalf("unexpected scores: liked %v, disliked %v, unknown %v", liked.Score, disliked.Score, unknown.Score)
+	}
+	if liked.Reasons[0].Kind != KindFeed || liked.Reasons[0].Name != "Tech Daily" {
+		t.Errorf("expected the feed to contribute most, got %+v", liked.Reasons)
+	}
+	if !strings.HasPrefix(liked.Explanation, "boosted because: feed Tech Daily, label Security") {
+		t.Errorf("unexpected explanation %q", liked.Explanation)
+	}
+	if !strings.HasPrefix(disliked.Explanation, "lowered because: feed Gossip") {
+		t.Errorf("unexpected explanation %q", disliked.Explanation)
+	}
+	if unknown.Explanation != "" || len(unknown.Reasons) != 0 {
+		t.Errorf("expected no reasons for an unknown article, got %+v", unknown)
+	}
+}
+
+func TestModelFeedbackDecays(t *testing.T) {
+	article := &database.ArticleFeatures{ArticleID: 1, FeedID: 1, FeedTitle: "Feed"}
+	recent := Train([]Example{{Features: article, Value: favoriteValue}})
+	old := Train([]Example{{Features: article, Value: favoriteValue, Age: 2 * halfLife}})
+
+	if r, o := recent.Score(article, nil).Score, old.Score(article, nil).Score; o >= r || o <= 0 {
+		t.Errorf("expected old feedback to count less: recent %v, old %v", r, o)
+	}
+}
+
+func TestModelSimilarity(t *testing.T) {
+	article := func(id, feedID int64) *database.ArticleFeatures {
+		return &database.ArticleFeatures{ArticleID: id, FeedID: feedID, FeedTitle: "Feed"}
+	}
+	model := Train([]Example{{Features: article(1, 1), Value: favoriteValue, Vector: []float32{1, 0}}})
+
+	similar := model.Score(article(2, 2), []float32{0.9, 0.1})
+	different := model.Score(article(3, 2), []float32{0, 1})
+	if similar.Score <= 0 || similar.Reasons[0].Kind != KindSimilarity {
+		t.Errorf("expected similarity to boost the article, got %+v", similar)
+	}
+	if similar.Explanation != "boosted because: "+similarityName {
+		t.Errorf("unexpected explanation %q", similar.Explanation)
+	}
+	if different.Score != 0 {
+		t.Errorf("expected no boost for an unrelated article, got %+v", different)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	reasons := []models.ScoreReason{
+		{Kind: KindKeyword, Name: "Rust", Contribution: 0.9},
+		{Kind: KindLength, Name: "long", Contribution: -0.4},
+		{Kind: KindFeed, Name: "Tech", Contribution: 0.3},
+		{Kind: KindLabel, Name: "Security", Contribution: 0.2},
+		{Kind: KindLabel, Name: "Tiny", Contribution: 0.05},
+	}
+	want := "boosted because: keyword Rust, feed Tech; lowered because: length long"
+	if got := Explain(reasons); got != want {
+		t.Errorf("Explain() = %q, want %q", got, want)
+	}
+	if got := Explain(nil); got != "" {
+		t.Errorf("Explain(nil) = %q", got)
+	}
+}
@@ -0,0 +1,321 @@
+package ranking
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// maxFeedback bounds the feedback signals the model learns from, newest first
+	maxFeedback = 5000
+	// maxRescored bounds the unread articles rescored when the model changes, newest first
+	maxRescored = 5000
+	// chunkSize is the number of articles scored per query
+	chunkSize = 500
+	// rescoreInterval is how often all unread articles are rescored without new feedback,
+	// which also scores articles saved outside the fetcher, e.g. by sync
+	rescoreInterval = time.Hour
+	// settleDelay lets bursts of feedback or fetched articles settle before scoring
+	settleDelay = 3 * time.Second
+)
+
+// Service scores newly fetched articles and rescores unread articles in the background
+// when feedback changes the model.
+type Service struct {
+	db *database.DB
+
+	wake chan struct{}
+
+	mu    sync.Mutex
+	fresh map[int64]bool // Newly fetched articles waiting to be scored
+	stale bool           // Feedback changed since unread articles were last scored
+}
+
+// New creates a ranking service. All unread articles are scored when it starts running.
+func New(db *database.DB) *Service {
+	return &Service{
+		db:    db,
+		wake:  make(chan struct{}, 1),
+		fresh: make(map[int64]bool),
+		stale: true,
+	}
+}
+
+// EnqueueArticles scores newly saved articles of a feed.
+func (s *Service) EnqueueArticles(feed models.Feed, articleIDs []int64) {
+	if len(articleIDs) == 0 {
+		return
+	}
+	s.mu.Lock()
+	for _, id := range articleIDs {
+		s.fresh[id] = true
+	}
+	s.mu.Unlock()
+	s.Trigger()
+}
+
+// Reset forgets the queued articles and rescores all unread articles, e.g. after the
+// database was replaced by a backup whose article IDs they do not match.
+func (s *Service) Reset() {
+	s.mu.Lock()
+	s.fresh = make(map[int64]bool)
+	s.stale = true
+	s.mu.Unlock()
+	s.Trigger()
+}
+
+// RecordState records the favorite and read later state of an article after the user
+// changed it.
+func (s *Service) RecordState(articleID int64) error {
+	if err := s.db.SyncArticleFeedback(articleID); err != nil {
+		return err
+	}
+	s.feedbackChanged()
+	return nil
+}
+
+// RecordReading records that the user read an article for a number of seconds.
+// Closing it within QuickReadSeconds counts as skipping it.
+func (s *Service) RecordReading(articleID int64, seconds float64) error {
+	if seconds < QuickReadSeconds {
+		return s.RecordSkip(articleID)
+	}
+	if err := s.db.SetArticleFeedback(articleID, database.FeedbackRead, seconds); err != nil {
+		return err
+	}
+	s.feedbackChanged()
+	return nil
+}
+
+// RecordSkip records that the user marked an article as read without reading it.
+func (s *Service) RecordSkip(articleID int64) error {
+	if err := s.db.SetArticleFeedback(articleID, database.FeedbackSkip, 0); err != nil {
+		return err
+	}
+	s.feedbackChanged()
+	return nil
+}
+
+// RecordMarkRead records that the user marked an article as read, which counts as
+// skipping it unless reading feedback says otherwise, or as unread, which withdraws
+// the skip.
+func (s *Service) RecordMarkRead(articleID int64, read bool) error {
+	var err error
+	if read {
+		err = s.db.AddSkipFeedback(articleID)
+	} else {
+		err = s.db.DeleteArticleFeedback(articleID, database.FeedbackSkip)
+	}
+	if err != nil {
+		return err
+	}
+	s.feedbackChanged()
+	return nil
+}
+
+// Trigger starts scoring without waiting for the next rescore.
+func (s *Service) Trigger() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// feedbackChanged schedules rescoring the unread articles.
+func (s *Service) feedbackChanged() {
+	s.mu.Lock()
+	s.stale = true
+	s.mu.Unlock()
+	s.Trigger()
+}
+
+// Run scores articles until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(rescoreInterval)
+	defer ticker.Stop()
+
+	for {
+		s.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(settleDelay):
+			}
+		case <-ticker.C:
+			s.mu.Lock()
+			s.stale = true
+			s.mu.Unlock()
+		}
+	}
+}
+
+// process scores the fresh articles, and all unread articles if the model is stale.
+func (s *Service) process(ctx context.Context) {
+	s.mu.Lock()
+	stale, fresh := s.stale, s.fresh
+	s.stale, s.fresh = false, make(map[int64]bool)
+	s.mu.Unlock()
+	if !stale && len(fresh) == 0 {
+		return
+	}
+
+	var ids []int64
+	if stale {
+		unread, err := s.db.GetUnreadArticleIDs(maxRescored)
+		if err != nil {
+			log.Printf("Error loading unread articles for ranking: %v", err)
+			return
+		}
+		ids = unread
+	}
+	for id := range fresh {
+		ids = append(ids, id)
+	}
+
+	model, embeddingModel, err := s.train()
+	if err != nil {
+		log.Printf("Error training ranking model: %v", err)
+		return
+	}
+	scored, err := s.scoreArticles(ctx, model, embeddingModel, ids)
+	if err != nil {
+		log.Printf("Error scoring articles: %v", err)
+		return
+	}
+	utils.DebugLog("Scored %d articles with a ranking model from %d feedback signals", scored, model.Examples())
+}
+
+// ScoreArticle scores an article with the current model and stores the score, or
+// returns nil if the article does not exist.
+func (s *Service) ScoreArticle(ctx context.Context, articleID int64) (*models.ArticleScore, error) {
+	model, embeddingModel, err := s.train()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.scoreArticles(ctx, model, embeddingModel, []int64{articleID}); err != nil {
+		return nil, err
+	}
+	return s.db.GetArticleScore(articleID)
+}
+
+// train learns a model from the stored feedback. It also returns the embeddings model
+// used for similarity, or "" if similarity is not used.
+func (s *Service) train() (*Model, string, error) {
+	feedback, err := s.db.GetArticleFeedback(maxFeedback)
+	if err != nil {
+		return nil, "", err
+	}
+	ids := make([]int64, 0, len(feedback))
+	seen := make(map[int64]bool)
+	for _, f := range feedback {
+		if !seen[f.ArticleID] {
+			seen[f.ArticleID] = true
+			ids = append(ids, f.ArticleID)
+		}
+	}
+	features, err := s.features(ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	embeddingModel := s.embeddingModel()
+	now := time.Now()
+	var examples []Example
+	for _, f := range feedback {
+		a, ok := features[f.ArticleID]
+		if !ok {
+			continue
+		}
+		ex := Example{Features: a, Value: SignalValue(f.Signal, f.Value, a.WordCount), Age: now.Sub(f.CreatedAt)}
+		if ex.Value > 0 && embeddingModel != "" {
+			ex.Vector = s.vector(f.ArticleID, embeddingModel)
+		}
+		examples = append(examples, ex)
+	}
+	return Train(examples), embeddingModel, nil
+}
+
+// scoreArticles scores and stores articles in chunks, returning the number scored.
+func (s *Service) scoreArticles(ctx context.Context, model *Model, embeddingModel string, ids []int64) (int, error) {
+	scored := 0
+	for start := 0; start < len(ids); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return scored, err
+		}
+		chunk := ids[start:min(start+chunkSize, len(ids))]
+		features, err := s.features(chunk)
+		if err != nil {
+			return scored, err
+		}
+
+		now := time.Now()
+		var scores []models.ArticleScore
+		for _, id := range chunk {
+			a, ok := features[id]
+			if !ok {
+				continue
+			}
+			var vector []float32
+			if embeddingModel != "" && model.centroid != nil {
+				vector = s.vector(id, embeddingModel)
+			}
+			score := model.Score(a, vector)
+			score.ScoredAt = now
+			scores = append(scores, score)
+		}
+		if err := s.db.SaveArticleScores(scores); err != nil {
+			return scored, err
+		}
+		scored += len(scores)
+	}
+	return scored, nil
+}
+
+// features loads the features of articles in chunks.
+func (s *Service) features(ids []int64) (map[int64]*database.ArticleFeatures, error) {
+	result := make(map[int64]*database.ArticleFeatures, len(ids))
+	for start := 0; start < len(ids); start += chunkSize {
+		chunk, err := s.db.GetArticleFeatures(ids[start:min(start+chunkSize, len(ids))])
+		if err != nil {
+			return nil, err
+		}
+		for id, a := range chunk {
+			result[id] = a
+		}
+	}
+	return result, nil
+}
+
+// embeddingModel returns the embeddings model whose vectors add similarity to scores,
+// or "" if embeddings or semantic ranking are disabled.
+func (s *Service) embeddingModel() string {
+	if enabled, _ := s.db.GetSetting("ai_embedding_enabled"); enabled != "true" {
+		return ""
+	}
+	if semantic, _ := s.db.GetSetting("ranking_semantic_enabled"); semantic == "false" {
+		return ""
+	}
+	cfg, _ := aiclient.EmbeddingConfigFromSettings(s.db)
+	return cfg.Model
+}
+
+// vector returns the stored embedding of an article from a model, or nil.
+func (s *Service) vector(articleID int64, model string) []float32 {
+	m, vector, err := s.db.GetArticleEmbedding(articleID)
+	if err != nil || m != model {
+		return nil
+	}
+	return vector
+}
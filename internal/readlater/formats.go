@@ -0,0 +1,393 @@
+package readlater
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// isCSV reports whether data looks like a CSV file rather than HTML.
+func isCSV(data []byte) bool {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	return len(data) > 0 && data[0] != '<'
+}
+
+// readCSV reads a CSV file with a header row into rows keyed by the lowercase column names.
+func readCSV(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parsePocketCSV parses the CSV export of Pocket, with title, url, time_added, tags
+// separated by "|" and the status "unread" or "archive".
+func parsePocketCSV(data []byte) ([]Entry, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, Entry{
+			URL:      row["url"],
+			Title:    row["title"],
+			AddedAt:  unixTime(row["time_added"]),
+			Tags:     strings.Split(row["tags"], "|"),
+			Archived: row["status"] == "archive",
+		})
+	}
+	return entries, nil
+}
+
+// parseInstapaper parses the CSV export of Instapaper, with URL, Title, Selection,
+// Folder, Timestamp and Tags. Articles in the Archive folder are archived and those
+// in Starred starred; other folders become tags.
+func parseInstapaper(data []byte) ([]Entry, error) {
+	rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		e := Entry{
+			URL:     row["url"],
+			Title:   row["title"],
+			AddedAt: unixTime(row["timestamp"]),
+		}
+		if tags := row["tags"]; tags != "" {
+			// Tags are a JSON array in newer exports
+			if err := json.Unmarshal([]byte(tags), &e.Tags); err != nil {
+				e.Tags = strings.Split(tags, ",")
+			}
+		}
+		switch folder := row["folder"]; strings.ToLower(folder) {
+		case "", "unread":
+		case "archive":
+			e.Archived = true
+		case "starred":
+			e.Favorite = true
+		default:
+			e.Tags = append(e.Tags, folder)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// htmlLink is a link of an HTML export with the context it appears in.
+type htmlLink struct {
+	attrs   map[string]string // Attributes, with lowercase names
+	title   string
+	heading string   // Text of the last h1 before the link
+	folders []string // Titles of the enclosing bookmark folders
+}
+
+// readHTMLLinks reads the links of an HTML export. Bookmark folders are h3 headings
+// followed by a dl list of their bookmarks.
+func readHTMLLinks(data []byte) ([]htmlLink, error) {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	var links []htmlLink
+	var folders []string
+	var heading, folder string
+	var text strings.Builder
+	var inHeading, inFolder bool
+	var link *htmlLink
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return links, nil
+			}
+			return nil, z.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "h1":
+				inHeading = true
+				text.Reset()
+			case "h3":
+				inFolder = true
+				text.Reset()
+			case "dl":
+				folders = append(folders, folder)
+				folder = ""
+			case "a":
+				attrs := make(map[string]string)
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = z.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+				link = &htmlLink{attrs: attrs, heading: heading}
+				for _, f := range folders {
+					if f != "" {
+						link.folders = append(link.folders, f)
+					}
+				}
+				text.Reset()
+			}
+
+		case html.TextToken:
+			if inHeading || inFolder || link != nil {
+				text.Write(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h1":
+				inHeading = false
+				heading = strings.TrimSpace(text.String())
+			case "h3":
+				inFolder = false
+				folder = strings.TrimSpace(text.String())
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "a":
+				if link != nil {
+					link.title = strings.TrimSpace(text.String())
+					links = append(links, *link)
+					link = nil
+				}
+			}
+		}
+	}
+}
+
+// parsePocketHTML parses the HTML export of Pocket: lists of links with time_added and
+// comma-separated tags, below an "Unread" and a "Read Archive" heading.
+func parsePocketHTML(data []byte) ([]Entry, error) {
+	links, err := readHTMLLinks(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(links))
+	for _, l := range links {
+		entries = append(entries, Entry{
+			URL:      l.attrs["href"],
+			Title:    l.title,
+			AddedAt:  unixTime(l.attrs["time_added"]),
+			Tags:     strings.Split(l.attrs["tags"], ","),
+			Archived: strings.Contains(strings.ToLower(l.heading), "archive"),
+		})
+	}
+	return entries, nil
+}
+
+// parseBookmarks parses a Netscape bookmarks file, as exported by browsers, Raindrop
+// and Pinboard. Bookmarks are starred and tagged with their folders. Bookmarks marked
+// to read are saved for later instead.
+func parseBookmarks(data []byte) ([]Entry, error) {
+	links, err := readHTMLLinks(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(links))
+	for _, l := range links {
+		toRead := l.attrs["toread"] == "1"
+		tags := append(l.folders, strings.Split(l.attrs["tags"], ",")...)
+		entries = append(entries, Entry{
+			URL:      l.attrs["href"],
+			Title:    l.title,
+			AddedAt:  unixTime(l.attrs["add_date"]),
+			Tags:     tags,
+			Archived: !toRead,
+			Favorite: !toRead,
+		})
+	}
+	return entries, nil
+}
+
+// flexBool is a boolean that is also read from 0, 1 and strings, as in Wallabag exports.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseBool(strings.Trim(string(data), `"`))
+	if err != nil && string(data) != "null" {
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	*b = flexBool(v)
+	return nil
+}
+
+// wallabagTag is a tag of a Wallabag export, a string or an object with a label.
+type wallabagTag string
+
+func (t *wallabagTag) UnmarshalJSON(data []byte) error {
+	var label string
+	if err := json.Unmarshal(data, &label); err == nil {
+		*t = wallabagTag(label)
+		return nil
+	}
+	var tag struct {
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	*t = wallabagTag(tag.Label)
+	return nil
+}
+
+// parseWallabag parses the JSON export of Wallabag, an array of entries with their
+// content.
+func parseWallabag(data []byte) ([]Entry, error) {
+	var items []struct {
+		URL        string        `json:"url"`
+		Title      string        `json:"title"`
+		Content    string        `json:"content"`
+		CreatedAt  string        `json:"created_at"`
+		IsArchived flexBool      `json:"is_archived"`
+		IsStarred  flexBool      `json:"is_starred"`
+		Tags       []wallabagTag `json:"tags"`
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse wallabag export: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		e := Entry{
+			URL:      item.URL,
+			Title:    item.Title,
+			AddedAt:  parseTime(item.CreatedAt),
+			Archived: bool(item.IsArchived),
+			Favorite: bool(item.IsStarred),
+			Content:  item.Content,
+		}
+		for _, tag := range item.Tags {
+			e.Tags = append(e.Tags, string(tag))
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// feedlyItem is an entry of a Feedly saved items export.
+type feedlyItem struct {
+	OriginID     string `json:"originId"`
+	CanonicalURL string `json:"canonicalUrl"`
+	Alternate    []struct {
+		Href string `json:"href"`
+	} `json:"alternate"`
+	Title           string `json:"title"`
+	Published       int64  `json:"published"`
+	Crawled         int64  `json:"crawled"`
+	ActionTimestamp int64  `json:"actionTimestamp"` // When the item was saved
+	Tags            []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+	} `json:"tags"`
+	Content *struct {
+		Content string `json:"content"`
+	} `json:"content"`
+	Summary *struct {
+		Content string `json:"content"`
+	} `json:"summary"`
+}
+
+// parseFeedly parses the saved items export of Feedly, an array of entries or a stream
+// with the entries as items. Saved items are saved for later and keep the user's tags.
+func parseFeedly(data []byte) ([]Entry, error) {
+	var items []feedlyItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		var stream struct {
+			Items []feedlyItem `json:"items"`
+		}
+		if err := json.Unmarshal(data, &stream); err != nil {
+			return nil, fmt.Errorf("parse feedly export: %w", err)
+		}
+		items = stream.Items
+	}
+
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		e := Entry{URL: item.CanonicalURL, Title: item.Title}
+		for _, alt := range item.Alternate {
+			if e.URL == "" {
+				e.URL = alt.Href
+			}
+		}
+		if e.URL == "" && (strings.HasPrefix(item.OriginID, "http://") || strings.HasPrefix(item.OriginID, "https://")) {
+			e.URL = item.OriginID
+		}
+		for _, ms := range []int64{item.ActionTimestamp, item.Crawled, item.Published} {
+			if ms > 0 {
+				e.AddedAt = time.UnixMilli(ms)
+				break
+			}
+		}
+		for _, tag := range item.Tags {
+			// Global tags such as global.saved are Feedly's own
+			if !strings.Contains(tag.ID, "/tag/global.") {
+				e.Tags = append(e.Tags, tag.Label)
+			}
+		}
+		if item.Content != nil {
+			e.Content = item.Content.Content
+		} else if item.Summary != nil {
+			e.Content = item.Summary.Content
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// unixTime parses a Unix timestamp in seconds, returning the zero time if it is invalid.
+func unixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// parseTime parses the timestamps of JSON exports, returning the zero time if it is invalid.
+func parseTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05-0700", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
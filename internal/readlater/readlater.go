@@ -0,0 +1,260 @@
+// Package readlater imports the saved articles of read-it-later services and bookmark
+// managers from their export files. Entries become articles of the local "Imported"
+// feed, keeping when they were saved, their tags, and whether they were archived or
+// starred.
+package readlater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/feed"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// FeedURL is the URL of the local feed imported articles are added to
+	FeedURL = feed.LocalURLPrefix + "imported"
+	// FeedTitle is the title the imported feed is created with
+	FeedTitle = "Imported"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatPocket     Format = "pocket"     // Pocket HTML or CSV export
+	FormatInstapaper Format = "instapaper" // Instapaper CSV export
+	FormatWallabag   Format = "wallabag"   // Wallabag JSON export
+	FormatBookmarks  Format = "bookmarks"  // Netscape bookmarks HTML, e.g. of browsers or Raindrop
+	FormatFeedly     Format = "feedly"     // Feedly saved items JSON
+)
+
+// ErrUnknownFormat is returned for files that are not in a supported format.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Entry is a saved article of an export file.
+type Entry struct {
+	URL      string
+	Title    string
+	AddedAt  time.Time // When the article was saved, zero if unknown
+	Tags     []string
+	Archived bool   // Read and archived, rather than saved for later
+	Favorite bool   // Starred or liked
+	Content  string // Article HTML stored by the service, if any
+}
+
+// Result describes what an import added.
+type Result struct {
+	FeedID     int64   `json:"feed_id"`
+	Added      int     `json:"added"`
+	Duplicates int     `json:"duplicates"` // Entries whose URL already is an article
+	Skipped    int     `json:"skipped"`    // Entries without an http or https URL
+	AddedIDs   []int64 `json:"-"`
+}
+
+// Parse parses an export file. An empty format detects the format from the content,
+// with the file name as a hint.
+func Parse(data []byte, format Format, filename string) ([]Entry, error) {
+	if format == "" {
+		format = Detect(data, filename)
+	}
+	switch format {
+	case FormatPocket:
+		if isCSV(data) {
+			return parsePocketCSV(data)
+		}
+		return parsePocketHTML(data)
+	case FormatInstapaper:
+		return parseInstapaper(data)
+	case FormatWallabag:
+		return parseWallabag(data)
+	case FormatBookmarks:
+		return parseBookmarks(data)
+	case FormatFeedly:
+		return parseFeedly(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Detect returns the format of an export file, or "" if it is not recognized.
+func Detect(data []byte, filename string) Format {
+	head := strings.ToLower(string(data[:min(len(data), 4096)]))
+	head = strings.TrimSpace(strings.TrimPrefix(head, "\ufeff"))
+
+	switch {
+	case strings.HasPrefix(head, "[") || strings.HasPrefix(head, "{"):
+		if strings.Contains(head, `"is_archived"`) || strings.Contains(head, `"is_starred"`) {
+			return FormatWallabag
+		}
+		if strings.Contains(head, `"originid"`) || strings.Contains(head, `"alternate"`) || strings.Contains(head, `"crawled"`) {
+			return FormatFeedly
+		}
+	case strings.Contains(head, "netscape-bookmark-file"):
+		return FormatBookmarks
+	case strings.Contains(head, "time_added") || strings.Contains(head, "<title>pocket export"):
+		return FormatPocket
+	case strings.HasPrefix(head, "url,title"):
+		return FormatInstapaper
+	case strings.HasPrefix(head, "<"):
+		if strings.HasSuffix(strings.ToLower(filename), ".html") || strings.Contains(head, "<dl") {
+			return FormatBookmarks
+		}
+	}
+	return ""
+}
+
+// EnsureFeed returns the ID of the imported feed, creating it if needed.
+func EnsureFeed(db *database.DB) (int64, error) {
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range feeds {
+		if f.URL == FeedURL {
+			return f.ID, nil
+		}
+	}
+	return db.AddFeed(&models.Feed{
+		Title:       FeedTitle,
+		URL:         FeedURL,
+		Description: "Articles imported from read-it-later services and bookmarks",
+	})
+}
+
+// Import adds the entries as articles of the imported feed. Archived entries are
+// marked read and the others read later. Entries whose URL already is an article,
+// ignoring query parameters such as tracking ones, are skipped, but stay starred if
+// they were starred in the export.
+func Import(ctx context.Context, db *database.DB, entries []Entry) (*Result, error) {
+	feedID, err := EnsureFeed(db)
+	if err != nil {
+		return nil, fmt.Errorf("create imported feed: %w", err)
+	}
+
+	result := &Result{FeedID: feedID}
+	seen := make(map[string]bool)
+	var articles []*models.Article
+	var imported []Entry
+	for _, e := range entries {
+		e.URL = strings.TrimSpace(e.URL)
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.Skipped++
+			continue
+		}
+		normalized := utils.NormalizeURLForComparison(e.URL)
+		if seen[normalized] {
+			result.Duplicates++
+			continue
+		}
+		seen[normalized] = true
+
+		existing, err := db.GetArticleStateByNormalizedURL(normalized)
+		if err != nil {
+			return nil, fmt.Errorf("get article: %w", err)
+		}
+		if existing != nil {
+			result.Duplicates++
+			if e.Favorite && !existing.Favorite {
+				if err := db.SetArticleFavorite(existing.ID, true); err != nil {
+					return nil, fmt.Errorf("star article: %w", err)
+				}
+			}
+			continue
+		}
+
+		title := strings.TrimSpace(e.Title)
+		if title == "" {
+			title = e.URL
+		}
+		addedAt := e.AddedAt
+		if addedAt.IsZero() {
+			addedAt = time.Now()
+		}
+		articles = append(articles, &models.Article{
+			FeedID:      feedID,
+			Title:       title,
+			URL:         e.URL,
+			PublishedAt: addedAt,
+			IsRead:      e.Archived,
+			IsReadLater: !e.Archived,
+			IsFavorite:  e.Favorite,
+		})
+		imported = append(imported, e)
+	}
+
+	if len(articles) == 0 {
+		return result, nil
+	}
+	if err := db.SaveArticles(ctx, articles); err != nil {
+		return nil, fmt.Errorf("save articles: %w", err)
+	}
+	for i, article := range articles {
+		if article.ID == 0 {
+			result.Duplicates++
+			continue
+		}
+		result.Added++
+		result.AddedIDs = append(result.AddedIDs, article.ID)
+
+		if tags := cleanTags(imported[i].Tags); len(tags) > 0 {
+			if err := db.SetArticleLabels(article.ID, tags, "import"); err != nil {
+				log.Printf("Error saving tags of imported article %d: %v", article.ID, err)
+			}
+		}
+		if imported[i].Content != "" {
+			if err := db.UpdateArticleContent(article.ID, imported[i].Content); err != nil {
+				log.Printf("Error saving content of imported article %d: %v", article.ID, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// FetchFullText fetches the full text of the imported articles that have no stored
+// content, e.g. with readability, and stores it.
+func FetchFullText(ctx context.Context, db *database.DB, ids []int64, fetch func(url string) (string, error)) {
+	texts, err := db.GetArticleTextsByIDs(ids)
+	if err != nil {
+		log.Printf("Error getting imported articles: %v", err)
+		return
+	}
+	for _, text := range texts {
+		if ctx.Err() != nil {
+			return
+		}
+		if text.Content != "" {
+			continue
+		}
+		content, err := fetch(text.URL)
+		if err != nil {
+			log.Printf("Error fetching full text of %s: %v", text.URL, err)
+			continue
+		}
+		if err := db.UpdateArticleContent(text.ID, content); err != nil {
+			log.Printf("Error saving full text of %s: %v", text.URL, err)
+		}
+	}
+}
+
+// cleanTags trims the tags and drops empty and repeated ones.
+func cleanTags(tags []string) []string {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		cleaned = append(cleaned, tag)
+	}
+	return cleaned
+}
@@ -0,0 +1,210 @@
+package readlater
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/feed"
+	"MrRSS/internal/models"
+)
+
+const pocketHTML = `<!DOCTYPE html>
+<html><head><title>Pocket Export</title></head><body>
+<h1>Unread</h1>
+<ul>
+<li><a href="https://example.com/unread" time_added="1700000000" tags="go,tools">Unread Article</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+<li><a href="https://example.com/read" time_added="1600000000" tags="">Read Article</a></li>
+</ul>
+</body></html>`
+
+const bookmarksHTML = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1600000000">Dev</H3>
+    <DL><p>
+        <DT><H3>Go</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/blog" ADD_DATE="1700000000" TAGS="lang">The Go Blog</A>
+        </DL><p>
+        <DT><A HREF="https://example.com/later" ADD_DATE="1700000001" TOREAD="1">Later</A>
+    </DL><p>
+    <DT><A HREF="place:sort=8">Recent</A>
+</DL><p>`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   []Entry
+	}{
+		{
+			name:   "pocket html",
+			format: FormatPocket,
+			data:   pocketHTML,
+			want: []Entry{
+				{URL: "https://example.com/unread", Title: "Unread Article", AddedAt: time.Unix(1700000000, 0), Tags: []string{"go", "tools"}},
+				{URL: "https://example.com/read", Title: "Read Article", AddedAt: time.Unix(1600000000, 0), Tags: []string{""}, Archived: true},
+			},
+		},
+		{
+			name:   "pocket csv",
+			format: FormatPocket,
+			data:   "title,url,time_added,tags,status\nRead,https://example.com/read,1600000000,go|tools,archive\n",
+			want: []Entry{
+				{URL: "https://example.com/read", Title: "Read", AddedAt: time.Unix(1600000000, 0), Tags: []string{"go", "tools"}, Archived: true},
+			},
+		},
+		{
+			name:   "instapaper",
+			format: FormatInstapaper,
+			data: "URL,Title,Selection,Folder,Timestamp,Tags\n" +
+				"https://example.com/a,\"A, with comma\",,Starred,1700000000,\"[\"\"go\"\"]\"\n" +
+				"https://example.com/b,B,,Archive,1600000000,\n" +
+				"https://example.com/c,C,,Recipes,1500000000,\n",
+			want: []Entry{
+				{URL: "https://example.com/a", Title: "A, with comma", AddedAt: time.Unix(1700000000, 0), Tags: []string{"go"}, Favorite: true},
+				{URL: "https://example.com/b", Title: "B", AddedAt: time.Unix(1600000000, 0), Archived: true},
+				{URL: "https://example.com/c", Title: "C", AddedAt: time.Unix(1500000000, 0), Tags: []string{"Recipes"}},
+			},
+		},
+		{
+			name:   "wallabag",
+			format: FormatWallabag,
+			data: `[{"is_archived":1,"is_starred":0,"tags":["go"],"title":"A","url":"https://example.com/a","content":"<p>A</p>","created_at":"2023-11-14T22:13:20+00:00"},
+				{"is_archived":false,"is_starred":true,"tags":[{"label":"news"}],"title":"B","url":"https://example.com/b","created_at":"bad"}]`,
+			want: []Entry{
+				{URL: "https://example.com/a", Title: "A", AddedAt: time.Unix(1700000000, 0), Tags: []string{"go"}, Archived: true, Content: "<p>A</p>"},
+				{URL: "https://example.com/b", Title: "B", Tags: []string{"news"}, Favorite: true},
+			},
+		},
+		{
+			name:   "bookmarks",
+			format: FormatBookmarks,
+			data:   bookmarksHTML,
+			want: []Entry{
+				{URL: "https://go.dev/blog", Title: "The Go Blog", AddedAt: time.Unix(1700000000, 0), Tags: []string{"Dev", "Go", "lang"}, Archived: true, Favorite: true},
+				{URL: "https://example.com/later", Title: "Later", AddedAt: time.Unix(1700000001, 0), Tags: []string{"Dev", ""}},
+				{URL: "place:sort=8", Title: "Recent", Tags: []string{""}, Archived: true, Favorite: true},
+			},
+		},
+		{
+			name:   "feedly",
+			format: FormatFeedly,
+			data: `{"items":[{"originId":"tag:example.com,1","alternate":[{"href":"https://example.com/a","type":"text/html"}],"title":"A",
+				"crawled":1600000000000,"actionTimestamp":1700000000000,"tags":[{"id":"user/1/tag/global.saved"},{"id":"user/1/tag/go","label":"go"}],
+				"summary":{"content":"<p>A</p>"}}]}`,
+			want: []Entry{
+				{URL: "https://example.com/a", Title: "A", AddedAt: time.UnixMilli(1700000000000), Tags: []string{"go"}, Content: "<p>A</p>"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.data), ""); got != tt.format {
+				t.Errorf("Detect() = %q, want %q", got, tt.format)
+			}
+			got, err := Parse([]byte(tt.data), tt.format, "")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			for i := range got {
+				// Compare instants, not locations
+				if !got[i].AddedAt.IsZero() {
+					got[i].AddedAt = time.Unix(0, got[i].AddedAt.UnixNano())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse([]byte("just some text"), "", "notes.txt"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Parse() error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Blog", URL: "https://example.com/feed"})
+	db.SaveArticles(ctx, []*models.Article{{FeedID: feedID, Title: "Known", URL: "https://example.com/known?utm_source=feed"}})
+
+	result, err := Import(ctx, db, []Entry{
+		{URL: "https://example.com/new", Title: "New", AddedAt: time.Unix(1700000000, 0), Tags: []string{"go", " Go ", ""}, Content: "<p>Stored</p>"},
+		{URL: "https://example.com/archived", Archived: true, Favorite: true},
+		{URL: "https://example.com/known", Title: "Known", Favorite: true},
+		{URL: "https://example.com/new#comments", Title: "Again"},
+		{URL: "place:sort=8"},
+	})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Added != 2 || result.Duplicates != 2 || result.Skipped != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	imported, err := db.GetFeedByID(result.FeedID)
+	if err != nil || imported.URL != FeedURL || !feed.IsLocal(*imported) {
+		t.Fatalf("imported feed = %+v, %v", imported, err)
+	}
+	articles, _ := db.GetArticles("", result.FeedID, "", true, 10, 0)
+	byURL := make(map[string]models.Article)
+	for _, a := range articles {
+		byURL[a.URL] = a
+	}
+	if a := byURL["https://example.com/new"]; a.IsRead || !a.IsReadLater || !reflect.DeepEqual(a.Labels, []string{"go"}) {
+		t.Errorf("unexpected new article: %+v", a)
+	}
+	if a := byURL["https://example.com/archived"]; !a.IsRead || a.IsReadLater || !a.IsFavorite || a.Title != a.URL {
+		t.Errorf("unexpected archived article: %+v", a)
+	}
+	var source string
+	if err := db.QueryRow("SELECT source FROM article_labels WHERE article_id = ?", byURL["https://example.com/new"].ID).Scan(&source); err != nil || source != "import" {
+		t.Errorf("label source = %q, %v, want import", source, err)
+	}
+	if texts, _ := db.GetArticleTextsByIDs(result.AddedIDs); len(texts) != 2 {
+		t.Errorf("got %d article texts, want 2", len(texts))
+	}
+
+	// The known article, whose URL only differs in its query, stays in its feed but is
+	// starred
+	if state, _ := db.GetArticleStateByURL("https://example.com/known?utm_source=feed"); state.FeedID != feedID || !state.Favorite {
+		t.Errorf("unexpected known article: %+v", state)
+	}
+
+	// Importing again adds nothing, and the full text is only fetched for articles
+	// without content
+	again, err := Import(ctx, db, []Entry{{URL: "https://example.com/new"}})
+	if err != nil || again.Added != 0 || again.FeedID != result.FeedID {
+		t.Errorf("second import = %+v, %v", again, err)
+	}
+	var fetched []string
+	FetchFullText(ctx, db, result.AddedIDs, func(url string) (string, error) {
+		fetched = append(fetched, url)
+		return "<p>Full text</p>", nil
+	})
+	if !reflect.DeepEqual(fetched, []string{"https://example.com/archived"}) {
+		t.Errorf("fetched %v, want only the article without content", fetched)
+	}
+}
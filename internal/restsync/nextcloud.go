@@ -0,0 +1,248 @@
+package restsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nextcloudAPIPath is the path of the News API under the server URL
+const nextcloudAPIPath = "/index.php/apps/news/api/v1-3"
+
+// Nextcloud is a Backend for the Nextcloud News v1-3 API, authenticating with basic
+// auth, ideally with an app password.
+type Nextcloud struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewNextcloud creates a Nextcloud News backend for a server.
+func NewNextcloud(serverURL, username, password string) *Nextcloud {
+	serverURL = strings.TrimSuffix(serverURL, "/")
+	if !strings.HasSuffix(serverURL, nextcloudAPIPath) {
+		serverURL += nextcloudAPIPath
+	}
+	return &Nextcloud{
+		baseURL:    serverURL,
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+type nextcloudFolder struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type nextcloudFeed struct {
+	ID       int64  `json:"id"`
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	FolderID *int64 `json:"folderId"` // nil or 0 at the root
+}
+
+type nextcloudItem struct {
+	ID           int64  `json:"id"`
+	FeedID       int64  `json:"feedId"`
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	Body         string `json:"body"`
+	PubDate      int64  `json:"pubDate"`      // Unix seconds
+	LastModified int64  `json:"lastModified"` // Unix seconds, or microseconds since News 15
+	Unread       bool   `json:"unread"`
+	Starred      bool   `json:"starred"`
+}
+
+// Title returns the display name of the provider.
+func (n *Nextcloud) Title() string {
+	return "Nextcloud News"
+}
+
+// Login checks the credentials.
+func (n *Nextcloud) Login(ctx context.Context) error {
+	return n.do(ctx, http.MethodGet, "/version", nil, nil)
+}
+
+// Subscriptions returns the subscribed feeds, with their folder as category.
+func (n *Nextcloud) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	folders, err := n.folders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(folders))
+	for _, f := range folders {
+		names[f.ID] = f.Name
+	}
+
+	var result struct {
+		Feeds []nextcloudFeed `json:"feeds"`
+	}
+	if err := n.do(ctx, http.MethodGet, "/feeds", nil, &result); err != nil {
+		return nil, err
+	}
+	subscriptions := make([]Subscription, 0, len(result.Feeds))
+	for _, feed := range result.Feeds {
+		sub := Subscription{ID: strconv.FormatInt(feed.ID, 10), URL: feed.URL, Title: feed.Title}
+		if feed.FolderID != nil {
+			sub.Category = names[*feed.FolderID]
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+// Subscribe subscribes to a feed in a folder.
+func (n *Nextcloud) Subscribe(ctx context.Context, feedURL, category string) error {
+	folderID, err := n.folderID(ctx, category)
+	if err != nil {
+		return err
+	}
+	return n.do(ctx, http.MethodPost, "/feeds", map[string]interface{}{"url": feedURL, "folderId": folderID}, nil)
+}
+
+// Unsubscribe removes a subscription.
+func (n *Nextcloud) Unsubscribe(ctx context.Context, id string) error {
+	return n.do(ctx, http.MethodDelete, "/feeds/"+url.PathEscape(id), nil, nil)
+}
+
+// Move moves a subscription to a folder.
+func (n *Nextcloud) Move(ctx context.Context, id, category string) error {
+	folderID, err := n.folderID(ctx, category)
+	if err != nil {
+		return err
+	}
+	return n.do(ctx, http.MethodPost, "/feeds/"+url.PathEscape(id)+"/move", map[string]interface{}{"folderId": folderID}, nil)
+}
+
+// ChangedItems returns the items of all feeds modified since a time.
+func (n *Nextcloud) ChangedItems(ctx context.Context, since time.Time) ([]Item, error) {
+	query := url.Values{
+		"lastModified": {strconv.FormatInt(since.Unix(), 10)},
+		"type":         {"3"}, // All items
+		"id":           {"0"},
+	}
+	var result struct {
+		Items []nextcloudItem `json:"items"`
+	}
+	if err := n.do(ctx, http.MethodGet, "/items/updated?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(result.Items))
+	for _, item := range result.Items {
+		changed := item.LastModified
+		if changed > 1e12 {
+			changed /= 1e6
+		}
+		items = append(items, Item{
+			ID:             strconv.FormatInt(item.ID, 10),
+			SubscriptionID: strconv.FormatInt(item.FeedID, 10),
+			URL:            item.URL,
+			Title:          item.Title,
+			Content:        item.Body,
+			Published:      time.Unix(item.PubDate, 0),
+			Changed:        time.Unix(changed, 0),
+			Read:           !item.Unread,
+			Starred:        item.Starred,
+		})
+	}
+	return items, nil
+}
+
+// SetRead marks items as read or unread.
+func (n *Nextcloud) SetRead(ctx context.Context, ids []string, read bool) error {
+	path := "/items/unread/multiple"
+	if read {
+		path = "/items/read/multiple"
+	}
+	return n.editItems(ctx, path, ids)
+}
+
+// SetStarred stars or unstars items.
+func (n *Nextcloud) SetStarred(ctx context.Context, ids []string, starred bool) error {
+	path := "/items/unstar/multiple"
+	if starred {
+		path = "/items/star/multiple"
+	}
+	return n.editItems(ctx, path, ids)
+}
+
+// editItems posts item IDs to an endpoint editing multiple items.
+func (n *Nextcloud) editItems(ctx context.Context, path string, ids []string) error {
+	itemIDs, err := parseIDs(ids)
+	if err != nil {
+		return err
+	}
+	return n.do(ctx, http.MethodPost, path, map[string]interface{}{"itemIds": itemIDs}, nil)
+}
+
+// folders returns the folders.
+func (n *Nextcloud) folders(ctx context.Context) ([]nextcloudFolder, error) {
+	var result struct {
+		Folders []nextcloudFolder `json:"folders"`
+	}
+	if err := n.do(ctx, http.MethodGet, "/folders", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Folders, nil
+}
+
+// folderID returns the ID of a folder, creating it if needed, or nil for the root.
+func (n *Nextcloud) folderID(ctx context.Context, name string) (*int64, error) {
+	if name == "" {
+		return nil, nil
+	}
+	folders, err := n.folders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range folders {
+		if f.Name == name {
+			return &f.ID, nil
+		}
+	}
+
+	var result struct {
+		Folders []nextcloudFolder `json:"folders"`
+	}
+	if err := n.do(ctx, http.MethodPost, "/folders", map[string]string{"name": name}, &result); err != nil {
+		return nil, fmt.Errorf("create folder %q: %w", name, err)
+	}
+	if len(result.Folders) == 0 {
+		return nil, fmt.Errorf("create folder %q: no folder returned", name)
+	}
+	return &result.Folders[0].ID, nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into result.
+func (n *Nextcloud) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(n.username, n.password)
+
+	return doJSON(n.httpClient, req, result)
+}
@@ -0,0 +1,223 @@
+package restsync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+// Subscriber manages the subscriptions of an account.
+type Subscriber interface {
+	// Title returns the display name of the provider
+	Title() string
+	// Subscriptions returns the subscribed feeds
+	Subscriptions(ctx context.Context) ([]Subscription, error)
+	// Subscribe subscribes to a feed in a category, creating the category if needed
+	Subscribe(ctx context.Context, feedURL, category string) error
+	// Unsubscribe removes a subscription
+	Unsubscribe(ctx context.Context, id string) error
+	// Move moves a subscription to a category, creating the category if needed
+	Move(ctx context.Context, id, category string) error
+}
+
+// SubscriptionDatabase is the part of the database that subscription syncs use.
+type SubscriptionDatabase interface {
+	GetFeeds() ([]models.Feed, error)
+	AddFeed(feed *models.Feed) (int64, error)
+	UpdateFeedCategory(id int64, category string) error
+
+	GetSyncFeeds(provider string) ([]database.SyncFeed, error)
+	SaveSyncFeed(provider string, feed database.SyncFeed) error
+	DeleteSyncFeed(provider, remoteID string) error
+	UnlinkSyncFeed(provider, remoteID string, feedID int64) error
+	GetSyncLocalFeeds(provider string) (map[int64]bool, error)
+	AddSyncLocalFeeds(provider string, feedIDs []int64) error
+}
+
+// SyncSubscriptions pushes local subscription adds, removes and category moves to the
+// account of a provider and pulls the remote ones, and returns the local feed ID of
+// each remote subscription.
+//
+// The first sync only maps and pulls; local feeds missing on the server are kept local
+// until the user pushes them. Feeds unsubscribed on the server are unlinked and kept
+// local too, with their articles.
+func SyncSubscriptions(ctx context.Context, subscriber Subscriber, db SubscriptionDatabase, provider string, first bool) (map[string]int64, error) {
+	title := subscriber.Title()
+	subscriptions, err := subscriber.Subscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get subscriptions: %w", err)
+	}
+	localFeeds, err := db.GetFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("get local feeds: %w", err)
+	}
+	mappings, err := db.GetSyncFeeds(provider)
+	if err != nil {
+		return nil, fmt.Errorf("get synced feeds: %w", err)
+	}
+	kept, err := db.GetSyncLocalFeeds(provider)
+	if err != nil {
+		return nil, fmt.Errorf("get local feeds kept off the account: %w", err)
+	}
+	first = first && len(mappings) == 0
+
+	remote := make(map[string]Subscription)
+	for _, sub := range subscriptions {
+		remote[sub.ID] = sub
+	}
+	local := make(map[int64]models.Feed)
+	for _, feed := range localFeeds {
+		local[feed.ID] = feed
+	}
+
+	feeds := make(map[string]int64)
+	for _, m := range mappings {
+		sub, onRemote := remote[m.RemoteID]
+		feed, onLocal := local[m.FeedID]
+
+		switch {
+		case !onLocal:
+			// Deleted locally since the last sync
+			if onRemote {
+				if err := subscriber.Unsubscribe(ctx, sub.ID); err != nil {
+					log.Printf("Failed to unsubscribe from %s: %v", sub.URL, err)
+					continue
+				}
+				delete(remote, sub.ID)
+			}
+			db.DeleteSyncFeed(provider, m.RemoteID)
+
+		case !onRemote:
+			// Unsubscribed remotely since the last sync. An empty subscription list is
+			// more likely a server problem than the user removing everything.
+			if len(subscriptions) == 0 {
+				feeds[m.RemoteID] = m.FeedID
+				continue
+			}
+			// The feed stays with its articles as a local feed
+			if err := db.UnlinkSyncFeed(provider, m.RemoteID, feed.ID); err != nil {
+				log.Printf("Failed to unlink feed %s: %v", feed.URL, err)
+				continue
+			}
+			kept[feed.ID] = true
+			log.Printf("Unlinked feed unsubscribed in %s, keeping it locally: %s", title, feed.Title)
+
+		default:
+			feeds[m.RemoteID] = feed.ID
+			category := m.Category
+			if feed.Category != m.Category {
+				// Moved locally, which wins over a remote move
+				if err := subscriber.Move(ctx, sub.ID, feed.Category); err != nil {
+					log.Printf("Failed to move %s to %q: %v", sub.URL, feed.Category, err)
+					continue
+				}
+				category = feed.Category
+			} else if sub.Category != m.Category {
+				if err := db.UpdateFeedCategory(feed.ID, sub.Category); err != nil {
+					log.Printf("Failed to move feed %s to %q: %v", feed.URL, sub.Category, err)
+					continue
+				}
+				category = sub.Category
+			}
+			if category != m.Category {
+				m.Category = category
+				db.SaveSyncFeed(provider, m)
+			}
+		}
+	}
+
+	// Push local subscriptions added since the last sync
+	remoteURLs := make(map[string]bool)
+	for _, sub := range remote {
+		remoteURLs[sub.URL] = true
+	}
+	mappedFeeds := make(map[int64]bool)
+	for _, feedID := range feeds {
+		mappedFeeds[feedID] = true
+	}
+	pushed := false
+	var keep []int64
+	for _, feed := range localFeeds {
+		if _, ok := local[feed.ID]; !ok || mappedFeeds[feed.ID] || remoteURLs[feed.URL] || kept[feed.ID] || !Syncable(feed) {
+			continue
+		}
+		if first {
+			keep = append(keep, feed.ID)
+			continue
+		}
+		if err := subscriber.Subscribe(ctx, feed.URL, feed.Category); err != nil {
+			log.Printf("Failed to subscribe to %s: %v", feed.URL, err)
+			continue
+		}
+		pushed = true
+	}
+	if len(keep) > 0 {
+		if err := db.AddSyncLocalFeeds(provider, keep); err != nil {
+			return nil, fmt.Errorf("keep local feeds: %w", err)
+		}
+		log.Printf("Kept %d local feeds off %s until they are pushed", len(keep), title)
+	}
+	if pushed {
+		// The server assigns the IDs of the new subscriptions
+		if subscriptions, err = subscriber.Subscriptions(ctx); err != nil {
+			return nil, fmt.Errorf("get subscriptions: %w", err)
+		}
+	}
+
+	// Map the remaining remote subscriptions to local feeds, adding missing ones
+	localByURL := make(map[string]models.Feed)
+	for _, feed := range local {
+		localByURL[feed.URL] = feed
+	}
+	for _, sub := range subscriptions {
+		if _, ok := feeds[sub.ID]; ok {
+			continue
+		}
+		if _, ok := remote[sub.ID]; !ok && !pushed {
+			continue
+		}
+
+		category := sub.Category
+		feed, exists := localByURL[sub.URL]
+		if exists {
+			// Keep the local category of feeds subscribed on both sides
+			if feed.Category != category {
+				if err := subscriber.Move(ctx, sub.ID, feed.Category); err != nil {
+					log.Printf("Failed to move %s to %q: %v", sub.URL, feed.Category, err)
+				}
+			}
+			category = feed.Category
+		} else {
+			feed = models.Feed{
+				Title:       sub.Title,
+				URL:         sub.URL,
+				Category:    category,
+				LastUpdated: time.Now(),
+			}
+			if feed.ID, err = db.AddFeed(&feed); err != nil {
+				log.Printf("Failed to add feed %s: %v", sub.URL, err)
+				continue
+			}
+			log.Printf("Added feed: %s (category: %s)", sub.Title, category)
+		}
+
+		if err := db.SaveSyncFeed(provider, database.SyncFeed{RemoteID: sub.ID, FeedID: feed.ID, Category: category}); err != nil {
+			return nil, fmt.Errorf("save synced feed: %w", err)
+		}
+		feeds[sub.ID] = feed.ID
+	}
+
+	return feeds, nil
+}
+
+// Syncable reports whether a local feed can be subscribed to on the server. Script and
+// XPath feeds only exist locally.
+func Syncable(feed models.Feed) bool {
+	return (strings.HasPrefix(feed.URL, "http://") || strings.HasPrefix(feed.URL, "https://")) &&
+		feed.ScriptPath == "" && feed.Type == ""
+}
@@ -0,0 +1,296 @@
+// Package restsync syncs subscriptions, entries and their read and starred state with
+// feed services through their native REST APIs: Miniflux and Nextcloud News. A Backend
+// adapts each API to the sync.
+package restsync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+const (
+	// initialWindow is how far back the first sync pulls entries
+	initialWindow = 30 * 24 * time.Hour
+	// changeOverlap overlaps incremental pulls with the last sync, for entries changed
+	// while it ran
+	changeOverlap = time.Minute
+	// requestTimeout bounds each API request
+	requestTimeout = 30 * time.Second
+)
+
+// Subscription is a feed subscribed to on the server
+type Subscription struct {
+	ID       string
+	URL      string
+	Title    string
+	Category string // "" if uncategorized
+}
+
+// Item is an entry of a subscription
+type Item struct {
+	ID             string
+	SubscriptionID string
+	URL            string
+	Title          string
+	Content        string
+	Published      time.Time
+	Changed        time.Time // Time of the last change, including read and starred state
+	Read           bool
+	Starred        bool
+}
+
+// Backend is a sync provider's API.
+type Backend interface {
+	Subscriber
+	// Login checks the credentials
+	Login(ctx context.Context) error
+	// ChangedItems returns the entries created or changed since a time
+	ChangedItems(ctx context.Context, since time.Time) ([]Item, error)
+	// SetRead marks entries as read or unread
+	SetRead(ctx context.Context, ids []string, read bool) error
+	// SetStarred stars or unstars entries
+	SetStarred(ctx context.Context, ids []string, starred bool) error
+}
+
+// Database interface for sync operations
+type Database interface {
+	SubscriptionDatabase
+	SaveArticles(ctx context.Context, articles []*models.Article) error
+	UpdateArticleContent(id int64, content string) error
+
+	GetSyncLastSync(provider string) (time.Time, error)
+	SetSyncLastSync(provider string, t time.Time) error
+	GetSyncItems(provider string) ([]database.SyncItem, error)
+	GetSyncedRemoteIDs(provider string) (map[string]bool, error)
+	SaveSyncItems(provider string, items []database.SyncItem) error
+	GetArticleStateByURL(url string) (*database.ArticleState, error)
+	SetArticleSyncState(id int64, read, favorite bool) error
+}
+
+// SyncService handles synchronization between MrRSS and a sync account
+type SyncService struct {
+	backend  Backend
+	db       Database
+	provider string
+}
+
+// NewSyncService creates a sync service for the account of a provider, whose name keys
+// its state in the sync tables.
+func NewSyncService(provider string, backend Backend, db Database) *SyncService {
+	return &SyncService{backend: backend, db: db, provider: provider}
+}
+
+// Sync performs a bidirectional sync: subscriptions and their categories, entries
+// changed since the last sync, and the read and starred state of all synced entries.
+// The first sync merges the subscriptions without pushing local ones.
+func (s *SyncService) Sync(ctx context.Context) error {
+	title := s.backend.Title()
+	if err := s.backend.Login(ctx); err != nil {
+		return fmt.Errorf("login to %s: %w", title, err)
+	}
+
+	// Changes after start are left to the next sync
+	start := time.Now().UTC().Truncate(time.Second)
+	last, err := s.db.GetSyncLastSync(s.provider)
+	if err != nil {
+		return fmt.Errorf("get last sync time: %w", err)
+	}
+
+	feeds, err := SyncSubscriptions(ctx, s.backend, s.db, s.provider, last.IsZero())
+	if err != nil {
+		return err
+	}
+
+	since := start.Add(-initialWindow)
+	if !last.IsZero() {
+		since = last.Add(-changeOverlap)
+	}
+	items, err := s.backend.ChangedItems(ctx, since)
+	if err != nil {
+		return fmt.Errorf("get entries: %w", err)
+	}
+	if err := s.pullItems(ctx, feeds, items); err != nil {
+		return err
+	}
+	if err := s.syncStates(ctx, items, last); err != nil {
+		return err
+	}
+
+	if err := s.db.SetSyncLastSync(s.provider, start); err != nil {
+		return fmt.Errorf("save last sync time: %w", err)
+	}
+	log.Printf("%s sync completed successfully", title)
+	return nil
+}
+
+// pullItems maps the changed entries that are not synced yet to articles, adding the
+// missing ones to their local feeds. Entries that are already local articles keep their
+// state merged.
+func (s *SyncService) pullItems(ctx context.Context, feeds map[string]int64, items []Item) error {
+	synced, err := s.db.GetSyncedRemoteIDs(s.provider)
+	if err != nil {
+		return fmt.Errorf("get synced items: %w", err)
+	}
+
+	var mapped []database.SyncItem
+	var newItems []Item
+	var newArticles []*models.Article
+	for _, item := range items {
+		feedID, ok := feeds[item.SubscriptionID]
+		if synced[item.ID] || !ok || item.URL == "" {
+			continue
+		}
+		synced[item.ID] = true
+
+		existing, err := s.db.GetArticleStateByURL(item.URL)
+		if err != nil {
+			return fmt.Errorf("get article: %w", err)
+		}
+		if existing == nil {
+			newItems = append(newItems, item)
+			newArticles = append(newArticles, &models.Article{
+				FeedID:      feedID,
+				Title:       item.Title,
+				URL:         item.URL,
+				PublishedAt: item.Published,
+				IsRead:      item.Read,
+				IsFavorite:  item.Starred,
+			})
+			continue
+		}
+
+		// Without a common earlier state, an entry read or starred on either side stays
+		// so; the local part is pushed with the state sync
+		read, starred := existing.Read || item.Read, existing.Favorite || item.Starred
+		if read != existing.Read || starred != existing.Favorite {
+			s.db.SetArticleSyncState(existing.ID, read, starred)
+		}
+		mapped = append(mapped, database.SyncItem{RemoteID: item.ID, ArticleID: existing.ID, Read: item.Read, Starred: item.Starred})
+	}
+
+	if len(newArticles) > 0 {
+		if err := s.db.SaveArticles(ctx, newArticles); err != nil {
+			return fmt.Errorf("save articles: %w", err)
+		}
+		for i, article := range newArticles {
+			// Duplicates within the changes are ignored and keep ID 0
+			if article.ID == 0 {
+				continue
+			}
+			if newItems[i].Content != "" {
+				s.db.UpdateArticleContent(article.ID, newItems[i].Content)
+			}
+			mapped = append(mapped, database.SyncItem{RemoteID: newItems[i].ID, ArticleID: article.ID, Read: article.IsRead, Starred: article.IsFavorite})
+		}
+	}
+	if err := s.db.SaveSyncItems(s.provider, mapped); err != nil {
+		return fmt.Errorf("save synced items: %w", err)
+	}
+	if len(mapped) > 0 {
+		log.Printf("Synced %d new articles from %s", len(mapped), s.backend.Title())
+	}
+	return nil
+}
+
+// syncStates reconciles the read and starred state of the synced entries, pushing local
+// changes and pulling remote ones. Entries missing from the changed ones kept their
+// remote state since the last sync.
+func (s *SyncService) syncStates(ctx context.Context, changedItems []Item, last time.Time) error {
+	changes := make(map[string]Item, len(changedItems))
+	for _, item := range changedItems {
+		changes[item.ID] = item
+	}
+	items, err := s.db.GetSyncItems(s.provider)
+	if err != nil {
+		return fmt.Errorf("get synced items: %w", err)
+	}
+
+	var markRead, markUnread, star, unstar []string
+	var changed []database.SyncItem
+	for _, it := range items {
+		remoteRead, remoteStarred, remoteAt := it.Read, it.Starred, last
+		if change, ok := changes[it.RemoteID]; ok {
+			remoteRead, remoteStarred, remoteAt = change.Read, change.Starred, change.Changed
+		}
+
+		read := Resolve(it.Read, it.LocalRead, remoteRead, it.LocalReadAt, remoteAt)
+		isStarred := Resolve(it.Starred, it.LocalStarred, remoteStarred, it.LocalStarredAt, remoteAt)
+
+		if read != remoteRead {
+			if read {
+				markRead = append(markRead, it.RemoteID)
+			} else {
+				markUnread = append(markUnread, it.RemoteID)
+			}
+		}
+		if isStarred != remoteStarred {
+			if isStarred {
+				star = append(star, it.RemoteID)
+			} else {
+				unstar = append(unstar, it.RemoteID)
+			}
+		}
+		pull := read != it.LocalRead || isStarred != it.LocalStarred
+		if pull {
+			if err := s.db.SetArticleSyncState(it.ArticleID, read, isStarred); err != nil {
+				return fmt.Errorf("update article: %w", err)
+			}
+		}
+		if pull || read != it.Read || isStarred != it.Starred || !it.LocalReadAt.IsZero() || !it.LocalStarredAt.IsZero() {
+			it.Read, it.Starred = read, isStarred
+			changed = append(changed, it)
+		}
+	}
+
+	// Push before saving, so failed pushes are retried with the next sync
+	for _, push := range []struct {
+		ids []string
+		set func(context.Context, []string, bool) error
+		on  bool
+	}{
+		{markRead, s.backend.SetRead, true},
+		{markUnread, s.backend.SetRead, false},
+		{star, s.backend.SetStarred, true},
+		{unstar, s.backend.SetStarred, false},
+	} {
+		if len(push.ids) == 0 {
+			continue
+		}
+		if err := push.set(ctx, push.ids, push.on); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.SaveSyncItems(s.provider, changed); err != nil {
+		return fmt.Errorf("save synced items: %w", err)
+	}
+	if n := len(markRead) + len(markUnread) + len(star) + len(unstar); n > 0 {
+		log.Printf("Pushed %d state changes to %s", n, s.backend.Title())
+	}
+	return nil
+}
+
+// Resolve reconciles one state of a synced item: synced is its value at the last sync,
+// local and remote the current values, localAt the time of the last local change since
+// the last sync (zero if none) and remoteAt the time of the remote one. The side that
+// changed since the last sync wins, and when both did, the last writer.
+func Resolve(synced, local, remote bool, localAt, remoteAt time.Time) bool {
+	localChanged := local != synced || !localAt.IsZero()
+	remoteChanged := remote != synced
+	switch {
+	case localChanged && remoteChanged:
+		if localAt.After(remoteAt) {
+			return local
+		}
+		return remote
+	case localChanged:
+		return local
+	default:
+		return remote
+	}
+}
@@ -0,0 +1,479 @@
+package restsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+// fakeStore is the account state behind the stand-in servers
+type fakeStore struct {
+	mu         sync.Mutex
+	nextID     int64
+	categories map[int64]string
+	feeds      map[int64]*fakeFeed
+	entries    map[int64]*fakeEntry
+}
+
+type fakeFeed struct {
+	id       int64
+	url      string
+	title    string
+	category int64 // 0 for none
+}
+
+type fakeEntry struct {
+	id      int64
+	feed    int64
+	url     string
+	title   string
+	read    bool
+	starred bool
+	changed time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{nextID: 100, categories: map[int64]string{}, feeds: map[int64]*fakeFeed{}, entries: map[int64]*fakeEntry{}}
+}
+
+func (s *fakeStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *fakeStore) category(name string) int64 {
+	for id, n := range s.categories {
+		if n == name {
+			return id
+		}
+	}
+	id := s.id()
+	s.categories[id] = name
+	return id
+}
+
+func (s *fakeStore) addFeed(url, title, category string) *fakeFeed {
+	feed := &fakeFeed{id: s.id(), url: url, title: title}
+	if category != "" {
+		feed.category = s.category(category)
+	}
+	s.feeds[feed.id] = feed
+	return feed
+}
+
+func (s *fakeStore) addEntry(feed *fakeFeed, url, title string, read, starred bool) *fakeEntry {
+	entry := &fakeEntry{id: s.id(), feed: feed.id, url: url, title: title, read: read, starred: starred, changed: time.Now()}
+	s.entries[entry.id] = entry
+	return entry
+}
+
+func (s *fakeStore) feedByURL(url string) *fakeFeed {
+	for _, feed := range s.feeds {
+		if feed.url == url {
+			return feed
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) changedSince(since int64) []*fakeEntry {
+	var entries []*fakeEntry
+	for _, e := range s.entries {
+		if e.changed.Unix() >= since {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	return entries
+}
+
+func (s *fakeStore) setEntries(ids []int64, set func(*fakeEntry)) {
+	for _, id := range ids {
+		if e, ok := s.entries[id]; ok {
+			set(e)
+			e.changed = time.Now()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func pathID(path, prefix string) int64 {
+	id, _ := strconv.ParseInt(strings.Split(strings.TrimPrefix(path, prefix), "/")[0], 10, 64)
+	return id
+}
+
+// newMinifluxServer starts a stand-in for the Miniflux /v1/ API with the API token
+// "token". The "All" category is created first, as for every Miniflux user.
+func newMinifluxServer(t *testing.T, s *fakeStore) *httptest.Server {
+	s.category("All")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "token" {
+			http.Error(w, `{"error_message":"Access Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var body struct {
+			FeedURL    string  `json:"feed_url"`
+			CategoryID int64   `json:"category_id"`
+			Title      string  `json:"title"`
+			EntryIDs   []int64 `json:"entry_ids"`
+			Status     string  `json:"status"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := strings.TrimPrefix(r.URL.Path, "/v1")
+
+		switch {
+		case path == "/me":
+			writeJSON(w, map[string]interface{}{"id": 1, "username": "user"})
+		case path == "/categories" && r.Method == http.MethodGet:
+			var categories []map[string]interface{}
+			for id, name := range s.categories {
+				categories = append(categories, map[string]interface{}{"id": id, "title": name})
+			}
+			writeJSON(w, categories)
+		case path == "/categories" && r.Method == http.MethodPost:
+			id := s.category(body.Title)
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, map[string]interface{}{"id": id, "title": body.Title})
+		case path == "/feeds" && r.Method == http.MethodGet:
+			var feeds []map[string]interface{}
+			for _, f := range s.feeds {
+				category := map[string]interface{}{"id": f.category, "title": s.categories[f.category]}
+				feeds = append(feeds, map[string]interface{}{"id": f.id, "feed_url": f.url, "title": f.title, "category": category})
+			}
+			writeJSON(w, feeds)
+		case path == "/feeds" && r.Method == http.MethodPost:
+			if _, ok := s.categories[body.CategoryID]; !ok {
+				http.Error(w, `{"error_message":"This category does not exist"}`, http.StatusBadRequest)
+				return
+			}
+			feed := &fakeFeed{id: s.id(), url: body.FeedURL, title: body.FeedURL, category: body.CategoryID}
+			s.feeds[feed.id] = feed
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, map[string]int64{"feed_id": feed.id})
+		case strings.HasPrefix(path, "/feeds/") && r.Method == http.MethodPut:
+			s.feeds[pathID(path, "/feeds/")].category = body.CategoryID
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "/feeds/") && r.Method == http.MethodDelete:
+			delete(s.feeds, pathID(path, "/feeds/"))
+			w.WriteHeader(http.StatusNoContent)
+		case path == "/entries" && r.Method == http.MethodGet:
+			since, _ := strconv.ParseInt(r.URL.Query().Get("changed_after"), 10, 64)
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			all := s.changedSince(since)
+			var entries []map[string]interface{}
+			for i := offset; i < len(all) && i < offset+limit; i++ {
+				e := all[i]
+				status := "unread"
+				if e.read {
+					status = "read"
+				}
+				entries = append(entries, map[string]interface{}{
+					"id": e.id, "feed_id": e.feed, "status": status, "title": e.title, "url": e.url,
+					"content": "<p>" + e.title + "</p>", "starred": e.starred,
+					"published_at": e.changed.Format(time.RFC3339), "changed_at": e.changed.Format(time.RFC3339),
+				})
+			}
+			writeJSON(w, map[string]interface{}{"total": len(all), "entries": entries})
+		case path == "/entries" && r.Method == http.MethodPut:
+			s.setEntries(body.EntryIDs, func(e *fakeEntry) { e.read = body.Status == "read" })
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(path, "/bookmark") && r.Method == http.MethodPut:
+			s.setEntries([]int64{pathID(path, "/entries/")}, func(e *fakeEntry) { e.starred = !e.starred })
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newNextcloudServer starts a stand-in for the Nextcloud News v1-3 API of the user
+// "user" with the app password "secret".
+func newNextcloudServer(t *testing.T, s *fakeStore) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var body struct {
+			URL      string  `json:"url"`
+			Name     string  `json:"name"`
+			FolderID *int64  `json:"folderId"`
+			ItemIDs  []int64 `json:"itemIds"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		folderID := func() int64 {
+			if body.FolderID == nil {
+				return 0
+			}
+			return *body.FolderID
+		}
+		path := strings.TrimPrefix(r.URL.Path, nextcloudAPIPath)
+
+		switch {
+		case path == "/version":
+			writeJSON(w, map[string]string{"version": "25.0.0"})
+		case path == "/folders" && r.Method == http.MethodGet:
+			var folders []map[string]interface{}
+			for id, name := range s.categories {
+				folders = append(folders, map[string]interface{}{"id": id, "name": name})
+			}
+			writeJSON(w, map[string]interface{}{"folders": folders})
+		case path == "/folders" && r.Method == http.MethodPost:
+			id := s.category(body.Name)
+			writeJSON(w, map[string]interface{}{"folders": []map[string]interface{}{{"id": id, "name": body.Name}}})
+		case path == "/feeds" && r.Method == http.MethodGet:
+			var feeds []map[string]interface{}
+			for _, f := range s.feeds {
+				var folder interface{}
+				if f.category != 0 {
+					folder = f.category
+				}
+				feeds = append(feeds, map[string]interface{}{"id": f.id, "url": f.url, "title": f.title, "folderId": folder})
+			}
+			writeJSON(w, map[string]interface{}{"feeds": feeds})
+		case path == "/feeds" && r.Method == http.MethodPost:
+			feed := &fakeFeed{id: s.id(), url: body.URL, title: body.URL, category: folderID()}
+			s.feeds[feed.id] = feed
+			writeJSON(w, map[string]interface{}{"feeds": []map[string]interface{}{{"id": feed.id, "url": feed.url}}})
+		case strings.HasSuffix(path, "/move") && r.Method == http.MethodPost:
+			s.feeds[pathID(path, "/feeds/")].category = folderID()
+		case strings.HasPrefix(path, "/feeds/") && r.Method == http.MethodDelete:
+			delete(s.feeds, pathID(path, "/feeds/"))
+		case path == "/items/updated":
+			since, _ := strconv.ParseInt(r.URL.Query().Get("lastModified"), 10, 64)
+			var items []map[string]interface{}
+			for _, e := range s.changedSince(since) {
+				items = append(items, map[string]interface{}{
+					"id": e.id, "feedId": e.feed, "url": e.url, "title": e.title, "body": "<p>" + e.title + "</p>",
+					"pubDate": e.changed.Unix(), "lastModified": e.changed.UnixMicro(), "unread": !e.read, "starred": e.starred,
+				})
+			}
+			writeJSON(w, map[string]interface{}{"items": items})
+		case strings.HasPrefix(path, "/items/") && strings.HasSuffix(path, "/multiple") && r.Method == http.MethodPost:
+			action := strings.TrimSuffix(strings.TrimPrefix(path, "/items/"), "/multiple")
+			s.setEntries(body.ItemIDs, func(e *fakeEntry) {
+				switch action {
+				case "read", "unread":
+					e.read = action == "read"
+				case "star", "unstar":
+					e.starred = action == "star"
+				}
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func setupSyncDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return db
+}
+
+func feedByURL(t *testing.T, db *database.DB, url string) *models.Feed {
+	t.Helper()
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		t.Fatalf("GetFeeds() error = %v", err)
+	}
+	for _, feed := range feeds {
+		if feed.URL == url {
+			return &feed
+		}
+	}
+	return nil
+}
+
+func TestSyncBidirectional(t *testing.T) {
+	backends := map[string]func(*testing.T, *fakeStore) (Backend, Backend){
+		"miniflux": func(t *testing.T, s *fakeStore) (Backend, Backend) {
+			ts := newMinifluxServer(t, s)
+			return NewMiniflux(ts.URL, "", "token"), NewMiniflux(ts.URL, "", "wrong")
+		},
+		"nextcloud": func(t *testing.T, s *fakeStore) (Backend, Backend) {
+			ts := newNextcloudServer(t, s)
+			return NewNextcloud(ts.URL, "user", "secret"), NewNextcloud(ts.URL, "user", "wrong")
+		},
+	}
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			store := newFakeStore()
+			backend, wrong := newBackend(t, store)
+			testSyncBidirectional(t, name, store, backend, wrong)
+		})
+	}
+}
+
+func testSyncBidirectional(t *testing.T, provider string, store *fakeStore, backend, wrong Backend) {
+	db := setupSyncDB(t)
+	service := NewSyncService(provider, backend, db)
+	ctx := t.Context()
+
+	if err := NewSyncService(provider, wrong, db).Sync(ctx); err == nil {
+		t.Fatal("expected a login error with wrong credentials")
+	}
+
+	remote := store.addFeed("https://remote.example/feed", "Remote", "Tech")
+	one := store.addEntry(remote, "https://remote.example/1", "One", false, false)
+	two := store.addEntry(remote, "https://remote.example/2", "Two", true, true)
+	three := store.addEntry(remote, "https://remote.example/3", "Three", false, false)
+
+	// A local feed, and a local article that the third entry maps to
+	db.AddFeed(&models.Feed{Title: "Local", URL: "https://local.example/feed", Category: "Local"})
+	db.AddFeed(&models.Feed{Title: "Uncategorized", URL: "https://plain.example/feed"})
+	existingFeedID, _ := db.AddFeed(&models.Feed{Title: "Other", URL: "freshrss://other"})
+	existing := &models.Article{FeedID: existingFeedID, Title: "Three", URL: "https://remote.example/3", IsRead: true, PublishedAt: time.Now()}
+	db.SaveArticles(ctx, []*models.Article{existing})
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The first sync only merges, keeping the local feeds off the server
+	if feed := store.feedByURL("https://local.example/feed"); feed != nil {
+		t.Errorf("expected the first sync not to push the local feed, got %+v", feed)
+	}
+	if kept, _ := db.GetSyncLocalFeeds(provider); len(kept) != 2 {
+		t.Errorf("expected the local HTTP feeds to be kept local, got %v", kept)
+	}
+	remoteFeed := feedByURL(t, db, "https://remote.example/feed")
+	if remoteFeed == nil || remoteFeed.Category != "Tech" {
+		t.Fatalf("expected the remote subscription as a local feed, got %+v", remoteFeed)
+	}
+	articles, _ := db.GetArticles("all", remoteFeed.ID, "", true, 10, 0)
+	if len(articles) != 2 {
+		t.Fatalf("expected 2 articles in the remote feed, got %d", len(articles))
+	}
+	states := make(map[string]models.Article)
+	for _, a := range articles {
+		states[a.Title] = a
+	}
+	if states["One"].IsRead || !states["Two"].IsRead || !states["Two"].IsFavorite {
+		t.Errorf("unexpected pulled articles %+v", states)
+	}
+	if !three.read {
+		t.Error("expected the local read state of the existing article to be pushed")
+	}
+	if texts, _ := db.GetArticleTextsByIDs([]int64{states["One"].ID}); len(texts) != 1 || texts[0].Content != "<p>One</p>" {
+		t.Errorf("expected the entry content to be stored, got %+v", texts)
+	}
+
+	// Pushing the kept local feeds subscribes to them on the next sync
+	db.DeleteSyncLocalFeeds(provider)
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if feed := store.feedByURL("https://local.example/feed"); feed == nil || store.categories[feed.category] != "Local" {
+		t.Errorf("expected the local feed to be subscribed remotely, got %+v", feed)
+	}
+	if store.feedByURL("freshrss://other") != nil {
+		t.Error("expected feeds without an HTTP URL to stay local")
+	}
+	if feed := feedByURL(t, db, "https://plain.example/feed"); feed.Category != "" {
+		t.Errorf("expected the uncategorized feed to stay uncategorized, got %q", feed.Category)
+	}
+
+	// Change state and categories on both sides
+	db.MarkArticleRead(states["One"].ID, true)
+	store.setEntries([]int64{two.id}, func(e *fakeEntry) { e.starred = false })
+	db.UpdateFeedCategory(remoteFeed.ID, "News")
+	store.feedByURL("https://local.example/feed").category = store.category("Elsewhere")
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !one.read {
+		t.Error("expected the local read to be pushed")
+	}
+	if a, _ := db.GetArticleByID(states["Two"].ID); a.IsFavorite {
+		t.Error("expected the remote unstar to be pulled")
+	}
+	if store.categories[remote.category] != "News" {
+		t.Errorf("expected the local category move to be pushed, got %q", store.categories[remote.category])
+	}
+	if feed := feedByURL(t, db, "https://local.example/feed"); feed.Category != "Elsewhere" {
+		t.Errorf("expected the remote category move to be pulled, got %q", feed.Category)
+	}
+
+	// Remove subscriptions on both sides
+	delete(store.feeds, store.feedByURL("https://local.example/feed").id)
+	db.DeleteFeed(remoteFeed.ID)
+
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if store.feedByURL("https://remote.example/feed") != nil {
+		t.Error("expected the local removal to be pushed")
+	}
+	// The feed unsubscribed remotely stays as a local feed that is not pushed again
+	feed := feedByURL(t, db, "https://local.example/feed")
+	if feed == nil {
+		t.Fatal("expected the remotely removed feed to stay locally")
+	}
+	if kept, _ := db.GetSyncLocalFeeds(provider); !kept[feed.ID] {
+		t.Errorf("expected the remotely removed feed to be kept local, got %v", kept)
+	}
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if store.feedByURL("https://local.example/feed") != nil {
+		t.Error("expected the unlinked feed not to be pushed again")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	last := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	later := last.Add(time.Minute)
+	tests := []struct {
+		name                  string
+		synced, local, remote bool
+		localAt               time.Time
+		want                  bool
+	}{
+		{"unchanged", false, false, false, time.Time{}, false},
+		{"local change", false, true, false, later, true},
+		{"remote change", false, false, true, time.Time{}, true},
+		{"same change on both sides", false, true, true, later, true},
+		{"local toggle and back", true, true, true, later, true},
+		{"local change after remote wins", false, false, true, later, false},
+		{"untimed local change loses", false, false, true, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(tt.synced, tt.local, tt.remote, tt.localAt, last); got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,13 +3,22 @@ package rules
 import (
 	"encoding/json"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	"MrRSS/internal/database"
+	"MrRSS/internal/embedding"
 	"MrRSS/internal/models"
 )
 
+// DefaultSimilarityThreshold is the cosine similarity a "similar_to" condition requires by default
+const DefaultSimilarityThreshold = 0.8
+
+// SimilarityFunc returns the similarity of two articles' embeddings, or false if
+// either article has no embedding.
+type SimilarityFunc func(articleID, exampleID int64) (float64, bool)
+
 // Condition represents a condition in a rule
 type Condition struct {
 	ID       int64    `json:"id"`
@@ -18,7 +27,9 @@ type Condition struct {
 	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", etc.
 	Operator string   `json:"operator"` // "contains", "exact"
 	Value    string   `json:"value"`    // Single value for text/date fields
-	Values   []string `json:"values"`   // Multiple values for feed_name and feed_category
+	Values   []string `json:"values"`   // Multiple values for feed_name, feed_category and label
+	// Minimum similarity for "similar_to", whose Value is the example article ID; 0 uses DefaultSimilarityThreshold
+	Threshold float64 `json:"threshold,omitempty"`
 }
 
 // Rule represents an automation rule
@@ -32,30 +43,101 @@ type Rule struct {
 
 // Engine handles rule application
 type Engine struct {
-	db *database.DB
+	db         *database.DB
+	similarity SimilarityFunc
 }
 
 // NewEngine creates a new rules engine
 func NewEngine(db *database.DB) *Engine {
-	return &Engine{db: db}
+	return &Engine{db: db, similarity: embedding.StoredSimilarity(db)}
 }
 
 // ApplyRulesToArticles applies all enabled rules to a batch of articles.
 // Each article is matched against rules in order, and only the first matching rule is applied.
 // This prevents conflicting actions from multiple rules being applied to the same article.
 func (e *Engine) ApplyRulesToArticles(articles []models.Article) (int, error) {
-	// Load rules from settings
+	rules, err := e.loadRules()
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+	return e.applyRules(articles, rules)
+}
+
+// ApplySimilarityRules applies the enabled rules with a "similar_to" condition to
+// articles that were just indexed, since those rules could not match them when they
+// were fetched. As with ApplyRulesToArticles, only the first matching rule is applied.
+func (e *Engine) ApplySimilarityRules(articles []models.Article) (int, error) {
+	return e.applyRulesWhere(articles, func(rule Rule) bool { return HasSimilarityCondition(rule.Conditions) })
+}
+
+// ApplyRulesWithoutLabels applies the enabled rules without a "label" condition to
+// articles whose labels are not assigned yet. ApplyLabelRules applies the others once
+// they are.
+func (e *Engine) ApplyRulesWithoutLabels(articles []models.Article) (int, error) {
+	return e.applyRulesWhere(articles, func(rule Rule) bool { return !HasLabelCondition(rule.Conditions) })
+}
+
+// ApplyLabelRules applies the enabled rules with a "label" condition to articles that
+// were just labeled. As with ApplyRulesToArticles, only the first matching rule is applied.
+func (e *Engine) ApplyLabelRules(articles []models.Article) (int, error) {
+	return e.applyRulesWhere(articles, func(rule Rule) bool { return HasLabelCondition(rule.Conditions) })
+}
+
+// applyRulesWhere applies the enabled rules that keep selects.
+func (e *Engine) applyRulesWhere(articles []models.Article, keep func(Rule) bool) (int, error) {
+	rules, err := e.loadRules()
+	if err != nil {
+		return 0, err
+	}
+	var selected []Rule
+	for _, rule := range rules {
+		if keep(rule) {
+			selected = append(selected, rule)
+		}
+	}
+	if len(selected) == 0 {
+		return 0, nil
+	}
+	return e.applyRules(articles, selected)
+}
+
+// HasLabelCondition reports whether conditions match the labels of articles.
+func HasLabelCondition(conditions []Condition) bool {
+	for _, c := range conditions {
+		if c.Field == "label" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSimilarityCondition reports whether conditions compare articles by embedding.
+func HasSimilarityCondition(conditions []Condition) bool {
+	for _, c := range conditions {
+		if c.Field == "similar_to" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRules reads the rules from settings.
+func (e *Engine) loadRules() ([]Rule, error) {
 	rulesJSON, _ := e.db.GetSetting("rules")
 	if rulesJSON == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	var rules []Rule
 	if err := json.Unmarshal([]byte(rulesJSON), &rules); err != nil {
 		log.Printf("Error parsing rules: %v", err)
-		return 0, err
+		return nil, err
 	}
+	return rules, nil
+}
 
+// applyRules applies the first matching enabled rule to each article.
+func (e *Engine) applyRules(articles []models.Article, rules []Rule) (int, error) {
 	// Get feeds for category and title lookup
 	feeds, err := e.db.GetFeeds()
 	if err != nil {
@@ -78,7 +160,7 @@ func (e *Engine) ApplyRulesToArticles(articles []models.Article) (int, error) {
 			}
 
 			// Check if article matches conditions
-			if matchesConditions(article, rule.Conditions, feedCategories, feedTitles) {
+			if MatchesConditionsWith(article, rule.Conditions, feedCategories, feedTitles, e.similarity) {
 				// Apply actions
 				for _, action := range rule.Actions {
 					if err := e.applyAction(article.ID, action); err != nil {
@@ -121,7 +203,7 @@ func (e *Engine) ApplyRule(rule Rule) (int, error) {
 
 	affected := 0
 	for _, article := range articles {
-		if matchesConditions(article, rule.Conditions, feedCategories, feedTitles) {
+		if MatchesConditionsWith(article, rule.Conditions, feedCategories, feedTitles, e.similarity) {
 			for _, action := range rule.Actions {
 				if err := e.applyAction(article.ID, action); err != nil {
 					log.Printf("Error applying action %s to article %d: %v", action, article.ID, err)
@@ -135,18 +217,26 @@ func (e *Engine) ApplyRule(rule Rule) (int, error) {
 	return affected, nil
 }
 
-// matchesConditions checks if an article matches the rule conditions
-func matchesConditions(article models.Article, conditions []Condition, feedCategories map[int64]string, feedTitles map[int64]string) bool {
+// MatchesConditions checks if an article matches the rule conditions.
+// feedCategories and feedTitles map feed IDs to their category and title.
+// "similar_to" conditions never match; use MatchesConditionsWith to evaluate them.
+func MatchesConditions(article models.Article, conditions []Condition, feedCategories map[int64]string, feedTitles map[int64]string) bool {
+	return MatchesConditionsWith(article, conditions, feedCategories, feedTitles, nil)
+}
+
+// MatchesConditionsWith is MatchesConditions with a similarity source for "similar_to"
+// conditions. similarity may be nil.
+func MatchesConditionsWith(article models.Article, conditions []Condition, feedCategories map[int64]string, feedTitles map[int64]string, similarity SimilarityFunc) bool {
 	// If no conditions, apply to all articles
 	if len(conditions) == 0 {
 		return true
 	}
 
-	result := evaluateCondition(article, conditions[0], feedCategories, feedTitles)
+	result := evaluateCondition(article, conditions[0], feedCategories, feedTitles, similarity)
 
 	for i := 1; i < len(conditions); i++ {
 		condition := conditions[i]
-		conditionResult := evaluateCondition(article, condition, feedCategories, feedTitles)
+		conditionResult := evaluateCondition(article, condition, feedCategories, feedTitles, similarity)
 
 		switch condition.Logic {
 		case "and":
@@ -160,7 +250,7 @@ func matchesConditions(article models.Article, conditions []Condition, feedCateg
 }
 
 // evaluateCondition evaluates a single rule condition
-func evaluateCondition(article models.Article, condition Condition, feedCategories map[int64]string, feedTitles map[int64]string) bool {
+func evaluateCondition(article models.Article, condition Condition, feedCategories map[int64]string, feedTitles map[int64]string, similarity SimilarityFunc) bool {
 	var result bool
 
 	switch condition.Field {
@@ -246,6 +336,25 @@ func evaluateCondition(article models.Article, condition Condition, feedCategori
 			result = article.IsReadLater == wantReadLater
 		}
 
+	case "label":
+		result = matchLabels(article.Labels, condition.Values, condition.Value)
+
+	case "similar_to":
+		// Articles without an embedding match neither the condition nor its negation
+		exampleID, err := strconv.ParseInt(strings.TrimSpace(condition.Value), 10, 64)
+		if err != nil || similarity == nil {
+			return false
+		}
+		score, ok := similarity(article.ID, exampleID)
+		if !ok {
+			return false
+		}
+		threshold := condition.Threshold
+		if threshold <= 0 {
+			threshold = DefaultSimilarityThreshold
+		}
+		result = score >= threshold
+
 	default:
 		result = true
 	}
@@ -273,6 +382,25 @@ func matchMultiSelect(fieldValue string, values []string, singleValue string) bo
 	return true
 }
 
+// matchLabels checks if any label equals (ignoring case) one of the selected values.
+// With no values selected, it matches articles that have any label.
+func matchLabels(labels []string, values []string, singleValue string) bool {
+	if len(values) == 0 && singleValue != "" {
+		values = []string{singleValue}
+	}
+	if len(values) == 0 {
+		return len(labels) > 0
+	}
+	for _, label := range labels {
+		for _, val := range values {
+			if strings.EqualFold(label, strings.TrimSpace(val)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // applyAction applies an action to an article
 func (e *Engine) applyAction(articleID int64, action string) error {
 	switch action {
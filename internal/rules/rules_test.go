@@ -100,3 +100,119 @@ func TestEngine_ApplyRule(t *testing.T) {
 		t.Errorf("Expected 0 articles to be processed, got %d", count)
 	}
 }
+
+func TestMatchesConditions_SimilarTo(t *testing.T) {
+	similarity := func(articleID, exampleID int64) (float64, bool) {
+		if exampleID != 10 {
+			return 0, false
+		}
+		switch articleID {
+		case 1:
+			return 0.9, true
+		case 2:
+			return 0.5, true
+		}
+		return 0, false
+	}
+	condition := Condition{Field: "similar_to", Value: "10"}
+
+	tests := []struct {
+		name      string
+		articleID int64
+		condition Condition
+		want      bool
+	}{
+		{"above default threshold", 1, condition, true},
+		{"below default threshold", 2, condition, false},
+		{"custom threshold", 2, Condition{Field: "similar_to", Value: "10", Threshold: 0.4}, true},
+		{"negated", 2, Condition{Field: "similar_to", Value: "10", Negate: true}, true},
+		{"not indexed", 3, condition, false},
+		{"not indexed negated", 3, Condition{Field: "similar_to", Value: "10", Negate: true}, false},
+		{"invalid example", 1, Condition{Field: "similar_to", Value: "x"}, false},
+	}
+	for _, tt := range tests {
+		article := models.Article{ID: tt.articleID}
+		if got := MatchesConditionsWith(article, []Condition{tt.condition}, nil, nil, similarity); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if MatchesConditions(models.Article{ID: 1}, []Condition{condition}, nil, nil) {
+		t.Error("similar_to must not match without a similarity source")
+	}
+}
+
+func TestMatchesConditions_Label(t *testing.T) {
+	article := models.Article{ID: 1, Labels: []string{"AI", "Security"}}
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"single value ignores case", Condition{Field: "label", Value: "security"}, true},
+		{"any of values", Condition{Field: "label", Values: []string{"Sports", "AI"}}, true},
+		{"no match", Condition{Field: "label", Values: []string{"Sports"}}, false},
+		{"no prefix match", Condition{Field: "label", Value: "Sec"}, false},
+		{"any label", Condition{Field: "label"}, true},
+		{"negated", Condition{Field: "label", Value: "Sports", Negate: true}, true},
+	}
+	for _, tt := range tests {
+		if got := MatchesConditions(article, []Condition{tt.condition}, nil, nil); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if MatchesConditions(models.Article{ID: 2}, []Condition{{Field: "label"}}, nil, nil) {
+		t.Error("an article without labels must not match an empty label condition")
+	}
+}
+
+func TestEngine_ApplySimilarityRules(t *testing.T) {
+	engine := setupTestEngine(t)
+	engine.similarity = func(articleID, exampleID int64) (float64, bool) { return 0.95, true }
+
+	rulesJSON, _ := json.Marshal([]Rule{
+		{Name: "Title", Enabled: true, Conditions: []Condition{{Field: "article_title", Value: "test"}}, Actions: []string{"favorite"}},
+		{Name: "Similar", Enabled: true, Conditions: []Condition{{Field: "similar_to", Value: "7"}}, Actions: []string{"mark_read"}},
+	})
+	engine.db.SetSetting("rules", string(rulesJSON))
+
+	articles := []models.Article{{ID: 1, Title: "A test article"}, {ID: 2, Title: "Other"}}
+	count, err := engine.ApplySimilarityRules(articles)
+	if err != nil {
+		t.Fatalf("ApplySimilarityRules failed: %v", err)
+	}
+	// Only the similarity rule is considered, so both articles match it
+	if count != 2 {
+		t.Errorf("Expected 2 articles to be processed, got %d", count)
+	}
+}
+
+func TestEngine_ApplyLabelRulesSeparately(t *testing.T) {
+	engine := setupTestEngine(t)
+
+	rulesJSON, _ := json.Marshal([]Rule{
+		{Name: "Security", Enabled: true, Conditions: []Condition{{Field: "label", Values: []string{"Security"}}}, Actions: []string{"favorite"}},
+		{Name: "Unlabeled", Enabled: true, Conditions: []Condition{{Field: "label", Negate: true}}, Actions: []string{"hide"}},
+		{Name: "Title", Enabled: true, Conditions: []Condition{{Field: "article_title", Value: "test"}}, Actions: []string{"mark_read"}},
+	})
+	engine.db.SetSetting("rules", string(rulesJSON))
+
+	// Before labeling, rules on labels must not match articles that have none yet
+	articles := []models.Article{{ID: 1, Title: "A test article"}, {ID: 2, Title: "Other"}}
+	count, err := engine.ApplyRulesWithoutLabels(articles)
+	if err != nil {
+		t.Fatalf("ApplyRulesWithoutLabels failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 article to be processed without labels, got %d", count)
+	}
+
+	articles[0].Labels = []string{"Security"}
+	count, err = engine.ApplyLabelRules(articles)
+	if err != nil {
+		t.Fatalf("ApplyLabelRules failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 articles to be processed with labels, got %d", count)
+	}
+}
@@ -0,0 +1,47 @@
+package summary
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"MrRSS/internal/aiclient"
+)
+
+// StreamTimeout bounds the total duration of a streamed completion.
+const StreamTimeout = 5 * time.Minute
+
+// SummarizeStream generates a summary like Summarize, passing generated text to onDelta as it arrives.
+// On error, the result holds any text generated before the failure and its usage.
+func (s *AISummarizer) SummarizeStream(ctx context.Context, text string, length SummaryLength, onDelta aiclient.DeltaFunc) (SummaryResult, error) {
+	systemPrompt, userPrompt, tooShort := s.buildPrompts(text, length)
+	if tooShort != nil {
+		return *tooShort, nil
+	}
+
+	resp, err := s.CompleteStream(ctx, systemPrompt, userPrompt, onDelta)
+	if err != nil {
+		// Keep the partial text so callers can account for what was generated
+		return SummaryResult{Summary: resp.Text, Usage: resp.Usage}, err
+	}
+
+	summary := strings.TrimSpace(resp.Text)
+	return SummaryResult{
+		Summary:       summary,
+		SentenceCount: len(splitSentences(summary)),
+		Usage:         resp.Usage,
+	}, nil
+}
+
+// CompleteStream sends a system and user prompt with streaming enabled, passing text to
+// onDelta as it arrives. The response is never nil and holds any text received before an error.
+func (s *AISummarizer) CompleteStream(ctx context.Context, systemPrompt, userPrompt string, onDelta aiclient.DeltaFunc) (*aiclient.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, StreamTimeout)
+	defer cancel()
+
+	client, err := s.newClient()
+	if err != nil {
+		return &aiclient.Response{}, err
+	}
+	return client.Stream(ctx, s.request(systemPrompt, userPrompt), onDelta)
+}
@@ -0,0 +1,81 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const streamTestText = "The city council approved a new budget for public transport on Monday. " +
+	"The plan adds twenty electric buses to the fleet over the next two years. " +
+	"Council members said the investment will cut emissions and shorten waiting times."
+
+func TestSummarizeStream_Ollama(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.URL.Path != "/api/chat" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"More buses "},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"are coming."},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":40,"eval_count":5}`)
+	}))
+	defer server.Close()
+
+	s := NewAISummarizer("", server.URL+"/api/generate", "llama3")
+	var deltas []string
+	result, err := s.SummarizeStream(context.Background(), streamTestText, Short, func(d string) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SummarizeStream() error = %v", err)
+	}
+	if result.Summary != "More buses are coming." || len(deltas) != 2 {
+		t.Errorf("got summary %q from deltas %q", result.Summary, deltas)
+	}
+	if result.Usage.Total() != 45 || result.Usage.Estimated {
+		t.Errorf("unexpected usage %+v", result.Usage)
+	}
+	// The backend is detected from the endpoint, so a single chat request is sent
+	if len(bodies) != 1 || bodies[0]["stream"] != true || bodies[0]["messages"] == nil {
+		t.Errorf("unexpected requests: %v", bodies)
+	}
+}
+
+func TestCompleteStream_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewAISummarizer("", server.URL, "gpt")
+	result, err := s.CompleteStream(ctx, "system", "user", func(d string) error {
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if !strings.Contains(result.Text, "partial") {
+		t.Errorf("expected partial text, got %q", result.Text)
+	}
+}
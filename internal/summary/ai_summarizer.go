@@ -1,27 +1,27 @@
 package summary
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
+	"MrRSS/internal/aiclient"
 	"MrRSS/internal/config"
-	"MrRSS/internal/utils"
 )
 
-// AISummarizer implements summarization using OpenAI-compatible APIs (GPT, Claude, etc.).
+// AISummarizer implements summarization using AI providers (OpenAI-compatible, Ollama, Anthropic or Gemini).
 type AISummarizer struct {
 	APIKey        string
 	Endpoint      string
 	Model         string
+	Backend       string // Empty or "auto" detects the backend from Endpoint
 	SystemPrompt  string
 	CustomHeaders string
 	client        *http.Client
 	db            DBInterface
+	ai            *aiclient.Client // Preconfigured client; overrides the connection fields above
 }
 
 // DBInterface defines the minimal database interface needed for proxy settings
@@ -30,28 +30,8 @@ type DBInterface interface {
 	GetEncryptedSetting(key string) (string, error)
 }
 
-// CreateHTTPClientWithProxy creates an HTTP client with global proxy settings if enabled
-func CreateHTTPClientWithProxy(db DBInterface, timeout time.Duration) (*http.Client, error) {
-	var proxyURL string
-
-	// Check if global proxy is enabled
-	proxyEnabled, _ := db.GetSetting("proxy_enabled")
-	if proxyEnabled == "true" {
-		// Build proxy URL from global settings
-		proxyType, _ := db.GetSetting("proxy_type")
-		proxyHost, _ := db.GetSetting("proxy_host")
-		proxyPort, _ := db.GetSetting("proxy_port")
-		proxyUsername, _ := db.GetEncryptedSetting("proxy_username")
-		proxyPassword, _ := db.GetEncryptedSetting("proxy_password")
-		proxyURL = utils.BuildProxyURL(proxyType, proxyHost, proxyPort, proxyUsername, proxyPassword)
-	}
-
-	// Create HTTP client with or without proxy
-	return utils.CreateHTTPClient(proxyURL, timeout)
-}
-
 // NewAISummarizer creates a new AI summarizer with the given credentials.
-// endpoint should be the full API URL (e.g., "https://api.openai.com/v1/chat/completions" for OpenAI, "http://localhost:11434/api/generate" for Ollama)
+// endpoint should be the full API URL (e.g., "https://api.openai.com/v1/chat/completions" for OpenAI, "http://localhost:11434/api/chat" for Ollama)
 // model should be the model name (e.g., "gpt-4o-mini", "claude-3-haiku-20240307")
 // Uses global AI settings shared between translation and summarization.
 // db is optional - if nil, no proxy will be used
@@ -84,7 +64,7 @@ func NewAISummarizerWithDB(apiKey, endpoint, model string, db DBInterface) *AISu
 	if model == "" {
 		model = defaults.AIModel
 	}
-	client, err := CreateHTTPClientWithProxy(db, 30*time.Second)
+	client, err := aiclient.NewHTTPClient(db, 30*time.Second)
 	if err != nil {
 		// Fallback to default client if proxy creation fails
 		client = &http.Client{Timeout: 30 * time.Second}
@@ -100,6 +80,30 @@ func NewAISummarizerWithDB(apiKey, endpoint, model string, db DBInterface) *AISu
 	}
 }
 
+// NewAISummarizerWithClient creates an AI summarizer that sends requests through a
+// preconfigured client, e.g. one created for an AI profile by aiclient.NewForFeature.
+func NewAISummarizerWithClient(client *aiclient.Client) *AISummarizer {
+	return &AISummarizer{
+		Model:   client.Model(),
+		Backend: string(client.Backend()),
+		ai:      client,
+	}
+}
+
+// NewAISummarizerForSummaries creates an AI summarizer from the AI profile selected for
+// summaries, or the global AI settings, with the custom summary prompt if one is set.
+func NewAISummarizerForSummaries(settings aiclient.SettingsProvider, timeout time.Duration) (*AISummarizer, error) {
+	client, err := aiclient.NewForFeature(settings, aiclient.FeatureSummary, timeout)
+	if err != nil {
+		return nil, err
+	}
+	s := NewAISummarizerWithClient(client)
+	if systemPrompt, _ := settings.GetSetting("ai_summary_prompt"); systemPrompt != "" {
+		s.SetSystemPrompt(systemPrompt)
+	}
+	return s, nil
+}
+
 // SetSystemPrompt sets a custom system prompt for the summarizer.
 func (s *AISummarizer) SetSystemPrompt(prompt string) {
 	s.SystemPrompt = prompt
@@ -110,32 +114,43 @@ func (s *AISummarizer) SetCustomHeaders(headers string) {
 	s.CustomHeaders = headers
 }
 
-// parseCustomHeaders parses the JSON string of custom headers into a map.
-func parseCustomHeaders(headersJSON string) (map[string]string, error) {
-	// Return empty map if headers string is empty
-	if headersJSON == "" {
-		return make(map[string]string), nil
-	}
+// SetBackend sets the AI backend ("openai", "ollama", "anthropic", "gemini" or "auto").
+func (s *AISummarizer) SetBackend(backend string) {
+	s.Backend = backend
+}
 
-	var headers map[string]string
-	if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
-		return nil, fmt.Errorf("failed to parse custom headers JSON: %w", err)
+// Summarize generates a summary of the given text using the configured AI backend.
+// The result includes the token usage reported by the provider.
+func (s *AISummarizer) Summarize(text string, length SummaryLength) (SummaryResult, error) {
+	systemPrompt, userPrompt, tooShort := s.buildPrompts(text, length)
+	if tooShort != nil {
+		return *tooShort, nil
 	}
-	return headers, nil
+
+	resp, err := s.Complete(context.Background(), systemPrompt, userPrompt)
+	if err != nil {
+		return SummaryResult{}, err
+	}
+	return SummaryResult{
+		Summary:       resp.Text,
+		SentenceCount: len(splitSentences(resp.Text)),
+		IsTooShort:    false,
+		Usage:         resp.Usage,
+	}, nil
 }
 
-// Summarize generates a summary of the given text using an OpenAI-compatible API.
-// Automatically detects and adapts to different API formats (OpenAI vs Ollama).
-func (s *AISummarizer) Summarize(text string, length SummaryLength) (SummaryResult, error) {
+// buildPrompts prepares the system and user prompts for summarizing text.
+// If the text is too short to summarize, the returned result should be used instead.
+func (s *AISummarizer) buildPrompts(text string, length SummaryLength) (string, string, *SummaryResult) {
 	// Clean the text first
 	cleanedText := cleanText(text)
 
 	// Check if text is too short
 	if len(cleanedText) < MinContentLength {
-		return SummaryResult{
+		return "", "", &SummaryResult{
 			Summary:    cleanedText,
 			IsTooShort: true,
-		}, nil
+		}
 	}
 
 	// Truncate text if too long to save tokens
@@ -153,183 +168,44 @@ func (s *AISummarizer) Summarize(text string, length SummaryLength) (SummaryResu
 		systemPrompt = "You are a summarizer. Generate a concise summary of the given text. Output ONLY the summary, nothing else."
 	}
 	userPrompt := fmt.Sprintf("Summarize the following text in approximately %d words:\n\n%s", targetWords, cleanedText)
-
-	// Try OpenAI format first
-	result, err := s.tryOpenAIFormat(systemPrompt, userPrompt)
-	if err == nil {
-		// Count sentences in the summary
-		sentences := splitSentences(result)
-		return SummaryResult{
-			Summary:       result,
-			SentenceCount: len(sentences),
-			IsTooShort:    false,
-		}, nil
-	}
-
-	// If OpenAI format fails, try Ollama format
-	result, err = s.tryOllamaFormat(systemPrompt, userPrompt)
-	if err == nil {
-		// Count sentences in the summary
-		sentences := splitSentences(result)
-		return SummaryResult{
-			Summary:       result,
-			SentenceCount: len(sentences),
-			IsTooShort:    false,
-		}, nil
-	}
-
-	// Both formats failed
-	return SummaryResult{}, fmt.Errorf("all API formats failed: OpenAI error: %v, Ollama error: %v", err, err)
+	return systemPrompt, userPrompt, nil
 }
 
-// tryOpenAIFormat attempts to use OpenAI-compatible API format
-func (s *AISummarizer) tryOpenAIFormat(systemPrompt, userPrompt string) (string, error) {
-	requestBody := map[string]interface{}{
-		"model": s.Model,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": userPrompt},
-		},
-		"temperature": 0.3, // Low temperature for consistent summaries
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
+// Complete sends a custom system and user prompt to the AI endpoint and returns the response
+// text with its token usage.
+func (s *AISummarizer) Complete(ctx context.Context, systemPrompt, userPrompt string) (*aiclient.Response, error) {
+	client, err := s.newClient()
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal OpenAI request: %w", err)
-	}
-
-	resp, err := s.sendRequest(jsonBody)
-	if err != nil {
-		return "", fmt.Errorf("OpenAI request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API returned status: %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode OpenAI response: %w", err)
+		return nil, err
 	}
-
-	if len(result.Choices) > 0 && result.Choices[0].Message.Content != "" {
-		// Clean up the response
-		summary := strings.TrimSpace(result.Choices[0].Message.Content)
-		return summary, nil
-	}
-
-	return "", fmt.Errorf("no summary found in OpenAI response")
+	return client.Complete(ctx, s.request(systemPrompt, userPrompt))
 }
 
-// tryOllamaFormat attempts to use Ollama API format
-func (s *AISummarizer) tryOllamaFormat(systemPrompt, userPrompt string) (string, error) {
-	// Combine system and user prompts for Ollama
-	fullPrompt := systemPrompt + "\n\n" + userPrompt
-
-	requestBody := map[string]interface{}{
-		"model":  s.Model,
-		"prompt": fullPrompt,
-		"stream": false,
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal Ollama request: %w", err)
-	}
-
-	resp, err := s.sendRequest(jsonBody)
-	if err != nil {
-		return "", fmt.Errorf("Ollama request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama API returned status: %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode Ollama response: %w", err)
-	}
-
-	if result.Done && result.Response != "" {
-		// Clean up the response
-		summary := strings.TrimSpace(result.Response)
-		return summary, nil
+// request builds a completion request for a system and user prompt.
+func (s *AISummarizer) request(systemPrompt, userPrompt string) aiclient.Request {
+	return aiclient.Request{
+		Messages: []aiclient.Message{
+			{Role: aiclient.RoleSystem, Content: systemPrompt},
+			{Role: aiclient.RoleUser, Content: userPrompt},
+		},
+		Temperature: 0.3, // Low temperature for consistent summaries
 	}
-
-	return "", fmt.Errorf("no summary found in Ollama response")
 }
 
-// sendRequest sends the HTTP request with proper headers
-func (s *AISummarizer) sendRequest(jsonBody []byte) (*http.Response, error) {
-	apiURL := s.Endpoint
-
-	// Validate endpoint URL to prevent SSRF attacks
-	parsedURL, err := url.Parse(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid API endpoint URL: %w", err)
-	}
-
-	// Allow HTTP for local endpoints (localhost, 127.0.0.1, etc.) for local LLM support (e.g., Ollama)
-	if parsedURL.Scheme != "https" && !isLocalEndpoint(parsedURL.Host) {
-		return nil, fmt.Errorf("API endpoint must use HTTPS for security (HTTP allowed only for localhost)")
+// newClient creates an AI client from the summarizer's settings.
+func (s *AISummarizer) newClient() (*aiclient.Client, error) {
+	if s.ai != nil {
+		return s.ai, nil
 	}
-
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	headers, err := aiclient.ParseCustomHeaders(s.CustomHeaders)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	// Only add Authorization header if API key is provided
-	if s.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+s.APIKey)
-	}
-
-	// Parse and add custom headers if provided
-	if s.CustomHeaders != "" {
-		customHeaders, err := parseCustomHeaders(s.CustomHeaders)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse custom headers: %w", err)
-		}
-		// Apply custom headers
-		for key, value := range customHeaders {
-			req.Header.Set(key, value)
-		}
-	}
-
-	return s.client.Do(req)
-}
-
-// isLocalEndpoint checks if a host is a local endpoint (localhost, 127.0.0.1, etc.)
-// This allows using HTTP for local LLM services like Ollama
-func isLocalEndpoint(host string) bool {
-	// Remove port if present
-	if idx := strings.LastIndex(host, ":"); idx != -1 {
-		// Handle IPv6 addresses like [::1]:8080
-		if !strings.Contains(host[idx:], "]") {
-			host = host[:idx]
-		}
-	}
-	// Remove brackets from IPv6 addresses
-	host = strings.Trim(host, "[]")
-
-	return host == "localhost" ||
-		host == "127.0.0.1" ||
-		host == "::1" ||
-		strings.HasPrefix(host, "127.") ||
-		host == "0.0.0.0"
+		return nil, fmt.Errorf("failed to parse custom headers: %w", err)
+	}
+	return aiclient.New(aiclient.Config{
+		Backend:       aiclient.Backend(s.Backend),
+		Endpoint:      s.Endpoint,
+		APIKey:        s.APIKey,
+		Model:         s.Model,
+		CustomHeaders: headers,
+	}, s.client)
 }
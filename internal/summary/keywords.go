@@ -0,0 +1,345 @@
+package summary
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Keyword is a keyphrase or named-entity-like term extracted from a text.
+type Keyword struct {
+	Term   string  // Lowercase form used to compare keywords across texts
+	Text   string  // Most frequent spelling in the text
+	Score  float64 // Importance in the text, 1 for the best keyword
+	Entity bool    // Capitalized name or acronym, e.g. "European Union" or "NASA"
+}
+
+const (
+	// DefaultKeywordLimit is the number of keywords ExtractKeywords returns by default
+	DefaultKeywordLimit = 10
+	// maxKeywordSentences bounds the sentences scored for keywords, keeping TextRank affordable
+	maxKeywordSentences = 40
+	// maxPhraseWords is the longest keyphrase; longer runs are split into single words
+	maxPhraseWords = 3
+	// titleKeywordWeight is the weight of a keyword occurrence in the title
+	titleKeywordWeight = 3.0
+	// entityBoost favors names and acronyms over common phrases
+	entityBoost = 1.5
+)
+
+// keywordStopWords are frequent words that are not stopwords for summaries but make
+// poor keywords
+var keywordStopWords = map[string]bool{
+	"said": true, "says": true, "say": true, "told": true, "according": true, "also": true,
+	"just": true, "like": true, "many": true, "much": true, "more": true, "most": true,
+	"new": true, "now": true, "one": true, "two": true, "get": true, "got": true,
+	"make": true, "made": true, "way": true, "year": true, "years": true, "today": true,
+	"week": true, "day": true, "time": true, "people": true, "thing": true, "things": true,
+}
+
+// phrase is a keyword candidate found in a sentence.
+type phrase struct {
+	term   string
+	text   string
+	words  int
+	entity bool
+}
+
+// ExtractKeywords returns the most important keyphrases and entities of a text (HTML
+// allowed), best first. Candidates are content words and runs of up to three of them
+// within a sentence; runs of capitalized words and acronyms are entities, and other
+// runs only count as keyphrases if they occur at least twice. Each occurrence
+// counts by the weight of its sentence from the combined TF-IDF and TextRank scores,
+// and occurrences in the title count most. Chinese text is segmented with gse and
+// yields single words.
+func ExtractKeywords(title, text string, limit int) []Keyword {
+	if limit <= 0 {
+		limit = DefaultKeywordLimit
+	}
+	title = cleanText(title)
+	sentences := splitSentences(cleanText(text))
+	if len(sentences) > maxKeywordSentences {
+		sentences = sentences[:maxKeywordSentences]
+	}
+	weights := sentenceWeights(sentences)
+
+	chinese := isChineseText(title + " " + strings.Join(sentences, " "))
+	proper := properWords(sentences)
+
+	acc := newKeywordAccumulator()
+	for _, p := range phrases(title, chinese, true, proper) {
+		acc.add(p, titleKeywordWeight)
+	}
+	for i, sentence := range sentences {
+		for _, p := range phrases(sentence, chinese, false, proper) {
+			acc.add(p, 1+weights[i])
+		}
+	}
+	return acc.top(limit)
+}
+
+// sentenceWeights scores sentences like Summarize and scales the scores to [0, 1].
+func sentenceWeights(sentences []string) []float64 {
+	weights := make([]float64, len(sentences))
+	if len(sentences) == 0 {
+		return weights
+	}
+	maxScore := 0.0
+	for i, s := range (&Summarizer{}).scoreSentences(sentences) {
+		weights[i] = s.score
+		if s.score > maxScore {
+			maxScore = s.score
+		}
+	}
+	if maxScore > 0 {
+		for i := range weights {
+			weights[i] /= maxScore
+		}
+	}
+	return weights
+}
+
+// properWords returns the lowercase words that are capitalized somewhere other than at
+// the start of a sentence, so they are names rather than sentence starts.
+func properWords(sentences []string) map[string]bool {
+	proper := make(map[string]bool)
+	for _, sentence := range sentences {
+		for i, w := range splitWords(sentence) {
+			if i > 0 && startsUpper(w.text) {
+				proper[strings.ToLower(w.text)] = true
+			}
+		}
+	}
+	return proper
+}
+
+// word is a word of a sentence; broken is true if punctuation separates it from the
+// previous word, which ends a phrase.
+type word struct {
+	text   string
+	broken bool
+}
+
+// splitWords splits a sentence into words of letters and digits. Spaces and hyphens
+// separate words within a phrase; other characters also end the phrase.
+func splitWords(sentence string) []word {
+	var words []word
+	var current strings.Builder
+	broken := false
+	for _, r := range sentence {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			current.WriteRune(r)
+			continue
+		}
+		if current.Len() > 0 {
+			words = append(words, word{text: current.String(), broken: broken})
+			current.Reset()
+			broken = false
+		}
+		if !unicode.IsSpace(r) && r != '-' {
+			broken = true
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, word{text: current.String(), broken: broken})
+	}
+	return words
+}
+
+// phrases returns the keyword candidates of a sentence. In titles, which are often
+// written in title case, only words known as proper or acronyms count as capitalized.
+func phrases(sentence string, chinese, isTitle bool, proper map[string]bool) []phrase {
+	if chinese {
+		var result []phrase
+		for _, token := range tokenize(sentence) {
+			if isKeywordToken(token) {
+				result = append(result, phrase{term: token, text: token, words: 1})
+			}
+		}
+		return result
+	}
+
+	var result []phrase
+	var run []string
+	runEntity := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		switch {
+		case runEntity && len(run) <= maxPhraseWords:
+			// Names are kept whole
+			result = append(result, newPhrase(run, true))
+		case runEntity:
+			for _, w := range run {
+				result = append(result, newPhrase([]string{w}, true))
+			}
+		default:
+			// Common words count on their own and in every phrase of up to maxPhraseWords
+			for start := range run {
+				for end := start + 1; end <= len(run) && end-start <= maxPhraseWords; end++ {
+					result = append(result, newPhrase(run[start:end], false))
+				}
+			}
+		}
+		run = nil
+	}
+
+	for i, w := range splitWords(sentence) {
+		lower := strings.ToLower(w.text)
+		acronym := isAcronym(w.text)
+		if w.broken {
+			flush()
+		}
+		if !acronym && (len(lower) <= 2 || isStopWord(lower) || keywordStopWords[lower] || isNumber(lower)) {
+			flush()
+			continue
+		}
+		entity := acronym || proper[lower] || (!isTitle && i > 0 && startsUpper(w.text))
+		if len(run) > 0 && entity != runEntity {
+			flush()
+		}
+		run = append(run, w.text)
+		runEntity = entity
+	}
+	flush()
+	return result
+}
+
+// newPhrase builds a candidate from the words of a run.
+func newPhrase(words []string, entity bool) phrase {
+	text := strings.Join(words, " ")
+	return phrase{term: strings.ToLower(text), text: text, words: len(words), entity: entity}
+}
+
+// isKeywordToken reports whether a segmented Chinese-text token can be a keyword:
+// words of two or more Chinese characters, or other words of three or more letters.
+func isKeywordToken(token string) bool {
+	han := 0
+	for _, r := range token {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+		if unicode.Is(unicode.Han, r) {
+			han++
+		}
+	}
+	if han > 0 {
+		return han >= 2
+	}
+	return len(token) > 2 && !isNumber(token) && !keywordStopWords[token]
+}
+
+// isAcronym reports whether a word is an acronym such as "EU" or "NASA".
+func isAcronym(w string) bool {
+	letters := 0
+	for _, r := range w {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 2 && len(w) <= 6
+}
+
+// startsUpper reports whether a word starts with an uppercase letter.
+func startsUpper(w string) bool {
+	for _, r := range w {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// isNumber reports whether a word consists of digits only.
+func isNumber(w string) bool {
+	for _, r := range w {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordStats accumulates the occurrences of a keyword.
+type keywordStats struct {
+	score  float64
+	count  int
+	words  int
+	entity bool
+	forms  map[string]int
+	order  int
+}
+
+// keywordAccumulator sums weighted keyword occurrences.
+type keywordAccumulator struct {
+	stats map[string]*keywordStats
+}
+
+func newKeywordAccumulator() *keywordAccumulator {
+	return &keywordAccumulator{stats: make(map[string]*keywordStats)}
+}
+
+// add records an occurrence of a candidate with a weight.
+func (a *keywordAccumulator) add(p phrase, weight float64) {
+	s, ok := a.stats[p.term]
+	if !ok {
+		s = &keywordStats{words: p.words, forms: make(map[string]int), order: len(a.stats)}
+		a.stats[p.term] = s
+	}
+	s.score += weight
+	s.count++
+	s.entity = s.entity || p.entity
+	s.forms[p.text]++
+}
+
+// top returns the best keywords with scores relative to the best one. Entities and
+// longer phrases are favored since they are more specific.
+func (a *keywordAccumulator) top(limit int) []Keyword {
+	type ranked struct {
+		Keyword
+		order int
+	}
+	var all []ranked
+	for term, s := range a.stats {
+		if s.words > 1 && !s.entity && s.count < 2 {
+			continue
+		}
+		score := s.score * (1 + 0.25*float64(s.words-1))
+		if s.entity {
+			score *= entityBoost
+		}
+		all = append(all, ranked{Keyword{Term: term, Text: preferredForm(s.forms), Score: score, Entity: s.entity}, s.order})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Score != all[j].Score {
+			return all[i].Score > all[j].Score
+		}
+		return all[i].order < all[j].order
+	})
+	if len(all) == 0 {
+		return nil
+	}
+	if len(all) > limit {
+		all = all[:limit]
+	}
+
+	keywords := make([]Keyword, len(all))
+	for i, r := range all {
+		keywords[i] = r.Keyword
+		keywords[i].Score /= all[0].Score
+	}
+	return keywords
+}
+
+// preferredForm returns the most frequent spelling, preferring capitalized ones on ties.
+func preferredForm(forms map[string]int) string {
+	best, bestCount := "", 0
+	for form, count := range forms {
+		if count > bestCount || (count == bestCount && form < best) {
+			best, bestCount = form, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,52 @@
+package summary
+
+import "testing"
+
+func TestExtractKeywords(t *testing.T) {
+	text := `<p>The European Union fined Apple 1.8 billion euros on Monday over its App Store rules for music streaming apps.
+The European Commission said Apple abused its dominant position. Spotify had complained about the App Store rules in 2019.
+Apple said it would appeal the decision of the European Commission. Music streaming services pay a commission on subscriptions sold through the App Store.</p>`
+
+	keywords := ExtractKeywords("EU Fines Apple Over Music Streaming", text, 10)
+	if len(keywords) != 10 || keywords[0].Text != "Apple" || !keywords[0].Entity || keywords[0].Score != 1 {
+		t.Fatalf("unexpected keywords %+v", keywords)
+	}
+
+	found := make(map[string]Keyword)
+	for i, k := range keywords {
+		found[k.Term] = k
+		if i > 0 && k.Score > keywords[i-1].Score {
+			t.Errorf("keywords not sorted by score: %+v", keywords)
+		}
+	}
+	for term, entity := range map[string]bool{"app store": true, "european commission": true, "eu": true, "music streaming": false} {
+		if k, ok := found[term]; !ok || k.Entity != entity {
+			t.Errorf("expected %q with entity=%v, got %+v", term, entity, keywords)
+		}
+	}
+	for _, term := range []string{"said", "the", "1.8", "monday over"} {
+		if _, ok := found[term]; ok {
+			t.Errorf("unexpected keyword %q", term)
+		}
+	}
+}
+
+func TestExtractKeywords_Chinese(t *testing.T) {
+	keywords := ExtractKeywords("欧盟对苹果处以罚款", "欧盟委员会周一宣布对苹果公司处以18亿欧元罚款，原因是其应用商店规则限制音乐流媒体应用。苹果公司表示将对欧盟委员会的决定提出上诉。", 10)
+	found := make(map[string]bool)
+	for _, k := range keywords {
+		found[k.Term] = true
+		if k.Term == "。" || k.Term == "，" {
+			t.Errorf("punctuation extracted as keyword: %+v", keywords)
+		}
+	}
+	if !found["欧盟委员会"] || !found["苹果公司"] {
+		t.Errorf("unexpected keywords %+v", keywords)
+	}
+}
+
+func TestExtractKeywords_Empty(t *testing.T) {
+	if keywords := ExtractKeywords("", "", 5); len(keywords) != 0 {
+		t.Errorf("expected no keywords, got %+v", keywords)
+	}
+}
@@ -0,0 +1,79 @@
+package summary
+
+import "strings"
+
+// titleMatchWeight is the score of a keyword found in the title
+const titleMatchWeight = 2.0
+
+// LabelKeywords describes a label by its name and the keywords that indicate it.
+type LabelKeywords struct {
+	Name     string
+	Keywords []string
+}
+
+// ScoreLabels scores how strongly a text is about each label, in input order. Each
+// keyword (and the label name) found in the title adds titleMatchWeight; each found
+// in a sentence adds between 0.5 and 1 depending on the sentence's TF-IDF score, so
+// keywords in sentences that carry the text weigh more than ones mentioned in passing.
+// Keywords of four or more characters also match words they prefix ("exploit" matches
+// "exploited"), and multi-word keywords match when all words occur in the sentence.
+func ScoreLabels(title, text string, labels []LabelKeywords) []float64 {
+	sentences := splitSentences(cleanText(text))
+	weights := calculateTFIDF(sentences)
+	sentenceTerms := make([]map[string]bool, len(sentences))
+	for i, sentence := range sentences {
+		sentenceTerms[i] = termSet(tokenize(sentence))
+	}
+	titleTerms := termSet(tokenize(cleanText(title)))
+
+	scores := make([]float64, len(labels))
+	for i, label := range labels {
+		for _, keyword := range append([]string{label.Name}, label.Keywords...) {
+			terms := tokenize(keyword)
+			if len(terms) == 0 {
+				continue
+			}
+			if containsTerms(titleTerms, terms) {
+				scores[i] += titleMatchWeight
+			}
+			for j, set := range sentenceTerms {
+				if containsTerms(set, terms) {
+					scores[i] += 0.5 + 0.5*weights[j]
+				}
+			}
+		}
+	}
+	return scores
+}
+
+// termSet returns the distinct terms of a token list.
+func termSet(tokens []string) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		set[t] = true
+	}
+	return set
+}
+
+// containsTerms reports whether every keyword term occurs in set, either exactly or,
+// for terms of four or more characters, as a prefix of a term in set.
+func containsTerms(set map[string]bool, terms []string) bool {
+	for _, term := range terms {
+		if set[term] {
+			continue
+		}
+		found := false
+		if len(term) >= 4 {
+			for candidate := range set {
+				if strings.HasPrefix(candidate, term) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
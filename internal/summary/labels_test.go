@@ -0,0 +1,29 @@
+package summary
+
+import "testing"
+
+func TestScoreLabels(t *testing.T) {
+	labels := []LabelKeywords{
+		{Name: "Security", Keywords: []string{"vulnerability", "exploit"}},
+		{Name: "Machine Learning", Keywords: []string{"neural network"}},
+		{Name: "Sports"},
+	}
+	text := "<p>Researchers disclosed a vulnerability in the popular web server. Attackers exploited the flaw for weeks before a patch shipped. " +
+		"The team trained a neural network to spot the attacks in network traffic logs.</p>"
+
+	scores := ScoreLabels("Web server flaw exploited in the wild", text, labels)
+	if len(scores) != 3 {
+		t.Fatalf("expected 3 scores, got %v", scores)
+	}
+	// Title match plus two sentence matches
+	if scores[0] < titleMatchWeight+1 {
+		t.Errorf("expected a strong Security score, got %v", scores[0])
+	}
+	// Only matched in one sentence, with both words of the keyword
+	if scores[1] <= 0 || scores[1] > 1 {
+		t.Errorf("expected a weak Machine Learning score, got %v", scores[1])
+	}
+	if scores[2] != 0 {
+		t.Errorf("expected no Sports score, got %v", scores[2])
+	}
+}
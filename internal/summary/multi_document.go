@@ -0,0 +1,196 @@
+package summary
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Document is a piece of text with an identifier, used for multi-document summarization
+type Document struct {
+	ID    int64
+	Title string
+	Text  string
+}
+
+// CitedSentence is a summary sentence together with the document it was taken from
+type CitedSentence struct {
+	Text       string
+	DocumentID int64
+}
+
+// DefaultClusterThreshold is the minimum cosine similarity for a document to join a cluster
+const DefaultClusterThreshold = 0.25
+
+// maxSentencesPerDocument bounds the sentences each document contributes to a
+// multi-document summary, keeping TextRank (quadratic in sentences) affordable
+const maxSentencesPerDocument = 8
+
+// ClusterDocuments groups related documents by the cosine similarity of their TF-IDF vectors.
+// Each document joins the most similar existing cluster if the similarity to that cluster's
+// centroid reaches threshold, otherwise it starts a new cluster.
+// Clusters are returned as indices into docs, largest first.
+func ClusterDocuments(docs []Document, threshold float64) [][]int {
+	vectors := documentVectors(docs)
+
+	type cluster struct {
+		members  []int
+		centroid map[string]float64
+	}
+	var clusters []*cluster
+
+	for i, vec := range vectors {
+		best, bestSim := -1, 0.0
+		for c, cl := range clusters {
+			if sim := cosineSimilarity(vec, cl.centroid); sim > bestSim {
+				best, bestSim = c, sim
+			}
+		}
+
+		if best >= 0 && bestSim >= threshold {
+			cl := clusters[best]
+			cl.members = append(cl.members, i)
+			// Keep the centroid as the running sum of member vectors
+			for term, w := range vec {
+				cl.centroid[term] += w
+			}
+			continue
+		}
+
+		centroid := make(map[string]float64, len(vec))
+		for term, w := range vec {
+			centroid[term] = w
+		}
+		clusters = append(clusters, &cluster{members: []int{i}, centroid: centroid})
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].members) > len(clusters[j].members)
+	})
+
+	result := make([][]int, len(clusters))
+	for i, cl := range clusters {
+		result[i] = cl.members
+	}
+	return result
+}
+
+// SummarizeDocuments produces an extractive summary across several documents using the same
+// combined TF-IDF and TextRank scoring as Summarize, keeping track of the source of each sentence.
+// Sentences are returned grouped by document in input order.
+func (s *Summarizer) SummarizeDocuments(docs []Document, length SummaryLength) []CitedSentence {
+	var sentences []string
+	var sources []int
+
+	for i, doc := range docs {
+		docSentences := splitSentences(cleanText(doc.Text))
+		if len(docSentences) == 0 {
+			// Fall back to the title so short items are still represented
+			if title := cleanText(doc.Title); len(title) > 10 {
+				docSentences = []string{title}
+			}
+		}
+		if len(docSentences) > maxSentencesPerDocument {
+			docSentences = docSentences[:maxSentencesPerDocument]
+		}
+		for _, sent := range docSentences {
+			sentences = append(sentences, sent)
+			sources = append(sources, i)
+		}
+	}
+
+	if len(sentences) == 0 {
+		return nil
+	}
+
+	isChinese := isChineseText(strings.Join(sentences, " "))
+	targetCount := getTargetWordCount(length)
+
+	scored := s.scoreSentences(sentences)
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	var selected []scoredSentence
+	currentCount := 0
+	for _, sent := range scored {
+		sentCount := countWordsOrChars(sent.text, isChinese)
+		if currentCount+sentCount <= targetCount || len(selected) == 0 {
+			selected = append(selected, sent)
+			currentCount += sentCount
+		}
+		if currentCount >= targetCount {
+			break
+		}
+	}
+
+	// Positions are global, so sorting by position keeps documents in input order
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].position < selected[j].position
+	})
+
+	result := make([]CitedSentence, len(selected))
+	for i, sent := range selected {
+		result[i] = CitedSentence{Text: sent.text, DocumentID: docs[sources[sent.position]].ID}
+	}
+	return result
+}
+
+// documentVectors builds L2-normalized TF-IDF vectors for each document.
+// Titles are counted twice since they carry most of the topic signal.
+func documentVectors(docs []Document) []map[string]float64 {
+	docFreq := make(map[string]int)
+	termFreqs := make([]map[string]int, len(docs))
+
+	for i, doc := range docs {
+		terms := tokenize(doc.Title + " " + doc.Title + " " + cleanText(doc.Text))
+		tf := make(map[string]int)
+		for _, term := range terms {
+			if tf[term] == 0 {
+				docFreq[term]++
+			}
+			tf[term]++
+		}
+		termFreqs[i] = tf
+	}
+
+	numDocs := float64(len(docs))
+	vectors := make([]map[string]float64, len(docs))
+	for i, tf := range termFreqs {
+		vec := make(map[string]float64, len(tf))
+		var norm float64
+		for term, count := range tf {
+			// Smoothed IDF so terms shared by every document still count a little
+			w := float64(count) * (math.Log((numDocs+1)/float64(docFreq[term]+1)) + 1)
+			vec[term] = w
+			norm += w * w
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for term := range vec {
+				vec[term] /= norm
+			}
+		}
+		vectors[i] = vec
+	}
+	return vectors
+}
+
+// cosineSimilarity returns the cosine similarity of two sparse vectors
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot, normA, normB float64
+	for term, w := range a {
+		dot += w * b[term]
+		normA += w * w
+	}
+	for _, w := range b {
+		normB += w * w
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
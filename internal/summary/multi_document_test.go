@@ -0,0 +1,45 @@
+package summary
+
+import "testing"
+
+func TestClusterDocuments(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Rust compiler release adds async closures", Text: "The Rust compiler team shipped async closures in the new release."},
+		{ID: 2, Title: "Lunar eclipse visible across Europe tonight", Text: "Astronomers expect a total lunar eclipse visible from Europe."},
+		{ID: 3, Title: "New Rust release brings async closures to the compiler", Text: "Async closures land in the Rust compiler release."},
+		{ID: 4, Title: "Europe prepares for tonight's lunar eclipse", Text: "Observatories across Europe open for the lunar eclipse."},
+	}
+
+	clusters := ClusterDocuments(docs, DefaultClusterThreshold)
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %v", clusters)
+	}
+	for _, c := range clusters {
+		if len(c) != 2 || docs[c[0]].ID%2 != docs[c[1]].ID%2 {
+			t.Errorf("unexpected cluster membership: %v", clusters)
+		}
+	}
+}
+
+func TestSummarizeDocuments_CitesSources(t *testing.T) {
+	docs := []Document{
+		{ID: 10, Title: "First", Text: "The city council approved the new transit budget on Monday. " +
+			"The budget funds three new tram lines across the city. Construction starts next spring."},
+		{ID: 20, Title: "Second", Text: "Residents welcomed the transit budget approved by the council. " +
+			"Critics argue the tram lines will take a decade to finish."},
+	}
+
+	sentences := NewSummarizer().SummarizeDocuments(docs, Short)
+	if len(sentences) == 0 {
+		t.Fatal("expected summary sentences")
+	}
+	for _, s := range sentences {
+		if s.DocumentID != 10 && s.DocumentID != 20 {
+			t.Errorf("sentence %q cites unknown document %d", s.Text, s.DocumentID)
+		}
+	}
+
+	if got := NewSummarizer().SummarizeDocuments(nil, Short); got != nil {
+		t.Errorf("expected nil for no documents, got %v", got)
+	}
+}
@@ -150,6 +150,12 @@ func tokenize(text string) []string {
 	return tokens
 }
 
+// Terms splits text into the lowercase index terms used for scoring, without
+// HTML and stopwords. Chinese text is segmented into words.
+func Terms(text string) []string {
+	return tokenize(cleanText(text))
+}
+
 // isStopWord checks if a word is a common stopword (English and Chinese)
 func isStopWord(word string) bool {
 	stopWords := map[string]bool{
@@ -2,6 +2,8 @@
 // It implements TF-IDF and TextRank-based sentence scoring for extractive summarization.
 package summary
 
+import "MrRSS/internal/aiclient"
+
 // SummaryLength represents the desired length of the summary
 type SummaryLength string
 
@@ -14,6 +16,21 @@ const (
 	Long SummaryLength = "long"
 )
 
+// ParseLength converts a length setting into a summary length. Empty and unknown values
+// select Medium; unknown values also report false.
+func ParseLength(length string) (SummaryLength, bool) {
+	switch length {
+	case "short":
+		return Short, true
+	case "long":
+		return Long, true
+	case "medium", "":
+		return Medium, true
+	default:
+		return Medium, false
+	}
+}
+
 // MinContentLength is the minimum text length required for meaningful summarization
 const MinContentLength = 200
 
@@ -36,9 +53,10 @@ const (
 
 // SummaryResult contains the generated summary and metadata
 type SummaryResult struct {
-	Summary       string `json:"summary"`
-	SentenceCount int    `json:"sentence_count"`
-	IsTooShort    bool   `json:"is_too_short"`
+	Summary       string         `json:"summary"`
+	SentenceCount int            `json:"sentence_count"`
+	IsTooShort    bool           `json:"is_too_short"`
+	Usage         aiclient.Usage `json:"-"` // Token usage of AI summaries
 }
 
 // scoredSentence holds a sentence with its calculated score and position
@@ -35,6 +35,13 @@ func getTargetWordCount(length SummaryLength) int {
 	}
 }
 
+// CountWords returns the length of a text (HTML allowed) in words, counting each
+// character of Chinese text as a word.
+func CountWords(text string) int {
+	text = cleanText(text)
+	return countWordsOrChars(text, isChineseText(text))
+}
+
 // countWordsOrChars counts words for English or characters for Chinese
 func countWordsOrChars(text string, isChinese bool) int {
 	if isChinese {
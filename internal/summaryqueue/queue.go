@@ -0,0 +1,353 @@
+// Package summaryqueue summarizes newly fetched articles in the background when
+// the summary trigger mode is automatic. The queue is stored in the database so
+// pending work survives restarts.
+package summaryqueue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/summary"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// DefaultConcurrency is the number of articles summarized at once when the setting is unset
+	DefaultConcurrency = 2
+	// MaxConcurrency bounds the summary_queue_concurrency setting
+	MaxConcurrency = 8
+	// MaxAttempts is the number of tries before an article is marked as failed
+	MaxAttempts = 3
+	// pollInterval is how often the queue is checked when no new work is signalled
+	pollInterval = time.Minute
+	// aiTimeout bounds the HTTP requests of one AI summary
+	aiTimeout = 30 * time.Second
+)
+
+// errNoContent is recorded for articles whose content could not be loaded.
+var errNoContent = errors.New("no content available for this article")
+
+// Status describes the current state of the queue.
+type Status struct {
+	Enabled   bool                      `json:"enabled"`
+	Pending   int                       `json:"pending"`
+	Running   int                       `json:"running"`
+	Failed    int                       `json:"failed"`
+	Completed int                       `json:"completed"` // Articles summarized since startup
+	Items     []models.SummaryQueueItem `json:"items"`
+}
+
+// Queue summarizes queued articles with bounded concurrency.
+type Queue struct {
+	db      *database.DB
+	tracker aiusage.Limiter
+	content utils.ContentFunc
+
+	wake chan struct{}
+
+	mu        sync.Mutex
+	running   map[int64]context.CancelFunc
+	completed int
+}
+
+// New creates a summary queue. tracker may be nil.
+func New(db *database.DB, tracker aiusage.Limiter, content utils.ContentFunc) *Queue {
+	return &Queue{
+		db:      db,
+		tracker: tracker,
+		content: content,
+		wake:    make(chan struct{}, 1),
+		running: make(map[int64]context.CancelFunc),
+	}
+}
+
+// Enabled reports whether articles are summarized automatically.
+func (q *Queue) Enabled() bool {
+	mode, _ := q.db.GetSetting("summary_trigger_mode")
+	return mode == "auto"
+}
+
+// EnqueueArticles queues newly saved articles of a feed if automatic summaries
+// are enabled and the feed matches the configured feeds or categories.
+func (q *Queue) EnqueueArticles(feed models.Feed, articleIDs []int64) {
+	if len(articleIDs) == 0 || !q.Enabled() || !q.matchesFeed(feed) {
+		return
+	}
+	if _, err := q.Add(articleIDs); err != nil {
+		log.Printf("Error queueing summaries for feed %s: %v", feed.Title, err)
+	}
+}
+
+// Add queues articles regardless of the trigger mode and feed filters.
+// It returns the number of articles that were not already queued.
+func (q *Queue) Add(articleIDs []int64) (int, error) {
+	added, err := q.db.EnqueueSummaries(articleIDs)
+	if err != nil {
+		return 0, err
+	}
+	if added > 0 {
+		q.signal()
+	}
+	return added, nil
+}
+
+// Cancel removes an article from the queue and stops it if it is being summarized.
+func (q *Queue) Cancel(articleID int64) (int64, error) {
+	q.mu.Lock()
+	if cancel, ok := q.running[articleID]; ok {
+		cancel()
+	}
+	q.mu.Unlock()
+	return q.db.RemoveSummaryQueueItems(articleID)
+}
+
+// CancelAll clears the queue and stops all running summaries.
+func (q *Queue) CancelAll() (int64, error) {
+	q.mu.Lock()
+	for _, cancel := range q.running {
+		cancel()
+	}
+	q.mu.Unlock()
+	return q.db.RemoveSummaryQueueItems()
+}
+
+// Status returns queue counts and up to limit queued items.
+func (q *Queue) Status(limit int) (*Status, error) {
+	counts, err := q.db.GetSummaryQueueCounts()
+	if err != nil {
+		return nil, err
+	}
+	items, err := q.db.GetSummaryQueueItems(limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.SummaryQueueItem{}
+	}
+
+	q.mu.Lock()
+	completed := q.completed
+	q.mu.Unlock()
+
+	return &Status{
+		Enabled:   q.Enabled(),
+		Pending:   counts[database.SummaryQueuePending],
+		Running:   counts[database.SummaryQueueRunning],
+		Failed:    counts[database.SummaryQueueFailed],
+		Completed: completed,
+		Items:     items,
+	}, nil
+}
+
+// Run processes the queue until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	// Items left running by a previous process are retried
+	if err := q.db.ResetRunningSummaries(); err != nil {
+		log.Printf("Error resetting summary queue: %v", err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		q.dispatch(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch starts workers for pending articles up to the configured concurrency.
+func (q *Queue) dispatch(ctx context.Context, wg *sync.WaitGroup) {
+	q.mu.Lock()
+	free := q.concurrency() - len(q.running)
+	q.mu.Unlock()
+	if free <= 0 || ctx.Err() != nil {
+		return
+	}
+
+	ids, err := q.db.ClaimPendingSummaries(free)
+	if err != nil {
+		log.Printf("Error claiming queued summaries: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		itemCtx, cancel := context.WithCancel(ctx)
+		q.mu.Lock()
+		q.running[id] = cancel
+		q.mu.Unlock()
+
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			q.process(itemCtx, id)
+
+			q.mu.Lock()
+			delete(q.running, id)
+			q.mu.Unlock()
+			cancel()
+			q.signal()
+		}(id)
+	}
+}
+
+// process summarizes one article and records the result.
+func (q *Queue) process(ctx context.Context, articleID int64) {
+	text, err := q.summarize(ctx, articleID)
+	if ctx.Err() != nil {
+		// Cancelled by the user or shutting down; shutdown leaves the item to be retried
+		return
+	}
+	if err != nil {
+		maxAttempts := MaxAttempts
+		if errors.Is(err, errNoContent) {
+			// Missing content will not appear by retrying
+			maxAttempts = 1
+		} else {
+			log.Printf("Error summarizing queued article %d: %v", articleID, err)
+		}
+		if err := q.db.FailSummaryQueueItem(articleID, err.Error(), maxAttempts); err != nil {
+			log.Printf("Error updating summary queue: %v", err)
+		}
+		return
+	}
+
+	if text != "" {
+		if err := q.db.UpdateArticleSummary(articleID, text); err != nil {
+			log.Printf("Failed to save summary for article %d: %v", articleID, err)
+			_ = q.db.FailSummaryQueueItem(articleID, err.Error(), MaxAttempts)
+			return
+		}
+	}
+	if err := q.db.CompleteSummaryQueueItem(articleID); err != nil {
+		log.Printf("Error updating summary queue: %v", err)
+	}
+
+	q.mu.Lock()
+	q.completed++
+	q.mu.Unlock()
+}
+
+// summarize produces a summary with the configured provider, falling back to the
+// local algorithm when AI fails or its usage limit is reached.
+func (q *Queue) summarize(ctx context.Context, articleID int64) (string, error) {
+	article, err := q.db.GetArticleByID(articleID)
+	if err != nil {
+		return "", err
+	}
+	if article.Summary != "" {
+		// Already summarized, e.g. opened by the user in the meantime
+		return "", nil
+	}
+
+	content, err := q.content(articleID)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", errNoContent
+	}
+
+	lengthSetting, _ := q.db.GetSetting("summary_length")
+	length, _ := summary.ParseLength(lengthSetting)
+
+	provider, _ := q.db.GetSetting("summary_provider")
+	if provider == "ai" && (q.tracker == nil || !q.tracker.IsLimitReached()) {
+		if q.tracker != nil {
+			q.tracker.WaitForRateLimit()
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		var result summary.SummaryResult
+		ai, err := summary.NewAISummarizerForSummaries(q.db, aiTimeout)
+		if err == nil {
+			result, err = ai.Summarize(content, length)
+		}
+		if err == nil {
+			if q.tracker != nil {
+				if err := q.tracker.Record(result.Usage.Record(aiusage.FeatureSummary, articleID)); err != nil {
+					log.Printf("Warning: failed to track AI usage: %v", err)
+				}
+			}
+			return result.Summary, nil
+		}
+		log.Printf("Error generating AI summary for article %d, falling back to local: %v", articleID, err)
+	}
+
+	return summary.NewSummarizer().Summarize(content, length).Summary, nil
+}
+
+// matchesFeed reports whether a feed is selected by the summary_auto_feeds and
+// summary_auto_categories settings. With neither set, every feed matches.
+func (q *Queue) matchesFeed(feed models.Feed) bool {
+	feedsSetting, _ := q.db.GetSetting("summary_auto_feeds")
+	categoriesSetting, _ := q.db.GetSetting("summary_auto_categories")
+	feedIDs := splitList(feedsSetting)
+	categories := splitList(categoriesSetting)
+	if len(feedIDs) == 0 && len(categories) == 0 {
+		return true
+	}
+
+	for _, s := range feedIDs {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil && id == feed.ID {
+			return true
+		}
+	}
+	for _, category := range categories {
+		// Categories include their subcategories
+		if feed.Category == category || strings.HasPrefix(feed.Category, category+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// concurrency reads the summary_queue_concurrency setting.
+func (q *Queue) concurrency() int {
+	value, _ := q.db.GetSetting("summary_queue_concurrency")
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return DefaultConcurrency
+	}
+	if n > MaxConcurrency {
+		return MaxConcurrency
+	}
+	return n
+}
+
+// signal wakes the dispatcher without blocking.
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// splitList splits a comma-separated setting, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
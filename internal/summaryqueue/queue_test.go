@@ -0,0 +1,227 @@
+package summaryqueue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+const sampleContent = "The city council approved a new budget for public transport on Monday. " +
+	"The plan adds twenty electric buses to the fleet over the next two years. " +
+	"Council members said the investment will cut emissions and shorten waiting times. " +
+	"Opposition members questioned whether the funding is sustainable in the long term. " +
+	"The first buses are expected to enter service early next spring."
+
+func setupQueueDB(t *testing.T) (*database.DB, models.Feed, []int64) {
+	t.Helper()
+	// The workers use several connections, which would each get an empty in-memory database
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "rss.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	feed := models.Feed{Title: "City News", URL: "https://city.example/feed", Category: "News/Local"}
+	feed.ID, _ = db.AddFeed(&feed)
+
+	articles := []*models.Article{
+		{FeedID: feed.ID, Title: "Budget approved", URL: "https://city.example/1", PublishedAt: time.Now()},
+		{FeedID: feed.ID, Title: "Bus fleet grows", URL: "https://city.example/2", PublishedAt: time.Now()},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	ids := []int64{articles[0].ID, articles[1].ID}
+	if ids[0] == 0 || ids[1] == 0 {
+		t.Fatalf("SaveArticles() did not set IDs: %v", ids)
+	}
+	return db, feed, ids
+}
+
+func staticContent(articleID int64) (string, error) { return sampleContent, nil }
+
+func waitForEmptyQueue(t *testing.T, q *Queue) *Status {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		status, err := q.Status(10)
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		if status.Pending == 0 && status.Running == 0 {
+			return status
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("queue did not drain")
+	return nil
+}
+
+func TestSaveArticles_DuplicatesKeepZeroID(t *testing.T) {
+	db, feed, _ := setupQueueDB(t)
+
+	dup := &models.Article{FeedID: feed.ID, Title: "Budget approved", URL: "https://city.example/1"}
+	if err := db.SaveArticles(context.Background(), []*models.Article{dup}); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	if dup.ID != 0 {
+		t.Errorf("duplicate article got ID %d, want 0", dup.ID)
+	}
+}
+
+func TestEnqueueArticles_RespectsModeAndFilters(t *testing.T) {
+	db, feed, ids := setupQueueDB(t)
+	q := New(db, nil, staticContent)
+
+	count := func() int {
+		counts, err := db.GetSummaryQueueCounts()
+		if err != nil {
+			t.Fatalf("GetSummaryQueueCounts() error = %v", err)
+		}
+		return counts[database.SummaryQueuePending]
+	}
+
+	db.SetSetting("summary_trigger_mode", "manual")
+	q.EnqueueArticles(feed, ids)
+	if n := count(); n != 0 {
+		t.Fatalf("manual mode queued %d articles", n)
+	}
+
+	db.SetSetting("summary_trigger_mode", "auto")
+	db.SetSetting("summary_auto_categories", "Sports")
+	q.EnqueueArticles(feed, ids)
+	if n := count(); n != 0 {
+		t.Fatalf("non-matching category queued %d articles", n)
+	}
+
+	// Parent categories include their subcategories
+	db.SetSetting("summary_auto_categories", "Sports, News")
+	q.EnqueueArticles(feed, ids)
+	if n := count(); n != 2 {
+		t.Fatalf("expected 2 queued articles, got %d", n)
+	}
+
+	// Queueing again does not duplicate items
+	q.EnqueueArticles(feed, ids)
+	if n := count(); n != 2 {
+		t.Fatalf("expected 2 queued articles after requeue, got %d", n)
+	}
+}
+
+func TestRun_SummarizesQueuedArticles(t *testing.T) {
+	db, _, ids := setupQueueDB(t)
+	db.SetSetting("summary_provider", "local")
+	q := New(db, nil, staticContent)
+
+	if _, err := q.Add(ids); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	status := waitForEmptyQueue(t, q)
+	if status.Completed != 2 || status.Failed != 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	for _, id := range ids {
+		article, err := db.GetArticleByID(id)
+		if err != nil {
+			t.Fatalf("GetArticleByID() error = %v", err)
+		}
+		if !strings.Contains(article.Summary, "bus") {
+			t.Errorf("article %d has unexpected summary %q", id, article.Summary)
+		}
+	}
+}
+
+func TestRun_FailuresAreRecorded(t *testing.T) {
+	db, _, ids := setupQueueDB(t)
+	db.SetSetting("summary_provider", "local")
+	q := New(db, nil, func(articleID int64) (string, error) {
+		if articleID == ids[0] {
+			return "", nil
+		}
+		return "", errors.New("feed unavailable")
+	})
+	q.Add(ids)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	status := waitForEmptyQueue(t, q)
+	if status.Failed != 2 {
+		t.Fatalf("expected 2 failed items, got %+v", status)
+	}
+	for _, item := range status.Items {
+		want := 1
+		if item.ArticleID == ids[1] {
+			want = MaxAttempts
+		}
+		if item.Attempts != want || item.LastError == "" {
+			t.Errorf("unexpected failed item: %+v", item)
+		}
+	}
+}
+
+func TestQueue_SurvivesRestartAndCancel(t *testing.T) {
+	db, _, ids := setupQueueDB(t)
+
+	// Simulate an item left running by a previous process
+	if _, err := db.EnqueueSummaries(ids); err != nil {
+		t.Fatalf("EnqueueSummaries() error = %v", err)
+	}
+	if claimed, err := db.ClaimPendingSummaries(1); err != nil || len(claimed) != 1 {
+		t.Fatalf("ClaimPendingSummaries() = %v, %v", claimed, err)
+	}
+	if err := db.ResetRunningSummaries(); err != nil {
+		t.Fatalf("ResetRunningSummaries() error = %v", err)
+	}
+
+	q := New(db, nil, staticContent)
+	status, err := q.Status(10)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Pending != 2 || status.Running != 0 {
+		t.Fatalf("unexpected status after restart: %+v", status)
+	}
+	if status.Items[0].Title == "" {
+		t.Errorf("expected article titles in queue items: %+v", status.Items)
+	}
+
+	if n, err := q.Cancel(ids[0]); err != nil || n != 1 {
+		t.Fatalf("Cancel() = %d, %v", n, err)
+	}
+	if n, err := q.CancelAll(); err != nil || n != 1 {
+		t.Fatalf("CancelAll() = %d, %v", n, err)
+	}
+	if status, _ := q.Status(10); status.Pending != 0 || len(status.Items) != 0 {
+		t.Errorf("queue not empty after cancel: %+v", status)
+	}
+}
@@ -0,0 +1,346 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/aiclient"
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/summary"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// BatchSize is the number of articles classified per AI prompt
+	BatchSize = 10
+	// MaxLabels is the maximum number of labels assigned to an article
+	MaxLabels = 3
+	// LocalThreshold is the score from summary.ScoreLabels a label needs locally,
+	// e.g. a keyword in the title or in two sentences
+	LocalThreshold = 1.5
+	// maxSnippetChars bounds the content sent to the AI model per article
+	maxSnippetChars = 600
+	// aiTimeout bounds the HTTP requests of one AI prompt
+	aiTimeout = 60 * time.Second
+)
+
+// Sources of assigned labels
+const (
+	SourceAI    = "ai"
+	SourceLocal = "local"
+)
+
+const systemPrompt = `You assign news articles to labels from a fixed list.
+Use only labels from the list, at most %d per article, and none if no label fits.
+Reply with a JSON object that maps each article number to an array of labels, for example {"1": ["Security"], "2": []}, and nothing else.`
+
+// Classifier labels newly fetched articles when tagging is enabled.
+type Classifier struct {
+	db      *database.DB
+	tracker aiusage.Limiter
+
+	wake chan struct{}
+
+	mu           sync.Mutex
+	onClassified ClassifiedFunc
+}
+
+// ClassifiedFunc receives newly fetched articles once their labels are stored.
+type ClassifiedFunc func(articles []models.Article)
+
+// New creates a classifier. tracker may be nil.
+func New(db *database.DB, tracker aiusage.Limiter) *Classifier {
+	return &Classifier{db: db, tracker: tracker, wake: make(chan struct{}, 1)}
+}
+
+// SetClassifiedFunc sets the function called with newly fetched articles once their
+// labels are stored, e.g. to apply the rules that match labels.
+func (c *Classifier) SetClassifiedFunc(f ClassifiedFunc) {
+	c.mu.Lock()
+	c.onClassified = f
+	c.mu.Unlock()
+}
+
+// Enabled reports whether tagging is enabled with a valid taxonomy.
+func (c *Classifier) Enabled() bool {
+	return len(c.Taxonomy()) > 0
+}
+
+// EnqueueArticles queues articles newly saved for a feed to be classified in the
+// background by Run. The queue is stored in the database, so that articles queued
+// before the app exits are classified by the next run.
+func (c *Classifier) EnqueueArticles(feed models.Feed, articles []*models.Article) {
+	if err := c.db.EnqueueClassifications(articles); err != nil {
+		log.Printf("Error queuing articles of %s for labeling: %v", feed.Title, err)
+		return
+	}
+	c.Trigger()
+}
+
+// Trigger wakes Run to classify queued articles.
+func (c *Classifier) Trigger() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Reset wakes Run to classify the articles queued in the database, e.g. after the
+// database was replaced by a backup with its own queue.
+func (c *Classifier) Reset() {
+	c.Trigger()
+}
+
+// Run classifies queued articles until ctx is cancelled. Articles still queued then
+// are classified by the next Run.
+func (c *Classifier) Run(ctx context.Context) {
+	for {
+		c.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.wake:
+		}
+	}
+}
+
+// drain classifies queued articles a batch at a time until the queue is empty.
+func (c *Classifier) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		queued, err := c.db.GetQueuedClassifications(BatchSize)
+		if err != nil {
+			log.Printf("Error getting articles queued for labeling: %v", err)
+			return
+		}
+		if len(queued) == 0 {
+			return
+		}
+
+		// Batch the articles of each feed, in queue order
+		var feeds []models.Feed
+		batches := make(map[int64][]*models.Article)
+		ids := make([]int64, len(queued))
+		for i := range queued {
+			article := &queued[i].Article
+			article.Content = queued[i].Content
+			if _, ok := batches[article.FeedID]; !ok {
+				feeds = append(feeds, models.Feed{ID: article.FeedID, Title: article.FeedTitle})
+			}
+			batches[article.FeedID] = append(batches[article.FeedID], article)
+			ids[i] = article.ID
+		}
+		for _, feed := range feeds {
+			c.ClassifyArticles(ctx, feed, batches[feed.ID])
+		}
+		if ctx.Err() != nil {
+			// Interrupted; classify the articles again on the next run
+			return
+		}
+		if err := c.db.RemoveQueuedClassifications(ids...); err != nil {
+			log.Printf("Error removing labeled articles from the queue: %v", err)
+			return
+		}
+
+		c.mu.Lock()
+		onClassified := c.onClassified
+		c.mu.Unlock()
+		if onClassified != nil {
+			articles := make([]models.Article, len(queued))
+			for i := range queued {
+				articles[i] = queued[i].Article
+			}
+			onClassified(articles)
+		}
+	}
+}
+
+// Taxonomy returns the configured labels if tagging is enabled, or nil.
+func (c *Classifier) Taxonomy() []Label {
+	enabled, _ := c.db.GetSetting("tagging_enabled")
+	if enabled != "true" {
+		return nil
+	}
+	value, _ := c.db.GetSetting("tagging_taxonomy")
+	labels, err := ParseTaxonomy(value)
+	if err != nil {
+		log.Printf("Error parsing tagging taxonomy: %v", err)
+		return nil
+	}
+	return labels
+}
+
+// ClassifyArticles assigns labels to articles newly saved for a feed and stores them.
+// Articles are sent to the AI model in batches of BatchSize when the tagging provider
+// is "ai"; batches that cannot be classified by AI are classified locally.
+func (c *Classifier) ClassifyArticles(ctx context.Context, feed models.Feed, articles []*models.Article) {
+	taxonomy := c.Taxonomy()
+	if len(taxonomy) == 0 || len(articles) == 0 {
+		return
+	}
+
+	provider, _ := c.db.GetSetting("tagging_provider")
+	var client *aiclient.Client
+	if provider == "ai" {
+		var err error
+		client, err = aiclient.NewForFeature(c.db, aiclient.FeatureTagging, aiTimeout)
+		if err != nil {
+			log.Printf("Error creating AI client for tagging, using local classifier: %v", err)
+		}
+	}
+
+	labeled := 0
+	for start := 0; start < len(articles); start += BatchSize {
+		if ctx.Err() != nil {
+			return
+		}
+		batch := articles[start:min(start+BatchSize, len(articles))]
+
+		source := SourceLocal
+		var assigned [][]string
+		if client != nil && (c.tracker == nil || !c.tracker.IsLimitReached()) {
+			var err error
+			if assigned, err = c.classifyAI(ctx, client, batch, taxonomy); err == nil {
+				source = SourceAI
+			} else {
+				log.Printf("Error classifying articles of %s with AI, falling back to local: %v", feed.Title, err)
+			}
+		}
+		if source == SourceLocal {
+			assigned = ClassifyLocal(batch, taxonomy)
+		}
+
+		for i, article := range batch {
+			article.Labels = assigned[i]
+			if len(assigned[i]) == 0 {
+				continue
+			}
+			if err := c.db.SetArticleLabels(article.ID, assigned[i], source); err != nil {
+				log.Printf("Error saving labels of article %d: %v", article.ID, err)
+				continue
+			}
+			labeled++
+		}
+	}
+	if labeled > 0 {
+		utils.DebugLog("Labeled %d articles in feed %s", labeled, feed.Title)
+	}
+}
+
+// ClassifyLocal assigns each article the best labels scoring at least LocalThreshold.
+func ClassifyLocal(articles []*models.Article, taxonomy []Label) [][]string {
+	keywords := make([]summary.LabelKeywords, len(taxonomy))
+	for i, label := range taxonomy {
+		keywords[i] = summary.LabelKeywords{Name: label.Name, Keywords: label.Keywords}
+	}
+
+	result := make([][]string, len(articles))
+	for i, article := range articles {
+		scores := summary.ScoreLabels(article.Title, article.Content, keywords)
+		var best []int
+		for j, score := range scores {
+			if score >= LocalThreshold {
+				best = append(best, j)
+			}
+		}
+		// Highest score first, keeping taxonomy order for ties
+		sort.SliceStable(best, func(a, b int) bool { return scores[best[a]] > scores[best[b]] })
+		if len(best) > MaxLabels {
+			best = best[:MaxLabels]
+		}
+		for _, j := range best {
+			result[i] = append(result[i], taxonomy[j].Name)
+		}
+	}
+	return result
+}
+
+// classifyAI asks the AI model to label a batch of articles and records the usage.
+func (c *Classifier) classifyAI(ctx context.Context, client *aiclient.Client, articles []*models.Article, taxonomy []Label) ([][]string, error) {
+	if c.tracker != nil {
+		c.tracker.WaitForRateLimit()
+	}
+	resp, err := client.Complete(ctx, aiclient.Request{
+		Messages: []aiclient.Message{
+			{Role: aiclient.RoleSystem, Content: fmt.Sprintf(systemPrompt, MaxLabels)},
+			{Role: aiclient.RoleUser, Content: buildPrompt(articles, taxonomy)},
+		},
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.tracker != nil {
+		if err := c.tracker.Record(resp.Usage.Record(aiusage.FeatureTagging, 0)); err != nil {
+			log.Printf("Warning: failed to track AI usage: %v", err)
+		}
+	}
+	return parseResponse(resp.Text, len(articles), taxonomy)
+}
+
+// buildPrompt lists the taxonomy and the numbered articles of a batch.
+func buildPrompt(articles []*models.Article, taxonomy []Label) string {
+	var b strings.Builder
+	b.WriteString("Labels:\n")
+	for _, label := range taxonomy {
+		b.WriteString("- " + label.Name)
+		if label.Description != "" {
+			b.WriteString(": " + label.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nArticles:\n")
+	for i, article := range articles {
+		fmt.Fprintf(&b, "\n[%d] %s\n", i+1, article.Title)
+		if snippet := utils.Truncate(utils.PlainText(article.Content), maxSnippetChars); snippet != "" {
+			b.WriteString(snippet + "\n")
+		}
+	}
+	return b.String()
+}
+
+// parseResponse reads the labels of each article from the model's JSON reply,
+// keeping only labels of the taxonomy in their configured spelling.
+func parseResponse(text string, count int, taxonomy []Label) ([][]string, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object in AI response")
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal([]byte(text[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid AI response: %w", err)
+	}
+
+	names := make(map[string]string, len(taxonomy))
+	for _, label := range taxonomy {
+		names[strings.ToLower(label.Name)] = label.Name
+	}
+
+	result := make([][]string, count)
+	for key, labels := range raw {
+		n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(key), "[]"))
+		if err != nil || n < 1 || n > count {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, label := range labels {
+			name, ok := names[strings.ToLower(strings.TrimSpace(label))]
+			if !ok || seen[name] || len(result[n-1]) >= MaxLabels {
+				continue
+			}
+			seen[name] = true
+			result[n-1] = append(result[n-1], name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,239 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/aiusage"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+const taxonomyJSON = `[
+	{"name": "Security", "description": "Vulnerabilities and attacks", "keywords": ["vulnerability", "exploit"]},
+	{"name": "Space", "keywords": ["rocket", "orbit"]},
+	{"name": "", "keywords": ["ignored"]},
+	{"name": "security"}
+]`
+
+type fakeTracker struct {
+	mu           sync.Mutex
+	limitReached bool
+	records      []models.AIUsageRecord
+}
+
+func (f *fakeTracker) IsLimitReached() bool { return f.limitReached }
+
+func (f *fakeTracker) WaitForRateLimit() {}
+
+func (f *fakeTracker) Record(rec models.AIUsageRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, rec)
+	return nil
+}
+
+func setupClassifier(t *testing.T, provider string) (*Classifier, *fakeTracker, *database.DB) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	db.SetSetting("tagging_enabled", "true")
+	db.SetSetting("tagging_provider", provider)
+	db.SetSetting("tagging_taxonomy", taxonomyJSON)
+	tracker := &fakeTracker{}
+	return New(db, tracker), tracker, db
+}
+
+// saveArticles saves count articles, alternating between a security and a space story.
+func saveArticles(t *testing.T, db *database.DB, count int) []*models.Article {
+	t.Helper()
+	feedID, _ := db.AddFeed(&models.Feed{Title: "News", URL: "https://news.example/feed"})
+	var articles []*models.Article
+	for i := 0; i < count; i++ {
+		a := &models.Article{FeedID: feedID, URL: fmt.Sprintf("https://news.example/%d", i), PublishedAt: time.Now()}
+		if i%2 == 0 {
+			a.Title = fmt.Sprintf("Router vulnerability %d", i)
+			a.Content = "<p>Attackers used the flaw to take over home routers. A patch is available.</p>"
+		} else {
+			a.Title = fmt.Sprintf("Launch report %d", i)
+			a.Content = "<p>The rocket reached orbit after a short delay. The crew is doing well.</p>"
+		}
+		articles = append(articles, a)
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles() error = %v", err)
+	}
+	return articles
+}
+
+func TestParseTaxonomy(t *testing.T) {
+	labels, err := ParseTaxonomy(taxonomyJSON)
+	if err != nil {
+		t.Fatalf("ParseTaxonomy() error = %v", err)
+	}
+	if len(labels) != 2 || labels[0].Name != "Security" || labels[1].Name != "Space" {
+		t.Errorf("unexpected labels %+v", labels)
+	}
+	if _, err := ParseTaxonomy("not json"); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestClassifyArticles_Local(t *testing.T) {
+	c, tracker, db := setupClassifier(t, "local")
+	articles := saveArticles(t, db, 2)
+
+	c.ClassifyArticles(context.Background(), models.Feed{Title: "News"}, articles)
+
+	if !reflect.DeepEqual(articles[0].Labels, []string{"Security"}) || !reflect.DeepEqual(articles[1].Labels, []string{"Space"}) {
+		t.Errorf("unexpected labels %v, %v", articles[0].Labels, articles[1].Labels)
+	}
+	if labels, _ := db.GetArticleLabels(articles[1].ID); !reflect.DeepEqual(labels, []string{"Space"}) {
+		t.Errorf("stored labels = %v", labels)
+	}
+	if len(tracker.records) != 0 {
+		t.Errorf("local classification must not record AI usage, got %+v", tracker.records)
+	}
+}
+
+func TestClassifyArticles_AIBatches(t *testing.T) {
+	c, tracker, db := setupClassifier(t, "ai")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		// Label every article of the batch, with labels the taxonomy does not have
+		reply := map[string][]string{}
+		for n := 1; strings.Contains(req.Messages[1].Content, fmt.Sprintf("[%d] ", n)); n++ {
+			reply[fmt.Sprint(n)] = []string{"space", "Politics"}
+		}
+		content, _ := json.Marshal(reply)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": "```json\n" + string(content) + "\n```"}}},
+			"usage":   map[string]int{"prompt_tokens": 100, "completion_tokens": 20},
+		})
+	}))
+	defer server.Close()
+	db.SetSetting("ai_endpoint", server.URL)
+	articles := saveArticles(t, db, BatchSize+2)
+
+	c.ClassifyArticles(context.Background(), models.Feed{Title: "News"}, articles)
+
+	if requests != 2 || len(tracker.records) != 2 || tracker.records[0].Feature != aiusage.FeatureTagging {
+		t.Fatalf("requests=%d records=%+v", requests, tracker.records)
+	}
+	for _, a := range articles {
+		if !reflect.DeepEqual(a.Labels, []string{"Space"}) {
+			t.Fatalf("article %d labels = %v", a.ID, a.Labels)
+		}
+	}
+	var source string
+	db.QueryRow("SELECT source FROM article_labels WHERE article_id = ?", articles[0].ID).Scan(&source)
+	if source != SourceAI {
+		t.Errorf("source = %q, want %q", source, SourceAI)
+	}
+}
+
+func TestClassifyArticles_FallsBackToLocal(t *testing.T) {
+	c, tracker, db := setupClassifier(t, "ai")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": "I cannot help with that."}}},
+		})
+	}))
+	defer server.Close()
+	db.SetSetting("ai_endpoint", server.URL)
+
+	articles := saveArticles(t, db, 2)
+	c.ClassifyArticles(context.Background(), models.Feed{}, articles)
+	if requests != 1 || !reflect.DeepEqual(articles[0].Labels, []string{"Security"}) {
+		t.Errorf("invalid reply: requests=%d labels=%v", requests, articles[0].Labels)
+	}
+
+	tracker.limitReached = true
+	c.ClassifyArticles(context.Background(), models.Feed{}, articles)
+	if requests != 1 || !reflect.DeepEqual(articles[1].Labels, []string{"Space"}) {
+		t.Errorf("limit reached: requests=%d labels=%v", requests, articles[1].Labels)
+	}
+}
+
+func TestClassifyArticles_Disabled(t *testing.T) {
+	c, _, db := setupClassifier(t, "local")
+	db.SetSetting("tagging_enabled", "false")
+	articles := saveArticles(t, db, 1)
+
+	c.ClassifyArticles(context.Background(), models.Feed{}, articles)
+	if labels, _ := db.GetArticleLabels(articles[0].ID); len(labels) != 0 || articles[0].Labels != nil {
+		t.Errorf("expected no labels, got %v", labels)
+	}
+}
+
+func TestRunClassifiesQueuedArticles(t *testing.T) {
+	c, _, db := setupClassifier(t, "local")
+	articles := saveArticles(t, db, 2)
+
+	done := make(chan []models.Article, 1)
+	c.SetClassifiedFunc(func(classified []models.Article) {
+		done <- classified
+	})
+	c.EnqueueArticles(models.Feed{Title: "News"}, articles)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	select {
+	case classified := <-done:
+		if len(classified) != 2 || classified[0].ID != articles[0].ID || !reflect.DeepEqual(classified[0].Labels, []string{"Security"}) {
+			t.Errorf("classified articles = %+v", classified)
+		}
+		if labels, _ := db.GetArticleLabels(articles[0].ID); !reflect.DeepEqual(labels, []string{"Security"}) {
+			t.Errorf("stored labels when done = %v", labels)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued articles were not classified")
+	}
+}
+
+func TestQueuedArticlesSurviveRestart(t *testing.T) {
+	c, _, db := setupClassifier(t, "local")
+	articles := saveArticles(t, db, 2)
+	c.EnqueueArticles(models.Feed{Title: "News"}, articles)
+
+	// A new classifier, as after a restart, classifies the articles with the content
+	// they were fetched with
+	var classified []models.Article
+	restarted := New(db, nil)
+	restarted.SetClassifiedFunc(func(articles []models.Article) { classified = append(classified, articles...) })
+	restarted.drain(context.Background())
+
+	if len(classified) != 2 {
+		t.Fatalf("classified %d articles, want 2", len(classified))
+	}
+	if labels, _ := db.GetArticleLabels(articles[1].ID); !reflect.DeepEqual(labels, []string{"Space"}) {
+		t.Errorf("labels = %v", labels)
+	}
+	if queued, _ := db.GetQueuedClassifications(10); len(queued) != 0 {
+		t.Errorf("classified articles are still queued: %+v", queued)
+	}
+}
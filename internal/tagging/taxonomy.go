@@ -0,0 +1,42 @@
+// Package tagging assigns labels from a user-defined taxonomy to newly fetched
+// articles, with the configured AI model or, when AI is off or out of quota, a
+// local keyword classifier based on the summary package's TF-IDF scoring.
+package tagging
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Label is an entry of the taxonomy. The description helps the AI model; the
+// keywords (and the name) are what the local classifier looks for.
+type Label struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+}
+
+// ParseTaxonomy parses the tagging_taxonomy setting, a JSON array of labels.
+// Labels without a name and repeated names (ignoring case) are dropped.
+func ParseTaxonomy(value string) ([]Label, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var raw []Label
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var labels []Label
+	for _, label := range raw {
+		label.Name = strings.TrimSpace(label.Name)
+		key := strings.ToLower(label.Name)
+		if label.Name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
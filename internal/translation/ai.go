@@ -1,29 +1,39 @@
 package translation
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"MrRSS/internal/aiclient"
 	"MrRSS/internal/config"
+	"MrRSS/internal/models"
 )
 
-// AITranslator implements translation using OpenAI-compatible APIs (GPT, Claude, etc.).
+// aiTranslateTimeout bounds a single AI translation including retries.
+const aiTranslateTimeout = 60 * time.Second
+
+// UsageFunc receives the token usage of each AI request.
+type UsageFunc func(usage aiclient.Usage)
+
+// AITranslator implements translation using AI providers (OpenAI-compatible, Ollama, Anthropic or Gemini).
 type AITranslator struct {
 	APIKey        string
 	Endpoint      string
 	Model         string
+	Backend       string // Empty or "auto" detects the backend from Endpoint
 	SystemPrompt  string
 	CustomHeaders string
 	client        *http.Client
 	db            DBInterface
+	onUsage       UsageFunc
+	ai            *aiclient.Client // Preconfigured client; overrides the connection fields above
 }
 
 // NewAITranslator creates a new AI translator with the given credentials.
-// endpoint should be the full API URL (e.g., "https://api.openai.com/v1/chat/completions" for OpenAI, "http://localhost:11434/api/generate" for Ollama)
+// endpoint should be the full API URL (e.g., "https://api.openai.com/v1/chat/completions" for OpenAI, "http://localhost:11434/api/chat" for Ollama)
 // model should be the model name (e.g., "gpt-4o-mini", "claude-3-haiku-20240307")
 // db is optional - if nil, no proxy will be used
 func NewAITranslator(apiKey, endpoint, model string) *AITranslator {
@@ -72,6 +82,16 @@ func NewAITranslatorWithDB(apiKey, endpoint, model string, db DBInterface) *AITr
 	}
 }
 
+// NewAITranslatorWithClient creates an AI translator that sends requests through a
+// preconfigured client, e.g. one created for an AI profile by aiclient.NewForFeature.
+func NewAITranslatorWithClient(client *aiclient.Client) *AITranslator {
+	return &AITranslator{
+		Model:   client.Model(),
+		Backend: string(client.Backend()),
+		ai:      client,
+	}
+}
+
 // SetSystemPrompt sets a custom system prompt for the translator.
 func (t *AITranslator) SetSystemPrompt(prompt string) {
 	t.SystemPrompt = prompt
@@ -82,23 +102,24 @@ func (t *AITranslator) SetCustomHeaders(headers string) {
 	t.CustomHeaders = headers
 }
 
-// parseCustomHeaders parses the JSON string of custom headers into a map.
-func parseCustomHeaders(headersJSON string) (map[string]string, error) {
-	// Return empty map if headers string is empty
-	if headersJSON == "" {
-		return make(map[string]string), nil
-	}
+// SetBackend sets the AI backend ("openai", "ollama", "anthropic", "gemini" or "auto").
+func (t *AITranslator) SetBackend(backend string) {
+	t.Backend = backend
+}
 
-	var headers map[string]string
-	if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
-		return nil, fmt.Errorf("failed to parse custom headers JSON: %w", err)
-	}
-	return headers, nil
+// SetUsageFunc sets a callback that receives the token usage of each request.
+func (t *AITranslator) SetUsageFunc(fn UsageFunc) {
+	t.onUsage = fn
 }
 
-// Translate translates text to the target language using an OpenAI-compatible API.
-// Automatically detects and adapts to different API formats (OpenAI vs Ollama).
+// Translate translates text to the target language using the configured AI backend.
 func (t *AITranslator) Translate(text, targetLang string) (string, error) {
+	return t.TranslateWithGlossary(text, "", targetLang, nil)
+}
+
+// TranslateWithGlossary translates text, instructing the model to follow the given glossary
+// and do-not-translate terms via the system prompt.
+func (t *AITranslator) TranslateWithGlossary(text, sourceLang, targetLang string, glossary []models.GlossaryEntry) (string, error) {
 	if text == "" {
 		return "", nil
 	}
@@ -110,145 +131,52 @@ func (t *AITranslator) Translate(text, targetLang string) (string, error) {
 	if systemPrompt == "" {
 		systemPrompt = "You are a translator. Translate the given text accurately. Output ONLY the translated text, nothing else."
 	}
+	systemPrompt += buildGlossaryPrompt(glossary)
 	userPrompt := fmt.Sprintf("Translate to %s:\n%s", langName, text)
 
-	// Try OpenAI format first
-	result, err := t.tryOpenAIFormat(systemPrompt, userPrompt)
-	if err == nil {
-		return result, nil
-	}
-
-	// If OpenAI format fails, try Ollama format
-	result, err = t.tryOllamaFormat(systemPrompt, userPrompt)
-	if err == nil {
-		return result, nil
+	client, err := t.newClient()
+	if err != nil {
+		return "", err
 	}
 
-	// Both formats failed
-	return "", fmt.Errorf("all API formats failed: OpenAI error: %v, Ollama error: %v", err, err)
-}
+	ctx, cancel := context.WithTimeout(context.Background(), aiTranslateTimeout)
+	defer cancel()
 
-// tryOpenAIFormat attempts to use OpenAI-compatible API format
-func (t *AITranslator) tryOpenAIFormat(systemPrompt, userPrompt string) (string, error) {
-	requestBody := map[string]interface{}{
-		"model": t.Model,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": userPrompt},
+	resp, err := client.Complete(ctx, aiclient.Request{
+		Messages: []aiclient.Message{
+			{Role: aiclient.RoleSystem, Content: systemPrompt},
+			{Role: aiclient.RoleUser, Content: userPrompt},
 		},
-		"temperature": 0.1, // Low temperature for consistent translations
-		"max_tokens":  256, // Limit output tokens for title translations
-	}
-
-	jsonBody, err := json.Marshal(requestBody)
+		Temperature: 0.1, // Low temperature for consistent translations
+		MaxTokens:   256, // Limit output tokens for title translations
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal OpenAI request: %w", err)
+		return "", fmt.Errorf("AI translation failed: %w", err)
 	}
-
-	resp, err := t.sendRequest(jsonBody)
-	if err != nil {
-		return "", fmt.Errorf("OpenAI request failed: %w", err)
+	if t.onUsage != nil {
+		t.onUsage(resp.Usage)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API returned status: %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode OpenAI response: %w", err)
-	}
-
-	if len(result.Choices) > 0 && result.Choices[0].Message.Content != "" {
-		// Clean up the response - remove any quotes or extra whitespace
-		translated := strings.TrimSpace(result.Choices[0].Message.Content)
-		translated = strings.Trim(translated, "\"'")
-		return translated, nil
-	}
-
-	return "", fmt.Errorf("no translation found in OpenAI response")
+	// Clean up the response - remove any quotes or extra whitespace
+	return strings.Trim(resp.Text, "\"'"), nil
 }
 
-// tryOllamaFormat attempts to use Ollama API format
-func (t *AITranslator) tryOllamaFormat(systemPrompt, userPrompt string) (string, error) {
-	// Combine system and user prompts for Ollama
-	fullPrompt := systemPrompt + "\n\n" + userPrompt
-
-	requestBody := map[string]interface{}{
-		"model":  t.Model,
-		"prompt": fullPrompt,
-		"stream": false,
+// newClient creates an AI client from the translator's settings.
+func (t *AITranslator) newClient() (*aiclient.Client, error) {
+	if t.ai != nil {
+		return t.ai, nil
 	}
-
-	jsonBody, err := json.Marshal(requestBody)
+	headers, err := aiclient.ParseCustomHeaders(t.CustomHeaders)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal Ollama request: %w", err)
-	}
-
-	resp, err := t.sendRequest(jsonBody)
-	if err != nil {
-		return "", fmt.Errorf("Ollama request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama API returned status: %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode Ollama response: %w", err)
-	}
-
-	if result.Done && result.Response != "" {
-		// Clean up the response - remove any quotes or extra whitespace
-		translated := strings.TrimSpace(result.Response)
-		translated = strings.Trim(translated, "\"'")
-		return translated, nil
-	}
-
-	return "", fmt.Errorf("no translation found in Ollama response")
-}
-
-// sendRequest sends the HTTP request with proper headers
-func (t *AITranslator) sendRequest(jsonBody []byte) (*http.Response, error) {
-	apiURL := t.Endpoint
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	// Only add Authorization header if API key is provided
-	if t.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+t.APIKey)
-	}
-
-	// Parse and add custom headers if provided
-	if t.CustomHeaders != "" {
-		customHeaders, err := parseCustomHeaders(t.CustomHeaders)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse custom headers: %w", err)
-		}
-		// Apply custom headers
-		for key, value := range customHeaders {
-			req.Header.Set(key, value)
-		}
-	}
-
-	return t.client.Do(req)
+		return nil, fmt.Errorf("failed to parse custom headers: %w", err)
+	}
+	return aiclient.New(aiclient.Config{
+		Backend:       aiclient.Backend(t.Backend),
+		Endpoint:      t.Endpoint,
+		APIKey:        t.APIKey,
+		Model:         t.Model,
+		CustomHeaders: headers,
+	}, t.client)
 }
 
 // getLanguageName converts a language code to a human-readable name.
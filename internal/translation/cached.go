@@ -12,6 +12,11 @@ type TranslationCache interface {
 	SetCachedTranslation(sourceTextHash, sourceText, targetLang, translatedText, provider string) error
 }
 
+// CacheStatsRecorder is implemented by caches that keep hit/miss counters
+type CacheStatsRecorder interface {
+	RecordTranslationCacheLookup(provider, targetLang string, hit bool) error
+}
+
 // CachedTranslator wraps a translator with caching functionality
 type CachedTranslator struct {
 	translator Translator
@@ -35,13 +40,15 @@ func (ct *CachedTranslator) Translate(text, targetLang string) (string, error) {
 	}
 
 	// Generate hash for cache lookup
-	textHash := hashText(text)
+	textHash := HashText(text)
 
 	// Try to get from cache first
 	if ct.cache != nil {
 		if cached, found, err := ct.cache.GetCachedTranslation(textHash, targetLang, ct.provider); err == nil && found {
+			ct.recordLookup(targetLang, true)
 			return cached, nil
 		}
+		ct.recordLookup(targetLang, false)
 	}
 
 	// Not in cache, perform translation
@@ -61,8 +68,19 @@ func (ct *CachedTranslator) Translate(text, targetLang string) (string, error) {
 	return translated, nil
 }
 
-// hashText creates a SHA256 hash of the text for cache lookup
-func hashText(text string) string {
+// recordLookup updates the cache hit/miss counters if the cache supports them
+func (ct *CachedTranslator) recordLookup(targetLang string, hit bool) {
+	recorder, ok := ct.cache.(CacheStatsRecorder)
+	if !ok {
+		return
+	}
+	if err := recorder.RecordTranslationCacheLookup(ct.provider, targetLang, hit); err != nil {
+		log.Printf("Warning: failed to record translation cache lookup: %v", err)
+	}
+}
+
+// HashText creates a SHA256 hash of the text for cache lookup
+func HashText(text string) string {
 	h := sha256.New()
 	h.Write([]byte(text))
 	return hex.EncodeToString(h.Sum(nil))
@@ -0,0 +1,44 @@
+package translation
+
+import "testing"
+
+type statsCache struct {
+	entries map[string]string
+	hits    int
+	misses  int
+}
+
+func (c *statsCache) GetCachedTranslation(hash, targetLang, provider string) (string, bool, error) {
+	v, ok := c.entries[hash+targetLang+provider]
+	return v, ok, nil
+}
+
+func (c *statsCache) SetCachedTranslation(hash, text, targetLang, translated, provider string) error {
+	c.entries[hash+targetLang+provider] = translated
+	return nil
+}
+
+func (c *statsCache) RecordTranslationCacheLookup(provider, targetLang string, hit bool) error {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return nil
+}
+
+func TestCachedTranslator_RecordsHitsAndMisses(t *testing.T) {
+	cache := &statsCache{entries: map[string]string{}}
+	ct := NewCachedTranslator(NewMockTranslator(), cache, "mock")
+
+	for i := 0; i < 3; i++ {
+		out, err := ct.Translate("Hello", "fr")
+		if err != nil || out != "[FR] Hello" {
+			t.Fatalf("Translate() = %q, %v", out, err)
+		}
+	}
+
+	if cache.misses != 1 || cache.hits != 2 {
+		t.Errorf("hits/misses = %d/%d, want 2/1", cache.hits, cache.misses)
+	}
+}
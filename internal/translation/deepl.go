@@ -3,11 +3,16 @@ package translation
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"MrRSS/internal/models"
 )
 
 type DeepLTranslator struct {
@@ -15,6 +20,29 @@ type DeepLTranslator struct {
 	Endpoint string // Custom endpoint for deeplx self-hosted service
 	client   *http.Client
 	db       DBInterface
+	baseURL  string // Overrides the DeepL API base URL (used in tests)
+
+	// Native glossary IDs keyed by language pair, with the entries hash they were created from
+	glossaryMu  sync.Mutex
+	glossaryIDs map[string]deeplGlossary
+	store       DeepLGlossaryStore // Persists glossary IDs across restarts, optional
+}
+
+// DeepLGlossaryStore records the glossaries created on DeepL accounts, so that they are
+// reused after a restart instead of created again.
+type DeepLGlossaryStore interface {
+	GetDeepLGlossary(account, langPair string) (id, hash string, err error)
+	SaveDeepLGlossary(account, langPair, id, hash string) error
+	DeleteDeepLGlossary(account, langPair string) error
+}
+
+// errGlossaryNotFound is returned when a translation refers to a glossary that no longer exists.
+var errGlossaryNotFound = errors.New("deepl glossary not found")
+
+// deeplGlossary records a glossary created on the DeepL account.
+type deeplGlossary struct {
+	id   string
+	hash string
 }
 
 // NewDeepLTranslator creates a new DeepL Translator
@@ -77,16 +105,77 @@ func (t *DeepLTranslator) Translate(text, targetLang string) (string, error) {
 		return t.translateWithDeeplx(text, targetLang)
 	}
 
-	// Standard DeepL API
-	apiURL := "https://api.deepl.com/v2/translate"
+	return t.translate(text, targetLang, "", "")
+}
+
+// TranslateWithGlossary translates text using a native DeepL glossary for the entries of
+// the source language. A glossary forces the source language, so it is only attached when
+// the source language is known. Do-not-translate terms, other entries and deeplx endpoints
+// (which have no glossary API) fall back to placeholder protection.
+func (t *DeepLTranslator) TranslateWithGlossary(text, sourceLang, targetLang string, glossary []models.GlossaryEntry) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	if t.Endpoint != "" {
+		return translateWithPlaceholders(translatorFunc(t.translateWithDeeplx), text, targetLang, glossary)
+	}
+
+	native, rest := splitNativeGlossary(glossary, sourceLang)
+	var glossaryID string
+	if len(native) > 0 {
+		id, err := t.ensureGlossary(sourceLang, targetLang, native)
+		if err != nil {
+			// Glossary creation failed (e.g. unsupported language pair), protect all terms instead
+			log.Printf("DeepL glossary unavailable, using placeholder protection: %v", err)
+			rest = glossary
+		} else {
+			glossaryID = id
+		}
+	}
+
+	translate := translatorFunc(func(s, lang string) (string, error) {
+		return t.translate(s, lang, sourceLang, glossaryID)
+	})
+	var translated string
+	var err error
+	if len(rest) == 0 {
+		translated, err = translate(text, targetLang)
+	} else {
+		translated, err = translateWithPlaceholders(translate, text, targetLang, rest)
+	}
+	if errors.Is(err, errGlossaryNotFound) {
+		// The glossary was deleted on the account; create it again next time
+		t.forgetGlossary(sourceLang, targetLang)
+		return translateWithPlaceholders(translatorFunc(func(s, lang string) (string, error) {
+			return t.translate(s, lang, "", "")
+		}), text, targetLang, glossary)
+	}
+	return translated, err
+}
+
+// apiBaseURL returns the DeepL API base URL for the configured key.
+func (t *DeepLTranslator) apiBaseURL() string {
+	if t.baseURL != "" {
+		return t.baseURL
+	}
 	if strings.HasSuffix(t.APIKey, ":fx") {
-		apiURL = "https://api-free.deepl.com/v2/translate"
+		return "https://api-free.deepl.com"
 	}
+	return "https://api.deepl.com"
+}
+
+// translate calls the standard DeepL API, optionally with a glossary (which requires a source language).
+func (t *DeepLTranslator) translate(text, targetLang, sourceLang, glossaryID string) (string, error) {
+	apiURL := t.apiBaseURL() + "/v2/translate"
 
 	data := url.Values{}
 	data.Set("auth_key", t.APIKey)
 	data.Set("text", text)
 	data.Set("target_lang", strings.ToUpper(targetLang))
+	if glossaryID != "" {
+		data.Set("source_lang", strings.ToUpper(primaryLang(sourceLang)))
+		data.Set("glossary_id", glossaryID)
+	}
 
 	resp, err := t.client.PostForm(apiURL, data)
 	if err != nil {
@@ -94,6 +183,9 @@ func (t *DeepLTranslator) Translate(text, targetLang string) (string, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound && glossaryID != "" {
+		return "", errGlossaryNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("deepl api returned status: %d", resp.StatusCode)
 	}
@@ -115,6 +207,173 @@ func (t *DeepLTranslator) Translate(text, targetLang string) (string, error) {
 	return "", fmt.Errorf("no translation found")
 }
 
+// SetGlossaryStore sets where the IDs of created glossaries are recorded.
+func (t *DeepLTranslator) SetGlossaryStore(store DeepLGlossaryStore) {
+	t.glossaryMu.Lock()
+	defer t.glossaryMu.Unlock()
+	t.store = store
+}
+
+// account identifies the DeepL account of the API key in the glossary store.
+func (t *DeepLTranslator) account() string {
+	return HashText(t.APIKey)
+}
+
+// glossaryKey returns the key of the glossary of a language pair.
+func glossaryKey(sourceLang, targetLang string) string {
+	return strings.ToLower(primaryLang(sourceLang)) + "|" + strings.ToLower(primaryLang(targetLang))
+}
+
+// ensureGlossary returns the ID of a DeepL glossary holding the given entries, reusing
+// the recorded glossary of the language pair, or creating it (and deleting the glossary it
+// replaces) when the entries changed.
+func (t *DeepLTranslator) ensureGlossary(sourceLang, targetLang string, entries []models.GlossaryEntry) (string, error) {
+	src := strings.ToLower(primaryLang(sourceLang))
+	tgt := strings.ToLower(primaryLang(targetLang))
+	tsv := glossaryTSV(entries)
+	hash := HashText(tsv)
+	key := glossaryKey(sourceLang, targetLang)
+
+	t.glossaryMu.Lock()
+	defer t.glossaryMu.Unlock()
+
+	if t.glossaryIDs == nil {
+		t.glossaryIDs = make(map[string]deeplGlossary)
+	}
+	existing, ok := t.glossaryIDs[key]
+	if !ok && t.store != nil {
+		if id, storedHash, err := t.store.GetDeepLGlossary(t.account(), key); err != nil {
+			log.Printf("Failed to get recorded DeepL glossary: %v", err)
+		} else if id != "" {
+			existing, ok = deeplGlossary{id: id, hash: storedHash}, true
+		}
+	}
+	if ok {
+		if existing.hash == hash {
+			t.glossaryIDs[key] = existing
+			return existing.id, nil
+		}
+		// Entries changed; the old glossary is stale
+		t.deleteGlossary(existing.id)
+		t.dropGlossary(key)
+	}
+
+	data := url.Values{}
+	data.Set("name", fmt.Sprintf("MrRSS %s-%s", src, tgt))
+	data.Set("source_lang", src)
+	data.Set("target_lang", tgt)
+	data.Set("entries", tsv)
+	data.Set("entries_format", "tsv")
+
+	req, err := http.NewRequest("POST", t.apiBaseURL()+"/v2/glossaries", strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create glossary request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+t.APIKey)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("glossary request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("deepl glossary api returned status: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		GlossaryID string `json:"glossary_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode glossary response: %w", err)
+	}
+	if result.GlossaryID == "" {
+		return "", fmt.Errorf("no glossary id in deepl response")
+	}
+
+	t.glossaryIDs[key] = deeplGlossary{id: result.GlossaryID, hash: hash}
+	if t.store != nil {
+		if err := t.store.SaveDeepLGlossary(t.account(), key, result.GlossaryID, hash); err != nil {
+			log.Printf("Failed to record DeepL glossary: %v", err)
+		}
+	}
+	return result.GlossaryID, nil
+}
+
+// forgetGlossary drops the glossary of a language pair, so that it is created again.
+func (t *DeepLTranslator) forgetGlossary(sourceLang, targetLang string) {
+	t.glossaryMu.Lock()
+	defer t.glossaryMu.Unlock()
+	t.dropGlossary(glossaryKey(sourceLang, targetLang))
+}
+
+// dropGlossary removes the glossary of a language pair from memory and the store. The
+// caller holds glossaryMu.
+func (t *DeepLTranslator) dropGlossary(key string) {
+	delete(t.glossaryIDs, key)
+	if t.store != nil {
+		if err := t.store.DeleteDeepLGlossary(t.account(), key); err != nil {
+			log.Printf("Failed to delete recorded DeepL glossary: %v", err)
+		}
+	}
+}
+
+// deleteGlossary removes a glossary from the DeepL account. Failures are only logged.
+func (t *DeepLTranslator) deleteGlossary(id string) {
+	req, err := http.NewRequest("DELETE", t.apiBaseURL()+"/v2/glossaries/"+url.PathEscape(id), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+t.APIKey)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		log.Printf("Failed to delete stale DeepL glossary %s: %v", id, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// splitNativeGlossary separates the entries that can go into a DeepL glossary (translated
+// terms of the source language) from those that need placeholder protection. Without a
+// known source language no glossary can be used.
+func splitNativeGlossary(entries []models.GlossaryEntry, sourceLang string) (native, rest []models.GlossaryEntry) {
+	source := strings.ToLower(primaryLang(sourceLang))
+	for _, e := range entries {
+		if source != "" && !e.DoNotTranslate && strings.ToLower(primaryLang(e.SourceLang)) == source {
+			native = append(native, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return native, rest
+}
+
+// glossaryTSV renders entries in DeepL's tab-separated glossary format.
+func glossaryTSV(entries []models.GlossaryEntry) string {
+	lines := make([]string, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		source := strings.TrimSpace(e.SourceTerm)
+		target := strings.TrimSpace(e.TargetTerm)
+		// DeepL rejects duplicate source terms and entries containing tabs or newlines
+		if source == "" || target == "" || seen[source] || strings.ContainsAny(source+target, "\t\r\n") {
+			continue
+		}
+		seen[source] = true
+		lines = append(lines, source+"\t"+target)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// primaryLang returns the primary subtag of a language code ("en-US" -> "en").
+func primaryLang(lang string) string {
+	if idx := strings.IndexAny(lang, "-_"); idx != -1 {
+		return lang[:idx]
+	}
+	return lang
+}
+
 // translateWithDeeplx handles translation using deeplx self-hosted service
 // deeplx API: POST /translate with JSON body {text, source_lang, target_lang}
 func (t *DeepLTranslator) translateWithDeeplx(text, targetLang string) (string, error) {
@@ -0,0 +1,114 @@
+package translation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// functionWords are frequent short words that tell Latin-script languages apart.
+var functionWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "are", "was", "this", "on", "by", "not", "you", "have"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "den", "von", "zu", "auf", "sich", "für", "auch", "dem", "im"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "du", "pour", "pas", "que", "dans", "sur", "avec", "qui", "au", "ce"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "en", "un", "una", "por", "con", "para", "del", "se", "al", "lo", "como"},
+	"it": {"il", "di", "che", "e", "la", "un", "una", "per", "non", "con", "del", "sono", "della", "gli", "le", "è", "nel", "alla"},
+	"pt": {"o", "os", "as", "e", "que", "um", "uma", "para", "com", "não", "do", "da", "em", "por", "dos", "das", "no", "na"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "met", "zijn", "voor", "die", "ook", "aan", "er", "bij"},
+}
+
+// functionWordLangs maps each function word to the languages it belongs to.
+var functionWordLangs = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range functionWords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// DetectLanguage guesses the primary language code of text ("en", "zh", ...) from its
+// script and, for Latin script, from common function words. It returns "" when the text
+// is too short or ambiguous to tell.
+func DetectLanguage(text string) string {
+	var latin, han, kana, hangul, cyrillic, arabic, greek, hebrew, thai int
+	ukrainian := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		}
+	}
+
+	// CJK characters carry more text per rune than letters do
+	cjk := (han + kana) * 3
+	best := max(latin, cjk, hangul*3, cyrillic, arabic, greek, hebrew, thai)
+	switch {
+	case best == 0:
+		return ""
+	case best == cjk && kana > 0:
+		return "ja"
+	case best == cjk:
+		return "zh"
+	case best == hangul*3:
+		return "ko"
+	case best == cyrillic && ukrainian:
+		return "uk"
+	case best == cyrillic:
+		return "ru"
+	case best == arabic:
+		return "ar"
+	case best == greek:
+		return "el"
+	case best == hebrew:
+		return "he"
+	case best == thai:
+		return "th"
+	}
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage picks the language whose function words occur most often in text.
+func detectLatinLanguage(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for _, lang := range functionWordLangs[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = lang, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	// Require a few hits and a clear winner
+	if bestScore < 2 || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
@@ -2,9 +2,11 @@ package translation
 
 import (
 	"fmt"
-	"net/url"
-	"strings"
+	"net/http"
 	"sync"
+	"time"
+
+	"MrRSS/internal/aiclient"
 )
 
 // SettingsProvider is an interface for retrieving translation settings.
@@ -24,17 +26,17 @@ type CacheProvider interface {
 type DynamicTranslator struct {
 	settings SettingsProvider
 	cache    CacheProvider
+	onUsage  UsageFunc
 	mu       sync.RWMutex
 	// Cache the current translator to avoid recreating it for every translation
-	cachedTranslator    Translator
-	cachedProvider      string
-	cachedAPIKey        string
-	cachedAppID         string
-	cachedSecretKey     string
-	cachedEndpoint      string
-	cachedModel         string
-	cachedPrompt        string
-	cachedCustomHeaders string
+	cachedTranslator Translator
+	cachedProvider   string
+	cachedAPIKey     string
+	cachedAppID      string
+	cachedSecretKey  string
+	cachedEndpoint   string
+	cachedAIConfig   string
+	cachedPrompt     string
 }
 
 // NewDynamicTranslator creates a new dynamic translator that uses the given settings provider.
@@ -52,6 +54,15 @@ func NewDynamicTranslatorWithCache(settings SettingsProvider, cache CacheProvide
 	}
 }
 
+// SetUsageFunc sets a callback that receives the token usage of each AI translation request.
+func (t *DynamicTranslator) SetUsageFunc(fn UsageFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onUsage = fn
+	// Recreate the translator so the callback is applied
+	t.cachedTranslator = nil
+}
+
 // Translate translates text using the currently configured translation provider.
 func (t *DynamicTranslator) Translate(text, targetLang string) (string, error) {
 	if text == "" {
@@ -63,6 +74,16 @@ func (t *DynamicTranslator) Translate(text, targetLang string) (string, error) {
 		return "", err
 	}
 
+	// Apply glossary and do-not-translate terms if the settings provider stores them
+	if glossaryProvider, ok := t.settings.(GlossaryProvider); ok {
+		if entries, err := glossaryProvider.GetGlossaryEntries(); err == nil && len(entries) > 0 {
+			glossaryTranslator := NewGlossaryTranslator(translator, entries)
+			sourceLang, _ := t.settings.GetSetting("translation_source_language")
+			glossaryTranslator.SetSourceLang(sourceLang)
+			translator = glossaryTranslator
+		}
+	}
+
 	// Wrap with caching if cache is available
 	if t.cache != nil {
 		cachedTranslator := NewCachedTranslator(translator, t.cache, provider)
@@ -81,7 +102,8 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 	}
 
 	// Get provider-specific settings (use encrypted methods for sensitive credentials)
-	var apiKey, appID, secretKey, endpoint, model, systemPrompt, customHeaders string
+	var apiKey, appID, secretKey, endpoint, aiConfigKey, systemPrompt string
+	var aiConfig aiclient.Config
 	switch provider {
 	case "deepl":
 		apiKey, _ = t.settings.GetEncryptedSetting("deepl_api_key")
@@ -90,11 +112,14 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 		appID, _ = t.settings.GetSetting("baidu_app_id")
 		secretKey, _ = t.settings.GetEncryptedSetting("baidu_secret_key")
 	case "ai":
-		apiKey, _ = t.settings.GetEncryptedSetting("ai_api_key")
-		endpoint, _ = t.settings.GetSetting("ai_endpoint")
-		model, _ = t.settings.GetSetting("ai_model")
+		// Use the AI profile selected for translation, or the global AI settings
+		var err error
+		aiConfig, err = aiclient.ConfigForFeature(t.settings, aiclient.FeatureTranslation)
+		if err != nil {
+			return nil, "", err
+		}
+		aiConfigKey = aiConfig.Key()
 		systemPrompt, _ = t.settings.GetSetting("ai_translation_prompt")
-		customHeaders, _ = t.settings.GetSetting("ai_custom_headers")
 	}
 
 	// Check if we can reuse the cached translator
@@ -105,9 +130,8 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 		t.cachedAppID == appID &&
 		t.cachedSecretKey == secretKey &&
 		t.cachedEndpoint == endpoint &&
-		t.cachedModel == model &&
-		t.cachedPrompt == systemPrompt &&
-		t.cachedCustomHeaders == customHeaders {
+		t.cachedAIConfig == aiConfigKey &&
+		t.cachedPrompt == systemPrompt {
 		translator := t.cachedTranslator
 		t.mu.RUnlock()
 		return translator, provider, nil
@@ -130,7 +154,12 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 		if endpoint != "" {
 			translator = NewDeepLTranslatorWithEndpoint(apiKey, endpoint)
 		} else {
-			translator = NewDeepLTranslator(apiKey)
+			deepl := NewDeepLTranslator(apiKey)
+			// Reuse the glossaries created on the account before a restart
+			if store, ok := t.settings.(DeepLGlossaryStore); ok {
+				deepl.SetGlossaryStore(store)
+			}
+			translator = deepl
 		}
 	case "baidu":
 		if appID == "" || secretKey == "" {
@@ -139,16 +168,18 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 		translator = NewBaiduTranslator(appID, secretKey)
 	case "ai":
 		// Allow empty API key for local endpoints (e.g., Ollama)
-		if apiKey == "" && !isLocalEndpoint(endpoint) {
+		if aiConfig.APIKey == "" && !aiclient.IsLocalEndpoint(aiConfig.Endpoint) {
 			return nil, "", fmt.Errorf("AI API key is required for non-local endpoints")
 		}
-		aiTranslator := NewAITranslator(apiKey, endpoint, model)
+		client, err := aiclient.New(aiConfig, &http.Client{Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, "", err
+		}
+		aiTranslator := NewAITranslatorWithClient(client)
+		aiTranslator.SetUsageFunc(t.onUsage)
 		if systemPrompt != "" {
 			aiTranslator.SetSystemPrompt(systemPrompt)
 		}
-		if customHeaders != "" {
-			aiTranslator.SetCustomHeaders(customHeaders)
-		}
 		translator = aiTranslator
 	default:
 		translator = NewGoogleFreeTranslator()
@@ -161,40 +192,8 @@ func (t *DynamicTranslator) getTranslatorWithProvider() (Translator, string, err
 	t.cachedAppID = appID
 	t.cachedSecretKey = secretKey
 	t.cachedEndpoint = endpoint
-	t.cachedModel = model
+	t.cachedAIConfig = aiConfigKey
 	t.cachedPrompt = systemPrompt
-	t.cachedCustomHeaders = customHeaders
 
 	return translator, provider, nil
 }
-
-// isLocalEndpoint checks if an endpoint URL points to a local service (localhost, 127.0.0.1, etc.)
-// This allows using empty API keys for local LLM services like Ollama
-func isLocalEndpoint(endpointURL string) bool {
-	if endpointURL == "" {
-		return false
-	}
-
-	// Parse the URL to extract the host
-	parsedURL, err := url.Parse(endpointURL)
-	if err != nil {
-		return false
-	}
-
-	host := parsedURL.Host
-	// Remove port if present
-	if idx := strings.LastIndex(host, ":"); idx != -1 {
-		// Handle IPv6 addresses like [::1]:8080
-		if !strings.Contains(host[idx:], "]") {
-			host = host[:idx]
-		}
-	}
-	// Remove brackets from IPv6 addresses
-	host = strings.Trim(host, "[]")
-
-	return host == "localhost" ||
-		host == "127.0.0.1" ||
-		host == "::1" ||
-		strings.HasPrefix(host, "127.") ||
-		host == "0.0.0.0"
-}
@@ -0,0 +1,245 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"MrRSS/internal/models"
+)
+
+// GlossaryProvider is an interface for retrieving glossary and do-not-translate entries.
+type GlossaryProvider interface {
+	GetGlossaryEntries() ([]models.GlossaryEntry, error)
+}
+
+// GlossaryAwareTranslator is implemented by translators that can apply glossary terms natively
+// (e.g. DeepL glossaries or AI prompt instructions) instead of placeholder protection.
+type GlossaryAwareTranslator interface {
+	Translator
+	// sourceLang is the configured or detected language of text, empty if unknown.
+	TranslateWithGlossary(text, sourceLang, targetLang string, glossary []models.GlossaryEntry) (string, error)
+}
+
+// GlossaryTranslator wraps a translator so glossary terms round-trip consistently.
+// Translators implementing GlossaryAwareTranslator receive the entries directly;
+// all others get the terms swapped for placeholders before translation.
+type GlossaryTranslator struct {
+	translator Translator
+	entries    []models.GlossaryEntry
+	sourceLang string // Configured source language; empty detects it per text
+}
+
+// NewGlossaryTranslator creates a new glossary-applying translator.
+func NewGlossaryTranslator(translator Translator, entries []models.GlossaryEntry) *GlossaryTranslator {
+	return &GlossaryTranslator{
+		translator: translator,
+		entries:    entries,
+	}
+}
+
+// SetSourceLang sets the language texts are written in. Empty detects the language of
+// each text.
+func (gt *GlossaryTranslator) SetSourceLang(lang string) {
+	gt.sourceLang = lang
+}
+
+// Translate translates text, applying the glossary entries that match the source and
+// target languages.
+func (gt *GlossaryTranslator) Translate(text, targetLang string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	sourceLang := gt.sourceLang
+	if sourceLang == "" {
+		sourceLang = DetectLanguage(text)
+	}
+	entries := FilterGlossary(gt.entries, sourceLang, targetLang)
+	if len(entries) == 0 {
+		return gt.translator.Translate(text, targetLang)
+	}
+
+	if aware, ok := gt.translator.(GlossaryAwareTranslator); ok {
+		return aware.TranslateWithGlossary(text, sourceLang, targetLang, entries)
+	}
+
+	return translateWithPlaceholders(gt.translator, text, targetLang, entries)
+}
+
+// FilterGlossary returns the entries that apply when translating from sourceLang into
+// targetLang. Entries with a source language are kept for an unknown (empty) sourceLang,
+// since their terms are only applied where they occur in the text.
+func FilterGlossary(entries []models.GlossaryEntry, sourceLang, targetLang string) []models.GlossaryEntry {
+	var result []models.GlossaryEntry
+	for _, e := range entries {
+		if strings.TrimSpace(e.SourceTerm) == "" {
+			continue
+		}
+		if !e.DoNotTranslate && e.TargetTerm == "" {
+			continue
+		}
+		if !langMatches(e.TargetLang, targetLang) {
+			continue
+		}
+		if sourceLang != "" && !langMatches(e.SourceLang, sourceLang) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// langMatches reports whether a glossary language (possibly empty or a primary subtag like "zh")
+// applies to the requested language code (e.g. "zh" or "zh-CN").
+func langMatches(entryLang, lang string) bool {
+	if entryLang == "" {
+		return true
+	}
+	entryLang = strings.ToLower(entryLang)
+	lang = strings.ToLower(lang)
+	return entryLang == lang || strings.HasPrefix(lang, entryLang+"-") || strings.HasPrefix(entryLang, lang+"-")
+}
+
+// translateWithPlaceholders replaces glossary terms with placeholders, translates,
+// and restores the placeholders with the target terms (or the original text for do-not-translate terms).
+func translateWithPlaceholders(translator Translator, text, targetLang string, entries []models.GlossaryEntry) (string, error) {
+	protected, replacements := protectTerms(text, entries)
+	if len(replacements) == 0 {
+		return translator.Translate(text, targetLang)
+	}
+
+	translated, err := translator.Translate(protected, targetLang)
+	if err != nil {
+		return "", err
+	}
+	return restoreTerms(translated, replacements), nil
+}
+
+// placeholderPattern matches placeholders produced by protectTerms, tolerating the
+// extra spaces and full-width brackets some providers introduce.
+var placeholderPattern = regexp.MustCompile(`[\[［【]{2}\s*(\d+)\s*[\]］】]{2}`)
+
+// protectTerms replaces every glossary term in text with a numbered placeholder.
+// It returns the protected text and the replacement for each placeholder index.
+func protectTerms(text string, entries []models.GlossaryEntry) (string, []string) {
+	pattern, ordered := buildTermPattern(entries)
+	if pattern == nil {
+		return text, nil
+	}
+
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var sb strings.Builder
+	var replacements []string
+	last := 0
+	for _, m := range matches {
+		// Find which alternative matched (group i+1 corresponds to ordered[i])
+		for i := range ordered {
+			start, end := m[2*(i+1)], m[2*(i+1)+1]
+			if start < 0 {
+				continue
+			}
+			entry := ordered[i]
+			replacement := entry.TargetTerm
+			if entry.DoNotTranslate {
+				replacement = text[start:end]
+			}
+			sb.WriteString(text[last:m[0]])
+			sb.WriteString(fmt.Sprintf("[[%d]]", len(replacements)))
+			replacements = append(replacements, replacement)
+			last = m[1]
+			break
+		}
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), replacements
+}
+
+// restoreTerms replaces placeholders in translated text with their recorded replacements.
+func restoreTerms(text string, replacements []string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := placeholderPattern.FindStringSubmatch(match)
+		idx, err := strconv.Atoi(sub[1])
+		if err != nil || idx < 0 || idx >= len(replacements) {
+			return match
+		}
+		return replacements[idx]
+	})
+}
+
+// buildTermPattern compiles a single alternation regex for all entries, longest terms first
+// so that overlapping terms prefer the most specific match.
+func buildTermPattern(entries []models.GlossaryEntry) (*regexp.Regexp, []models.GlossaryEntry) {
+	ordered := make([]models.GlossaryEntry, 0, len(entries))
+	for _, e := range entries {
+		if strings.TrimSpace(e.SourceTerm) != "" {
+			ordered = append(ordered, e)
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return len(ordered[i].SourceTerm) > len(ordered[j].SourceTerm)
+	})
+
+	parts := make([]string, len(ordered))
+	for i, e := range ordered {
+		term := strings.TrimSpace(e.SourceTerm)
+		expr := regexp.QuoteMeta(term)
+		// Only use word boundaries around word characters, so terms don't match inside
+		// longer words ("Go" in "Google") while CJK terms still match anywhere.
+		runes := []rune(term)
+		if isWordRune(runes[0]) {
+			expr = `\b` + expr
+		}
+		if isWordRune(runes[len(runes)-1]) {
+			expr += `\b`
+		}
+		if !e.CaseSensitive {
+			expr = "(?i:" + expr + ")"
+		}
+		parts[i] = "(" + expr + ")"
+	}
+
+	pattern, err := regexp.Compile(strings.Join(parts, "|"))
+	if err != nil {
+		return nil, nil
+	}
+	return pattern, ordered
+}
+
+// isWordRune reports whether r is an ASCII word character, matching regexp's \b semantics.
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// buildGlossaryPrompt renders glossary entries as instructions for AI translators.
+func buildGlossaryPrompt(entries []models.GlossaryEntry) string {
+	var glossary, keep []string
+	for _, e := range entries {
+		if e.DoNotTranslate {
+			keep = append(keep, fmt.Sprintf("- %q", e.SourceTerm))
+		} else {
+			glossary = append(glossary, fmt.Sprintf("- %q -> %q", e.SourceTerm, e.TargetTerm))
+		}
+	}
+
+	var sb strings.Builder
+	if len(glossary) > 0 {
+		sb.WriteString("\n\nAlways translate these terms exactly as given:\n")
+		sb.WriteString(strings.Join(glossary, "\n"))
+	}
+	if len(keep) > 0 {
+		sb.WriteString("\n\nNever translate these terms, keep them exactly as written:\n")
+		sb.WriteString(strings.Join(keep, "\n"))
+	}
+	return sb.String()
+}
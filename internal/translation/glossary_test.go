@@ -0,0 +1,229 @@
+package translation
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestGlossaryTranslator_PlaceholderRoundTrip(t *testing.T) {
+	entries := []models.GlossaryEntry{
+		{SourceTerm: "MrRSS", DoNotTranslate: true},
+		{SourceTerm: "feed", TargetTerm: "订阅源", TargetLang: "zh"},
+		{SourceTerm: "Go", TargetTerm: "Go语言", CaseSensitive: true},
+	}
+
+	var sent string
+	inner := translatorFunc(func(text, targetLang string) (string, error) {
+		sent = text
+		// Simulate a provider that adds spaces inside placeholders
+		return strings.ReplaceAll(text, "[[", "[[ "), nil
+	})
+
+	gt := NewGlossaryTranslator(inner, entries)
+	out, err := gt.Translate("mrrss reads every Feed, not Google or go", "zh")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+
+	if strings.Contains(strings.ToLower(sent), "mrrss") || strings.Contains(strings.ToLower(sent), "feed") {
+		t.Errorf("glossary terms were sent to provider unprotected: %q", sent)
+	}
+	if !strings.Contains(sent, "Google") || !strings.Contains(sent, " go") {
+		t.Errorf("case-sensitive whole-word term matched too eagerly: %q", sent)
+	}
+	want := "mrrss reads every 订阅源, not Google or go"
+	if out != want {
+		t.Errorf("Translate() = %q, want %q", out, want)
+	}
+}
+
+func TestGlossaryTranslator_TargetLangFilter(t *testing.T) {
+	entries := []models.GlossaryEntry{
+		{SourceTerm: "feed", TargetTerm: "flux", TargetLang: "fr"},
+	}
+
+	gt := NewGlossaryTranslator(NewMockTranslator(), entries)
+	out, err := gt.Translate("feed", "de")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if out != "[DE] feed" {
+		t.Errorf("entry for another language was applied: %q", out)
+	}
+
+	out, err = gt.Translate("feed", "fr-FR")
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if out != "[FR-FR] flux" {
+		t.Errorf("Translate() = %q, want %q", out, "[FR-FR] flux")
+	}
+}
+
+func TestAITranslator_GlossaryInSystemPrompt(t *testing.T) {
+	var systemPrompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Messages) > 0 {
+			systemPrompt = body.Messages[0].Content
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"你好 MrRSS"}}]}`))
+	}))
+	defer server.Close()
+
+	translator := NewAITranslator("key", server.URL, "model")
+	gt := NewGlossaryTranslator(translator, []models.GlossaryEntry{
+		{SourceTerm: "MrRSS", DoNotTranslate: true},
+		{SourceTerm: "feed", TargetTerm: "订阅源"},
+	})
+
+	if _, err := gt.Translate("Hello MrRSS feed", "zh"); err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if !strings.Contains(systemPrompt, `"feed" -> "订阅源"`) {
+		t.Errorf("glossary missing from system prompt: %q", systemPrompt)
+	}
+	if !strings.Contains(systemPrompt, `"MrRSS"`) {
+		t.Errorf("do-not-translate term missing from system prompt: %q", systemPrompt)
+	}
+}
+
+// memoryGlossaryStore records DeepL glossaries in memory.
+type memoryGlossaryStore map[string][2]string
+
+func (m memoryGlossaryStore) GetDeepLGlossary(account, langPair string) (string, string, error) {
+	g := m[account+langPair]
+	return g[0], g[1], nil
+}
+
+func (m memoryGlossaryStore) SaveDeepLGlossary(account, langPair, id, hash string) error {
+	m[account+langPair] = [2]string{id, hash}
+	return nil
+}
+
+func (m memoryGlossaryStore) DeleteDeepLGlossary(account, langPair string) error {
+	delete(m, account+langPair)
+	return nil
+}
+
+func TestDeepLTranslator_NativeGlossary(t *testing.T) {
+	glossaryCreates := 0
+	var deleted []string
+	var translateForm url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/glossaries":
+			glossaryCreates++
+			body, _ := io.ReadAll(r.Body)
+			form, _ := url.ParseQuery(string(body))
+			if !strings.HasPrefix(form.Get("entries"), "feed\t订阅源") || form.Get("source_lang") != "en" || form.Get("target_lang") != "zh" {
+				t.Errorf("unexpected glossary request: %v", form)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"glossary_id":"g-` + strconv.Itoa(glossaryCreates) + `"}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/v2/glossaries/"))
+		case r.URL.Path == "/v2/translate":
+			r.ParseForm()
+			translateForm = r.PostForm
+			w.Write([]byte(`{"translations":[{"text":"` + r.PostForm.Get("text") + `"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	store := memoryGlossaryStore{}
+	newTranslator := func() *DeepLTranslator {
+		translator := NewDeepLTranslator("key")
+		translator.baseURL = server.URL
+		translator.SetGlossaryStore(store)
+		return translator
+	}
+	entries := []models.GlossaryEntry{
+		{SourceTerm: "feed", TargetTerm: "订阅源", SourceLang: "en"},
+		{SourceTerm: "MrRSS", DoNotTranslate: true},
+	}
+	const text = "MrRSS reads the feed and the news for you"
+
+	// A restart reuses the recorded glossary
+	for i := 0; i < 2; i++ {
+		out, err := NewGlossaryTranslator(newTranslator(), entries).Translate(text, "zh")
+		if err != nil {
+			t.Fatalf("Translate failed: %v", err)
+		}
+		if out != text {
+			t.Errorf("do-not-translate term not restored: %q", out)
+		}
+	}
+	if glossaryCreates != 1 {
+		t.Errorf("expected glossary to be created once, got %d", glossaryCreates)
+	}
+	if translateForm.Get("glossary_id") != "g-1" || translateForm.Get("source_lang") != "EN" {
+		t.Errorf("translate request missing glossary parameters: %v", translateForm)
+	}
+
+	// Text in another language gets no glossary and no forced source language
+	if _, err := NewGlossaryTranslator(newTranslator(), entries).Translate("Der Feed ist nicht mit dem Server verbunden", "zh"); err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if translateForm.Get("glossary_id") != "" || translateForm.Get("source_lang") != "" {
+		t.Errorf("glossary attached to text in another language: %v", translateForm)
+	}
+
+	// Changed entries replace the glossary and delete the old one
+	entries = append(entries, models.GlossaryEntry{SourceTerm: "news", TargetTerm: "新闻", SourceLang: "en"})
+	if _, err := NewGlossaryTranslator(newTranslator(), entries).Translate(text, "zh"); err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if glossaryCreates != 2 || len(deleted) != 1 || deleted[0] != "g-1" {
+		t.Errorf("glossary not replaced: %d created, deleted %v", glossaryCreates, deleted)
+	}
+	if len(store) != 1 {
+		t.Errorf("expected one recorded glossary, got %v", store)
+	}
+}
+
+func TestFilterGlossary_SourceLang(t *testing.T) {
+	entries := []models.GlossaryEntry{
+		{SourceTerm: "feed", TargetTerm: "订阅源", SourceLang: "en"},
+		{SourceTerm: "Feed", TargetTerm: "订阅源", SourceLang: "de"},
+		{SourceTerm: "MrRSS", DoNotTranslate: true},
+	}
+	if got := FilterGlossary(entries, "en-US", "zh"); len(got) != 2 || got[0].SourceLang != "en" {
+		t.Errorf("FilterGlossary(en) = %v", got)
+	}
+	if got := FilterGlossary(entries, "", "zh"); len(got) != 3 {
+		t.Errorf("FilterGlossary with unknown source = %v", got)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"The new release is available for all users of the app":         "en",
+		"Die neue Version ist jetzt für alle Nutzer verfügbar":          "de",
+		"La nouvelle version est disponible pour tous les utilisateurs": "fr",
+		"新版本现已向所有用户开放":                                                  "zh",
+		"新しいバージョンが利用可能です":                                               "ja",
+		"새 버전을 사용할 수 있습니다":                                              "ko",
+		"Новая версия уже доступна":                                     "ru",
+		"Apple iPhone": "",
+	}
+	for text, want := range tests {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"MrRSS/internal/aiclient"
 )
 
 type rtFunc func(*http.Request) (*http.Response, error)
@@ -85,19 +87,19 @@ func TestAITranslate_SuccessAndEmpty(t *testing.T) {
 func TestAITranslate_AutoDetectOllama(t *testing.T) {
 	t1 := NewAITranslator("", "http://localhost:11434/api/generate", "llama3.2:1b")
 
-	// Mock Ollama response (first try OpenAI format, which should fail, then try Ollama format)
+	// The Ollama backend is detected from the endpoint, so no OpenAI request is attempted
 	callCount := 0
+	var path string
 	t1.client = &http.Client{Transport: rtFunc(func(req *http.Request) (*http.Response, error) {
 		callCount++
-		if callCount == 1 {
-			// First call - OpenAI format fails
-			return &http.Response{StatusCode: 400, Body: io.NopCloser(strings.NewReader(`{"error":{"message":"Invalid format"}}`)), Header: http.Header{"Content-Type": {"application/json"}}}, nil
-		}
-		// Second call - Ollama format succeeds
-		body := `{"response":"Bonjour","done":true}`
+		path = req.URL.Path
+		body := `{"message":{"role":"assistant","content":"Bonjour"},"done":true,"prompt_eval_count":12,"eval_count":3}`
 		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{"Content-Type": {"application/json"}}}, nil
 	}), Timeout: 5 * time.Second}
 
+	var tokens int64
+	t1.SetUsageFunc(func(usage aiclient.Usage) { tokens = usage.Total() })
+
 	out, err := t1.Translate("Hello", "fr")
 	if err != nil {
 		t.Fatalf("AI translate auto-detect failed: %v", err)
@@ -105,7 +107,13 @@ func TestAITranslate_AutoDetectOllama(t *testing.T) {
 	if out != "Bonjour" {
 		t.Fatalf("expected Bonjour, got %s", out)
 	}
-	if callCount != 2 {
-		t.Fatalf("expected 2 API calls (OpenAI then Ollama), got %d", callCount)
+	if callCount != 1 {
+		t.Fatalf("expected 1 API call, got %d", callCount)
+	}
+	if path != "/api/chat" {
+		t.Errorf("expected request to /api/chat, got %s", path)
+	}
+	if tokens != 15 {
+		t.Errorf("expected reported usage of 15 tokens, got %d", tokens)
 	}
 }
@@ -1,7 +1,7 @@
 package translation
 
 import (
-	"MrRSS/internal/utils"
+	"MrRSS/internal/aiclient"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,6 +13,14 @@ type Translator interface {
 	Translate(text, targetLang string) (string, error)
 }
 
+// translatorFunc adapts a plain function to the Translator interface.
+type translatorFunc func(text, targetLang string) (string, error)
+
+// Translate calls f(text, targetLang).
+func (f translatorFunc) Translate(text, targetLang string) (string, error) {
+	return f(text, targetLang)
+}
+
 // DBInterface defines the minimal database interface needed for proxy settings
 type DBInterface interface {
 	GetSetting(key string) (string, error)
@@ -21,22 +29,7 @@ type DBInterface interface {
 
 // CreateHTTPClientWithProxy creates an HTTP client with global proxy settings if enabled
 func CreateHTTPClientWithProxy(db DBInterface, timeout time.Duration) (*http.Client, error) {
-	var proxyURL string
-
-	// Check if global proxy is enabled
-	proxyEnabled, _ := db.GetSetting("proxy_enabled")
-	if proxyEnabled == "true" {
-		// Build proxy URL from global settings
-		proxyType, _ := db.GetSetting("proxy_type")
-		proxyHost, _ := db.GetSetting("proxy_host")
-		proxyPort, _ := db.GetSetting("proxy_port")
-		proxyUsername, _ := db.GetEncryptedSetting("proxy_username")
-		proxyPassword, _ := db.GetEncryptedSetting("proxy_password")
-		proxyURL = utils.BuildProxyURL(proxyType, proxyHost, proxyPort, proxyUsername, proxyPassword)
-	}
-
-	// Create HTTP client with or without proxy
-	return utils.CreateHTTPClient(proxyURL, timeout)
+	return aiclient.NewHTTPClient(db, timeout)
 }
 
 // MockTranslator is a simple translator for demonstration
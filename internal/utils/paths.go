@@ -7,6 +7,9 @@ import (
 	"sync"
 )
 
+// CustomCSSFileName is the name of the custom article CSS file in the data directory
+const CustomCSSFileName = "custom_article.css"
+
 var (
 	isPortableMode   bool
 	portableModeOnce sync.Once
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// ContentFunc returns the content of an article, for features that need the content of
+// articles that have none stored.
+type ContentFunc func(articleID int64) (string, error)
+
+// PlainText strips HTML tags and collapses whitespace.
+func PlainText(text string) string {
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// Truncate shortens text to at most maxChars bytes without splitting a character.
+func Truncate(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxChars], "")
+}
+
+// TruncateAtWord shortens text to at most maxChars bytes at a word boundary, marking the
+// cut with an ellipsis.
+func TruncateAtWord(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	cut := Truncate(text, maxChars)
+	if i := strings.LastIndexByte(cut, ' '); i > maxChars/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,21 @@
+package utils
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	if got := PlainText("<p>Hello\n  <b>world</b></p>"); got != "Hello world" {
+		t.Errorf("PlainText() = %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("héllo", 2); got != "h" {
+		t.Errorf("Truncate() split a character: %q", got)
+	}
+	if got := TruncateAtWord("the quick brown fox", 14); got != "the quick…" {
+		t.Errorf("TruncateAtWord() = %q", got)
+	}
+	if got := TruncateAtWord("short", 14); got != "short" {
+		t.Errorf("TruncateAtWord() = %q", got)
+	}
+}
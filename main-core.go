@@ -22,16 +22,21 @@ import (
 	"MrRSS/internal/feed"
 	aihandlers "MrRSS/internal/handlers/ai"
 	article "MrRSS/internal/handlers/article"
+	backupHandler "MrRSS/internal/handlers/backup"
 	browser "MrRSS/internal/handlers/browser"
 	chat "MrRSS/internal/handlers/chat"
 	handlers "MrRSS/internal/handlers/core"
 	customcss "MrRSS/internal/handlers/custom_css"
+	dbBackupHandler "MrRSS/internal/handlers/dbbackup"
+	digesthandlers "MrRSS/internal/handlers/digest"
 	discovery "MrRSS/internal/handlers/discovery"
 	feedhandlers "MrRSS/internal/handlers/feed"
 	freshrssHandler "MrRSS/internal/handlers/freshrss"
 	media "MrRSS/internal/handlers/media"
 	networkhandlers "MrRSS/internal/handlers/network"
 	opml "MrRSS/internal/handlers/opml"
+	readerSyncHandler "MrRSS/internal/handlers/readersync"
+	readLaterHandler "MrRSS/internal/handlers/readlater"
 	rules "MrRSS/internal/handlers/rules"
 	script "MrRSS/internal/handlers/script"
 	settings "MrRSS/internal/handlers/settings"
@@ -163,15 +168,56 @@ func main() {
 	apiMux.HandleFunc("/api/articles/filter", func(w http.ResponseWriter, r *http.Request) { article.HandleFilteredArticles(h, w, r) })
 	apiMux.HandleFunc("/api/articles/read", func(w http.ResponseWriter, r *http.Request) { article.HandleMarkRead(h, w, r) })
 	apiMux.HandleFunc("/api/articles/favorite", func(w http.ResponseWriter, r *http.Request) { article.HandleToggleFavorite(h, w, r) })
+	apiMux.HandleFunc("/api/articles/duplicates", func(w http.ResponseWriter, r *http.Request) { article.HandleDuplicateArticles(h, w, r) })
+	apiMux.HandleFunc("/api/articles/labels", func(w http.ResponseWriter, r *http.Request) { article.HandleLabels(h, w, r) })
+	apiMux.HandleFunc("/api/articles/keywords", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleKeywords(h, w, r) })
+	apiMux.HandleFunc("/api/keywords/trending", func(w http.ResponseWriter, r *http.Request) { article.HandleTrendingKeywords(h, w, r) })
+	apiMux.HandleFunc("/api/articles/score", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleScore(h, w, r) })
+	apiMux.HandleFunc("/api/articles/feedback", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleFeedback(h, w, r) })
 	apiMux.HandleFunc("/api/articles/cleanup", func(w http.ResponseWriter, r *http.Request) { article.HandleCleanupArticles(h, w, r) })
 	apiMux.HandleFunc("/api/articles/translate", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleTranslateArticle(h, w, r) })
 	apiMux.HandleFunc("/api/articles/translate-text", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleTranslateText(h, w, r) })
 	apiMux.HandleFunc("/api/articles/clear-translations", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleClearTranslations(h, w, r) })
+	apiMux.HandleFunc("/api/translation/glossary", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleGlossary(h, w, r) })
+	apiMux.HandleFunc("/api/translation/glossary/add", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleAddGlossaryEntry(h, w, r) })
+	apiMux.HandleFunc("/api/translation/glossary/update", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleUpdateGlossaryEntry(h, w, r) })
+	apiMux.HandleFunc("/api/translation/glossary/delete", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleDeleteGlossaryEntry(h, w, r) })
+	apiMux.HandleFunc("/api/translation/cache", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleTranslationCache(h, w, r) })
+	apiMux.HandleFunc("/api/translation/cache/stats", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleTranslationCacheStats(h, w, r) })
+	apiMux.HandleFunc("/api/translation/cache/update", func(w http.ResponseWriter, r *http.Request) {
+		translationhandlers.HandleUpdateCachedTranslation(h, w, r)
+	})
+	apiMux.HandleFunc("/api/translation/cache/delete", func(w http.ResponseWriter, r *http.Request) {
+		translationhandlers.HandleDeleteCachedTranslation(h, w, r)
+	})
+	apiMux.HandleFunc("/api/translation/cache/export", func(w http.ResponseWriter, r *http.Request) {
+		translationhandlers.HandleExportTranslationCache(h, w, r)
+	})
+	apiMux.HandleFunc("/api/translation/cache/import", func(w http.ResponseWriter, r *http.Request) {
+		translationhandlers.HandleImportTranslationCache(h, w, r)
+	})
 	apiMux.HandleFunc("/api/ai-usage", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleGetAIUsage(h, w, r) })
 	apiMux.HandleFunc("/api/ai-usage/reset", func(w http.ResponseWriter, r *http.Request) { translationhandlers.HandleResetAIUsage(h, w, r) })
 	apiMux.HandleFunc("/api/ai-chat", func(w http.ResponseWriter, r *http.Request) { chat.HandleAIChat(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/stream", func(w http.ResponseWriter, r *http.Request) { chat.HandleAIChatStream(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/library", func(w http.ResponseWriter, r *http.Request) { chat.HandleLibraryChat(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/sessions", func(w http.ResponseWriter, r *http.Request) { chat.HandleChatSessions(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/sessions/get", func(w http.ResponseWriter, r *http.Request) { chat.HandleGetChatSession(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/sessions/rename", func(w http.ResponseWriter, r *http.Request) { chat.HandleRenameChatSession(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/sessions/delete", func(w http.ResponseWriter, r *http.Request) { chat.HandleDeleteChatSession(h, w, r) })
+	apiMux.HandleFunc("/api/ai-chat/sessions/export", func(w http.ResponseWriter, r *http.Request) { chat.HandleExportChatSession(h, w, r) })
 	apiMux.HandleFunc("/api/ai/test", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleTestAIConfig(h, w, r) })
 	apiMux.HandleFunc("/api/ai/test/info", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleGetAITestInfo(h, w, r) })
+	apiMux.HandleFunc("/api/ai-usage/stats", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleAIUsageStats(h, w, r) })
+	apiMux.HandleFunc("/api/ai/profiles", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleAIProfiles(h, w, r) })
+	apiMux.HandleFunc("/api/ai/profiles/add", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleAddAIProfile(h, w, r) })
+	apiMux.HandleFunc("/api/ai/profiles/update", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleUpdateAIProfile(h, w, r) })
+	apiMux.HandleFunc("/api/ai/profiles/delete", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleDeleteAIProfile(h, w, r) })
+	apiMux.HandleFunc("/api/ai/embeddings", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleEmbeddingStatus(h, w, r) })
+	apiMux.HandleFunc("/api/ai/embeddings/backfill", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleEmbeddingBackfill(h, w, r) })
+	apiMux.HandleFunc("/api/ai/embeddings/rebuild", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleEmbeddingRebuild(h, w, r) })
+	apiMux.HandleFunc("/api/articles/related", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleRelatedArticles(h, w, r) })
+	apiMux.HandleFunc("/api/articles/semantic-search", func(w http.ResponseWriter, r *http.Request) { aihandlers.HandleSemanticSearch(h, w, r) })
 	apiMux.HandleFunc("/api/articles/toggle-hide", func(w http.ResponseWriter, r *http.Request) { article.HandleToggleHideArticle(h, w, r) })
 	apiMux.HandleFunc("/api/articles/toggle-read-later", func(w http.ResponseWriter, r *http.Request) { article.HandleToggleReadLater(h, w, r) })
 	apiMux.HandleFunc("/api/articles/content", func(w http.ResponseWriter, r *http.Request) { article.HandleGetArticleContent(h, w, r) })
@@ -180,12 +226,29 @@ func main() {
 	apiMux.HandleFunc("/api/articles/mark-all-read", func(w http.ResponseWriter, r *http.Request) { article.HandleMarkAllAsRead(h, w, r) })
 	apiMux.HandleFunc("/api/articles/clear-read-later", func(w http.ResponseWriter, r *http.Request) { article.HandleClearReadLater(h, w, r) })
 	apiMux.HandleFunc("/api/articles/summarize", func(w http.ResponseWriter, r *http.Request) { summary.HandleSummarizeArticle(h, w, r) })
+	apiMux.HandleFunc("/api/articles/summarize/stream", func(w http.ResponseWriter, r *http.Request) { summary.HandleSummarizeArticleStream(h, w, r) })
+	apiMux.HandleFunc("/api/summary-queue", func(w http.ResponseWriter, r *http.Request) { summary.HandleSummaryQueue(h, w, r) })
+	apiMux.HandleFunc("/api/summary-queue/enqueue", func(w http.ResponseWriter, r *http.Request) { summary.HandleEnqueueSummaries(h, w, r) })
+	apiMux.HandleFunc("/api/summary-queue/cancel", func(w http.ResponseWriter, r *http.Request) { summary.HandleCancelSummaries(h, w, r) })
+	apiMux.HandleFunc("/api/digests", func(w http.ResponseWriter, r *http.Request) { digesthandlers.HandleDigests(h, w, r) })
+	apiMux.HandleFunc("/api/digests/get", func(w http.ResponseWriter, r *http.Request) { digesthandlers.HandleGetDigest(h, w, r) })
+	apiMux.HandleFunc("/api/digests/generate", func(w http.ResponseWriter, r *http.Request) { digesthandlers.HandleGenerateDigest(h, w, r) })
+	apiMux.HandleFunc("/api/digests/delete", func(w http.ResponseWriter, r *http.Request) { digesthandlers.HandleDeleteDigest(h, w, r) })
+	apiMux.HandleFunc("/api/digests/export", func(w http.ResponseWriter, r *http.Request) { digesthandlers.HandleExportDigest(h, w, r) })
 	apiMux.HandleFunc("/api/articles/export/obsidian", func(w http.ResponseWriter, r *http.Request) { article.HandleExportToObsidian(h, w, r) })
+	apiMux.HandleFunc("/api/articles/export/markdown", func(w http.ResponseWriter, r *http.Request) { article.HandleExportMarkdown(h, w, r) })
 	apiMux.HandleFunc("/api/settings", func(w http.ResponseWriter, r *http.Request) { settings.HandleSettings(h, w, r) })
 	apiMux.HandleFunc("/api/refresh", func(w http.ResponseWriter, r *http.Request) { article.HandleRefresh(h, w, r) })
 	apiMux.HandleFunc("/api/progress", func(w http.ResponseWriter, r *http.Request) { article.HandleProgress(h, w, r) })
 	apiMux.HandleFunc("/api/opml/import", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLImport(h, w, r) })
+	apiMux.HandleFunc("/api/opml/import/preview", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLImportPreview(h, w, r) })
+	apiMux.HandleFunc("/api/opml/import/apply", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLImportApply(h, w, r) })
 	apiMux.HandleFunc("/api/opml/export", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLExport(h, w, r) })
+	apiMux.HandleFunc("/api/opml/lists", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLLists(h, w, r) })
+	apiMux.HandleFunc("/api/opml/lists/add", func(w http.ResponseWriter, r *http.Request) { opml.HandleAddOPMLList(h, w, r) })
+	apiMux.HandleFunc("/api/opml/lists/update", func(w http.ResponseWriter, r *http.Request) { opml.HandleUpdateOPMLList(h, w, r) })
+	apiMux.HandleFunc("/api/opml/lists/delete", func(w http.ResponseWriter, r *http.Request) { opml.HandleDeleteOPMLList(h, w, r) })
+	apiMux.HandleFunc("/api/opml/lists/refresh", func(w http.ResponseWriter, r *http.Request) { opml.HandleRefreshOPMLList(h, w, r) })
 	apiMux.HandleFunc("/api/opml/import-dialog", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLImportDialog(h, w, r) })
 	apiMux.HandleFunc("/api/opml/export-dialog", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLExportDialog(h, w, r) })
 	apiMux.HandleFunc("/api/check-updates", func(w http.ResponseWriter, r *http.Request) { update.HandleCheckUpdates(h, w, r) })
@@ -210,6 +273,17 @@ func main() {
 	apiMux.HandleFunc("/api/custom-css/delete", func(w http.ResponseWriter, r *http.Request) { customcss.HandleDeleteCSS(h, w, r) })
 	apiMux.HandleFunc("/api/freshrss/sync", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSync(h, w, r) })
 	apiMux.HandleFunc("/api/freshrss/test-connection", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleTestConnection(h, w, r) })
+	apiMux.HandleFunc("/api/sync/providers", func(w http.ResponseWriter, r *http.Request) { readerSyncHandler.HandleProviders(h, w, r) })
+	apiMux.HandleFunc("/api/sync/status", func(w http.ResponseWriter, r *http.Request) { readerSyncHandler.HandleStatus(h, w, r) })
+	apiMux.HandleFunc("/api/sync/run", func(w http.ResponseWriter, r *http.Request) { readerSyncHandler.HandleRun(h, w, r) })
+	apiMux.HandleFunc("/api/sync/push-local", func(w http.ResponseWriter, r *http.Request) { readerSyncHandler.HandlePushLocal(h, w, r) })
+	apiMux.HandleFunc("/api/sync/test-connection", func(w http.ResponseWriter, r *http.Request) { readerSyncHandler.HandleTestConnection(h, w, r) })
+	apiMux.HandleFunc("/api/backup/export", func(w http.ResponseWriter, r *http.Request) { backupHandler.HandleExport(h, w, r) })
+	apiMux.HandleFunc("/api/backup/restore", func(w http.ResponseWriter, r *http.Request) { backupHandler.HandleRestore(h, w, r) })
+	apiMux.HandleFunc("/api/readlater/import", func(w http.ResponseWriter, r *http.Request) { readLaterHandler.HandleImport(h, w, r) })
+	apiMux.HandleFunc("/api/db-backups", func(w http.ResponseWriter, r *http.Request) { dbBackupHandler.HandleList(h, w, r) })
+	apiMux.HandleFunc("/api/db-backups/create", func(w http.ResponseWriter, r *http.Request) { dbBackupHandler.HandleCreate(h, w, r) })
+	apiMux.HandleFunc("/api/db-backups/restore", func(w http.ResponseWriter, r *http.Request) { dbBackupHandler.HandleRestore(h, w, r) })
 
 	// Static Files
 	log.Println("Setting up static files...")